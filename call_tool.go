@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/toolset"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// runCall implements the call subcommand, which runs one tool without an MCP
+// client and prints its text result to stdout:
+//
+//	last9-mcp call get_service_summary --args '{"lookback_minutes": 30}'
+//	echo '{"query": "up"}' | last9-mcp call prometheus_instant_query --args -
+//
+// The remaining flags, env vars and .env file configure the server as usual.
+// A tool error is returned as an error, so the process exits non-zero.
+func runCall(args []string, stdin io.Reader, stdout io.Writer) error {
+	name, rawArgs, rest, err := splitCallArgs(args)
+	if err != nil {
+		return err
+	}
+	if rawArgs == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read arguments from stdin: %w", err)
+		}
+		rawArgs = string(data)
+	}
+
+	server, closeServer, err := newLocalServer(rest)
+	if err != nil {
+		return err
+	}
+	defer closeServer()
+	return callTool(context.Background(), server, name, rawArgs, stdout)
+}
+
+// newLocalServer builds an MCP server with the Last9 tools for the
+// subcommands that call tools in-process. args are configuration flags; env
+// vars and .env apply as usual. The returned func releases the tools.
+func newLocalServer(args []string) (*mcp.Server, func(), error) {
+	_ = godotenv.Load()
+	cfg, err := SetupConfig(models.Config{}, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config error: %w", err)
+	}
+	closeBackend, err := connect(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	ts := toolset.New(cfg)
+	release := func() {
+		ts.Close()
+		closeBackend()
+	}
+	server, err := last9mcp.NewServerWithOptions("last9-mcp", Version, last9mcp.WithSkipProviderInit())
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to create MCP server: %w", err)
+	}
+	if err := ts.Register(server); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to register tools: %w", err)
+	}
+	return server.Server, release, nil
+}
+
+// splitCallArgs separates the tool name and --args value from the
+// configuration flags that follow them.
+func splitCallArgs(args []string) (name, rawArgs string, rest []string, err error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", "", nil, fmt.Errorf("usage: last9-mcp call <tool> [--args '<json>'] [flags]")
+	}
+	name, rawArgs = args[0], "{}"
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--args" || arg == "-args":
+			if i+1 >= len(args) {
+				return "", "", nil, fmt.Errorf("--args needs a JSON object, or - to read it from stdin")
+			}
+			i++
+			rawArgs = args[i]
+		case strings.HasPrefix(arg, "--args=") || strings.HasPrefix(arg, "-args="):
+			_, rawArgs, _ = strings.Cut(arg, "=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return name, rawArgs, rest, nil
+}
+
+// callTool calls the named tool on server over in-memory transports, so the
+// call takes the same path as one from an MCP client, and writes the text of
+// the result to w.
+func callTool(ctx context.Context, server *mcp.Server, name, rawArgs string, w io.Writer) error {
+	var arguments map[string]any
+	if err := json.Unmarshal([]byte(rawArgs), &arguments); err != nil {
+		return fmt.Errorf("--args must be a JSON object: %w", err)
+	}
+	session, closeSession, err := connectInMemory(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: arguments})
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", name, err)
+	}
+	if result.IsError {
+		return fmt.Errorf("%s returned an error: %s", name, resultText(result))
+	}
+	fmt.Fprintln(w, resultText(result))
+	return nil
+}
+
+// resultText joins the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSplitCallArgs(t *testing.T) {
+	name, rawArgs, rest, err := splitCallArgs([]string{"get_alerts", "--args", `{"severity":"breach"}`, "--datasource", "prod"})
+	if err != nil || name != "get_alerts" || rawArgs != `{"severity":"breach"}` || !reflect.DeepEqual(rest, []string{"--datasource", "prod"}) {
+		t.Errorf("splitCallArgs = %q, %q, %q, %v", name, rawArgs, rest, err)
+	}
+	if _, rawArgs, _, _ := splitCallArgs([]string{"get_alerts", "-args=-"}); rawArgs != "-" {
+		t.Errorf("-args= form: rawArgs = %q", rawArgs)
+	}
+	if _, rawArgs, _, _ := splitCallArgs([]string{"get_alerts"}); rawArgs != "{}" {
+		t.Errorf("default rawArgs = %q, want {}", rawArgs)
+	}
+	for _, args := range [][]string{nil, {"--args", "{}"}, {"get_alerts", "--args"}} {
+		if _, _, _, err := splitCallArgs(args); err == nil {
+			t.Errorf("splitCallArgs(%q): want error", args)
+		}
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	mcp.AddTool(srv, &mcp.Tool{Name: "echo"}, func(ctx context.Context, req *mcp.CallToolRequest, args echoArgs) (*mcp.CallToolResult, any, error) {
+		if args.Fail {
+			return nil, nil, errors.New("upstream unavailable")
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "hello " + args.Service}}}, nil, nil
+	})
+
+	var out bytes.Buffer
+	if err := callTool(context.Background(), srv, "echo", `{"service":"checkout"}`, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hello checkout\n" {
+		t.Errorf("output = %q", out.String())
+	}
+
+	if err := callTool(context.Background(), srv, "echo", `{"service":"checkout","fail":true}`, &out); err == nil || !strings.Contains(err.Error(), "upstream unavailable") {
+		t.Errorf("tool error = %v", err)
+	}
+	if err := callTool(context.Background(), srv, "echo", `not json`, &out); err == nil || !strings.Contains(err.Error(), "JSON object") {
+		t.Errorf("bad args error = %v", err)
+	}
+	if err := callTool(context.Background(), srv, "missing", `{}`, &out); err == nil {
+		t.Error("unknown tool: want error")
+	}
+}
@@ -9,9 +9,9 @@ import (
 	"sort"
 	"time"
 
-	"last9-mcp/internal/attributes"
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/toolset"
 
 	last9mcp "github.com/last9/mcp-go-sdk/mcp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -44,8 +44,9 @@ func dumpTools(w io.Writer) error {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
 
-	attrCache := attributes.NewAttributeCache(auth.GetHTTPClient(), cfg)
-	if err := registerAllTools(server, cfg, attrCache); err != nil {
+	ts := toolset.New(cfg)
+	defer ts.Close()
+	if err := ts.Register(server); err != nil {
 		return fmt.Errorf("failed to register tools: %w", err)
 	}
 	fmt.Fprintln(os.Stderr, "note: label cache is cold; {{labels}} placeholders substitute to empty (deterministic default snapshot)")
@@ -8,7 +8,7 @@ import (
 	"strings"
 	"testing"
 
-	"last9-mcp/internal/apm"
+	"github.com/last9/last9-mcp-server/internal/apm"
 )
 
 func TestDumpTools(t *testing.T) {
@@ -95,8 +95,8 @@ func TestDumpTools(t *testing.T) {
 		t.Fatal("served schema must not have a top-level required list")
 	}
 	properties := served["properties"].(map[string]interface{})
-	if len(properties) != 10 {
-		t.Fatalf("served schema has %d properties, want 10", len(properties))
+	if len(properties) != 12 {
+		t.Fatalf("served schema has %d properties, want 12", len(properties))
 	}
 	for name, value := range properties {
 		property := value.(map[string]interface{})
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/last9/last9-mcp-server/internal/apm"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// exploreTopN is how many services the explorer lists.
+const exploreTopN = 50
+
+// runExplore implements the explore subcommand: an interactive terminal
+// frontend that lists services and drills into a service's operations and
+// dependencies. It calls the same tools an agent would, in-process.
+func runExplore(args []string, stdin io.Reader, stdout io.Writer) error {
+	server, closeServer, err := newLocalServer(args)
+	if err != nil {
+		return err
+	}
+	defer closeServer()
+
+	ctx := context.Background()
+	session, closeSession, err := connectInMemory(ctx, server)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	return newExplorer(session, stdin, stdout).run(ctx)
+}
+
+// explorer holds the state of an explore session: the time window and env
+// filter applied to every call, and the services on the last listing.
+type explorer struct {
+	session  *mcp.ClientSession
+	in       *bufio.Scanner
+	out      io.Writer
+	lookback float64
+	env      string
+	services []apm.RankedService
+}
+
+func newExplorer(session *mcp.ClientSession, in io.Reader, out io.Writer) *explorer {
+	return &explorer{session: session, in: bufio.NewScanner(in), out: out, lookback: 60}
+}
+
+// run shows the service list and reads commands until quit or end of input.
+func (e *explorer) run(ctx context.Context) error {
+	if err := e.listServices(ctx); err != nil {
+		return err
+	}
+	for {
+		cmd, arg, ok := e.prompt("services [number, l <minutes>, e <env>, r, q]")
+		if !ok || cmd == "q" {
+			return nil
+		}
+		switch cmd {
+		case "":
+			continue
+		case "r":
+		case "l":
+			minutes, err := strconv.ParseFloat(arg, 64)
+			if err != nil || minutes < 1 {
+				fmt.Fprintln(e.out, "l needs a number of minutes, at least 1")
+				continue
+			}
+			e.lookback = minutes
+		case "e":
+			e.env = arg
+		default:
+			n, err := strconv.Atoi(cmd)
+			if err != nil || n < 1 || n > len(e.services) {
+				fmt.Fprintf(e.out, "unknown command %q\n", cmd)
+				continue
+			}
+			if quit := e.exploreService(ctx, e.services[n-1]); quit {
+				return nil
+			}
+		}
+		if err := e.listServices(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// exploreService shows one service and its drill-downs. It reports whether
+// the operator asked to quit.
+func (e *explorer) exploreService(ctx context.Context, svc apm.RankedService) bool {
+	e.printServices([]apm.RankedService{svc})
+	for {
+		cmd, _, ok := e.prompt(svc.ServiceName + " [o operations, d dependencies, b back, q]")
+		if !ok || cmd == "q" {
+			return true
+		}
+		args := e.windowArgs()
+		args["service_name"] = svc.ServiceName
+		switch cmd {
+		case "":
+		case "b":
+			return false
+		case "o":
+			e.show(ctx, "get_service_operations_summary", args)
+		case "d":
+			e.show(ctx, "get_service_dependency_graph", args)
+		default:
+			fmt.Fprintf(e.out, "unknown command %q\n", cmd)
+		}
+	}
+}
+
+// listServices fetches the services ranked by error percentage and prints
+// them numbered. Tool errors are printed and leave the list empty.
+func (e *explorer) listServices(ctx context.Context) error {
+	args := e.windowArgs()
+	args["sort_by"] = "error_percent"
+	args["top_n"] = exploreTopN
+	text, err := e.call(ctx, "get_service_summary", args)
+	if err != nil {
+		fmt.Fprintln(e.out, err)
+		e.services = nil
+		return nil
+	}
+	var overview apm.FleetOverview
+	if err := json.Unmarshal([]byte(text), &overview); err != nil {
+		return fmt.Errorf("failed to parse get_service_summary result: %w", err)
+	}
+	e.services = overview.Services
+	env := e.env
+	if env == "" {
+		env = "default"
+	}
+	fmt.Fprintf(e.out, "\n%d of %d services, last %g minutes, env %s\n", len(e.services), overview.Total, e.lookback, env)
+	e.printServices(e.services)
+	return nil
+}
+
+func (e *explorer) printServices(services []apm.RankedService) {
+	tw := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tSERVICE\tENV\tREQ/MIN\tERR/MIN\tERR%\tRESPONSE TIME")
+	for _, s := range services {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.1f\t%.2f\t%.2f\t%.1fms\n", s.Rank, s.ServiceName, s.Env, s.Throughput, s.ErrorRate, s.ErrorPercent, s.ResponseTime)
+	}
+	tw.Flush()
+}
+
+// show calls a tool and prints its result as indented JSON.
+func (e *explorer) show(ctx context.Context, tool string, args map[string]any) {
+	text, err := e.call(ctx, tool, args)
+	if err != nil {
+		fmt.Fprintln(e.out, err)
+		return
+	}
+	var v any
+	if json.Unmarshal([]byte(text), &v) == nil {
+		if indented, err := json.MarshalIndent(v, "", "  "); err == nil {
+			text = string(indented)
+		}
+	}
+	fmt.Fprintln(e.out, text)
+}
+
+// call runs a tool and returns the text of its result.
+func (e *explorer) call(ctx context.Context, tool string, args map[string]any) (string, error) {
+	result, err := e.session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+	if err != nil {
+		return "", fmt.Errorf("failed to call %s: %w", tool, err)
+	}
+	if result.IsError {
+		return "", fmt.Errorf("%s: %s", tool, resultText(result))
+	}
+	return resultText(result), nil
+}
+
+func (e *explorer) windowArgs() map[string]any {
+	args := map[string]any{"lookback_minutes": e.lookback}
+	if e.env != "" {
+		args["env"] = e.env
+	}
+	return args
+}
+
+// prompt prints label and reads a command and its optional argument. ok is
+// false at end of input.
+func (e *explorer) prompt(label string) (cmd, arg string, ok bool) {
+	fmt.Fprintf(e.out, "%s> ", label)
+	if !e.in.Scan() {
+		fmt.Fprintln(e.out)
+		return "", "", false
+	}
+	cmd, arg, _ = strings.Cut(strings.TrimSpace(e.in.Text()), " ")
+	return cmd, strings.TrimSpace(arg), true
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type exploreArgs struct {
+	ServiceName     string  `json:"service_name,omitempty"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty"`
+	Env             string  `json:"env,omitempty"`
+	SortBy          string  `json:"sort_by,omitempty"`
+	TopN            int     `json:"top_n,omitempty"`
+}
+
+func TestExplorer(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	var summaryCalls []exploreArgs
+	mcp.AddTool(srv, &mcp.Tool{Name: "get_service_summary"}, func(ctx context.Context, req *mcp.CallToolRequest, args exploreArgs) (*mcp.CallToolResult, any, error) {
+		summaryCalls = append(summaryCalls, args)
+		text := `{"sort_by":"error_percent","total":2,"services":[` +
+			`{"Rank":1,"ServiceName":"checkout","Env":"prod","Throughput":120,"ErrorRate":6,"ResponseTime":250,"ErrorPercent":5},` +
+			`{"Rank":2,"ServiceName":"cart","Env":"prod","Throughput":80,"ErrorRate":0,"ResponseTime":40,"ErrorPercent":0}]}`
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil, nil
+	})
+	mcp.AddTool(srv, &mcp.Tool{Name: "get_service_operations_summary"}, func(ctx context.Context, req *mcp.CallToolRequest, args exploreArgs) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: `{"operations":["GET /cart/` + args.ServiceName + `"]}`}}}, nil, nil
+	})
+
+	session, closeSession, err := connectInMemory(context.Background(), srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeSession()
+
+	var out strings.Builder
+	in := strings.NewReader("l 15\ne prod\n2\no\nd\nb\n9\nq\n")
+	if err := newExplorer(session, in, &out).run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"2 of 2 services, last 60 minutes, env default",
+		"2 of 2 services, last 15 minutes, env prod",
+		"checkout  prod",
+		`"GET /cart/cart"`, // operations for the second service, indented
+		"get_service_dependency_graph",
+		`unknown command "9"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	last := summaryCalls[len(summaryCalls)-1]
+	if last.LookbackMinutes != 15 || last.Env != "prod" || last.SortBy != "error_percent" || last.TopN != exploreTopN {
+		t.Errorf("last get_service_summary args = %+v", last)
+	}
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/toolset"
+	"github.com/last9/last9-mcp-server/internal/wstransport"
 
 	last9mcp "github.com/last9/mcp-go-sdk/mcp"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -25,6 +35,9 @@ type HTTPServer struct {
 	toolsMap map[string]interface{}
 	sessions map[string]*MCPSession
 	mu       sync.RWMutex
+	// readiness is the startup preflight result served at /ready; nil
+	// until the preflight has run.
+	readiness *toolset.Readiness
 }
 
 // MCPSession represents an MCP session state
@@ -55,11 +68,52 @@ func newStatelessStreamableHandler(getServer func(*http.Request) *mcp.Server) ht
 	return mcp.NewStreamableHTTPHandler(getServer, &mcp.StreamableHTTPOptions{Stateless: true})
 }
 
+// newServerTLSConfig builds the TLS configuration for the HTTP server from
+// cfg, or returns nil when TLS is not configured. With a client CA file the
+// server requires client certificates that chain to it (mTLS).
+func newServerTLSConfig(cfg models.Config) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS client CA file %s contains no PEM certificates", cfg.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
 // Start starts the HTTP server with streamable HTTP support
 func (h *HTTPServer) Start() error {
 	// url is host:port
 	url := h.config.Host + ":" + h.config.Port
 
+	logger := logging.Logger("http")
+
+	tlsConfig, err := newServerTLSConfig(h.config)
+	if err != nil {
+		return err
+	}
+	httpScheme, wsScheme := "http", "ws"
+	if tlsConfig != nil {
+		httpScheme, wsScheme = "https", "wss"
+	}
+
 	// Create a mux to handle multiple endpoints
 	mux := http.NewServeMux()
 
@@ -72,17 +126,38 @@ func (h *HTTPServer) Start() error {
 	mux.Handle("/", httpHandler)    // Root endpoint for standard MCP clients
 	mux.Handle("/mcp", httpHandler) // /mcp endpoint for explicit MCP usage
 	mux.HandleFunc("/health", h.handleHealth)
+	mux.HandleFunc("/ready", h.handleReady)
+	mux.Handle("/api/", newRESTHandler(h.server.Server)) // REST facade over the same tools
+
+	handler := gzipMiddleware(limitRequestMiddleware(mux, h.config.MaxRequestBytes, h.config.MaxRequestJSONDepth))
+	if h.config.Transport == models.TransportWebSocket {
+		// The upgrade hijacks the connection, which the gzip writer cannot
+		// pass through, so /ws sits in front of gzip but still behind the
+		// request limits.
+		root := http.NewServeMux()
+		ws := wstransport.NewHandler(h.server.Server, h.config.MaxRequestBytes)
+		root.Handle("/ws", limitRequestMiddleware(ws, h.config.MaxRequestBytes, h.config.MaxRequestJSONDepth))
+		root.Handle("/", handler)
+		handler = root
+		logger.Info("MCP WebSocket endpoint", "url", wsScheme+"://"+url+"/ws")
+	}
 
 	// Create HTTP server with timeouts
 	httpServer := &http.Server{
-		Addr:         url,
-		Handler:      mux,
-		ReadTimeout:  constants.DefaultHTTPTimeout,
-		WriteTimeout: constants.DefaultHTTPTimeout,
-		IdleTimeout:  60 * time.Second,
+		Addr:              url,
+		Handler:           handler,
+		ReadHeaderTimeout: constants.HTTPServerReadHeaderTimeout,
+		ReadTimeout:       constants.HTTPServerReadTimeout,
+		WriteTimeout:      constants.DefaultHTTPTimeout,
+		IdleTimeout:       constants.HTTPServerIdleTimeout,
+		MaxHeaderBytes:    constants.HTTPServerMaxHeaderBytes,
+		TLSConfig:         tlsConfig,
 	}
 
-	log.Printf("🚀 MCP server listening on %s", url)
+	logger.Info("MCP server listening", "address", url,
+		"tls", tlsConfig != nil,
+		"client_certificates_required", tlsConfig != nil && tlsConfig.ClientCAs != nil)
+	logger.Info("REST tool API", "url", httpScheme+"://"+url+"/api/tools")
 
 	// add shutdown hook
 	signalChan := make(chan os.Signal, 1)
@@ -91,7 +166,14 @@ func (h *HTTPServer) Start() error {
 	// Start server in a goroutine
 	serverErr := make(chan error, 1)
 	go func() {
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			// The certificate is already in TLSConfig.
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
@@ -100,10 +182,10 @@ func (h *HTTPServer) Start() error {
 	select {
 	// add signal chan
 	case sig := <-signalChan:
-		log.Printf("🛑 Received signal: %v, initiating graceful shutdown...", sig)
+		logger.Info("received signal, initiating graceful shutdown", "signal", sig.String())
 
 	case err := <-serverErr:
-		log.Printf("❌ Server error: %v", err)
+		logger.Error("server error", "error", err)
 		return err
 	}
 
@@ -112,26 +194,203 @@ func (h *HTTPServer) Start() error {
 
 	// Attempt graceful shutdown
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("❌ Graceful shutdown failed: %v", err)
+		logger.Error("graceful shutdown failed", "error", err)
 		return err
 	}
-	log.Printf("✅ HTTP server shutdown complete")
+	logger.Info("HTTP server shutdown complete")
 
 	if err := h.server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("❌ MCP server shutdown error: %v", err)
+		logger.Error("MCP server shutdown error", "error", err)
 		return err
 	}
 
-	log.Printf("✅ MCP server shutdown complete")
+	logger.Info("MCP server shutdown complete")
 	return nil
 }
 
 func (h *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
+	health := map[string]any{
 		"status":  "healthy",
 		"server":  "last9-mcp",
 		"version": "1.0.0",
+	}
+	if h.config.TokenManager != nil {
+		health["token"] = h.config.TokenManager.Status()
+	}
+	json.NewEncoder(w).Encode(health)
+}
+
+// limitRequestMiddleware rejects request bodies over maxBytes with 413 and
+// JSON bodies nested deeper than maxDepth with 400, before the MCP or REST
+// handlers decode them. A limit of 0 or less disables it.
+func limitRequestMiddleware(next http.Handler, maxBytes, maxDepth int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := r.Body
+		if maxBytes > 0 {
+			if r.ContentLength > int64(maxBytes) {
+				writeRESTError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", maxBytes))
+				return
+			}
+			body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+		}
+		if maxDepth <= 0 {
+			r.Body = body
+			next.ServeHTTP(w, r)
+			return
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeRESTError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", maxBytes))
+				return
+			}
+			writeRESTError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+			return
+		}
+		if jsonDepthExceeds(data, maxDepth) {
+			writeRESTError(w, http.StatusBadRequest, fmt.Errorf("request JSON is nested deeper than %d levels", maxDepth))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		next.ServeHTTP(w, r)
 	})
 }
+
+// jsonDepthExceeds reports whether arrays and objects in data nest deeper
+// than maxDepth. It only tracks brackets outside strings, so it is cheap and
+// works on malformed input, which the handlers reject themselves.
+func jsonDepthExceeds(data []byte, maxDepth int) bool {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return false
+}
+
+// handleReady serves the startup preflight result: 200 when the server is
+// ready, 503 otherwise, for readiness probes.
+func (h *HTTPServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	readiness := toolset.Readiness{}
+	if h.readiness != nil {
+		readiness = *h.readiness
+	}
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeRESTJSON(w, status, readiness)
+}
+
+// gzipMiddleware compresses responses for clients that advertise
+// Accept-Encoding: gzip. Large tool results (range queries, log pages) are
+// mostly repetitive JSON and compress several-fold. SSE streams are passed
+// through untouched: buffering inside the gzip writer would delay events and
+// some proxies mishandle compressed event streams.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.Close()
+		w.Header().Add("Vary", "Accept-Encoding")
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(enc), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter decides whether to compress when the status line is
+// written, based on the response headers the wrapped handler has set.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	h := g.Header()
+	compressible := status != http.StatusNoContent &&
+		status != http.StatusNotModified &&
+		h.Get("Content-Encoding") == "" &&
+		!strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")
+	if compressible {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+func (g *gzipResponseWriter) Flush() {
+	if g.gz != nil {
+		_ = g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+func (g *gzipResponseWriter) Close() {
+	if g.gz != nil {
+		_ = g.gz.Close()
+	}
+}
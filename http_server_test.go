@@ -2,13 +2,33 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/toolset"
+	"github.com/last9/last9-mcp-server/internal/wstransport"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/net/websocket"
 )
 
 // TestStatelessStreamableHandler verifies the HTTP handler runs in stateless
@@ -66,3 +86,289 @@ func TestStatelessStreamableHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestGzipMiddleware(t *testing.T) {
+	payload := strings.Repeat(`{"metric":{"service_name":"svc"},"value":[1700000000,"1"]},`, 200)
+	handler := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sse" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		io.WriteString(w, payload)
+	}))
+
+	t.Run("compresses when client accepts gzip", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		if rec.Body.Len() >= len(payload) {
+			t.Fatalf("compressed body (%d bytes) not smaller than payload (%d bytes)", rec.Body.Len(), len(payload))
+		}
+		gz, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+		body, _ := io.ReadAll(gz)
+		if string(body) != payload {
+			t.Fatal("decompressed body does not match payload")
+		}
+	})
+
+	t.Run("passes through without Accept-Encoding", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Fatalf("Content-Encoding = %q, want none", got)
+		}
+		if rec.Body.String() != payload {
+			t.Fatal("body should be unmodified")
+		}
+	})
+
+	t.Run("does not compress event streams", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Fatalf("Content-Encoding = %q, want none for text/event-stream", got)
+		}
+	})
+
+	t.Run("honours q=0", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("Accept-Encoding", "gzip;q=0, identity")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Fatalf("Content-Encoding = %q, want none", got)
+		}
+	})
+}
+
+func TestHandleHealthReportsTokenExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	cfg := models.Config{TokenManager: &auth.TokenManager{AccessToken: "token", ExpiresAt: expiresAt}}
+	h := NewHTTPServer(nil, cfg)
+
+	rec := httptest.NewRecorder()
+	h.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var body struct {
+		Status string           `json:"status"`
+		Token  auth.TokenStatus `json:"token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode health response: %v", err)
+	}
+	if body.Status != "healthy" {
+		t.Errorf("status = %q, want healthy", body.Status)
+	}
+	if !body.Token.ExpiresAt.Equal(expiresAt) || body.Token.ExpiresInSeconds <= 0 {
+		t.Errorf("unexpected token status: %+v", body.Token)
+	}
+}
+
+func TestHandleReady(t *testing.T) {
+	h := NewHTTPServer(nil, models.Config{})
+	rec := httptest.NewRecorder()
+	h.handleReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status before preflight = %d, want 503", rec.Code)
+	}
+
+	h.readiness = &toolset.Readiness{Ready: true, Checks: []toolset.PreflightCheck{{Name: "token", OK: true}}}
+	rec = httptest.NewRecorder()
+	h.handleReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	var body toolset.Readiness
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode ready response: %v", err)
+	}
+	if rec.Code != http.StatusOK || !body.Ready || len(body.Checks) != 1 {
+		t.Errorf("ready = %d %+v, want 200 with one check", rec.Code, body)
+	}
+}
+
+// testCert issues a certificate for 127.0.0.1 signed by parent (self-signed
+// when parent is nil) and writes it and its key as PEM files under dir.
+func testCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, certFile, keyFile
+}
+
+func TestNewServerTLSConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, caFile, _ := testCert(t, dir, "ca", nil, nil)
+	_, _, serverCert, serverKey := testCert(t, dir, "server", ca, caKey)
+	_, _, clientCert, clientKey := testCert(t, dir, "client", ca, caKey)
+
+	tlsConfig, err := newServerTLSConfig(models.Config{TLSCertFile: serverCert, TLSKeyFile: serverKey, TLSClientCAFile: caFile})
+	if err != nil {
+		t.Fatalf("newServerTLSConfig: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = tlsConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	withoutCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if resp, err := withoutCert.Get(ts.URL); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected a client without a certificate to be rejected")
+	}
+
+	pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}}}}
+	resp, err := withCert.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request with a client certificate failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "client" {
+		t.Errorf("peer = %q, want client", body)
+	}
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	if tlsConfig, err := newServerTLSConfig(models.Config{}); tlsConfig != nil || err != nil {
+		t.Errorf("without a certificate: %v, %v; want no TLS", tlsConfig, err)
+	}
+	dir := t.TempDir()
+	_, _, certFile, keyFile := testCert(t, dir, "server", nil, nil)
+	tlsConfig, err := newServerTLSConfig(models.Config{TLSCertFile: certFile, TLSKeyFile: keyFile})
+	if err != nil || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Errorf("TLS without a client CA: %v, %v; want no client certificates required", tlsConfig, err)
+	}
+	if _, err := newServerTLSConfig(models.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: keyFile}); err == nil {
+		t.Error("expected an error for a client CA file without certificates")
+	}
+}
+
+func TestLimitRequestMiddleware(t *testing.T) {
+	ts := httptest.NewServer(limitRequestMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("handler read: %v", err)
+		}
+		_, _ = w.Write(body)
+	}), 64, 3))
+	defer ts.Close()
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"within limits", `{"a":[{"b":1}]}`, http.StatusOK},
+		{"brackets inside strings", `{"q":"[[[[{{{{\"]]]]"}`, http.StatusOK},
+		{"too deep", `{"a":[{"b":[1]}]}`, http.StatusBadRequest},
+		{"too large", `{"q":"` + strings.Repeat("x", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL, "application/json", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			got, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status = %d (%s), want %d", resp.StatusCode, got, tc.want)
+			}
+			if tc.want == http.StatusOK && string(got) != tc.body {
+				t.Errorf("handler saw %q, want the full body", got)
+			}
+		})
+	}
+
+	// Without a Content-Length the size limit applies while reading.
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, io.MultiReader(strings.NewReader(strings.Repeat(" ", 100))))
+	req.ContentLength = -1
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked oversized body: status = %d, want 413", resp.StatusCode)
+	}
+}
+
+// The WebSocket upgrade hijacks the connection, which the request limits
+// must pass through.
+func TestLimitRequestMiddlewareWebSocket(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	ts := httptest.NewServer(limitRequestMiddleware(wstransport.NewHandler(server, 4096), 64, 3))
+	defer ts.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), "", ts.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+	if err := websocket.Message.Send(ws, `{"jsonrpc":"2.0","id":1,"method":"ping"}`); err != nil {
+		t.Fatal(err)
+	}
+	var reply string
+	if err := websocket.Message.Receive(ws, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(reply, `"id":1`) {
+		t.Errorf("ping reply = %s", reply)
+	}
+}
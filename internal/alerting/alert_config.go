@@ -11,8 +11,9 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 )
 
 const (
@@ -61,19 +62,6 @@ type filterAlertGroupEntitiesRequest struct {
 	Orders  []any                    `json:"orders"`
 }
 
-func validateGetAlertConfigArgs(args GetAlertConfigArgs) error {
-	ruleType := strings.ToLower(strings.TrimSpace(args.RuleType))
-	if ruleType == "" {
-		return nil
-	}
-
-	if ruleType != alertConfigRuleTypeStatic && ruleType != alertConfigRuleTypeAnomaly {
-		return fmt.Errorf("rule_type must be one of %q or %q", alertConfigRuleTypeStatic, alertConfigRuleTypeAnomaly)
-	}
-
-	return nil
-}
-
 type kpiDefinition struct {
 	Query string `json:"query"`
 	Unit  string `json:"unit"`
@@ -87,7 +75,7 @@ type kpiResponse struct {
 
 func fetchKPI(
 	ctx context.Context,
-	client *http.Client,
+	client utils.HTTPClient,
 	cfg models.Config,
 	entityID, kpiID string,
 ) (kpiResponse, error) {
@@ -126,7 +114,7 @@ func fetchKPI(
 
 func resolveAlertConfigKPIs(
 	ctx context.Context,
-	client *http.Client,
+	client utils.HTTPClient,
 	cfg models.Config,
 	alertConfig AlertConfigResponse,
 ) {
@@ -168,7 +156,7 @@ func resolveAlertConfigKPIs(
 
 func fetchAlertConfig(
 	ctx context.Context,
-	client *http.Client,
+	client utils.HTTPClient,
 	cfg models.Config,
 ) (AlertConfigResponse, error) {
 	baseURL := fmt.Sprintf("%s%s", cfg.APIBaseURL, constants.EndpointAlertRules)
@@ -206,7 +194,7 @@ func fetchAlertConfig(
 
 func fetchAlertGroupEntities(
 	ctx context.Context,
-	client *http.Client,
+	client utils.HTTPClient,
 	cfg models.Config,
 	args GetAlertConfigArgs,
 ) (map[string]alertGroupEntity, error) {
@@ -10,10 +10,10 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -281,23 +281,6 @@ func TestGetAlertConfigHandler_EntityLookupFailure(t *testing.T) {
 	}
 }
 
-func TestGetAlertConfigHandler_InvalidRuleType(t *testing.T) {
-	state := alertConfigTestServerState{
-		alertRules:         sampleAlertConfigRules(),
-		entityGroups:       sampleAlertGroupEntities(),
-		alertRulesStatus:   http.StatusOK,
-		entityLookupStatus: http.StatusOK,
-	}
-
-	_, _, err := executeGetAlertConfig(t, &state, GetAlertConfigArgs{RuleType: "unknown"})
-	if err == nil {
-		t.Fatal("expected invalid rule type to return an error")
-	}
-	if !strings.Contains(err.Error(), "rule_type must be one of") {
-		t.Fatalf("unexpected error: %v", err)
-	}
-}
-
 func executeGetAlertConfig(
 	t *testing.T,
 	state *alertConfigTestServerState,
@@ -0,0 +1,361 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	flappingDefaultLookbackMinutes = 24 * 60
+	flappingMaxLookbackMinutes     = 7 * 24 * 60
+	// flappingMaxSamples caps /alerts/monitor calls per analysis.
+	flappingMaxSamples          = 288
+	flappingDefaultSamples      = 144
+	flappingMaxConcurrency      = 8
+	flappingDefaultShortMinutes = 15
+	flappingDefaultMinEpisodes  = 3
+)
+
+// AnalyzeAlertFlappingArgs represents the input arguments for the analyze_alert_flapping tool
+type AnalyzeAlertFlappingArgs struct {
+	StartTimeISO        string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2026-02-09T15:04:05Z)"`
+	EndTimeISO          string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (default: now)"`
+	LookbackMinutes     float64 `json:"lookback_minutes,omitempty" jsonschema:"Window in minutes ending now (default: 1440, max: 10080)"`
+	StepMinutes         int     `json:"step_minutes,omitempty" jsonschema:"Sampling resolution in minutes (default: window / 144, minimum 1). Episodes shorter than one step can be missed."`
+	RuleName            string  `json:"rule_name,omitempty" jsonschema:"Case-insensitive regex filter on rule name"`
+	ShortEpisodeMinutes int     `json:"short_episode_minutes,omitempty" jsonschema:"Firing episodes or gaps between them at or below this length count as flaps (default: 15)"`
+	MinEpisodes         int     `json:"min_episodes,omitempty" jsonschema:"Minimum firing episodes in the window before a rule can be flagged (default: 3)"`
+	Limit               int     `json:"limit,omitempty" jsonschema:"Maximum rules returned, noisiest first (default: 20, max: 200)"`
+}
+
+// RuleFlapping is the firing history summary of one alert rule.
+type RuleFlapping struct {
+	RuleID              string   `json:"rule_id"`
+	RuleName            string   `json:"rule_name"`
+	AlertGroupName      string   `json:"alert_group_name,omitempty"`
+	Severity            string   `json:"severity,omitempty"`
+	Episodes            int      `json:"episodes"`
+	EpisodesPerDay      float64  `json:"episodes_per_day"`
+	ShortEpisodes       int      `json:"short_episodes"`
+	ShortGaps           int      `json:"short_gaps"`
+	FiringPercent       float64  `json:"firing_percent"`
+	AvgFiringMinutes    float64  `json:"avg_firing_minutes"`
+	MedianFiringMinutes float64  `json:"median_firing_minutes"`
+	MedianGapMinutes    *float64 `json:"median_gap_minutes,omitempty"`
+	FiringAtEnd         bool     `json:"firing_at_end"`
+	Flapping            bool     `json:"flapping"`
+	SuggestedForMinutes *int     `json:"suggested_for_minutes,omitempty"`
+	SuggestedKeepFiring *int     `json:"suggested_keep_firing_minutes,omitempty"`
+	Suggestions         []string `json:"suggestions,omitempty"`
+}
+
+// AlertFlappingReport is the analyze_alert_flapping response.
+type AlertFlappingReport struct {
+	StartTime     string         `json:"start_time"`
+	EndTime       string         `json:"end_time"`
+	StepMinutes   int            `json:"step_minutes"`
+	Samples       int            `json:"samples"`
+	RulesFired    int            `json:"rules_fired"`
+	FlappingRules int            `json:"flapping_rules"`
+	Rules         []RuleFlapping `json:"rules"`
+	OmittedRules  int            `json:"omitted_rules,omitempty"`
+	FailedSamples int            `json:"failed_samples,omitempty"`
+	Note          string         `json:"note"`
+}
+
+// ruleHistory is the sampled firing state of one rule.
+type ruleHistory struct {
+	rule   AlertRuleData
+	firing []bool
+}
+
+// NewAnalyzeAlertFlappingHandler samples alert rule state across a window and
+// flags rules that fire and resolve repeatedly.
+func NewAnalyzeAlertFlappingHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, AnalyzeAlertFlappingArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args AnalyzeAlertFlappingArgs) (*mcp.CallToolResult, any, error) {
+		if args.LookbackMinutes < 0 || args.LookbackMinutes > flappingMaxLookbackMinutes {
+			return nil, nil, fmt.Errorf("lookback_minutes must be between 1 and %d", flappingMaxLookbackMinutes)
+		}
+		var ruleName *regexp.Regexp
+		if args.RuleName != "" {
+			re, err := regexp.Compile("(?i)" + args.RuleName)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid rule_name regex: %w", err)
+			}
+			ruleName = re
+		}
+		shortMinutes := args.ShortEpisodeMinutes
+		if shortMinutes == 0 {
+			shortMinutes = flappingDefaultShortMinutes
+		}
+		minEpisodes := args.MinEpisodes
+		if minEpisodes == 0 {
+			minEpisodes = flappingDefaultMinEpisodes
+		}
+		if shortMinutes < 0 || minEpisodes < 0 || args.StepMinutes < 0 {
+			return nil, nil, fmt.Errorf("step_minutes, short_episode_minutes and min_episodes must be positive")
+		}
+		limit := args.Limit
+		if limit == 0 {
+			limit = alertsDefaultLimit
+		}
+		if limit < 1 || limit > alertsMaxLimit {
+			return nil, nil, fmt.Errorf("limit must be between 1 and %d", alertsMaxLimit)
+		}
+
+		startTime, endTime, err := utils.TimeRange{
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: args.LookbackMinutes,
+		}.Resolve(flappingDefaultLookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		window := endTime.Sub(startTime)
+		if window > flappingMaxLookbackMinutes*time.Minute {
+			return nil, nil, fmt.Errorf("time range must not exceed %d minutes", flappingMaxLookbackMinutes)
+		}
+		step := time.Duration(args.StepMinutes) * time.Minute
+		if step == 0 {
+			step = max(window/flappingDefaultSamples, time.Minute).Truncate(time.Minute)
+		}
+		samples := int(window / step)
+		if samples < 2 {
+			return nil, nil, fmt.Errorf("time range must cover at least two steps")
+		}
+		if samples > flappingMaxSamples {
+			return nil, nil, fmt.Errorf("time range and step result in too many samples (%d). Maximum is %d; increase step_minutes", samples, flappingMaxSamples)
+		}
+
+		histories, failed, err := sampleAlertRuleStates(ctx, client, cfg, endTime.Unix(), int64(step.Seconds()), samples)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		stepMinutes := step.Minutes()
+		var rules []RuleFlapping
+		for _, h := range histories {
+			if ruleName != nil && !ruleName.MatchString(h.rule.RuleName) {
+				continue
+			}
+			r := analyzeRuleFlapping(h.firing, stepMinutes, float64(shortMinutes), minEpisodes)
+			if r.Episodes == 0 {
+				continue
+			}
+			r.RuleID = h.rule.RuleID
+			r.RuleName = h.rule.RuleName
+			r.AlertGroupName = h.rule.AlertGroupName
+			r.Severity = h.rule.Severity
+			rules = append(rules, r)
+		}
+		sort.SliceStable(rules, func(i, j int) bool {
+			if rules[i].Flapping != rules[j].Flapping {
+				return rules[i].Flapping
+			}
+			if rules[i].Episodes != rules[j].Episodes {
+				return rules[i].Episodes > rules[j].Episodes
+			}
+			return rules[i].RuleName < rules[j].RuleName
+		})
+
+		report := AlertFlappingReport{
+			StartTime:     endTime.Add(-time.Duration(samples) * step).Format(time.RFC3339),
+			EndTime:       endTime.Format(time.RFC3339),
+			StepMinutes:   int(stepMinutes),
+			Samples:       samples,
+			RulesFired:    len(rules),
+			Rules:         []RuleFlapping{},
+			FailedSamples: failed,
+			Note:          "State is sampled once per step from the alerts monitor; a step counts as firing if the rule fired within it. Samples where a rule is absent count as not firing.",
+		}
+		for _, r := range rules {
+			if r.Flapping {
+				report.FlappingRules++
+			}
+		}
+		if len(rules) > limit {
+			report.OmittedRules = len(rules) - limit
+			rules = rules[:limit]
+		}
+		report.Rules = append(report.Rules, rules...)
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// sampleAlertRuleStates queries the alerts monitor for samples consecutive
+// steps ending at end and returns each rule's firing state per step, oldest
+// first. Failed samples are counted and treated as not firing; the call
+// fails only if every sample fails.
+func sampleAlertRuleStates(ctx context.Context, client utils.HTTPClient, cfg models.Config, end, step int64, samples int) ([]ruleHistory, int, error) {
+	responses := make([]AlertsResponse, samples)
+	errs := make([]error, samples)
+	sem := make(chan struct{}, flappingMaxConcurrency)
+	var wg sync.WaitGroup
+	for i := range samples {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			ts := end - int64(samples-1-i)*step
+			responses[i], errs[i] = fetchAlertsMonitor(ctx, client, cfg, ts, step)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	failed := 0
+	byRule := map[string]*ruleHistory{}
+	var order []string
+	for i, resp := range responses {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		for _, rule := range resp.AlertRules {
+			h := byRule[rule.RuleID]
+			if h == nil {
+				h = &ruleHistory{rule: rule, firing: make([]bool, samples)}
+				byRule[rule.RuleID] = h
+				order = append(order, rule.RuleID)
+			}
+			h.firing[i] = h.firing[i] || rule.State == "firing"
+		}
+	}
+	if failed == samples {
+		return nil, failed, fmt.Errorf("all %d alert state samples failed: %w", samples, errs[0])
+	}
+	histories := make([]ruleHistory, 0, len(order))
+	for _, id := range order {
+		histories = append(histories, *byRule[id])
+	}
+	return histories, failed, nil
+}
+
+// analyzeRuleFlapping summarizes the firing episodes (runs of firing steps)
+// in a sampled history. A rule flaps when it has at least minEpisodes
+// episodes and most of them, or most gaps between them, are short.
+func analyzeRuleFlapping(firing []bool, stepMinutes, shortMinutes float64, minEpisodes int) RuleFlapping {
+	var episodes, gaps []float64
+	run, gap := 0, -1 // gap < 0 until the first episode ends
+	firingSteps := 0
+	var r RuleFlapping
+	for _, f := range firing {
+		if f {
+			if run == 0 && gap > 0 {
+				gaps = append(gaps, float64(gap)*stepMinutes)
+			}
+			run++
+			firingSteps++
+			continue
+		}
+		if run > 0 {
+			episodes = append(episodes, float64(run)*stepMinutes)
+			run, gap = 0, 0
+		}
+		if gap >= 0 {
+			gap++
+		}
+	}
+	if run > 0 {
+		// The last episode is still open, so its length is a lower bound.
+		episodes = append(episodes, float64(run)*stepMinutes)
+		r.FiringAtEnd = true
+	}
+
+	r.Episodes = len(episodes)
+	if r.Episodes == 0 {
+		return r
+	}
+	windowDays := float64(len(firing)) * stepMinutes / (24 * 60)
+	r.EpisodesPerDay = roundTo(float64(r.Episodes)/windowDays, 1)
+	r.FiringPercent = roundTo(float64(firingSteps)/float64(len(firing))*100, 1)
+
+	var total float64
+	for _, e := range episodes {
+		total += e
+		if e <= shortMinutes {
+			r.ShortEpisodes++
+		}
+	}
+	for _, g := range gaps {
+		if g <= shortMinutes {
+			r.ShortGaps++
+		}
+	}
+	r.AvgFiringMinutes = roundTo(total/float64(r.Episodes), 1)
+	r.MedianFiringMinutes = percentile(episodes, 0.5)
+	if len(gaps) > 0 {
+		median := percentile(gaps, 0.5)
+		r.MedianGapMinutes = &median
+	}
+
+	shortEpisodes := r.ShortEpisodes*2 > r.Episodes
+	shortGaps := len(gaps) > 0 && r.ShortGaps*2 > len(gaps)
+	r.Flapping = r.Episodes >= minEpisodes && (shortEpisodes || shortGaps)
+	if !r.Flapping {
+		return r
+	}
+	if shortEpisodes {
+		// Waiting out three quarters of the observed episodes would have
+		// suppressed most of them.
+		forMinutes := int(math.Ceil(percentile(episodes, 0.75) + stepMinutes))
+		r.SuggestedForMinutes = &forMinutes
+		r.Suggestions = append(r.Suggestions, fmt.Sprintf(
+			"%d of %d episodes lasted %g min or less (median %g min). Require the condition to hold for at least %d min (for / pending duration) so transient breaches do not fire.",
+			r.ShortEpisodes, r.Episodes, shortMinutes, r.MedianFiringMinutes, forMinutes))
+	}
+	if shortGaps {
+		keepMinutes := int(math.Ceil(percentile(gaps, 0.75) + stepMinutes))
+		r.SuggestedKeepFiring = &keepMinutes
+		r.Suggestions = append(r.Suggestions, fmt.Sprintf(
+			"The rule re-fires within %g min of resolving (median gap %g min). Add hysteresis: keep firing for %d min after recovery, or resolve at a lower threshold than it fires.",
+			shortMinutes, *r.MedianGapMinutes, keepMinutes))
+	}
+	if r.FiringPercent >= 20 {
+		r.Suggestions = append(r.Suggestions, fmt.Sprintf(
+			"It fires %g%% of the time: the value hovers around the threshold. Move the threshold outside the normal band or alert on a longer evaluation window.",
+			r.FiringPercent))
+	}
+	return r
+}
+
+// percentile returns the nearest-rank percentile of values, rounded to 0.1.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+	return roundTo(sorted[idx], 1)
+}
+
+// roundTo rounds v to the given number of decimal places.
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
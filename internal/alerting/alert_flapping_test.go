@@ -0,0 +1,133 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// firingPattern turns "x..x" into a sampled history: x is firing.
+func firingPattern(p string) []bool {
+	out := make([]bool, len(p))
+	for i, c := range p {
+		out[i] = c == 'x'
+	}
+	return out
+}
+
+func TestAnalyzeRuleFlapping(t *testing.T) {
+	t.Run("short episodes and gaps", func(t *testing.T) {
+		r := analyzeRuleFlapping(firingPattern("x.x.x.xx......"), 5, 15, 3)
+		if r.Episodes != 4 || r.ShortEpisodes != 4 || r.ShortGaps != 3 || !r.Flapping {
+			t.Fatalf("r = %+v", r)
+		}
+		if r.MedianFiringMinutes != 5 || *r.MedianGapMinutes != 5 || r.AvgFiringMinutes != 6.3 {
+			t.Errorf("durations = median %v, gap %v, avg %v", r.MedianFiringMinutes, *r.MedianGapMinutes, r.AvgFiringMinutes)
+		}
+		if *r.SuggestedForMinutes != 10 || *r.SuggestedKeepFiring != 10 || len(r.Suggestions) != 3 {
+			t.Errorf("suggestions = for %v, keep %v, %q", *r.SuggestedForMinutes, *r.SuggestedKeepFiring, r.Suggestions)
+		}
+	})
+
+	t.Run("long stable incident", func(t *testing.T) {
+		r := analyzeRuleFlapping(firingPattern("....xxxxxxxx...."), 5, 15, 3)
+		if r.Episodes != 1 || r.Flapping || r.AvgFiringMinutes != 40 || r.MedianGapMinutes != nil {
+			t.Errorf("r = %+v", r)
+		}
+	})
+
+	t.Run("open episode at end", func(t *testing.T) {
+		r := analyzeRuleFlapping(firingPattern("x...........x"), 10, 15, 2)
+		if !r.FiringAtEnd || r.Episodes != 2 || r.ShortGaps != 0 || !r.Flapping || r.SuggestedKeepFiring != nil {
+			t.Errorf("r = %+v", r)
+		}
+	})
+
+	t.Run("never fired", func(t *testing.T) {
+		if r := analyzeRuleFlapping(firingPattern("...."), 5, 15, 3); r.Episodes != 0 || r.Flapping {
+			t.Errorf("r = %+v", r)
+		}
+	})
+}
+
+func TestAnalyzeAlertFlappingHandler(t *testing.T) {
+	const end = int64(1767225600)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+		if r.URL.Query().Get("window") != "300" {
+			t.Errorf("window = %s, want 300", r.URL.Query().Get("window"))
+		}
+		step := (end - ts) / 300
+		flappy := "resolved"
+		if step%2 == 0 {
+			flappy = "firing"
+		}
+		resp := AlertsResponse{Timestamp: ts, Window: 300, AlertRules: []AlertRuleData{
+			{RuleID: "r1", RuleName: "CPU high", Severity: "threat", State: flappy},
+			{RuleID: "r2", RuleName: "Disk full", State: "resolved"},
+		}}
+		if step < 3 {
+			resp.AlertRules = append(resp.AlertRules, AlertRuleData{RuleID: "r3", RuleName: "Error rate", State: "firing"})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "mock-token", ExpiresAt: time.Now().Add(time.Hour)}
+	handler := NewAnalyzeAlertFlappingHandler(server.Client(), cfg)
+
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, AnalyzeAlertFlappingArgs{
+		EndTimeISO:      "2026-01-01T00:00:00Z",
+		LookbackMinutes: 60,
+		StepMinutes:     5,
+	})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var report AlertFlappingReport
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Samples != 12 || report.RulesFired != 2 || report.FlappingRules != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if r := report.Rules[0]; r.RuleID != "r1" || r.Episodes != 6 || !r.Flapping || r.FiringPercent != 50 {
+		t.Errorf("flapping rule = %+v", r)
+	}
+	if r := report.Rules[1]; r.RuleID != "r3" || r.Flapping || !r.FiringAtEnd {
+		t.Errorf("stable rule = %+v", r)
+	}
+
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, AnalyzeAlertFlappingArgs{
+		EndTimeISO: "2026-01-01T00:00:00Z", LookbackMinutes: 60, StepMinutes: 5, RuleName: "error",
+	})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; strings.Contains(text, "CPU high") || !strings.Contains(text, "Error rate") {
+		t.Errorf("rule_name filter not applied: %s", text)
+	}
+
+	for _, args := range []AnalyzeAlertFlappingArgs{
+		{RuleName: "("},
+		{LookbackMinutes: 20000},
+		{LookbackMinutes: 60, StepMinutes: 60},
+		{LookbackMinutes: 1440, StepMinutes: 1},
+		{Limit: 500},
+	} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("expected error for %+v", args)
+		}
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"net/url"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -27,9 +29,9 @@ type AlertRuleStateRequest struct {
 
 const alertRuleStateMaxPoints = 100
 
-func NewAlertRuleStateHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, AlertRuleStateRequest) (*mcp.CallToolResult, any, error) {
+func NewAlertRuleStateHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, AlertRuleStateRequest) (*mcp.CallToolResult, any, error) {
 	if client == nil {
-		client = http.DefaultClient
+		client = auth.GetHTTPClient()
 	}
 	return func(ctx context.Context, req *mcp.CallToolRequest, args AlertRuleStateRequest) (*mcp.CallToolResult, any, error) {
 		if args.StartTime >= args.EndTime {
@@ -56,6 +58,9 @@ func NewAlertRuleStateHandler(client *http.Client, cfg models.Config) func(conte
 		tokenMgr := cfg.TokenManager
 
 		for t := args.StartTime; t <= args.EndTime; t += args.Step {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
 			queryParams := url.Values{}
 			queryParams.Set("timestamp", fmt.Sprintf("%d", t))
 			queryParams.Set("window", fmt.Sprintf("%d", args.Step))
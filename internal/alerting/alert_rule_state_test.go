@@ -11,8 +11,8 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
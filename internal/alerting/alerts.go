@@ -7,12 +7,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -107,19 +109,15 @@ type GetAlertConfigArgs struct {
 	SearchTerm     string   `json:"search_term,omitempty" jsonschema:"Case-insensitive substring search across rule name and alert group metadata (optional)"`
 	RuleName       string   `json:"rule_name,omitempty" jsonschema:"Case-insensitive substring match on rule name (optional)"`
 	Severity       string   `json:"severity,omitempty" jsonschema:"Exact case-insensitive severity filter (optional, e.g. breach or threat)"`
-	RuleType       string   `json:"rule_type,omitempty" jsonschema:"Derived rule type filter (optional, allowed values: static or anomaly)"`
+	RuleType       string   `json:"rule_type,omitempty" jsonschema:"Derived rule type filter (optional, one of: static, anomaly)"`
 	AlertGroupName string   `json:"alert_group_name,omitempty" jsonschema:"Case-insensitive substring match on alert group name (optional)"`
 	AlertGroupType string   `json:"alert_group_type,omitempty" jsonschema:"Case-insensitive substring match on alert group type (optional)"`
 	DataSourceName string   `json:"data_source_name,omitempty" jsonschema:"Case-insensitive substring match on alert group data source name (optional)"`
 	Tags           []string `json:"tags,omitempty" jsonschema:"Alert group tag filters combined with AND semantics (optional)"`
 }
 
-func NewGetAlertConfigHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetAlertConfigArgs) (*mcp.CallToolResult, any, error) {
+func NewGetAlertConfigHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetAlertConfigArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetAlertConfigArgs) (*mcp.CallToolResult, any, error) {
-		if err := validateGetAlertConfigArgs(args); err != nil {
-			return nil, nil, err
-		}
-
 		alertConfig, err := fetchAlertConfig(ctx, client, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -164,9 +162,19 @@ type GetAlertsArgs struct {
 	Timestamp       float64 `json:"timestamp,omitempty" jsonschema:"Unix timestamp for query time (deprecated alias; defaults to current time)"`
 	Window          float64 `json:"window,omitempty" jsonschema:"Time window in seconds (default: 900, range: 1-3600)"`
 	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Time window in minutes (default: 15, range: 1-60). Used only when window is omitted."`
+	Severity        string  `json:"severity,omitempty" jsonschema:"Exact case-insensitive severity filter (e.g. breach or threat)"`
+	State           string  `json:"state,omitempty" jsonschema:"Exact case-insensitive state filter on the rule or its alert instances (e.g. firing, resolved)"`
+	ServiceName     string  `json:"service_name,omitempty" jsonschema:"Keep only alert instances whose group labels name this service (service_name, service or service.name)"`
+	RuleName        string  `json:"rule_name,omitempty" jsonschema:"Case-insensitive regex filter on rule name"`
+	SortBy          string  `json:"sort_by,omitempty" jsonschema:"Rule ordering: last_fired puts the most recent first (default), severity puts breach first, instances puts the most alert instances first (one of: last_fired, severity, instances)"`
+	Limit           int     `json:"limit,omitempty" jsonschema:"Maximum alert rules to return (default: 20, max: 200)"`
+	Offset          int     `json:"offset,omitempty" jsonschema:"Number of matching alert rules to skip, for pagination (default: 0)"`
+	// SuppressMaintenance drops instances whose service is in a declared
+	// maintenance window; by default they are annotated instead.
+	SuppressMaintenance bool `json:"suppress_maintenance,omitempty" jsonschema:"Drop alert instances whose service is in a declared maintenance window during the evaluation window (default: false, they are annotated instead)"`
 }
 
-func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetAlertsArgs) (*mcp.CallToolResult, any, error) {
+func NewGetAlertsHandler(client utils.HTTPClient, cfg models.Config, windows *maintenance.Store) func(context.Context, *mcp.CallToolRequest, GetAlertsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetAlertsArgs) (*mcp.CallToolResult, any, error) {
 		// Parse window parameter (defaults to 900 seconds = 15 minutes).
 		window := int64(900)
@@ -183,14 +191,29 @@ func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Co
 			return nil, nil, fmt.Errorf("window must be between 1 and 3600 seconds")
 		}
 
+		filter, err := newAlertRulesFilter(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		limit := args.Limit
+		if limit == 0 {
+			limit = alertsDefaultLimit
+		}
+		if limit < 1 || limit > alertsMaxLimit {
+			return nil, nil, fmt.Errorf("limit must be between 1 and %d", alertsMaxLimit)
+		}
+		if args.Offset < 0 {
+			return nil, nil, fmt.Errorf("offset must be non-negative")
+		}
+
 		// Resolve timestamp using shared time-range logic.
-		timeParams := map[string]interface{}{}
+		var timeRange utils.TimeRange
 		if args.TimeISO != "" {
-			timeParams["end_time_iso"] = args.TimeISO
+			timeRange.EndTimeISO = args.TimeISO
 		} else if args.Timestamp != 0 {
-			timeParams["end_time_iso"] = time.Unix(int64(args.Timestamp), 0).UTC().Format(time.RFC3339)
+			timeRange.EndTimeISO = time.Unix(int64(args.Timestamp), 0).UTC().Format(time.RFC3339)
 		} else if args.LookbackMinutes != 0 && args.Window == 0 {
-			timeParams["lookback_minutes"] = args.LookbackMinutes
+			timeRange.LookbackMinutes = args.LookbackMinutes
 		}
 
 		defaultLookbackMinutes := int(window / 60)
@@ -198,7 +221,7 @@ func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Co
 			defaultLookbackMinutes = 1
 		}
 
-		_, endTime, err := utils.GetTimeRange(timeParams, defaultLookbackMinutes)
+		_, endTime, err := timeRange.Resolve(defaultLookbackMinutes)
 		if err != nil {
 			if args.TimeISO != "" {
 				return nil, nil, fmt.Errorf("invalid time_iso format: %w", err)
@@ -207,68 +230,59 @@ func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Co
 		}
 		timestamp := endTime.Unix()
 
-		// Build the base URL for alerts monitoring API
-		// Datasource is already configured in cfg via PopulateAPICfg
-		baseURL := fmt.Sprintf("%s%s", cfg.APIBaseURL, constants.EndpointAlertsMonitor)
-		queryParams := url.Values{}
-		queryParams.Set("timestamp", fmt.Sprintf("%d", timestamp))
-		queryParams.Set("window", fmt.Sprintf("%d", window))
-		// Note: read_url is not needed here as datasource is configured at config level
-
-		// Build final URL with query parameters
-		finalURL := fmt.Sprintf("%s?%s", baseURL, queryParams.Encode())
-
-		// Create HTTP request
-		httpReq, err := http.NewRequestWithContext(ctx, "GET", finalURL, nil)
+		alertsResp, err := fetchAlertsMonitor(ctx, client, cfg, timestamp, window)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to create request: %w", err)
+			return nil, nil, err
 		}
 
-		// Set headers
-		httpReq.Header.Set(constants.HeaderAccept, constants.HeaderAcceptJSON)
-		httpReq.Header.Set(constants.HeaderXLast9APIToken, constants.BearerPrefix+cfg.TokenManager.GetAccessToken(ctx))
+		// Format the response
+		timeStr := time.Unix(alertsResp.Timestamp, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+		formattedResponse := fmt.Sprintf("Alerts for timestamp %s (window: %d seconds):\n", timeStr, alertsResp.Window)
 
-		// Make the request
-		resp, err := client.Do(httpReq)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to make request: %w", err)
+		windowStart, windowEnd := time.Unix(timestamp-window, 0), time.Unix(timestamp, 0)
+		rules := filter.apply(alertsResp.AlertRules)
+		suppressed := 0
+		if args.SuppressMaintenance {
+			rules, suppressed = suppressMaintenance(rules, windows, windowStart, windowEnd)
 		}
-		defer resp.Body.Close()
+		sortAlertRules(rules, strings.ToLower(strings.TrimSpace(args.SortBy)))
 
-		// Check response status
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return nil, nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		totalAlertInstances := 0
+		for _, rule := range rules {
+			totalAlertInstances += len(rule.Alerts)
 		}
 
-		// Read response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read response: %w", err)
+		formattedResponse += fmt.Sprintf("Found %d alert rule(s) with %d alert instance(s)", len(rules), totalAlertInstances)
+		if len(rules) != len(alertsResp.AlertRules) {
+			formattedResponse += fmt.Sprintf(" matching filters (of %d rule(s) in the window)", len(alertsResp.AlertRules))
 		}
-
-		// Parse JSON response
-		var alertsResp AlertsResponse
-		if err := json.Unmarshal(body, &alertsResp); err != nil {
-			return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		formattedResponse += ":\n"
+		if suppressed > 0 {
+			formattedResponse += fmt.Sprintf("Suppressed %d alert instance(s) of services in declared maintenance windows.\n", suppressed)
 		}
-
-		// Format the response
-		timeStr := time.Unix(alertsResp.Timestamp, 0).UTC().Format("2006-01-02 15:04:05 UTC")
-		formattedResponse := fmt.Sprintf("Alerts for timestamp %s (window: %d seconds):\n", timeStr, alertsResp.Window)
-
-		totalAlertInstances := 0
-		for _, rule := range alertsResp.AlertRules {
-			totalAlertInstances += len(rule.Alerts)
+		if len(rules) > 0 {
+			formattedResponse += summarizeAlertRules(rules)
 		}
 
-		formattedResponse += fmt.Sprintf("Found %d alert rule(s) with %d alert instance(s):\n\n", len(alertsResp.AlertRules), totalAlertInstances)
+		start := min(args.Offset, len(rules))
+		end := min(start+limit, len(rules))
+		page := rules[start:end]
+		if len(page) < len(rules) {
+			formattedResponse += fmt.Sprintf("Showing rules %d-%d of %d", start+1, end, len(rules))
+			if end < len(rules) {
+				formattedResponse += fmt.Sprintf("; pass offset=%d for the next page", end)
+			}
+			formattedResponse += ".\n"
+		}
+		formattedResponse += "\n"
 
-		if len(alertsResp.AlertRules) == 0 {
+		if len(rules) == 0 {
 			formattedResponse += "No alerts found in the specified time window.\n"
+		} else if len(page) == 0 {
+			formattedResponse += fmt.Sprintf("No alert rules at offset %d.\n", args.Offset)
 		} else {
-			for i, rule := range alertsResp.AlertRules {
-				formattedResponse += fmt.Sprintf("Alert Rule %d:\n", i+1)
+			for i, rule := range page {
+				formattedResponse += fmt.Sprintf("Alert Rule %d:\n", start+i+1)
 				formattedResponse += fmt.Sprintf("  Rule ID: %s\n", rule.RuleID)
 				formattedResponse += fmt.Sprintf("  Rule Name: %s\n", rule.RuleName)
 				formattedResponse += fmt.Sprintf("  Alert Group: %s\n", rule.AlertGroupName)
@@ -293,6 +307,14 @@ func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Co
 					}
 				}
 
+				if services := alertRuleServices(rule); len(services) > 0 {
+					names := make([]string, len(services))
+					for k, ref := range services {
+						names[k] = ref.String()
+					}
+					formattedResponse += fmt.Sprintf("  Services: %s\n", strings.Join(names, ", "))
+				}
+
 				if len(rule.Alerts) > 0 {
 					formattedResponse += fmt.Sprintf("  Alert Instances (%d):\n", len(rule.Alerts))
 					for j, alert := range rule.Alerts {
@@ -300,6 +322,12 @@ func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Co
 						formattedResponse += fmt.Sprintf("      State: %s\n", alert.State)
 						formattedResponse += fmt.Sprintf("      Current Value: %.4f\n", alert.CurrentValue)
 						formattedResponse += fmt.Sprintf("      Metric Degradation: %.4f\n", alert.MetricDegradation)
+						if ref, ok := resolveAlertService(alert.GroupLabels); ok {
+							formattedResponse += fmt.Sprintf("      Service: %s\n", ref)
+						}
+						for _, w := range instanceMaintenance(windows, alert, windowStart, windowEnd) {
+							formattedResponse += fmt.Sprintf("      Maintenance: %s\n", w)
+						}
 
 						if len(alert.GroupLabels) > 0 {
 							formattedResponse += "      Group Labels:\n"
@@ -335,3 +363,55 @@ func NewGetAlertsHandler(client *http.Client, cfg models.Config) func(context.Co
 		}, nil, nil
 	}
 }
+
+// fetchAlertsMonitor fetches alert rules and their instances evaluated at
+// timestamp over the preceding window seconds.
+func fetchAlertsMonitor(ctx context.Context, client utils.HTTPClient, cfg models.Config, timestamp, window int64) (AlertsResponse, error) {
+	// Build the base URL for alerts monitoring API
+	// Datasource is already configured in cfg via PopulateAPICfg
+	baseURL := fmt.Sprintf("%s%s", cfg.APIBaseURL, constants.EndpointAlertsMonitor)
+	queryParams := url.Values{}
+	queryParams.Set("timestamp", fmt.Sprintf("%d", timestamp))
+	queryParams.Set("window", fmt.Sprintf("%d", window))
+	// Note: read_url is not needed here as datasource is configured at config level
+
+	// Build final URL with query parameters
+	finalURL := fmt.Sprintf("%s?%s", baseURL, queryParams.Encode())
+
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", finalURL, nil)
+	if err != nil {
+		return AlertsResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set(constants.HeaderAccept, constants.HeaderAcceptJSON)
+	httpReq.Header.Set(constants.HeaderXLast9APIToken, constants.BearerPrefix+cfg.TokenManager.GetAccessToken(ctx))
+
+	// Make the request
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return AlertsResponse{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return AlertsResponse{}, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return AlertsResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Parse JSON response
+	var alertsResp AlertsResponse
+	if err := json.Unmarshal(body, &alertsResp); err != nil {
+		return AlertsResponse{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return alertsResp, nil
+}
@@ -0,0 +1,171 @@
+package alerting
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	alertsDefaultLimit = 20
+	alertsMaxLimit     = 200
+)
+
+// Sort orders accepted by get_alerts.
+const (
+	alertsSortLastFired = "last_fired"
+	alertsSortSeverity  = "severity"
+	alertsSortInstances = "instances"
+)
+
+// alertSeverityRank orders Last9 severities from most to least urgent.
+var alertSeverityRank = map[string]int{
+	"breach": 0,
+	"threat": 1,
+}
+
+// alertRulesFilter is the validated form of the get_alerts filter arguments.
+type alertRulesFilter struct {
+	severity    string
+	state       string
+	serviceName string
+	ruleName    *regexp.Regexp
+}
+
+func newAlertRulesFilter(args GetAlertsArgs) (alertRulesFilter, error) {
+	filter := alertRulesFilter{
+		severity:    strings.TrimSpace(args.Severity),
+		state:       strings.TrimSpace(args.State),
+		serviceName: strings.TrimSpace(args.ServiceName),
+	}
+	if args.RuleName != "" {
+		re, err := regexp.Compile("(?i)" + args.RuleName)
+		if err != nil {
+			return filter, fmt.Errorf("invalid rule_name regex: %w", err)
+		}
+		filter.ruleName = re
+	}
+	return filter, nil
+}
+
+// instanceFiltered reports whether the filter narrows alert instances, in which
+// case rules left without matching instances are dropped.
+func (f alertRulesFilter) instanceFiltered() bool {
+	return f.state != "" || f.serviceName != ""
+}
+
+// apply returns the rules (and, within them, the alert instances) matching
+// every filter. Severity and rule name are matched on the rule; state matches
+// either the rule or individual instances; service_name matches instance group
+// labels.
+func (f alertRulesFilter) apply(rules []AlertRuleData) []AlertRuleData {
+	out := make([]AlertRuleData, 0, len(rules))
+	for _, rule := range rules {
+		if f.severity != "" && !strings.EqualFold(rule.Severity, f.severity) {
+			continue
+		}
+		if f.ruleName != nil && !f.ruleName.MatchString(rule.RuleName) {
+			continue
+		}
+		if !f.instanceFiltered() {
+			out = append(out, rule)
+			continue
+		}
+
+		ruleStateMatches := f.state == "" || strings.EqualFold(rule.State, f.state)
+		instances := make([]AlertInstance, 0, len(rule.Alerts))
+		for _, inst := range rule.Alerts {
+			if f.state != "" && !ruleStateMatches && !strings.EqualFold(inst.State, f.state) {
+				continue
+			}
+			if f.serviceName != "" {
+				ref, _ := resolveAlertService(inst.GroupLabels)
+				if !strings.EqualFold(ref.ServiceName, f.serviceName) {
+					continue
+				}
+			}
+			instances = append(instances, inst)
+		}
+		if len(instances) == 0 && (f.serviceName != "" || !ruleStateMatches) {
+			continue
+		}
+		rule.Alerts = instances
+		out = append(out, rule)
+	}
+	return out
+}
+
+// sortAlertRules orders rules in place. Ties fall back to rule name so pages
+// are stable across calls.
+func sortAlertRules(rules []AlertRuleData, sortBy string) {
+	severityRank := func(s string) int {
+		if rank, ok := alertSeverityRank[strings.ToLower(s)]; ok {
+			return rank
+		}
+		return len(alertSeverityRank)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		switch sortBy {
+		case alertsSortSeverity:
+			if ra, rb := severityRank(a.Severity), severityRank(b.Severity); ra != rb {
+				return ra < rb
+			}
+		case alertsSortInstances:
+			if len(a.Alerts) != len(b.Alerts) {
+				return len(a.Alerts) > len(b.Alerts)
+			}
+		default:
+			if a.LastFiredAt != b.LastFiredAt {
+				return a.LastFiredAt > b.LastFiredAt
+			}
+		}
+		return a.RuleName < b.RuleName
+	})
+}
+
+// summarizeAlertRules renders counts by severity and by state, for rules and
+// alert instances, as a compact header.
+func summarizeAlertRules(rules []AlertRuleData) string {
+	bySeverity := map[string]int{}
+	byState := map[string]int{}
+	instancesByState := map[string]int{}
+	instancesByService := map[string]int{}
+	for _, rule := range rules {
+		bySeverity[orUnknown(rule.Severity)]++
+		byState[orUnknown(rule.State)]++
+		for _, inst := range rule.Alerts {
+			instancesByState[orUnknown(inst.State)]++
+			if ref, ok := resolveAlertService(inst.GroupLabels); ok {
+				instancesByService[ref.ServiceName]++
+			}
+		}
+	}
+	summary := fmt.Sprintf("Summary: rules by severity {%s}; rules by state {%s}; instances by state {%s}",
+		formatCounts(bySeverity), formatCounts(byState), formatCounts(instancesByState))
+	if len(instancesByService) > 0 {
+		summary += fmt.Sprintf("; instances by service {%s}", formatCounts(instancesByService))
+	}
+	return summary + "\n"
+}
+
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s: %d", k, counts[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,171 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func testAlertRules() []AlertRuleData {
+	return []AlertRuleData{
+		{
+			RuleName: "checkout error rate", Severity: "breach", State: "firing", LastFiredAt: 300,
+			Alerts: []AlertInstance{
+				{State: "firing", GroupLabels: map[string]interface{}{"service_name": "checkout"}},
+				{State: "resolved", GroupLabels: map[string]interface{}{"service_name": "cart"}},
+			},
+		},
+		{
+			RuleName: "Checkout latency", Severity: "threat", State: "resolved", LastFiredAt: 100,
+			Alerts: []AlertInstance{
+				{State: "resolved", GroupLabels: map[string]interface{}{"service": "checkout"}},
+			},
+		},
+		{
+			RuleName: "disk usage", Severity: "threat", State: "firing", LastFiredAt: 200,
+			Alerts: []AlertInstance{
+				{State: "firing", GroupLabels: map[string]interface{}{"instance": "db-1"}},
+				{State: "firing", GroupLabels: map[string]interface{}{"instance": "db-2"}},
+				{State: "firing", GroupLabels: map[string]interface{}{"instance": "db-3"}},
+			},
+		},
+	}
+}
+
+func ruleNames(rules []AlertRuleData) []string {
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.RuleName
+	}
+	return names
+}
+
+func TestAlertRulesFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          GetAlertsArgs
+		wantRules     []string
+		wantInstances []int
+	}{
+		{"no filters", GetAlertsArgs{}, []string{"checkout error rate", "Checkout latency", "disk usage"}, []int{2, 1, 3}},
+		{"severity", GetAlertsArgs{Severity: "BREACH"}, []string{"checkout error rate"}, []int{2}},
+		{"rule name regex is case-insensitive", GetAlertsArgs{RuleName: "^checkout"}, []string{"checkout error rate", "Checkout latency"}, []int{2, 1}},
+		{"service matches any service label key", GetAlertsArgs{ServiceName: "checkout"}, []string{"checkout error rate", "Checkout latency"}, []int{1, 1}},
+		{"state keeps matching rules whole", GetAlertsArgs{State: "firing"}, []string{"checkout error rate", "disk usage"}, []int{2, 3}},
+		{"state matches instances of other rules", GetAlertsArgs{State: "resolved"}, []string{"checkout error rate", "Checkout latency"}, []int{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newAlertRulesFilter(tt.args)
+			if err != nil {
+				t.Fatalf("newAlertRulesFilter: %v", err)
+			}
+			got := filter.apply(testAlertRules())
+			if strings.Join(ruleNames(got), "|") != strings.Join(tt.wantRules, "|") {
+				t.Fatalf("rules = %v, want %v", ruleNames(got), tt.wantRules)
+			}
+			for i, r := range got {
+				if len(r.Alerts) != tt.wantInstances[i] {
+					t.Errorf("%s: %d instances, want %d", r.RuleName, len(r.Alerts), tt.wantInstances[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewAlertRulesFilter_InvalidRegex(t *testing.T) {
+	if _, err := newAlertRulesFilter(GetAlertsArgs{RuleName: "("}); err == nil {
+		t.Fatal("expected error for invalid rule_name regex")
+	}
+}
+
+func TestSortAlertRules(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"", []string{"checkout error rate", "disk usage", "Checkout latency"}},
+		{alertsSortSeverity, []string{"checkout error rate", "Checkout latency", "disk usage"}},
+		{alertsSortInstances, []string{"disk usage", "checkout error rate", "Checkout latency"}},
+	}
+	for _, tt := range tests {
+		rules := testAlertRules()
+		sortAlertRules(rules, tt.sortBy)
+		if strings.Join(ruleNames(rules), "|") != strings.Join(tt.want, "|") {
+			t.Errorf("sortAlertRules(%q) = %v, want %v", tt.sortBy, ruleNames(rules), tt.want)
+		}
+	}
+}
+
+func TestSummarizeAlertRules(t *testing.T) {
+	got := summarizeAlertRules(testAlertRules())
+	want := "Summary: rules by severity {breach: 1, threat: 2}; rules by state {firing: 2, resolved: 1}; instances by state {firing: 4, resolved: 2}; instances by service {cart: 1, checkout: 2}\n"
+	if got != want {
+		t.Errorf("summarizeAlertRules() = %q, want %q", got, want)
+	}
+}
+
+func TestGetAlertsHandler_FiltersAndPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(AlertsResponse{
+			Timestamp:  time.Now().Unix(),
+			Window:     900,
+			AlertRules: testAlertRules(),
+		})
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{
+		AccessToken: "mock-token",
+		ExpiresAt:   time.Now().Add(365 * 24 * time.Hour),
+	}
+	handler := NewGetAlertsHandler(server.Client(), cfg, nil)
+
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetAlertsArgs{Severity: "threat", Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{
+		"Found 2 alert rule(s) with 4 alert instance(s) matching filters (of 3 rule(s) in the window)",
+		"rules by severity {threat: 2}",
+		"Showing rules 1-1 of 2; pass offset=1 for the next page.",
+		"Rule Name: disk usage",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("response missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Checkout latency") {
+		t.Errorf("response should only include the first page:\n%s", text)
+	}
+
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, GetAlertsArgs{Severity: "threat", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text = result.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, "Alert Rule 2:") || !strings.Contains(text, "Rule Name: Checkout latency") {
+		t.Errorf("second page should contain rule 2 (Checkout latency):\n%s", text)
+	}
+	if !strings.Contains(text, "  Services: checkout\n") || !strings.Contains(text, "      Service: checkout\n") {
+		t.Errorf("alerts should be annotated with their service:\n%s", text)
+	}
+
+	for _, args := range []GetAlertsArgs{{Limit: 201}, {Offset: -1}} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("expected validation error for %+v", args)
+		}
+	}
+}
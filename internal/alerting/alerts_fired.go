@@ -0,0 +1,154 @@
+package alerting
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+const (
+	// alertsMaxWindow is the longest window the alerts API accepts, in
+	// seconds. Longer ranges are walked in windows of this size.
+	alertsMaxWindow = 3600
+	// firedAlertsParallelWindows bounds how many windows are fetched at once.
+	firedAlertsParallelWindows = 4
+)
+
+// FiredAlert is an alert rule that fired during a range, for callers outside
+// this package that report on alert history.
+type FiredAlert struct {
+	RuleName string `json:"rule_name"`
+	Severity string `json:"severity"`
+	// Instances counts the distinct alert instances that fired.
+	Instances   int      `json:"instances"`
+	Services    []string `json:"services,omitempty"`
+	LastFiredAt int64    `json:"last_fired_at"`
+	// Firing reports whether an instance was still firing at the end of the
+	// range.
+	Firing bool `json:"firing"`
+}
+
+// FiredAlerts returns the alert rules with instances that fired between start
+// and end (unix seconds), walking the range in windows the alerts API
+// accepts. An empty env or ".*" matches every environment; instances without
+// an env label match any env. Rules still firing come first, then breaches,
+// then the most recently fired.
+func FiredAlerts(ctx context.Context, client utils.HTTPClient, cfg models.Config, env string, start, end int64) ([]FiredAlert, error) {
+	var windowEnds []int64
+	for t := end; t > start; t -= alertsMaxWindow {
+		windowEnds = append(windowEnds, t)
+	}
+	responses := make([]AlertsResponse, len(windowEnds))
+	errs := make([]error, len(windowEnds))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, firedAlertsParallelWindows)
+	for i, t := range windowEnds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			responses[i], errs[i] = fetchAlertsMonitor(ctx, client, cfg, t, min(t-start, alertsMaxWindow))
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type firedRule struct {
+		FiredAlert
+		instances map[string]bool
+		services  map[string]bool
+	}
+	byRule := map[string]*firedRule{}
+	var order []string
+	// responses[0] is the window ending at end, so its firing state is the
+	// current one.
+	for i, resp := range responses {
+		for _, rule := range resp.AlertRules {
+			for _, inst := range rule.Alerts {
+				fired := strings.EqualFold(inst.State, "firing") || inst.LastFiredAt >= start
+				if !fired {
+					continue
+				}
+				ref, hasService := resolveAlertService(inst.GroupLabels)
+				if env != "" && env != ".*" && ref.Env != "" && !strings.EqualFold(ref.Env, env) {
+					continue
+				}
+				key := rule.RuleID
+				if key == "" {
+					key = rule.RuleName
+				}
+				fr, ok := byRule[key]
+				if !ok {
+					fr = &firedRule{
+						FiredAlert: FiredAlert{RuleName: rule.RuleName, Severity: rule.Severity},
+						instances:  map[string]bool{},
+						services:   map[string]bool{},
+					}
+					byRule[key] = fr
+					order = append(order, key)
+				}
+				instance := inst.LabelHash
+				if instance == "" {
+					instance = labelsKey(inst.GroupLabels)
+				}
+				fr.instances[instance] = true
+				if hasService {
+					fr.services[ref.String()] = true
+				}
+				fr.LastFiredAt = max(fr.LastFiredAt, inst.LastFiredAt, rule.LastFiredAt)
+				if i == 0 && strings.EqualFold(inst.State, "firing") {
+					fr.Firing = true
+				}
+			}
+		}
+	}
+
+	out := make([]FiredAlert, 0, len(order))
+	for _, key := range order {
+		fr := byRule[key]
+		fr.Instances = len(fr.instances)
+		for s := range fr.services {
+			fr.Services = append(fr.Services, s)
+		}
+		sort.Strings(fr.Services)
+		out = append(out, fr.FiredAlert)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Firing != out[j].Firing {
+			return out[i].Firing
+		}
+		if bi, bj := strings.EqualFold(out[i].Severity, "breach"), strings.EqualFold(out[j].Severity, "breach"); bi != bj {
+			return bi
+		}
+		return out[i].LastFiredAt > out[j].LastFiredAt
+	})
+	return out, nil
+}
+
+// labelsKey identifies an instance by its group labels when the API gives no
+// label hash.
+func labelsKey(labels map[string]interface{}) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + firstLabel(labels, []string{k}) + ",")
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+func TestFiredAlerts(t *testing.T) {
+	end := time.Now().Unix()
+	start := end - 3*alertsMaxWindow + 60
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		latest := r.URL.Query().Get("timestamp") == strconv.FormatInt(end, 10)
+		checkoutState := "resolved"
+		if latest {
+			checkoutState = "firing"
+		}
+		_ = json.NewEncoder(w).Encode(AlertsResponse{AlertRules: []AlertRuleData{
+			{
+				RuleID: "r1", RuleName: "disk usage", Severity: "threat",
+				Alerts: []AlertInstance{{State: "resolved", LabelHash: "d1", LastFiredAt: start - 600}},
+			},
+			{
+				RuleID: "r2", RuleName: "checkout errors", Severity: "breach",
+				Alerts: []AlertInstance{
+					{State: checkoutState, LabelHash: "c1", LastFiredAt: end - 60, GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "prod"}},
+					{State: "firing", LabelHash: "c2", LastFiredAt: end - 60, GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "staging"}},
+				},
+			},
+			{
+				RuleID: "r3", RuleName: "cart latency", Severity: "threat",
+				Alerts: []AlertInstance{{State: "resolved", LabelHash: "l1", LastFiredAt: end - 1800, GroupLabels: map[string]interface{}{"service_name": "cart"}}},
+			},
+		}})
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{
+		AccessToken: "mock-token",
+		ExpiresAt:   time.Now().Add(365 * 24 * time.Hour),
+	}
+	got, err := FiredAlerts(context.Background(), server.Client(), cfg, "prod", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("alerts API called %d times, want one per hour", calls.Load())
+	}
+	if len(got) != 2 {
+		t.Fatalf("fired = %+v, want checkout errors and cart latency", got)
+	}
+	if a := got[0]; a.RuleName != "checkout errors" || !a.Firing || a.Instances != 1 || len(a.Services) != 1 || a.Services[0] != "checkout (env: prod)" {
+		t.Errorf("first = %+v, want checkout errors firing with the prod instance only", a)
+	}
+	if a := got[1]; a.RuleName != "cart latency" || a.Firing || a.LastFiredAt != end-1800 {
+		t.Errorf("second = %+v, want cart latency resolved", a)
+	}
+}
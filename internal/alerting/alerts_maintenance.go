@@ -0,0 +1,47 @@
+package alerting
+
+import (
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+)
+
+// instanceMaintenance returns the declared maintenance windows covering an
+// alert instance's service during [start, end]. Instances without a service
+// label are never covered.
+func instanceMaintenance(windows *maintenance.Store, inst AlertInstance, start, end time.Time) []maintenance.Window {
+	ref, ok := resolveAlertService(inst.GroupLabels)
+	if !ok {
+		return nil
+	}
+	return windows.Find(ref.ServiceName, ref.Env, start, end)
+}
+
+// suppressMaintenance drops alert instances covered by a maintenance window,
+// and rules left without instances. Rules that had no instances to begin
+// with are kept. It returns the kept rules and the number of instances
+// dropped.
+func suppressMaintenance(rules []AlertRuleData, windows *maintenance.Store, start, end time.Time) ([]AlertRuleData, int) {
+	out := make([]AlertRuleData, 0, len(rules))
+	suppressed := 0
+	for _, rule := range rules {
+		if len(rule.Alerts) == 0 {
+			out = append(out, rule)
+			continue
+		}
+		instances := make([]AlertInstance, 0, len(rule.Alerts))
+		for _, inst := range rule.Alerts {
+			if len(instanceMaintenance(windows, inst, start, end)) > 0 {
+				suppressed++
+				continue
+			}
+			instances = append(instances, inst)
+		}
+		if len(instances) == 0 {
+			continue
+		}
+		rule.Alerts = instances
+		out = append(out, rule)
+	}
+	return out, suppressed
+}
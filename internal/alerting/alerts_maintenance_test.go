@@ -0,0 +1,81 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSuppressMaintenance(t *testing.T) {
+	now := time.Now()
+	windows := maintenance.New("")
+	if _, err := windows.Add(maintenance.Window{ServiceName: "checkout", Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "db upgrade"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, suppressed := suppressMaintenance(testAlertRules(), windows, now.Add(-15*time.Minute), now)
+	if suppressed != 2 {
+		t.Errorf("suppressed = %d, want both checkout instances", suppressed)
+	}
+	if got := strings.Join(ruleNames(rules), "|"); got != "checkout error rate|disk usage" {
+		t.Errorf("kept rules = %s, want the Checkout latency rule dropped", got)
+	}
+	if len(rules[0].Alerts) != 1 || rules[0].Alerts[0].GroupLabels["service_name"] != "cart" {
+		t.Errorf("checkout error rate instances = %+v, want only cart", rules[0].Alerts)
+	}
+
+	if _, suppressed := suppressMaintenance(testAlertRules(), windows, now.Add(2*time.Hour), now.Add(3*time.Hour)); suppressed != 0 {
+		t.Errorf("suppressed %d instances outside the window", suppressed)
+	}
+}
+
+func TestGetAlertsHandler_Maintenance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(AlertsResponse{
+			Timestamp:  time.Now().Unix(),
+			Window:     900,
+			AlertRules: testAlertRules(),
+		})
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{
+		AccessToken: "mock-token",
+		ExpiresAt:   time.Now().Add(365 * 24 * time.Hour),
+	}
+	windows := maintenance.New("")
+	if _, err := windows.Add(maintenance.Window{ServiceName: "cart", Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour), Reason: "cache migration"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewGetAlertsHandler(server.Client(), cfg, windows)
+
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetAlertsArgs{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if strings.Count(text, "      Maintenance: cache migration") != 1 {
+		t.Errorf("the cart instance should be annotated with its maintenance window:\n%s", text)
+	}
+
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, GetAlertsArgs{SuppressMaintenance: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text = result.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, "Suppressed 1 alert instance(s)") || strings.Contains(text, "Maintenance:") {
+		t.Errorf("the cart instance should be suppressed:\n%s", text)
+	}
+}
@@ -0,0 +1,135 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// alertServiceLabelKeys are the group label keys that name the service an
+// alert instance belongs to, in order of preference.
+var alertServiceLabelKeys = []string{"service_name", "service", "service.name"}
+
+// alertEnvLabelKeys are the group label keys that name the deployment
+// environment, in order of preference.
+var alertEnvLabelKeys = []string{"env", "deployment_environment", "deployment.environment", "environment"}
+
+// alertServiceRef is the canonical service an alert instance is about, in the
+// form accepted by the APM tools' service_name and env arguments.
+type alertServiceRef struct {
+	ServiceName string
+	Env         string
+}
+
+func (r alertServiceRef) String() string {
+	if r.Env == "" {
+		return r.ServiceName
+	}
+	return fmt.Sprintf("%s (env: %s)", r.ServiceName, r.Env)
+}
+
+// resolveAlertService extracts the service and environment from alert group
+// labels. ok is false when no service label is present.
+func resolveAlertService(labels map[string]interface{}) (ref alertServiceRef, ok bool) {
+	ref.ServiceName = firstLabel(labels, alertServiceLabelKeys)
+	if ref.ServiceName == "" {
+		return alertServiceRef{}, false
+	}
+	ref.Env = firstLabel(labels, alertEnvLabelKeys)
+	return ref, true
+}
+
+// alertRuleServices returns the distinct services referenced by a rule's alert
+// instances, sorted by name then env.
+func alertRuleServices(rule AlertRuleData) []alertServiceRef {
+	seen := map[alertServiceRef]bool{}
+	var refs []alertServiceRef
+	for _, inst := range rule.Alerts {
+		if ref, ok := resolveAlertService(inst.GroupLabels); ok && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].ServiceName != refs[j].ServiceName {
+			return refs[i].ServiceName < refs[j].ServiceName
+		}
+		return refs[i].Env < refs[j].Env
+	})
+	return refs
+}
+
+func firstLabel(labels map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if v, ok := labels[key]; ok && v != nil {
+			if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// ServiceAlertSummary counts the firing alert instances attributed to one
+// service, for callers outside this package that fold alert state into their
+// own output.
+type ServiceAlertSummary struct {
+	Firing int      `json:"firing"`
+	Breach int      `json:"breach"`
+	Threat int      `json:"threat"`
+	Rules  []string `json:"rules,omitempty"`
+	// FiringSince is the earliest time (unix seconds) any of the counted
+	// instances started firing, or 0 when unknown.
+	FiringSince int64 `json:"firing_since,omitempty"`
+}
+
+// SummarizeServiceAlerts fetches alerts evaluated at timestamp over the
+// preceding window seconds and counts the firing instances whose group labels
+// name serviceName. An empty env or ".*" matches every environment; instances
+// without an env label match any env.
+func SummarizeServiceAlerts(ctx context.Context, client utils.HTTPClient, cfg models.Config, serviceName, env string, timestamp, window int64) (ServiceAlertSummary, error) {
+	resp, err := fetchAlertsMonitor(ctx, client, cfg, timestamp, window)
+	if err != nil {
+		return ServiceAlertSummary{}, err
+	}
+	return summarizeServiceAlerts(resp.AlertRules, serviceName, env), nil
+}
+
+func summarizeServiceAlerts(rules []AlertRuleData, serviceName, env string) ServiceAlertSummary {
+	var summary ServiceAlertSummary
+	for _, rule := range rules {
+		matched := false
+		for _, inst := range rule.Alerts {
+			if !strings.EqualFold(inst.State, "firing") {
+				continue
+			}
+			ref, ok := resolveAlertService(inst.GroupLabels)
+			if !ok || !strings.EqualFold(ref.ServiceName, serviceName) {
+				continue
+			}
+			if env != "" && env != ".*" && ref.Env != "" && !strings.EqualFold(ref.Env, env) {
+				continue
+			}
+			summary.Firing++
+			if inst.Since > 0 && (summary.FiringSince == 0 || inst.Since < summary.FiringSince) {
+				summary.FiringSince = inst.Since
+			}
+			switch strings.ToLower(rule.Severity) {
+			case "breach":
+				summary.Breach++
+			case "threat":
+				summary.Threat++
+			}
+			matched = true
+		}
+		if matched {
+			summary.Rules = append(summary.Rules, rule.RuleName)
+		}
+	}
+	sort.Strings(summary.Rules)
+	return summary
+}
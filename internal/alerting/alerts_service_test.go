@@ -0,0 +1,78 @@
+package alerting
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveAlertService(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]interface{}
+		want   alertServiceRef
+		wantOK bool
+	}{
+		{"service_name and env", map[string]interface{}{"service_name": "checkout", "env": "prod"}, alertServiceRef{"checkout", "prod"}, true},
+		{"otel-style keys", map[string]interface{}{"service.name": "cart", "deployment.environment": "staging"}, alertServiceRef{"cart", "staging"}, true},
+		{"service_name preferred over service", map[string]interface{}{"service": "b", "service_name": "a"}, alertServiceRef{"a", ""}, true},
+		{"blank service ignored", map[string]interface{}{"service_name": " ", "instance": "db-1"}, alertServiceRef{}, false},
+		{"no labels", nil, alertServiceRef{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveAlertService(tt.labels)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolveAlertService() = (%+v, %v), want (%+v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAlertServiceRefString(t *testing.T) {
+	if got := (alertServiceRef{ServiceName: "checkout", Env: "prod"}).String(); got != "checkout (env: prod)" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (alertServiceRef{ServiceName: "checkout"}).String(); got != "checkout" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestAlertRuleServices(t *testing.T) {
+	rule := AlertRuleData{Alerts: []AlertInstance{
+		{GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "prod"}},
+		{GroupLabels: map[string]interface{}{"service_name": "cart", "env": "prod"}},
+		{GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "prod"}},
+		{GroupLabels: map[string]interface{}{"instance": "db-1"}},
+	}}
+	want := []alertServiceRef{{"cart", "prod"}, {"checkout", "prod"}}
+	if got := alertRuleServices(rule); !reflect.DeepEqual(got, want) {
+		t.Errorf("alertRuleServices() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSummarizeServiceAlerts(t *testing.T) {
+	rules := []AlertRuleData{
+		{RuleName: "error rate", Severity: "breach", Alerts: []AlertInstance{
+			{State: "firing", Since: 1700000600, GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "prod"}},
+			{State: "firing", Since: 1700000000, GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "staging"}},
+			{State: "resolved", GroupLabels: map[string]interface{}{"service_name": "checkout", "env": "prod"}},
+		}},
+		{RuleName: "latency", Severity: "threat", Alerts: []AlertInstance{
+			{State: "firing", GroupLabels: map[string]interface{}{"service": "checkout"}},
+			{State: "firing", GroupLabels: map[string]interface{}{"service": "cart"}},
+		}},
+	}
+
+	got := summarizeServiceAlerts(rules, "checkout", "prod")
+	want := ServiceAlertSummary{Firing: 2, Breach: 1, Threat: 1, Rules: []string{"error rate", "latency"}, FiringSince: 1700000600}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeServiceAlerts(prod) = %+v, want %+v", got, want)
+	}
+
+	if got := summarizeServiceAlerts(rules, "checkout", ".*"); got.Firing != 3 || got.Breach != 2 || got.FiringSince != 1700000000 {
+		t.Errorf("summarizeServiceAlerts(.*) = %+v, want 3 firing, 2 breach, firing since 1700000000", got)
+	}
+	if got := summarizeServiceAlerts(rules, "payments", ""); got.Firing != 0 || got.Rules != nil {
+		t.Errorf("summarizeServiceAlerts(payments) = %+v, want empty", got)
+	}
+}
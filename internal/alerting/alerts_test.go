@@ -10,9 +10,9 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -63,7 +63,7 @@ func TestGetAlertConfigHandler_Integration(t *testing.T) {
 func TestGetAlertsHandler_Integration(t *testing.T) {
 	cfg := utils.SetupTestConfigOrSkip(t)
 
-	handler := NewGetAlertsHandler(http.DefaultClient, *cfg)
+	handler := NewGetAlertsHandler(http.DefaultClient, *cfg, nil)
 
 	tests := []struct {
 		name string
@@ -136,7 +136,7 @@ func TestGetAlertsHandler_WindowValidation(t *testing.T) {
 		AccessToken: "mock-token",
 		ExpiresAt:   time.Now().Add(365 * 24 * time.Hour),
 	}
-	handler := NewGetAlertsHandler(server.Client(), cfg)
+	handler := NewGetAlertsHandler(server.Client(), cfg, nil)
 
 	tests := []struct {
 		name        string
@@ -247,7 +247,7 @@ func TestGetAlertsHandler_TimeParameterPrecedence(t *testing.T) {
 		ExpiresAt:   time.Now().Add(365 * 24 * time.Hour),
 	}
 
-	handler := NewGetAlertsHandler(server.Client(), cfg)
+	handler := NewGetAlertsHandler(server.Client(), cfg, nil)
 
 	tests := []struct {
 		name            string
@@ -8,9 +8,9 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -34,7 +34,7 @@ func TestGetAlertsHandler_TimeISOPrecedence(t *testing.T) {
 		},
 	}
 
-	handler := NewGetAlertsHandler(server.Client(), cfg)
+	handler := NewGetAlertsHandler(server.Client(), cfg, nil)
 	_, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetAlertsArgs{
 		TimeISO:   "2026-02-09T15:04:05Z",
 		Timestamp: 1111111111, // deprecated alias should be ignored when time_iso is present
@@ -58,7 +58,7 @@ func TestGetAlertsHandler_InvalidTimeISO(t *testing.T) {
 		},
 	}
 
-	handler := NewGetAlertsHandler(http.DefaultClient, cfg)
+	handler := NewGetAlertsHandler(http.DefaultClient, cfg, nil)
 	_, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetAlertsArgs{
 		TimeISO: "2026/02/09 15:04:05",
 	})
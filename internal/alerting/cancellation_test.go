@@ -0,0 +1,40 @@
+package alerting
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAlertHandlersHonourCancellation(t *testing.T) {
+	config := func(baseURL string) models.Config {
+		return models.Config{
+			APIBaseURL:   baseURL,
+			TokenManager: &auth.TokenManager{AccessToken: "mock-token", ExpiresAt: time.Now().Add(time.Hour)},
+		}
+	}
+	now := time.Now().Unix()
+
+	t.Run("get_alerts", func(t *testing.T) {
+		utils.AssertCancelsPromptly(t, func(ctx context.Context, baseURL string) error {
+			_, _, err := NewGetAlertsHandler(http.DefaultClient, config(baseURL), nil)(ctx, &mcp.CallToolRequest{}, GetAlertsArgs{})
+			return err
+		})
+	})
+
+	t.Run("get_alert_rule_state", func(t *testing.T) {
+		utils.AssertCancelsPromptly(t, func(ctx context.Context, baseURL string) error {
+			_, _, err := NewAlertRuleStateHandler(http.DefaultClient, config(baseURL))(ctx, &mcp.CallToolRequest{}, AlertRuleStateRequest{
+				StartTime: now - 3600, EndTime: now, Step: 60,
+			})
+			return err
+		})
+	})
+}
@@ -0,0 +1,267 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// alertRuleIndicator names the KPI a rule created by create_alert_rule
+	// evaluates; it is the rule's primary indicator.
+	alertRuleIndicator = "indicator"
+
+	defaultAlertRuleEvalWindow = 5
+	maxAlertRuleEvalWindow     = 60
+
+	maxAlertRuleErrorBodyBytes = 4096
+)
+
+// CreateAlertRuleArgs holds the input arguments for create_alert_rule.
+type CreateAlertRuleArgs struct {
+	EntityID               string  `json:"entity_id" jsonschema:"Alert group (entity) ID the rule belongs to, from get_alert_config (required)"`
+	RuleID                 string  `json:"rule_id,omitempty" jsonschema:"Existing rule in the alert group to update instead of creating a new one (optional)"`
+	RuleName               string  `json:"rule_name" jsonschema:"Rule name (required, e.g. checkout 5xx rate)"`
+	Query                  string  `json:"query" jsonschema:"PromQL expression the rule evaluates; should return one series per alerting target (required)"`
+	Operator               string  `json:"operator" jsonschema:"How the query value is compared to threshold (required, one of: >, >=, <, <=)"`
+	Threshold              float64 `json:"threshold" jsonschema:"Value the query result is compared to (required, e.g. 5)"`
+	Severity               string  `json:"severity" jsonschema:"Alert severity (required, one of: breach, threat)"`
+	Unit                   string  `json:"unit,omitempty" jsonschema:"Unit of the query result shown with alerts (optional, e.g. percent, ms)"`
+	EvalWindowMinutes      int     `json:"eval_window_minutes,omitempty" jsonschema:"Minutes of data each evaluation looks at (default: 5, max: 60)"`
+	AlertAfterMinutes      int     `json:"alert_after_minutes,omitempty" jsonschema:"Minutes within the window the condition must hold before the rule fires (default: eval_window_minutes)"`
+	NotificationChannelIDs []int   `json:"notification_channel_ids,omitempty" jsonschema:"Notification channel IDs from get_notification_channels to send the rule's alerts to (optional; default: channels routed by severity)"`
+}
+
+// alertRuleKPIRequest is the KPI (indicator) definition a rule evaluates.
+type alertRuleKPIRequest struct {
+	Name       string `json:"name"`
+	KPIType    string `json:"kpi_type"`
+	Definition struct {
+		Source string `json:"source"`
+		Query  string `json:"query"`
+		Unit   string `json:"unit,omitempty"`
+	} `json:"definition"`
+}
+
+// alertRuleRequest is the alert rule body sent to the entity alert rules API.
+type alertRuleRequest struct {
+	RuleName         string                            `json:"rule_name"`
+	PrimaryIndicator string                            `json:"primary_indicator"`
+	Expression       string                            `json:"expression"`
+	ExpressionArgs   map[string]AlertRuleExpressionArg `json:"expression_args"`
+	Severity         string                            `json:"severity"`
+	EvalWindow       int                               `json:"eval_window"`
+	AlertCondition   string                            `json:"alert_condition"`
+	Properties       map[string]any                    `json:"properties,omitempty"`
+}
+
+// validateCreateAlertRuleArgs normalizes args in place and returns a
+// user-facing message for the first problem found.
+func validateCreateAlertRuleArgs(args *CreateAlertRuleArgs) string {
+	args.EntityID = strings.TrimSpace(args.EntityID)
+	args.RuleID = strings.TrimSpace(args.RuleID)
+	args.RuleName = strings.TrimSpace(args.RuleName)
+	args.Query = strings.TrimSpace(args.Query)
+	args.Operator = strings.TrimSpace(args.Operator)
+	args.Severity = strings.ToLower(strings.TrimSpace(args.Severity))
+	switch {
+	case args.EntityID == "":
+		return "entity_id is required; find the alert group with get_alert_config"
+	case args.RuleName == "":
+		return "rule_name is required"
+	case args.Query == "":
+		return "query is required"
+	case args.Operator == "":
+		return "operator is required"
+	case args.Severity == "":
+		return "severity is required"
+	}
+	if args.EvalWindowMinutes == 0 {
+		args.EvalWindowMinutes = defaultAlertRuleEvalWindow
+	}
+	if args.EvalWindowMinutes < 1 || args.EvalWindowMinutes > maxAlertRuleEvalWindow {
+		return fmt.Sprintf("eval_window_minutes must be between 1 and %d", maxAlertRuleEvalWindow)
+	}
+	if args.AlertAfterMinutes == 0 {
+		args.AlertAfterMinutes = args.EvalWindowMinutes
+	}
+	if args.AlertAfterMinutes < 1 || args.AlertAfterMinutes > args.EvalWindowMinutes {
+		return fmt.Sprintf("alert_after_minutes must be between 1 and eval_window_minutes (%d)", args.EvalWindowMinutes)
+	}
+	return ""
+}
+
+// buildAlertRuleRequest builds the rule body for args evaluating the KPI
+// kpiID. args must have been validated.
+func buildAlertRuleRequest(args CreateAlertRuleArgs, kpiID string) alertRuleRequest {
+	condition := fmt.Sprintf("%s %s %s", alertRuleIndicator, args.Operator, strconv.FormatFloat(args.Threshold, 'f', -1, 64))
+	rule := alertRuleRequest{
+		RuleName:         args.RuleName,
+		PrimaryIndicator: alertRuleIndicator,
+		Expression:       condition,
+		ExpressionArgs:   map[string]AlertRuleExpressionArg{alertRuleIndicator: {ID: kpiID}},
+		Severity:         args.Severity,
+		EvalWindow:       args.EvalWindowMinutes,
+		AlertCondition:   fmt.Sprintf("count_true(%s) >= %d", condition, args.AlertAfterMinutes),
+		Properties:       map[string]any{"created_by": "last9-mcp-server"},
+	}
+	if len(args.NotificationChannelIDs) > 0 {
+		rule.Properties["notification_channels"] = args.NotificationChannelIDs
+	}
+	return rule
+}
+
+// NewCreateAlertRuleHandler returns the MCP tool handler for
+// create_alert_rule. The rule's PromQL query is stored as a KPI of the alert
+// group and the rule compares it against the threshold; updating a rule
+// updates that KPI in place.
+func NewCreateAlertRuleHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, CreateAlertRuleArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, _ *mcp.CallToolRequest, args CreateAlertRuleArgs) (*mcp.CallToolResult, any, error) {
+		if msg := validateCreateAlertRuleArgs(&args); msg != "" {
+			return toolErrorResult(msg), nil, nil
+		}
+
+		if len(args.NotificationChannelIDs) > 0 {
+			channels, err := fetchNotificationChannels(ctx, client, cfg)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch notification channels: %w", err)
+			}
+			known := make(map[int]bool, len(channels))
+			for _, ch := range channels {
+				known[ch.ID] = true
+			}
+			for _, id := range args.NotificationChannelIDs {
+				if !known[id] {
+					return toolErrorResult(fmt.Sprintf("notification channel %d not found; list channels with get_notification_channels", id)), nil, nil
+				}
+			}
+		}
+
+		kpi := alertRuleKPIRequest{Name: args.RuleName, KPIType: "custom"}
+		kpi.Definition.Source = "promql"
+		kpi.Definition.Query = args.Query
+		kpi.Definition.Unit = args.Unit
+
+		// An update keeps the rule's existing indicator KPI when it has one.
+		var kpiID string
+		if args.RuleID != "" {
+			rules, err := fetchEntityAlertRules(ctx, client, cfg, args.EntityID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch alert rules: %w", err)
+			}
+			var existing *AlertRule
+			for i := range rules {
+				if rules[i].ID == args.RuleID {
+					existing = &rules[i]
+					break
+				}
+			}
+			if existing == nil {
+				return toolErrorResult(fmt.Sprintf("alert rule %s not found in alert group %s", args.RuleID, args.EntityID)), nil, nil
+			}
+			kpiID = existing.ExpressionArgs[existing.PrimaryIndicator].ID
+		}
+
+		if kpiID != "" {
+			if err := sendAlertingJSON(ctx, client, cfg, http.MethodPut, fmt.Sprintf(constants.EndpointEntityKPI, args.EntityID, kpiID), kpi, nil); err != nil {
+				return nil, nil, fmt.Errorf("failed to update indicator: %w", err)
+			}
+		} else {
+			var created struct {
+				ID string `json:"id"`
+			}
+			if err := sendAlertingJSON(ctx, client, cfg, http.MethodPost, fmt.Sprintf(constants.EndpointEntityKPIs, args.EntityID), kpi, &created); err != nil {
+				return nil, nil, fmt.Errorf("failed to create indicator: %w", err)
+			}
+			if created.ID == "" {
+				return nil, nil, fmt.Errorf("failed to create indicator: response has no id")
+			}
+			kpiID = created.ID
+		}
+
+		rule := buildAlertRuleRequest(args, kpiID)
+		var saved struct {
+			ID string `json:"id"`
+		}
+		action := "created"
+		if args.RuleID != "" {
+			action = "updated"
+			saved.ID = args.RuleID
+			err := sendAlertingJSON(ctx, client, cfg, http.MethodPut, fmt.Sprintf(constants.EndpointEntityAlertRule, args.EntityID, args.RuleID), rule, nil)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to update alert rule: %w", err)
+			}
+		} else if err := sendAlertingJSON(ctx, client, cfg, http.MethodPost, fmt.Sprintf(constants.EndpointEntityAlertRules, args.EntityID), rule, &saved); err != nil {
+			return nil, nil, fmt.Errorf("failed to create alert rule: %w", err)
+		}
+
+		resultJSON, err := json.Marshal(map[string]any{
+			action:             true,
+			"rule_id":          saved.ID,
+			"entity_id":        args.EntityID,
+			"rule_name":        rule.RuleName,
+			"query":            args.Query,
+			"expression":       rule.Expression,
+			"alert_condition":  rule.AlertCondition,
+			"eval_window":      rule.EvalWindow,
+			"severity":         rule.Severity,
+			"indicator_kpi_id": kpiID,
+			"note":             fmt.Sprintf("Use get_entity_alert_rules with entity_id=%q to review the rule.", args.EntityID),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		return &mcp.CallToolResult{
+			Meta:    deeplink.ToMeta(dlBuilder.BuildAlertingGroupsLink()),
+			Content: []mcp.Content{&mcp.TextContent{Text: string(resultJSON)}},
+		}, nil, nil
+	}
+}
+
+// sendAlertingJSON sends body as JSON to path with method and, when out is
+// non-nil, decodes the response into it.
+func sendAlertingJSON(ctx context.Context, client utils.HTTPClient, cfg models.Config, method, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, cfg.APIBaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set(constants.HeaderContentType, constants.HeaderContentTypeJSON)
+	httpReq.Header.Set(constants.HeaderAccept, constants.HeaderAcceptJSON)
+	httpReq.Header.Set(constants.HeaderXLast9APIToken, constants.BearerPrefix+cfg.TokenManager.GetAccessToken(ctx))
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxAlertRuleErrorBodyBytes))
+		return fmt.Errorf("API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestCreateAlertRule_Create(t *testing.T) {
+	var rule alertRuleRequest
+	var kpi alertRuleKPIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/notification_settings":
+			_ = json.NewEncoder(w).Encode([]NotificationChannel{{ID: 7, Name: "oncall"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/entities/g1/kpis":
+			_ = json.NewDecoder(r.Body).Decode(&kpi)
+			_, _ = w.Write([]byte(`{"id":"k1"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/entities/g1/alert-rules":
+			_ = json.NewDecoder(r.Body).Decode(&rule)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"r1"}`))
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "mock-token", ExpiresAt: time.Now().Add(time.Hour)}
+	handler := NewCreateAlertRuleHandler(server.Client(), cfg)
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, CreateAlertRuleArgs{
+		EntityID:               "g1",
+		RuleName:               "checkout 5xx",
+		Query:                  `sum(rate(http_requests_total{service="checkout",status=~"5.."}[1m]))`,
+		Operator:               ">",
+		Threshold:              2.5,
+		Severity:               "Breach",
+		AlertAfterMinutes:      3,
+		NotificationChannelIDs: []int{7},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+	if kpi.Definition.Query == "" || kpi.Definition.Source != "promql" {
+		t.Errorf("kpi = %+v, want the PromQL query", kpi)
+	}
+	if rule.Expression != "indicator > 2.5" || rule.AlertCondition != "count_true(indicator > 2.5) >= 3" || rule.EvalWindow != 5 || rule.Severity != "breach" {
+		t.Errorf("rule = %+v", rule)
+	}
+	if rule.ExpressionArgs[rule.PrimaryIndicator].ID != "k1" {
+		t.Errorf("rule indicator = %+v, want KPI k1", rule.ExpressionArgs)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, `"rule_id":"r1"`) || !strings.Contains(text, `"created":true`) {
+		t.Errorf("result = %s", text)
+	}
+}
+
+func TestCreateAlertRule_UpdateReusesIndicator(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"rules":[{"id":"r1","primary_indicator":"errors","expression_args":{"errors":{"id":"k9"}}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "mock-token", ExpiresAt: time.Now().Add(time.Hour)}
+	handler := NewCreateAlertRuleHandler(server.Client(), cfg)
+	args := CreateAlertRuleArgs{EntityID: "g1", RuleID: "r1", RuleName: "errors", Query: "sum(errors)", Operator: ">=", Threshold: 1, Severity: "threat"}
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil || result.IsError {
+		t.Fatalf("update failed: %v %v", err, result)
+	}
+	want := "GET /entities/g1/alert-rules,PUT /entities/g1/kpis/k9,PUT /entities/g1/alert-rules/r1"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+
+	args.RuleID = "missing"
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil || !result.IsError {
+		t.Errorf("unknown rule_id: want a tool error, got %v %v", result, err)
+	}
+}
+
+func TestValidateCreateAlertRuleArgs(t *testing.T) {
+	valid := CreateAlertRuleArgs{EntityID: "g1", RuleName: "n", Query: "up", Operator: "<", Severity: "threat"}
+	if msg := validateCreateAlertRuleArgs(&valid); msg != "" {
+		t.Fatalf("valid args rejected: %s", msg)
+	}
+	if valid.EvalWindowMinutes != 5 || valid.AlertAfterMinutes != 5 {
+		t.Errorf("defaults = %d/%d, want 5/5", valid.EvalWindowMinutes, valid.AlertAfterMinutes)
+	}
+	for _, mutate := range []func(*CreateAlertRuleArgs){
+		func(a *CreateAlertRuleArgs) { a.EntityID = " " },
+		func(a *CreateAlertRuleArgs) { a.Query = "" },
+		func(a *CreateAlertRuleArgs) { a.Operator = "" },
+		func(a *CreateAlertRuleArgs) { a.Severity = " " },
+		func(a *CreateAlertRuleArgs) { a.EvalWindowMinutes = 61 },
+		func(a *CreateAlertRuleArgs) { a.EvalWindowMinutes, a.AlertAfterMinutes = 5, 6 },
+	} {
+		args := CreateAlertRuleArgs{EntityID: "g1", RuleName: "n", Query: "up", Operator: "<", Severity: "threat"}
+		mutate(&args)
+		if msg := validateCreateAlertRuleArgs(&args); msg == "" {
+			t.Errorf("%+v: expected a validation error", args)
+		}
+	}
+}
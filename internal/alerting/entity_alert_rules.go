@@ -9,9 +9,10 @@ import (
 	"sort"
 	"strings"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -23,7 +24,7 @@ type GetEntityAlertRulesArgs struct {
 }
 
 // NewGetEntityAlertRulesHandler returns the MCP tool handler for get_entity_alert_rules.
-func NewGetEntityAlertRulesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetEntityAlertRulesArgs) (*mcp.CallToolResult, any, error) {
+func NewGetEntityAlertRulesHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetEntityAlertRulesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, _ *mcp.CallToolRequest, args GetEntityAlertRulesArgs) (*mcp.CallToolResult, any, error) {
 		entityID := strings.TrimSpace(args.EntityID)
 		if entityID == "" {
@@ -61,7 +62,7 @@ func NewGetEntityAlertRulesHandler(client *http.Client, cfg models.Config) func(
 
 func fetchEntityAlertRules(
 	ctx context.Context,
-	client *http.Client,
+	client utils.HTTPClient,
 	cfg models.Config,
 	entityID string,
 ) (AlertConfigResponse, error) {
@@ -9,9 +9,10 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -43,15 +44,26 @@ type notificationChannelService struct {
 	Namespace string `json:"namespace"`
 }
 
-type GetNotificationChannelsArgs struct{}
+type GetNotificationChannelsArgs struct {
+	ServiceName string `json:"service_name,omitempty" jsonschema:"Only channels that receive this service's alerts: global channels plus channels scoped to the service (optional)"`
+	Severity    string `json:"severity,omitempty" jsonschema:"Only channels that receive alerts of this severity, e.g. breach or threat; channels with no severity receive all (optional)"`
+}
 
-func NewGetNotificationChannelsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetNotificationChannelsArgs) (*mcp.CallToolResult, any, error) {
+func NewGetNotificationChannelsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetNotificationChannelsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetNotificationChannelsArgs) (*mcp.CallToolResult, any, error) {
 		channels, err := fetchNotificationChannels(ctx, client, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		text := formatNotificationChannelsResponse(channels)
+		if args.ServiceName != "" || args.Severity != "" {
+			routed := filterNotificationChannels(channels, args)
+			title := fmt.Sprintf("Found %d notification channel(s) routing %s (of %d configured):",
+				len(routed), describeNotificationRoute(args), len(channels))
+			text = formatNotificationChannelsTable(title, routed)
+		}
+
 		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
 		dashboardURL := dlBuilder.BuildNotificationChannelsLink()
 
@@ -59,14 +71,14 @@ func NewGetNotificationChannelsHandler(client *http.Client, cfg models.Config) f
 			Meta: deeplink.ToMeta(dashboardURL),
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: formatNotificationChannelsResponse(channels),
+					Text: text,
 				},
 			},
 		}, nil, nil
 	}
 }
 
-func fetchNotificationChannels(ctx context.Context, client *http.Client, cfg models.Config) ([]NotificationChannel, error) {
+func fetchNotificationChannels(ctx context.Context, client utils.HTTPClient, cfg models.Config) ([]NotificationChannel, error) {
 	url := cfg.APIBaseURL + constants.EndpointNotificationSettings
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -112,9 +124,55 @@ func fetchNotificationChannels(ctx context.Context, client *http.Client, cfg mod
 	return channels, nil
 }
 
+// filterNotificationChannels returns the channels an alert for args'
+// service and severity is delivered to. A channel receives a service's alerts
+// when it is global or lists the service (by name, or namespace/name); it
+// receives a severity when its own severity is unset or equal. Snoozed
+// channels are kept so callers can see why nobody was notified.
+func filterNotificationChannels(channels []NotificationChannel, args GetNotificationChannelsArgs) []NotificationChannel {
+	service := strings.TrimSpace(args.ServiceName)
+	severity := strings.TrimSpace(args.Severity)
+
+	out := make([]NotificationChannel, 0, len(channels))
+	for _, ch := range channels {
+		if severity != "" && ch.Severity != "" && !strings.EqualFold(ch.Severity, severity) {
+			continue
+		}
+		if service != "" && !ch.Global && !notificationChannelHasService(ch, service) {
+			continue
+		}
+		out = append(out, ch)
+	}
+	return out
+}
+
+func notificationChannelHasService(ch NotificationChannel, service string) bool {
+	for _, svc := range ch.Services {
+		if strings.EqualFold(svc.Name, service) || strings.EqualFold(svc.Namespace+"/"+svc.Name, service) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeNotificationRoute(args GetNotificationChannelsArgs) string {
+	var parts []string
+	if args.ServiceName != "" {
+		parts = append(parts, "service "+args.ServiceName)
+	}
+	if args.Severity != "" {
+		parts = append(parts, "severity "+args.Severity)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func formatNotificationChannelsResponse(channels []NotificationChannel) string {
+	return formatNotificationChannelsTable(fmt.Sprintf("Found %d notification channel(s):", len(channels)), channels)
+}
+
+func formatNotificationChannelsTable(title string, channels []NotificationChannel) string {
 	rows := make([]string, 0, len(channels)+2)
-	rows = append(rows, fmt.Sprintf("Found %d notification channel(s):", len(channels)))
+	rows = append(rows, title)
 	rows = append(rows, "id\tname\ttype\tglobal\tin_use\tsend_resolved\tsnoozed_until\tseverity\tpriority\tservices")
 
 	for _, ch := range channels {
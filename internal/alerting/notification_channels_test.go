@@ -9,10 +9,10 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -296,6 +296,44 @@ func TestGetNotificationChannelsHandler_DeepLink(t *testing.T) {
 	}
 }
 
+func TestGetNotificationChannelsHandler_Routing(t *testing.T) {
+	channels := []NotificationChannel{
+		{ID: 1, Name: "oncall-global", Type: "pagerduty", Global: true, Severity: "breach"},
+		{ID: 2, Name: "payments-slack", Type: "slack", Services: []notificationChannelService{{Name: "payments", Namespace: "prod"}}},
+		{ID: 3, Name: "api-email", Type: "email", Services: []notificationChannelService{{Name: "api"}}},
+		{ID: 4, Name: "all-threats", Type: "slack", Global: true, Severity: "threat"},
+	}
+
+	tests := []struct {
+		name    string
+		args    GetNotificationChannelsArgs
+		wantIDs []string
+		title   string
+	}{
+		{"service", GetNotificationChannelsArgs{ServiceName: "payments"}, []string{"1", "2", "4"}, "Found 3 notification channel(s) routing service payments (of 4 configured):"},
+		{"namespaced service", GetNotificationChannelsArgs{ServiceName: "prod/payments"}, []string{"1", "2", "4"}, ""},
+		{"severity", GetNotificationChannelsArgs{Severity: "BREACH"}, []string{"1", "2", "3"}, ""},
+		{"service and severity", GetNotificationChannelsArgs{ServiceName: "api", Severity: "threat"}, []string{"3", "4"}, "Found 2 notification channel(s) routing service api, severity threat (of 4 configured):"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, _, err := executeGetNotificationChannelsWithArgs(t, channels, tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rows := assertStableNotificationChannelsTSV(t, text, len(tt.wantIDs))
+			for i, cols := range rows {
+				if cols[0] != tt.wantIDs[i] {
+					t.Fatalf("row %d id = %s, want %s\n%s", i, cols[0], tt.wantIDs[i], text)
+				}
+			}
+			if tt.title != "" && !strings.HasPrefix(text, tt.title) {
+				t.Fatalf("title mismatch:\n%s", text)
+			}
+		})
+	}
+}
+
 // executeGetNotificationChannels spins up a mock API server, calls the handler, and returns
 // the text response, the full result, and any error.
 func executeGetNotificationChannels(
@@ -305,6 +343,10 @@ func executeGetNotificationChannels(
 ) (string, *mcp.CallToolResult, error) {
 	t.Helper()
 
+	if statusCode == http.StatusOK {
+		return executeGetNotificationChannelsWithArgs(t, channels, GetNotificationChannelsArgs{})
+	}
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != constants.EndpointNotificationSettings {
 			http.NotFound(w, r)
@@ -331,6 +373,34 @@ func executeGetNotificationChannels(
 	return utils.GetTextContent(t, result), result, nil
 }
 
+// executeGetNotificationChannelsWithArgs is executeGetNotificationChannels for a
+// successful response, passing args to the handler.
+func executeGetNotificationChannelsWithArgs(
+	t *testing.T,
+	channels []NotificationChannel,
+	args GetNotificationChannelsArgs,
+) (string, *mcp.CallToolResult, error) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.EndpointNotificationSettings {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(channels)
+	}))
+	defer server.Close()
+
+	handler := NewGetNotificationChannelsHandler(server.Client(), newTestNotificationChannelsConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		return "", result, err
+	}
+
+	return utils.GetTextContent(t, result), result, nil
+}
+
 func newTestNotificationChannelsConfig(apiBaseURL string) models.Config {
 	cfg := models.Config{
 		APIBaseURL: apiBaseURL,
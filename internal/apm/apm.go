@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/export"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -30,6 +32,15 @@ func firstNonEmpty(vals ...string) string {
 type ServiceSummary struct {
 	Throughput, ErrorRate, ResponseTime float64
 	ServiceName, Env                    string
+	// Set with include_sparklines: requests per minute in sparklineBuckets
+	// equal buckets over the window, oldest first; null where there was no
+	// sample.
+	ThroughputSparkline []*float64 `json:",omitempty"`
+	ErrorRateSparkline  []*float64 `json:",omitempty"`
+	// Set with extrapolate_sampling: the fraction of traces the service
+	// keeps, and whether Throughput and ErrorRate were divided by it.
+	SamplingRate float64 `json:",omitempty"`
+	Extrapolated bool    `json:",omitempty"`
 }
 
 type apiPromInstantResp []struct {
@@ -44,10 +55,17 @@ type apiPromRangeResp []struct {
 
 // Input structs for MCP SDK handlers
 type ServiceSummaryArgs struct {
-	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
-	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
-	Env             string  `json:"env,omitempty" jsonschema:"Environment to filter by (default: .*, e.g. prod)"`
+	StartTimeISO        string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO          string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes     float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Env                 string  `json:"env,omitempty" jsonschema:"Environment to filter by (e.g. prod). Default: the server default env if configured, else .* (all)."`
+	View                string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	SortBy              string  `json:"sort_by,omitempty" jsonschema:"Rank services highest first by this metric, error_percent by default. Setting any of sort_by, top_n, min_error_percent or offset returns a ranked list instead of a map (optional, one of: error_percent, error_rate, latency, throughput)"`
+	TopN                int     `json:"top_n,omitempty" jsonschema:"Maximum ranked services to return (default: 20, max: 200)"`
+	MinErrorPercent     float64 `json:"min_error_percent,omitempty" jsonschema:"Keep only services whose 5xx share of requests is at least this percentage (0-100)"`
+	Offset              int     `json:"offset,omitempty" jsonschema:"Number of ranked services to skip, for pagination (default: 0)"`
+	IncludeSparklines   bool    `json:"include_sparklines,omitempty" jsonschema:"Add ThroughputSparkline and ErrorRateSparkline per service: requests per minute in 12 equal buckets over the window, to tell a spike from a steady rate (default: false)"`
+	ExtrapolateSampling bool    `json:"extrapolate_sampling,omitempty" jsonschema:"Divide Throughput and ErrorRate by each service's trace sampling rate, from the server's configured rates or sampling rate metric, to estimate traffic before head sampling. Extrapolated services are marked (default: false)"`
 }
 
 type ServiceEnvironmentsArgs struct {
@@ -55,45 +73,59 @@ type ServiceEnvironmentsArgs struct {
 	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
 	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
 	ServiceName     string  `json:"service_name,omitempty" jsonschema:"Optional service name to filter environments for (e.g. my-api). When omitted, returns environments across all services."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
 }
 
 type ServicePerformanceDetailsArgs struct {
-	ServiceName     string  `json:"service_name" jsonschema:"Name of the service to get performance details for (required)"`
-	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
-	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
-	Env             string  `json:"env,omitempty" jsonschema:"Environment to filter by (default: .*, e.g. prod)"`
+	ServiceName     string   `json:"service_name" jsonschema:"Name of the service to get performance details for (required)"`
+	StartTimeISO    string   `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string   `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64  `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Env             string   `json:"env,omitempty" jsonschema:"Environment to filter by (e.g. prod). Default: the server default env if configured, else .* (all)."`
+	View            string   `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	Quantiles       []string `json:"quantiles,omitempty" jsonschema:"Response-time quantiles to report: p50, p75, p90, p95, p99, p999, avg, max (default: the server's configured set, else p50, p90, p95, avg and max)"`
+	IncludeInfra    bool     `json:"include_infra,omitempty" jsonschema:"Add container CPU, throttling, memory, OOM kills and restarts for the Kubernetes pods that served the service's spans (default: false)"`
 }
 
 type ServiceOperationsSummaryArgs struct {
-	ServiceName     string  `json:"service_name" jsonschema:"Name of the service to get operations summary for (required)"`
-	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
-	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
-	Env             string  `json:"env,omitempty" jsonschema:"Environment to filter by (default: .*, e.g. prod)"`
+	ServiceName     string          `json:"service_name" jsonschema:"Name of the service to get operations summary for (required)"`
+	StartTimeISO    string          `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string          `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64         `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Env             string          `json:"env,omitempty" jsonschema:"Environment to filter by (e.g. prod). Default: the server default env if configured, else .* (all)."`
+	View            string          `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
+	Quantiles       []string        `json:"quantiles,omitempty" jsonschema:"Response-time quantiles to report: p50, p75, p90, p95, p99, p999, avg, max (default: the server's configured set, else p50, p90, p95, avg and max)"`
 }
 
 type ServiceDependencyGraphArgs struct {
-	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
-	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
-	Env             string  `json:"env,omitempty" jsonschema:"Environment to filter by (default: .*, e.g. prod)"`
-	ServiceName     string  `json:"service_name,omitempty" jsonschema:"Service name to focus on in the dependency graph (e.g. api-service)"`
+	StartTimeISO    string          `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string          `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64         `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Env             string          `json:"env,omitempty" jsonschema:"Environment to filter by (e.g. prod). Default: the server default env if configured, else .* (all)."`
+	View            string          `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	ServiceName     string          `json:"service_name,omitempty" jsonschema:"Service name to focus on in the dependency graph (e.g. api-service)"`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
+	Quantiles       []string        `json:"quantiles,omitempty" jsonschema:"Response-time quantiles to report: p50, p75, p90, p95, p99, p999, avg, max (default: the server's configured set, else p50, p90, p95, avg and max)"`
+	MinThroughput   float64         `json:"min_throughput,omitempty" jsonschema:"Drop edges, databases and messaging systems with fewer calls per minute than this, to hide sampling noise (optional, e.g. 0.5)"`
 }
 
 type PromqlRangeQueryArgs struct {
-	Query           string  `json:"query" jsonschema:"PromQL query to execute (required)"`
-	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
-	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
-	Datasource      string  `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+	Query           string          `json:"query" jsonschema:"PromQL query to execute (required)"`
+	StartTimeISO    string          `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string          `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64         `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Datasource      string          `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+	Encoding        string          `json:"encoding,omitempty" jsonschema:"Result encoding: json returns raw [timestamp, value] pairs per series (default), compact lists timestamps once with per-series value arrays aligned to them (one of: json, compact)"`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
 }
 
 type PromqlInstantQueryArgs struct {
-	Query           string  `json:"query" jsonschema:"PromQL query to execute (required)"`
-	TimeISO         string  `json:"time_iso,omitempty" jsonschema:"Evaluation time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). If omitted, defaults to now or now-lookback_minutes."`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now when time_iso is omitted (default: 0, minimum: 1)."`
-	Datasource      string  `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+	Query           string          `json:"query" jsonschema:"PromQL query to execute (required)"`
+	TimeISO         string          `json:"time_iso,omitempty" jsonschema:"Evaluation time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). If omitted, defaults to now or now-lookback_minutes."`
+	LookbackMinutes float64         `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now when time_iso is omitted (default: 0, minimum: 1)."`
+	Datasource      string          `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
 }
 
 type PromqlLabelValuesArgs struct {
@@ -116,18 +148,11 @@ type PromqlLabelsArgs struct {
 }
 
 func resolveTimeRange(startTimeISO, endTimeISO string, lookbackMinutes float64) (int64, int64, error) {
-	params := map[string]interface{}{}
-	if startTimeISO != "" {
-		params["start_time_iso"] = startTimeISO
-	}
-	if endTimeISO != "" {
-		params["end_time_iso"] = endTimeISO
-	}
-	if lookbackMinutes != 0 {
-		params["lookback_minutes"] = lookbackMinutes
-	}
-
-	startTime, endTime, err := utils.GetTimeRange(params, utils.DefaultLookbackMinutes)
+	startTime, endTime, err := utils.TimeRange{
+		StartTimeISO:    startTimeISO,
+		EndTimeISO:      endTimeISO,
+		LookbackMinutes: lookbackMinutes,
+	}.Resolve(utils.DefaultLookbackMinutes)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -135,11 +160,27 @@ func resolveTimeRange(startTimeISO, endTimeISO string, lookbackMinutes float64)
 	return startTime.Unix(), endTime.Unix(), nil
 }
 
+// resolveEnv returns the env label pattern APM queries filter by: the
+// requested env, else the configured default env, else every environment.
+func resolveEnv(cfg models.Config, env string) string {
+	if env := defaultEnv(cfg, env); env != "" {
+		return env
+	}
+	return ".*"
+}
+
+// defaultEnv returns the requested env, else the configured default env.
+// An empty result means every environment.
+func defaultEnv(cfg models.Config, env string) string {
+	if env != "" {
+		return env
+	}
+	return cfg.DefaultEnv
+}
+
 func resolveInstantQueryTime(timeISO string, lookbackMinutes float64) (int64, error) {
 	if timeISO != "" {
-		_, endTime, err := utils.GetTimeRange(map[string]interface{}{
-			"end_time_iso": timeISO,
-		}, utils.DefaultLookbackMinutes)
+		_, endTime, err := utils.TimeRange{EndTimeISO: timeISO}.Resolve(utils.DefaultLookbackMinutes)
 		if err != nil {
 			return 0, fmt.Errorf("invalid time_iso format: %w", err)
 		}
@@ -147,9 +188,7 @@ func resolveInstantQueryTime(timeISO string, lookbackMinutes float64) (int64, er
 	}
 
 	if lookbackMinutes != 0 {
-		startTime, _, err := utils.GetTimeRange(map[string]interface{}{
-			"lookback_minutes": lookbackMinutes,
-		}, utils.DefaultLookbackMinutes)
+		startTime, _, err := utils.TimeRange{LookbackMinutes: lookbackMinutes}.Resolve(utils.DefaultLookbackMinutes)
 		if err != nil {
 			return 0, err
 		}
@@ -159,18 +198,33 @@ func resolveInstantQueryTime(timeISO string, lookbackMinutes float64) (int64, er
 	return time.Now().UTC().Unix(), nil
 }
 
-func NewServiceSummaryHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceSummaryArgs) (*mcp.CallToolResult, any, error) {
+// TimeRange resolves the query window to Unix seconds.
+func (a PromqlRangeQueryArgs) TimeRange() (int64, int64, error) {
+	return resolveTimeRange(a.StartTimeISO, a.EndTimeISO, a.LookbackMinutes)
+}
+
+// EvalTime resolves the evaluation time to Unix seconds.
+func (a PromqlInstantQueryArgs) EvalTime() (int64, error) {
+	return resolveInstantQueryTime(a.TimeISO, a.LookbackMinutes)
+}
+
+func NewServiceSummaryHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceSummaryArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args ServiceSummaryArgs) (*mcp.CallToolResult, any, error) {
 		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		// Accept env from parameters if provided
-		env := args.Env
-		if env == "" {
-			env = ".*" // default value
+		var sortBy string
+		var topN int
+		if args.wantsFleetOverview() {
+			if sortBy, topN, err = validateFleetArgs(args); err != nil {
+				return nil, nil, err
+			}
 		}
+
+		// Accept env from parameters, falling back to the configured default
+		env := resolveEnv(cfg, args.Env)
 		// get the value of service througputs using the query
 		// quantile_over_time(0.95, sum by (service_name)(trace_endpoint_count{service_name=~'.*', env=~'prod', span_kind=~'SPAN_KIND_SERVER|SPAN_KIND_CLIENT'})[30m])
 		// add the filter values in the promql from the filterParams
@@ -220,7 +274,7 @@ func NewServiceSummaryHandler(client *http.Client, cfg models.Config) func(conte
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					&mcp.TextContent{
-						Text: "No services found for the given parameters",
+						Text: fmt.Sprintf("No services found for the given parameters (env=~%q)", env),
 					},
 				},
 			}, nil, nil
@@ -301,7 +355,31 @@ func NewServiceSummaryHandler(client *http.Client, cfg models.Config) func(conte
 				}
 			}
 		}
-		returnText, err := json.Marshal(promResp)
+		var caveats []string
+		if args.IncludeSparklines {
+			caveats = addServiceSparklines(ctx, client, cfg, promResp, env, startTimeParam, endTimeParam)
+		}
+		if args.ExtrapolateSampling {
+			caveats = append(caveats, extrapolateSampling(ctx, client, cfg, promResp, env, startTimeParam, endTimeParam)...)
+		}
+		meta := buildResponseMeta(checkFreshness(ctx, client, cfg, startTimeParam, endTimeParam,
+			fmt.Sprintf("trace_endpoint_count{env=~'%s', span_kind='SPAN_KIND_SERVER'}", env),
+			fmt.Sprintf("trace_service_response_time{env=~'%s'}", env),
+		), caveats...)
+		var response any
+		if args.wantsFleetOverview() {
+			overview := rankServices(promResp, sortBy, args.MinErrorPercent, args.Offset, topN)
+			overview.Meta = meta
+			response = overview
+		} else {
+			summaries := make(map[string]any, len(promResp)+1)
+			for name, summary := range promResp {
+				summaries[name] = summary
+			}
+			summaries["_meta"] = meta
+			response = summaries
+		}
+		returnText, err := json.Marshal(response)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
 		}
@@ -337,6 +415,10 @@ type PromRangeResponse struct {
 }
 
 func parsePromTimeSeries(respBody []byte) ([]TimeSeries, error) {
+	respBody, _, err := unwrapPromBody(respBody)
+	if err != nil {
+		return nil, err
+	}
 	var promResp []PromRangeResponse
 	var resp []TimeSeries
 	if err := json.Unmarshal(respBody, &promResp); err != nil {
@@ -379,6 +461,7 @@ type ServiceOperationsSummaryResponse struct {
 	ServiceName string                    `json:"service_name"`
 	Env         string                    `json:"env"`
 	Operations  []ServiceOperationSummary `json:"operations"`
+	Meta        *ResponseMeta             `json:"_meta,omitempty"`
 }
 
 type ServiceOperationSummary struct {
@@ -401,17 +484,19 @@ type ServicePerformanceDetails struct {
 	Throughput    []TimeSeries `json:"throughput"` // by status code
 	ErrorRate     []TimeSeries `json:"error_rate"` // by status code
 	ErrorPercent  []TimeSeries `json:"error_percentage"`
-	ResponseTimes []TimeSeries `json:"response_times"` // p50, p90, p95, avg, max
+	ResponseTimes []TimeSeries `json:"response_times"` // one series per selected quantile
 	ApdexScore    []TimeSeries `json:"apdex_score"`
 	Availability  []TimeSeries `json:"availability"`
 	TopOperations struct {
 		ByResponseTime []map[string]float64 `json:"by_response_time"`
 		ByErrorRate    []map[string]int64   `json:"by_error_rate"`
 	} `json:"top_operations"`
-	TopErrors []map[string]int64 `json:"top_errors"`
+	TopErrors []models.ErrorEntry `json:"top_errors"`
+	Infra     *ServiceInfra       `json:"infra,omitempty"` // set when include_infra is true
+	Meta      *ResponseMeta       `json:"_meta,omitempty"`
 }
 
-func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServicePerformanceDetailsArgs) (*mcp.CallToolResult, any, error) {
+func NewServicePerformanceDetailsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServicePerformanceDetailsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args ServicePerformanceDetailsArgs) (*mcp.CallToolResult, any, error) {
 		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
@@ -419,9 +504,10 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 		}
 
 		// Handle environment
-		env := args.Env
-		if env == "" {
-			env = ".*"
+		env := resolveEnv(cfg, args.Env)
+		quantiles, err := resolveQuantiles(cfg, args.Quantiles)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		// Handle service_name
@@ -429,6 +515,11 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 		if serviceName == "" {
 			return nil, nil, fmt.Errorf("service_name is required")
 		}
+		steps := 9
+		if args.IncludeInfra {
+			steps++
+		}
+		progress := utils.NewProgressReporter(req, steps)
 
 		timeRange := fmt.Sprintf("%dm", int((endTimeParam-startTimeParam)/60))
 
@@ -436,18 +527,28 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			ServiceName: serviceName,
 			Env:         env,
 		}
+		var caveats []string
+		// Throughput, availability and the error series use "default", so
+		// they always return a series and are not tracked.
+		var checks dataChecks
 
 		// Get Apdex Score over time range as a vector
 		apdexQuery := fmt.Sprintf(
 			"sum(trace_service_apdex_score{service_name='%s', env=~'%s'})",
 			serviceName, env,
 		)
+		if err := progress.Step(ctx, "apdex score"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err := utils.MakePromRangeAPIQuery(ctx, client, apdexQuery, startTimeParam, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("apdex_score unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			data, err := io.ReadAll(httpResp.Body)
 			if err != nil {
@@ -458,19 +559,26 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 				return nil, nil, fmt.Errorf("failed to parse apdex score: %w", err)
 			}
 			details.ApdexScore = seriesList
+			checks.record("apdex_score", subQueryTraffic, len(seriesList))
 		}
 
 		// Get Response Times - keep vector output
 		rtQuery := fmt.Sprintf(
-			"sum by (quantile) (trace_service_response_time{service_name='%s', env='%s'}[%s])",
-			serviceName, env, timeRange,
+			"sum by (quantile) (trace_service_response_time{service_name='%s', env='%s', %s}[%s])",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
+		if err := progress.Step(ctx, "response time percentiles"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromRangeAPIQuery(ctx, client, rtQuery, startTimeParam, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("response_times unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			data, err := io.ReadAll(httpResp.Body)
 			if err != nil {
@@ -481,6 +589,7 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 				return nil, nil, fmt.Errorf("failed to parse response times: %w", err)
 			}
 			details.ResponseTimes = seriesList
+			checks.record("response_times", subQueryTraffic, len(seriesList))
 		}
 
 		// Get Availability over time range as a vector
@@ -488,12 +597,18 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			"(1 - (sum(rate(trace_endpoint_count{service_name='%s', env='%s', span_kind='SPAN_KIND_SERVER', http_status_code=~'4.*|5.*'}[%s])) or 0) / (sum(rate(trace_endpoint_count{service_name='%s', env='%s', span_kind='SPAN_KIND_SERVER'}[%s])) + 0.0000001)) * 100 default -999",
 			serviceName, env, timeRange, serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "availability"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromRangeAPIQuery(ctx, client, availQuery, startTimeParam, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("availability unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			data, err := io.ReadAll(httpResp.Body)
 			if err != nil {
@@ -511,12 +626,18 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			"sum by (http_status_code)(rate(trace_endpoint_count{service_name='%s', env='%s', span_kind='SPAN_KIND_SERVER'}[%s])) * 60 default 0",
 			serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "throughput"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromRangeAPIQuery(ctx, client, throughputQuery, startTimeParam, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("throughput unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			// read response body to byte array
 			data, err := io.ReadAll(httpResp.Body)
@@ -535,12 +656,18 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			"sum by (service_name, http_status_code)(rate(trace_endpoint_count{service_name='%s', env='%s', span_kind='SPAN_KIND_SERVER', http_status_code=~'4.*|5.*'}[%s])) * 60 default 0",
 			serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "error rate"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromRangeAPIQuery(ctx, client, errorRateQuery, startTimeParam, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("error_rate unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			data, err := io.ReadAll(httpResp.Body)
 			if err != nil {
@@ -557,12 +684,18 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			"(sum(rate(trace_endpoint_count{service_name='%s', env='%s', span_kind='SPAN_KIND_SERVER', http_status_code=~'4.*|5.*'}[%s])) / sum(rate(trace_endpoint_count{service_name='%s', env='%s', span_kind='SPAN_KIND_SERVER'}[%s])) * 100) default 0",
 			serviceName, env, timeRange, serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "error percentage"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromRangeAPIQuery(ctx, client, errorPercentQuery, startTimeParam, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("error_percentage unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			data, err := io.ReadAll(httpResp.Body)
 			if err != nil {
@@ -579,16 +712,23 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			"topk(10, quantile_over_time(0.95, sum by (span_name, messaging_system, rpc_system, span_kind,net_peer_name,process_runtime_name,db_system)(trace_endpoint_duration{service_name='%s', span_kind!='SPAN_KIND_INTERNAL', env='%s', quantile='p95'}[%s])))",
 			serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "slowest endpoints"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, topRTQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("top_operations.by_response_time unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			var topErrResp apiPromInstantResp
 			if err := json.NewDecoder(httpResp.Body).Decode(&topErrResp); err == nil {
 				details.TopOperations.ByResponseTime = make([]map[string]float64, 0)
+				checks.record("top_operations.by_response_time", subQueryTraffic, len(topErrResp))
 				for _, r := range topErrResp {
 					// join values of r.Timeseries with a - to create a unique key
 					key := fmt.Sprintf("%s-%s-%s-%s-%s-%s-%s",
@@ -618,16 +758,23 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			 sum by (span_name, span_kind, net_peer_name, db_system, rpc_system, messaging_system, process_runtime_name, http_status_code)(sum_over_time(trace_endpoint_count{service_name="%s", env='%s', http_status_code=~"^[45].*"}[%s]))`,
 			serviceName, env, timeRange, serviceName, env, timeRange, serviceName, env, timeRange, serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "endpoints with most errors"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, topErrQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
 
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("top_operations.by_error_rate unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			var topErrResp apiPromInstantResp
 			if err := json.NewDecoder(httpResp.Body).Decode(&topErrResp); err == nil {
 				details.TopOperations.ByErrorRate = make([]map[string]int64, 0)
+				checks.record("top_operations.by_error_rate", subQueryErrors, len(topErrResp))
 				for _, r := range topErrResp {
 					// join values of r.Timeseries with a - to create a unique key
 					key := fmt.Sprintf("%s-%s-%s-%s-%s-%s-%s",
@@ -649,44 +796,60 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 			}
 		}
 
-		// Get Top 10 Errors - keep vector output
+		// Get Top 10 Errors by kind (exception type, HTTP status, span status) - keep vector output
 		topErrorsQuery := fmt.Sprintf(
-			`sum by (exception_type)(sum by (exception_type, span_kind)(sum_over_time(trace_client_count{service_name="%s", env='%s', exception_type!=''}[%s])) or
-			 sum by (exception_type, span_kind)(sum_over_time(trace_endpoint_count{service_name="%s", env='%s', exception_type!=''}[%s]))) or
-			 sum by (http_status_code)(sum by (http_status_code, span_kind)(sum_over_time(trace_client_count{service_name="%s", env='%s', http_status_code=~"^[45].*"}[%s])) or
-			 sum by (http_status_code, span_kind)(sum_over_time(trace_endpoint_count{service_name="%s", env='%s', http_status_code=~"^[45].*"}[%s])))`,
+			`sum by (exception_type, span_name)(sum by (exception_type, span_name, span_kind)(sum_over_time(trace_client_count{service_name="%s", env='%s', exception_type!=''}[%s])) or
+			 sum by (exception_type, span_name, span_kind)(sum_over_time(trace_endpoint_count{service_name="%s", env='%s', exception_type!=''}[%s]))) or
+			 sum by (http_status_code, span_name)(sum by (http_status_code, span_name, span_kind)(sum_over_time(trace_client_count{service_name="%s", env='%s', http_status_code=~"^[45].*"}[%s])) or
+			 sum by (http_status_code, span_name, span_kind)(sum_over_time(trace_endpoint_count{service_name="%s", env='%s', http_status_code=~"^[45].*"}[%s]))) or
+			 sum by (status_code, span_name)(sum by (status_code, span_name, span_kind)(sum_over_time(trace_client_count{service_name="%s", env='%s', status_code="STATUS_CODE_ERROR"}[%s])) or
+			 sum by (status_code, span_name, span_kind)(sum_over_time(trace_endpoint_count{service_name="%s", env='%s', status_code="STATUS_CODE_ERROR"}[%s])))`,
 			serviceName, env, timeRange, serviceName, env, timeRange, serviceName, env, timeRange, serviceName, env, timeRange,
+			serviceName, env, timeRange, serviceName, env, timeRange,
 		)
+		if err := progress.Step(ctx, "top errors"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, topErrorsQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
 		}
 		defer httpResp.Body.Close()
+		if httpResp.StatusCode != http.StatusOK {
+			caveats = append(caveats, fmt.Sprintf("top_errors unavailable: upstream returned %s", httpResp.Status))
+		}
 		if httpResp.StatusCode == http.StatusOK {
 			var topErrResp apiPromInstantResp
 			if err := json.NewDecoder(httpResp.Body).Decode(&topErrResp); err == nil {
-				details.TopErrors = make([]map[string]int64, 0)
-				for _, r := range topErrResp {
-					// join values of r.Timeseries with a - to create a unique key
-					// extract either exception_type or http_status_code
-					var key string
-					if exceptionType, ok := r.Metric["exception_type"]; ok && exceptionType != "" {
-						key = exceptionType
-					} else if httpStatusCode, ok := r.Metric["http_status_code"]; ok && httpStatusCode != "" {
-						key = httpStatusCode
-					} else {
-						continue // skip if neither is present
-					}
-					if valStr, ok := r.Value[1].(string); ok {
-						val, _ := strconv.ParseInt(valStr, 10, 64)
-						op := make(map[string]int64)
-						op[key] = val
-						details.TopErrors = append(details.TopErrors, op)
-					}
+				details.TopErrors = buildTopErrors(topErrResp)
+				checks.record("top_errors", subQueryErrors, len(topErrResp))
+				if len(details.TopErrors) > topErrorsLimit {
+					details.TopErrors = details.TopErrors[:topErrorsLimit]
+					caveats = append(caveats, fmt.Sprintf("top_errors is limited to the %d most frequent errors", topErrorsLimit))
 				}
 			}
 		}
 
+		if args.IncludeInfra {
+			if err := progress.Step(ctx, "pod resources"); err != nil {
+				return nil, nil, err
+			}
+			var infraCaveats []string
+			details.Infra, infraCaveats = fetchServiceInfra(ctx, client, cfg, serviceName, env, timeRange, endTimeParam)
+			caveats = append(caveats, infraCaveats...)
+		}
+
+		if len(details.TopOperations.ByResponseTime) == 10 {
+			caveats = append(caveats, "top_operations.by_response_time is limited to the 10 slowest operations")
+		}
+		details.Meta = buildResponseMeta(checkFreshness(ctx, client, cfg, startTimeParam, endTimeParam,
+			fmt.Sprintf("trace_endpoint_count{service_name='%s', env=~'%s', span_kind='SPAN_KIND_SERVER'}", serviceName, env),
+			fmt.Sprintf("trace_service_response_time{service_name='%s', env=~'%s'}", serviceName, env),
+			fmt.Sprintf("trace_service_apdex_score{service_name='%s', env=~'%s'}", serviceName, env),
+		), caveats...).withDataAvailability(serviceName, env, checks).
+			withServiceSuggestions(ctx, client, cfg, serviceName, env, startTimeParam, endTimeParam).
+			withWiderWindowProbe(ctx, client, cfg, startTimeParam, endTimeParam)
+
 		resultJSON, err := json.Marshal(details)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
@@ -707,22 +870,24 @@ func NewServicePerformanceDetailsHandler(client *http.Client, cfg models.Config)
 	}
 }
 
-func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceOperationsSummaryArgs) (*mcp.CallToolResult, any, error) {
+func NewServiceOperationsSummaryHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceOperationsSummaryArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args ServiceOperationsSummaryArgs) (*mcp.CallToolResult, any, error) {
 		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		env := args.Env
-		if env == "" {
-			env = ".*" // default environment
+		env := resolveEnv(cfg, args.Env)
+		quantiles, err := resolveQuantiles(cfg, args.Quantiles)
+		if err != nil {
+			return nil, nil, err
 		}
 		serviceName := args.ServiceName
 		if serviceName == "" {
 			return nil, nil, fmt.Errorf("service_name is required")
 		}
 		timeRange := fmt.Sprintf("%dm", int((endTimeParam-startTimeParam)/60))
+		var checks dataChecks
 		// Prepare the Prometheus query for throughput of endpoint operations
 		throughputQuery := fmt.Sprintf(
 			"sum by (span_name, span_kind)(sum_over_time(trace_endpoint_count{service_name='%s', span_kind='SPAN_KIND_SERVER', env=~'%s'}[%s])) / %d",
@@ -741,10 +906,11 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&promResp); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("operations.throughput", subQueryTraffic, len(promResp))
 		// Prepare the Prometheus query for response times of endpoint operations
 		respTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95, sum by (quantile, span_name, span_kind) (trace_endpoint_duration{service_name='%s', span_kind='SPAN_KIND_SERVER', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95, sum by (quantile, span_name, span_kind) (trace_endpoint_duration{service_name='%s', span_kind='SPAN_KIND_SERVER', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
 		// Prepare request to Prometheus (or your metrics backend)
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, respTimeQuery, endTimeParam, cfg)
@@ -759,6 +925,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&respTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("operations.response_time", subQueryTraffic, len(respTimeRaw))
 		// Prepare the Prometheus query for error rate of endpoint operations
 		errorRateQuery := fmt.Sprintf(
 			"100 * (sum by (span_name, span_kind) (sum_over_time(trace_endpoint_count{service_name='%s', span_kind='SPAN_KIND_SERVER', env=~'%s', http_status_code=~'4.*|5.*'}[%s])) / %d) / (sum by (span_name, span_kind) (sum_over_time(trace_endpoint_count{service_name='%s', span_kind='SPAN_KIND_SERVER', env=~'%s'}[%s])) / %d)",
@@ -778,6 +945,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&errorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("operations.error_rate", subQueryErrors, len(errorRateRaw))
 		// Prepare the Prometheus query for throughput of database operations
 		dbThroughputQuery := fmt.Sprintf(
 			"sum by (span_name, db_system, net_peer_name, rpc_system, span_kind)(sum_over_time(trace_client_count{service_name='%s', span_kind='SPAN_KIND_CLIENT', db_system!='', env=~'%s'}[%s])) / %d",
@@ -796,10 +964,11 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&dbThroughputRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("databases.throughput", subQueryCalls, len(dbThroughputRaw))
 		// Prepare the Prometheus query for response times of database operations
 		dbRespTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95, sum by (quantile, span_name, db_system, net_peer_name, rpc_system, span_kind) (trace_client_duration{service_name='%s', span_kind='SPAN_KIND_CLIENT', db_system!='', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95, sum by (quantile, span_name, db_system, net_peer_name, rpc_system, span_kind) (trace_client_duration{service_name='%s', span_kind='SPAN_KIND_CLIENT', db_system!='', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
 		// Prepare request to Prometheus (or your metrics backend)
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, dbRespTimeQuery, endTimeParam, cfg)
@@ -814,6 +983,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&dbRespTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("databases.response_time", subQueryCalls, len(dbRespTimeRaw))
 		// Prepare the Prometheus query for error rate of database operations
 		dbErrorRateQuery := fmt.Sprintf(
 			`
@@ -848,6 +1018,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&dbErrorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("databases.error_rate", subQueryErrors, len(dbErrorRateRaw))
 		// Prepare query for http operations
 		httpThroughputQuery := fmt.Sprintf(
 			"sum by(span_name, db_system, net_peer_name, rpc_system, span_kind)(sum_over_time(trace_client_count{service_name='%s', span_kind='SPAN_KIND_CLIENT', env=~'%s'}[%s])) / %d",
@@ -866,10 +1037,11 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&httpThroughputRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("http_calls.throughput", subQueryCalls, len(httpThroughputRaw))
 		// Prepare the Prometheus query for response times of http operations
 		httpRespTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95, sum by (quantile, span_name, net_peer_name, rpc_system, span_kind) (trace_client_duration{service_name='%s', span_kind='SPAN_KIND_CLIENT', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95, sum by (quantile, span_name, net_peer_name, rpc_system, span_kind) (trace_client_duration{service_name='%s', span_kind='SPAN_KIND_CLIENT', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
 		// Prepare request to Prometheus (or your metrics backend)
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, httpRespTimeQuery, endTimeParam, cfg)
@@ -884,6 +1056,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&httpRespTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("http_calls.response_time", subQueryCalls, len(httpRespTimeRaw))
 		// Prepare the Prometheus query for error rate of http operations
 		httpErrorRateQuery := fmt.Sprintf(
 			`			100 * 
@@ -916,6 +1089,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&httpErrorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("http_calls.error_rate", subQueryErrors, len(httpErrorRateRaw))
 		// Prepare query for messaging operations
 		messagingThroughputQuery := fmt.Sprintf(
 			"sum by(span_name, messaging_system, net_peer_name, rpc_system, span_kind)(sum_over_time(trace_client_count{service_name='%s', messaging_system!='', span_kind='SPAN_KIND_PRODUCER', env=~'%s'}[%s])) / %d",
@@ -934,10 +1108,11 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&messagingThroughputRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("messaging.throughput", subQueryCalls, len(messagingThroughputRaw))
 		// Prepare the Prometheus query for response times of messaging operations
 		messagingRespTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95, sum by (quantile, span_name, messaging_system, net_peer_name, rpc_system, span_kind) (trace_client_duration{service_name='%s', messaging_system!='', span_kind='SPAN_KIND_PRODUCER', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95, sum by (quantile, span_name, messaging_system, net_peer_name, rpc_system, span_kind) (trace_client_duration{service_name='%s', messaging_system!='', span_kind='SPAN_KIND_PRODUCER', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
 		// Prepare request to Prometheus (or your metrics backend)
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, messagingRespTimeQuery, endTimeParam, cfg)
@@ -952,6 +1127,7 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&messagingRespTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("messaging.response_time", subQueryCalls, len(messagingRespTimeRaw))
 		// Prepare the Prometheus query for error rate of messaging operations
 		messagingErrorRateQuery := fmt.Sprintf(
 			`			100 * 
@@ -984,24 +1160,19 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		if err := json.NewDecoder(httpResp.Body).Decode(&messagingErrorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("messaging.error_rate", subQueryErrors, len(messagingErrorRateRaw))
 		// Prepare the response structure
 		operationsSummary := make([]ServiceOperationSummary, 0)
 		for _, r := range promResp {
 			// Extract operation details
 			operation := ServiceOperationSummary{
-				Name:        r.Metric["span_name"],
-				ServiceName: serviceName,
-				Env:         env,
-				Throughput:  0, // default to 0, will be updated later
-				ErrorRate:   0, // default to 0, will be updated later
-				ResponseTime: map[string]float64{
-					"p95": 0, // default to 0, will be updated later
-					"p90": 0,
-					"p50": 0,
-					"avg": 0,
-					"max": 0,
-				},
-				ErrorPercent: 0, // default to 0, will be updated later
+				Name:         r.Metric["span_name"],
+				ServiceName:  serviceName,
+				Env:          env,
+				Throughput:   0,                           // default to 0, will be updated later
+				ErrorRate:    0,                           // default to 0, will be updated later
+				ResponseTime: newResponseTimes(quantiles), // default to 0, will be updated later
+				ErrorPercent: 0,                           // default to 0, will be updated later
 			}
 			if valStr, ok := r.Value[1].(string); ok {
 				if throughputVal, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1012,8 +1183,8 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 			for _, rt := range respTimeRaw {
 				if rt.Metric["span_name"] == operation.Name {
 					quantile, ok := rt.Metric["quantile"]
-					if !ok {
-						continue // skip if quantile is not present
+					if !ok || !slices.Contains(quantiles, quantile) {
+						continue // skip if quantile is not present or not selected
 					}
 					if valStr, ok := rt.Value[1].(string); ok {
 						if val, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1043,21 +1214,15 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		for _, r := range dbThroughputRaw {
 			// Extract operation details
 			operation := ServiceOperationSummary{
-				Name:        r.Metric["span_name"],
-				ServiceName: serviceName,
-				Env:         env,
-				DBSystem:    r.Metric["db_system"],
-				NetPeerName: r.Metric["net_peer_name"],
-				Throughput:  0, // default to 0, will be updated later
-				ErrorRate:   0, // default to 0, will be updated later
-				ResponseTime: map[string]float64{
-					"p95": 0, // default to 0, will be updated later
-					"p90": 0,
-					"p50": 0,
-					"avg": 0,
-					"max": 0,
-				},
-				ErrorPercent: 0, // default to 0, will be updated later
+				Name:         r.Metric["span_name"],
+				ServiceName:  serviceName,
+				Env:          env,
+				DBSystem:     r.Metric["db_system"],
+				NetPeerName:  r.Metric["net_peer_name"],
+				Throughput:   0,                           // default to 0, will be updated later
+				ErrorRate:    0,                           // default to 0, will be updated later
+				ResponseTime: newResponseTimes(quantiles), // default to 0, will be updated later
+				ErrorPercent: 0,                           // default to 0, will be updated later
 			}
 			if valStr, ok := r.Value[1].(string); ok {
 				if throughputVal, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1070,8 +1235,8 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 					rt.Metric["db_system"] == operation.DBSystem &&
 					rt.Metric["net_peer_name"] == operation.NetPeerName {
 					quantile, ok := rt.Metric["quantile"]
-					if !ok {
-						continue // skip if quantile is not present
+					if !ok || !slices.Contains(quantiles, quantile) {
+						continue // skip if quantile is not present or not selected
 					}
 					if valStr, ok := rt.Value[1].(string); ok {
 						if val, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1103,21 +1268,15 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 		for _, r := range httpThroughputRaw {
 			// Extract operation details
 			operation := ServiceOperationSummary{
-				Name:        r.Metric["span_name"],
-				ServiceName: serviceName,
-				Env:         env,
-				NetPeerName: r.Metric["net_peer_name"],
-				RPCSystem:   r.Metric["rpc_system"],
-				Throughput:  0, // default to 0, will be updated later
-				ErrorRate:   0, // default to 0, will be updated later
-				ResponseTime: map[string]float64{
-					"p95": 0, // default to 0, will be updated later
-					"p90": 0,
-					"p50": 0,
-					"avg": 0,
-					"max": 0,
-				},
-				ErrorPercent: 0, // default to 0, will be updated later
+				Name:         r.Metric["span_name"],
+				ServiceName:  serviceName,
+				Env:          env,
+				NetPeerName:  r.Metric["net_peer_name"],
+				RPCSystem:    r.Metric["rpc_system"],
+				Throughput:   0,                           // default to 0, will be updated later
+				ErrorRate:    0,                           // default to 0, will be updated later
+				ResponseTime: newResponseTimes(quantiles), // default to 0, will be updated later
+				ErrorPercent: 0,                           // default to 0, will be updated later
 			}
 			if valStr, ok := r.Value[1].(string); ok {
 				if throughputVal, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1130,8 +1289,8 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 					rt.Metric["net_peer_name"] == operation.NetPeerName &&
 					rt.Metric["rpc_system"] == operation.RPCSystem {
 					quantile, ok := rt.Metric["quantile"]
-					if !ok {
-						continue // skip if quantile is not present
+					if !ok || !slices.Contains(quantiles, quantile) {
+						continue // skip if quantile is not present or not selected
 					}
 					if valStr, ok := rt.Value[1].(string); ok {
 						if val, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1169,16 +1328,10 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 				MessagingSystem: r.Metric["messaging_system"],
 				NetPeerName:     r.Metric["net_peer_name"],
 				RPCSystem:       r.Metric["rpc_system"],
-				Throughput:      0, // default to 0, will be updated later
-				ErrorRate:       0, // default to 0, will be updated later
-				ResponseTime: map[string]float64{
-					"p95": 0, // default to 0, will be updated later
-					"p90": 0,
-					"p50": 0,
-					"avg": 0,
-					"max": 0,
-				},
-				ErrorPercent: 0, // default to 0, will be updated later
+				Throughput:      0,                           // default to 0, will be updated later
+				ErrorRate:       0,                           // default to 0, will be updated later
+				ResponseTime:    newResponseTimes(quantiles), // default to 0, will be updated later
+				ErrorPercent:    0,                           // default to 0, will be updated later
 			}
 			if valStr, ok := r.Value[1].(string); ok {
 				if throughputVal, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1192,8 +1345,8 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 					rt.Metric["net_peer_name"] == operation.NetPeerName &&
 					rt.Metric["rpc_system"] == operation.RPCSystem {
 					quantile, ok := rt.Metric["quantile"]
-					if !ok {
-						continue // skip if quantile is not present
+					if !ok || !slices.Contains(quantiles, quantile) {
+						continue // skip if quantile is not present or not selected
 					}
 					if valStr, ok := rt.Value[1].(string); ok {
 						if val, err := strconv.ParseFloat(valStr, 64); err == nil {
@@ -1227,6 +1380,12 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 			ServiceName: serviceName,
 			Env:         env,
 			Operations:  operationsSummary,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTimeParam, endTimeParam,
+				fmt.Sprintf("trace_endpoint_count{service_name='%s', env=~'%s'}", serviceName, env),
+				fmt.Sprintf("trace_endpoint_duration{service_name='%s', env=~'%s'}", serviceName, env),
+			)).withDataAvailability(serviceName, env, checks).
+				withServiceSuggestions(ctx, client, cfg, serviceName, env, startTimeParam, endTimeParam).
+				withWiderWindowProbe(ctx, client, cfg, startTimeParam, endTimeParam),
 		}
 		// Return the response
 		resultJSON, err := json.Marshal(details)
@@ -1249,12 +1408,6 @@ func NewServiceOperationsSummaryHandler(client *http.Client, cfg models.Config)
 	}
 }
 
-type RedMetrics struct {
-	Throughput, ResponseTimeP95, ErrorRate, ErrorPercent float64
-	ResponseTimeP50, ResponseTimeP90, ResponseTimeAvg    float64
-	ResponseTimeMax                                      float64
-}
-
 type ServiceDependencyGraphDetails struct {
 	ServiceName      string                `json:"service_name"`
 	Env              string                `json:"env"`
@@ -1262,24 +1415,31 @@ type ServiceDependencyGraphDetails struct {
 	Outgoing         map[string]RedMetrics `json:"outgoing"`
 	MessagingSystems map[string]RedMetrics `json:"messaging_systems"`
 	Databases        map[string]RedMetrics `json:"databases"`
+	Meta             *ResponseMeta         `json:"_meta,omitempty"`
 }
 
-func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceDependencyGraphArgs) (*mcp.CallToolResult, any, error) {
+func NewServiceDependencyGraphHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceDependencyGraphArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args ServiceDependencyGraphArgs) (*mcp.CallToolResult, any, error) {
 		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		env := args.Env
-		if env == "" {
-			env = ".*" // default environment
+		env := resolveEnv(cfg, args.Env)
+		quantiles, err := resolveQuantiles(cfg, args.Quantiles)
+		if err != nil {
+			return nil, nil, err
 		}
 		serviceName := args.ServiceName
 		if serviceName == "" {
 			return nil, nil, fmt.Errorf("service_name is required")
 		}
+		if args.MinThroughput < 0 {
+			return nil, nil, fmt.Errorf("min_throughput must be non-negative")
+		}
+		progress := utils.NewProgressReporter(req, 10)
 		timeRange := fmt.Sprintf("%dm", int((endTimeParam-startTimeParam)/60))
+		var checks dataChecks
 
 		incoming := make(map[string]RedMetrics)
 		outgoing := make(map[string]RedMetrics)
@@ -1292,6 +1452,9 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			"sum by (client)(sum_over_time(trace_call_graph_count{server='%s', env=~'%s'}[%s])) / %d",
 			serviceName, env, timeRange, int((endTimeParam-startTimeParam)/60),
 		)
+		if err := progress.Step(ctx, "incoming throughput"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err := utils.MakePromInstantAPIQuery(ctx, client, incomingThroughputQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1304,11 +1467,15 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&incomingThroughputRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("incoming.throughput", subQueryCalls, len(incomingThroughputRaw))
 		// response times
 		incomingRespTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95 ,sum by (client, quantile) (trace_call_graph_duration{server='%s', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95 ,sum by (client, quantile) (trace_call_graph_duration{server='%s', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
+		if err := progress.Step(ctx, "incoming response time"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, incomingRespTimeQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1321,11 +1488,15 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&incomingRespTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("incoming.response_time", subQueryCalls, len(incomingRespTimeRaw))
 		// error rate
 		incomingErrorRateQuery := fmt.Sprintf(
 			"sum by (client)(sum_over_time(trace_call_graph_count{server='%s', env=~'%s', client_status=~'4.*|5.*'}[%s])) / %d",
 			serviceName, env, timeRange, int((endTimeParam-startTimeParam)/60),
 		)
+		if err := progress.Step(ctx, "incoming error rate"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, incomingErrorRateQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1338,13 +1509,14 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&incomingErrorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("incoming.error_rate", subQueryErrors, len(incomingErrorRateRaw))
 		// Process incoming data
 		for _, r := range incomingThroughputRaw {
 			client := r.Metric["client"]
 			if client == "" {
 				client = "unknown"
 			}
-			metrics := RedMetrics{}
+			metrics := newRedMetrics(quantiles)
 			if valStr, ok := r.Value[1].(string); ok {
 				if throughputVal, err := strconv.ParseFloat(valStr, 64); err == nil {
 					metrics.Throughput = throughputVal
@@ -1361,18 +1533,7 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			metrics := incoming[client]
 			if valStr, ok := r.Value[1].(string); ok {
 				if val, err := strconv.ParseFloat(valStr, 64); err == nil {
-					switch quantile {
-					case "p95":
-						metrics.ResponseTimeP95 = val
-					case "p90":
-						metrics.ResponseTimeP90 = val
-					case "p50":
-						metrics.ResponseTimeP50 = val
-					case "avg":
-						metrics.ResponseTimeAvg = val
-					case "max":
-						metrics.ResponseTimeMax = val
-					}
+					metrics.setResponseTime(quantiles, quantile, val)
 				}
 			}
 			incoming[client] = metrics
@@ -1391,6 +1552,9 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			incoming[client] = metrics
 		}
 		for client, metrics := range incoming {
+			if metrics.ResponseTime == nil {
+				metrics.ResponseTime = newResponseTimes(quantiles)
+			}
 			if metrics.Throughput > 0 {
 				metrics.ErrorPercent = (metrics.ErrorRate / metrics.Throughput) * 100
 			}
@@ -1402,6 +1566,9 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			"sum by (server)(sum_over_time(trace_call_graph_count{client='%s', env=~'%s'}[%s])) / %d",
 			serviceName, env, timeRange, int((endTimeParam-startTimeParam)/60),
 		)
+		if err := progress.Step(ctx, "outgoing throughput"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, outgoingThroughputQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1414,11 +1581,15 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&outgoingThroughputRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("outgoing.throughput", subQueryCalls, len(outgoingThroughputRaw))
 		// response times
 		outgoingRespTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95 ,sum by (server, quantile) (trace_call_graph_duration{client='%s', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95 ,sum by (server, quantile) (trace_call_graph_duration{client='%s', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
+		if err := progress.Step(ctx, "outgoing response time"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, outgoingRespTimeQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1431,11 +1602,15 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&outgoingRespTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("outgoing.response_time", subQueryCalls, len(outgoingRespTimeRaw))
 		// error rate
 		outgoingErrorRateQuery := fmt.Sprintf(
 			"sum by (server)(sum_over_time(trace_call_graph_count{client='%s', env=~'%s', client_status=~'4.*|5.*'}[%s])) / %d",
 			serviceName, env, timeRange, int((endTimeParam-startTimeParam)/60),
 		)
+		if err := progress.Step(ctx, "outgoing error rate"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, outgoingErrorRateQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1448,6 +1623,7 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&outgoingErrorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("outgoing.error_rate", subQueryErrors, len(outgoingErrorRateRaw))
 		// Process outgoing data
 
 		for _, r := range outgoingThroughputRaw {
@@ -1455,7 +1631,7 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			if server == "" {
 				server = "unknown"
 			}
-			metrics := RedMetrics{}
+			metrics := newRedMetrics(quantiles)
 			if valStr, ok := r.Value[1].(string); ok {
 				if throughputVal, err := strconv.ParseFloat(valStr, 64); err == nil {
 					metrics.Throughput = throughputVal
@@ -1472,18 +1648,7 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			metrics := outgoing[server]
 			if valStr, ok := r.Value[1].(string); ok {
 				if val, err := strconv.ParseFloat(valStr, 64); err == nil {
-					switch quantile {
-					case "p95":
-						metrics.ResponseTimeP95 = val
-					case "p90":
-						metrics.ResponseTimeP90 = val
-					case "p50":
-						metrics.ResponseTimeP50 = val
-					case "avg":
-						metrics.ResponseTimeAvg = val
-					case "max":
-						metrics.ResponseTimeMax = val
-					}
+					metrics.setResponseTime(quantiles, quantile, val)
 				}
 			}
 			outgoing[server] = metrics
@@ -1502,6 +1667,9 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			outgoing[server] = metrics
 		}
 		for server, metrics := range outgoing {
+			if metrics.ResponseTime == nil {
+				metrics.ResponseTime = newResponseTimes(quantiles)
+			}
 			if metrics.Throughput > 0 {
 				metrics.ErrorPercent = (metrics.ErrorRate / metrics.Throughput) * 100
 			}
@@ -1513,6 +1681,9 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			"sum by (server_host, server_db_system, server_rpc_system, server_messaging_system, server_rpc_service) (sum_over_time(trace_internal_call_graph_count{client='%s', env=~'%s'}[%s])) / %d",
 			serviceName, env, timeRange, int((endTimeParam-startTimeParam)/60),
 		)
+		if err := progress.Step(ctx, "database and messaging throughput"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, infrastructureThroughputQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1525,11 +1696,15 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&infrastructureThroughputRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("infrastructure.throughput", subQueryCalls, len(infrastructureThroughputRaw))
 		// response times
 		infrastructureRespTimeQuery := fmt.Sprintf(
-			"quantile_over_time(0.95 ,sum by (server_host, server_db_system, server_rpc_system, server_messaging_system, server_rpc_service, quantile) (trace_internal_call_graph_duration{client='%s', env=~'%s'}[%s]))",
-			serviceName, env, timeRange,
+			"quantile_over_time(0.95 ,sum by (server_host, server_db_system, server_rpc_system, server_messaging_system, server_rpc_service, quantile) (trace_internal_call_graph_duration{client='%s', env=~'%s', %s}[%s]))",
+			serviceName, env, quantileMatcher(quantiles), timeRange,
 		)
+		if err := progress.Step(ctx, "database and messaging response time"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, infrastructureRespTimeQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1542,11 +1717,15 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&infrastructureRespTimeRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("infrastructure.response_time", subQueryCalls, len(infrastructureRespTimeRaw))
 		// error rate
 		infrastructureErrorRateQuery := fmt.Sprintf(
 			"sum by (server_host, server_db_system, server_rpc_system, server_messaging_system, server_rpc_service) (sum_over_time(trace_internal_call_graph_count{client='%s', env=~'%s', client_status=~'4.*|5.*'}[%s])) / %d",
 			serviceName, env, timeRange, int((endTimeParam-startTimeParam)/60),
 		)
+		if err := progress.Step(ctx, "database and messaging error rate"); err != nil {
+			return nil, nil, err
+		}
 		httpResp, err = utils.MakePromInstantAPIQuery(ctx, client, infrastructureErrorRateQuery, endTimeParam, cfg)
 		if err != nil {
 			return nil, nil, err
@@ -1559,6 +1738,7 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 		if err := json.NewDecoder(httpResp.Body).Decode(&infrastructureErrorRateRaw); err != nil {
 			return nil, nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
 		}
+		checks.record("infrastructure.error_rate", subQueryErrors, len(infrastructureErrorRateRaw))
 		// Process infrastructure data
 		for _, r := range infrastructureThroughputRaw {
 			host := r.Metric["server_host"]
@@ -1606,18 +1786,7 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			}
 			if valStr, ok := r.Value[1].(string); ok {
 				if val, err := strconv.ParseFloat(valStr, 64); err == nil {
-					switch quantile {
-					case "p95":
-						metrics.ResponseTimeP95 = val
-					case "p90":
-						metrics.ResponseTimeP90 = val
-					case "p50":
-						metrics.ResponseTimeP50 = val
-					case "avg":
-						metrics.ResponseTimeAvg = val
-					case "max":
-						metrics.ResponseTimeMax = val
-					}
+					metrics.setResponseTime(quantiles, quantile, val)
 				}
 			}
 			if dbSystem != "" {
@@ -1655,17 +1824,46 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			}
 		}
 		for key, metrics := range databases {
+			if metrics.ResponseTime == nil {
+				metrics.ResponseTime = newResponseTimes(quantiles)
+			}
 			if metrics.Throughput > 0 {
 				metrics.ErrorPercent = (metrics.ErrorRate / metrics.Throughput) * 100
 			}
 			databases[key] = metrics
 		}
 		for key, metrics := range messagingSystems {
+			if metrics.ResponseTime == nil {
+				metrics.ResponseTime = newResponseTimes(quantiles)
+			}
 			if metrics.Throughput > 0 {
 				metrics.ErrorPercent = (metrics.ErrorRate / metrics.Throughput) * 100
 			}
 			messagingSystems[key] = metrics
 		}
+		for _, edges := range []map[string]RedMetrics{incoming, outgoing, databases, messagingSystems} {
+			dropLowThroughput(edges, args.MinThroughput)
+		}
+		// Edge evidence: call counts and the span kinds each peer emitted.
+		if err := progress.Step(ctx, "edge span kinds"); err != nil {
+			return nil, nil, err
+		}
+		var peers []string
+		for _, edges := range []map[string]RedMetrics{incoming, outgoing} {
+			for peer := range edges {
+				if peer != "unknown" && !slices.Contains(peers, peer) {
+					peers = append(peers, peer)
+				}
+			}
+		}
+		slices.Sort(peers)
+		spanKinds, err := fetchServiceSpanKinds(ctx, client, cfg, peers, env, timeRange, endTimeParam)
+		if err != nil {
+			return nil, nil, err
+		}
+		windowMinutes := (endTimeParam - startTimeParam) / 60
+		annotateEdges(incoming, spanKinds, windowMinutes, callerSpanKinds)
+		annotateEdges(outgoing, spanKinds, windowMinutes, calleeSpanKinds)
 		// Prepare the final response structure
 		details := ServiceDependencyGraphDetails{
 			ServiceName:      serviceName,
@@ -1674,6 +1872,12 @@ func NewServiceDependencyGraphHandler(client *http.Client, cfg models.Config) fu
 			Outgoing:         outgoing,
 			Databases:        databases,
 			MessagingSystems: messagingSystems,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTimeParam, endTimeParam,
+				fmt.Sprintf("trace_call_graph_count{server='%s', env=~'%s'}", serviceName, env),
+				fmt.Sprintf("trace_call_graph_count{client='%s', env=~'%s'}", serviceName, env),
+			)).withDataAvailability(serviceName, env, checks).
+				withServiceSuggestions(ctx, client, cfg, serviceName, env, startTimeParam, endTimeParam).
+				withWiderWindowProbe(ctx, client, cfg, startTimeParam, endTimeParam),
 		}
 		// Return the response
 		resultJSON, err := json.Marshal(details)
@@ -1718,14 +1922,16 @@ func resolveDatasourceCfg(cfg models.Config, datasourceName string) (models.Conf
 	return cfg, nil
 }
 
-func NewPromqlRangeQueryHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlRangeQueryArgs) (*mcp.CallToolResult, any, error) {
+func NewPromqlRangeQueryHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlRangeQueryArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args PromqlRangeQueryArgs) (*mcp.CallToolResult, any, error) {
 		query := args.Query
 		if query == "" {
 			return nil, nil, fmt.Errorf("query is required")
 		}
 
-		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		encoding := rangeEncoding(args.Encoding)
+
+		startTimeParam, endTimeParam, err := args.TimeRange()
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1735,6 +1941,17 @@ func NewPromqlRangeQueryHandler(client *http.Client, cfg models.Config) func(con
 			return nil, nil, err
 		}
 
+		if limitErr := checkRangeQueryWindow(cfg, startTimeParam, endTimeParam); limitErr != nil {
+			return limitErr.result(), nil, nil
+		}
+		limitErr, err := checkRangeQuerySeries(ctx, client, queryCfg, query, endTimeParam)
+		if err != nil {
+			return nil, nil, err
+		}
+		if limitErr != nil {
+			return limitErr.result(), nil, nil
+		}
+
 		httpResp, err := utils.MakePromRangeAPIQuery(ctx, client, query, startTimeParam, endTimeParam, queryCfg)
 		if err != nil {
 			return nil, nil, err
@@ -1746,30 +1963,47 @@ func NewPromqlRangeQueryHandler(client *http.Client, cfg models.Config) func(con
 			return nil, nil, fmt.Errorf("failed to execute Prometheus range query: %s", httpResp.Status)
 		}
 		defer httpResp.Body.Close()
-		// return the response body string as the content without parsing
-		responseBodyBytes, err := io.ReadAll(httpResp.Body)
+		// Decode series by series so a large body is not buffered whole, and
+		// stop reading once the series or point limit is reached.
+		series, warnings, err := decodeRangeStream(httpResp.Body, rangeQueryLimits(cfg))
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+			return nil, nil, err
+		}
+		var out any = series
+		if encoding == encodingCompact {
+			compact, err := compactRange(series)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = compact
+		}
+		result, err := json.Marshal(out)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		text, err := promResultText(result, warnings)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: string(responseBodyBytes),
+					Text: text,
 				},
 			},
 		}, nil, nil
 	}
 }
 
-func NewPromqlInstantQueryHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlInstantQueryArgs) (*mcp.CallToolResult, any, error) {
+func NewPromqlInstantQueryHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlInstantQueryArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args PromqlInstantQueryArgs) (*mcp.CallToolResult, any, error) {
 		query := args.Query
 		if query == "" {
 			return nil, nil, fmt.Errorf("query is required")
 		}
 
-		timeParam, err := resolveInstantQueryTime(args.TimeISO, args.LookbackMinutes)
+		timeParam, err := args.EvalTime()
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1794,11 +2028,19 @@ func NewPromqlInstantQueryHandler(client *http.Client, cfg models.Config) func(c
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 		}
+		result, warnings, err := unwrapPromBody(responseBodyBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, err := promResultText(result, warnings)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: string(responseBodyBytes),
+					Text: text,
 				},
 			},
 		}, nil, nil
@@ -1808,7 +2050,7 @@ func NewPromqlInstantQueryHandler(client *http.Client, cfg models.Config) func(c
 // tool handler to make the query
 // sum by (env)(last_over_time(domain_attributes_count))
 // iterate over the values of `env` label and return the unique values
-func NewServiceEnvironmentsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceEnvironmentsArgs) (*mcp.CallToolResult, any, error) {
+func NewServiceEnvironmentsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ServiceEnvironmentsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args ServiceEnvironmentsArgs) (*mcp.CallToolResult, any, error) {
 		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
@@ -1837,19 +2079,27 @@ func NewServiceEnvironmentsHandler(client *http.Client, cfg models.Config) func(
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 		}
+		result, warnings, err := unwrapPromBody(responseBodyBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, err := promResultText(result, warnings)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		// Return the environments as the content
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: string(responseBodyBytes),
+					Text: text,
 				},
 			},
 		}, nil, nil
 	}
 }
 
-func NewPromqlLabelValuesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlLabelValuesArgs) (*mcp.CallToolResult, any, error) {
+func NewPromqlLabelValuesHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlLabelValuesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args PromqlLabelValuesArgs) (*mcp.CallToolResult, any, error) {
 		query := firstNonEmpty(args.MatchQuery, args.Match)
 		if query == "" {
@@ -1885,18 +2135,26 @@ func NewPromqlLabelValuesHandler(client *http.Client, cfg models.Config) func(co
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 		}
+		result, warnings, err := unwrapPromBody(responseBodyBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, err := promResultText(result, warnings)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: string(responseBodyBytes),
+					Text: text,
 				},
 			},
 		}, nil, nil
 	}
 }
 
-func NewPromqlLabelsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlLabelsArgs) (*mcp.CallToolResult, any, error) {
+func NewPromqlLabelsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, PromqlLabelsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args PromqlLabelsArgs) (*mcp.CallToolResult, any, error) {
 		query := firstNonEmpty(args.MatchQuery, args.Match)
 		if query == "" {
@@ -1928,11 +2186,19 @@ func NewPromqlLabelsHandler(client *http.Client, cfg models.Config) func(context
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 		}
+		result, warnings, err := unwrapPromBody(responseBodyBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, err := promResultText(result, warnings)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{
-					Text: string(responseBodyBytes),
+					Text: text,
 				},
 			},
 		}, nil, nil
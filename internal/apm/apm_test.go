@@ -12,13 +12,32 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+func TestResolveEnv(t *testing.T) {
+	tests := []struct {
+		name, requested, configured, want, wantDefault string
+	}{
+		{"requested wins", "staging", "production", "staging", "staging"},
+		{"configured default", "", "production", "production", "production"},
+		{"all environments", "", "", ".*", ""},
+	}
+	for _, tt := range tests {
+		cfg := models.Config{DefaultEnv: tt.configured}
+		if got := resolveEnv(cfg, tt.requested); got != tt.want {
+			t.Errorf("%s: resolveEnv() = %q, want %q", tt.name, got, tt.want)
+		}
+		if got := defaultEnv(cfg, tt.requested); got != tt.wantDefault {
+			t.Errorf("%s: defaultEnv() = %q, want %q", tt.name, got, tt.wantDefault)
+		}
+	}
+}
+
 func TestNewServiceSummaryHandler_ExtraParams(t *testing.T) {
 	// Mock responses should match apiPromInstantResp format (direct array)
 	throughputResp := `[
@@ -102,6 +121,15 @@ func TestNewServiceSummaryHandler_ExtraParams(t *testing.T) {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
+	var withMeta struct {
+		Meta ResponseMeta `json:"_meta"`
+	}
+	if err := json.Unmarshal([]byte(textContent.Text), &withMeta); err != nil {
+		t.Fatalf("failed to unmarshal _meta: %v", err)
+	}
+	if len(withMeta.Meta.Freshness) != 2 || withMeta.Meta.Confidence == "" {
+		t.Errorf("expected _meta with 2 freshness probes and a confidence, got %+v", withMeta.Meta)
+	}
 }
 
 func TestGetServicePerformanceDetails(t *testing.T) {
@@ -284,8 +312,8 @@ func TestPromqlRangeQueryHandler(t *testing.T) {
 }
 
 func TestResolveTimeRange_Precedence(t *testing.T) {
-	startISO := "2025-06-23 16:00:00"
-	endISO := "2025-06-23 16:30:00"
+	startISO := "2025-06-23T16:00:00Z"
+	endISO := "2025-06-23T16:30:00Z"
 
 	start, end, err := resolveTimeRange(startISO, endISO, 5)
 	if err != nil {
@@ -351,6 +379,11 @@ func TestPromqlRangeHandler_UsesLookbackAndExplicitPrecedence(t *testing.T) {
 		if !strings.Contains(r.URL.Path, "/prom_query") {
 			t.Fatalf("expected prom_query endpoint, got %s", r.URL.Path)
 		}
+		if strings.HasSuffix(r.URL.Path, "/prom_query_instant") {
+			// Series-count guardrail probe.
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
 
 		body, _ := io.ReadAll(r.Body)
 		var reqPayload capturedReq
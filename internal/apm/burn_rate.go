@@ -0,0 +1,295 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// burnRateWindowPlaceholder is replaced by each evaluation window in
+// error_ratio_query.
+const burnRateWindowPlaceholder = "$window"
+
+const defaultSLOPeriodDays = 30
+
+// Burn rate alert outcomes.
+const (
+	burnRateStatusPage   = "page"
+	burnRateStatusTicket = "ticket"
+	burnRateStatusOK     = "ok"
+	burnRateStatusNoData = "no_data"
+)
+
+// burnRateAlert is one multi-window alert from the SRE workbook: it fires
+// when both windows burn faster than the rate that would spend
+// budgetFraction of the error budget within the long window.
+type burnRateAlert struct {
+	severity       string
+	longWindow     time.Duration
+	shortWindow    time.Duration
+	budgetFraction float64
+}
+
+// burnRateAlerts are the page (2% of budget in 1h) and ticket (5% in 6h)
+// pairs; for a 30-day period these are burn rates of 14.4 and 6.
+var burnRateAlerts = []burnRateAlert{
+	{severity: burnRateStatusPage, longWindow: time.Hour, shortWindow: 5 * time.Minute, budgetFraction: 0.02},
+	{severity: burnRateStatusTicket, longWindow: 6 * time.Hour, shortWindow: 30 * time.Minute, budgetFraction: 0.05},
+}
+
+// EvaluateBurnRateArgs represents the input arguments for the evaluate_burn_rate tool
+type EvaluateBurnRateArgs struct {
+	Objective       float64 `json:"objective" jsonschema:"SLO target in percent (e.g. 99.9) (required)"`
+	ErrorRatioQuery string  `json:"error_ratio_query,omitempty" jsonschema:"PromQL returning the error ratio (0-1) with $window as the range (e.g. sum(rate(http_requests_total{code=~\"5..\"}[$window])) / sum(rate(http_requests_total[$window]))). Required unless service_name is set."`
+	ServiceName     string  `json:"service_name,omitempty" jsonschema:"Use the span-derived error ratio of this service's server spans as the SLI instead of error_ratio_query"`
+	Env             string  `json:"env,omitempty" jsonschema:"Environment for service_name. Default: the server default env if configured, else all."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	SLOPeriodDays   int     `json:"slo_period_days,omitempty" jsonschema:"SLO period in days used to derive the burn rate thresholds (default: 30)"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"Evaluation time in RFC3339/ISO8601 format (default: now)"`
+	Datasource      string  `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+}
+
+// BurnRateWindow is the measured error ratio and burn rate over one window.
+type BurnRateWindow struct {
+	Window     string   `json:"window"`
+	ErrorRatio *float64 `json:"error_ratio"`
+	BurnRate   *float64 `json:"burn_rate"`
+}
+
+// BurnRateAlertResult is the evaluation of one multi-window alert.
+type BurnRateAlertResult struct {
+	Severity              string   `json:"severity"`
+	LongWindow            string   `json:"long_window"`
+	ShortWindow           string   `json:"short_window"`
+	Threshold             float64  `json:"threshold"`
+	BudgetConsumedPercent float64  `json:"budget_consumed_percent"`
+	LongBurnRate          *float64 `json:"long_burn_rate"`
+	ShortBurnRate         *float64 `json:"short_burn_rate"`
+	Breached              bool     `json:"breached"`
+}
+
+// BurnRateReport is the evaluate_burn_rate response.
+type BurnRateReport struct {
+	Objective     float64               `json:"objective"`
+	ErrorBudget   float64               `json:"error_budget"`
+	SLOPeriodDays int                   `json:"slo_period_days"`
+	EvaluatedAt   int64                 `json:"evaluated_at"`
+	Query         string                `json:"query"`
+	Status        string                `json:"status"`
+	Summary       string                `json:"summary"`
+	Windows       []BurnRateWindow      `json:"windows"`
+	Alerts        []BurnRateAlertResult `json:"alerts"`
+	Caveats       []string              `json:"caveats,omitempty"`
+}
+
+// NewEvaluateBurnRateHandler evaluates the multi-window, multi-burn-rate
+// alerts from the SRE workbook for an SLI at a point in time.
+func NewEvaluateBurnRateHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, EvaluateBurnRateArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args EvaluateBurnRateArgs) (*mcp.CallToolResult, any, error) {
+		if args.Objective <= 0 || args.Objective >= 100 {
+			return nil, nil, fmt.Errorf("objective must be a percentage between 0 and 100 (e.g. 99.9), got %v", args.Objective)
+		}
+		if args.SLOPeriodDays < 0 {
+			return nil, nil, fmt.Errorf("slo_period_days must be positive")
+		}
+		args.Env = resolveEnv(cfg, args.Env)
+		query, err := burnRateQuery(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		periodDays := args.SLOPeriodDays
+		if periodDays == 0 {
+			periodDays = defaultSLOPeriodDays
+		}
+
+		endTime := time.Now().UTC()
+		if args.EndTimeISO != "" {
+			endTime, err = utils.ParseToolTimestamp(args.EndTimeISO)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid end_time_iso: %w", err)
+			}
+		}
+		queryCfg, err := resolveDatasourceCfg(cfg, args.Datasource)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		windows := burnRateWindows()
+		var (
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+			ratios  = make(map[time.Duration]*float64, len(windows))
+			caveats []string
+		)
+		for _, window := range windows {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				expr := strings.ReplaceAll(query, burnRateWindowPlaceholder, promDuration(window))
+				series, err := fetchPromInstant(ctx, client, queryCfg, expr, endTime.Unix())
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					caveats = append(caveats, fmt.Sprintf("%s window failed: %v", promDuration(window), err))
+					return
+				}
+				if len(series) > 1 {
+					caveats = append(caveats, fmt.Sprintf("%s window returned %d series; the highest error ratio was used. Aggregate the query with sum() for a single SLI.", promDuration(window), len(series)))
+				}
+				ratios[window] = maxPromValue(series)
+			}()
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		report := evaluateBurnRates(args.Objective, periodDays, ratios)
+		report.EvaluatedAt = endTime.Unix()
+		report.Query = query
+		report.Caveats = caveats
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// burnRateQuery returns the SLI template: the caller's query, or the error
+// ratio of the service's server spans.
+func burnRateQuery(args EvaluateBurnRateArgs) (string, error) {
+	if args.ErrorRatioQuery != "" {
+		if !strings.Contains(args.ErrorRatioQuery, burnRateWindowPlaceholder) {
+			return "", fmt.Errorf("error_ratio_query must use %s as the range of its range vectors, e.g. rate(errors_total[%s])", burnRateWindowPlaceholder, burnRateWindowPlaceholder)
+		}
+		return args.ErrorRatioQuery, nil
+	}
+	if args.ServiceName == "" {
+		return "", fmt.Errorf("either error_ratio_query or service_name is required")
+	}
+	env := args.Env
+	if env == "" {
+		env = ".*"
+	}
+	sel := fmt.Sprintf(`service_name="%s", env=~"%s", span_kind="SPAN_KIND_SERVER"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+	return fmt.Sprintf(
+		`(sum(sum_over_time(trace_endpoint_count{%[1]s, status_code="STATUS_CODE_ERROR"}[%[2]s])) or vector(0)) / sum(sum_over_time(trace_endpoint_count{%[1]s}[%[2]s]))`,
+		sel, burnRateWindowPlaceholder,
+	), nil
+}
+
+// burnRateWindows lists every window the alerts need, shortest first.
+func burnRateWindows() []time.Duration {
+	var windows []time.Duration
+	seen := map[time.Duration]bool{}
+	for _, a := range burnRateAlerts {
+		for _, w := range []time.Duration{a.shortWindow, a.longWindow} {
+			if !seen[w] {
+				seen[w] = true
+				windows = append(windows, w)
+			}
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	return windows
+}
+
+// evaluateBurnRates turns per-window error ratios into burn rates and alert
+// outcomes. An alert breaches only when both of its windows exceed the
+// threshold; a missing window never breaches.
+func evaluateBurnRates(objective float64, periodDays int, ratios map[time.Duration]*float64) BurnRateReport {
+	budget := 1 - objective/100
+	report := BurnRateReport{
+		Objective:     objective,
+		ErrorBudget:   budget,
+		SLOPeriodDays: periodDays,
+		Status:        burnRateStatusOK,
+	}
+
+	burn := map[time.Duration]*float64{}
+	anyData := false
+	for _, window := range burnRateWindows() {
+		w := BurnRateWindow{Window: promDuration(window), ErrorRatio: ratios[window]}
+		if r := ratios[window]; r != nil {
+			anyData = true
+			rate := *r / budget
+			w.BurnRate = &rate
+			burn[window] = &rate
+		}
+		report.Windows = append(report.Windows, w)
+	}
+
+	period := time.Duration(periodDays) * 24 * time.Hour
+	for _, a := range burnRateAlerts {
+		threshold := a.budgetFraction * period.Hours() / a.longWindow.Hours()
+		result := BurnRateAlertResult{
+			Severity:              a.severity,
+			LongWindow:            promDuration(a.longWindow),
+			ShortWindow:           promDuration(a.shortWindow),
+			Threshold:             math.Round(threshold*100) / 100,
+			BudgetConsumedPercent: a.budgetFraction * 100,
+			LongBurnRate:          burn[a.longWindow],
+			ShortBurnRate:         burn[a.shortWindow],
+		}
+		result.Breached = result.LongBurnRate != nil && result.ShortBurnRate != nil &&
+			*result.LongBurnRate > threshold && *result.ShortBurnRate > threshold
+		if result.Breached && report.Status == burnRateStatusOK {
+			report.Status = a.severity
+		}
+		report.Alerts = append(report.Alerts, result)
+	}
+
+	switch {
+	case !anyData:
+		report.Status = burnRateStatusNoData
+		report.Summary = "No SLI data in any window; check the query and that the metric is being ingested."
+	case report.Status == burnRateStatusOK:
+		report.Summary = fmt.Sprintf("Within budget: no burn rate pair exceeds its threshold for a %.10g%% objective.", objective)
+	default:
+		for _, a := range report.Alerts {
+			if a.Severity == report.Status {
+				report.Summary = fmt.Sprintf("%s: burning %.1fx (%s) and %.1fx (%s) against a threshold of %gx; at this rate %g%% of the %d-day error budget is spent in %s.",
+					strings.ToUpper(a.Severity), *a.LongBurnRate, a.LongWindow, *a.ShortBurnRate, a.ShortWindow, a.Threshold, a.BudgetConsumedPercent, periodDays, a.LongWindow)
+				break
+			}
+		}
+	}
+	return report
+}
+
+// maxPromValue returns the highest finite sample, or nil when there is none.
+func maxPromValue(series apiPromInstantResp) *float64 {
+	var out *float64
+	for _, s := range series {
+		v := parsePromValue(s.Value)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		if out == nil || v > *out {
+			out = &v
+		}
+	}
+	return out
+}
+
+// promDuration formats d as a PromQL duration (5m, 1h, 6h).
+func promDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
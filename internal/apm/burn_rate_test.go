@@ -0,0 +1,117 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestEvaluateBurnRates(t *testing.T) {
+	ratios := func(r5m, r30m, r1h, r6h float64) map[time.Duration]*float64 {
+		return map[time.Duration]*float64{
+			5 * time.Minute:  ptr(r5m),
+			30 * time.Minute: ptr(r30m),
+			time.Hour:        ptr(r1h),
+			6 * time.Hour:    ptr(r6h),
+		}
+	}
+
+	t.Run("page", func(t *testing.T) {
+		// 99.9% objective: budget 0.001, so a 2% error ratio burns 20x.
+		report := evaluateBurnRates(99.9, 30, ratios(0.02, 0.02, 0.02, 0.002))
+		if report.Status != burnRateStatusPage {
+			t.Fatalf("status = %s, want page", report.Status)
+		}
+		page := report.Alerts[0]
+		if page.Threshold != 14.4 || !page.Breached || *page.LongBurnRate < 19.99 {
+			t.Errorf("page alert = %+v", page)
+		}
+		if report.Alerts[1].Breached {
+			t.Error("ticket alert should not breach with a 2x 6h burn")
+		}
+		if !strings.HasPrefix(report.Summary, "PAGE") {
+			t.Errorf("summary = %q", report.Summary)
+		}
+	})
+
+	t.Run("short window recovered", func(t *testing.T) {
+		report := evaluateBurnRates(99.9, 30, ratios(0.0001, 0.01, 0.02, 0.01))
+		if report.Status != burnRateStatusTicket {
+			t.Errorf("status = %s, want ticket (page short window has recovered)", report.Status)
+		}
+	})
+
+	t.Run("period scales thresholds", func(t *testing.T) {
+		report := evaluateBurnRates(99, 7, ratios(0, 0, 0, 0))
+		if report.Status != burnRateStatusOK || report.Alerts[0].Threshold != 3.36 || report.Alerts[1].Threshold != 1.4 {
+			t.Errorf("report = %+v", report)
+		}
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		report := evaluateBurnRates(99.9, 30, nil)
+		if report.Status != burnRateStatusNoData || len(report.Windows) != 4 {
+			t.Errorf("report = %+v", report)
+		}
+	})
+}
+
+func TestBurnRateQuery(t *testing.T) {
+	if _, err := burnRateQuery(EvaluateBurnRateArgs{ErrorRatioQuery: "rate(x[5m])"}); err == nil {
+		t.Error("query without $window should be rejected")
+	}
+	if _, err := burnRateQuery(EvaluateBurnRateArgs{}); err == nil {
+		t.Error("expected error without query or service")
+	}
+	q, err := burnRateQuery(EvaluateBurnRateArgs{ServiceName: `api"x`})
+	if err != nil || !strings.Contains(q, `service_name="api\"x"`) || strings.Count(q, "$window") != 2 {
+		t.Errorf("service query = %q, %v", q, err)
+	}
+}
+
+func TestEvaluateBurnRateHandler(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Query)
+		ratio := "0.0005"
+		if strings.Contains(body.Query, "[6h]") || strings.Contains(body.Query, "[30m]") {
+			ratio = "0.01"
+		}
+		json.NewEncoder(w).Encode([]map[string]any{{"metric": map[string]string{}, "value": []any{1700000000, ratio}}})
+	}))
+	defer server.Close()
+
+	handler := NewEvaluateBurnRateHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, EvaluateBurnRateArgs{
+		Objective:       99.9,
+		ErrorRatioQuery: `sum(rate(errors[$window])) / sum(rate(requests[$window]))`,
+		EndTimeISO:      "2026-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var report BurnRateReport
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != burnRateStatusTicket || report.EvaluatedAt != 1767225600 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(queries) != 4 {
+		t.Errorf("ran %d queries, want 4", len(queries))
+	}
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, EvaluateBurnRateArgs{Objective: 100, ServiceName: "api"}); err == nil {
+		t.Error("expected error for 100% objective")
+	}
+}
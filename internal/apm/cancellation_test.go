@@ -0,0 +1,61 @@
+package apm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestHandlersHonourCancellation asserts that cancelling a tool call aborts
+// the in-flight upstream query and stops further sub-queries.
+func TestHandlersHonourCancellation(t *testing.T) {
+	type handlerCall func(ctx context.Context, client *http.Client, baseURL string) error
+	call := func(run func(ctx context.Context, client *http.Client, baseURL string) (*mcp.CallToolResult, any, error)) handlerCall {
+		return func(ctx context.Context, client *http.Client, baseURL string) error {
+			_, _, err := run(ctx, client, baseURL)
+			return err
+		}
+	}
+	req := &mcp.CallToolRequest{}
+
+	tests := map[string]handlerCall{
+		"get_service_summary": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewServiceSummaryHandler(c, testDBConfig(u))(ctx, req, ServiceSummaryArgs{})
+		}),
+		"get_service_performance_details": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewServicePerformanceDetailsHandler(c, testDBConfig(u))(ctx, req, ServicePerformanceDetailsArgs{ServiceName: "checkout"})
+		}),
+		"get_service_operations_summary": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewServiceOperationsSummaryHandler(c, testDBConfig(u))(ctx, req, ServiceOperationsSummaryArgs{ServiceName: "checkout"})
+		}),
+		"get_service_dependency_graph": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewServiceDependencyGraphHandler(c, testDBConfig(u))(ctx, req, ServiceDependencyGraphArgs{ServiceName: "checkout"})
+		}),
+		"get_service_health_score": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewGetServiceHealthScoreHandler(c, testDBConfig(u))(ctx, req, GetServiceHealthScoreArgs{ServiceName: "checkout"})
+		}),
+		"get_service_endpoints": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewGetServiceEndpointsHandler(c, testDBConfig(u))(ctx, req, GetServiceEndpointsArgs{ServiceName: "checkout"})
+		}),
+		"get_databases": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewGetDatabasesHandler(c, testDBConfig(u))(ctx, req, GetDatabasesArgs{})
+		}),
+		"get_apm_service_deviations": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewAPMServiceDeviationsHandler(c, testDBConfig(u), nil)(ctx, req, DeviationArgs{ServiceName: "checkout"})
+		}),
+		"prometheus_range_query": call(func(ctx context.Context, c *http.Client, u string) (*mcp.CallToolResult, any, error) {
+			return NewPromqlRangeQueryHandler(c, testDBConfig(u))(ctx, req, PromqlRangeQueryArgs{Query: "up"})
+		}),
+	}
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			utils.AssertCancelsPromptly(t, func(ctx context.Context, baseURL string) error {
+				return run(ctx, http.DefaultClient, baseURL)
+			})
+		})
+	}
+}
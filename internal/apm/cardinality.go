@@ -0,0 +1,277 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxCardinalityLabels caps how many labels are analyzed per call; each
+	// costs two label-values requests.
+	maxCardinalityLabels = 30
+	// maxConcurrentCardinalityQueries bounds in-flight label-values requests.
+	maxConcurrentCardinalityQueries = 8
+	defaultCardinalitySampleValues  = 5
+	maxCardinalitySampleValues      = 50
+	// fastestGrowingLimit is how many labels are listed as fastest growing.
+	fastestGrowingLimit = 5
+)
+
+// AnalyzeCardinalityArgs represents the input arguments for the analyze_cardinality tool
+type AnalyzeCardinalityArgs struct {
+	Selector        string   `json:"selector" jsonschema:"Metric name or series selector to analyze (e.g. http_requests_total or http_requests_total{env=\"prod\"}) (required)"`
+	Labels          []string `json:"labels,omitempty" jsonschema:"Only analyze these labels (default: every label on the matching series, up to 30)"`
+	SampleValues    int      `json:"sample_values,omitempty" jsonschema:"Example values returned per label (default: 5, max: 50)"`
+	StartTimeISO    string   `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string   `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64  `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Datasource      string   `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+}
+
+// LabelCardinality is the distinct value count of one label, split across
+// the two halves of the window to show growth.
+type LabelCardinality struct {
+	Label          string   `json:"label"`
+	DistinctValues int      `json:"distinct_values"`
+	EarlierValues  int      `json:"earlier_half_values"`
+	LaterValues    int      `json:"later_half_values"`
+	NewValues      int      `json:"new_values"`
+	GrowthPercent  *float64 `json:"growth_percent,omitempty"`
+	SampleValues   []string `json:"sample_values"`
+}
+
+// CardinalityReport is the analyze_cardinality response.
+type CardinalityReport struct {
+	Selector       string             `json:"selector"`
+	StartTime      string             `json:"start_time"`
+	EndTime        string             `json:"end_time"`
+	SeriesCount    *float64           `json:"series_count"`
+	Labels         []LabelCardinality `json:"labels"`
+	FastestGrowing []string           `json:"fastest_growing"`
+	OmittedLabels  []string           `json:"omitted_labels,omitempty"`
+	Caveats        []string           `json:"caveats,omitempty"`
+}
+
+// NewAnalyzeCardinalityHandler reports per-label distinct value counts for a
+// metric or selector, and which labels gained the most new values between
+// the first and second half of the window.
+func NewAnalyzeCardinalityHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, AnalyzeCardinalityArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args AnalyzeCardinalityArgs) (*mcp.CallToolResult, any, error) {
+		selector := strings.TrimSpace(args.Selector)
+		if selector == "" {
+			return nil, nil, fmt.Errorf("selector is required")
+		}
+		for _, label := range args.Labels {
+			if !promLabelNamePattern.MatchString(label) {
+				return nil, nil, fmt.Errorf("invalid label name %q", label)
+			}
+		}
+		samples := args.SampleValues
+		if samples == 0 {
+			samples = defaultCardinalitySampleValues
+		}
+		if samples < 0 || samples > maxCardinalitySampleValues {
+			return nil, nil, fmt.Errorf("sample_values must be between 1 and %d", maxCardinalitySampleValues)
+		}
+
+		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		queryCfg, err := resolveDatasourceCfg(cfg, args.Datasource)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		report := CardinalityReport{
+			Selector:       selector,
+			StartTime:      time.Unix(startTimeParam, 0).UTC().Format(time.RFC3339),
+			EndTime:        time.Unix(endTimeParam, 0).UTC().Format(time.RFC3339),
+			Labels:         []LabelCardinality{},
+			FastestGrowing: []string{},
+		}
+
+		labels := args.Labels
+		if len(labels) == 0 {
+			labels, err = fetchPromLabelNames(ctx, client, queryCfg, selector, startTimeParam, endTimeParam)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		labels = uniqueSorted(labels)
+		if len(labels) > maxCardinalityLabels {
+			report.OmittedLabels = labels[maxCardinalityLabels:]
+			labels = labels[:maxCardinalityLabels]
+			report.Caveats = append(report.Caveats, fmt.Sprintf("Only the first %d labels (alphabetically) were analyzed; pass labels to choose which.", maxCardinalityLabels))
+		}
+
+		if series, err := fetchPromInstant(ctx, client, queryCfg, fmt.Sprintf("count(%s)", selector), endTimeParam); err != nil {
+			report.Caveats = append(report.Caveats, fmt.Sprintf("series count failed: %v", err))
+		} else {
+			report.SeriesCount = promScalar(series)
+		}
+
+		mid := startTimeParam + (endTimeParam-startTimeParam)/2
+		type halves struct{ earlier, later []string }
+		results := make([]halves, len(labels))
+		errs := make([]error, len(labels))
+		sem := make(chan struct{}, maxConcurrentCardinalityQueries)
+		var wg sync.WaitGroup
+		for i, label := range labels {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fetch := func(start, end int64) ([]string, error) {
+					select {
+					case sem <- struct{}{}:
+					case <-ctx.Done():
+						return nil, ctx.Err()
+					}
+					defer func() { <-sem }()
+					return fetchPromLabelValues(ctx, client, queryCfg, label, selector, start, end)
+				}
+				if results[i].earlier, errs[i] = fetch(startTimeParam, mid); errs[i] != nil {
+					return
+				}
+				results[i].later, errs[i] = fetch(mid, endTimeParam)
+			}()
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		for i, label := range labels {
+			if errs[i] != nil {
+				report.Caveats = append(report.Caveats, fmt.Sprintf("label %s failed: %v", label, errs[i]))
+				continue
+			}
+			report.Labels = append(report.Labels, labelCardinality(label, results[i].earlier, results[i].later, samples))
+		}
+		sort.SliceStable(report.Labels, func(i, j int) bool {
+			return report.Labels[i].DistinctValues > report.Labels[j].DistinctValues
+		})
+		report.FastestGrowing = fastestGrowingLabels(report.Labels)
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// labelCardinality compares the values seen in each half of the window.
+// New values are those only seen in the later half.
+func labelCardinality(label string, earlier, later []string, samples int) LabelCardinality {
+	seen := make(map[string]bool, len(earlier))
+	for _, v := range earlier {
+		seen[v] = true
+	}
+	out := LabelCardinality{
+		Label:         label,
+		EarlierValues: len(seen),
+		SampleValues:  []string{},
+	}
+	laterSet := make(map[string]bool, len(later))
+	for _, v := range later {
+		if laterSet[v] {
+			continue
+		}
+		laterSet[v] = true
+		if !seen[v] {
+			out.NewValues++
+		}
+	}
+	out.LaterValues = len(laterSet)
+	out.DistinctValues = out.EarlierValues + out.NewValues
+	if out.EarlierValues > 0 {
+		growth := round1(float64(out.NewValues) / float64(out.EarlierValues) * 100)
+		out.GrowthPercent = &growth
+	}
+
+	all := make([]string, 0, out.DistinctValues)
+	for v := range seen {
+		all = append(all, v)
+	}
+	for v := range laterSet {
+		if !seen[v] {
+			all = append(all, v)
+		}
+	}
+	sort.Strings(all)
+	if len(all) > samples {
+		all = all[:samples]
+	}
+	out.SampleValues = append(out.SampleValues, all...)
+	return out
+}
+
+// fastestGrowingLabels lists labels that gained values in the later half,
+// most new values first.
+func fastestGrowingLabels(labels []LabelCardinality) []string {
+	growing := make([]LabelCardinality, 0, len(labels))
+	for _, l := range labels {
+		if l.NewValues > 0 {
+			growing = append(growing, l)
+		}
+	}
+	sort.SliceStable(growing, func(i, j int) bool { return growing[i].NewValues > growing[j].NewValues })
+	out := []string{}
+	for i, l := range growing {
+		if i == fastestGrowingLimit {
+			break
+		}
+		out = append(out, l.Label)
+	}
+	return out
+}
+
+// fetchPromLabelNames returns the label names present on series matching
+// selector in the window.
+func fetchPromLabelNames(ctx context.Context, client utils.HTTPClient, cfg models.Config, selector string, start, end int64) ([]string, error) {
+	resp, err := utils.MakePromLabelsAPIQuery(ctx, client, selector, start, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to execute Prometheus labels query: %s", resp.Status)
+	}
+	var labels []string
+	if err := json.NewDecoder(resp.Body).Decode(&labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels response: %w", err)
+	}
+	return labels, nil
+}
+
+// fetchPromLabelValues returns the values of label on series matching
+// selector in the window.
+func fetchPromLabelValues(ctx context.Context, client utils.HTTPClient, cfg models.Config, label, selector string, start, end int64) ([]string, error) {
+	resp, err := utils.MakePromLabelValuesAPIQuery(ctx, client, label, selector, start, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to execute Prometheus label values query: %s", resp.Status)
+	}
+	var values []string
+	if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode label values response: %w", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,111 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestLabelCardinality(t *testing.T) {
+	got := labelCardinality("pod", []string{"a", "b", "b"}, []string{"b", "c", "d", "d"}, 2)
+	if got.DistinctValues != 4 || got.EarlierValues != 2 || got.LaterValues != 3 || got.NewValues != 2 {
+		t.Errorf("counts = %+v", got)
+	}
+	if got.GrowthPercent == nil || *got.GrowthPercent != 100 {
+		t.Errorf("growth = %v, want 100", got.GrowthPercent)
+	}
+	if strings.Join(got.SampleValues, ",") != "a,b" {
+		t.Errorf("samples = %v", got.SampleValues)
+	}
+
+	if fresh := labelCardinality("x", nil, []string{"1"}, 5); fresh.GrowthPercent != nil || fresh.NewValues != 1 {
+		t.Errorf("label absent from the earlier half = %+v", fresh)
+	}
+}
+
+func TestFastestGrowingLabels(t *testing.T) {
+	got := fastestGrowingLabels([]LabelCardinality{
+		{Label: "env", NewValues: 0},
+		{Label: "pod", NewValues: 3},
+		{Label: "user_id", NewValues: 40},
+	})
+	if strings.Join(got, ",") != "user_id,pod" {
+		t.Errorf("fastest growing = %v", got)
+	}
+}
+
+func TestAnalyzeCardinalityHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Label     string   `json:"label"`
+			Timestamp int64    `json:"timestamp"`
+			Matches   []string `json:"matches"`
+			Metric    string   `json:"metric"`
+			Query     string   `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/apm/labels"):
+			if body.Metric != `http_requests_total{env="prod"}` {
+				t.Errorf("labels selector = %q", body.Metric)
+			}
+			json.NewEncoder(w).Encode([]string{"pod", "env", "pod"})
+		case strings.HasSuffix(r.URL.Path, "/prom_label_values"):
+			later := body.Timestamp == 1767225600
+			switch {
+			case body.Label == "env":
+				json.NewEncoder(w).Encode([]string{"prod"})
+			case later:
+				json.NewEncoder(w).Encode([]string{"p1", "p2", "p3"})
+			default:
+				json.NewEncoder(w).Encode([]string{"p1"})
+			}
+		case strings.HasSuffix(r.URL.Path, "/prom_query_instant"):
+			if body.Query != `count(http_requests_total{env="prod"})` {
+				t.Errorf("count query = %q", body.Query)
+			}
+			w.Write([]byte(`[{"metric":{},"value":[1767225600,"12"]}]`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewAnalyzeCardinalityHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, AnalyzeCardinalityArgs{
+		Selector:     `http_requests_total{env="prod"}`,
+		StartTimeISO: "2025-12-31T23:00:00Z",
+		EndTimeISO:   "2026-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var report CardinalityReport
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.SeriesCount == nil || *report.SeriesCount != 12 || len(report.Labels) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if pod := report.Labels[0]; pod.Label != "pod" || pod.DistinctValues != 3 || pod.NewValues != 2 {
+		t.Errorf("pod = %+v", pod)
+	}
+	if strings.Join(report.FastestGrowing, ",") != "pod" {
+		t.Errorf("fastest growing = %v", report.FastestGrowing)
+	}
+
+	for _, args := range []AnalyzeCardinalityArgs{
+		{},
+		{Selector: "up", Labels: []string{"bad-label"}},
+		{Selector: "up", SampleValues: 500},
+	} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("expected error for %+v", args)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package apm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range query result encodings accepted by prometheus_range_query.
+const (
+	encodingJSON    = "json"
+	encodingCompact = "compact"
+)
+
+// CompactRangeResult is the column-oriented encoding of a PromQL range
+// result. Timestamps are emitted once and every series carries a values
+// array aligned to them, with nil where the series has no sample. For wide
+// queries this is several times smaller than repeating [ts, "value"] pairs
+// per series.
+type CompactRangeResult struct {
+	Timestamps []int64         `json:"timestamps"`
+	Series     []CompactSeries `json:"series"`
+}
+
+type CompactSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values []*float64        `json:"values"`
+}
+
+// rangeEncoding returns the encoding to use for the encoding argument;
+// anything but compact means json.
+func rangeEncoding(encoding string) string {
+	if strings.EqualFold(strings.TrimSpace(encoding), encodingCompact) {
+		return encodingCompact
+	}
+	return encodingJSON
+}
+
+// decodeCompactRange aligns the series of a raw range response body to a
+// shared, sorted timestamp axis.
+func decodeCompactRange(respBody []byte) (CompactRangeResult, error) {
+	var raw []PromRangeResponse
+	if err := json.Unmarshal(respBody, &raw); err != nil {
+		return CompactRangeResult{}, fmt.Errorf("failed to unmarshal Prometheus response: %w", err)
+	}
+	return compactRange(raw)
+}
+
+// compactRange aligns already decoded series to a shared, sorted timestamp
+// axis. Non-finite samples (NaN, ±Inf) have no JSON representation and are
+// emitted as null alongside genuinely missing points.
+func compactRange(raw []PromRangeResponse) (CompactRangeResult, error) {
+	type sample struct {
+		ts  int64
+		val *float64
+	}
+	perSeries := make([][]sample, len(raw))
+	seen := make(map[int64]struct{})
+	for i, r := range raw {
+		perSeries[i] = make([]sample, 0, len(r.Values))
+		for _, v := range r.Values {
+			if len(v) != 2 {
+				return CompactRangeResult{}, fmt.Errorf("invalid value format in Prometheus response: %v", v)
+			}
+			tsFloat, ok := v[0].(float64)
+			if !ok {
+				return CompactRangeResult{}, fmt.Errorf("invalid timestamp type in Prometheus response: %T", v[0])
+			}
+			valStr, ok := v[1].(string)
+			if !ok {
+				return CompactRangeResult{}, fmt.Errorf("invalid value type in Prometheus response: %T", v[1])
+			}
+			ts := int64(tsFloat)
+			seen[ts] = struct{}{}
+			val, err := strconv.ParseFloat(valStr, 64)
+			if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+				perSeries[i] = append(perSeries[i], sample{ts: ts})
+				continue
+			}
+			perSeries[i] = append(perSeries[i], sample{ts: ts, val: &val})
+		}
+	}
+
+	out := CompactRangeResult{
+		Timestamps: make([]int64, 0, len(seen)),
+		Series:     make([]CompactSeries, 0, len(raw)),
+	}
+	for ts := range seen {
+		out.Timestamps = append(out.Timestamps, ts)
+	}
+	sort.Slice(out.Timestamps, func(i, j int) bool { return out.Timestamps[i] < out.Timestamps[j] })
+	index := make(map[int64]int, len(out.Timestamps))
+	for i, ts := range out.Timestamps {
+		index[ts] = i
+	}
+
+	for i, r := range raw {
+		metric := r.Metric
+		if metric == nil {
+			metric = map[string]string{}
+		}
+		values := make([]*float64, len(out.Timestamps))
+		for _, s := range perSeries[i] {
+			values[index[s.ts]] = s.val
+		}
+		out.Series = append(out.Series, CompactSeries{Metric: metric, Values: values})
+	}
+
+	return out, nil
+}
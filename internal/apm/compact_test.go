@@ -0,0 +1,99 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestCompactRange_AlignsSeriesOnSharedTimestamps(t *testing.T) {
+	var raw []PromRangeResponse
+	if err := json.Unmarshal([]byte(`[
+		{"metric": {"service_name": "a"}, "values": [[1700000000, "1"], [1700000060, "2"]]},
+		{"metric": {"service_name": "b"}, "values": [[1700000060, "5"], [1700000120, "NaN"]]}
+	]`), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := compactRange(raw)
+	if err != nil {
+		t.Fatalf("compactRange() error = %v", err)
+	}
+
+	wantTS := []int64{1700000000, 1700000060, 1700000120}
+	if len(got.Timestamps) != len(wantTS) {
+		t.Fatalf("timestamps = %v, want %v", got.Timestamps, wantTS)
+	}
+	for i := range wantTS {
+		if got.Timestamps[i] != wantTS[i] {
+			t.Fatalf("timestamps = %v, want %v", got.Timestamps, wantTS)
+		}
+	}
+	if len(got.Series) != 2 {
+		t.Fatalf("series count = %d, want 2", len(got.Series))
+	}
+
+	a := got.Series[0]
+	if a.Metric["service_name"] != "a" || a.Values[0] == nil || *a.Values[0] != 1 || a.Values[2] != nil {
+		t.Errorf("series a values misaligned: %+v", a.Values)
+	}
+	b := got.Series[1]
+	if b.Values[0] != nil || b.Values[1] == nil || *b.Values[1] != 5 {
+		t.Errorf("series b values misaligned: %+v", b.Values)
+	}
+	if b.Values[2] != nil {
+		t.Errorf("NaN sample should encode as null, got %v", *b.Values[2])
+	}
+}
+
+func TestCompactRange_Empty(t *testing.T) {
+	got, err := compactRange(nil)
+	if err != nil {
+		t.Fatalf("compactRange() error = %v", err)
+	}
+	out, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"timestamps":[],"series":[]}` {
+		t.Fatalf("empty result = %s", out)
+	}
+}
+
+func TestRangeEncoding(t *testing.T) {
+	for in, want := range map[string]string{"": encodingJSON, "json": encodingJSON, "compact": encodingCompact, " Compact": encodingCompact} {
+		if got := rangeEncoding(in); got != want {
+			t.Errorf("rangeEncoding(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPromqlRangeHandler_CompactEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `[{"metric": {"job": "api"}, "values": [[1700000000, "0.5"], [1700000060, "0.75"]]}]`)
+	}))
+	defer server.Close()
+
+	handler := NewPromqlRangeQueryHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, PromqlRangeQueryArgs{
+		Query:    "up",
+		Encoding: "compact",
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var got CompactRangeResult
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+		t.Fatalf("response is not compact-encoded: %v", err)
+	}
+	if len(got.Timestamps) != 2 || len(got.Series) != 1 || *got.Series[0].Values[1] != 0.75 {
+		t.Fatalf("unexpected compact result: %+v", got)
+	}
+}
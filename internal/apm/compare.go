@@ -0,0 +1,292 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- compare_services tool ---
+
+type CompareServicesArgs struct {
+	ServiceNames    []string `json:"service_names" jsonschema:"Services to compare, 2 to 10 (required, e.g. [checkout, payments, cart])"`
+	Env             string   `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	LookbackMinutes float64  `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string   `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string   `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+const (
+	minCompareServices = 2
+	maxCompareServices = 10
+)
+
+// Compared signals.
+const (
+	compareRequests   = "requests"
+	compareErrors     = "errors"
+	compareLatencyP95 = "p95_latency"
+	compareApdex      = "apdex"
+)
+
+// ServiceComparison is one row of the compare_services table. Ranks run
+// from 1, the most suspicious service: highest error percent, highest p95,
+// lowest apdex, and for throughput the busiest. A signal without data has
+// no value and no rank.
+type ServiceComparison struct {
+	ServiceName      string   `json:"service_name"`
+	Throughput       *float64 `json:"throughput_rpm,omitempty"`
+	ErrorPercent     *float64 `json:"error_percent,omitempty"`
+	P95Latency       *float64 `json:"p95_latency,omitempty"`
+	Apdex            *float64 `json:"apdex,omitempty"`
+	ThroughputRank   int      `json:"throughput_rank,omitempty"`
+	ErrorPercentRank int      `json:"error_percent_rank,omitempty"`
+	LatencyRank      int      `json:"p95_latency_rank,omitempty"`
+	ApdexRank        int      `json:"apdex_rank,omitempty"`
+	// Rank orders services by their mean error, latency and apdex rank.
+	Rank   int  `json:"rank"`
+	NoData bool `json:"no_data,omitempty"`
+}
+
+// ServiceComparisonResult is the response of compare_services.
+type ServiceComparisonResult struct {
+	Env      string              `json:"env"`
+	Window   ReleaseWindow       `json:"window"`
+	Services []ServiceComparison `json:"services"`
+	// Suspect is the top-ranked service and why, empty when no service has
+	// data.
+	Suspect       string        `json:"suspect,omitempty"`
+	SuspectReason string        `json:"suspect_reason,omitempty"`
+	Meta          *ResponseMeta `json:"_meta,omitempty"`
+}
+
+// validateCompareServices trims and de-duplicates names, keeping their
+// order, and checks how many there are.
+func validateCompareServices(names []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	if len(out) < minCompareServices || len(out) > maxCompareServices {
+		return nil, fmt.Errorf("service_names must list between %d and %d distinct services, got %d", minCompareServices, maxCompareServices, len(out))
+	}
+	return out, nil
+}
+
+// rankComparisons fills in the ranks of rows, sorts them by Rank (ties by
+// error percent, then name) and returns the suspect's reason. It is pure so
+// the ranking can be tested without a backend.
+func rankComparisons(rows []ServiceComparison) string {
+	rankBy := func(value func(*ServiceComparison) *float64, higherIsWorse bool, rank func(*ServiceComparison) *int) {
+		var idx []int
+		for i := range rows {
+			if value(&rows[i]) != nil {
+				idx = append(idx, i)
+			}
+		}
+		sort.SliceStable(idx, func(a, b int) bool {
+			va, vb := *value(&rows[idx[a]]), *value(&rows[idx[b]])
+			if higherIsWorse {
+				return va > vb
+			}
+			return va < vb
+		})
+		for r, i := range idx {
+			*rank(&rows[i]) = r + 1
+			// Equal values share a rank.
+			if prev := idx[max(r-1, 0)]; r > 0 && *value(&rows[i]) == *value(&rows[prev]) {
+				*rank(&rows[i]) = *rank(&rows[prev])
+			}
+		}
+	}
+	rankBy(func(s *ServiceComparison) *float64 { return s.Throughput }, true, func(s *ServiceComparison) *int { return &s.ThroughputRank })
+	rankBy(func(s *ServiceComparison) *float64 { return s.ErrorPercent }, true, func(s *ServiceComparison) *int { return &s.ErrorPercentRank })
+	rankBy(func(s *ServiceComparison) *float64 { return s.P95Latency }, true, func(s *ServiceComparison) *int { return &s.LatencyRank })
+	rankBy(func(s *ServiceComparison) *float64 { return s.Apdex }, false, func(s *ServiceComparison) *int { return &s.ApdexRank })
+
+	meanRank := func(s ServiceComparison) float64 {
+		sum, n := 0, 0
+		for _, r := range []int{s.ErrorPercentRank, s.LatencyRank, s.ApdexRank} {
+			if r > 0 {
+				sum += r
+				n++
+			}
+		}
+		if n == 0 {
+			return float64(len(rows) + 1)
+		}
+		return float64(sum) / float64(n)
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		ma, mb := meanRank(rows[a]), meanRank(rows[b])
+		if ma != mb {
+			return ma < mb
+		}
+		ea, eb := valueOr(rows[a].ErrorPercent, -1), valueOr(rows[b].ErrorPercent, -1)
+		if ea != eb {
+			return ea > eb
+		}
+		return rows[a].ServiceName < rows[b].ServiceName
+	})
+	for i := range rows {
+		rows[i].Rank = i + 1
+	}
+
+	top := rows[0]
+	if top.NoData {
+		return ""
+	}
+	var worst []string
+	if top.ErrorPercentRank == 1 && top.ErrorPercent != nil && *top.ErrorPercent > 0 {
+		worst = append(worst, fmt.Sprintf("highest error rate (%.2f%%)", *top.ErrorPercent))
+	}
+	if top.LatencyRank == 1 && top.P95Latency != nil {
+		worst = append(worst, fmt.Sprintf("highest p95 latency (%.4g)", *top.P95Latency))
+	}
+	if top.ApdexRank == 1 && top.Apdex != nil {
+		worst = append(worst, fmt.Sprintf("lowest apdex (%.2f)", *top.Apdex))
+	}
+	if len(worst) == 0 {
+		return "worst on average across error rate, p95 latency and apdex, though not the worst on any one"
+	}
+	return strings.Join(worst, ", ")
+}
+
+func valueOr(v *float64, fallback float64) float64 {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+func NewCompareServicesHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, CompareServicesArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args CompareServicesArgs) (*mcp.CallToolResult, any, error) {
+		services, err := validateCompareServices(args.ServiceNames)
+		if err != nil {
+			return nil, nil, err
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		durationMin := max((endTime-startTime)/60, 1)
+		env := resolveEnv(cfg, args.Env)
+
+		patterns := make([]string, len(services))
+		for i, s := range services {
+			patterns[i] = regexp.QuoteMeta(s)
+		}
+		sel := fmt.Sprintf(`service_name=~"%s", env=~"%s"`, escapePromQLLabel(strings.Join(patterns, "|")), escapePromQLLabel(env))
+		serverSel := sel + `, span_kind="SPAN_KIND_SERVER"`
+		queries := map[string]string{
+			compareRequests:   fmt.Sprintf(`sum by (service_name) (sum_over_time(trace_endpoint_count{%s}[%dm]))`, serverSel, durationMin),
+			compareErrors:     fmt.Sprintf(`sum by (service_name) (sum_over_time(trace_endpoint_count{%s, status_code="STATUS_CODE_ERROR"}[%dm]))`, serverSel, durationMin),
+			compareLatencyP95: fmt.Sprintf(`max by (service_name) (avg_over_time(trace_service_response_time{%s, quantile="p95"}[%dm]))`, sel, durationMin),
+			compareApdex:      fmt.Sprintf(`avg by (service_name) (avg_over_time(trace_service_apdex_score{%s}[%dm]))`, sel, durationMin),
+		}
+
+		var (
+			mu       sync.Mutex
+			values   = make(map[string]map[string]float64, len(queries))
+			failures []string
+			wg       sync.WaitGroup
+		)
+		for name, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s query failed: %v", name, err))
+					return
+				}
+				byService := map[string]float64{}
+				for _, s := range series {
+					if v := promScalar(apiPromInstantResp{s}); v != nil {
+						byService[s.Metric["service_name"]] = *v
+					}
+				}
+				values[name] = byService
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		lookup := func(signal, service string) *float64 {
+			if v, ok := values[signal][service]; ok {
+				return &v
+			}
+			return nil
+		}
+		rows := make([]ServiceComparison, len(services))
+		var missing []string
+		for i, s := range services {
+			row := ServiceComparison{ServiceName: s, P95Latency: lookup(compareLatencyP95, s)}
+			if v := lookup(compareApdex, s); v != nil {
+				a := round3(*v)
+				row.Apdex = &a
+			}
+			if requests := lookup(compareRequests, s); requests != nil {
+				rpm := round1(*requests / float64(durationMin))
+				row.Throughput = &rpm
+				if _, ok := values[compareErrors]; ok && *requests > 0 {
+					errPct := round1(100 * valueOr(lookup(compareErrors, s), 0) / *requests)
+					row.ErrorPercent = &errPct
+				}
+			}
+			if row.Throughput == nil && row.P95Latency == nil && row.Apdex == nil {
+				row.NoData = true
+				missing = append(missing, s)
+			}
+			rows[i] = row
+		}
+		reason := rankComparisons(rows)
+
+		sort.Strings(failures)
+		caveats := failures
+		if len(missing) > 0 {
+			caveats = append(caveats, fmt.Sprintf("no data for %s; check the names with did_you_mean", strings.Join(missing, ", ")))
+		}
+		result := ServiceComparisonResult{
+			Env:      env,
+			Window:   ReleaseWindow{Start: time.Unix(startTime, 0).UTC().Format(time.RFC3339), End: time.Unix(endTime, 0).UTC().Format(time.RFC3339)},
+			Services: rows,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf("trace_endpoint_count{%s}", serverSel),
+			), caveats...),
+		}
+		if reason != "" {
+			result.Suspect, result.SuspectReason = rows[0].ServiceName, reason
+		}
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
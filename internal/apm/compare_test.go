@@ -0,0 +1,52 @@
+package apm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCompareServices(t *testing.T) {
+	got, err := validateCompareServices([]string{" checkout ", "cart", "checkout", ""})
+	if err != nil || strings.Join(got, ",") != "checkout,cart" {
+		t.Errorf("validateCompareServices = %v, %v", got, err)
+	}
+	if _, err := validateCompareServices([]string{"checkout", "checkout"}); err == nil {
+		t.Error("expected an error for a single distinct service")
+	}
+	if _, err := validateCompareServices(strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")); err == nil {
+		t.Error("expected an error for 11 services")
+	}
+}
+
+func TestRankComparisons(t *testing.T) {
+	rows := []ServiceComparison{
+		{ServiceName: "cart", Throughput: ptr(500), ErrorPercent: ptr(0.1), P95Latency: ptr(0.12), Apdex: ptr(0.98)},
+		{ServiceName: "payments", Throughput: ptr(80), ErrorPercent: ptr(4.2), P95Latency: ptr(0.9), Apdex: ptr(0.71)},
+		{ServiceName: "ghost", NoData: true},
+		{ServiceName: "checkout", Throughput: ptr(300), ErrorPercent: ptr(0.1), P95Latency: ptr(0.3), Apdex: ptr(0.95)},
+	}
+	reason := rankComparisons(rows)
+
+	var order []string
+	for _, r := range rows {
+		order = append(order, r.ServiceName)
+	}
+	if got := strings.Join(order, ","); got != "payments,checkout,cart,ghost" {
+		t.Errorf("order = %s", got)
+	}
+	if !strings.Contains(reason, "highest error rate (4.20%)") || !strings.Contains(reason, "lowest apdex") {
+		t.Errorf("reason = %q", reason)
+	}
+	// cart and checkout share the error percent rank.
+	if rows[1].ErrorPercentRank != 2 || rows[2].ErrorPercentRank != 2 {
+		t.Errorf("tied error ranks = %d, %d, want 2, 2", rows[1].ErrorPercentRank, rows[2].ErrorPercentRank)
+	}
+	if rows[2].ThroughputRank != 1 || rows[3].ThroughputRank != 0 || rows[3].Rank != 4 {
+		t.Errorf("cart throughput rank = %d, ghost = %+v", rows[2].ThroughputRank, rows[3])
+	}
+
+	none := []ServiceComparison{{ServiceName: "a", NoData: true}, {ServiceName: "b", NoData: true}}
+	if reason := rankComparisons(none); reason != "" {
+		t.Errorf("no data: reason = %q, want none", reason)
+	}
+}
@@ -0,0 +1,257 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_component_consumers tool ---
+
+type GetComponentConsumersArgs struct {
+	HostOrSystem    string  `json:"host_or_system" jsonschema:"Database or broker host, db or messaging system, or topic/queue to look up; hosts and topics match as a case-insensitive substring (required, e.g. pg-main.internal, postgresql, kafka, orders)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+// componentGroup are the trace_internal_call_graph labels a consumer row is
+// keyed by: the calling service and the component it calls.
+const componentGroup = "client, server_host, server_db_system, server_messaging_system, server_rpc_system, server_rpc_service"
+
+// Component kinds.
+const (
+	componentDatabase  = "database"
+	componentMessaging = "messaging"
+)
+
+// ComponentConsumer is a service calling a matching database or producing
+// to a matching broker, with the RED metrics of those calls.
+type ComponentConsumer struct {
+	ServiceName     string   `json:"service_name"`
+	Kind            string   `json:"kind"`
+	Host            string   `json:"host,omitempty"`
+	DBSystem        string   `json:"db_system,omitempty"`
+	MessagingSystem string   `json:"messaging_system,omitempty"`
+	Destination     string   `json:"destination,omitempty"`
+	MatchedOn       []string `json:"matched_on"`
+	Throughput      float64  `json:"throughput_rpm"`
+	ErrorPercent    *float64 `json:"error_percent,omitempty"`
+	P95Latency      *float64 `json:"p95_latency_ms,omitempty"`
+}
+
+// ComponentConsumersResult is the response of get_component_consumers.
+type ComponentConsumersResult struct {
+	HostOrSystem string              `json:"host_or_system"`
+	Env          string              `json:"env"`
+	Window       ReleaseWindow       `json:"window"`
+	Services     []string            `json:"services"`
+	Summary      string              `json:"summary"`
+	Consumers    []ComponentConsumer `json:"consumers"`
+	Meta         *ResponseMeta       `json:"_meta,omitempty"`
+}
+
+// componentMatch reports which labels of an internal call graph series
+// match needle: the host and destination as a case-insensitive substring,
+// the db and messaging systems exactly.
+func componentMatch(metric map[string]string, needle string) []string {
+	needle = strings.ToLower(needle)
+	var matched []string
+	for _, label := range []string{"server_host", "server_rpc_service"} {
+		if v := strings.ToLower(metric[label]); v != "" && strings.Contains(v, needle) {
+			matched = append(matched, label)
+		}
+	}
+	for _, label := range []string{"server_db_system", "server_messaging_system"} {
+		if strings.EqualFold(metric[label], needle) {
+			matched = append(matched, label)
+		}
+	}
+	return matched
+}
+
+// componentConsumersQuery returns the or of one selector per matched label,
+// so a series matching on several labels is returned once.
+func componentConsumersQuery(format, needle string) string {
+	substring := "(?i).*" + escapePromQLLabel(regexp.QuoteMeta(needle)) + ".*"
+	exact := "(?i)" + escapePromQLLabel(regexp.QuoteMeta(needle))
+	matchers := []string{
+		fmt.Sprintf(`server_host=~"%s"`, substring),
+		fmt.Sprintf(`server_rpc_service=~"%s"`, substring),
+		fmt.Sprintf(`server_db_system=~"%s"`, exact),
+		fmt.Sprintf(`server_messaging_system=~"%s"`, exact),
+	}
+	branches := make([]string, len(matchers))
+	for i, m := range matchers {
+		branches[i] = fmt.Sprintf(format, m)
+	}
+	return strings.Join(branches, " or ")
+}
+
+// componentKey identifies a consumer row.
+func componentKey(metric map[string]string) string {
+	return strings.Join([]string{metric["client"], metric["server_host"], metric["server_db_system"], metric["server_messaging_system"], metric["server_rpc_system"], metric["server_rpc_service"]}, "\x00")
+}
+
+// summarizeConsumers names the services that depend on the matched
+// components, busiest first.
+func summarizeConsumers(needle string, consumers []ComponentConsumer) (services []string, summary string) {
+	byService := map[string]float64{}
+	for _, c := range consumers {
+		byService[c.ServiceName] += c.Throughput
+	}
+	for s := range byService {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if byService[services[i]] != byService[services[j]] {
+			return byService[services[i]] > byService[services[j]]
+		}
+		return services[i] < services[j]
+	})
+	if len(services) == 0 {
+		return []string{}, fmt.Sprintf("No service called a database or broker matching %q in the window.", needle)
+	}
+	noun := "services depend"
+	if len(services) == 1 {
+		noun = "service depends"
+	}
+	return services, fmt.Sprintf("%d %s on components matching %q: %s. Restarting them affects these services' calls.", len(services), noun, needle, strings.Join(services, ", "))
+}
+
+func NewGetComponentConsumersHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetComponentConsumersArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetComponentConsumersArgs) (*mcp.CallToolResult, any, error) {
+		needle := strings.TrimSpace(args.HostOrSystem)
+		if needle == "" {
+			return nil, nil, fmt.Errorf("host_or_system is required")
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		durationMin := max((endTime-startTime)/60, 1)
+		env := resolveEnv(cfg, args.Env)
+		envSel := fmt.Sprintf(`env=~"%s"`, escapePromQLLabel(env))
+
+		queries := map[string]string{
+			compareRequests:   componentConsumersQuery(fmt.Sprintf(`sum by (%s) (sum_over_time(trace_internal_call_graph_count{%s, %%s}[%dm])) / %d`, componentGroup, envSel, durationMin, durationMin), needle),
+			compareErrors:     componentConsumersQuery(fmt.Sprintf(`sum by (%s) (sum_over_time(trace_internal_call_graph_count{%s, client_status=~"4.*|5.*", %%s}[%dm])) / %d`, componentGroup, envSel, durationMin, durationMin), needle),
+			compareLatencyP95: componentConsumersQuery(fmt.Sprintf(`max by (%s) (avg_over_time(trace_internal_call_graph_duration{%s, quantile="p95", %%s}[%dm]))`, componentGroup, envSel, durationMin), needle),
+		}
+
+		var (
+			mu       sync.Mutex
+			series   = make(map[string]apiPromInstantResp, len(queries))
+			failures []string
+			wg       sync.WaitGroup
+		)
+		for name, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s query failed: %v", name, err))
+					return
+				}
+				series[name] = result
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := series[compareRequests]; !ok {
+			return nil, nil, fmt.Errorf("failed to fetch component consumers: %s", strings.Join(failures, "; "))
+		}
+
+		values := func(name string) map[string]float64 {
+			out := map[string]float64{}
+			for _, s := range series[name] {
+				if v := promScalar(apiPromInstantResp{s}); v != nil {
+					out[componentKey(s.Metric)] = *v
+				}
+			}
+			return out
+		}
+		errs, p95s := values(compareErrors), values(compareLatencyP95)
+		consumers := []ComponentConsumer{}
+		for _, s := range series[compareRequests] {
+			matched := componentMatch(s.Metric, needle)
+			rpm := promScalar(apiPromInstantResp{s})
+			if s.Metric["client"] == "" || len(matched) == 0 || rpm == nil {
+				continue
+			}
+			c := ComponentConsumer{
+				ServiceName:     s.Metric["client"],
+				Kind:            componentDatabase,
+				Host:            s.Metric["server_host"],
+				DBSystem:        s.Metric["server_db_system"],
+				MessagingSystem: s.Metric["server_messaging_system"],
+				Destination:     s.Metric["server_rpc_service"],
+				MatchedOn:       matched,
+				Throughput:      round1(*rpm),
+			}
+			if c.DBSystem == "" && c.MessagingSystem != "" {
+				c.Kind = componentMessaging
+			}
+			key := componentKey(s.Metric)
+			if _, ok := series[compareErrors]; ok && *rpm > 0 {
+				errPct := round1(100 * errs[key] / *rpm)
+				c.ErrorPercent = &errPct
+			}
+			if p95, ok := p95s[key]; ok {
+				p95 = round1(p95)
+				c.P95Latency = &p95
+			}
+			consumers = append(consumers, c)
+		}
+		sort.Slice(consumers, func(i, j int) bool {
+			if consumers[i].Throughput != consumers[j].Throughput {
+				return consumers[i].Throughput > consumers[j].Throughput
+			}
+			return consumers[i].ServiceName < consumers[j].ServiceName
+		})
+		services, summary := summarizeConsumers(needle, consumers)
+
+		sort.Strings(failures)
+		caveats := failures
+		if len(consumers) == 0 {
+			caveats = append(caveats, "no calls matched; only database and messaging calls recorded in trace_internal_call_graph_count are covered, so HTTP and gRPC callers are not listed (see get_service_dependency_graph)")
+		}
+		result := ComponentConsumersResult{
+			HostOrSystem: needle,
+			Env:          env,
+			Window:       ReleaseWindow{Start: time.Unix(startTime, 0).UTC().Format(time.RFC3339), End: time.Unix(endTime, 0).UTC().Format(time.RFC3339)},
+			Services:     services,
+			Summary:      summary,
+			Consumers:    consumers,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf("trace_internal_call_graph_count{%s}", envSel),
+			), caveats...),
+		}
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,62 @@
+package apm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComponentMatch(t *testing.T) {
+	db := map[string]string{"client": "checkout", "server_host": "pg-main.shop.internal", "server_db_system": "postgresql"}
+	kafka := map[string]string{"client": "payments", "server_host": "kafka.shop.internal", "server_messaging_system": "kafka", "server_rpc_system": "kafka", "server_rpc_service": "payment-events"}
+	tests := []struct {
+		metric map[string]string
+		needle string
+		want   []string
+	}{
+		{db, "PG-Main", []string{"server_host"}},
+		{db, "postgresql", []string{"server_db_system"}},
+		{db, "postgres", nil}, // systems match exactly
+		{kafka, "kafka", []string{"server_host", "server_messaging_system"}},
+		{kafka, "payment-events", []string{"server_rpc_service"}},
+		{kafka, "pg-main", nil},
+	}
+	for _, tc := range tests {
+		if got := componentMatch(tc.metric, tc.needle); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("componentMatch(%s, %q) = %v, want %v", tc.metric["client"], tc.needle, got, tc.want)
+		}
+	}
+}
+
+func TestComponentConsumersQuery(t *testing.T) {
+	q := componentConsumersQuery(`sum(trace_internal_call_graph_count{%s})`, `pg.main"`)
+	branches := strings.Split(q, " or ")
+	want := []string{
+		`sum(trace_internal_call_graph_count{server_host=~"(?i).*pg\\.main\".*"})`,
+		`sum(trace_internal_call_graph_count{server_rpc_service=~"(?i).*pg\\.main\".*"})`,
+		`sum(trace_internal_call_graph_count{server_db_system=~"(?i)pg\\.main\""})`,
+		`sum(trace_internal_call_graph_count{server_messaging_system=~"(?i)pg\\.main\""})`,
+	}
+	if !reflect.DeepEqual(branches, want) {
+		t.Errorf("query branches = %q, want %q", branches, want)
+	}
+}
+
+func TestSummarizeConsumers(t *testing.T) {
+	services, summary := summarizeConsumers("kafka", []ComponentConsumer{
+		{ServiceName: "payments", Throughput: 290},
+		{ServiceName: "checkout", Throughput: 300},
+		{ServiceName: "payments", Throughput: 50},
+	})
+	if !reflect.DeepEqual(services, []string{"payments", "checkout"}) {
+		t.Errorf("services = %v, want payments (340 rpm) before checkout", services)
+	}
+	if !strings.HasPrefix(summary, `2 services depend on components matching "kafka": payments, checkout.`) {
+		t.Errorf("summary = %s", summary)
+	}
+
+	services, summary = summarizeConsumers("mysql", nil)
+	if len(services) != 0 || !strings.HasPrefix(summary, "No service") {
+		t.Errorf("empty = %v, %s", services, summary)
+	}
+}
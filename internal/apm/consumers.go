@@ -0,0 +1,211 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_consumer_operations tool ---
+
+type GetConsumerOperationsArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"Name of the service whose message consumers to summarise (required)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	MessagingSystem string  `json:"messaging_system,omitempty" jsonschema:"Restrict to one messaging system (e.g. kafka, rabbitmq, aws_sqs)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+// ConsumerOperation is one topic/queue a service consumes from.
+type ConsumerOperation struct {
+	MessagingSystem string  `json:"messaging_system"`
+	Destination     string  `json:"destination"`
+	Operation       string  `json:"operation,omitempty"`
+	SpanName        string  `json:"span_name"`
+	Throughput      float64 `json:"throughput_rpm"`
+	ErrorRate       float64 `json:"error_rpm"`
+	ErrorPercent    float64 `json:"error_percent"`
+	P95Latency      float64 `json:"p95_processing_ms"`
+}
+
+// consumerLagCaveat explains what the latency figure does and does not cover.
+const consumerLagCaveat = "consumer lag (messages waiting in the broker) is not derivable from spans; p95_processing_ms is the time spent handling each message"
+
+// consumerOperations are the operation names the OpenTelemetry messaging
+// conventions append to consumer span names ("orders process").
+var consumerOperations = map[string]bool{
+	"process": true, "receive": true, "deliver": true, "settle": true, "consume": true,
+}
+
+// splitConsumerSpanName splits "orders process" into destination and operation.
+// Span names without a recognised operation suffix are returned as the
+// destination with an empty operation.
+func splitConsumerSpanName(spanName string) (destination, operation string) {
+	if i := strings.LastIndex(spanName, " "); i > 0 {
+		if op := strings.ToLower(spanName[i+1:]); consumerOperations[op] {
+			return strings.TrimSpace(spanName[:i]), op
+		}
+	}
+	return spanName, ""
+}
+
+func NewGetConsumerOperationsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetConsumerOperationsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetConsumerOperationsArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		durationMin := (endTime - startTime) / 60
+		if durationMin <= 0 {
+			durationMin = 1
+		}
+
+		env := resolveEnv(cfg, args.Env)
+		messagingSystem := `.+`
+		if args.MessagingSystem != "" {
+			messagingSystem = escapePromQLLabel(args.MessagingSystem)
+		}
+
+		baseFilter := fmt.Sprintf(
+			`service_name="%s", env=~"%s", span_kind="SPAN_KIND_CONSUMER", messaging_system=~"%s"`,
+			escapePromQLLabel(args.ServiceName), escapePromQLLabel(env), messagingSystem,
+		)
+
+		statusQuery := fmt.Sprintf(
+			`sum by(span_name, messaging_system, status_code)(sum_over_time(trace_endpoint_count{%s}[%dm])) / %d`,
+			baseFilter, durationMin, durationMin,
+		)
+		latencyQuery := fmt.Sprintf(
+			`max by(span_name, messaging_system)(avg_over_time(trace_endpoint_duration{%s, quantile="p95"}[%dm]))`,
+			baseFilter, durationMin,
+		)
+
+		var (
+			statusSeries  apiPromInstantResp
+			latencySeries apiPromInstantResp
+			statusErr     error
+			latencyErr    error
+			wg            sync.WaitGroup
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			statusSeries, statusErr = fetchPromInstant(ctx, client, cfg, statusQuery, endTime)
+		}()
+		go func() {
+			defer wg.Done()
+			latencySeries, latencyErr = fetchPromInstant(ctx, client, cfg, latencyQuery, endTime)
+		}()
+		wg.Wait()
+
+		if statusErr != nil {
+			return nil, nil, fmt.Errorf("failed to fetch consumer throughput: %w", statusErr)
+		}
+
+		key := func(metric map[string]string) string {
+			return metric["messaging_system"] + "\x00" + metric["span_name"]
+		}
+		operations := make(map[string]*ConsumerOperation)
+		for _, point := range statusSeries {
+			spanName := point.Metric["span_name"]
+			if spanName == "" {
+				continue
+			}
+			op, ok := operations[key(point.Metric)]
+			if !ok {
+				destination, operation := splitConsumerSpanName(spanName)
+				op = &ConsumerOperation{
+					MessagingSystem: point.Metric["messaging_system"],
+					Destination:     destination,
+					Operation:       operation,
+					SpanName:        spanName,
+				}
+				operations[key(point.Metric)] = op
+			}
+			val := parsePromValue(point.Value)
+			op.Throughput += val
+			if point.Metric["status_code"] == "STATUS_CODE_ERROR" {
+				op.ErrorRate += val
+			}
+		}
+
+		var warnings []string
+		if latencyErr != nil {
+			warnings = append(warnings, "p95 processing latency unavailable")
+		}
+		for _, point := range latencySeries {
+			if op, ok := operations[key(point.Metric)]; ok {
+				op.P95Latency = parsePromValue(point.Value)
+			}
+		}
+
+		if len(operations) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("No consumer (SPAN_KIND_CONSUMER) operations found for service %q (env=~%q) in the given time range.", args.ServiceName, env)},
+				},
+			}, nil, nil
+		}
+
+		result := make([]ConsumerOperation, 0, len(operations))
+		for _, op := range operations {
+			if op.Throughput > 0 {
+				op.ErrorPercent = op.ErrorRate / op.Throughput * 100
+			}
+			result = append(result, *op)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Throughput != result[j].Throughput {
+				return result[i].Throughput > result[j].Throughput
+			}
+			return result[i].SpanName < result[j].SpanName
+		})
+
+		meta := buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+			fmt.Sprintf("trace_endpoint_count{%s}", baseFilter),
+		))
+		// Informational only, so it does not lower confidence.
+		meta.Caveats = append(meta.Caveats, consumerLagCaveat)
+
+		response := map[string]any{
+			"service_name": args.ServiceName,
+			"env":          env,
+			"count":        len(result),
+			"consumers":    result,
+			"_meta":        meta,
+		}
+		if len(warnings) > 0 {
+			response["_warnings"] = warnings
+		}
+
+		jsonBytes, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "operations")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
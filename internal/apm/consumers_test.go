@@ -0,0 +1,110 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSplitConsumerSpanName(t *testing.T) {
+	tests := []struct {
+		in, destination, operation string
+	}{
+		{"orders process", "orders", "process"},
+		{"payments.events receive", "payments.events", "receive"},
+		{"orders Process", "orders", "process"},
+		{"OrderConsumer.handle", "OrderConsumer.handle", ""},
+		{"process", "process", ""},
+	}
+	for _, tt := range tests {
+		destination, operation := splitConsumerSpanName(tt.in)
+		if destination != tt.destination || operation != tt.operation {
+			t.Errorf("splitConsumerSpanName(%q) = (%q, %q), want (%q, %q)", tt.in, destination, operation, tt.destination, tt.operation)
+		}
+	}
+}
+
+func TestGetConsumerOperationsHandler(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+
+		var response []map[string]any
+		switch {
+		case strings.Contains(body.Query, "by(span_name, messaging_system, status_code)"):
+			response = []map[string]any{
+				{"metric": map[string]string{"span_name": "orders process", "messaging_system": "kafka", "status_code": "STATUS_CODE_UNSET"}, "value": []any{1700000000, "45"}},
+				{"metric": map[string]string{"span_name": "orders process", "messaging_system": "kafka", "status_code": "STATUS_CODE_ERROR"}, "value": []any{1700000000, "5"}},
+				{"metric": map[string]string{"span_name": "emails receive", "messaging_system": "rabbitmq", "status_code": "STATUS_CODE_OK"}, "value": []any{1700000000, "3"}},
+			}
+		case strings.Contains(body.Query, "trace_endpoint_duration"):
+			response = []map[string]any{
+				{"metric": map[string]string{"span_name": "orders process", "messaging_system": "kafka"}, "value": []any{1700000000, "250"}},
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	handler := NewGetConsumerOperationsHandler(server.Client(), testDBConfig(server.URL))
+	now := time.Now().UTC()
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetConsumerOperationsArgs{
+		ServiceName:  "worker",
+		Env:          "prod",
+		StartTimeISO: now.Add(-60 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var response struct {
+		Count     int                 `json:"count"`
+		Consumers []ConsumerOperation `json:"consumers"`
+		Meta      ResponseMeta        `json:"_meta"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Count != 2 {
+		t.Fatalf("count = %d, want 2", response.Count)
+	}
+
+	top := response.Consumers[0]
+	if top.MessagingSystem != "kafka" || top.Destination != "orders" || top.Operation != "process" {
+		t.Errorf("top consumer = %+v, want kafka orders process", top)
+	}
+	if top.Throughput != 50 || top.ErrorRate != 5 || top.ErrorPercent != 10 || top.P95Latency != 250 {
+		t.Errorf("top consumer metrics = %+v", top)
+	}
+	if !strings.Contains(strings.Join(response.Meta.Caveats, "\n"), "consumer lag") {
+		t.Errorf("_meta.caveats should explain consumer lag: %v", response.Meta.Caveats)
+	}
+
+	if !strings.Contains(strings.Join(queries, "\n"), `span_kind="SPAN_KIND_CONSUMER"`) {
+		t.Errorf("queries should select consumer spans: %v", queries)
+	}
+}
+
+func TestGetConsumerOperationsHandler_RequiresServiceName(t *testing.T) {
+	handler := NewGetConsumerOperationsHandler(http.DefaultClient, testDBConfig("http://unused"))
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetConsumerOperationsArgs{}); err == nil {
+		t.Fatal("expected error when service_name is missing")
+	}
+}
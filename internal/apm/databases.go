@@ -11,9 +11,10 @@ import (
 	"strings"
 	"sync"
 
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/export"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -21,7 +22,8 @@ import (
 // --- get_databases tool ---
 
 type GetDatabasesArgs struct {
-	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
 	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
 	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
 	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
@@ -36,8 +38,9 @@ type DatabaseSummary struct {
 	ServiceCount int     `json:"service_count"`
 }
 
-func NewGetDatabasesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabasesArgs) (*mcp.CallToolResult, any, error) {
+func NewGetDatabasesHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabasesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetDatabasesArgs) (*mcp.CallToolResult, any, error) {
+		args.Env = defaultEnv(cfg, args.Env)
 		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
 			return nil, nil, err
@@ -157,7 +160,7 @@ func NewGetDatabasesHandler(client *http.Client, cfg models.Config) func(context
 		if len(databases) == 0 {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: "No databases found for the given parameters. Ensure services are instrumented with OpenTelemetry and have db_system span attribute set."},
+					&mcp.TextContent{Text: fmt.Sprintf("No databases found for the given parameters (env=~%q). Ensure services are instrumented with OpenTelemetry and have db_system span attribute set.", resolveEnv(cfg, args.Env))},
 				},
 			}, nil, nil
 		}
@@ -173,6 +176,7 @@ func NewGetDatabasesHandler(client *http.Client, cfg models.Config) func(context
 
 		response := map[string]any{
 			"count":     len(result),
+			"env":       resolveEnv(cfg, args.Env),
 			"databases": result,
 		}
 		if len(warnings) > 0 {
@@ -200,15 +204,17 @@ func NewGetDatabasesHandler(client *http.Client, cfg models.Config) func(context
 // --- get_database_slow_queries tool ---
 
 type GetDatabaseSlowQueriesArgs struct {
-	DBSystem        string  `json:"db_system,omitempty" jsonschema:"Database system filter (e.g. postgresql, mysql, mongodb, redis)"`
-	Host            string  `json:"host,omitempty" jsonschema:"Database host filter (net_peer_name)"`
-	ServiceName     string  `json:"service_name,omitempty" jsonschema:"Calling service name filter"`
-	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment filter"`
-	MinDurationMs   float64 `json:"min_duration_ms,omitempty" jsonschema:"Minimum query duration in milliseconds"`
-	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
-	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
-	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
-	Limit           int     `json:"limit,omitempty" jsonschema:"Maximum results (default: 20)"`
+	DBSystem        string          `json:"db_system,omitempty" jsonschema:"Database system filter (e.g. postgresql, mysql, mongodb, redis)"`
+	Host            string          `json:"host,omitempty" jsonschema:"Database host filter (net_peer_name)"`
+	ServiceName     string          `json:"service_name,omitempty" jsonschema:"Calling service name filter"`
+	Env             string          `json:"env,omitempty" jsonschema:"Deployment environment filter. Default: the server default env if configured, else all environments."`
+	View            string          `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	MinDurationMs   float64         `json:"min_duration_ms,omitempty" jsonschema:"Minimum query duration in milliseconds"`
+	LookbackMinutes float64         `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string          `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string          `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+	Limit           int             `json:"limit,omitempty" jsonschema:"Maximum results (default: 20)"`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
 }
 
 type SlowQuery struct {
@@ -231,8 +237,9 @@ type SlowQuery struct {
 	RowsReturned int64  `json:"rows_returned,omitempty"`
 }
 
-func NewGetDatabaseSlowQueriesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabaseSlowQueriesArgs) (*mcp.CallToolResult, any, error) {
+func NewGetDatabaseSlowQueriesHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabaseSlowQueriesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetDatabaseSlowQueriesArgs) (*mcp.CallToolResult, any, error) {
+		args.Env = defaultEnv(cfg, args.Env)
 		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
 			return nil, nil, err
@@ -361,7 +368,7 @@ func NewGetDatabaseSlowQueriesHandler(client *http.Client, cfg models.Config) fu
 		if len(slowQueries) == 0 {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: "No slow database queries found for the given parameters."},
+					&mcp.TextContent{Text: fmt.Sprintf("No slow database queries found for the given parameters (env=%q).", resolveEnv(cfg, args.Env))},
 				},
 			}, nil, nil
 		}
@@ -378,6 +385,7 @@ func NewGetDatabaseSlowQueriesHandler(client *http.Client, cfg models.Config) fu
 
 		response := map[string]any{
 			"count":        len(slowQueries),
+			"env":          resolveEnv(cfg, args.Env),
 			"from_traces":  traceCount,
 			"from_logs":    logCount,
 			"slow_queries": slowQueries,
@@ -406,11 +414,12 @@ func NewGetDatabaseSlowQueriesHandler(client *http.Client, cfg models.Config) fu
 type GetDatabaseQueriesArgs struct {
 	DBSystem        string  `json:"db_system" jsonschema:"Database system (required, e.g. postgresql, mysql, mongodb, redis)"`
 	Host            string  `json:"host,omitempty" jsonschema:"Database host filter (net_peer_name)"`
-	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment filter"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment filter. Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
 	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60)"`
 	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
 	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
-	SortBy          string  `json:"sort_by,omitempty" jsonschema:"Sort by: throughput (default), latency, or errors"`
+	SortBy          string  `json:"sort_by,omitempty" jsonschema:"Sort order, throughput by default (one of: throughput, latency, errors)"`
 }
 
 type QueryPattern struct {
@@ -421,8 +430,9 @@ type QueryPattern struct {
 	ErrorRate   float64 `json:"error_rate_pct"`
 }
 
-func NewGetDatabaseQueriesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabaseQueriesArgs) (*mcp.CallToolResult, any, error) {
+func NewGetDatabaseQueriesHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabaseQueriesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetDatabaseQueriesArgs) (*mcp.CallToolResult, any, error) {
+		args.Env = defaultEnv(cfg, args.Env)
 		if args.DBSystem == "" {
 			return nil, nil, fmt.Errorf("db_system parameter is required (e.g. postgresql, mysql, mongodb, redis)")
 		}
@@ -454,7 +464,7 @@ func NewGetDatabaseQueriesHandler(client *http.Client, cfg models.Config) func(c
 		if len(patterns) == 0 {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: fmt.Sprintf("No query patterns found for db_system=%s. Ensure services are instrumented with OpenTelemetry database client spans.", args.DBSystem)},
+					&mcp.TextContent{Text: fmt.Sprintf("No query patterns found for db_system=%s (env=~%q). Ensure services are instrumented with OpenTelemetry database client spans.", args.DBSystem, resolveEnv(cfg, args.Env))},
 				},
 			}, nil, nil
 		}
@@ -533,10 +543,7 @@ func NewGetDatabaseQueriesHandler(client *http.Client, cfg models.Config) func(c
 			result = append(result, *p)
 		}
 
-		sortBy := args.SortBy
-		if sortBy == "" {
-			sortBy = "throughput"
-		}
+		sortBy := strings.ToLower(strings.TrimSpace(args.SortBy))
 		sort.Slice(result, func(i, j int) bool {
 			switch sortBy {
 			case "latency":
@@ -556,6 +563,7 @@ func NewGetDatabaseQueriesHandler(client *http.Client, cfg models.Config) func(c
 		response := map[string]any{
 			"count":     len(result),
 			"db_system": args.DBSystem,
+			"env":       resolveEnv(cfg, args.Env),
 			"sort_by":   sortBy,
 			"queries":   result,
 		}
@@ -596,7 +604,7 @@ func escapePromQLLabel(s string) string {
 	return s
 }
 
-func fetchPromBySpanName(ctx context.Context, client *http.Client, cfg models.Config, query string, endTime int64, patterns map[string]*QueryPattern, setter func(*QueryPattern, float64)) error {
+func fetchPromBySpanName(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64, patterns map[string]*QueryPattern, setter func(*QueryPattern, float64)) error {
 	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, endTime, cfg)
 	if err != nil {
 		return err
@@ -628,7 +636,7 @@ func fetchPromBySpanName(ctx context.Context, client *http.Client, cfg models.Co
 	return nil
 }
 
-func fetchPromToSpanNameMap(ctx context.Context, client *http.Client, cfg models.Config, query string, endTime int64, result map[string]float64) {
+func fetchPromToSpanNameMap(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64, result map[string]float64) {
 	fetchPromToMapByKey(ctx, client, cfg, query, endTime, result, func(m map[string]string) string {
 		return m["span_name"]
 	})
@@ -782,7 +790,7 @@ var supportedDBSystems = func() string {
 	return strings.Join(keys, ", ")
 }()
 
-func NewGetDatabaseServerMetricsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabaseServerMetricsArgs) (*mcp.CallToolResult, any, error) {
+func NewGetDatabaseServerMetricsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetDatabaseServerMetricsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetDatabaseServerMetricsArgs) (*mcp.CallToolResult, any, error) {
 		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
 		if err != nil {
@@ -904,7 +912,7 @@ func NewGetDatabaseServerMetricsHandler(client *http.Client, cfg models.Config)
 }
 
 // probeMetricPrefix checks if any metrics with the given prefixes exist in Prometheus.
-func probeMetricPrefix(ctx context.Context, client *http.Client, cfg models.Config, prefixes []string, startTime, endTime int64) bool {
+func probeMetricPrefix(ctx context.Context, client utils.HTTPClient, cfg models.Config, prefixes []string, startTime, endTime int64) bool {
 	// Build a regex that matches any of the prefixes
 	prefixRegex := strings.Join(prefixes, "|")
 	matchFilter := fmt.Sprintf(`{__name__=~"(%s).*"}`, prefixRegex)
@@ -928,7 +936,7 @@ func probeMetricPrefix(ctx context.Context, client *http.Client, cfg models.Conf
 }
 
 // queryPromInstantValue runs a PromQL instant query and returns the scalar value, or nil.
-func queryPromInstantValue(ctx context.Context, client *http.Client, cfg models.Config, query string, endTime int64) any {
+func queryPromInstantValue(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64) any {
 	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, endTime, cfg)
 	if err != nil {
 		return nil
@@ -957,7 +965,7 @@ func queryPromInstantValue(ctx context.Context, client *http.Client, cfg models.
 // fetchSlowQueryLogs queries the logs API for entries with attributes['slow_query']='true'
 // and extracts database-specific fields like plan_summary, docs_examined, etc.
 // This is best-effort — returns nil on any error (traces are the primary source).
-func fetchSlowQueryLogs(ctx context.Context, client *http.Client, cfg models.Config, args GetDatabaseSlowQueriesArgs, startMs, endMs int64, limit int) []SlowQuery {
+func fetchSlowQueryLogs(ctx context.Context, client utils.HTTPClient, cfg models.Config, args GetDatabaseSlowQueriesArgs, startMs, endMs int64, limit int) []SlowQuery {
 	// Build log pipeline filter: attributes['slow_query'] = 'true'
 	var conditions []any
 	conditions = append(conditions, map[string]any{
@@ -1232,7 +1240,7 @@ func extractSlowQueries(rawResult map[string]any) []SlowQuery {
 
 // fetchPromAndPopulate runs a PromQL instant query and populates DatabaseSummary entries
 // keyed by "db_system|net_peer_name".
-func fetchPromAndPopulate(ctx context.Context, client *http.Client, cfg models.Config, query string, endTime int64, databases map[string]*DatabaseSummary, setter func(*DatabaseSummary, float64)) error {
+func fetchPromAndPopulate(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64, databases map[string]*DatabaseSummary, setter func(*DatabaseSummary, float64)) error {
 	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, endTime, cfg)
 	if err != nil {
 		return err
@@ -1269,7 +1277,7 @@ func fetchPromAndPopulate(ctx context.Context, client *http.Client, cfg models.C
 }
 
 // fetchPromToMap runs a PromQL query and stores values in a map keyed by "db_system|net_peer_name".
-func fetchPromToMap(ctx context.Context, client *http.Client, cfg models.Config, query string, endTime int64, result map[string]float64) {
+func fetchPromToMap(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64, result map[string]float64) {
 	fetchPromToMapByKey(ctx, client, cfg, query, endTime, result, func(m map[string]string) string {
 		return m["db_system"] + "|" + m["net_peer_name"]
 	})
@@ -1277,7 +1285,7 @@ func fetchPromToMap(ctx context.Context, client *http.Client, cfg models.Config,
 
 // fetchPromToMapByKey is the generic version: runs a PromQL instant query
 // and stores values in a map using a caller-provided key extractor.
-func fetchPromToMapByKey(ctx context.Context, client *http.Client, cfg models.Config, query string, endTime int64, result map[string]float64, keyFn func(map[string]string) string) {
+func fetchPromToMapByKey(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64, result map[string]float64, keyFn func(map[string]string) string) {
 	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, endTime, cfg)
 	if err != nil {
 		return
@@ -1316,3 +1324,30 @@ func parsePromValue(value []any) float64 {
 		return 0
 	}
 }
+
+// fetchPromInstant runs a PromQL instant query and decodes the series.
+func fetchPromInstant(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64) (apiPromInstantResp, error) {
+	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, endTime, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("PromQL query failed with status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PromQL response: %w", err)
+	}
+	result, _, err := unwrapPromBody(body)
+	if err != nil {
+		return nil, err
+	}
+	var series apiPromInstantResp
+	if err := json.Unmarshal(result, &series); err != nil {
+		return nil, fmt.Errorf("failed to decode PromQL response: %w", err)
+	}
+	return series, nil
+}
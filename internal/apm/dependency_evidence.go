@@ -0,0 +1,129 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// EdgeEvidence says how well the traces support one incoming or outgoing
+// edge of the dependency graph. Edges derived from trace_call_graph_count
+// can be sampling artefacts: a handful of calls, or a peer that never
+// emitted spans of the kind its side of the call implies.
+type EdgeEvidence struct {
+	// SampleCount is the number of calls counted on the edge in the window.
+	SampleCount int64
+	// SpanKinds are the span kinds the peer service emitted in the window.
+	SpanKinds []string
+	// DirectionConfirmed is set when the peer emitted spans of the kind its
+	// side of the edge implies: client or producer spans for a caller,
+	// server or consumer spans for a callee.
+	DirectionConfirmed bool
+	// Confidence is between 0 and 1; see edgeConfidence.
+	Confidence float64
+}
+
+// Span kinds that confirm each side of an edge.
+var (
+	callerSpanKinds = []string{"SPAN_KIND_CLIENT", "SPAN_KIND_PRODUCER"}
+	calleeSpanKinds = []string{"SPAN_KIND_SERVER", "SPAN_KIND_CONSUMER"}
+)
+
+// edgeConfidence scores an edge from its call volume on a log scale that
+// reaches 1 at 1000 calls, scaled by 0.6 when the peer's span kinds don't
+// confirm the direction.
+func edgeConfidence(samples int64, directionConfirmed bool) float64 {
+	score := math.Min(1, math.Log10(float64(samples)+1)/3)
+	if !directionConfirmed {
+		score *= 0.6
+	}
+	return math.Round(score*100) / 100
+}
+
+// fetchServiceSpanKinds returns the span kinds each of services emitted in
+// the window, from trace_endpoint_count.
+func fetchServiceSpanKinds(ctx context.Context, client utils.HTTPClient, cfg models.Config, services []string, env, timeRange string, end int64) (map[string][]string, error) {
+	if len(services) == 0 {
+		return map[string][]string{}, nil
+	}
+	patterns := make([]string, len(services))
+	for i, s := range services {
+		patterns[i] = regexp.QuoteMeta(s)
+	}
+	query := fmt.Sprintf(
+		`sum by (service_name, span_kind)(sum_over_time(trace_endpoint_count{env=~'%s', service_name=~"%s"}[%s]))`,
+		env, escapePromQLLabel(strings.Join(patterns, "|")), timeRange,
+	)
+	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get span kinds: %s", resp.Status)
+	}
+	var raw apiPromInstantResp
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
+	}
+	kinds := map[string][]string{}
+	for _, r := range raw {
+		service, kind := r.Metric["service_name"], r.Metric["span_kind"]
+		if kind == "" || slices.Contains(kinds[service], kind) {
+			continue
+		}
+		if valStr, ok := r.Value[1].(string); ok {
+			if v, err := strconv.ParseFloat(valStr, 64); err != nil || v <= 0 {
+				continue
+			}
+		}
+		kinds[service] = append(kinds[service], kind)
+	}
+	for _, k := range kinds {
+		sort.Strings(k)
+	}
+	return kinds, nil
+}
+
+// annotateEdges sets the Edge evidence of each entry of edges, keyed by peer
+// service. windowMinutes converts the per-minute throughput back to a call
+// count; confirming are the span kinds that confirm the peer's side.
+func annotateEdges(edges map[string]RedMetrics, spanKinds map[string][]string, windowMinutes int64, confirming []string) {
+	for peer, metrics := range edges {
+		kinds := spanKinds[peer]
+		if kinds == nil {
+			kinds = []string{}
+		}
+		confirmed := slices.ContainsFunc(kinds, func(k string) bool { return slices.Contains(confirming, k) })
+		samples := int64(math.Round(metrics.Throughput * float64(windowMinutes)))
+		metrics.Edge = &EdgeEvidence{
+			SampleCount:        samples,
+			SpanKinds:          kinds,
+			DirectionConfirmed: confirmed,
+			Confidence:         edgeConfidence(samples, confirmed),
+		}
+		edges[peer] = metrics
+	}
+}
+
+// dropLowThroughput removes entries below minThroughput calls per minute.
+func dropLowThroughput(edges map[string]RedMetrics, minThroughput float64) {
+	if minThroughput <= 0 {
+		return
+	}
+	for key, metrics := range edges {
+		if metrics.Throughput < minThroughput {
+			delete(edges, key)
+		}
+	}
+}
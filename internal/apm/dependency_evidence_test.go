@@ -0,0 +1,91 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestEdgeConfidence(t *testing.T) {
+	tests := []struct {
+		samples   int64
+		confirmed bool
+		want      float64
+	}{
+		{0, true, 0},
+		{9, true, 0.33},
+		{999, true, 1},
+		{50000, true, 1},
+		{999, false, 0.6},
+	}
+	for _, tt := range tests {
+		if got := edgeConfidence(tt.samples, tt.confirmed); got != tt.want {
+			t.Errorf("edgeConfidence(%d, %v) = %v, want %v", tt.samples, tt.confirmed, got, tt.want)
+		}
+	}
+}
+
+func TestNewServiceDependencyGraphHandler_EdgeEvidence(t *testing.T) {
+	var spanKindQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch q := body.Query; {
+		case strings.Contains(q, "by (service_name, span_kind)"):
+			spanKindQuery = q
+			io.WriteString(w, `[
+				{"metric":{"service_name":"web","span_kind":"SPAN_KIND_CLIENT"},"value":[0,"600"]},
+				{"metric":{"service_name":"web","span_kind":"SPAN_KIND_SERVER"},"value":[0,"900"]},
+				{"metric":{"service_name":"payments","span_kind":"SPAN_KIND_CLIENT"},"value":[0,"40"]}]`)
+		case strings.Contains(q, "client_status") || strings.Contains(q, "quantile") || strings.Contains(q, "timestamp("):
+			io.WriteString(w, `[]`)
+		case strings.Contains(q, "sum by (client)"):
+			io.WriteString(w, `[{"metric":{"client":"web"},"value":[0,"10"]},{"metric":{"client":"cron"},"value":[0,"0.05"]}]`)
+		case strings.Contains(q, "sum by (server)"):
+			io.WriteString(w, `[{"metric":{"server":"payments"},"value":[0,"2"]}]`)
+		default:
+			io.WriteString(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewServiceDependencyGraphHandler(server.Client(), testDBConfig(server.URL))
+	args := ServiceDependencyGraphArgs{ServiceName: "api", StartTimeISO: "1970-01-01T00:00:00Z", EndTimeISO: "1970-01-01T01:00:00Z", MinThroughput: 0.1}
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	var details struct {
+		Incoming map[string]map[string]any `json:"incoming"`
+		Outgoing map[string]map[string]any `json:"outgoing"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &details); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := details.Incoming["cron"]; ok {
+		t.Error("cron (0.05 calls/min) kept despite min_throughput 0.1")
+	}
+	web := details.Incoming["web"]
+	if web["SampleCount"] != 600.0 || web["DirectionConfirmed"] != true || web["Confidence"] != 0.93 ||
+		!reflect.DeepEqual(web["SpanKinds"], []any{"SPAN_KIND_CLIENT", "SPAN_KIND_SERVER"}) {
+		t.Errorf("incoming web = %v", web)
+	}
+	// payments only emitted client spans, so it is not confirmed as a callee.
+	payments := details.Outgoing["payments"]
+	if payments["SampleCount"] != 120.0 || payments["DirectionConfirmed"] != false || payments["Confidence"] != 0.42 {
+		t.Errorf("outgoing payments = %v", payments)
+	}
+	if !strings.Contains(spanKindQuery, `service_name=~"payments|web"`) {
+		t.Errorf("span kind query = %s", spanKindQuery)
+	}
+}
@@ -6,13 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"net/http"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -28,9 +30,11 @@ type deviationHandlerDeps struct {
 	now                func() time.Time
 	queryStep          time.Duration
 	resolveDatasource  func(models.Config, string) (models.Config, error)
-	runnerFactory      func(*http.Client, models.Config) deviationQueryRunner
+	runnerFactory      func(utils.HTTPClient, models.Config) deviationQueryRunner
 	execute            func(context.Context, deviationQueryRunner, deviationQueryPlan) deviationQueryExecution
 	hasAnyAPMTelemetry func(context.Context, deviationQueryRunner, DeviationArgs, DeviationWindows) (bool, error)
+	maintenance        *maintenance.Store
+	dependencies       func(context.Context, deviationQueryRunner, []ServiceDeviation, DeviationWindows) (map[string][]string, error)
 }
 
 type deviationPartialError struct {
@@ -103,7 +107,7 @@ type aggregateWindowSummary struct {
 }
 
 // NewAPMServiceDeviationsHandler compares bounded APM RED aggregates across equal windows.
-func NewAPMServiceDeviationsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, DeviationArgs) (*mcp.CallToolResult, any, error) {
+func NewAPMServiceDeviationsHandler(client utils.HTTPClient, cfg models.Config, windows *maintenance.Store) func(context.Context, *mcp.CallToolRequest, DeviationArgs) (*mcp.CallToolResult, any, error) {
 	return newAPMServiceDeviationsHandler(client, cfg, deviationHandlerDeps{
 		now:                func() time.Time { return time.Now().UTC() },
 		queryStep:          deviationQueryStep,
@@ -111,11 +115,14 @@ func NewAPMServiceDeviationsHandler(client *http.Client, cfg models.Config) func
 		runnerFactory:      newHTTPDeviationQueryRunner,
 		execute:            executeDeviationQueries,
 		hasAnyAPMTelemetry: hasAnyAPMTelemetry,
+		maintenance:        windows,
+		dependencies:       serviceDependencies,
 	})
 }
 
-func newAPMServiceDeviationsHandler(client *http.Client, baseCfg models.Config, deps deviationHandlerDeps) func(context.Context, *mcp.CallToolRequest, DeviationArgs) (*mcp.CallToolResult, any, error) {
+func newAPMServiceDeviationsHandler(client utils.HTTPClient, baseCfg models.Config, deps deviationHandlerDeps) func(context.Context, *mcp.CallToolRequest, DeviationArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, _ *mcp.CallToolRequest, args DeviationArgs) (*mcp.CallToolResult, any, error) {
+		args.Env = defaultEnv(baseCfg, args.Env)
 		maxServices, err := deviationLimit("max_services", args.MaxServices)
 		if err != nil {
 			return nil, nil, err
@@ -188,6 +195,17 @@ func newAPMServiceDeviationsHandler(client *http.Client, baseCfg models.Config,
 				result.OperationApdexReconciliations = reconcileOperationApdex(result, opExecution, windows, maxOperations)
 			}
 		}
+		var dependencies map[string][]string
+		if deps.dependencies != nil && len(deps.maintenance.All()) > 0 && len(result.Services) > 0 {
+			dependencies, err = deps.dependencies(ctx, runner, result.Services, windows)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				result.Warnings = append(result.Warnings, "Service dependencies could not be resolved; only maintenance windows declared for the services themselves are shown.")
+			}
+		}
+		annotateDeviationMaintenance(&result.DeviationResponse, deps.maintenance, windows, dependencies)
 		result.RecommendedFollowups = recommendedDeviationFollowups(result, args)
 		result.Warnings = uniqueSorted(result.Warnings)
 		result.PartialErrors = sortedPartialErrors(result.PartialErrors)
@@ -211,6 +229,72 @@ func newAPMServiceDeviationsHandler(client *http.Client, baseCfg models.Config,
 	}
 }
 
+// annotateDeviationMaintenance attaches the declared maintenance windows that
+// overlap either comparison window to each service, including those of the
+// services it depends on, and warns about them: a deviation during
+// maintenance, or against a baseline that contained maintenance, is likely
+// expected rather than a regression.
+func annotateDeviationMaintenance(result *DeviationResponse, windows *maintenance.Store, w DeviationWindows, dependencies map[string][]string) {
+	for i := range result.Services {
+		svc := &result.Services[i]
+		seen := map[string]bool{}
+		for _, span := range []struct {
+			name string
+			TimeWindow
+		}{
+			{"current", effectiveCurrentWindow(w)},
+			{"baseline", effectiveBaselineWindow(w)},
+		} {
+			for _, mw := range windows.FindWithDependencies(svc.ServiceName, svc.Env, dependencies[svc.ServiceName], span.Start, span.End) {
+				if !seen[mw.ID] {
+					seen[mw.ID] = true
+					svc.Maintenance = append(svc.Maintenance, mw)
+				}
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: the %s window overlaps maintenance window %s", svc.ServiceName, span.name, mw))
+			}
+		}
+	}
+}
+
+// serviceDependencies returns the services each service called in its env,
+// per the trace call graph, across both comparison windows.
+func serviceDependencies(ctx context.Context, runner deviationQueryRunner, services []ServiceDeviation, windows DeviationWindows) (map[string][]string, error) {
+	envs := make(map[string]string, len(services))
+	names := make([]string, 0, len(services))
+	for _, svc := range services {
+		envs[svc.ServiceName] = svc.Env
+		names = append(names, svc.ServiceName)
+	}
+	start, end := windows.EffectiveBaselineStart, windows.EffectiveCurrentEnd
+	if windows.EffectiveCurrentStart.Before(start) {
+		start = windows.EffectiveCurrentStart
+	}
+	if windows.EffectiveBaselineEnd.After(end) {
+		end = windows.EffectiveBaselineEnd
+	}
+	minutes := max(int(math.Ceil(end.Sub(start).Minutes())), 1)
+	query := fmt.Sprintf(`sum by (client, server, env) (sum_over_time(trace_call_graph_count{client=~"%s"}[%dm])) > 0`, servicesRegex(names), minutes)
+	vectors, err := runner.Query(ctx, query, end)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]string{}
+	for _, v := range vectors {
+		client, server := v.Metric["client"], v.Metric["server"]
+		env, ok := envs[client]
+		if !ok || server == "" || server == client || (env != "" && v.Metric["env"] != env) {
+			continue
+		}
+		if !slices.Contains(out[client], server) {
+			out[client] = append(out[client], server)
+		}
+	}
+	for client := range out {
+		sort.Strings(out[client])
+	}
+	return out, nil
+}
+
 func deviationLimit(name string, value int) (int, error) {
 	if value == 0 {
 		return deviationResultCap, nil
@@ -16,7 +16,15 @@ func GetAPMServiceDeviationsInputSchema() map[string]interface{} {
 			},
 			"env": map[string]interface{}{
 				"type":        "string",
-				"description": "Exact environment to compare. Omit to return environments separately; environments are never merged.",
+				"description": "Exact environment to compare. Omit to use the server default env if configured, otherwise environments are returned separately; environments are never merged.",
+			},
+			"view": map[string]interface{}{
+				"type":        "string",
+				"description": "Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it.",
+			},
+			"display_timezone": map[string]interface{}{
+				"type":        "string",
+				"description": "IANA timezone (e.g. Asia/Kolkata) for human-readable *_local timestamps added next to epoch values. Overrides the server default display timezone.",
 			},
 			"datasource": map[string]interface{}{
 				"type":        "string",
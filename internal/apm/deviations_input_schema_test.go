@@ -12,7 +12,7 @@ import (
 var deviationInputFields = []string{
 	"service_name", "env", "datasource", "start_time_iso", "end_time_iso",
 	"lookback_minutes", "baseline_start_time_iso", "baseline_end_time_iso",
-	"max_services", "max_operations",
+	"max_services", "max_operations", "view", "display_timezone",
 }
 
 func validateDeviationInputSchema(t *testing.T, args any) error {
@@ -11,8 +11,8 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 )
 
 type deviationQueryScope struct {
@@ -125,11 +125,11 @@ func (fn deviationQueryRunnerFunc) Query(ctx context.Context, query string, end
 }
 
 type httpDeviationQueryRunner struct {
-	client *http.Client
+	client utils.HTTPClient
 	cfg    models.Config
 }
 
-func newHTTPDeviationQueryRunner(client *http.Client, cfg models.Config) deviationQueryRunner {
+func newHTTPDeviationQueryRunner(client utils.HTTPClient, cfg models.Config) deviationQueryRunner {
 	return httpDeviationQueryRunner{client: client, cfg: cfg}
 }
 
@@ -390,7 +390,13 @@ func executeDeviationQueryGroup(ctx context.Context, runner deviationQueryRunner
 	for _, query := range queries {
 		query := query
 		go func() {
-			sem <- struct{}{}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				// Queued queries must not start once the call is cancelled.
+				results <- queryResult{query: query, err: ctx.Err()}
+				return
+			}
 			defer func() { <-sem }()
 			vectors, err := runner.Query(ctx, query.Text, end)
 			results <- queryResult{query: query, vectors: vectors, err: err}
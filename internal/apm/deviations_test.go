@@ -12,8 +12,10 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -814,7 +816,7 @@ func TestAPMServiceDeviationsHandlerUsesResolvedDatasourceHTTPPath(t *testing.T)
 			Name: "selected", ReadURL: "https://selected.invalid", Username: "selected-user", Password: "selected-password", Region: "test", ClusterID: "selected-cluster",
 		}},
 	}
-	handler := NewAPMServiceDeviationsHandler(server.Client(), cfg)
+	handler := NewAPMServiceDeviationsHandler(server.Client(), cfg, nil)
 	response := callDeviationHandler(t, handler, DeviationArgs{
 		Datasource: "selected", StartTimeISO: "2026-07-11T08:00:00Z", EndTimeISO: "2026-07-11T09:00:00Z",
 	})
@@ -833,7 +835,7 @@ func testDeviationHandlerDeps() deviationHandlerDeps {
 		resolveDatasource: func(cfg models.Config, _ string) (models.Config, error) {
 			return cfg, nil
 		},
-		runnerFactory: func(*http.Client, models.Config) deviationQueryRunner {
+		runnerFactory: func(utils.HTTPClient, models.Config) deviationQueryRunner {
 			return deviationQueryRunnerFunc(func(context.Context, string, time.Time) ([]deviationVector, error) { return nil, nil })
 		},
 		execute: executeDeviationQueries,
@@ -908,3 +910,80 @@ func deviationResultText(t *testing.T, result *mcp.CallToolResult) string {
 	}
 	return content.Text
 }
+
+func TestAnnotateDeviationMaintenance(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Minute)
+	windows := DeviationWindows{
+		EffectiveCurrentStart:  now.Add(-time.Hour),
+		EffectiveCurrentEnd:    now,
+		EffectiveBaselineStart: now.Add(-2 * time.Hour),
+		EffectiveBaselineEnd:   now.Add(-time.Hour),
+	}
+	store := maintenance.New("")
+	if _, err := store.Add(maintenance.Window{ServiceName: "checkout", Start: now.Add(-90 * time.Minute), End: now.Add(-30 * time.Minute), Reason: "db upgrade"}); err != nil {
+		t.Fatal(err)
+	}
+	result := DeviationResponse{Services: []ServiceDeviation{{ServiceName: "checkout"}, {ServiceName: "cart"}}}
+
+	annotateDeviationMaintenance(&result, store, windows, nil)
+	if len(result.Services[0].Maintenance) != 1 || result.Services[0].Maintenance[0].Reason != "db upgrade" {
+		t.Errorf("checkout maintenance = %+v, want the window once", result.Services[0].Maintenance)
+	}
+	if len(result.Services[1].Maintenance) != 0 {
+		t.Errorf("cart maintenance = %+v, want none", result.Services[1].Maintenance)
+	}
+	joined := strings.Join(result.Warnings, "\n")
+	if !strings.Contains(joined, "checkout: the current window overlaps maintenance window db upgrade") || !strings.Contains(joined, "checkout: the baseline window") {
+		t.Errorf("warnings = %v, want both windows flagged", result.Warnings)
+	}
+}
+
+func TestAnnotateDeviationMaintenanceDependencies(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Minute)
+	windows := DeviationWindows{
+		EffectiveCurrentStart:  now.Add(-time.Hour),
+		EffectiveCurrentEnd:    now,
+		EffectiveBaselineStart: now.Add(-2 * time.Hour),
+		EffectiveBaselineEnd:   now.Add(-time.Hour),
+	}
+	store := maintenance.New("")
+	if _, err := store.Add(maintenance.Window{ServiceName: "payments-db", Env: "prod", Start: now.Add(-30 * time.Minute), End: now.Add(30 * time.Minute), Reason: "db failover"}); err != nil {
+		t.Fatal(err)
+	}
+	services := []ServiceDeviation{{ServiceName: "checkout", Env: "prod"}, {ServiceName: "cart", Env: "prod"}}
+
+	var query string
+	runner := deviationQueryRunnerFunc(func(_ context.Context, q string, _ time.Time) ([]deviationVector, error) {
+		query = q
+		return []deviationVector{
+			{Metric: map[string]string{"client": "checkout", "server": "payments-db", "env": "prod"}},
+			{Metric: map[string]string{"client": "checkout", "server": "payments-db", "env": "prod"}},
+			{Metric: map[string]string{"client": "cart", "server": "payments-db", "env": "staging"}},
+		}, nil
+	})
+	dependencies, err := serviceDependencies(context.Background(), runner, services, windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(query, `client=~"checkout|cart"`) || !strings.Contains(query, "[120m]") {
+		t.Errorf("query = %s, want both services over both windows", query)
+	}
+	if got := dependencies["checkout"]; len(got) != 1 || got[0] != "payments-db" {
+		t.Errorf("checkout dependencies = %v, want payments-db once", got)
+	}
+	if got := dependencies["cart"]; len(got) != 0 {
+		t.Errorf("cart dependencies = %v, want none from another env", got)
+	}
+
+	result := DeviationResponse{Services: services}
+	annotateDeviationMaintenance(&result, store, windows, dependencies)
+	if m := result.Services[0].Maintenance; len(m) != 1 || m[0].Dependency != "payments-db" {
+		t.Errorf("checkout maintenance = %+v, want the payments-db window", m)
+	}
+	if len(result.Services[1].Maintenance) != 0 {
+		t.Errorf("cart maintenance = %+v, want none", result.Services[1].Maintenance)
+	}
+	if joined := strings.Join(result.Warnings, "\n"); !strings.Contains(joined, "checkout: the current window overlaps maintenance window db failover") || !strings.Contains(joined, "on dependency payments-db") {
+		t.Errorf("warnings = %v, want the dependency window flagged", result.Warnings)
+	}
+}
@@ -1,10 +1,15 @@
 package apm
 
-import "time"
+import (
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+)
 
 type DeviationArgs struct {
 	ServiceName      string  `json:"service_name,omitempty"`
 	Env              string  `json:"env,omitempty"`
+	View             string  `json:"view,omitempty"`
 	Datasource       string  `json:"datasource,omitempty"`
 	StartTimeISO     string  `json:"start_time_iso,omitempty"`
 	EndTimeISO       string  `json:"end_time_iso,omitempty"`
@@ -106,9 +111,10 @@ type SignalComparison struct {
 }
 
 type ServiceDeviation struct {
-	ServiceName string             `json:"service_name"`
-	Env         string             `json:"env,omitempty"`
-	Signals     []SignalComparison `json:"signals"`
+	ServiceName string               `json:"service_name"`
+	Env         string               `json:"env,omitempty"`
+	Signals     []SignalComparison   `json:"signals"`
+	Maintenance []maintenance.Match  `json:"maintenance,omitempty"`
 }
 
 type LeaderboardEntry struct {
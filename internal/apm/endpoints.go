@@ -0,0 +1,205 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/export"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_service_endpoints tool ---
+
+type GetServiceEndpointsArgs struct {
+	ServiceName     string          `json:"service_name" jsonschema:"Name of the service to list HTTP endpoints for (required)"`
+	Env             string          `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string          `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	LookbackMinutes float64         `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string          `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string          `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
+}
+
+// ServiceEndpoint is one HTTP route served by a service.
+type ServiceEndpoint struct {
+	Method       string             `json:"method,omitempty"`
+	Route        string             `json:"route"`
+	SpanName     string             `json:"span_name"`
+	Throughput   float64            `json:"throughput_rpm"`
+	ErrorPercent float64            `json:"error_percent"`
+	P95Latency   float64            `json:"p95_latency_ms"`
+	StatusCodes  map[string]float64 `json:"status_codes_rpm"`
+}
+
+// httpMethods are the request methods recognised as a span name prefix
+// ("GET /users/{id}") under the OpenTelemetry HTTP server span naming convention.
+var httpMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true,
+	"DELETE": true, "OPTIONS": true, "TRACE": true, "CONNECT": true,
+}
+
+// splitHTTPSpanName splits "GET /users/{id}" into method and route. Span names
+// without a method prefix are returned as the route with an empty method.
+func splitHTTPSpanName(spanName string) (method, route string) {
+	if m, rest, ok := strings.Cut(spanName, " "); ok && httpMethods[strings.ToUpper(m)] {
+		return strings.ToUpper(m), strings.TrimSpace(rest)
+	}
+	return "", spanName
+}
+
+func NewGetServiceEndpointsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetServiceEndpointsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetServiceEndpointsArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		durationMin := (endTime - startTime) / 60
+		if durationMin <= 0 {
+			durationMin = 1
+		}
+
+		env := resolveEnv(cfg, args.Env)
+
+		// HTTP server spans only: gRPC and messaging consumers are also
+		// SPAN_KIND_SERVER-ish but carry rpc_system / messaging_system.
+		baseFilter := fmt.Sprintf(
+			`service_name="%s", env=~"%s", span_kind="SPAN_KIND_SERVER", rpc_system="", messaging_system=""`,
+			escapePromQLLabel(args.ServiceName), escapePromQLLabel(env),
+		)
+
+		statusQuery := fmt.Sprintf(
+			`sum by(span_name, http_status_code)(sum_over_time(trace_endpoint_count{%s}[%dm])) / %d`,
+			baseFilter, durationMin, durationMin,
+		)
+		latencyQuery := fmt.Sprintf(
+			`max by(span_name)(avg_over_time(trace_endpoint_duration{%s, quantile="p95"}[%dm]))`,
+			baseFilter, durationMin,
+		)
+
+		var (
+			statusSeries  apiPromInstantResp
+			latencySeries apiPromInstantResp
+			statusErr     error
+			latencyErr    error
+			wg            sync.WaitGroup
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			statusSeries, statusErr = fetchPromInstant(ctx, client, cfg, statusQuery, endTime)
+		}()
+		go func() {
+			defer wg.Done()
+			latencySeries, latencyErr = fetchPromInstant(ctx, client, cfg, latencyQuery, endTime)
+		}()
+		wg.Wait()
+
+		if statusErr != nil {
+			return nil, nil, fmt.Errorf("failed to fetch endpoint throughput: %w", statusErr)
+		}
+
+		endpoints := make(map[string]*ServiceEndpoint)
+		for _, point := range statusSeries {
+			spanName := point.Metric["span_name"]
+			if spanName == "" {
+				continue
+			}
+			ep, ok := endpoints[spanName]
+			if !ok {
+				method, route := splitHTTPSpanName(spanName)
+				ep = &ServiceEndpoint{
+					Method:      method,
+					Route:       route,
+					SpanName:    spanName,
+					StatusCodes: make(map[string]float64),
+				}
+				endpoints[spanName] = ep
+			}
+			code := point.Metric["http_status_code"]
+			if code == "" {
+				code = "unset"
+			}
+			val := parsePromValue(point.Value)
+			ep.StatusCodes[code] += val
+			ep.Throughput += val
+		}
+
+		var warnings []string
+		if latencyErr != nil {
+			warnings = append(warnings, "p95 latency unavailable")
+		}
+		for _, point := range latencySeries {
+			if ep, ok := endpoints[point.Metric["span_name"]]; ok {
+				ep.P95Latency = parsePromValue(point.Value)
+			}
+		}
+
+		if len(endpoints) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("No HTTP endpoints found for service %q (env=~%q) in the given time range. gRPC and messaging operations are excluded; use get_service_operations_summary for those.", args.ServiceName, env)},
+				},
+			}, nil, nil
+		}
+
+		result := make([]ServiceEndpoint, 0, len(endpoints))
+		for _, ep := range endpoints {
+			var errorRPM float64
+			for code, rpm := range ep.StatusCodes {
+				if strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5") {
+					errorRPM += rpm
+				}
+			}
+			if ep.Throughput > 0 {
+				ep.ErrorPercent = errorRPM / ep.Throughput * 100
+			}
+			result = append(result, *ep)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Throughput != result[j].Throughput {
+				return result[i].Throughput > result[j].Throughput
+			}
+			return result[i].SpanName < result[j].SpanName
+		})
+
+		response := map[string]any{
+			"service_name": args.ServiceName,
+			"env":          env,
+			"count":        len(result),
+			"endpoints":    result,
+			"_meta": buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf("trace_endpoint_count{%s}", baseFilter),
+			)),
+		}
+		if len(warnings) > 0 {
+			response["_warnings"] = warnings
+		}
+
+		jsonBytes, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "operations")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
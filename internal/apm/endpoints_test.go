@@ -0,0 +1,148 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSplitHTTPSpanName(t *testing.T) {
+	tests := []struct {
+		in, method, route string
+	}{
+		{"GET /users/{id}", "GET", "/users/{id}"},
+		{"post /orders", "POST", "/orders"},
+		{"/health", "", "/health"},
+		{"HTTP GET", "", "HTTP GET"},
+		{"GET", "", "GET"},
+	}
+	for _, tt := range tests {
+		method, route := splitHTTPSpanName(tt.in)
+		if method != tt.method || route != tt.route {
+			t.Errorf("splitHTTPSpanName(%q) = (%q, %q), want (%q, %q)", tt.in, method, route, tt.method, tt.route)
+		}
+	}
+}
+
+func TestGetServiceEndpointsHandler(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+
+		var response []map[string]any
+		switch {
+		case strings.Contains(body.Query, "by(span_name, http_status_code)"):
+			response = []map[string]any{
+				{"metric": map[string]string{"span_name": "GET /users/{id}", "http_status_code": "200"}, "value": []any{1700000000, "90"}},
+				{"metric": map[string]string{"span_name": "GET /users/{id}", "http_status_code": "500"}, "value": []any{1700000000, "10"}},
+				{"metric": map[string]string{"span_name": "POST /orders", "http_status_code": "201"}, "value": []any{1700000000, "5"}},
+			}
+		case strings.Contains(body.Query, "trace_endpoint_duration"):
+			response = []map[string]any{
+				{"metric": map[string]string{"span_name": "GET /users/{id}"}, "value": []any{1700000000, "120.5"}},
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	handler := NewGetServiceEndpointsHandler(server.Client(), testDBConfig(server.URL))
+	now := time.Now().UTC()
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetServiceEndpointsArgs{
+		ServiceName:  "api",
+		Env:          "prod",
+		StartTimeISO: now.Add(-60 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var response struct {
+		Count     int               `json:"count"`
+		Endpoints []ServiceEndpoint `json:"endpoints"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Count != 2 {
+		t.Fatalf("count = %d, want 2", response.Count)
+	}
+
+	top := response.Endpoints[0]
+	if top.Method != "GET" || top.Route != "/users/{id}" {
+		t.Errorf("top endpoint = %s %s, want GET /users/{id}", top.Method, top.Route)
+	}
+	if top.Throughput != 100 || top.ErrorPercent != 10 || top.P95Latency != 120.5 {
+		t.Errorf("top endpoint metrics = %+v", top)
+	}
+	if top.StatusCodes["500"] != 10 {
+		t.Errorf("status_codes_rpm = %v, want 500 -> 10", top.StatusCodes)
+	}
+
+	if !strings.Contains(strings.Join(queries, "\n"), `rpc_system=""`) {
+		t.Errorf("queries should exclude gRPC spans: %v", queries)
+	}
+}
+
+func TestGetServiceEndpointsHandler_RequiresServiceName(t *testing.T) {
+	handler := NewGetServiceEndpointsHandler(http.DefaultClient, testDBConfig("http://unused"))
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetServiceEndpointsArgs{}); err == nil {
+		t.Fatal("expected error when service_name is missing")
+	}
+}
+
+func TestGetServiceEndpointsHandler_DefaultEnv(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	cfg := testDBConfig(server.URL)
+	cfg.DefaultEnv = "production"
+	handler := NewGetServiceEndpointsHandler(server.Client(), cfg)
+
+	for _, tt := range []struct{ env, want string }{{"", "production"}, {"staging", "staging"}} {
+		queries = nil
+		result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetServiceEndpointsArgs{ServiceName: "api", Env: tt.env})
+		if err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		// No endpoints: the message still names the env that was queried.
+		if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, `env=~"`+tt.want+`"`) {
+			t.Errorf("env %q: response does not echo %s: %s", tt.env, tt.want, text)
+		}
+		for _, q := range queries {
+			if !strings.Contains(q, `env=~"`+tt.want+`"`) {
+				t.Errorf("env %q: query does not filter by %s: %s", tt.env, tt.want, q)
+			}
+		}
+	}
+}
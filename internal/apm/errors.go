@@ -0,0 +1,80 @@
+package apm
+
+import (
+	"math"
+	"sort"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+// topErrorsLimit caps the number of entries in ServicePerformanceDetails.TopErrors.
+const topErrorsLimit = 10
+
+// classifyErrorSeries maps a series labelled with exception_type,
+// http_status_code or status_code to an error kind and name.
+func classifyErrorSeries(metric map[string]string) (kind, name string, ok bool) {
+	switch {
+	case metric["exception_type"] != "":
+		return models.ErrorKindException, metric["exception_type"], true
+	case metric["http_status_code"] != "":
+		return models.ErrorKindHTTP, metric["http_status_code"], true
+	case metric["status_code"] != "":
+		return models.ErrorKindOTelStatus, metric["status_code"], true
+	}
+	return "", "", false
+}
+
+// buildTopErrors folds per-span error series into one entry per kind and name,
+// keeping the span with the most occurrences as the sample. Entries are sorted
+// by count, highest first. A span that threw an exception and set an error
+// status is counted under both kinds.
+func buildTopErrors(series apiPromInstantResp) []models.ErrorEntry {
+	type aggregate struct {
+		entry      models.ErrorEntry
+		spanCounts map[string]float64
+		total      float64
+	}
+	byKey := make(map[string]*aggregate)
+	for _, point := range series {
+		kind, name, ok := classifyErrorSeries(point.Metric)
+		if !ok {
+			continue
+		}
+		key := kind + "\x00" + name
+		agg, exists := byKey[key]
+		if !exists {
+			agg = &aggregate{
+				entry:      models.ErrorEntry{Kind: kind, Name: name},
+				spanCounts: make(map[string]float64),
+			}
+			byKey[key] = agg
+		}
+		val := parsePromValue(point.Value)
+		agg.total += val
+		if span := point.Metric["span_name"]; span != "" {
+			agg.spanCounts[span] += val
+		}
+	}
+
+	entries := make([]models.ErrorEntry, 0, len(byKey))
+	for _, agg := range byKey {
+		var best float64
+		for span, count := range agg.spanCounts {
+			if count > best || (count == best && span < agg.entry.SampleSpan) {
+				best, agg.entry.SampleSpan = count, span
+			}
+		}
+		agg.entry.Count = int64(math.Round(agg.total))
+		entries = append(entries, agg.entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
@@ -0,0 +1,34 @@
+package apm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+func TestBuildTopErrors(t *testing.T) {
+	series := apiPromInstantResp{
+		{Metric: map[string]string{"exception_type": "TimeoutError", "span_name": "GET /a"}, Value: []any{1700000000, "3"}},
+		{Metric: map[string]string{"exception_type": "TimeoutError", "span_name": "GET /b"}, Value: []any{1700000000, "7"}},
+		{Metric: map[string]string{"http_status_code": "503", "span_name": "GET /b"}, Value: []any{1700000000, "4"}},
+		{Metric: map[string]string{"status_code": "STATUS_CODE_ERROR", "span_name": "GET /b"}, Value: []any{1700000000, "11.6"}},
+		{Metric: map[string]string{"span_name": "GET /c"}, Value: []any{1700000000, "99"}},
+	}
+
+	got := buildTopErrors(series)
+	want := []models.ErrorEntry{
+		{Kind: models.ErrorKindOTelStatus, Name: "STATUS_CODE_ERROR", Count: 12, SampleSpan: "GET /b"},
+		{Kind: models.ErrorKindException, Name: "TimeoutError", Count: 10, SampleSpan: "GET /b"},
+		{Kind: models.ErrorKindHTTP, Name: "503", Count: 4, SampleSpan: "GET /b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildTopErrors() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestBuildTopErrors_Empty(t *testing.T) {
+	if got := buildTopErrors(nil); got == nil || len(got) != 0 {
+		t.Errorf("buildTopErrors(nil) = %#v, want empty non-nil slice", got)
+	}
+}
@@ -0,0 +1,120 @@
+package apm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// get_service_summary sort orders for the ranked fleet overview.
+const (
+	fleetSortErrorPercent = "error_percent"
+	fleetSortErrorRate    = "error_rate"
+	fleetSortLatency      = "latency"
+	fleetSortThroughput   = "throughput"
+)
+
+const (
+	fleetDefaultTopN = 20
+	fleetMaxTopN     = 200
+)
+
+// RankedService is one service of the ranked fleet overview. ErrorPercent is
+// the share of server requests that returned 5xx.
+type RankedService struct {
+	Rank int
+	ServiceSummary
+	ErrorPercent float64
+}
+
+// FleetOverview is the get_service_summary response when sorting, filtering or
+// pagination is requested: services ranked unhealthiest first by SortBy.
+type FleetOverview struct {
+	SortBy     string          `json:"sort_by"`
+	Services   []RankedService `json:"services"`
+	Total      int             `json:"total"`   // services in the window
+	Matched    int             `json:"matched"` // services passing min_error_percent
+	Offset     int             `json:"offset"`
+	NextOffset int             `json:"next_offset,omitempty"`
+	Meta       *ResponseMeta   `json:"_meta,omitempty"`
+}
+
+// wantsFleetOverview reports whether args ask for the ranked overview rather
+// than the map keyed by service name.
+func (a ServiceSummaryArgs) wantsFleetOverview() bool {
+	return a.SortBy != "" || a.TopN != 0 || a.MinErrorPercent != 0 || a.Offset != 0
+}
+
+// validateFleetArgs checks the ranking arguments and returns the sort order
+// and page size to use.
+func validateFleetArgs(a ServiceSummaryArgs) (string, int, error) {
+	sortBy := strings.ToLower(strings.TrimSpace(a.SortBy))
+	if sortBy == "" {
+		sortBy = fleetSortErrorPercent
+	}
+	topN := a.TopN
+	if topN == 0 {
+		topN = fleetDefaultTopN
+	}
+	if topN < 1 || topN > fleetMaxTopN {
+		return "", 0, fmt.Errorf("top_n must be between 1 and %d", fleetMaxTopN)
+	}
+	if a.Offset < 0 {
+		return "", 0, fmt.Errorf("offset must be non-negative")
+	}
+	if a.MinErrorPercent < 0 || a.MinErrorPercent > 100 {
+		return "", 0, fmt.Errorf("min_error_percent must be between 0 and 100")
+	}
+	return sortBy, topN, nil
+}
+
+// rankServices filters summaries by minErrorPercent, sorts them highest first
+// by sortBy (ties by name) and returns the page at offset.
+func rankServices(summaries map[string]ServiceSummary, sortBy string, minErrorPercent float64, offset, topN int) FleetOverview {
+	ranked := make([]RankedService, 0, len(summaries))
+	for _, s := range summaries {
+		r := RankedService{ServiceSummary: s}
+		if s.Throughput > 0 {
+			r.ErrorPercent = s.ErrorRate / s.Throughput * 100
+		}
+		if r.ErrorPercent < minErrorPercent {
+			continue
+		}
+		ranked = append(ranked, r)
+	}
+
+	key := func(r RankedService) float64 {
+		switch sortBy {
+		case fleetSortErrorRate:
+			return r.ErrorRate
+		case fleetSortLatency:
+			return r.ResponseTime
+		case fleetSortThroughput:
+			return r.Throughput
+		}
+		return r.ErrorPercent
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ki, kj := key(ranked[i]), key(ranked[j]); ki != kj {
+			return ki > kj
+		}
+		return ranked[i].ServiceName < ranked[j].ServiceName
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+
+	start := min(offset, len(ranked))
+	end := min(start+topN, len(ranked))
+	overview := FleetOverview{
+		SortBy:   sortBy,
+		Services: ranked[start:end],
+		Total:    len(summaries),
+		Matched:  len(ranked),
+		Offset:   offset,
+	}
+	if end < len(ranked) {
+		overview.NextOffset = end
+	}
+	return overview
+}
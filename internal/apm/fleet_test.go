@@ -0,0 +1,107 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var fleet = map[string]ServiceSummary{
+	"api":      {ServiceName: "api", Throughput: 100, ErrorRate: 5, ResponseTime: 40},
+	"cart":     {ServiceName: "cart", Throughput: 10, ErrorRate: 2, ResponseTime: 300},
+	"checkout": {ServiceName: "checkout", Throughput: 50, ErrorRate: 0, ResponseTime: 120},
+	"search":   {ServiceName: "search", Throughput: 0, ErrorRate: 1, ResponseTime: 0},
+}
+
+func rankedNames(o FleetOverview) string {
+	var names []string
+	for _, s := range o.Services {
+		names = append(names, s.ServiceName)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRankServices(t *testing.T) {
+	tests := []struct {
+		sortBy     string
+		minErrPct  float64
+		offset     int
+		topN       int
+		want       string
+		matched    int
+		nextOffset int
+	}{
+		{fleetSortErrorPercent, 0, 0, 20, "cart,api,checkout,search", 4, 0},
+		{fleetSortErrorRate, 0, 0, 2, "api,cart", 4, 2},
+		{fleetSortLatency, 0, 2, 2, "api,search", 4, 0},
+		{fleetSortThroughput, 0, 0, 1, "api", 4, 1},
+		{fleetSortErrorPercent, 5, 0, 20, "cart,api", 2, 0},
+		{fleetSortErrorPercent, 0, 10, 20, "", 4, 0},
+	}
+	for _, tt := range tests {
+		got := rankServices(fleet, tt.sortBy, tt.minErrPct, tt.offset, tt.topN)
+		if names := rankedNames(got); names != tt.want || got.Matched != tt.matched || got.NextOffset != tt.nextOffset || got.Total != 4 {
+			t.Errorf("%s/min %v/offset %d/top %d: got %s matched=%d next=%d, want %s matched=%d next=%d",
+				tt.sortBy, tt.minErrPct, tt.offset, tt.topN, names, got.Matched, got.NextOffset, tt.want, tt.matched, tt.nextOffset)
+		}
+	}
+
+	got := rankServices(fleet, fleetSortErrorPercent, 0, 1, 1)
+	if s := got.Services[0]; s.Rank != 2 || s.ErrorPercent != 5 {
+		t.Errorf("second service = %+v, want rank 2 with 5%% errors", s)
+	}
+}
+
+func TestValidateFleetArgs(t *testing.T) {
+	if sortBy, topN, err := validateFleetArgs(ServiceSummaryArgs{MinErrorPercent: 1}); err != nil || sortBy != fleetSortErrorPercent || topN != fleetDefaultTopN {
+		t.Errorf("defaults = %s, %d, %v", sortBy, topN, err)
+	}
+	for name, args := range map[string]ServiceSummaryArgs{
+		"top_n":             {TopN: fleetMaxTopN + 1},
+		"offset":            {Offset: -1},
+		"min_error_percent": {MinErrorPercent: 101},
+	} {
+		if _, _, err := validateFleetArgs(args); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: error = %v", name, err)
+		}
+	}
+}
+
+func TestNewServiceSummaryHandler_FleetOverview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case strings.Contains(body.Query, "timestamp("):
+			io.WriteString(w, `[]`)
+		case strings.Contains(body.Query, "http_status_code"):
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"value":[0,"1"]},{"metric":{"service_name":"cart"},"value":[0,"4"]}]`)
+		case strings.Contains(body.Query, "trace_service_response_time"):
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"value":[0,"20"]}]`)
+		default:
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"value":[0,"100"]},{"metric":{"service_name":"cart"},"value":[0,"10"]},{"metric":{"service_name":"web"},"value":[0,"30"]}]`)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewServiceSummaryHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ServiceSummaryArgs{TopN: 2, MinErrorPercent: 0.5})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	var overview FleetOverview
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &overview); err != nil {
+		t.Fatal(err)
+	}
+	if names := rankedNames(overview); names != "cart,api" || overview.Total != 3 || overview.Matched != 2 || overview.Meta == nil {
+		t.Errorf("overview = %+v", overview)
+	}
+}
@@ -0,0 +1,156 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// stalenessThreshold is how far the newest sample of a metric may trail the
+// end of the query window before the metric is reported as stale.
+const stalenessThreshold = 5 * time.Minute
+
+// samplingCaveat is attached to every APM response: all RED metrics here are
+// derived from ingested spans.
+const samplingCaveat = "RED metrics are derived from ingested spans; if head or tail sampling is enabled upstream, absolute throughput and error counts are under-reported while ratios and latency quantiles remain representative."
+
+// Freshness statuses reported per probed metric.
+const (
+	freshnessFresh   = "fresh"
+	freshnessStale   = "stale"
+	freshnessNoData  = "no_data"
+	freshnessUnknown = "unknown"
+)
+
+// Confidence levels summarising a ResponseMeta.
+const (
+	confidenceHigh   = "high"
+	confidenceMedium = "medium"
+	confidenceLow    = "low"
+)
+
+// ResponseMeta describes how complete and current the data behind an APM
+// response is, so agents can qualify conclusions drawn from it.
+type ResponseMeta struct {
+	Confidence string            `json:"confidence"`
+	Freshness  []MetricFreshness `json:"freshness"`
+	Caveats    []string          `json:"caveats"`
+
+	// Set by withDataAvailability for handlers that track sub-queries.
+	DataAvailable *bool          `json:"data_available,omitempty"`
+	SubQueries    []SubQueryData `json:"sub_queries,omitempty"`
+	Hints         []string       `json:"hints,omitempty"`
+	DidYouMean    []string       `json:"did_you_mean,omitempty"` // closest known service names, when there is no data
+
+	// Set by withWiderWindowProbe when data exists before an empty window.
+	TimeRangeSuggestion *TimeRangeSuggestion `json:"time_range_suggestion,omitempty"`
+}
+
+// MetricFreshness reports the newest sample seen for one metric selector at
+// the end of the query window.
+type MetricFreshness struct {
+	Metric       string `json:"metric"`
+	Status       string `json:"status"`
+	LatestSample int64  `json:"latest_sample,omitempty"` // unix seconds
+	LagSeconds   int64  `json:"lag_seconds,omitempty"`
+}
+
+// checkFreshness runs one instant query per selector, in parallel, asking for
+// the timestamp of the newest sample in [startTime, endTime]. Selectors with
+// no samples in the window are reported as no_data; failed probes as
+// unknown. Results keep the order of selectors.
+func checkFreshness(ctx context.Context, client utils.HTTPClient, cfg models.Config, startTime, endTime int64, selectors ...string) []MetricFreshness {
+	out := make([]MetricFreshness, len(selectors))
+	var wg sync.WaitGroup
+	for i, selector := range selectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out[i] = probeFreshness(ctx, client, cfg, startTime, endTime, selector)
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// probeFreshness looks back over the whole window with last_over_time: a
+// bare selector in an instant query only sees samples within the backend's
+// lookback (about 5 minutes), so a metric that stopped earlier in the window
+// would read as no_data rather than stale.
+func probeFreshness(ctx context.Context, client utils.HTTPClient, cfg models.Config, startTime, endTime int64, selector string) MetricFreshness {
+	result := MetricFreshness{Metric: selector, Status: freshnessUnknown}
+
+	window := max(endTime-startTime, 60)
+	query := fmt.Sprintf("max(timestamp(last_over_time(%s[%ds])))", selector, window)
+	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, endTime, cfg)
+	if err != nil {
+		return result
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return result
+	}
+
+	var series apiPromInstantResp
+	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
+		return result
+	}
+	if len(series) == 0 {
+		result.Status = freshnessNoData
+		return result
+	}
+
+	latest := int64(parsePromValue(series[0].Value))
+	result.LatestSample = latest
+	result.LagSeconds = max(endTime-latest, 0)
+	if time.Duration(result.LagSeconds)*time.Second > stalenessThreshold {
+		result.Status = freshnessStale
+	} else {
+		result.Status = freshnessFresh
+	}
+	return result
+}
+
+// buildResponseMeta combines freshness probes and handler-specific caveats
+// (partial results, truncation) into a ResponseMeta. Confidence is high when
+// every probe is fresh and there are no extra caveats, low when no probe found
+// current data, and medium otherwise.
+func buildResponseMeta(freshness []MetricFreshness, caveats ...string) *ResponseMeta {
+	meta := &ResponseMeta{
+		Freshness: freshness,
+		Caveats:   append([]string{}, caveats...),
+	}
+
+	fresh, current := 0, 0
+	for _, f := range freshness {
+		switch f.Status {
+		case freshnessFresh:
+			fresh++
+			current++
+		case freshnessStale:
+			meta.Caveats = append(meta.Caveats, fmt.Sprintf("%s: newest sample is %ds older than the end of the window", f.Metric, f.LagSeconds))
+		case freshnessNoData:
+			meta.Caveats = append(meta.Caveats, fmt.Sprintf("%s: no samples in the query window", f.Metric))
+		case freshnessUnknown:
+			current++ // cannot tell; don't count it against the data
+		}
+	}
+
+	switch {
+	case len(freshness) > 0 && fresh == 0 && current == 0:
+		meta.Confidence = confidenceLow
+	case fresh == len(freshness) && len(caveats) == 0:
+		meta.Confidence = confidenceHigh
+	default:
+		meta.Confidence = confidenceMedium
+	}
+
+	meta.Caveats = append(meta.Caveats, samplingCaveat)
+	return meta
+}
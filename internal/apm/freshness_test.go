@@ -0,0 +1,104 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCheckFreshness(t *testing.T) {
+	const startTime, endTime = 1699996400, 1700000000
+	// Newest sample per metric. Like Prometheus, the server only sees a
+	// sample within the query's last_over_time range, or within 5 minutes
+	// of endTime for a bare selector.
+	newest := map[string]int64{
+		"fresh_metric": endTime - 30,
+		"stale_metric": endTime - 900,
+		"old_metric":   startTime - 600,
+	}
+	rangeArg := regexp.MustCompile(`\[(\d+)s\]`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if strings.Contains(body.Query, "broken_metric") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		lookback := int64(300)
+		if m := rangeArg.FindStringSubmatch(body.Query); m != nil && strings.Contains(body.Query, "last_over_time(") {
+			lookback, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		for name, ts := range newest {
+			if strings.Contains(body.Query, name) && ts >= endTime-lookback {
+				json.NewEncoder(w).Encode([]map[string]any{{"metric": map[string]string{}, "value": []any{endTime, strconv.FormatInt(ts, 10)}}})
+				return
+			}
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	got := checkFreshness(context.Background(), server.Client(), testDBConfig(server.URL), startTime, endTime,
+		"fresh_metric{}", "stale_metric{}", "old_metric{}", "missing_metric{}", "broken_metric{}")
+
+	want := []MetricFreshness{
+		{Metric: "fresh_metric{}", Status: freshnessFresh, LatestSample: endTime - 30, LagSeconds: 30},
+		{Metric: "stale_metric{}", Status: freshnessStale, LatestSample: endTime - 900, LagSeconds: 900},
+		{Metric: "old_metric{}", Status: freshnessNoData},
+		{Metric: "missing_metric{}", Status: freshnessNoData},
+		{Metric: "broken_metric{}", Status: freshnessUnknown},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildResponseMeta_Confidence(t *testing.T) {
+	fresh := MetricFreshness{Metric: "a", Status: freshnessFresh}
+	stale := MetricFreshness{Metric: "b", Status: freshnessStale, LagSeconds: 900}
+	noData := MetricFreshness{Metric: "c", Status: freshnessNoData}
+	unknown := MetricFreshness{Metric: "d", Status: freshnessUnknown}
+
+	tests := []struct {
+		name      string
+		freshness []MetricFreshness
+		caveats   []string
+		want      string
+	}{
+		{"all fresh", []MetricFreshness{fresh, fresh}, nil, confidenceHigh},
+		{"fresh with partial result", []MetricFreshness{fresh}, []string{"apdex_score unavailable"}, confidenceMedium},
+		{"one stale", []MetricFreshness{fresh, stale}, nil, confidenceMedium},
+		{"probe failed", []MetricFreshness{fresh, unknown}, nil, confidenceMedium},
+		{"nothing current", []MetricFreshness{stale, noData}, nil, confidenceLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := buildResponseMeta(tt.freshness, tt.caveats...)
+			if meta.Confidence != tt.want {
+				t.Errorf("confidence = %q, want %q", meta.Confidence, tt.want)
+			}
+			if last := meta.Caveats[len(meta.Caveats)-1]; last != samplingCaveat {
+				t.Errorf("last caveat = %q, want sampling caveat", last)
+			}
+		})
+	}
+}
+
+func TestBuildResponseMeta_StaleCaveat(t *testing.T) {
+	meta := buildResponseMeta([]MetricFreshness{{Metric: "trace_endpoint_count{}", Status: freshnessStale, LagSeconds: 900}})
+	if len(meta.Caveats) != 2 || !strings.Contains(meta.Caveats[0], "900s older") {
+		t.Errorf("caveats = %v, want stale caveat first", meta.Caveats)
+	}
+}
@@ -0,0 +1,541 @@
+package apm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	updateGolden = flag.Bool("update", false, "rewrite testdata/golden from the handlers' current output")
+	recordFrom   = flag.String("record", "", "API base URL (e.g. of the mock backend) to record testdata/fixtures from instead of replaying them")
+)
+
+// apiFixture is one recorded API response. A request uses the first fixture,
+// in file order, whose Path equals the request path and whose other fields,
+// when set, match too: the Query regular expression the PromQL query in the
+// request body, Timestamp its evaluation time, Label the label whose values
+// are listed and URLQuery the request's query string.
+type apiFixture struct {
+	Path      string          `json:"path"`
+	URLQuery  string          `json:"url_query,omitempty"`
+	Query     string          `json:"query,omitempty"`
+	Timestamp int64           `json:"timestamp,omitempty"`
+	Label     string          `json:"label,omitempty"`
+	Response  json.RawMessage `json:"response"`
+
+	re *regexp.Regexp
+}
+
+// fixtureClient is the utils.HTTPClient the golden tests give handlers in
+// place of the Last9 API. It answers from testdata/fixtures/<name>.json; a
+// request no fixture matches fails the test, so fixtures cover every query
+// a handler makes, freshness probes included. With -record it forwards
+// requests to that API instead and saves the answers as the fixtures.
+type fixtureClient struct {
+	t    *testing.T
+	name string
+
+	mu       sync.Mutex
+	fixtures []apiFixture
+}
+
+func newFixtureClient(t *testing.T, name string) *fixtureClient {
+	t.Helper()
+	c := &fixtureClient{t: t, name: name}
+	if *recordFrom != "" {
+		t.Cleanup(c.save)
+		return c
+	}
+	data, err := os.ReadFile(c.path())
+	if err != nil {
+		t.Fatalf("read fixtures (record them with -record): %v", err)
+	}
+	if err := json.Unmarshal(data, &c.fixtures); err != nil {
+		t.Fatalf("parse fixtures: %v", err)
+	}
+	for i := range c.fixtures {
+		if c.fixtures[i].Query != "" {
+			c.fixtures[i].re = regexp.MustCompile(c.fixtures[i].Query)
+		}
+	}
+	return c
+}
+
+func (c *fixtureClient) path() string {
+	return filepath.Join("testdata", "fixtures", c.name+".json")
+}
+
+func (c *fixtureClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	var promReq struct {
+		Query     string `json:"query"`
+		Timestamp int64  `json:"timestamp"`
+		Label     string `json:"label"`
+	}
+	_ = json.Unmarshal(body, &promReq)
+	key := apiFixture{Path: req.URL.Path, URLQuery: req.URL.RawQuery, Timestamp: promReq.Timestamp, Label: promReq.Label}
+	if *recordFrom != "" {
+		if promReq.Query != "" {
+			key.Query = "^" + regexp.QuoteMeta(promReq.Query) + "$"
+		}
+		return c.record(req, body, key)
+	}
+	for _, f := range c.fixtures {
+		if f.Path == key.Path && (f.URLQuery == "" || f.URLQuery == key.URLQuery) &&
+			(f.re == nil || f.re.MatchString(promReq.Query)) &&
+			(f.Timestamp == 0 || f.Timestamp == key.Timestamp) && (f.Label == "" || f.Label == key.Label) {
+			return fixtureResponse(req, http.StatusOK, f.Response), nil
+		}
+	}
+	c.t.Errorf("no fixture for %s %s query=%q", req.Method, req.URL.Path, promReq.Query)
+	return fixtureResponse(req, http.StatusNotFound, []byte("no fixture")), nil
+}
+
+// record forwards a request to the -record API and keeps its answer as the
+// fixture f, which matches the request exactly.
+func (c *fixtureClient) record(req *http.Request, body []byte, f apiFixture) (*http.Response, error) {
+	target, err := http.NewRequestWithContext(req.Context(), req.Method, strings.TrimSuffix(*recordFrom, "/")+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	target.Header = req.Header.Clone()
+	resp, err := http.DefaultClient.Do(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK && json.Valid(data) {
+		f.Response = data
+		c.mu.Lock()
+		c.fixtures = append(c.fixtures, f)
+		c.mu.Unlock()
+	}
+	return fixtureResponse(req, resp.StatusCode, data), nil
+}
+
+// save writes the recorded fixtures, sorted so concurrent queries record
+// the same file on every run.
+func (c *fixtureClient) save() {
+	matchKey := func(f apiFixture) string {
+		return strings.Join([]string{f.Path, f.URLQuery, f.Query, strconv.FormatInt(f.Timestamp, 10), f.Label}, "\x00")
+	}
+	sort.SliceStable(c.fixtures, func(i, j int) bool { return matchKey(c.fixtures[i]) < matchKey(c.fixtures[j]) })
+	compacted := []apiFixture{}
+	for _, f := range c.fixtures {
+		if n := len(compacted); n > 0 && matchKey(compacted[n-1]) == matchKey(f) {
+			continue
+		}
+		compacted = append(compacted, f)
+	}
+	data, err := json.MarshalIndent(compacted, "", "  ")
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := os.WriteFile(c.path(), append(data, '\n'), 0o644); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func fixtureResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// assertGolden compares the first text content of a handler's result,
+// indented when it is JSON, with testdata/golden/<name>.golden, or rewrites
+// the file with -update. render_chart's image is not compared, only the
+// summary after it.
+func assertGolden(t *testing.T, name string, result *mcp.CallToolResult) {
+	t.Helper()
+	var text *mcp.TextContent
+	if result != nil {
+		for _, c := range result.Content {
+			if tc, ok := c.(*mcp.TextContent); ok {
+				text = tc
+				break
+			}
+		}
+	}
+	if text == nil {
+		t.Fatal("handler returned no text content")
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, bytes.TrimSpace([]byte(text.Text)), "", "  "); err != nil {
+		got.Reset()
+		got.WriteString(strings.TrimSpace(text.Text))
+	}
+	got.WriteByte('\n')
+
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run go test ./internal/apm -run TestHandlersGolden -update to create it): %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("%s differs from %s (run with -update if the change is intended):\n%s", name, path, got.String())
+	}
+}
+
+// TestHandlersGolden runs handlers against recorded API responses and
+// compares their output with golden files, so query and formatting
+// refactors show up as diffs. Add a handler with a fixtures file, a case
+// here and -update. Time ranges are fixed so queries and output are stable.
+func TestHandlersGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error)
+	}{
+		{
+			name: "get_service_health_score",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetServiceHealthScoreHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetServiceHealthScoreArgs{
+					ServiceName:  "api",
+					Env:          "prod",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "check_release_health",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewCheckReleaseHealthHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, CheckReleaseHealthArgs{
+					ServiceName:   "checkout",
+					Env:           "production",
+					DeployTimeISO: "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_summary",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewServiceSummaryHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, ServiceSummaryArgs{
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_environments",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewServiceEnvironmentsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, ServiceEnvironmentsArgs{
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_performance_details",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewServicePerformanceDetailsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, ServicePerformanceDetailsArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_operations_summary",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewServiceOperationsSummaryHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, ServiceOperationsSummaryArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_dependency_graph",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewServiceDependencyGraphHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, ServiceDependencyGraphArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "prometheus_range_query",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewPromqlRangeQueryHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, PromqlRangeQueryArgs{
+					Query:        `sum by (service_name) (rate(trace_endpoint_count{env="production"}[5m]))`,
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T10:30:00Z",
+				})
+			},
+		},
+		{
+			name: "prometheus_instant_query",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewPromqlInstantQueryHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, PromqlInstantQueryArgs{
+					Query:   `sum by (service_name) (rate(trace_endpoint_count{env="production"}[5m]))`,
+					TimeISO: "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "prometheus_label_values",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewPromqlLabelValuesHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, PromqlLabelValuesArgs{
+					MatchQuery:   "trace_endpoint_count",
+					Label:        "service_name",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "prometheus_labels",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewPromqlLabelsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, PromqlLabelsArgs{
+					MatchQuery:   "trace_endpoint_count",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_databases",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetDatabasesHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetDatabasesArgs{
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_database_slow_queries",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetDatabaseSlowQueriesHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetDatabaseSlowQueriesArgs{
+					DBSystem:     "postgresql",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_database_queries",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetDatabaseQueriesHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetDatabaseQueriesArgs{
+					DBSystem:     "postgresql",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_database_server_metrics",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetDatabaseServerMetricsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetDatabaseServerMetricsArgs{
+					DBSystem:     "postgresql",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_apm_service_deviations",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewAPMServiceDeviationsHandler(client, cfg, nil)(ctx, &mcp.CallToolRequest{}, DeviationArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_endpoints",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetServiceEndpointsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetServiceEndpointsArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_consumer_operations",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetConsumerOperationsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetConsumerOperationsArgs{
+					ServiceName:  "inventory",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_grpc_operations",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetGRPCOperationsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetGRPCOperationsArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "draft_rca",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewDraftRCAHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, DraftRCAArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "render_chart",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewRenderChartHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, RenderChartArgs{
+					Query:        `sum by (service_name) (rate(trace_endpoint_count{env="production"}[5m]))`,
+					Format:       "svg",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_latency_distribution",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetLatencyDistributionHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetLatencyDistributionArgs{
+					Metric:       "http_server_request_duration_seconds",
+					Matchers:     `service_name="checkout",env="production"`,
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "evaluate_burn_rate",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewEvaluateBurnRateHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, EvaluateBurnRateArgs{
+					Objective:   99.9,
+					ServiceName: "checkout",
+					Env:         "production",
+					EndTimeISO:  "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "analyze_cardinality",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewAnalyzeCardinalityHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, AnalyzeCardinalityArgs{
+					Selector:     `trace_endpoint_count{env="production"}`,
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_ingestion_volume",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetIngestionVolumeHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetIngestionVolumeArgs{
+					By:           "service_name",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_runtime_metrics",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetRuntimeMetricsHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetRuntimeMetricsArgs{
+					ServiceName:  "checkout",
+					Env:          "production",
+					Runtime:      "go",
+					StartTimeISO: "2026-05-01T10:00:00Z",
+					EndTimeISO:   "2026-05-01T11:00:00Z",
+				})
+			},
+		},
+		{
+			name: "get_service_history",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGetServiceHistoryHandler(client, cfg)(ctx, &mcp.CallToolRequest{}, GetServiceHistoryArgs{
+					ServiceName: "checkout",
+					Env:         "production",
+					Days:        3,
+				})
+			},
+		},
+		{
+			name: "generate_handoff_summary",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				return NewGenerateHandoffSummaryHandler(client, cfg, nil)(ctx, &mcp.CallToolRequest{}, GenerateHandoffSummaryArgs{
+					Env:      "production",
+					SinceISO: "2026-05-01T03:00:00Z",
+				})
+			},
+		},
+		{
+			name: "list_datasources",
+			call: func(ctx context.Context, client utils.HTTPClient, cfg models.Config) (*mcp.CallToolResult, any, error) {
+				cfg.Datasources = []models.DatasourceInfo{{Name: "production", IsDefault: true}, {Name: "staging"}}
+				return NewListDatasourcesHandler(cfg)(ctx, &mcp.CallToolRequest{}, ListDatasourcesArgs{})
+			},
+		},
+	}
+	// Handlers that read the clock see the end of the windows above.
+	now := time.Date(2026, 5, 1, 11, 0, 0, 0, time.UTC)
+	setClock(t, &historyNow, now)
+	setClock(t, &handoffNow, now)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := tt.call(context.Background(), newFixtureClient(t, tt.name), testDBConfig("https://fixtures.last9.test"))
+			if err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			assertGolden(t, tt.name, result)
+		})
+	}
+}
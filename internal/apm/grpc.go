@@ -0,0 +1,248 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_grpc_operations tool ---
+
+type GetGRPCOperationsArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"Name of the service to summarise gRPC methods for (required)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	SpanKind        string  `json:"span_kind,omitempty" jsonschema:"server for the methods the service implements (default), client for the methods it calls (one of: server, client)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+// GRPCMethod is one rpc_service/rpc_method pair.
+type GRPCMethod struct {
+	RPCService   string             `json:"rpc_service"`
+	RPCMethod    string             `json:"rpc_method"`
+	SpanName     string             `json:"span_name"`
+	Throughput   float64            `json:"throughput_rpm"`
+	ErrorRate    float64            `json:"error_rpm"`
+	ErrorPercent float64            `json:"error_percent"`
+	P95Latency   float64            `json:"p95_latency_ms"`
+	StatusCodes  map[string]float64 `json:"status_codes_rpm"`
+}
+
+// grpcStatusNames maps numeric gRPC status codes to their canonical names.
+var grpcStatusNames = map[string]string{
+	"0": "OK", "1": "CANCELLED", "2": "UNKNOWN", "3": "INVALID_ARGUMENT",
+	"4": "DEADLINE_EXCEEDED", "5": "NOT_FOUND", "6": "ALREADY_EXISTS",
+	"7": "PERMISSION_DENIED", "8": "RESOURCE_EXHAUSTED", "9": "FAILED_PRECONDITION",
+	"10": "ABORTED", "11": "OUT_OF_RANGE", "12": "UNIMPLEMENTED", "13": "INTERNAL",
+	"14": "UNAVAILABLE", "15": "DATA_LOSS", "16": "UNAUTHENTICATED",
+}
+
+// grpcServerErrorCodes are the codes the OpenTelemetry RPC conventions treat
+// as server errors; the rest describe caller mistakes. On the client side
+// every non-OK code is an error.
+var grpcServerErrorCodes = map[string]bool{
+	"2": true, "4": true, "12": true, "13": true, "14": true, "15": true,
+}
+
+// splitGRPCSpanName splits "helloworld.Greeter/SayHello" into service and
+// method. Span names without a slash are returned as the method with an
+// empty service.
+func splitGRPCSpanName(spanName string) (service, method string) {
+	if i := strings.LastIndex(spanName, "/"); i >= 0 {
+		return strings.TrimPrefix(spanName[:i], "/"), spanName[i+1:]
+	}
+	return "", spanName
+}
+
+// grpcStatusLabel names a series' gRPC status, falling back to the span
+// status when the rpc_grpc_status_code attribute was not recorded.
+func grpcStatusLabel(metric map[string]string) string {
+	if code := metric["rpc_grpc_status_code"]; code != "" {
+		if name, ok := grpcStatusNames[code]; ok {
+			return name
+		}
+		return code
+	}
+	if metric["status_code"] == "STATUS_CODE_ERROR" {
+		return "span_error"
+	}
+	return "unset"
+}
+
+// isGRPCError classifies a series as failed using its gRPC status code where
+// present, otherwise the span status.
+func isGRPCError(metric map[string]string, client bool) bool {
+	code := metric["rpc_grpc_status_code"]
+	if code == "" {
+		return metric["status_code"] == "STATUS_CODE_ERROR"
+	}
+	if _, err := strconv.Atoi(code); err != nil {
+		return metric["status_code"] == "STATUS_CODE_ERROR"
+	}
+	if client {
+		return code != "0"
+	}
+	return grpcServerErrorCodes[code]
+}
+
+func NewGetGRPCOperationsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetGRPCOperationsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetGRPCOperationsArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+
+		var metric, spanKind string
+		if strings.EqualFold(strings.TrimSpace(args.SpanKind), "client") {
+			metric, spanKind = "trace_client", "SPAN_KIND_CLIENT"
+		} else {
+			metric, spanKind = "trace_endpoint", "SPAN_KIND_SERVER"
+		}
+		isClient := spanKind == "SPAN_KIND_CLIENT"
+
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		durationMin := (endTime - startTime) / 60
+		if durationMin <= 0 {
+			durationMin = 1
+		}
+
+		env := resolveEnv(cfg, args.Env)
+
+		baseFilter := fmt.Sprintf(
+			`service_name="%s", env=~"%s", span_kind="%s", rpc_system="grpc"`,
+			escapePromQLLabel(args.ServiceName), escapePromQLLabel(env), spanKind,
+		)
+
+		statusQuery := fmt.Sprintf(
+			`sum by(span_name, rpc_grpc_status_code, status_code)(sum_over_time(%s_count{%s}[%dm])) / %d`,
+			metric, baseFilter, durationMin, durationMin,
+		)
+		latencyQuery := fmt.Sprintf(
+			`max by(span_name)(avg_over_time(%s_duration{%s, quantile="p95"}[%dm]))`,
+			metric, baseFilter, durationMin,
+		)
+
+		var (
+			statusSeries  apiPromInstantResp
+			latencySeries apiPromInstantResp
+			statusErr     error
+			latencyErr    error
+			wg            sync.WaitGroup
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			statusSeries, statusErr = fetchPromInstant(ctx, client, cfg, statusQuery, endTime)
+		}()
+		go func() {
+			defer wg.Done()
+			latencySeries, latencyErr = fetchPromInstant(ctx, client, cfg, latencyQuery, endTime)
+		}()
+		wg.Wait()
+
+		if statusErr != nil {
+			return nil, nil, fmt.Errorf("failed to fetch gRPC throughput: %w", statusErr)
+		}
+
+		methods := make(map[string]*GRPCMethod)
+		for _, point := range statusSeries {
+			spanName := point.Metric["span_name"]
+			if spanName == "" {
+				continue
+			}
+			m, ok := methods[spanName]
+			if !ok {
+				service, method := splitGRPCSpanName(spanName)
+				m = &GRPCMethod{
+					RPCService:  service,
+					RPCMethod:   method,
+					SpanName:    spanName,
+					StatusCodes: make(map[string]float64),
+				}
+				methods[spanName] = m
+			}
+			val := parsePromValue(point.Value)
+			m.StatusCodes[grpcStatusLabel(point.Metric)] += val
+			m.Throughput += val
+			if isGRPCError(point.Metric, isClient) {
+				m.ErrorRate += val
+			}
+		}
+
+		var warnings []string
+		if latencyErr != nil {
+			warnings = append(warnings, "p95 latency unavailable")
+		}
+		for _, point := range latencySeries {
+			if m, ok := methods[point.Metric["span_name"]]; ok {
+				m.P95Latency = parsePromValue(point.Value)
+			}
+		}
+
+		if len(methods) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("No gRPC %s operations found for service %q (env=~%q) in the given time range.", strings.ToLower(strings.TrimPrefix(spanKind, "SPAN_KIND_")), args.ServiceName, env)},
+				},
+			}, nil, nil
+		}
+
+		result := make([]GRPCMethod, 0, len(methods))
+		for _, m := range methods {
+			if m.Throughput > 0 {
+				m.ErrorPercent = m.ErrorRate / m.Throughput * 100
+			}
+			result = append(result, *m)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Throughput != result[j].Throughput {
+				return result[i].Throughput > result[j].Throughput
+			}
+			return result[i].SpanName < result[j].SpanName
+		})
+
+		response := map[string]any{
+			"service_name": args.ServiceName,
+			"env":          env,
+			"span_kind":    spanKind,
+			"count":        len(result),
+			"methods":      result,
+			"_meta": buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf("%s_count{%s}", metric, baseFilter),
+			)),
+		}
+		if len(warnings) > 0 {
+			response["_warnings"] = warnings
+		}
+
+		jsonBytes, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "operations")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
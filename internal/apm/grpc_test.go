@@ -0,0 +1,129 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSplitGRPCSpanName(t *testing.T) {
+	tests := []struct {
+		in, service, method string
+	}{
+		{"helloworld.Greeter/SayHello", "helloworld.Greeter", "SayHello"},
+		{"/grpc.health.v1.Health/Check", "grpc.health.v1.Health", "Check"},
+		{"SayHello", "", "SayHello"},
+	}
+	for _, tt := range tests {
+		service, method := splitGRPCSpanName(tt.in)
+		if service != tt.service || method != tt.method {
+			t.Errorf("splitGRPCSpanName(%q) = (%q, %q), want (%q, %q)", tt.in, service, method, tt.service, tt.method)
+		}
+	}
+}
+
+func TestIsGRPCError(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric map[string]string
+		client bool
+		want   bool
+	}{
+		{"ok", map[string]string{"rpc_grpc_status_code": "0"}, false, false},
+		{"server internal", map[string]string{"rpc_grpc_status_code": "13"}, false, true},
+		{"server not found is caller error", map[string]string{"rpc_grpc_status_code": "5"}, false, false},
+		{"client not found", map[string]string{"rpc_grpc_status_code": "5"}, true, true},
+		{"falls back to span status", map[string]string{"status_code": "STATUS_CODE_ERROR"}, false, true},
+		{"unset", map[string]string{"status_code": "STATUS_CODE_UNSET"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := isGRPCError(tt.metric, tt.client); got != tt.want {
+			t.Errorf("%s: isGRPCError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetGRPCOperationsHandler(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+
+		var response []map[string]any
+		switch {
+		case strings.Contains(body.Query, "by(span_name, rpc_grpc_status_code, status_code)"):
+			response = []map[string]any{
+				{"metric": map[string]string{"span_name": "shop.Cart/Checkout", "rpc_grpc_status_code": "0"}, "value": []any{1700000000, "80"}},
+				{"metric": map[string]string{"span_name": "shop.Cart/Checkout", "rpc_grpc_status_code": "14"}, "value": []any{1700000000, "15"}},
+				{"metric": map[string]string{"span_name": "shop.Cart/Checkout", "rpc_grpc_status_code": "3"}, "value": []any{1700000000, "5"}},
+				{"metric": map[string]string{"span_name": "shop.Cart/Get", "rpc_grpc_status_code": "0"}, "value": []any{1700000000, "10"}},
+			}
+		case strings.Contains(body.Query, "trace_endpoint_duration"):
+			response = []map[string]any{
+				{"metric": map[string]string{"span_name": "shop.Cart/Checkout"}, "value": []any{1700000000, "42"}},
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	handler := NewGetGRPCOperationsHandler(server.Client(), testDBConfig(server.URL))
+	now := time.Now().UTC()
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetGRPCOperationsArgs{
+		ServiceName:  "cart",
+		StartTimeISO: now.Add(-60 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var response struct {
+		Count   int          `json:"count"`
+		Methods []GRPCMethod `json:"methods"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Count != 2 {
+		t.Fatalf("count = %d, want 2", response.Count)
+	}
+
+	top := response.Methods[0]
+	if top.RPCService != "shop.Cart" || top.RPCMethod != "Checkout" {
+		t.Errorf("top method = %s/%s, want shop.Cart/Checkout", top.RPCService, top.RPCMethod)
+	}
+	// INVALID_ARGUMENT is a caller error on the server side; only UNAVAILABLE counts.
+	if top.Throughput != 100 || top.ErrorRate != 15 || top.ErrorPercent != 15 || top.P95Latency != 42 {
+		t.Errorf("top method metrics = %+v", top)
+	}
+	if top.StatusCodes["UNAVAILABLE"] != 15 {
+		t.Errorf("status_codes_rpm = %v, want UNAVAILABLE -> 15", top.StatusCodes)
+	}
+
+	if !strings.Contains(strings.Join(queries, "\n"), `rpc_system="grpc"`) {
+		t.Errorf("queries should select gRPC spans: %v", queries)
+	}
+}
+
+func TestGetGRPCOperationsHandler_Validation(t *testing.T) {
+	handler := NewGetGRPCOperationsHandler(http.DefaultClient, testDBConfig("http://unused"))
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetGRPCOperationsArgs{}); err == nil {
+		t.Fatal("expected error when service_name is missing")
+	}
+}
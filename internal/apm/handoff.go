@@ -0,0 +1,424 @@
+package apm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/change_events"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- generate_handoff_summary tool ---
+
+const (
+	// handoffDefaultLookback is one shift.
+	handoffDefaultLookback = 8 * time.Hour
+	handoffMaxLookback     = 24 * time.Hour
+	// handoffRecentWindow is how far back "still degraded" looks: a service
+	// that regressed during the shift is unresolved if it still is over the
+	// last few minutes.
+	handoffRecentWindow = 15 * time.Minute
+	// handoffMaxServices caps the degraded services listed.
+	handoffMaxServices = 20
+	// handoffMaxChanges caps the change events listed.
+	handoffMaxChanges = 20
+)
+
+type GenerateHandoffSummaryArgs struct {
+	Env      string `json:"env,omitempty" jsonschema:"Deployment environment to summarise (e.g. production). Default: the server default env if configured, else all environments."`
+	SinceISO string `json:"since_iso,omitempty" jsonschema:"Start of the shift in RFC3339 format (e.g. 2026-02-09T06:00:00Z). Default: 8 hours ago; at most 24 hours ago."`
+}
+
+// handoffService is a service whose error rate or p95 latency regressed over
+// the shift against the equally long window before it.
+type handoffService struct {
+	name                       string
+	errorPercent, baseErrorPct float64
+	p95Ms, baseP95Ms           float64
+	recentErrorPct, recentP95  float64
+	hasRecent                  bool
+	reasons                    []string
+}
+
+// unresolved reports whether the service is still regressed over the recent
+// window.
+func (s handoffService) unresolved() bool {
+	return s.hasRecent && handoffRegressed(s.recentErrorPct, s.baseErrorPct, s.recentP95, s.baseP95Ms)
+}
+
+// handoffRegressed applies the draft_rca thresholds: error percentage up by
+// rcaErrorDeltaPercent points, or p95 up by rcaLatencyRatio.
+func handoffRegressed(errPct, baseErrPct, p95, baseP95 float64) bool {
+	return errPct-baseErrPct >= rcaErrorDeltaPercent || (baseP95 > 0 && p95/baseP95 >= rcaLatencyRatio)
+}
+
+// handoffChange is a change event recorded during the shift.
+type handoffChange struct {
+	at      int64
+	service string
+	event   string
+}
+
+// handoffInputs is everything the document is assembled from.
+type handoffInputs struct {
+	env         string
+	start, end  int64
+	alerts      []alerting.FiredAlert
+	services    []handoffService
+	changes     []handoffChange
+	maintenance []maintenance.Window
+	failures    []string
+}
+
+// handoffServiceQueries returns per-service error percentage and p95 queries
+// over rangeExpr (e.g. "480m offset 480m"), keyed by signal.
+func handoffServiceQueries(env, rangeExpr string) map[string]string {
+	sel := fmt.Sprintf(`env=~"%s", span_kind="SPAN_KIND_SERVER"`, escapePromQLLabel(env))
+	return map[string]string{
+		"error_percent": fmt.Sprintf(
+			`100 * (sum by (service_name)(sum_over_time(trace_endpoint_count{%[1]s, status_code="STATUS_CODE_ERROR"}[%[2]s])) or (0 * sum by (service_name)(sum_over_time(trace_endpoint_count{%[1]s}[%[2]s])))) / sum by (service_name)(sum_over_time(trace_endpoint_count{%[1]s}[%[2]s]))`,
+			sel, rangeExpr,
+		),
+		"p95": fmt.Sprintf(
+			`max by (service_name)(avg_over_time(trace_service_response_time{env=~"%s", quantile="p95"}[%s]))`,
+			escapePromQLLabel(env), rangeExpr,
+		),
+	}
+}
+
+// handoffDegradedServices compares each service's shift, baseline and recent
+// windows, keyed "<window>/<signal>", and returns the regressed services,
+// unresolved first, then by error percentage increase.
+func handoffDegradedServices(series map[string]apiPromInstantResp) []handoffService {
+	values := map[string]map[string]float64{}
+	for key, resp := range series {
+		for _, s := range resp {
+			name := s.Metric["service_name"]
+			v := parsePromValue(s.Value)
+			if name == "" || math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			if values[name] == nil {
+				values[name] = map[string]float64{}
+			}
+			values[name][key] = v
+		}
+	}
+
+	var out []handoffService
+	for name, v := range values {
+		if _, ok := v["shift/error_percent"]; !ok {
+			continue
+		}
+		s := handoffService{
+			name:         name,
+			errorPercent: round1(v["shift/error_percent"]),
+			baseErrorPct: round1(v["baseline/error_percent"]),
+			p95Ms:        round1(v["shift/p95"]),
+			baseP95Ms:    round1(v["baseline/p95"]),
+		}
+		if recent, ok := v["recent/error_percent"]; ok {
+			s.hasRecent = true
+			s.recentErrorPct = round1(recent)
+			s.recentP95 = round1(v["recent/p95"])
+		}
+		if s.errorPercent-s.baseErrorPct >= rcaErrorDeltaPercent {
+			s.reasons = append(s.reasons, fmt.Sprintf("error rate %.1f%% (was %.1f%%)", s.errorPercent, s.baseErrorPct))
+		}
+		if s.baseP95Ms > 0 && s.p95Ms/s.baseP95Ms >= rcaLatencyRatio {
+			s.reasons = append(s.reasons, fmt.Sprintf("p95 %.0fms (was %.0fms)", s.p95Ms, s.baseP95Ms))
+		}
+		if len(s.reasons) > 0 {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ui, uj := out[i].unresolved(), out[j].unresolved(); ui != uj {
+			return ui
+		}
+		di, dj := out[i].errorPercent-out[i].baseErrorPct, out[j].errorPercent-out[j].baseErrorPct
+		if di != dj {
+			return di > dj
+		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+// handoffChangesFromSeries turns change event series into one change per
+// series, dated by its first sample, oldest first.
+func handoffChangesFromSeries(series []change_events.TimeSeries) []handoffChange {
+	var out []handoffChange
+	for _, s := range series {
+		if len(s.Values) == 0 {
+			continue
+		}
+		event := firstNonEmpty(s.Metric["event_name"], s.Metric["event_type"], "change")
+		if state := s.Metric["event_state"]; state != "" {
+			event += " (" + state + ")"
+		}
+		if version := s.Metric["version"]; version != "" {
+			event += " version=" + version
+		}
+		out = append(out, handoffChange{at: int64(s.Values[0].Timestamp), service: s.Metric["service_name"], event: event})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].at < out[j].at })
+	return out
+}
+
+// buildHandoffMarkdown renders the handoff document. It does no I/O.
+func buildHandoffMarkdown(in handoffInputs) string {
+	var b strings.Builder
+	env := in.env
+	if env == "" || env == ".*" {
+		env = "all environments"
+	}
+	fmt.Fprintf(&b, "# On-call handoff: %s\n\n", env)
+	fmt.Fprintf(&b, "Shift: %s to %s\n\n", rcaTime(in.start), rcaTime(in.end))
+
+	firing, unresolved := 0, 0
+	for _, a := range in.alerts {
+		if a.Firing {
+			firing++
+		}
+	}
+	for _, s := range in.services {
+		if s.unresolved() {
+			unresolved++
+		}
+	}
+
+	b.WriteString("## Summary\n\n")
+	fmt.Fprintf(&b, "- %d alert rule(s) fired, %d still firing\n", len(in.alerts), firing)
+	fmt.Fprintf(&b, "- %d service(s) degraded against the previous shift, %d still degraded\n", len(in.services), unresolved)
+	fmt.Fprintf(&b, "- %d change event(s)\n", len(in.changes))
+	fmt.Fprintf(&b, "- %d maintenance window(s) active or upcoming\n\n", len(in.maintenance))
+
+	b.WriteString("## Needs attention\n\n")
+	attention := 0
+	for _, a := range in.alerts {
+		if a.Firing {
+			fmt.Fprintf(&b, "- Alert **%s** (%s) still firing%s\n", a.RuleName, a.Severity, handoffServicesSuffix(a.Services))
+			attention++
+		}
+	}
+	for _, s := range in.services {
+		if s.unresolved() {
+			fmt.Fprintf(&b, "- **%s** still degraded: error rate %.1f%%, p95 %.0fms over the last %d minutes\n", s.name, s.recentErrorPct, s.recentP95, int(handoffRecentWindow.Minutes()))
+			attention++
+		}
+	}
+	if attention == 0 {
+		b.WriteString("Nothing unresolved.\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Alerts fired\n\n")
+	if len(in.alerts) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, a := range in.alerts {
+		status := "resolved"
+		if a.Firing {
+			status = "firing"
+		}
+		fmt.Fprintf(&b, "- %s (%s, %s): %d instance(s)%s", a.RuleName, a.Severity, status, a.Instances, handoffServicesSuffix(a.Services))
+		if a.LastFiredAt > 0 {
+			fmt.Fprintf(&b, ", last fired %s", rcaTime(a.LastFiredAt))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Degraded services\n\n")
+	if len(in.services) == 0 {
+		b.WriteString("None.\n")
+	}
+	for i, s := range in.services {
+		if i == handoffMaxServices {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(in.services)-handoffMaxServices)
+			break
+		}
+		status := "recovered"
+		if s.unresolved() {
+			status = "still degraded"
+		} else if !s.hasRecent {
+			status = "no recent traffic"
+		}
+		fmt.Fprintf(&b, "- %s: %s; %s\n", s.name, strings.Join(s.reasons, ", "), status)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Changes\n\n")
+	if len(in.changes) == 0 {
+		b.WriteString("None recorded.\n")
+	}
+	for i, c := range in.changes {
+		if i == handoffMaxChanges {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(in.changes)-handoffMaxChanges)
+			break
+		}
+		service := ""
+		if c.service != "" {
+			service = c.service + ": "
+		}
+		fmt.Fprintf(&b, "- %s %s%s\n", rcaTime(c.at), service, c.event)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Maintenance\n\n")
+	if len(in.maintenance) == 0 {
+		b.WriteString("None declared.\n")
+	}
+	for _, w := range in.maintenance {
+		fmt.Fprintf(&b, "- %s: %s\n", w.ServiceName, w)
+	}
+
+	if len(in.failures) > 0 {
+		b.WriteString("\n## Incomplete data\n\n")
+		for _, f := range in.failures {
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+	return b.String()
+}
+
+// handoffNow is the clock a shift ends at; tests replace it.
+var handoffNow = time.Now
+
+func handoffServicesSuffix(services []string) string {
+	if len(services) == 0 {
+		return ""
+	}
+	return " on " + strings.Join(services, ", ")
+}
+
+func NewGenerateHandoffSummaryHandler(client utils.HTTPClient, cfg models.Config, windows *maintenance.Store) func(context.Context, *mcp.CallToolRequest, GenerateHandoffSummaryArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GenerateHandoffSummaryArgs) (*mcp.CallToolResult, any, error) {
+		now := handoffNow().UTC()
+		since := now.Add(-handoffDefaultLookback)
+		if args.SinceISO != "" {
+			t, err := time.Parse(time.RFC3339, args.SinceISO)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid since_iso: %w", err)
+			}
+			since = t
+		}
+		if !since.Before(now) {
+			return nil, nil, fmt.Errorf("since_iso must be in the past")
+		}
+		if now.Sub(since) > handoffMaxLookback {
+			return nil, nil, fmt.Errorf("since_iso must be within the last %s", handoffMaxLookback)
+		}
+		start, end := since.Unix(), now.Unix()
+		env := resolveEnv(cfg, args.Env)
+		durationMin := max((end-start)/60, 1)
+
+		ranges := map[string]string{
+			"shift":    fmt.Sprintf("%dm", durationMin),
+			"baseline": fmt.Sprintf("%dm offset %dm", durationMin, durationMin),
+			"recent":   fmt.Sprintf("%dm", int(handoffRecentWindow.Minutes())),
+		}
+
+		var (
+			mu       sync.Mutex
+			series   = map[string]apiPromInstantResp{}
+			alerts   []alerting.FiredAlert
+			changes  []handoffChange
+			failures []string
+			wg       sync.WaitGroup
+		)
+		fail := func(format string, a ...any) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, fmt.Sprintf(format, a...))
+		}
+		for window, rangeExpr := range ranges {
+			for signal, query := range handoffServiceQueries(env, rangeExpr) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := fetchPromInstant(ctx, client, cfg, query, end)
+					if err != nil {
+						fail("%s %s query failed: %v", window, signal, err)
+						return
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					series[window+"/"+signal] = resp
+				}()
+			}
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			fired, err := alerting.FiredAlerts(ctx, client, cfg, env, start, end)
+			if err != nil {
+				fail("alerts lookup failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			alerts = fired
+		}()
+		go func() {
+			defer wg.Done()
+			changeEnv := env
+			if changeEnv == ".*" {
+				changeEnv = ""
+			}
+			resp, err := change_events.QueryChangeEvents(ctx, client, cfg, "", changeEnv, "", start, end)
+			if err != nil {
+				fail("change events lookup failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			changes = handoffChangesFromSeries(resp)
+		}()
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(failures)
+
+		var active []maintenance.Window
+		for _, w := range windows.All() {
+			if w.End.After(now) && (env == ".*" || w.Env == "" || strings.EqualFold(w.Env, env)) {
+				active = append(active, w)
+			}
+		}
+
+		doc := buildHandoffMarkdown(handoffInputs{
+			env:         env,
+			start:       start,
+			end:         end,
+			alerts:      alerts,
+			services:    handoffDegradedServices(series),
+			changes:     changes,
+			maintenance: active,
+			failures:    failures,
+		})
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(start*1000, end*1000, "", env, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: doc},
+			},
+		}, nil, nil
+	}
+}
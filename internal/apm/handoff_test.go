@@ -0,0 +1,122 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandoffDegradedServices(t *testing.T) {
+	series := func(values map[string]string) apiPromInstantResp {
+		var out apiPromInstantResp
+		for name, v := range values {
+			out = append(out, struct {
+				Metric map[string]string `json:"metric"`
+				Value  []any             `json:"value"`
+			}{Metric: map[string]string{"service_name": name}, Value: []any{1700000000.0, v}})
+		}
+		return out
+	}
+	got := handoffDegradedServices(map[string]apiPromInstantResp{
+		"shift/error_percent":    series(map[string]string{"api": "8", "cart": "6", "search": "0.5"}),
+		"baseline/error_percent": series(map[string]string{"api": "1", "cart": "1", "search": "0.5"}),
+		"recent/error_percent":   series(map[string]string{"api": "0.5", "cart": "5"}),
+		"shift/p95":              series(map[string]string{"api": "100", "cart": "100", "search": "400"}),
+		"baseline/p95":           series(map[string]string{"api": "100", "cart": "100", "search": "100"}),
+		"recent/p95":             series(map[string]string{"api": "100", "cart": "100"}),
+	})
+	if len(got) != 3 {
+		t.Fatalf("degraded = %+v, want api, cart and search", got)
+	}
+	if got[0].name != "cart" || !got[0].unresolved() {
+		t.Errorf("first = %+v, want cart, still degraded", got[0])
+	}
+	if got[1].name != "api" || got[1].unresolved() {
+		t.Errorf("second = %+v, want api, recovered", got[1])
+	}
+	if got[2].name != "search" || got[2].hasRecent || !strings.Contains(strings.Join(got[2].reasons, ","), "p95 400ms (was 100ms)") {
+		t.Errorf("third = %+v, want search with a latency regression and no recent traffic", got[2])
+	}
+}
+
+func TestGenerateHandoffSummaryHandler(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constants.EndpointAlertsMonitor:
+			json.NewEncoder(w).Encode(alerting.AlertsResponse{AlertRules: []alerting.AlertRuleData{{
+				RuleID: "r1", RuleName: "checkout errors", Severity: "breach", State: "firing",
+				Alerts: []alerting.AlertInstance{{State: "firing", LabelHash: "h1", LastFiredAt: now.Unix(), GroupLabels: map[string]interface{}{"service_name": "checkout"}}},
+			}}})
+			return
+		case constants.EndpointPromQuery:
+			json.NewEncoder(w).Encode([]map[string]any{{
+				"metric": map[string]string{"event_name": "deployment", "service_name": "checkout", "version": "v42"},
+				"values": [][]any{{float64(now.Add(-time.Hour).Unix()), "1"}},
+			}})
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		value := "100"
+		switch {
+		case strings.Contains(body.Query, "100 *") && strings.Contains(body.Query, "offset"):
+			value = "1"
+		case strings.Contains(body.Query, "100 *"):
+			value = "9"
+		}
+		json.NewEncoder(w).Encode([]map[string]any{{"metric": map[string]string{"service_name": "checkout"}, "value": []any{1700000000, value}}})
+	}))
+	defer server.Close()
+
+	windows := maintenance.New("")
+	if _, err := windows.Add(maintenance.Window{ServiceName: "payments", Start: now, End: now.Add(time.Hour), Reason: "card vault rotation"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewGenerateHandoffSummaryHandler(server.Client(), testDBConfig(server.URL), windows)
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GenerateHandoffSummaryArgs{
+		Env:      "prod",
+		SinceISO: now.Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	doc := result.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{
+		"# On-call handoff: prod",
+		"- 1 alert rule(s) fired, 1 still firing",
+		"- Alert **checkout errors** (breach) still firing on checkout",
+		"- **checkout** still degraded: error rate 9.0%",
+		"- checkout: error rate 9.0% (was 1.0%); still degraded",
+		"checkout: deployment version=v42",
+		"payments: card vault rotation",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("handoff missing %q:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "Incomplete data") {
+		t.Errorf("unexpected lookup failure:\n%s", doc)
+	}
+}
+
+func TestGenerateHandoffSummaryHandler_Validation(t *testing.T) {
+	handler := NewGenerateHandoffSummaryHandler(http.DefaultClient, testDBConfig("http://unused"), nil)
+	for _, since := range []string{"yesterday", time.Now().Add(-48 * time.Hour).Format(time.RFC3339), time.Now().Add(time.Hour).Format(time.RFC3339)} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GenerateHandoffSummaryArgs{SinceISO: since}); err == nil {
+			t.Errorf("since_iso %q: expected an error", since)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_service_health_score tool ---
+
+type GetServiceHealthScoreArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"Name of the service to score (required)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+// Health score components and their weights (out of 100). Components without
+// data are left out and the remaining weights are scaled back up to 100.
+const (
+	healthComponentErrors       = "error_rate"
+	healthComponentLatency      = "latency_vs_baseline"
+	healthComponentApdex        = "apdex"
+	healthComponentAlerts       = "alerts"
+	healthComponentDependencies = "dependencies"
+)
+
+var healthComponentWeights = map[string]float64{
+	healthComponentErrors:       30,
+	healthComponentLatency:      20,
+	healthComponentApdex:        25,
+	healthComponentAlerts:       15,
+	healthComponentDependencies: 10,
+}
+
+// healthComponentOrder fixes the order components are reported in.
+var healthComponentOrder = []string{
+	healthComponentErrors, healthComponentLatency, healthComponentApdex,
+	healthComponentAlerts, healthComponentDependencies,
+}
+
+const (
+	// healthErrorPercentCeiling is the error percentage that scores zero.
+	healthErrorPercentCeiling = 10.0
+	// healthLatencyRatioFloor and healthLatencyRatioCeiling bound the p95 to
+	// baseline ratio between full and zero score.
+	healthLatencyRatioFloor   = 1.1
+	healthLatencyRatioCeiling = 3.0
+	// healthBaselineOffset is how far back the latency baseline is taken.
+	healthBaselineOffset = "1d"
+	// healthAlertsMaxWindow is the longest window the alerts API accepts.
+	healthAlertsMaxWindow = 3600
+)
+
+// Health grades by total score.
+const (
+	healthGradeHealthy   = "healthy"   // >= 80
+	healthGradeDegraded  = "degraded"  // >= 50
+	healthGradeUnhealthy = "unhealthy" // < 50
+)
+
+// HealthComponent is one input to the health score. Score is 0-100 for the
+// component alone; Contribution is its share of the total after weighting.
+type HealthComponent struct {
+	Name         string   `json:"name"`
+	Available    bool     `json:"available"`
+	Value        *float64 `json:"value,omitempty"`
+	Score        float64  `json:"score"`
+	Weight       float64  `json:"weight"`
+	Contribution float64  `json:"contribution"`
+	Reason       string   `json:"reason"`
+}
+
+// ServiceHealthScore is the response of get_service_health_score.
+type ServiceHealthScore struct {
+	ServiceName string            `json:"service_name"`
+	Env         string            `json:"env"`
+	Score       float64           `json:"score"`
+	Grade       string            `json:"grade"`
+	Components  []HealthComponent `json:"components"`
+	Meta        *ResponseMeta     `json:"_meta,omitempty"`
+}
+
+// healthInputs are the raw measurements a score is computed from; nil means
+// no data.
+type healthInputs struct {
+	errorPercent      *float64
+	latencyP95        *float64
+	latencyBaseline   *float64
+	apdex             *float64
+	alerts            *alerting.ServiceAlertSummary
+	dependencyErrPerc *float64
+}
+
+func linearScore(value, good, bad float64) float64 {
+	switch {
+	case value <= good:
+		return 100
+	case value >= bad:
+		return 0
+	}
+	return 100 * (bad - value) / (bad - good)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// scoreHealth turns measurements into per-component scores and a weighted
+// total. It is pure so the scoring rules can be tested without a backend.
+func scoreHealth(in healthInputs) (float64, []HealthComponent) {
+	components := make(map[string]*HealthComponent, len(healthComponentOrder))
+	for _, name := range healthComponentOrder {
+		components[name] = &HealthComponent{Name: name}
+	}
+
+	if c := components[healthComponentErrors]; in.errorPercent != nil {
+		c.Available, c.Value = true, in.errorPercent
+		c.Score = linearScore(*in.errorPercent, 0, healthErrorPercentCeiling)
+		c.Reason = fmt.Sprintf("%.2f%% of server requests failed (0%% scores 100, %.0f%% or more scores 0)", *in.errorPercent, healthErrorPercentCeiling)
+	} else {
+		c.Reason = "no server span data in the window"
+	}
+
+	if c := components[healthComponentLatency]; in.latencyP95 != nil && in.latencyBaseline != nil && *in.latencyBaseline > 0 {
+		ratio := *in.latencyP95 / *in.latencyBaseline
+		c.Available, c.Value = true, &ratio
+		c.Score = linearScore(ratio, healthLatencyRatioFloor, healthLatencyRatioCeiling)
+		c.Reason = fmt.Sprintf("p95 latency is %.2fx the same window %s earlier (up to %.1fx scores 100, %.1fx or more scores 0)", ratio, healthBaselineOffset, healthLatencyRatioFloor, healthLatencyRatioCeiling)
+	} else if in.latencyP95 != nil {
+		c.Reason = fmt.Sprintf("no latency baseline from %s earlier to compare against", healthBaselineOffset)
+	} else {
+		c.Reason = "no latency data in the window"
+	}
+
+	if c := components[healthComponentApdex]; in.apdex != nil {
+		c.Available, c.Value = true, in.apdex
+		c.Score = math.Max(0, math.Min(100, *in.apdex*100))
+		c.Reason = fmt.Sprintf("apdex %.2f", *in.apdex)
+	} else {
+		c.Reason = "no apdex data in the window"
+	}
+
+	if c := components[healthComponentAlerts]; in.alerts != nil {
+		firing := float64(in.alerts.Firing)
+		c.Available, c.Value = true, &firing
+		switch {
+		case in.alerts.Breach > 0:
+			c.Score = 0
+			c.Reason = fmt.Sprintf("%d breach alert instance(s) firing: %s", in.alerts.Breach, strings.Join(in.alerts.Rules, ", "))
+		case in.alerts.Firing > 0:
+			c.Score = 50
+			c.Reason = fmt.Sprintf("%d threat alert instance(s) firing: %s", in.alerts.Firing, strings.Join(in.alerts.Rules, ", "))
+		default:
+			c.Score = 100
+			c.Reason = "no alerts firing for this service"
+		}
+	} else {
+		c.Reason = "alert state unavailable"
+	}
+
+	if c := components[healthComponentDependencies]; in.dependencyErrPerc != nil {
+		c.Available, c.Value = true, in.dependencyErrPerc
+		c.Score = linearScore(*in.dependencyErrPerc, 0, healthErrorPercentCeiling)
+		c.Reason = fmt.Sprintf("%.2f%% of outbound calls (databases, HTTP/gRPC clients, producers) failed", *in.dependencyErrPerc)
+	} else {
+		c.Reason = "no outbound calls in the window"
+	}
+
+	var totalWeight float64
+	for _, c := range components {
+		if c.Available {
+			totalWeight += healthComponentWeights[c.Name]
+		}
+	}
+
+	var score float64
+	out := make([]HealthComponent, 0, len(healthComponentOrder))
+	for _, name := range healthComponentOrder {
+		c := components[name]
+		if c.Available && totalWeight > 0 {
+			c.Weight = round1(healthComponentWeights[name] * 100 / totalWeight)
+			c.Contribution = round1(c.Score * healthComponentWeights[name] / totalWeight)
+			score += c.Score * healthComponentWeights[name] / totalWeight
+		}
+		c.Score = round1(c.Score)
+		out = append(out, *c)
+	}
+	return round1(score), out
+}
+
+func healthGrade(score float64) string {
+	switch {
+	case score >= 80:
+		return healthGradeHealthy
+	case score >= 50:
+		return healthGradeDegraded
+	}
+	return healthGradeUnhealthy
+}
+
+// promScalar returns the value of the first series, or nil when the query
+// returned no data.
+func promScalar(series apiPromInstantResp) *float64 {
+	if len(series) == 0 {
+		return nil
+	}
+	v := parsePromValue(series[0].Value)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+func NewGetServiceHealthScoreHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetServiceHealthScoreArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetServiceHealthScoreArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		durationMin := (endTime - startTime) / 60
+		if durationMin <= 0 {
+			durationMin = 1
+		}
+
+		env := resolveEnv(cfg, args.Env)
+
+		svc := fmt.Sprintf(`service_name="%s", env=~"%s"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+		serverSel := svc + `, span_kind="SPAN_KIND_SERVER"`
+		errorPercentQuery := func(metric, sel string) string {
+			return fmt.Sprintf(
+				`100 * (sum(sum_over_time(%[1]s{%[2]s, status_code="STATUS_CODE_ERROR"}[%[3]dm])) or vector(0)) / sum(sum_over_time(%[1]s{%[2]s}[%[3]dm]))`,
+				metric, sel, durationMin,
+			)
+		}
+		queries := map[string]string{
+			healthComponentErrors:       errorPercentQuery("trace_endpoint_count", serverSel),
+			healthComponentDependencies: errorPercentQuery("trace_client_count", svc),
+			"latency":                   fmt.Sprintf(`max(avg_over_time(trace_service_response_time{%s, quantile="p95"}[%dm]))`, svc, durationMin),
+			"latency_baseline":          fmt.Sprintf(`max(avg_over_time(trace_service_response_time{%s, quantile="p95"}[%dm] offset %s))`, svc, durationMin, healthBaselineOffset),
+			healthComponentApdex:        fmt.Sprintf(`avg(avg_over_time(trace_service_apdex_score{%s}[%dm]))`, svc, durationMin),
+		}
+
+		var (
+			mu       sync.Mutex
+			values   = make(map[string]*float64, len(queries))
+			failures []string
+			alerts   *alerting.ServiceAlertSummary
+			wg       sync.WaitGroup
+		)
+		for name, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s query failed: %v", name, err))
+					return
+				}
+				values[name] = promScalar(series)
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			window := min(endTime-startTime, healthAlertsMaxWindow)
+			summary, err := alerting.SummarizeServiceAlerts(ctx, client, cfg, args.ServiceName, env, endTime, max(window, 1))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("alerts lookup failed: %v", err))
+				return
+			}
+			alerts = &summary
+		}()
+		wg.Wait()
+		// Query failures are normally reported as missing components; a
+		// cancelled call must not be scored from whatever finished first.
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		score, components := scoreHealth(healthInputs{
+			errorPercent:      values[healthComponentErrors],
+			latencyP95:        values["latency"],
+			latencyBaseline:   values["latency_baseline"],
+			apdex:             values[healthComponentApdex],
+			alerts:            alerts,
+			dependencyErrPerc: values[healthComponentDependencies],
+		})
+
+		available := 0
+		for _, c := range components {
+			if c.Available {
+				available++
+			}
+		}
+		if available == 0 {
+			text := fmt.Sprintf("No data to score service %q (env=~%q) in the given time range. Check the service name and env with did_you_mean or get_service_summary.", args.ServiceName, env)
+			if suggestions, _ := suggestServices(ctx, client, cfg, args.ServiceName, env, startTime, endTime); len(suggestions) > 0 {
+				text += fmt.Sprintf(" Closest known services: %s.", strings.Join(suggestions, ", "))
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: text},
+				},
+			}, nil, nil
+		}
+
+		sort.Strings(failures)
+		caveats := failures
+		for _, c := range components {
+			if !c.Available {
+				caveats = append(caveats, fmt.Sprintf("%s left out of the score: %s", c.Name, c.Reason))
+			}
+		}
+
+		result := ServiceHealthScore{
+			ServiceName: args.ServiceName,
+			Env:         env,
+			Score:       score,
+			Grade:       healthGrade(score),
+			Components:  components,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf("trace_endpoint_count{%s}", serverSel),
+			), caveats...),
+		}
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,142 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/constants"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestScoreHealth(t *testing.T) {
+	score, components := scoreHealth(healthInputs{
+		errorPercent:      ptr(5),   // 50
+		latencyP95:        ptr(0.2), // ratio 1.0 -> 100
+		latencyBaseline:   ptr(0.2),
+		apdex:             ptr(0.9),                                            // 90
+		alerts:            &alerting.ServiceAlertSummary{Firing: 1, Threat: 1}, // 50
+		dependencyErrPerc: ptr(0),                                              // 100
+	})
+	// 0.30*50 + 0.20*100 + 0.25*90 + 0.15*50 + 0.10*100 = 75
+	if score != 75 {
+		t.Errorf("score = %v, want 75", score)
+	}
+	if healthGrade(score) != healthGradeDegraded {
+		t.Errorf("grade = %s, want degraded", healthGrade(score))
+	}
+	if len(components) != 5 || components[0].Name != healthComponentErrors {
+		t.Fatalf("components = %+v", components)
+	}
+	if c := components[0]; c.Score != 50 || c.Weight != 30 || c.Contribution != 15 {
+		t.Errorf("error component = %+v", c)
+	}
+}
+
+func TestScoreHealth_RenormalizesMissingComponents(t *testing.T) {
+	score, components := scoreHealth(healthInputs{
+		errorPercent: ptr(0),
+		apdex:        ptr(0.5),
+		latencyP95:   ptr(0.3), // no baseline
+	})
+	// errors (30) and apdex (25) only: (30*100 + 25*50) / 55 = 77.27
+	if score != 77.3 {
+		t.Errorf("score = %v, want 77.3", score)
+	}
+	for _, c := range components {
+		if c.Name == healthComponentLatency {
+			if c.Available || !strings.Contains(c.Reason, "baseline") {
+				t.Errorf("latency component = %+v, want unavailable for lack of baseline", c)
+			}
+		}
+	}
+}
+
+func TestScoreHealth_BreachAlertZeroesComponent(t *testing.T) {
+	_, components := scoreHealth(healthInputs{
+		alerts: &alerting.ServiceAlertSummary{Firing: 2, Breach: 1, Rules: []string{"error rate"}},
+	})
+	c := components[3]
+	if c.Name != healthComponentAlerts || c.Score != 0 || !strings.Contains(c.Reason, "error rate") {
+		t.Errorf("alerts component = %+v", c)
+	}
+}
+
+func TestGetServiceHealthScoreHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == constants.EndpointAlertsMonitor {
+			json.NewEncoder(w).Encode(alerting.AlertsResponse{})
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		var response []map[string]any
+		value := func(v string) []map[string]any {
+			return []map[string]any{{"metric": map[string]string{}, "value": []any{1700000000, v}}}
+		}
+		switch {
+		case strings.Contains(body.Query, "timestamp("):
+			response = value("1700000000")
+		case strings.Contains(body.Query, "trace_endpoint_count"):
+			response = value("2")
+		case strings.Contains(body.Query, "trace_client_count"):
+			response = value("0")
+		case strings.Contains(body.Query, "offset 1d"):
+			response = value("0.1")
+		case strings.Contains(body.Query, "trace_service_response_time"):
+			response = value("0.1")
+		case strings.Contains(body.Query, "trace_service_apdex_score"):
+			response = value("0.96")
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	handler := NewGetServiceHealthScoreHandler(server.Client(), testDBConfig(server.URL))
+	now := time.Now().UTC()
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetServiceHealthScoreArgs{
+		ServiceName:  "api",
+		Env:          "prod",
+		StartTimeISO: now.Add(-60 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var response ServiceHealthScore
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	// errors 2% -> 80, latency 1x -> 100, apdex 96, alerts 100, deps 100
+	// 0.3*80 + 0.2*100 + 0.25*96 + 0.15*100 + 0.1*100 = 93
+	if response.Score != 93 || response.Grade != healthGradeHealthy {
+		t.Errorf("score = %v (%s), want 93 (healthy); components: %+v", response.Score, response.Grade, response.Components)
+	}
+	for _, c := range response.Components {
+		if !c.Available {
+			t.Errorf("component %s unavailable: %s", c.Name, c.Reason)
+		}
+	}
+	if response.Meta == nil {
+		t.Error("expected _meta")
+	}
+}
+
+func TestGetServiceHealthScoreHandler_RequiresServiceName(t *testing.T) {
+	handler := NewGetServiceHealthScoreHandler(http.DefaultClient, testDBConfig("http://unused"))
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetServiceHealthScoreArgs{}); err == nil {
+		t.Fatal("expected error when service_name is missing")
+	}
+}
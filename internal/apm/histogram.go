@@ -0,0 +1,491 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	histogramClassic = "classic"
+	histogramNative  = "native"
+
+	// maxHistogramGroups caps the groups returned when by is set.
+	maxHistogramGroups = 50
+	// defaultHeatmapColumns is the target number of heatmap columns when no
+	// interval is given.
+	defaultHeatmapColumns = 30
+)
+
+var (
+	defaultHistogramQuantiles = []float64{0.5, 0.9, 0.95, 0.99}
+	promMetricNamePattern     = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	promLabelNamePattern      = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// GetLatencyDistributionArgs represents the input arguments for the get_latency_distribution tool
+type GetLatencyDistributionArgs struct {
+	Metric                 string    `json:"metric" jsonschema:"Histogram metric name, with or without the _bucket suffix (e.g. http_server_request_duration_seconds) (required)"`
+	Matchers               string    `json:"matchers,omitempty" jsonschema:"Label matchers without braces (e.g. service_name=\"api\",env=\"prod\")"`
+	By                     []string  `json:"by,omitempty" jsonschema:"Labels to split the distribution by (e.g. [service_name]); at most 50 groups are returned"`
+	Quantiles              []float64 `json:"quantiles,omitempty" jsonschema:"Quantiles to compute between 0 and 1 (default: [0.5, 0.9, 0.95, 0.99])"`
+	StartTimeISO           string    `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO             string    `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes        float64   `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1)"`
+	Datasource             string    `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+	Heatmap                bool      `json:"heatmap,omitempty" jsonschema:"Also return per-interval bucket counts over the window, for a heatmap (classic histograms only)"`
+	HeatmapIntervalMinutes int       `json:"heatmap_interval_minutes,omitempty" jsonschema:"Heatmap column width in minutes (default: window / 30, minimum: 1)"`
+}
+
+// HistogramBucket is one bucket of a merged classic histogram. Count is the
+// number of observations in (previous le, le].
+type HistogramBucket struct {
+	LE         string  `json:"le"`
+	Count      float64 `json:"count"`
+	Cumulative float64 `json:"cumulative"`
+}
+
+// HistogramQuantile is a quantile recomputed from the buckets.
+type HistogramQuantile struct {
+	Quantile float64  `json:"quantile"`
+	Value    *float64 `json:"value"`
+}
+
+// HistogramGroup is the distribution for one combination of the by labels.
+type HistogramGroup struct {
+	Labels    map[string]string   `json:"labels,omitempty"`
+	Count     float64             `json:"count"`
+	Mean      *float64            `json:"mean,omitempty"`
+	Quantiles []HistogramQuantile `json:"quantiles"`
+	Buckets   []HistogramBucket   `json:"buckets,omitempty"`
+}
+
+// HistogramHeatmap holds per-interval bucket counts. Counts[i][j] is the
+// number of observations in Buckets[j] during the interval ending at
+// Timestamps[i]; null where there was no data.
+type HistogramHeatmap struct {
+	IntervalMinutes int          `json:"interval_minutes"`
+	Buckets         []string     `json:"buckets"`
+	Timestamps      []int64      `json:"timestamps"`
+	Counts          [][]*float64 `json:"counts"`
+}
+
+// LatencyDistribution is the get_latency_distribution response.
+type LatencyDistribution struct {
+	Metric        string            `json:"metric"`
+	HistogramType string            `json:"histogram_type"`
+	StartTime     int64             `json:"start_time"`
+	EndTime       int64             `json:"end_time"`
+	Groups        []HistogramGroup  `json:"groups"`
+	OmittedGroups int               `json:"omitted_groups,omitempty"`
+	Heatmap       *HistogramHeatmap `json:"heatmap,omitempty"`
+	Caveats       []string          `json:"caveats,omitempty"`
+}
+
+// NewGetLatencyDistributionHandler merges the buckets of a Prometheus
+// histogram over the window and recomputes quantiles from them. Classic (le
+// bucket) histograms are tried first; when the metric has no _bucket series
+// it is queried as a native histogram, for which PromQL computes the
+// quantiles and no bucket layout is returned.
+func NewGetLatencyDistributionHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetLatencyDistributionArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetLatencyDistributionArgs) (*mcp.CallToolResult, any, error) {
+		metric := strings.TrimSuffix(args.Metric, "_bucket")
+		if metric == "" {
+			return nil, nil, fmt.Errorf("metric is required")
+		}
+		if !promMetricNamePattern.MatchString(metric) {
+			return nil, nil, fmt.Errorf("invalid metric name %q", args.Metric)
+		}
+		if strings.ContainsAny(args.Matchers, "{}") {
+			return nil, nil, fmt.Errorf("matchers must not include braces, e.g. service_name=\"api\",env=\"prod\"")
+		}
+		for _, label := range args.By {
+			if !promLabelNamePattern.MatchString(label) || label == "le" {
+				return nil, nil, fmt.Errorf("invalid by label %q", label)
+			}
+		}
+		quantiles := args.Quantiles
+		if len(quantiles) == 0 {
+			quantiles = defaultHistogramQuantiles
+		}
+		for _, q := range quantiles {
+			if q < 0 || q > 1 || math.IsNaN(q) {
+				return nil, nil, fmt.Errorf("quantiles must be between 0 and 1, got %v", q)
+			}
+		}
+		if args.HeatmapIntervalMinutes < 0 {
+			return nil, nil, fmt.Errorf("heatmap_interval_minutes must be positive")
+		}
+
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		queryCfg, err := resolveDatasourceCfg(cfg, args.Datasource)
+		if err != nil {
+			return nil, nil, err
+		}
+		windowMinutes := int(math.Ceil(float64(endTime-startTime) / 60))
+		if windowMinutes < 1 {
+			windowMinutes = 1
+		}
+		selector := "{" + args.Matchers + "}"
+		by := strings.Join(args.By, ", ")
+
+		out := LatencyDistribution{
+			Metric:        metric,
+			HistogramType: histogramClassic,
+			StartTime:     startTime,
+			EndTime:       endTime,
+		}
+
+		bucketSeries, err := fetchPromInstant(ctx, client, queryCfg, fmt.Sprintf(
+			`sum by (%s) (increase(%s_bucket%s[%dm]))`, strings.Join(append([]string{"le"}, args.By...), ", "), metric, selector, windowMinutes), endTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query histogram buckets: %w", err)
+		}
+
+		if len(bucketSeries) > 0 {
+			groups, fixed := mergeHistogramBuckets(bucketSeries, args.By, quantiles)
+			if fixed {
+				out.Caveats = append(out.Caveats, "Some cumulative bucket counts decreased with le (usually series with different bucket layouts or counter resets); they were raised to keep the histogram monotonic.")
+			}
+			sums, err := fetchPromInstant(ctx, client, queryCfg, fmt.Sprintf(
+				`sum by (%s) (increase(%s_sum%s[%dm]))`, by, metric, selector, windowMinutes), endTime)
+			if err != nil {
+				out.Caveats = append(out.Caveats, fmt.Sprintf("mean unavailable: %v", err))
+			} else {
+				applyHistogramMeans(groups, sums, args.By)
+			}
+			out.Groups = groups
+
+			if args.Heatmap {
+				interval := args.HeatmapIntervalMinutes
+				if interval == 0 {
+					interval = max(1, windowMinutes/defaultHeatmapColumns)
+				}
+				heatmap, err := fetchHistogramHeatmap(ctx, client, queryCfg, fmt.Sprintf(
+					`sum by (le) (increase(%s_bucket%s[%dm]))`, metric, selector, interval), startTime, endTime)
+				if err != nil {
+					out.Caveats = append(out.Caveats, fmt.Sprintf("heatmap unavailable: %v", err))
+				} else {
+					heatmap.IntervalMinutes = interval
+					out.Heatmap = heatmap
+				}
+			}
+		} else {
+			out.HistogramType = histogramNative
+			groups, err := fetchNativeHistogram(ctx, client, queryCfg, metric+selector, args.By, quantiles, windowMinutes, endTime)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(groups) == 0 {
+				return nil, nil, fmt.Errorf("no histogram data for %s%s in the window: neither %s_bucket nor a native histogram was found", metric, selector, metric)
+			}
+			out.Groups = groups
+			out.Caveats = append(out.Caveats, "Native histogram: quantiles and mean are computed by PromQL; bucket boundaries are not available through this API.")
+			if args.Heatmap {
+				out.Caveats = append(out.Caveats, "heatmap is only supported for classic histograms")
+			}
+		}
+
+		sort.SliceStable(out.Groups, func(i, j int) bool { return out.Groups[i].Count > out.Groups[j].Count })
+		if len(out.Groups) > maxHistogramGroups {
+			out.OmittedGroups = len(out.Groups) - maxHistogramGroups
+			out.Groups = out.Groups[:maxHistogramGroups]
+		}
+
+		data, err := json.Marshal(out)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// histogramGroupKey identifies a group by its by-label values.
+func histogramGroupKey(metric map[string]string, by []string) (string, map[string]string) {
+	if len(by) == 0 {
+		return "", nil
+	}
+	labels := make(map[string]string, len(by))
+	parts := make([]string, len(by))
+	for i, label := range by {
+		labels[label] = metric[label]
+		parts[i] = metric[label]
+	}
+	return strings.Join(parts, "\xff"), labels
+}
+
+type leCount struct {
+	le         float64
+	cumulative float64
+}
+
+// mergeHistogramBuckets turns cumulative per-le counts into per-group
+// histograms. It reports whether any cumulative count had to be raised to
+// keep the buckets monotonic, as histogram_quantile does.
+func mergeHistogramBuckets(series apiPromInstantResp, by []string, quantiles []float64) ([]HistogramGroup, bool) {
+	type acc struct {
+		labels  map[string]string
+		buckets map[float64]float64
+	}
+	groups := map[string]*acc{}
+	var order []string
+	for _, s := range series {
+		le, err := strconv.ParseFloat(s.Metric["le"], 64)
+		if err != nil {
+			continue
+		}
+		v := parsePromValue(s.Value)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		key, labels := histogramGroupKey(s.Metric, by)
+		g, ok := groups[key]
+		if !ok {
+			g = &acc{labels: labels, buckets: map[float64]float64{}}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.buckets[le] += v
+	}
+
+	fixed := false
+	out := make([]HistogramGroup, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		buckets := make([]leCount, 0, len(g.buckets))
+		for le, c := range g.buckets {
+			buckets = append(buckets, leCount{le: le, cumulative: c})
+		}
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i].le < buckets[j].le })
+		for i := 1; i < len(buckets); i++ {
+			if buckets[i].cumulative < buckets[i-1].cumulative {
+				buckets[i].cumulative = buckets[i-1].cumulative
+				fixed = true
+			}
+		}
+
+		group := HistogramGroup{Labels: g.labels, Quantiles: make([]HistogramQuantile, 0, len(quantiles))}
+		prev := 0.0
+		for _, b := range buckets {
+			group.Buckets = append(group.Buckets, HistogramBucket{
+				LE:         formatLE(b.le),
+				Count:      b.cumulative - prev,
+				Cumulative: b.cumulative,
+			})
+			prev = b.cumulative
+		}
+		if n := len(buckets); n > 0 {
+			group.Count = buckets[n-1].cumulative
+		}
+		for _, q := range quantiles {
+			group.Quantiles = append(group.Quantiles, HistogramQuantile{Quantile: q, Value: bucketQuantile(q, buckets)})
+		}
+		out = append(out, group)
+	}
+	return out, fixed
+}
+
+// bucketQuantile estimates the q-quantile from sorted, monotonic cumulative
+// buckets with linear interpolation inside the target bucket, following
+// PromQL's histogram_quantile. It returns nil when the histogram is empty or
+// has no +Inf bucket.
+func bucketQuantile(q float64, buckets []leCount) *float64 {
+	n := len(buckets)
+	if n < 2 || !math.IsInf(buckets[n-1].le, 1) {
+		return nil
+	}
+	total := buckets[n-1].cumulative
+	if total == 0 {
+		return nil
+	}
+	rank := q * total
+	i := sort.Search(n, func(i int) bool { return buckets[i].cumulative >= rank })
+	var v float64
+	switch {
+	case i == n-1:
+		// The quantile falls in the +Inf bucket: report the highest finite
+		// bound, as histogram_quantile does.
+		v = buckets[n-2].le
+	case i == 0 && buckets[0].le <= 0:
+		v = buckets[0].le
+	default:
+		lower, below := 0.0, 0.0
+		if i > 0 {
+			lower, below = buckets[i-1].le, buckets[i-1].cumulative
+		}
+		upper, inBucket := buckets[i].le, buckets[i].cumulative-below
+		v = upper
+		if inBucket > 0 {
+			v = lower + (upper-lower)*(rank-below)/inBucket
+		}
+	}
+	return &v
+}
+
+// applyHistogramMeans sets Mean from the per-group _sum increase.
+func applyHistogramMeans(groups []HistogramGroup, sums apiPromInstantResp, by []string) {
+	byKey := make(map[string]float64, len(sums))
+	for _, s := range sums {
+		key, _ := histogramGroupKey(s.Metric, by)
+		byKey[key] = parsePromValue(s.Value)
+	}
+	for i := range groups {
+		key, _ := histogramGroupKey(groups[i].Labels, by)
+		sum, ok := byKey[key]
+		if !ok || groups[i].Count == 0 || math.IsNaN(sum) {
+			continue
+		}
+		mean := sum / groups[i].Count
+		groups[i].Mean = &mean
+	}
+}
+
+// fetchNativeHistogram queries count, sum and each quantile of a native
+// histogram with PromQL's histogram functions, in parallel.
+func fetchNativeHistogram(ctx context.Context, client utils.HTTPClient, cfg models.Config, selector string, by []string, quantiles []float64, windowMinutes int, endTime int64) ([]HistogramGroup, error) {
+	merged := fmt.Sprintf(`sum by (%s) (increase(%s[%dm]))`, strings.Join(by, ", "), selector, windowMinutes)
+	queries := []string{
+		fmt.Sprintf(`histogram_count(%s)`, merged),
+		fmt.Sprintf(`histogram_sum(%s)`, merged),
+	}
+	for _, q := range quantiles {
+		queries = append(queries, fmt.Sprintf(`histogram_quantile(%g, %s)`, q, merged))
+	}
+	results := make([]apiPromInstantResp, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = fetchPromInstant(ctx, client, cfg, query, endTime)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to query native histogram: %w", err)
+		}
+	}
+
+	groups := map[string]*HistogramGroup{}
+	var order []string
+	for _, s := range results[0] {
+		key, labels := histogramGroupKey(s.Metric, by)
+		groups[key] = &HistogramGroup{Labels: labels, Count: parsePromValue(s.Value), Quantiles: make([]HistogramQuantile, len(quantiles))}
+		for i, q := range quantiles {
+			groups[key].Quantiles[i] = HistogramQuantile{Quantile: q}
+		}
+		order = append(order, key)
+	}
+	for _, s := range results[1] {
+		key, _ := histogramGroupKey(s.Metric, by)
+		if g, ok := groups[key]; ok && g.Count > 0 {
+			mean := parsePromValue(s.Value) / g.Count
+			g.Mean = &mean
+		}
+	}
+	for i := range quantiles {
+		for _, s := range results[2+i] {
+			key, _ := histogramGroupKey(s.Metric, by)
+			if g, ok := groups[key]; ok {
+				g.Quantiles[i].Value = promScalar(apiPromInstantResp{s})
+			}
+		}
+	}
+
+	out := make([]HistogramGroup, 0, len(order))
+	for _, key := range order {
+		out = append(out, *groups[key])
+	}
+	return out, nil
+}
+
+func formatLE(le float64) string {
+	if math.IsInf(le, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
+
+// fetchHistogramHeatmap runs a range query of per-le increases and converts
+// each timestamp's cumulative counts into per-bucket counts.
+func fetchHistogramHeatmap(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, startTime, endTime int64) (*HistogramHeatmap, error) {
+	resp, err := utils.MakePromRangeAPIQuery(ctx, client, query, startTime, endTime, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("range query failed with status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	data, err := decodeCompactRange(body)
+	if err != nil {
+		return nil, err
+	}
+	return buildHistogramHeatmap(data), nil
+}
+
+// buildHistogramHeatmap orders the le series and differences adjacent
+// cumulative counts at every timestamp.
+func buildHistogramHeatmap(data CompactRangeResult) *HistogramHeatmap {
+	type leSeries struct {
+		le     float64
+		values []*float64
+	}
+	var series []leSeries
+	for _, s := range data.Series {
+		le, err := strconv.ParseFloat(s.Metric["le"], 64)
+		if err != nil {
+			continue
+		}
+		series = append(series, leSeries{le: le, values: s.Values})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].le < series[j].le })
+
+	heatmap := &HistogramHeatmap{
+		Buckets:    make([]string, len(series)),
+		Timestamps: data.Timestamps,
+		Counts:     make([][]*float64, len(data.Timestamps)),
+	}
+	for j, s := range series {
+		heatmap.Buckets[j] = formatLE(s.le)
+	}
+	for i := range data.Timestamps {
+		row := make([]*float64, len(series))
+		prev := 0.0
+		for j, s := range series {
+			if s.values[i] == nil {
+				continue
+			}
+			cumulative := math.Max(*s.values[i], prev)
+			count := cumulative - prev
+			row[j] = &count
+			prev = cumulative
+		}
+		heatmap.Counts[i] = row
+	}
+	return heatmap
+}
@@ -0,0 +1,219 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// bucketSeries builds an instant response of cumulative bucket counts.
+func bucketSeries(labels map[string]string, counts map[string]string) apiPromInstantResp {
+	var out apiPromInstantResp
+	for le, v := range counts {
+		metric := map[string]string{"le": le}
+		for k, val := range labels {
+			metric[k] = val
+		}
+		out = append(out, struct {
+			Metric map[string]string `json:"metric"`
+			Value  []any             `json:"value"`
+		}{Metric: metric, Value: []any{1700000000.0, v}})
+	}
+	return out
+}
+
+func TestBucketQuantile(t *testing.T) {
+	buckets := []leCount{{0.1, 50}, {0.5, 90}, {1, 100}, {math.Inf(1), 100}}
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{0.5, 0.1},   // exactly at the first bound
+		{0.25, 0.05}, // interpolated from 0 in the first bucket
+		{0.7, 0.3},   // halfway through (0.1, 0.5]
+		{0.95, 0.75}, // halfway through (0.5, 1]
+	}
+	for _, tt := range tests {
+		got := bucketQuantile(tt.q, buckets)
+		if got == nil || math.Abs(*got-tt.want) > 1e-9 {
+			t.Errorf("q=%v: got %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	overflow := []leCount{{1, 10}, {math.Inf(1), 100}}
+	if got := bucketQuantile(0.99, overflow); got == nil || *got != 1 {
+		t.Errorf("quantile in +Inf bucket should report the highest finite bound, got %v", got)
+	}
+	if got := bucketQuantile(0.5, []leCount{{1, 0}, {math.Inf(1), 0}}); got != nil {
+		t.Errorf("empty histogram should have no quantile, got %v", *got)
+	}
+	if got := bucketQuantile(0.5, []leCount{{1, 5}, {2, 10}}); got != nil {
+		t.Errorf("histogram without +Inf should have no quantile, got %v", *got)
+	}
+}
+
+func TestMergeHistogramBuckets(t *testing.T) {
+	series := append(
+		bucketSeries(map[string]string{"service": "api"}, map[string]string{"0.1": "40", "0.5": "38", "+Inf": "50"}),
+		bucketSeries(map[string]string{"service": "web"}, map[string]string{"1": "5", "+Inf": "5"})...,
+	)
+	groups, fixed := mergeHistogramBuckets(series, []string{"service"}, []float64{0.5})
+	if !fixed {
+		t.Error("decreasing cumulative count should be reported as fixed")
+	}
+	if len(groups) != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	var api HistogramGroup
+	for _, g := range groups {
+		if g.Labels["service"] == "api" {
+			api = g
+		}
+	}
+	if api.Count != 50 || len(api.Buckets) != 3 {
+		t.Fatalf("api group = %+v", api)
+	}
+	if b := api.Buckets[1]; b.LE != "0.5" || b.Count != 0 || b.Cumulative != 40 {
+		t.Errorf("fixed bucket = %+v", b)
+	}
+	if b := api.Buckets[2]; b.LE != "+Inf" || b.Count != 10 {
+		t.Errorf("+Inf bucket = %+v", b)
+	}
+}
+
+func TestBuildHistogramHeatmap(t *testing.T) {
+	heatmap := buildHistogramHeatmap(CompactRangeResult{
+		Timestamps: []int64{100, 200},
+		Series: []CompactSeries{
+			{Metric: map[string]string{"le": "+Inf"}, Values: []*float64{ptr(10), ptr(4)}},
+			{Metric: map[string]string{"le": "0.5"}, Values: []*float64{ptr(7), nil}},
+		},
+	})
+	if strings.Join(heatmap.Buckets, ",") != "0.5,+Inf" {
+		t.Fatalf("buckets = %v", heatmap.Buckets)
+	}
+	if *heatmap.Counts[0][0] != 7 || *heatmap.Counts[0][1] != 3 {
+		t.Errorf("row 0 = %v, %v", *heatmap.Counts[0][0], *heatmap.Counts[0][1])
+	}
+	if heatmap.Counts[1][0] != nil || *heatmap.Counts[1][1] != 4 {
+		t.Errorf("row 1 should have a gap then 4")
+	}
+}
+
+func TestLatencyDistributionHandler_Classic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/prom_query"):
+			json.NewEncoder(w).Encode([]map[string]any{
+				{"metric": map[string]string{"le": "0.5"}, "values": [][]any{{1700000000, "3"}}},
+				{"metric": map[string]string{"le": "+Inf"}, "values": [][]any{{1700000000, "4"}}},
+			})
+		case strings.Contains(body.Query, "_bucket"):
+			if !strings.Contains(body.Query, `http_duration_seconds_bucket{env="prod"}`) {
+				t.Errorf("unexpected bucket query %s", body.Query)
+			}
+			json.NewEncoder(w).Encode(bucketSeries(nil, map[string]string{"0.5": "80", "1": "100", "+Inf": "100"}))
+		case strings.Contains(body.Query, "_sum"):
+			json.NewEncoder(w).Encode([]map[string]any{{"metric": map[string]string{}, "value": []any{1700000000, "45"}}})
+		default:
+			t.Errorf("unexpected query %s", body.Query)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewGetLatencyDistributionHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetLatencyDistributionArgs{
+		Metric:    "http_duration_seconds_bucket",
+		Matchers:  `env="prod"`,
+		Quantiles: []float64{0.9},
+		Heatmap:   true,
+	})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var got LatencyDistribution
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.HistogramType != histogramClassic || len(got.Groups) != 1 {
+		t.Fatalf("response = %+v", got)
+	}
+	g := got.Groups[0]
+	if g.Count != 100 || *g.Mean != 0.45 || *g.Quantiles[0].Value != 0.75 {
+		t.Errorf("group = %+v (mean %v, p90 %v)", g, *g.Mean, *g.Quantiles[0].Value)
+	}
+	if got.Heatmap == nil || got.Heatmap.IntervalMinutes != 2 || len(got.Heatmap.Counts) != 1 {
+		t.Errorf("heatmap = %+v", got.Heatmap)
+	}
+}
+
+func TestLatencyDistributionHandler_NativeFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		value := func(v string) []map[string]any {
+			return []map[string]any{{"metric": map[string]string{"service": "api"}, "value": []any{1700000000, v}}}
+		}
+		switch {
+		case strings.Contains(body.Query, "_bucket"):
+			w.Write([]byte(`[]`))
+		case strings.HasPrefix(body.Query, "histogram_count"):
+			json.NewEncoder(w).Encode(value("200"))
+		case strings.HasPrefix(body.Query, "histogram_sum"):
+			json.NewEncoder(w).Encode(value("50"))
+		case strings.HasPrefix(body.Query, "histogram_quantile(0.99"):
+			json.NewEncoder(w).Encode(value("1.2"))
+		default:
+			t.Errorf("unexpected query %s", body.Query)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewGetLatencyDistributionHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetLatencyDistributionArgs{
+		Metric:    "rpc_duration",
+		By:        []string{"service"},
+		Quantiles: []float64{0.99},
+	})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var got LatencyDistribution
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.HistogramType != histogramNative || len(got.Groups) != 1 {
+		t.Fatalf("response = %+v", got)
+	}
+	g := got.Groups[0]
+	if g.Labels["service"] != "api" || g.Count != 200 || *g.Mean != 0.25 || *g.Quantiles[0].Value != 1.2 {
+		t.Errorf("group = %+v", g)
+	}
+}
+
+func TestLatencyDistributionHandler_Validation(t *testing.T) {
+	handler := NewGetLatencyDistributionHandler(http.DefaultClient, testDBConfig("http://unused"))
+	for _, args := range []GetLatencyDistributionArgs{
+		{},
+		{Metric: "bad-name"},
+		{Metric: "m", Matchers: `{env="prod"}`},
+		{Metric: "m", By: []string{"le"}},
+		{Metric: "m", Quantiles: []float64{95}},
+	} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("expected error for %+v", args)
+		}
+	}
+}
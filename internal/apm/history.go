@@ -0,0 +1,322 @@
+package apm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_service_history tool ---
+
+const (
+	historyDefaultDays = 30
+	historyMaxDays     = 90
+	// historyDefaultObjective is the availability SLO, in percent, the error
+	// budget is measured against when the call does not give one.
+	historyDefaultObjective = 99.9
+	// historyParallelDays bounds how many missing days are queried at once.
+	historyParallelDays = 4
+	// rollupSchemaVersion is part of every rollup key, so a change to how
+	// rollups are computed does not serve rollups stored by older versions.
+	rollupSchemaVersion = 1
+	// rollupIngestionGrace is how long after a day ends its rollup is still
+	// queried rather than stored, so late-arriving spans are not cut off
+	// for good.
+	rollupIngestionGrace = 6 * time.Hour
+)
+
+// historyNow is the clock get_service_history reports against; tests
+// replace it.
+var historyNow = time.Now
+
+type GetServiceHistoryArgs struct {
+	ServiceName string  `json:"service_name" jsonschema:"(Required) Name of the service to report daily history for (e.g. checkout)"`
+	Env         string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View        string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	Days        int     `json:"days,omitempty" jsonschema:"Number of completed UTC days to report, ending yesterday (default: 30, maximum: 90)"`
+	Objective   float64 `json:"objective,omitempty" jsonschema:"Availability SLO in percent the error budget is measured against (default: 99.9)"`
+}
+
+// DailyRollup is one completed UTC day of a service's server spans.
+// Availability and P95LatencyMs are nil for days without traffic.
+type DailyRollup struct {
+	Date         string   `json:"date"` // YYYY-MM-DD, UTC
+	Requests     float64  `json:"requests"`
+	Errors       float64  `json:"errors"`
+	Availability *float64 `json:"availability_percent"`
+	P95LatencyMs *float64 `json:"p95_latency_ms"`
+	// ErrorBudgetConsumed is the share of the day's error budget spent, in
+	// percent; over 100 means the day alone missed the objective.
+	ErrorBudgetConsumed *float64 `json:"error_budget_consumed_percent"`
+}
+
+// rollupRecord is what the rollup store keeps per service, env and day. The
+// error budget depends on the objective, so it is derived at read time.
+type rollupRecord struct {
+	Requests     float64  `json:"requests"`
+	Errors       float64  `json:"errors"`
+	P95LatencyMs *float64 `json:"p95_latency_ms"`
+}
+
+// HistoryPeriod aggregates the days of one calendar month.
+type HistoryPeriod struct {
+	Month                string   `json:"month"` // YYYY-MM
+	Days                 int      `json:"days"`
+	Requests             float64  `json:"requests"`
+	Availability         *float64 `json:"availability_percent"`
+	AvgP95LatencyMs      *float64 `json:"avg_p95_latency_ms"`
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining_percent"`
+}
+
+// rollupStore returns the store daily rollups are materialized in, or nil
+// when the on-disk cache is disabled. Days past the ingestion grace period
+// no longer change, so entries do not expire.
+func rollupStore(cfg models.Config) *diskcache.Store {
+	if cfg.CacheDir == "" {
+		return nil
+	}
+	return diskcache.New(filepath.Join(cfg.CacheDir, "rollups"))
+}
+
+// rollupKey hashes the service, env and day. The store maps characters
+// outside [A-Za-z0-9._-] in keys to "_", so names used directly could
+// collide and serve another service's rollup.
+func rollupKey(service, env, date string) string {
+	sum := sha256.Sum256([]byte(service + "\x00" + env + "\x00" + date))
+	return fmt.Sprintf("v%d-%s", rollupSchemaVersion, hex.EncodeToString(sum[:]))
+}
+
+// storableRollup reports whether a day's rollup is final: the day ended more
+// than the ingestion grace period ago and had traffic. Empty days are
+// queried again, as a misspelled service or delayed ingestion looks the same
+// as no traffic.
+func storableRollup(r rollupRecord, dayEnd, now time.Time) bool {
+	return r.Requests > 0 && now.Sub(dayEnd) >= rollupIngestionGrace
+}
+
+// fetchDailyRollup queries one UTC day ending at end.
+func fetchDailyRollup(ctx context.Context, client utils.HTTPClient, cfg models.Config, service, env string, end int64) (rollupRecord, error) {
+	svc := fmt.Sprintf(`service_name="%s", env=~"%s"`, escapePromQLLabel(service), escapePromQLLabel(env))
+	serverSel := svc + `, span_kind="SPAN_KIND_SERVER"`
+	queries := []string{
+		fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%s}[1d]))`, serverSel),
+		fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%s, status_code="STATUS_CODE_ERROR"}[1d]))`, serverSel),
+		fmt.Sprintf(`max(avg_over_time(trace_service_response_time{%s, quantile="p95"}[1d]))`, svc),
+	}
+	values := make([]*float64, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			series, err := fetchPromInstant(ctx, client, cfg, query, end)
+			values[i], errs[i] = promScalar(series), err
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return rollupRecord{}, err
+		}
+	}
+	var r rollupRecord
+	if values[0] != nil {
+		r.Requests = math.Round(*values[0])
+	}
+	if values[1] != nil {
+		r.Errors = math.Round(*values[1])
+	}
+	if r.Requests > 0 {
+		r.P95LatencyMs = values[2]
+	}
+	return r, nil
+}
+
+// dailyRollup derives a day's availability and error budget from its record.
+func dailyRollup(date string, r rollupRecord, objective float64) DailyRollup {
+	d := DailyRollup{Date: date, Requests: r.Requests, Errors: r.Errors, P95LatencyMs: r.P95LatencyMs}
+	if r.Requests > 0 {
+		availability := round3(100 * (1 - r.Errors/r.Requests))
+		consumed := round3(100 * (r.Errors / r.Requests) / (1 - objective/100))
+		d.Availability, d.ErrorBudgetConsumed = &availability, &consumed
+	}
+	return d
+}
+
+// historyPeriods groups days by calendar month. Availability and the error
+// budget are request-weighted, so quiet days count for less.
+func historyPeriods(days []DailyRollup, objective float64) []HistoryPeriod {
+	var (
+		periods   []HistoryPeriod
+		errors    float64
+		p95Sum    float64
+		p95Count  int
+		flushLast = func() {
+			p := &periods[len(periods)-1]
+			if p.Requests > 0 {
+				availability := round3(100 * (1 - errors/p.Requests))
+				remaining := round3(100 - 100*(errors/p.Requests)/(1-objective/100))
+				p.Availability, p.ErrorBudgetRemaining = &availability, &remaining
+			}
+			if p95Count > 0 {
+				avg := round3(p95Sum / float64(p95Count))
+				p.AvgP95LatencyMs = &avg
+			}
+		}
+	)
+	for _, d := range days {
+		month := d.Date[:7]
+		if len(periods) == 0 || periods[len(periods)-1].Month != month {
+			if len(periods) > 0 {
+				flushLast()
+			}
+			periods = append(periods, HistoryPeriod{Month: month})
+			errors, p95Sum, p95Count = 0, 0, 0
+		}
+		p := &periods[len(periods)-1]
+		p.Days++
+		p.Requests += d.Requests
+		errors += d.Errors
+		if d.P95LatencyMs != nil {
+			p95Sum += *d.P95LatencyMs
+			p95Count++
+		}
+	}
+	if len(periods) > 0 {
+		flushLast()
+	}
+	return periods
+}
+
+func NewGetServiceHistoryHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetServiceHistoryArgs) (*mcp.CallToolResult, any, error) {
+	store := rollupStore(cfg)
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetServiceHistoryArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		days := args.Days
+		if days <= 0 {
+			days = historyDefaultDays
+		}
+		if days > historyMaxDays {
+			return nil, nil, fmt.Errorf("days must be at most %d, got %d", historyMaxDays, days)
+		}
+		objective := args.Objective
+		if objective == 0 {
+			objective = historyDefaultObjective
+		}
+		if objective <= 0 || objective >= 100 {
+			return nil, nil, fmt.Errorf("objective must be between 0 and 100 (exclusive), got %v", objective)
+		}
+		env := resolveEnv(cfg, args.Env)
+
+		// Only completed UTC days are reported.
+		now := historyNow()
+		today := now.UTC().Truncate(24 * time.Hour)
+		dates := make([]time.Time, days)
+		for i := range dates {
+			dates[i] = today.AddDate(0, 0, i-days)
+		}
+
+		records := make([]rollupRecord, days)
+		var missing []int
+		for i, date := range dates {
+			if _, ok := store.Get(rollupKey(args.ServiceName, env, date.Format(time.DateOnly)), 0, &records[i]); !ok {
+				missing = append(missing, i)
+			}
+		}
+
+		var (
+			mu       sync.Mutex
+			failures []string
+			wg       sync.WaitGroup
+			sem      = make(chan struct{}, historyParallelDays)
+		)
+		for _, i := range missing {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					return
+				}
+				date := dates[i].Format(time.DateOnly)
+				dayEnd := dates[i].AddDate(0, 0, 1)
+				record, err := fetchDailyRollup(ctx, client, cfg, args.ServiceName, env, dayEnd.Unix())
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %v", date, err))
+					return
+				}
+				records[i] = record
+				if !storableRollup(record, dayEnd, now) {
+					return
+				}
+				if err := store.Put(rollupKey(args.ServiceName, env, date), record, now); err != nil {
+					failures = append(failures, fmt.Sprintf("%s: rollup not stored: %v", date, err))
+				}
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if len(failures) == len(missing) && len(missing) > 0 {
+			return nil, nil, fmt.Errorf("failed to fetch daily history: %s", failures[0])
+		}
+
+		rollups := make([]DailyRollup, days)
+		for i, date := range dates {
+			rollups[i] = dailyRollup(date.Format(time.DateOnly), records[i], objective)
+		}
+
+		var caveats []string
+		if store == nil {
+			caveats = append(caveats, "rollup store disabled (no cache directory); every day was queried from the backend")
+		}
+		if len(failures) > 0 {
+			caveats = append(caveats, fmt.Sprintf("%d day(s) could not be fetched and are reported as empty: %v", len(failures), failures))
+		}
+		response := map[string]any{
+			"service_name":       args.ServiceName,
+			"env":                env,
+			"objective_percent":  objective,
+			"days":               rollups,
+			"months":             historyPeriods(rollups, objective),
+			"rollups_from_store": days - len(missing),
+			"rollups_queried":    len(missing),
+			"_meta":              buildResponseMeta(nil, caveats...),
+		}
+
+		jsonBytes, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(dates[0].UnixMilli(), today.UnixMilli(), args.ServiceName, env, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,180 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestDailyRollup(t *testing.T) {
+	p95 := 120.0
+	got := dailyRollup("2026-01-20", rollupRecord{Requests: 10000, Errors: 5, P95LatencyMs: &p95}, 99.9)
+	if got.Availability == nil || *got.Availability != 99.95 {
+		t.Errorf("availability = %v, want 99.95", got.Availability)
+	}
+	if got.ErrorBudgetConsumed == nil || *got.ErrorBudgetConsumed != 50 {
+		t.Errorf("error budget consumed = %v, want 50", got.ErrorBudgetConsumed)
+	}
+	if idle := dailyRollup("2026-01-21", rollupRecord{}, 99.9); idle.Availability != nil || idle.ErrorBudgetConsumed != nil {
+		t.Errorf("day without traffic = %+v, want nil availability and budget", idle)
+	}
+}
+
+func TestHistoryPeriods(t *testing.T) {
+	p95 := func(v float64) *float64 { return &v }
+	days := []DailyRollup{
+		dailyRollup("2026-01-30", rollupRecord{Requests: 1000, Errors: 1, P95LatencyMs: p95(100)}, 99),
+		dailyRollup("2026-01-31", rollupRecord{Requests: 3000, Errors: 3, P95LatencyMs: p95(200)}, 99),
+		dailyRollup("2026-02-01", rollupRecord{}, 99),
+	}
+	got := historyPeriods(days, 99)
+	if len(got) != 2 || got[0].Month != "2026-01" || got[1].Month != "2026-02" {
+		t.Fatalf("periods = %+v, want January and February", got)
+	}
+	jan := got[0]
+	if jan.Days != 2 || jan.Requests != 4000 || *jan.Availability != 99.9 || *jan.ErrorBudgetRemaining != 90 || *jan.AvgP95LatencyMs != 150 {
+		t.Errorf("january = %+v", jan)
+	}
+	if feb := got[1]; feb.Days != 1 || feb.Availability != nil || feb.AvgP95LatencyMs != nil {
+		t.Errorf("february = %+v, want an empty month", feb)
+	}
+}
+
+func TestGetServiceHistoryHandler_StoresRollups(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		value := "1000"
+		switch {
+		case strings.Contains(body.Query, "STATUS_CODE_ERROR"):
+			value = "2"
+		case strings.Contains(body.Query, "trace_service_response_time"):
+			value = "85"
+		}
+		json.NewEncoder(w).Encode([]map[string]any{{"metric": map[string]string{}, "value": []any{1700000000, value}}})
+	}))
+	defer server.Close()
+
+	setClock(t, &historyNow, time.Date(2026, 5, 2, 12, 0, 0, 0, time.UTC))
+	cfg := testDBConfig(server.URL)
+	cfg.CacheDir = t.TempDir()
+	handler := NewGetServiceHistoryHandler(server.Client(), cfg)
+	call := func() (fromStore, queried int, days []DailyRollup) {
+		t.Helper()
+		result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetServiceHistoryArgs{ServiceName: "checkout", Days: 3})
+		if err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		var payload struct {
+			Days      []DailyRollup `json:"days"`
+			FromStore int           `json:"rollups_from_store"`
+			Queried   int           `json:"rollups_queried"`
+		}
+		if err := json.Unmarshal([]byte(utils.GetTextContent(t, result)), &payload); err != nil {
+			t.Fatalf("failed to decode tool response: %v", err)
+		}
+		return payload.FromStore, payload.Queried, payload.Days
+	}
+
+	fromStore, queried, days := call()
+	if fromStore != 0 || queried != 3 || len(days) != 3 {
+		t.Fatalf("first call: from store %d, queried %d, %d days; want 0, 3, 3", fromStore, queried, len(days))
+	}
+	if d := days[0]; d.Requests != 1000 || d.Errors != 2 || *d.Availability != 99.8 || *d.P95LatencyMs != 85 {
+		t.Errorf("day = %+v", d)
+	}
+	if days[0].Date >= days[2].Date {
+		t.Errorf("days should be oldest first: %s, %s", days[0].Date, days[2].Date)
+	}
+
+	before := calls.Load()
+	if fromStore, queried, _ = call(); fromStore != 3 || queried != 0 {
+		t.Errorf("second call: from store %d, queried %d; want 3, 0", fromStore, queried)
+	}
+	if calls.Load() != before {
+		t.Errorf("second call queried the backend %d times, want none", calls.Load()-before)
+	}
+}
+
+func TestGetServiceHistoryHandler_Validation(t *testing.T) {
+	handler := NewGetServiceHistoryHandler(http.DefaultClient, testDBConfig("http://unused"))
+	for name, args := range map[string]GetServiceHistoryArgs{
+		"missing service": {},
+		"too many days":   {ServiceName: "checkout", Days: historyMaxDays + 1},
+		"objective 100":   {ServiceName: "checkout", Objective: 100},
+	} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// setClock makes a handler clock such as historyNow return now for the rest
+// of the test.
+func setClock(t *testing.T, clock *func() time.Time, now time.Time) {
+	t.Helper()
+	previous := *clock
+	*clock = func() time.Time { return now }
+	t.Cleanup(func() { *clock = previous })
+}
+
+func TestRollupKey(t *testing.T) {
+	pairs := [][2][3]string{
+		{{"cart-api", "prod", "2026-05-01"}, {"cart", "api-prod", "2026-05-01"}},
+		{{"a/b", "prod", "2026-05-01"}, {"a_b", "prod", "2026-05-01"}},
+		{{"cart", "prod|canary", "2026-05-01"}, {"cart", "prod_canary", "2026-05-01"}},
+	}
+	for _, p := range pairs {
+		a, b := rollupKey(p[0][0], p[0][1], p[0][2]), rollupKey(p[1][0], p[1][1], p[1][2])
+		if a == b {
+			t.Errorf("rollupKey(%v) = rollupKey(%v) = %s", p[0], p[1], a)
+		}
+	}
+}
+
+func TestGetServiceHistoryHandler_StoresOnlyFinalDays(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string `json:"query"`
+			Timestamp int64  `json:"timestamp"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var series []map[string]any
+		// 2026-04-30, which ends at 1777593600, had no traffic; the other
+		// days did.
+		if body.Timestamp != 1777593600 && !strings.Contains(body.Query, "STATUS_CODE_ERROR") {
+			series = append(series, map[string]any{"metric": map[string]string{}, "value": []any{1700000000, "1000"}})
+		}
+		json.NewEncoder(w).Encode(series)
+	}))
+	defer server.Close()
+
+	// An hour into 2026-05-02: 2026-05-01 is inside the ingestion grace
+	// period.
+	setClock(t, &historyNow, time.Date(2026, 5, 2, 1, 0, 0, 0, time.UTC))
+	cfg := testDBConfig(server.URL)
+	cfg.CacheDir = t.TempDir()
+	handler := NewGetServiceHistoryHandler(server.Client(), cfg)
+	handler(context.Background(), &mcp.CallToolRequest{}, GetServiceHistoryArgs{ServiceName: "checkout", Days: 3})
+
+	store := rollupStore(cfg)
+	for date, want := range map[string]bool{"2026-04-29": true, "2026-04-30": false, "2026-05-01": false} {
+		var r rollupRecord
+		if _, ok := store.Get(rollupKey("checkout", resolveEnv(cfg, ""), date), 0, &r); ok != want {
+			t.Errorf("%s stored = %v, want %v", date, ok, want)
+		}
+	}
+}
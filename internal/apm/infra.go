@@ -0,0 +1,202 @@
+package apm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// infraPodLimit caps how many pods include_infra reports on, keeping the
+// container queries' pod regex and the response small.
+const infraPodLimit = 50
+
+// Saturation thresholds for ServiceInfra.Saturation.
+const (
+	infraThrottledPercent = 25.0 // share of CFS periods throttled
+	infraLimitPercent     = 90.0 // CPU or memory use relative to the limit
+)
+
+// ServiceInfra is the container resource usage of the Kubernetes pods that
+// served a service's spans in the window.
+type ServiceInfra struct {
+	Pods []PodInfra `json:"pods"`
+	// Saturation lists pods that were throttled, near a limit, OOM killed or
+	// restarted, so they can be read next to the latency series.
+	Saturation []string `json:"saturation"`
+}
+
+// PodInfra is one pod's container resource usage over the window. Limits
+// are zero when the pod's containers set none.
+type PodInfra struct {
+	Namespace           string  `json:"namespace"`
+	Pod                 string  `json:"pod"`
+	CPUCores            float64 `json:"cpu_cores"`
+	CPULimitCores       float64 `json:"cpu_limit_cores,omitempty"`
+	CPUThrottledPercent float64 `json:"cpu_throttled_percent"`
+	MemoryBytes         float64 `json:"memory_working_set_bytes"`
+	MemoryLimitBytes    float64 `json:"memory_limit_bytes,omitempty"`
+	OOMKills            int64   `json:"oom_kills"`
+	Restarts            int64   `json:"restarts"`
+}
+
+// infraQueries are the per-pod container queries, from cAdvisor and
+// kube-state-metrics. Each takes the pod selector as %[1]s and the window as
+// %[2]s, and is grouped by namespace and pod.
+var infraQueries = []struct {
+	name  string
+	query string
+	set   func(*PodInfra, float64)
+}{
+	{"cpu", `sum by (namespace, pod)(rate(container_cpu_usage_seconds_total{%[1]s, container!=""}[%[2]s]))`,
+		func(p *PodInfra, v float64) { p.CPUCores = v }},
+	{"cpu_limit", `sum by (namespace, pod)(kube_pod_container_resource_limits{%[1]s, resource="cpu"})`,
+		func(p *PodInfra, v float64) { p.CPULimitCores = v }},
+	{"cpu_throttling", `sum by (namespace, pod)(increase(container_cpu_cfs_throttled_periods_total{%[1]s, container!=""}[%[2]s])) / sum by (namespace, pod)(increase(container_cpu_cfs_periods_total{%[1]s, container!=""}[%[2]s])) * 100`,
+		func(p *PodInfra, v float64) { p.CPUThrottledPercent = v }},
+	{"memory", `sum by (namespace, pod)(max_over_time(container_memory_working_set_bytes{%[1]s, container!=""}[%[2]s]))`,
+		func(p *PodInfra, v float64) { p.MemoryBytes = v }},
+	{"memory_limit", `sum by (namespace, pod)(kube_pod_container_resource_limits{%[1]s, resource="memory"})`,
+		func(p *PodInfra, v float64) { p.MemoryLimitBytes = v }},
+	{"oom_kills", `sum by (namespace, pod)(increase(container_oom_events_total{%[1]s, container!=""}[%[2]s]))`,
+		func(p *PodInfra, v float64) { p.OOMKills = int64(math.Round(v)) }},
+	{"restarts", `sum by (namespace, pod)(increase(kube_pod_container_status_restarts_total{%[1]s}[%[2]s]))`,
+		func(p *PodInfra, v float64) { p.Restarts = int64(math.Round(v)) }},
+}
+
+// podKey identifies a pod across namespaces.
+type podKey struct{ namespace, pod string }
+
+// fetchServicePods returns the pods that emitted the service's server spans
+// in the window, from the k8s_namespace_name and k8s_pod_name resource
+// labels the collector's k8sattributes processor adds.
+func fetchServicePods(ctx context.Context, client utils.HTTPClient, cfg models.Config, serviceName, env, timeRange string, end int64) ([]podKey, error) {
+	query := fmt.Sprintf(
+		`sum by (k8s_namespace_name, k8s_pod_name)(sum_over_time(trace_endpoint_count{service_name='%s', env=~'%s', k8s_pod_name!=''}[%s]))`,
+		escapePromQLLabel(serviceName), env, timeRange,
+	)
+	series, err := fetchPromInstant(ctx, client, cfg, query, end)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]podKey, 0, len(series))
+	for _, s := range series {
+		if pod := s.Metric["k8s_pod_name"]; pod != "" {
+			pods = append(pods, podKey{s.Metric["k8s_namespace_name"], pod})
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].namespace != pods[j].namespace {
+			return pods[i].namespace < pods[j].namespace
+		}
+		return pods[i].pod < pods[j].pod
+	})
+	return pods, nil
+}
+
+// podSelector matches the container series of pods by namespace and pod.
+func podSelector(pods []podKey) string {
+	namespaces := map[string]bool{}
+	var nsPatterns, podPatterns []string
+	for _, p := range pods {
+		if !namespaces[p.namespace] {
+			namespaces[p.namespace] = true
+			nsPatterns = append(nsPatterns, regexp.QuoteMeta(p.namespace))
+		}
+		podPatterns = append(podPatterns, regexp.QuoteMeta(p.pod))
+	}
+	return fmt.Sprintf(`namespace=~"%s", pod=~"%s"`,
+		escapePromQLLabel(strings.Join(nsPatterns, "|")), escapePromQLLabel(strings.Join(podPatterns, "|")))
+}
+
+// podSaturation describes how a pod was saturated, or returns nil.
+func podSaturation(p PodInfra) []string {
+	var out []string
+	name := p.Pod
+	if p.Namespace != "" {
+		name = p.Namespace + "/" + p.Pod
+	}
+	if p.CPUThrottledPercent >= infraThrottledPercent {
+		out = append(out, fmt.Sprintf("%s: CPU throttled in %.0f%% of scheduling periods", name, p.CPUThrottledPercent))
+	}
+	if p.CPULimitCores > 0 && p.CPUCores/p.CPULimitCores*100 >= infraLimitPercent {
+		out = append(out, fmt.Sprintf("%s: CPU at %.0f%% of its %.2g-core limit", name, p.CPUCores/p.CPULimitCores*100, p.CPULimitCores))
+	}
+	if p.MemoryLimitBytes > 0 && p.MemoryBytes/p.MemoryLimitBytes*100 >= infraLimitPercent {
+		out = append(out, fmt.Sprintf("%s: memory peaked at %.0f%% of its limit", name, p.MemoryBytes/p.MemoryLimitBytes*100))
+	}
+	if p.OOMKills > 0 {
+		out = append(out, fmt.Sprintf("%s: %d OOM kill(s)", name, p.OOMKills))
+	}
+	if p.Restarts > 0 {
+		out = append(out, fmt.Sprintf("%s: %d container restart(s)", name, p.Restarts))
+	}
+	return out
+}
+
+// fetchServiceInfra joins container CPU and memory usage for the pods backing
+// a service. Failed sub-queries leave their fields zero and are returned as
+// caveats rather than failing the call.
+func fetchServiceInfra(ctx context.Context, client utils.HTTPClient, cfg models.Config, serviceName, env, timeRange string, end int64) (*ServiceInfra, []string) {
+	pods, err := fetchServicePods(ctx, client, cfg, serviceName, env, timeRange, end)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("infra unavailable: failed to find the service's pods: %v", err)}
+	}
+	infra := &ServiceInfra{Pods: []PodInfra{}, Saturation: []string{}}
+	if len(pods) == 0 {
+		return infra, []string{"infra: no k8s_pod_name label on the service's spans; add the k8sattributes processor to the OpenTelemetry collector to link spans to pods"}
+	}
+
+	var caveats []string
+	if len(pods) > infraPodLimit {
+		caveats = append(caveats, fmt.Sprintf("infra is limited to %d of the service's %d pods", infraPodLimit, len(pods)))
+		pods = pods[:infraPodLimit]
+	}
+
+	byPod := make(map[podKey]*PodInfra, len(pods))
+	for _, p := range pods {
+		byPod[p] = &PodInfra{Namespace: p.namespace, Pod: p.pod}
+	}
+
+	selector := podSelector(pods)
+	results := make([]apiPromInstantResp, len(infraQueries))
+	errs := make([]error, len(infraQueries))
+	var wg sync.WaitGroup
+	for i, q := range infraQueries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = fetchPromInstant(ctx, client, cfg, fmt.Sprintf(q.query, selector, timeRange), end)
+		}()
+	}
+	wg.Wait()
+
+	for i, q := range infraQueries {
+		if errs[i] != nil {
+			caveats = append(caveats, fmt.Sprintf("infra %s unavailable: %v", q.name, errs[i]))
+			continue
+		}
+		for _, s := range results[i] {
+			p, ok := byPod[podKey{s.Metric["namespace"], s.Metric["pod"]}]
+			if !ok {
+				continue
+			}
+			if v := parsePromValue(s.Value); !math.IsNaN(v) && !math.IsInf(v, 0) {
+				q.set(p, math.Round(v*1000)/1000)
+			}
+		}
+	}
+
+	for _, key := range pods {
+		p := *byPod[key]
+		infra.Pods = append(infra.Pods, p)
+		infra.Saturation = append(infra.Saturation, podSaturation(p)...)
+	}
+	return infra, caveats
+}
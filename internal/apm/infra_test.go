@@ -0,0 +1,111 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPodSaturation(t *testing.T) {
+	got := podSaturation(PodInfra{
+		Namespace:           "shop",
+		Pod:                 "cart-1",
+		CPUCores:            0.95,
+		CPULimitCores:       1,
+		CPUThrottledPercent: 40,
+		MemoryBytes:         500,
+		MemoryLimitBytes:    1000,
+		OOMKills:            2,
+	})
+	joined := strings.Join(got, "\n")
+	for _, want := range []string{"shop/cart-1: CPU throttled in 40%", "CPU at 95%", "2 OOM kill(s)"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("saturation missing %q:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "memory") || strings.Contains(joined, "restart") {
+		t.Errorf("unexpected saturation entries:\n%s", joined)
+	}
+	if got := podSaturation(PodInfra{Pod: "idle", CPUCores: 0.1}); got != nil {
+		t.Errorf("podSaturation(idle) = %v, want nil", got)
+	}
+}
+
+func TestFetchServiceInfra(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+
+		pod := func(name, value string) map[string]any {
+			return map[string]any{"metric": map[string]string{"namespace": "shop", "pod": name}, "value": []any{1700000000, value}}
+		}
+		var response []map[string]any
+		switch {
+		case strings.Contains(body.Query, "trace_endpoint_count"):
+			response = []map[string]any{
+				{"metric": map[string]string{"k8s_namespace_name": "shop", "k8s_pod_name": "cart-2"}, "value": []any{1700000000, "10"}},
+				{"metric": map[string]string{"k8s_namespace_name": "shop", "k8s_pod_name": "cart-1"}, "value": []any{1700000000, "12"}},
+			}
+		case strings.Contains(body.Query, "container_cpu_cfs_throttled_periods_total"):
+			response = []map[string]any{pod("cart-1", "30"), pod("cart-2", "1")}
+		case strings.Contains(body.Query, "container_memory_working_set_bytes"):
+			response = []map[string]any{pod("cart-1", "100"), pod("cart-2", "950"), pod("other", "1")}
+		case strings.Contains(body.Query, `resource="memory"`):
+			response = []map[string]any{pod("cart-1", "1000"), pod("cart-2", "1000")}
+		case strings.Contains(body.Query, "kube_pod_container_status_restarts_total"):
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	infra, caveats := fetchServiceInfra(context.Background(), server.Client(), testDBConfig(server.URL), "cart", ".*", "60m", time.Now().Unix())
+	if infra == nil || len(infra.Pods) != 2 {
+		t.Fatalf("infra = %+v, want two pods", infra)
+	}
+	if p := infra.Pods[0]; p.Pod != "cart-1" || p.CPUThrottledPercent != 30 || p.MemoryBytes != 100 {
+		t.Errorf("first pod = %+v", p)
+	}
+	if p := infra.Pods[1]; p.Pod != "cart-2" || p.MemoryBytes != 950 || p.MemoryLimitBytes != 1000 {
+		t.Errorf("second pod = %+v", p)
+	}
+	if len(infra.Saturation) != 2 || !strings.Contains(infra.Saturation[0], "shop/cart-1: CPU throttled") || !strings.Contains(infra.Saturation[1], "shop/cart-2: memory peaked at 95%") {
+		t.Errorf("saturation = %v", infra.Saturation)
+	}
+	if len(caveats) != 1 || !strings.Contains(caveats[0], "infra restarts unavailable") {
+		t.Errorf("caveats = %v, want the failed restarts query", caveats)
+	}
+	if !strings.Contains(strings.Join(queries, "\n"), `namespace=~"shop", pod=~"cart-1|cart-2"`) {
+		t.Errorf("container queries should select the service's pods: %v", queries)
+	}
+}
+
+func TestFetchServiceInfra_NoPods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	infra, caveats := fetchServiceInfra(context.Background(), server.Client(), testDBConfig(server.URL), "cart", ".*", "60m", time.Now().Unix())
+	if infra == nil || len(infra.Pods) != 0 {
+		t.Fatalf("infra = %+v, want no pods", infra)
+	}
+	if len(caveats) != 1 || !strings.Contains(caveats[0], "k8sattributes") {
+		t.Errorf("caveats = %v, want a hint about pod labels", caveats)
+	}
+}
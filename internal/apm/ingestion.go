@@ -0,0 +1,260 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	ingestionByMetric = "metric"
+
+	defaultIngestionLookbackMinutes = 15
+	defaultIngestionLimit           = 20
+	maxIngestionLimit               = 100
+	// defaultBytesPerSample approximates compressed TSDB storage per sample.
+	defaultBytesPerSample = 2.0
+
+	ingestionNoValue = "(none)"
+)
+
+// histogramSeriesSuffixes are folded into their metric family.
+var histogramSeriesSuffixes = []string{"_bucket", "_sum", "_count"}
+
+// GetIngestionVolumeArgs represents the input arguments for the get_ingestion_volume tool
+type GetIngestionVolumeArgs struct {
+	By              string  `json:"by,omitempty" jsonschema:"Group by metric family (metric, default) or by a label such as service_name, job or namespace"`
+	Matchers        string  `json:"matchers,omitempty" jsonschema:"Label matchers without braces to scope the analysis (e.g. env=\"prod\")"`
+	Limit           int     `json:"limit,omitempty" jsonschema:"Maximum number of groups returned, largest first (default: 20, max: 100)"`
+	BytesPerSample  float64 `json:"bytes_per_sample,omitempty" jsonschema:"Assumed stored bytes per sample for the byte estimates (default: 2)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to measure over, ending now (default: 15). Longer windows are more accurate but scan more data."`
+	Datasource      string  `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+}
+
+// IngestionGroup is the ingestion volume of one metric family or label value.
+type IngestionGroup struct {
+	Group                string  `json:"group"`
+	Series               float64 `json:"series"`
+	SamplesPerSecond     float64 `json:"samples_per_second"`
+	SharePercent         float64 `json:"share_percent"`
+	EstimatedBytesPerDay float64 `json:"estimated_bytes_per_day"`
+}
+
+// IngestionReport is the get_ingestion_volume response.
+type IngestionReport struct {
+	By                   string           `json:"by"`
+	Selector             string           `json:"selector"`
+	StartTime            string           `json:"start_time"`
+	EndTime              string           `json:"end_time"`
+	TotalSeries          float64          `json:"total_series"`
+	TotalSamplesPerSec   float64          `json:"total_samples_per_second"`
+	EstimatedBytesPerDay float64          `json:"estimated_bytes_per_day"`
+	BytesPerSample       float64          `json:"bytes_per_sample"`
+	Groups               []IngestionGroup `json:"groups"`
+	OmittedGroups        int              `json:"omitted_groups,omitempty"`
+	Notes                []string         `json:"notes,omitempty"`
+}
+
+// NewGetIngestionVolumeHandler estimates samples/sec and stored bytes per
+// metric family or label value, to show who drives ingestion volume.
+func NewGetIngestionVolumeHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetIngestionVolumeArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetIngestionVolumeArgs) (*mcp.CallToolResult, any, error) {
+		by := args.By
+		if by == "" {
+			by = ingestionByMetric
+		}
+		if by != ingestionByMetric && (by == "__name__" || !promLabelNamePattern.MatchString(by)) {
+			return nil, nil, fmt.Errorf("invalid by %q: use %q or a label name", args.By, ingestionByMetric)
+		}
+		if strings.ContainsAny(args.Matchers, "{}") {
+			return nil, nil, fmt.Errorf("matchers must not include braces, e.g. env=\"prod\"")
+		}
+		limit := args.Limit
+		if limit == 0 {
+			limit = defaultIngestionLimit
+		}
+		if limit < 0 || limit > maxIngestionLimit {
+			return nil, nil, fmt.Errorf("limit must be between 1 and %d", maxIngestionLimit)
+		}
+		bytesPerSample := args.BytesPerSample
+		if bytesPerSample == 0 {
+			bytesPerSample = defaultBytesPerSample
+		}
+		if bytesPerSample < 0 {
+			return nil, nil, fmt.Errorf("bytes_per_sample must be positive")
+		}
+
+		lookback := args.LookbackMinutes
+		if lookback == 0 && args.StartTimeISO == "" {
+			lookback = defaultIngestionLookbackMinutes
+		}
+		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, lookback)
+		if err != nil {
+			return nil, nil, err
+		}
+		windowSeconds := endTimeParam - startTimeParam
+		if windowSeconds < 60 {
+			return nil, nil, fmt.Errorf("window must be at least 1 minute")
+		}
+		queryCfg, err := resolveDatasourceCfg(cfg, args.Datasource)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		selector := `{__name__=~".+"}`
+		if m := strings.TrimSpace(args.Matchers); m != "" {
+			selector = fmt.Sprintf(`{__name__=~".+",%s}`, m)
+		}
+		window := fmt.Sprintf("%ds", windowSeconds)
+
+		var groups []IngestionGroup
+		var notes []string
+		if by == ingestionByMetric {
+			groups, err = ingestionByMetricFamily(ctx, client, queryCfg, selector, window, windowSeconds, endTimeParam)
+			notes = append(notes, "count_over_time drops metric names, so per-family samples/sec is the family's series count times the measured average samples/sec per series across the selector.")
+		} else {
+			groups, err = ingestionByLabel(ctx, client, queryCfg, selector, by, window, windowSeconds, endTimeParam)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		report := summarizeIngestion(groups, limit, bytesPerSample)
+		report.By = by
+		report.Selector = selector
+		report.StartTime = time.Unix(startTimeParam, 0).UTC().Format(time.RFC3339)
+		report.EndTime = time.Unix(endTimeParam, 0).UTC().Format(time.RFC3339)
+		report.Notes = append(notes, fmt.Sprintf("Bytes are estimates at %g bytes per stored sample; actual billing may be based on samples, series or different compression.", bytesPerSample))
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// ingestionByMetricFamily counts series per metric name, folds histogram
+// series into their family and apportions the measured sample rate by series.
+func ingestionByMetricFamily(ctx context.Context, client utils.HTTPClient, cfg models.Config, selector, window string, windowSeconds, end int64) ([]IngestionGroup, error) {
+	perName, err := fetchPromInstant(ctx, client, cfg, fmt.Sprintf("count by (__name__) (%s)", selector), end)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := fetchPromInstant(ctx, client, cfg, fmt.Sprintf("sum(count_over_time(%s[%s]))", selector, window), end)
+	if err != nil {
+		return nil, err
+	}
+	series, err := fetchPromInstant(ctx, client, cfg, fmt.Sprintf("count(last_over_time(%s[%s]))", selector, window), end)
+	if err != nil {
+		return nil, err
+	}
+
+	var perSeriesRate float64
+	if total, n := promScalar(samples), promScalar(series); total != nil && n != nil && *n > 0 {
+		perSeriesRate = *total / float64(windowSeconds) / *n
+	}
+
+	families := map[string]float64{}
+	for _, s := range perName {
+		families[metricFamily(s.Metric["__name__"])] += parsePromValue(s.Value)
+	}
+	groups := make([]IngestionGroup, 0, len(families))
+	for family, count := range families {
+		groups = append(groups, IngestionGroup{Group: family, Series: count, SamplesPerSecond: count * perSeriesRate})
+	}
+	return groups, nil
+}
+
+// ingestionByLabel measures samples and series per value of label.
+func ingestionByLabel(ctx context.Context, client utils.HTTPClient, cfg models.Config, selector, label, window string, windowSeconds, end int64) ([]IngestionGroup, error) {
+	samples, err := fetchPromInstant(ctx, client, cfg, fmt.Sprintf("sum by (%s) (count_over_time(%s[%s]))", label, selector, window), end)
+	if err != nil {
+		return nil, err
+	}
+	series, err := fetchPromInstant(ctx, client, cfg, fmt.Sprintf("count by (%s) (last_over_time(%s[%s]))", label, selector, window), end)
+	if err != nil {
+		return nil, err
+	}
+
+	byValue := map[string]*IngestionGroup{}
+	group := func(metric map[string]string) *IngestionGroup {
+		value := metric[label]
+		if value == "" {
+			value = ingestionNoValue
+		}
+		if byValue[value] == nil {
+			byValue[value] = &IngestionGroup{Group: value}
+		}
+		return byValue[value]
+	}
+	for _, s := range samples {
+		group(s.Metric).SamplesPerSecond += parsePromValue(s.Value) / float64(windowSeconds)
+	}
+	for _, s := range series {
+		group(s.Metric).Series += parsePromValue(s.Value)
+	}
+	groups := make([]IngestionGroup, 0, len(byValue))
+	for _, g := range byValue {
+		groups = append(groups, *g)
+	}
+	return groups, nil
+}
+
+// summarizeIngestion totals the groups, fills in shares and byte estimates
+// and keeps the largest groups by samples/sec.
+func summarizeIngestion(groups []IngestionGroup, limit int, bytesPerSample float64) IngestionReport {
+	report := IngestionReport{BytesPerSample: bytesPerSample, Groups: []IngestionGroup{}}
+	// Sort first so the float totals add up in the same order every run.
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].SamplesPerSecond != groups[j].SamplesPerSecond {
+			return groups[i].SamplesPerSecond > groups[j].SamplesPerSecond
+		}
+		return groups[i].Group < groups[j].Group
+	})
+	for _, g := range groups {
+		report.TotalSeries += g.Series
+		report.TotalSamplesPerSec += g.SamplesPerSecond
+	}
+	bytesPerDay := func(rate float64) float64 {
+		return round1(rate * bytesPerSample * (24 * time.Hour).Seconds())
+	}
+	for i, g := range groups {
+		if i == limit {
+			report.OmittedGroups = len(groups) - limit
+			break
+		}
+		if report.TotalSamplesPerSec > 0 {
+			g.SharePercent = round1(g.SamplesPerSecond / report.TotalSamplesPerSec * 100)
+		}
+		g.EstimatedBytesPerDay = bytesPerDay(g.SamplesPerSecond)
+		g.SamplesPerSecond = round1(g.SamplesPerSecond)
+		report.Groups = append(report.Groups, g)
+	}
+	report.EstimatedBytesPerDay = bytesPerDay(report.TotalSamplesPerSec)
+	report.TotalSamplesPerSec = round1(report.TotalSamplesPerSec)
+	return report
+}
+
+// metricFamily strips histogram and summary series suffixes, e.g.
+// http_duration_seconds_bucket -> http_duration_seconds.
+func metricFamily(name string) string {
+	for _, suffix := range histogramSeriesSuffixes {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
@@ -0,0 +1,141 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestMetricFamily(t *testing.T) {
+	for name, want := range map[string]string{
+		"http_duration_seconds_bucket": "http_duration_seconds",
+		"http_duration_seconds_count":  "http_duration_seconds",
+		"http_requests_total":          "http_requests_total",
+		"_sum":                         "_sum",
+	} {
+		if got := metricFamily(name); got != want {
+			t.Errorf("metricFamily(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSummarizeIngestion(t *testing.T) {
+	report := summarizeIngestion([]IngestionGroup{
+		{Group: "b", Series: 10, SamplesPerSecond: 1},
+		{Group: "a", Series: 30, SamplesPerSecond: 3},
+		{Group: "c", Series: 0, SamplesPerSecond: 0},
+	}, 2, 2)
+	if report.TotalSeries != 40 || report.TotalSamplesPerSec != 4 || report.OmittedGroups != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if a := report.Groups[0]; a.Group != "a" || a.SharePercent != 75 || a.EstimatedBytesPerDay != 3*2*86400 {
+		t.Errorf("top group = %+v", a)
+	}
+	if report.EstimatedBytesPerDay != 4*2*86400 {
+		t.Errorf("total bytes/day = %v", report.EstimatedBytesPerDay)
+	}
+}
+
+func ingestionServer(t *testing.T, answer func(query string) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		resp := answer(body.Query)
+		if resp == "" {
+			t.Errorf("unexpected query %s", body.Query)
+			resp = "[]"
+		}
+		w.Write([]byte(resp))
+	}))
+}
+
+func TestIngestionVolumeHandler_ByMetric(t *testing.T) {
+	server := ingestionServer(t, func(q string) string {
+		switch {
+		case strings.HasPrefix(q, "count by (__name__)"):
+			if !strings.Contains(q, `{__name__=~".+",env="prod"}`) {
+				t.Errorf("matchers not applied: %s", q)
+			}
+			return `[{"metric":{"__name__":"rpc_seconds_bucket"},"value":[0,"60"]},
+				{"metric":{"__name__":"rpc_seconds_count"},"value":[0,"6"]},
+				{"metric":{"__name__":"up"},"value":[0,"34"]}]`
+		case strings.HasPrefix(q, "sum(count_over_time"):
+			if !strings.Contains(q, "[900s]") {
+				t.Errorf("window not 15m: %s", q)
+			}
+			return `[{"metric":{},"value":[0,"6000"]}]` // 100 series * 900s / 15s scrape
+		case strings.HasPrefix(q, "count(last_over_time"):
+			return `[{"metric":{},"value":[0,"100"]}]`
+		}
+		return ""
+	})
+	defer server.Close()
+
+	handler := NewGetIngestionVolumeHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetIngestionVolumeArgs{Matchers: `env="prod"`})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var report IngestionReport
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.By != "metric" || len(report.Groups) != 2 || report.TotalSamplesPerSec != 6.7 {
+		t.Fatalf("report = %+v", report)
+	}
+	if g := report.Groups[0]; g.Group != "rpc_seconds" || g.Series != 66 || g.SamplesPerSecond != 4.4 {
+		t.Errorf("top family = %+v", g)
+	}
+}
+
+func TestIngestionVolumeHandler_ByLabel(t *testing.T) {
+	server := ingestionServer(t, func(q string) string {
+		switch {
+		case strings.HasPrefix(q, "sum by (service_name)"):
+			return `[{"metric":{"service_name":"api"},"value":[0,"600"]},{"metric":{},"value":[0,"60"]}]`
+		case strings.HasPrefix(q, "count by (service_name)"):
+			return `[{"metric":{"service_name":"api"},"value":[0,"10"]},{"metric":{},"value":[0,"1"]}]`
+		}
+		return ""
+	})
+	defer server.Close()
+
+	handler := NewGetIngestionVolumeHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetIngestionVolumeArgs{By: "service_name", LookbackMinutes: 1})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var report IngestionReport
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Groups) != 2 || report.Groups[0].Group != "api" || report.Groups[0].SamplesPerSecond != 10 {
+		t.Fatalf("groups = %+v", report.Groups)
+	}
+	if g := report.Groups[1]; g.Group != ingestionNoValue || g.Series != 1 || g.SamplesPerSecond != 1 {
+		t.Errorf("unlabelled group = %+v", g)
+	}
+}
+
+func TestIngestionVolumeHandler_Validation(t *testing.T) {
+	handler := NewGetIngestionVolumeHandler(http.DefaultClient, testDBConfig("http://unused"))
+	for _, args := range []GetIngestionVolumeArgs{
+		{By: "bad-label"},
+		{By: "__name__"},
+		{Matchers: `{env="prod"}`},
+		{Limit: 1000},
+		{BytesPerSample: -1},
+		{LookbackMinutes: 0.5},
+	} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("expected error for %+v", args)
+		}
+	}
+}
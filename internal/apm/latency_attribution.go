@@ -0,0 +1,375 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- attribute_dependency_latency tool ---
+
+type AttributeDependencyLatencyArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"Name of the slow service (required)"`
+	EndpointName    string  `json:"endpoint_name,omitempty" jsonschema:"Server span name of the slow endpoint (e.g. POST /checkout). Its p95 is the latency being attributed; default: the service p95"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	Depth           int     `json:"depth,omitempty" jsonschema:"Levels of callees to follow (default: 2, max: 4)"`
+	Decay           float64 `json:"decay,omitempty" jsonschema:"Factor applied to shares per level below the direct callees, between 0 and 1 (default: 0.5)"`
+	MinSharePercent float64 `json:"min_share_percent,omitempty" jsonschema:"Leave out callees with a smaller share of the latency, in percent (default: 5)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+const (
+	defaultAttributionDepth    = 2
+	maxAttributionDepth        = 4
+	defaultAttributionDecay    = 0.5
+	defaultAttributionMinShare = 5.0
+	// maxAttributionFrontier caps the services whose callees are fetched
+	// per level, busiest first.
+	maxAttributionFrontier = 20
+	// attributionTopContributors is the length of the flat ranking.
+	attributionTopContributors = 5
+)
+
+// LatencyAttribution is one callee in the attribution tree. Shares are of
+// the root's p95: a callee's time per parent request (calls per request
+// times its p95) over the parent's latency, multiplied down the path and by
+// the decay per level below the direct callees.
+type LatencyAttribution struct {
+	Service              string               `json:"service"`
+	Depth                int                  `json:"depth"`
+	CallsPerRequest      float64              `json:"calls_per_request"`
+	P95Latency           float64              `json:"p95_latency"`
+	ShareOfParentPercent float64              `json:"share_of_parent_percent"`
+	SharePercent         float64              `json:"share_percent"`
+	AttributedLatency    float64              `json:"attributed_latency"`
+	Children             []LatencyAttribution `json:"children,omitempty"`
+}
+
+// LatencyAttributionResult is the response of attribute_dependency_latency.
+type LatencyAttributionResult struct {
+	ServiceName      string               `json:"service_name"`
+	EndpointName     string               `json:"endpoint_name,omitempty"`
+	Env              string               `json:"env"`
+	Window           ReleaseWindow        `json:"window"`
+	P95Latency       float64              `json:"p95_latency"`
+	ThroughputRPM    float64              `json:"throughput_rpm"`
+	Depth            int                  `json:"depth"`
+	Decay            float64              `json:"decay"`
+	SelfSharePercent float64              `json:"self_share_percent"`
+	TopContributors  []string             `json:"top_contributors,omitempty"`
+	Callees          []LatencyAttribution `json:"callees"`
+	Meta             *ResponseMeta        `json:"_meta,omitempty"`
+}
+
+// callEdge is the traffic from one service to another over the window.
+type callEdge struct {
+	callsPerMin float64
+	p95         *float64
+}
+
+// callGraph holds the fetched part of the call graph: edges by client and
+// server, and the requests per minute each service serves.
+type callGraph struct {
+	edges map[string]map[string]callEdge
+	rpm   map[string]float64
+}
+
+// attributionOptions are the validated tree-building arguments.
+type attributionOptions struct {
+	depth    int
+	decay    float64
+	minShare float64 // percent
+}
+
+// attributeLatency builds the attribution tree below root, whose requests
+// take rootLatency at p95, and returns it with the share of rootLatency not
+// spent in direct callees. Callees are ranked by share; cycles and callees
+// under opts.minShare are left out. It is pure so the arithmetic can be
+// tested without a backend.
+func attributeLatency(g callGraph, root string, rootLatency float64, opts attributionOptions) ([]LatencyAttribution, float64) {
+	var build func(service string, latency, rootShare float64, depth int, path map[string]bool) []LatencyAttribution
+	build = func(service string, latency, rootShare float64, depth int, path map[string]bool) []LatencyAttribution {
+		rpm := g.rpm[service]
+		if depth > opts.depth || rpm <= 0 || latency <= 0 {
+			return nil
+		}
+		levelDecay := 1.0
+		if depth > 1 {
+			levelDecay = opts.decay
+		}
+		var out []LatencyAttribution
+		for callee, e := range g.edges[service] {
+			if path[callee] || e.p95 == nil {
+				continue
+			}
+			callsPerRequest := e.callsPerMin / rpm
+			shareOfParent := math.Min(1, callsPerRequest**e.p95/latency)
+			share := rootShare * shareOfParent * levelDecay
+			if 100*share < opts.minShare {
+				continue
+			}
+			path[callee] = true
+			node := LatencyAttribution{
+				Service:              callee,
+				Depth:                depth,
+				CallsPerRequest:      round3(callsPerRequest),
+				P95Latency:           round3(*e.p95),
+				ShareOfParentPercent: round1(100 * shareOfParent),
+				SharePercent:         round1(100 * share),
+				AttributedLatency:    round3(share * rootLatency),
+				Children:             build(callee, *e.p95, share, depth+1, path),
+			}
+			delete(path, callee)
+			out = append(out, node)
+		}
+		sort.Slice(out, func(i, j int) bool {
+			if out[i].SharePercent != out[j].SharePercent {
+				return out[i].SharePercent > out[j].SharePercent
+			}
+			return out[i].Service < out[j].Service
+		})
+		return out
+	}
+	callees := build(root, rootLatency, 1, 1, map[string]bool{root: true})
+
+	// Self time counts every direct callee, including those under minShare.
+	var calleeShare float64
+	if rpm := g.rpm[root]; rpm > 0 && rootLatency > 0 {
+		for callee, e := range g.edges[root] {
+			if callee != root && e.p95 != nil {
+				calleeShare += e.callsPerMin / rpm * *e.p95 / rootLatency
+			}
+		}
+	}
+	return callees, round1(100 * math.Max(0, 1-calleeShare))
+}
+
+// topContributors flattens the tree and returns the largest shares as
+// "a → b (12.5%)" paths.
+func topContributors(root string, callees []LatencyAttribution, n int) []string {
+	type entry struct {
+		path  string
+		share float64
+	}
+	var all []entry
+	var walk func(prefix string, nodes []LatencyAttribution)
+	walk = func(prefix string, nodes []LatencyAttribution) {
+		for _, c := range nodes {
+			path := prefix + " → " + c.Service
+			all = append(all, entry{path, c.SharePercent})
+			walk(path, c.Children)
+		}
+	}
+	walk(root, callees)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].share > all[j].share })
+	out := make([]string, 0, min(n, len(all)))
+	for _, e := range all[:min(n, len(all))] {
+		out = append(out, fmt.Sprintf("%s (%.1f%%)", e.path, e.share))
+	}
+	return out
+}
+
+// fetchByLabels runs an instant query and returns its values keyed by the
+// given labels joined with "\x00".
+func fetchByLabels(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, end int64, labels ...string) (map[string]float64, error) {
+	series, err := fetchPromInstant(ctx, client, cfg, query, end)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(series))
+	for _, s := range series {
+		v := promScalar(apiPromInstantResp{s})
+		if v == nil {
+			continue
+		}
+		key := make([]string, len(labels))
+		for i, l := range labels {
+			key[i] = s.Metric[l]
+		}
+		out[strings.Join(key, "\x00")] = *v
+	}
+	return out, nil
+}
+
+func servicesRegex(services []string) string {
+	patterns := make([]string, len(services))
+	for i, s := range services {
+		patterns[i] = regexp.QuoteMeta(s)
+	}
+	return escapePromQLLabel(strings.Join(patterns, "|"))
+}
+
+func NewAttributeDependencyLatencyHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, AttributeDependencyLatencyArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args AttributeDependencyLatencyArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		opts := attributionOptions{depth: args.Depth, decay: args.Decay, minShare: args.MinSharePercent}
+		if opts.depth == 0 {
+			opts.depth = defaultAttributionDepth
+		}
+		if opts.decay == 0 {
+			opts.decay = defaultAttributionDecay
+		}
+		if opts.minShare == 0 {
+			opts.minShare = defaultAttributionMinShare
+		}
+		if opts.depth < 1 || opts.depth > maxAttributionDepth {
+			return nil, nil, fmt.Errorf("depth must be between 1 and %d", maxAttributionDepth)
+		}
+		if opts.decay < 0 || opts.decay > 1 {
+			return nil, nil, fmt.Errorf("decay must be between 0 and 1")
+		}
+		if opts.minShare < 0 || opts.minShare > 100 {
+			return nil, nil, fmt.Errorf("min_share_percent must be between 0 and 100")
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		durationMin := max((endTime-startTime)/60, 1)
+		envName := resolveEnv(cfg, args.Env)
+		env := escapePromQLLabel(envName)
+
+		// The root: the endpoint's or service's p95 and request rate.
+		rootSel := fmt.Sprintf(`service_name="%s", env=~"%s", span_kind="SPAN_KIND_SERVER"`, escapePromQLLabel(args.ServiceName), env)
+		latencyQuery := fmt.Sprintf(`max(avg_over_time(trace_service_response_time{service_name="%s", env=~"%s", quantile="p95"}[%dm]))`, escapePromQLLabel(args.ServiceName), env, durationMin)
+		if args.EndpointName != "" {
+			latencyQuery = fmt.Sprintf(`max(avg_over_time(trace_endpoint_duration{%s, span_name="%s", quantile="p95"}[%dm]))`, rootSel, escapePromQLLabel(args.EndpointName), durationMin)
+		}
+		series, err := fetchPromInstant(ctx, client, cfg, latencyQuery, endTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch root latency: %w", err)
+		}
+		rootLatency := promScalar(series)
+		if rootLatency == nil || *rootLatency <= 0 {
+			return nil, nil, fmt.Errorf("no p95 latency for %s (env=~%q) in the given time range; check the names with did_you_mean", args.ServiceName, envName)
+		}
+		series, err = fetchPromInstant(ctx, client, cfg,
+			fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%s}[%dm])) / %d`, rootSel, durationMin, durationMin), endTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch root throughput: %w", err)
+		}
+		rootRPM := promScalar(series)
+		if rootRPM == nil || *rootRPM <= 0 {
+			return nil, nil, fmt.Errorf("no requests to %s (env=~%q) in the given time range", args.ServiceName, envName)
+		}
+
+		// Walk the call graph a level at a time. Call graph metrics are per
+		// service pair, so an endpoint's callees are its service's callees.
+		g := callGraph{edges: map[string]map[string]callEdge{}, rpm: map[string]float64{args.ServiceName: *rootRPM}}
+		frontier := []string{args.ServiceName}
+		var caveats []string
+		for level := 1; level <= opts.depth && len(frontier) > 0; level++ {
+			sel := fmt.Sprintf(`client=~"%s", env=~"%s"`, servicesRegex(frontier), env)
+			calls, err := fetchByLabels(ctx, client, cfg,
+				fmt.Sprintf(`sum by (client, server)(sum_over_time(trace_call_graph_count{%s}[%dm])) / %d`, sel, durationMin, durationMin),
+				endTime, "client", "server")
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch calls at depth %d: %w", level, err)
+			}
+			latencies, err := fetchByLabels(ctx, client, cfg,
+				fmt.Sprintf(`max by (client, server)(avg_over_time(trace_call_graph_duration{%s, quantile="p95"}[%dm]))`, sel, durationMin),
+				endTime, "client", "server")
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch call latency at depth %d: %w", level, err)
+			}
+			next := map[string]float64{}
+			for key, rate := range calls {
+				caller, callee, _ := strings.Cut(key, "\x00")
+				if callee == "" {
+					continue
+				}
+				e := callEdge{callsPerMin: rate}
+				if v, ok := latencies[key]; ok {
+					e.p95 = &v
+				}
+				if g.edges[caller] == nil {
+					g.edges[caller] = map[string]callEdge{}
+				}
+				g.edges[caller][callee] = e
+				if _, seen := g.rpm[callee]; !seen {
+					next[callee] += rate
+				}
+			}
+			if level == opts.depth || len(next) == 0 {
+				break
+			}
+
+			frontier = frontier[:0]
+			for callee := range next {
+				frontier = append(frontier, callee)
+			}
+			sort.Slice(frontier, func(i, j int) bool { return next[frontier[i]] > next[frontier[j]] })
+			if len(frontier) > maxAttributionFrontier {
+				caveats = append(caveats, fmt.Sprintf("only the %d busiest of %d callees at depth %d were followed", maxAttributionFrontier, len(frontier), level))
+				frontier = frontier[:maxAttributionFrontier]
+			}
+			served, err := fetchByLabels(ctx, client, cfg,
+				fmt.Sprintf(`sum by (server)(sum_over_time(trace_call_graph_count{server=~"%s", env=~"%s"}[%dm])) / %d`, servicesRegex(frontier), env, durationMin, durationMin),
+				endTime, "server")
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch callee traffic at depth %d: %w", level, err)
+			}
+			for _, s := range frontier {
+				g.rpm[s] = served[s]
+			}
+		}
+
+		callees, selfShare := attributeLatency(g, args.ServiceName, *rootLatency, opts)
+		if selfShare == 0 {
+			caveats = append(caveats, "callee time adds up to more than the p95, so calls likely run in parallel and shares overlap")
+		}
+		if args.EndpointName != "" {
+			caveats = append(caveats, "call graph metrics are per service, so callee calls per request are averaged over all of the service's endpoints")
+		}
+
+		result := LatencyAttributionResult{
+			ServiceName:      args.ServiceName,
+			EndpointName:     args.EndpointName,
+			Env:              envName,
+			Window:           ReleaseWindow{Start: time.Unix(startTime, 0).UTC().Format(time.RFC3339), End: time.Unix(endTime, 0).UTC().Format(time.RFC3339)},
+			P95Latency:       round3(*rootLatency),
+			ThroughputRPM:    round1(*rootRPM),
+			Depth:            opts.depth,
+			Decay:            opts.decay,
+			SelfSharePercent: selfShare,
+			TopContributors:  topContributors(args.ServiceName, callees, attributionTopContributors),
+			Callees:          callees,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf(`trace_call_graph_count{client="%s", env=~"%s"}`, escapePromQLLabel(args.ServiceName), env),
+			), caveats...),
+		}
+		if result.Callees == nil {
+			result.Callees = []LatencyAttribution{}
+		}
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, envName, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
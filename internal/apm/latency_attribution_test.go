@@ -0,0 +1,72 @@
+package apm
+
+import (
+	"strings"
+	"testing"
+)
+
+func testCallGraph() callGraph {
+	edge := func(calls, p95 float64) callEdge { return callEdge{callsPerMin: calls, p95: ptr(p95)} }
+	return callGraph{
+		edges: map[string]map[string]callEdge{
+			"checkout": {
+				"payments": edge(100, 200),
+				"cart":     edge(200, 40),
+				"audit":    edge(10, 8),
+				"unknown":  {callsPerMin: 50},
+			},
+			"payments": {
+				"stripe-gw": edge(150, 150),
+				"checkout":  edge(150, 10),
+			},
+		},
+		rpm: map[string]float64{"checkout": 100, "payments": 150, "cart": 200},
+	}
+}
+
+func TestAttributeLatency(t *testing.T) {
+	callees, self := attributeLatency(testCallGraph(), "checkout", 400, attributionOptions{depth: 2, decay: 0.5, minShare: 5})
+
+	// payments: 1 call per request at 200 of 400; cart: 2 calls at 40; audit
+	// is under min_share, and the edge without latency is skipped.
+	if len(callees) != 2 || callees[0].Service != "payments" || callees[1].Service != "cart" {
+		t.Fatalf("callees = %+v", callees)
+	}
+	if c := callees[0]; c.SharePercent != 50 || c.AttributedLatency != 200 || c.CallsPerRequest != 1 {
+		t.Errorf("payments = %+v", c)
+	}
+	if c := callees[1]; c.SharePercent != 20 || c.CallsPerRequest != 2 {
+		t.Errorf("cart = %+v", c)
+	}
+	// stripe-gw takes 75% of payments' latency, decayed by half; the call
+	// back to checkout is a cycle.
+	children := callees[0].Children
+	if len(children) != 1 || children[0].Service != "stripe-gw" || children[0].ShareOfParentPercent != 75 || children[0].SharePercent != 18.8 {
+		t.Errorf("payments children = %+v", children)
+	}
+	if self != 29.8 {
+		t.Errorf("self share = %v, want 29.8", self)
+	}
+
+	top := topContributors("checkout", callees, 5)
+	if got := strings.Join(top, "; "); got != "checkout → payments (50.0%); checkout → cart (20.0%); checkout → payments → stripe-gw (18.8%)" {
+		t.Errorf("top contributors = %s", got)
+	}
+}
+
+func TestAttributeLatencyDepth(t *testing.T) {
+	callees, _ := attributeLatency(testCallGraph(), "checkout", 400, attributionOptions{depth: 1, decay: 0.5, minShare: 5})
+	for _, c := range callees {
+		if len(c.Children) > 0 {
+			t.Errorf("depth 1 should not follow %s's callees: %+v", c.Service, c.Children)
+		}
+	}
+
+	// Parallel calls can add up to more than the latency; self time stops at 0.
+	g := testCallGraph()
+	g.edges["checkout"]["cart"] = callEdge{callsPerMin: 1000, p95: ptr(100)}
+	_, self := attributeLatency(g, "checkout", 400, attributionOptions{depth: 1, decay: 0.5, minShare: 5})
+	if self != 0 {
+		t.Errorf("self share = %v, want 0", self)
+	}
+}
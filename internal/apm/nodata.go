@@ -0,0 +1,76 @@
+package apm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sub-query kinds decide what an empty result means.
+const (
+	// subQueryTraffic selects the service's own spans; when none of these
+	// return series the service has no data in the window.
+	subQueryTraffic = "traffic"
+	// subQueryErrors selects errored spans only; empty means no errors were
+	// recorded, provided there is traffic.
+	subQueryErrors = "errors"
+	// subQueryCalls selects calls to or from other systems; empty means the
+	// service made or received no such calls.
+	subQueryCalls = "calls"
+)
+
+// SubQueryData is the number of series one sub-query of a response returned.
+type SubQueryData struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Series int    `json:"series"`
+}
+
+// dataChecks collects sub-query series counts for withDataAvailability.
+// Sub-queries that failed are not recorded; they are reported as caveats.
+type dataChecks []SubQueryData
+
+func (c *dataChecks) record(name, kind string, series int) {
+	*c = append(*c, SubQueryData{Name: name, Kind: kind, Series: series})
+}
+
+// withDataAvailability adds the sub-query series counts to meta and tells
+// zero traffic apart from missing data: data_available is false when no
+// traffic or calls sub-query returned a series, so zeros and empty lists in
+// the response mean "nothing found", not "healthy". Hints suggest what to
+// check. Confidence drops to low when there is no data.
+func (meta *ResponseMeta) withDataAvailability(serviceName, env string, checks dataChecks) *ResponseMeta {
+	available := false
+	var emptyTraffic, emptyErrors []string
+	for _, c := range checks {
+		switch {
+		case c.Series > 0 && c.Kind != subQueryErrors:
+			available = true
+		case c.Series == 0 && c.Kind == subQueryTraffic:
+			emptyTraffic = append(emptyTraffic, c.Name)
+		case c.Series == 0 && c.Kind == subQueryErrors:
+			emptyErrors = append(emptyErrors, c.Name)
+		}
+	}
+
+	meta.DataAvailable = &available
+	meta.SubQueries = checks
+	if !available {
+		meta.Confidence = confidenceLow
+		meta.Hints = append(meta.Hints,
+			fmt.Sprintf("No series for service %q in env %q in this window: zero values and empty lists mean missing data, not a healthy service.", serviceName, env),
+			"Check the service name spelling; get_service_summary lists services with traffic and did_you_mean suggests close matches.",
+		)
+		if env != ".*" {
+			meta.Hints = append(meta.Hints, "Check the env; get_service_environments lists the environments that report data.")
+		}
+		meta.Hints = append(meta.Hints, "If the name and env are right, the service had no traffic in the window or is not instrumented; try a longer lookback.")
+		return meta
+	}
+	if len(emptyTraffic) > 0 {
+		meta.Hints = append(meta.Hints, fmt.Sprintf("%s returned no series although the service has data; that signal may not be instrumented.", strings.Join(emptyTraffic, ", ")))
+	}
+	if len(emptyErrors) > 0 {
+		meta.Hints = append(meta.Hints, fmt.Sprintf("%s returned no series because no errors were recorded in the window.", strings.Join(emptyErrors, ", ")))
+	}
+	return meta
+}
@@ -0,0 +1,89 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestWithDataAvailability(t *testing.T) {
+	t.Run("no data", func(t *testing.T) {
+		var checks dataChecks
+		checks.record("operations.throughput", subQueryTraffic, 0)
+		checks.record("operations.error_rate", subQueryErrors, 0)
+		checks.record("databases.throughput", subQueryCalls, 0)
+		meta := buildResponseMeta(nil).withDataAvailability("chekout", "prod", checks)
+
+		if meta.DataAvailable == nil || *meta.DataAvailable {
+			t.Fatalf("data_available = %v, want false", meta.DataAvailable)
+		}
+		if meta.Confidence != confidenceLow {
+			t.Errorf("confidence = %s, want low", meta.Confidence)
+		}
+		hints := strings.Join(meta.Hints, "\n")
+		for _, want := range []string{`service "chekout"`, "spelling", "get_service_environments"} {
+			if !strings.Contains(hints, want) {
+				t.Errorf("hints lack %q: %s", want, hints)
+			}
+		}
+	})
+
+	t.Run("traffic without errors", func(t *testing.T) {
+		var checks dataChecks
+		checks.record("operations.throughput", subQueryTraffic, 3)
+		checks.record("operations.response_time", subQueryTraffic, 0)
+		checks.record("operations.error_rate", subQueryErrors, 0)
+		checks.record("databases.throughput", subQueryCalls, 0)
+		meta := buildResponseMeta(nil).withDataAvailability("checkout", ".*", checks)
+
+		if meta.DataAvailable == nil || !*meta.DataAvailable {
+			t.Fatalf("data_available = %v, want true", meta.DataAvailable)
+		}
+		if len(meta.Hints) != 2 ||
+			!strings.Contains(meta.Hints[0], "operations.response_time returned no series although") ||
+			!strings.Contains(meta.Hints[1], "operations.error_rate returned no series because no errors") {
+			t.Errorf("hints = %q", meta.Hints)
+		}
+		if len(meta.SubQueries) != 4 || meta.SubQueries[0].Series != 3 {
+			t.Errorf("sub_queries = %+v", meta.SubQueries)
+		}
+	})
+
+	t.Run("calls only", func(t *testing.T) {
+		var checks dataChecks
+		checks.record("incoming.throughput", subQueryCalls, 0)
+		checks.record("outgoing.throughput", subQueryCalls, 2)
+		meta := buildResponseMeta(nil).withDataAvailability("worker", ".*", checks)
+		if !*meta.DataAvailable || len(meta.Hints) != 0 {
+			t.Errorf("data_available = %v, hints = %q", *meta.DataAvailable, meta.Hints)
+		}
+	})
+}
+
+func TestServiceOperationsSummaryHandler_NoData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	handler := NewServiceOperationsSummaryHandler(server.Client(), testDBConfig(server.URL))
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ServiceOperationsSummaryArgs{ServiceName: "chekout", Env: "prod"})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	var resp ServiceOperationsSummaryResponse
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Meta == nil || resp.Meta.DataAvailable == nil || *resp.Meta.DataAvailable {
+		t.Fatalf("_meta = %+v, want data_available false", resp.Meta)
+	}
+	if len(resp.Meta.SubQueries) != 12 || len(resp.Meta.Hints) == 0 {
+		t.Errorf("sub_queries = %d, hints = %q", len(resp.Meta.SubQueries), resp.Meta.Hints)
+	}
+}
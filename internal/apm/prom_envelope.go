@@ -0,0 +1,94 @@
+package apm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// promEnvelope is the Prometheus HTTP API response wrapper. The Last9 API
+// usually returns the bare result, but may pass the envelope through, and
+// with it the warnings a backend adds when it drops or truncates data.
+type promEnvelope struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+	Warnings  []string        `json:"warnings"`
+	Infos     []string        `json:"infos"`
+}
+
+// partialWarning matches warnings that mean the result is missing data, as
+// opposed to PromQL notes such as "metric might not be a counter".
+var partialWarning = regexp.MustCompile(`(?i)partial|truncat|incomplete|limit|exceed|dropped|timeout|timed out|unavailable|unreachable`)
+
+// PromWarnings are the warnings of an upstream response. Partial is set when
+// any warning says data was left out, or the server cut the result short
+// (Truncation), so the result must not be read as complete.
+type PromWarnings struct {
+	Warnings   []string         `json:"warnings,omitempty"`
+	Partial    bool             `json:"partial,omitempty"`
+	Truncation *RangeTruncation `json:"truncation,omitempty"`
+}
+
+// unwrapPromBody returns the result in body and the warnings that came with
+// it. Bare results are returned as they are. For an envelope, the result is
+// data.result for queries and data itself for label APIs; an error status
+// is returned as an error. Infos are kept as warnings.
+func unwrapPromBody(body []byte) ([]byte, PromWarnings, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return body, PromWarnings{}, nil
+	}
+	var env promEnvelope
+	if err := json.Unmarshal(trimmed, &env); err != nil || env.Status == "" {
+		return body, PromWarnings{}, nil
+	}
+
+	w := envelopeWarnings(env)
+	if env.Status == "error" {
+		return nil, w, fmt.Errorf("prometheus query failed (%s): %s", env.ErrorType, env.Error)
+	}
+
+	result := []byte(env.Data)
+	var data struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(env.Data), []byte("{")) && json.Unmarshal(env.Data, &data) == nil && data.Result != nil {
+		result = data.Result
+	}
+	if len(result) == 0 {
+		result = []byte("[]")
+	}
+	return result, w, nil
+}
+
+// envelopeWarnings collects the warnings and infos of env and flags them
+// partial when a warning says data is missing.
+func envelopeWarnings(env promEnvelope) PromWarnings {
+	w := PromWarnings{Warnings: append(env.Warnings, env.Infos...)}
+	for _, msg := range env.Warnings {
+		if partialWarning.MatchString(msg) {
+			w.Partial = true
+		}
+	}
+	return w
+}
+
+// promResultText is the text returned by the pass-through Prometheus
+// tools: the result as it came, or, when there are warnings or the result
+// was truncated, an object with the result under "result" beside them.
+func promResultText(result []byte, w PromWarnings) (string, error) {
+	if len(w.Warnings) == 0 && w.Truncation == nil {
+		return string(result), nil
+	}
+	out, err := json.Marshal(struct {
+		Result json.RawMessage `json:"result"`
+		PromWarnings
+	}{result, w})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return string(out), nil
+}
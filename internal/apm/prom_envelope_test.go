@@ -0,0 +1,85 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestUnwrapPromBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     string
+		warnings int
+		partial  bool
+		wantErr  bool
+	}{
+		{name: "bare result", body: `[{"metric":{},"value":[1,"1"]}]`, want: `[{"metric":{},"value":[1,"1"]}]`},
+		{name: "not an envelope", body: `{"env":["prod"]}`, want: `{"env":["prod"]}`},
+		{
+			name: "query envelope",
+			body: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"1"]}]}}`,
+			want: `[{"metric":{},"value":[1,"1"]}]`,
+		},
+		{
+			name:     "truncated",
+			body:     `{"status":"success","data":{"resultType":"matrix","result":[]},"warnings":["results truncated due to limit"]}`,
+			want:     `[]`,
+			warnings: 1,
+			partial:  true,
+		},
+		{
+			name:     "notes only",
+			body:     `{"status":"success","data":["prod","staging"],"infos":["PromQL info: metric might not be a counter"]}`,
+			want:     `["prod","staging"]`,
+			warnings: 1,
+		},
+		{name: "error", body: `{"status":"error","errorType":"timeout","error":"query timed out"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, w, err := unwrapPromBody([]byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want || len(w.Warnings) != tt.warnings || w.Partial != tt.partial {
+				t.Errorf("got %s, %+v; want %s with %d warnings, partial %v", got, w, tt.want, tt.warnings, tt.partial)
+			}
+		})
+	}
+}
+
+func TestPromqlInstantHandler_Warnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"api"},"value":[1700000000,"1"]}]},"warnings":["partial response: store gateway unavailable"]}`)
+	}))
+	defer server.Close()
+
+	result, _, err := NewPromqlInstantQueryHandler(server.Client(), testDBConfig(server.URL))(context.Background(), &mcp.CallToolRequest{}, PromqlInstantQueryArgs{Query: "up"})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	var got struct {
+		Result   apiPromInstantResp `json:"result"`
+		Warnings []string           `json:"warnings"`
+		Partial  bool               `json:"partial"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if len(got.Result) != 1 || len(got.Warnings) != 1 || !got.Partial {
+		t.Errorf("unexpected response: %+v", got)
+	}
+}
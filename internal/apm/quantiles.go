@@ -0,0 +1,117 @@
+package apm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+// supportedQuantiles are the quantile label values of the trace duration
+// metrics, in the order they are reported.
+var supportedQuantiles = []string{"p50", "p75", "p90", "p95", "p99", "p999", "avg", "max"}
+
+// DefaultQuantiles are the response-time quantiles reported when neither the
+// call nor the server configuration selects any.
+var DefaultQuantiles = []string{"p50", "p90", "p95", "avg", "max"}
+
+// ParseQuantiles validates a quantile list, such as the --quantiles flag
+// split on commas. Names are case-insensitive; duplicates and blanks are
+// dropped and the result is in canonical order.
+func ParseQuantiles(names []string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(supportedQuantiles, name) {
+			return nil, fmt.Errorf("unsupported quantile %q: use %s", name, strings.Join(supportedQuantiles, ", "))
+		}
+		selected[name] = true
+	}
+	var out []string
+	for _, q := range supportedQuantiles {
+		if selected[q] {
+			out = append(out, q)
+		}
+	}
+	return out, nil
+}
+
+// resolveQuantiles returns the quantiles a call reports: the requested ones,
+// else the configured ones, else DefaultQuantiles.
+func resolveQuantiles(cfg models.Config, requested []string) ([]string, error) {
+	quantiles, err := ParseQuantiles(requested)
+	if err != nil {
+		return nil, err
+	}
+	if len(quantiles) > 0 {
+		return quantiles, nil
+	}
+	if len(cfg.Quantiles) > 0 {
+		return cfg.Quantiles, nil
+	}
+	return DefaultQuantiles, nil
+}
+
+// quantileMatcher is the PromQL label matcher selecting quantiles.
+func quantileMatcher(quantiles []string) string {
+	return fmt.Sprintf("quantile=~'%s'", strings.Join(quantiles, "|"))
+}
+
+// newResponseTimes returns a response-time map with every quantile at zero,
+// so quantiles without data are still reported.
+func newResponseTimes(quantiles []string) map[string]float64 {
+	m := make(map[string]float64, len(quantiles))
+	for _, q := range quantiles {
+		m[q] = 0
+	}
+	return m
+}
+
+// RedMetrics are the rate, error and duration metrics of one dependency.
+// ResponseTime is keyed by quantile and encoded as ResponseTimeP95,
+// ResponseTimeAvg and so on.
+type RedMetrics struct {
+	Throughput, ErrorRate, ErrorPercent float64
+	ResponseTime                        map[string]float64
+	// Edge is set on dependency graph edges to other services.
+	Edge *EdgeEvidence
+}
+
+func newRedMetrics(quantiles []string) RedMetrics {
+	return RedMetrics{ResponseTime: newResponseTimes(quantiles)}
+}
+
+// setResponseTime records the value of one quantile. Quantiles outside
+// the selection are ignored.
+func (m *RedMetrics) setResponseTime(quantiles []string, quantile string, val float64) {
+	if !slices.Contains(quantiles, quantile) {
+		return
+	}
+	if m.ResponseTime == nil {
+		m.ResponseTime = newResponseTimes(quantiles)
+	}
+	m.ResponseTime[quantile] = val
+}
+
+func (m RedMetrics) MarshalJSON() ([]byte, error) {
+	out := map[string]any{
+		"Throughput":   m.Throughput,
+		"ErrorRate":    m.ErrorRate,
+		"ErrorPercent": m.ErrorPercent,
+	}
+	for q, v := range m.ResponseTime {
+		out["ResponseTime"+strings.ToUpper(q[:1])+q[1:]] = v
+	}
+	if e := m.Edge; e != nil {
+		out["SampleCount"] = e.SampleCount
+		out["SpanKinds"] = e.SpanKinds
+		out["DirectionConfirmed"] = e.DirectionConfirmed
+		out["Confidence"] = e.Confidence
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,119 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParseQuantiles(t *testing.T) {
+	got, err := ParseQuantiles([]string{" P999", "avg", "", "p50", "p999"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"p50", "p999", "avg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseQuantiles() = %v, want %v", got, want)
+	}
+	if _, err := ParseQuantiles([]string{"p42"}); err == nil {
+		t.Error("ParseQuantiles(p42): want error")
+	}
+	if got, err := ParseQuantiles([]string{""}); err != nil || got != nil {
+		t.Errorf("ParseQuantiles(empty) = %v, %v", got, err)
+	}
+}
+
+func TestResolveQuantiles(t *testing.T) {
+	cfg := testDBConfig("")
+	if got, _ := resolveQuantiles(cfg, nil); !reflect.DeepEqual(got, DefaultQuantiles) {
+		t.Errorf("no config: got %v, want %v", got, DefaultQuantiles)
+	}
+	cfg.Quantiles = []string{"p99", "p999"}
+	if got, _ := resolveQuantiles(cfg, nil); !reflect.DeepEqual(got, cfg.Quantiles) {
+		t.Errorf("configured: got %v, want %v", got, cfg.Quantiles)
+	}
+	if got, _ := resolveQuantiles(cfg, []string{"p75"}); !reflect.DeepEqual(got, []string{"p75"}) {
+		t.Errorf("requested: got %v, want [p75]", got)
+	}
+	if _, err := resolveQuantiles(cfg, []string{"median"}); err == nil {
+		t.Error("unsupported quantile: want error")
+	}
+	if got := quantileMatcher([]string{"p50", "p99"}); got != "quantile=~'p50|p99'" {
+		t.Errorf("quantileMatcher() = %s", got)
+	}
+}
+
+func TestRedMetricsJSON(t *testing.T) {
+	quantiles := []string{"p99", "p999"}
+	m := newRedMetrics(quantiles)
+	m.Throughput = 12
+	m.setResponseTime(quantiles, "p999", 0.8)
+	m.setResponseTime(quantiles, "p50", 0.1) // not selected
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]float64
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"Throughput": 12, "ErrorRate": 0, "ErrorPercent": 0, "ResponseTimeP99": 0, "ResponseTimeP999": 0.8}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RedMetrics JSON = %v, want %v", got, want)
+	}
+}
+
+func TestServiceOperationsSummaryHandler_Quantiles(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	cfg := testDBConfig(server.URL)
+	cfg.Quantiles = []string{"p95"}
+	handler := NewServiceOperationsSummaryHandler(server.Client(), cfg)
+
+	for _, tt := range []struct {
+		requested []string
+		want      string
+	}{{nil, "quantile=~'p95'"}, {[]string{"p99", "p999"}, "quantile=~'p99|p999'"}} {
+		queries = nil
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ServiceOperationsSummaryArgs{ServiceName: "api", Quantiles: tt.requested}); err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		matched := 0
+		for _, q := range queries {
+			if strings.Contains(q, "sum by (quantile") {
+				if !strings.Contains(q, tt.want) {
+					t.Errorf("quantiles %v: response-time query lacks %s: %s", tt.requested, tt.want, q)
+				}
+				matched++
+			}
+		}
+		if matched == 0 {
+			t.Errorf("quantiles %v: no response-time queries sent: %v", tt.requested, queries)
+		}
+	}
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ServiceOperationsSummaryArgs{ServiceName: "api", Quantiles: []string{"p42"}}); err == nil {
+		t.Error("unsupported quantile: want error")
+	}
+}
@@ -0,0 +1,107 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// queryLimitError is the structured error returned when a query is refused
+// by the cost guardrails.
+type queryLimitError struct {
+	Error      string `json:"error"`
+	Reason     string `json:"reason"`
+	Limit      int64  `json:"limit"`
+	Estimate   int64  `json:"estimate"`
+	Suggestion string `json:"suggestion"`
+}
+
+const (
+	queryLimitSeries = "series_limit_exceeded"
+	queryLimitWindow = "window_limit_exceeded"
+)
+
+func (e queryLimitError) result() *mcp.CallToolResult {
+	body, _ := json.Marshal(e)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(body)}},
+		IsError: true,
+	}
+}
+
+// checkRangeQueryWindow refuses range queries longer than the configured
+// maximum window.
+func checkRangeQueryWindow(cfg models.Config, startTime, endTime int64) *queryLimitError {
+	maxHours := int64(cfg.MaxQueryWindowHours)
+	if maxHours <= 0 {
+		maxHours = models.DefaultMaxQueryWindowHours
+	}
+	windowHours := int64(time.Duration(endTime-startTime) * time.Second / time.Hour)
+	if windowHours <= maxHours {
+		return nil
+	}
+	return &queryLimitError{
+		Error:      queryLimitWindow,
+		Reason:     fmt.Sprintf("query window is %dh, above the %dh limit", windowHours, maxHours),
+		Limit:      maxHours,
+		Estimate:   windowHours,
+		Suggestion: "narrow the time range, or query an aggregate with prometheus_instant_query over the full window (e.g. avg_over_time(...[7d]))",
+	}
+}
+
+// estimateQuerySeries returns the number of series query evaluates to at
+// endTime, via an instant count(). ok is false when the estimate could not be
+// made (e.g. the query returns a scalar); callers then let the query run.
+func estimateQuerySeries(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64) (count int64, ok bool, err error) {
+	series, err := fetchPromInstant(ctx, client, cfg, fmt.Sprintf("count(%s)", query), endTime)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, false, ctxErr
+		}
+		return 0, false, nil
+	}
+	if len(series) == 0 {
+		return 0, true, nil
+	}
+	return int64(parsePromValue(series[0].Value)), true, nil
+}
+
+// checkRangeQuerySeries refuses range queries that would return more series
+// than the configured maximum.
+func checkRangeQuerySeries(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, endTime int64) (*queryLimitError, error) {
+	limit := int64(cfg.MaxQuerySeries)
+	if limit <= 0 {
+		limit = models.DefaultMaxQuerySeries
+	}
+	count, ok, err := estimateQuerySeries(ctx, client, cfg, query, endTime)
+	if err != nil || !ok || count <= limit {
+		return nil, err
+	}
+	return &queryLimitError{
+		Error:      queryLimitSeries,
+		Reason:     fmt.Sprintf("query returns about %d series, above the %d series limit", count, limit),
+		Limit:      limit,
+		Estimate:   count,
+		Suggestion: "aggregate with sum by (...) / topk(...), or add label matchers to select fewer series",
+	}, nil
+}
+
+// rangeQueryLimits are the series and point limits applied while a range
+// result is read. The series limit also catches queries whose count()
+// estimate was too low or could not be made.
+func rangeQueryLimits(cfg models.Config) rangeLimits {
+	limits := rangeLimits{maxSeries: cfg.MaxQuerySeries, maxPoints: int64(cfg.MaxQueryPoints)}
+	if limits.maxSeries <= 0 {
+		limits.maxSeries = models.DefaultMaxQuerySeries
+	}
+	if limits.maxPoints <= 0 {
+		limits.maxPoints = models.DefaultMaxQueryPoints
+	}
+	return limits
+}
@@ -0,0 +1,99 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestCheckRangeQueryWindow(t *testing.T) {
+	cfg := testDBConfig("")
+	if err := checkRangeQueryWindow(cfg, 0, 7*24*3600); err != nil {
+		t.Errorf("7d window should be allowed by default, got %+v", err)
+	}
+	err := checkRangeQueryWindow(cfg, 0, 8*24*3600)
+	if err == nil || err.Error != queryLimitWindow || err.Estimate != 192 || err.Limit != 168 {
+		t.Errorf("8d window: got %+v", err)
+	}
+
+	cfg.MaxQueryWindowHours = 24
+	if err := checkRangeQueryWindow(cfg, 0, 25*3600); err == nil {
+		t.Error("configured 24h limit should refuse a 25h window")
+	}
+}
+
+// rangeQueryLimitServer answers the count() probe with seriesCount and
+// counts range queries.
+func rangeQueryLimitServer(t *testing.T, seriesCount string, probeStatus int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var rangeQueries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/prom_query_instant") {
+			var payload struct {
+				Query string `json:"query"`
+			}
+			_ = json.Unmarshal(body, &payload)
+			if !strings.HasPrefix(payload.Query, "count(") {
+				t.Errorf("unexpected probe query %q", payload.Query)
+			}
+			if probeStatus != http.StatusOK {
+				w.WriteHeader(probeStatus)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"metric":{},"value":[1700000000,"` + seriesCount + `"]}]`))
+			return
+		}
+		rangeQueries.Add(1)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &rangeQueries
+}
+
+func TestPromqlRangeHandler_SeriesLimit(t *testing.T) {
+	t.Run("refuses queries above the limit", func(t *testing.T) {
+		server, rangeQueries := rangeQueryLimitServer(t, "12000", http.StatusOK)
+		result, _, err := NewPromqlRangeQueryHandler(server.Client(), testDBConfig(server.URL))(context.Background(), &mcp.CallToolRequest{}, PromqlRangeQueryArgs{Query: "http_requests_total"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.IsError {
+			t.Fatal("expected an error result")
+		}
+		var got queryLimitError
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Error != queryLimitSeries || got.Estimate != 12000 || got.Limit != 5000 || got.Suggestion == "" {
+			t.Errorf("unexpected limit error: %+v", got)
+		}
+		if n := rangeQueries.Load(); n != 0 {
+			t.Errorf("range query should not run, ran %d time(s)", n)
+		}
+	})
+
+	t.Run("runs queries within the limit", func(t *testing.T) {
+		server, rangeQueries := rangeQueryLimitServer(t, "12", http.StatusOK)
+		result, _, err := NewPromqlRangeQueryHandler(server.Client(), testDBConfig(server.URL))(context.Background(), &mcp.CallToolRequest{}, PromqlRangeQueryArgs{Query: "http_requests_total"})
+		if err != nil || result.IsError || rangeQueries.Load() != 1 {
+			t.Errorf("expected the range query to run: err=%v result=%+v", err, result)
+		}
+	})
+
+	t.Run("failed probe lets the query run", func(t *testing.T) {
+		server, rangeQueries := rangeQueryLimitServer(t, "", http.StatusBadRequest)
+		_, _, err := NewPromqlRangeQueryHandler(server.Client(), testDBConfig(server.URL))(context.Background(), &mcp.CallToolRequest{}, PromqlRangeQueryArgs{Query: "scalar(up)"})
+		if err != nil || rangeQueries.Load() != 1 {
+			t.Errorf("expected the range query to run after a failed probe: err=%v", err)
+		}
+	})
+}
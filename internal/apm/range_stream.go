@@ -0,0 +1,181 @@
+package apm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Range result truncation reasons.
+const (
+	rangeTruncatedSeries = "series_limit"
+	rangeTruncatedPoints = "points_limit"
+)
+
+// RangeTruncation tells that a range result was cut short while it was
+// read, and what was kept.
+type RangeTruncation struct {
+	Reason         string `json:"reason"`
+	Limit          int64  `json:"limit"`
+	SeriesReturned int    `json:"series_returned"`
+	PointsReturned int64  `json:"points_returned"`
+	Suggestion     string `json:"suggestion"`
+}
+
+// rangeLimits caps what decodeRangeStream keeps; zero means no limit.
+type rangeLimits struct {
+	maxSeries int
+	maxPoints int64
+}
+
+// rangeStream holds the state of one decodeRangeStream call.
+type rangeStream struct {
+	dec    *json.Decoder
+	limits rangeLimits
+	series []PromRangeResponse
+	points int64
+	trunc  *RangeTruncation
+}
+
+// decodeRangeStream reads a range result from r, bare or in a Prometheus
+// envelope, one series at a time, so a large body is never held in memory
+// as a whole. Once limits are hit it stops reading and returns the series
+// kept so far, with the truncation in the warnings; warnings after the
+// result in an envelope are then not seen.
+func decodeRangeStream(r io.Reader, limits rangeLimits) ([]PromRangeResponse, PromWarnings, error) {
+	s := &rangeStream{dec: json.NewDecoder(r), limits: limits, series: []PromRangeResponse{}}
+	tok, err := s.dec.Token()
+	if err != nil {
+		return nil, PromWarnings{}, fmt.Errorf("failed to decode Prometheus response: %w", err)
+	}
+	var w PromWarnings
+	switch tok {
+	case json.Delim('['):
+		err = s.readSeries()
+	case json.Delim('{'):
+		w, err = s.readEnvelope()
+	default:
+		err = fmt.Errorf("failed to decode Prometheus response: unexpected %v", tok)
+	}
+	if err != nil {
+		return nil, w, err
+	}
+	if s.trunc != nil {
+		w.Partial, w.Truncation = true, s.trunc
+	}
+	return s.series, w, nil
+}
+
+// readSeries reads the elements of a result array whose opening bracket
+// has been consumed, stopping early at a limit.
+func (s *rangeStream) readSeries() error {
+	for s.dec.More() {
+		if s.limits.maxSeries > 0 && len(s.series) >= s.limits.maxSeries {
+			s.truncate(rangeTruncatedSeries, int64(s.limits.maxSeries))
+			return nil
+		}
+		var r PromRangeResponse
+		if err := s.dec.Decode(&r); err != nil {
+			return fmt.Errorf("failed to decode Prometheus response: %w", err)
+		}
+		if s.limits.maxPoints > 0 && s.points+int64(len(r.Values)) > s.limits.maxPoints {
+			if keep := s.limits.maxPoints - s.points; keep > 0 {
+				r.Values = r.Values[:keep]
+				s.series = append(s.series, r)
+				s.points += keep
+			}
+			s.truncate(rangeTruncatedPoints, s.limits.maxPoints)
+			return nil
+		}
+		s.series = append(s.series, r)
+		s.points += int64(len(r.Values))
+	}
+	if _, err := s.dec.Token(); err != nil { // closing bracket
+		return fmt.Errorf("failed to decode Prometheus response: %w", err)
+	}
+	return nil
+}
+
+func (s *rangeStream) truncate(reason string, limit int64) {
+	s.trunc = &RangeTruncation{
+		Reason:         reason,
+		Limit:          limit,
+		SeriesReturned: len(s.series),
+		PointsReturned: s.points,
+		Suggestion:     "aggregate with sum by (...) or topk(...), add label matchers, or narrow the time range",
+	}
+}
+
+// readEnvelope reads the fields of a Prometheus envelope whose opening
+// brace has been consumed, streaming data.result.
+func (s *rangeStream) readEnvelope() (PromWarnings, error) {
+	var env promEnvelope
+	for s.dec.More() {
+		key, err := s.dec.Token()
+		if err != nil {
+			return PromWarnings{}, fmt.Errorf("failed to decode Prometheus response: %w", err)
+		}
+		switch key {
+		case "data":
+			if err := s.readData(); err != nil {
+				return PromWarnings{}, err
+			}
+		case "status":
+			err = s.dec.Decode(&env.Status)
+		case "errorType":
+			err = s.dec.Decode(&env.ErrorType)
+		case "error":
+			err = s.dec.Decode(&env.Error)
+		case "warnings":
+			err = s.dec.Decode(&env.Warnings)
+		case "infos":
+			err = s.dec.Decode(&env.Infos)
+		default:
+			err = s.dec.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return PromWarnings{}, fmt.Errorf("failed to decode Prometheus response: %w", err)
+		}
+		if s.trunc != nil {
+			break
+		}
+	}
+	w := envelopeWarnings(env)
+	if env.Status == "error" {
+		return w, fmt.Errorf("prometheus query failed (%s): %s", env.ErrorType, env.Error)
+	}
+	return w, nil
+}
+
+// readData reads an envelope's data object, streaming its result array.
+func (s *rangeStream) readData() error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode Prometheus response: %w", err)
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("failed to decode Prometheus response: data is not an object")
+	}
+	for s.dec.More() {
+		key, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode Prometheus response: %w", err)
+		}
+		if key != "result" {
+			if err := s.dec.Decode(&json.RawMessage{}); err != nil {
+				return fmt.Errorf("failed to decode Prometheus response: %w", err)
+			}
+			continue
+		}
+		if tok, err := s.dec.Token(); err != nil || tok != json.Delim('[') {
+			return fmt.Errorf("failed to decode Prometheus response: result is not an array")
+		}
+		if err := s.readSeries(); err != nil || s.trunc != nil {
+			return err
+		}
+	}
+	if _, err := s.dec.Token(); err != nil { // closing brace
+		return fmt.Errorf("failed to decode Prometheus response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const threeSeries = `[
+	{"metric": {"job": "a"}, "values": [[1700000000, "1"], [1700000060, "2"]]},
+	{"metric": {"job": "b"}, "values": [[1700000000, "3"], [1700000060, "4"]]},
+	{"metric": {"job": "c"}, "values": [[1700000000, "5"], [1700000060, "6"]]}
+]`
+
+func TestDecodeRangeStream(t *testing.T) {
+	series, w, err := decodeRangeStream(strings.NewReader(threeSeries), rangeLimits{maxSeries: 10, maxPoints: 100})
+	if err != nil || len(series) != 3 || w.Partial || w.Truncation != nil {
+		t.Fatalf("within limits: %d series, %+v, %v", len(series), w, err)
+	}
+
+	series, w, err = decodeRangeStream(strings.NewReader(threeSeries), rangeLimits{maxSeries: 2})
+	if err != nil || len(series) != 2 || w.Truncation == nil || w.Truncation.Reason != rangeTruncatedSeries || !w.Partial {
+		t.Fatalf("series limit: %d series, %+v, %v", len(series), w, err)
+	}
+
+	// The point limit cuts the series it falls in.
+	series, w, err = decodeRangeStream(strings.NewReader(threeSeries), rangeLimits{maxPoints: 3})
+	if err != nil || len(series) != 2 || len(series[1].Values) != 1 || w.Truncation == nil || w.Truncation.PointsReturned != 3 {
+		t.Fatalf("points limit: %+v, %+v, %v", series, w, err)
+	}
+}
+
+func TestDecodeRangeStream_Envelope(t *testing.T) {
+	body := `{"status": "success", "data": {"resultType": "matrix", "result": ` + threeSeries + `}, "warnings": ["PromQL info: metric might not be a counter"]}`
+	series, w, err := decodeRangeStream(strings.NewReader(body), rangeLimits{})
+	if err != nil || len(series) != 3 || len(w.Warnings) != 1 || w.Partial {
+		t.Fatalf("envelope: %d series, %+v, %v", len(series), w, err)
+	}
+
+	// Truncating stops reading, so the warnings after the result are not seen.
+	series, w, err = decodeRangeStream(strings.NewReader(body), rangeLimits{maxSeries: 1})
+	if err != nil || len(series) != 1 || w.Truncation == nil || len(w.Warnings) != 0 {
+		t.Fatalf("truncated envelope: %d series, %+v, %v", len(series), w, err)
+	}
+
+	if _, _, err := decodeRangeStream(strings.NewReader(`{"status": "error", "errorType": "bad_data", "error": "parse error"}`), rangeLimits{}); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("error envelope: err = %v", err)
+	}
+	if _, _, err := decodeRangeStream(strings.NewReader(`[{"metric": {}, "values": [[1, "1"]]`), rangeLimits{}); err == nil {
+		t.Error("expected an error for a cut-off body")
+	}
+}
+
+func TestPromqlRangeHandler_Truncation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/prom_query_instant") {
+			io.WriteString(w, `[]`) // series-count guardrail probe
+			return
+		}
+		io.WriteString(w, threeSeries)
+	}))
+	defer server.Close()
+
+	cfg := testDBConfig(server.URL)
+	cfg.MaxQueryPoints = 4
+	result, _, err := NewPromqlRangeQueryHandler(server.Client(), cfg)(context.Background(), &mcp.CallToolRequest{}, PromqlRangeQueryArgs{Query: "up"})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	var got struct {
+		Result     []PromRangeResponse `json:"result"`
+		Partial    bool                `json:"partial"`
+		Truncation *RangeTruncation    `json:"truncation"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if len(got.Result) != 2 || !got.Partial || got.Truncation == nil || got.Truncation.Reason != rangeTruncatedPoints {
+		t.Errorf("unexpected response: %+v", got)
+	}
+}
@@ -0,0 +1,569 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/change_events"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- draft_rca tool ---
+
+type DraftRCAArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"Service affected by the incident (required)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Incident start in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"Incident end in RFC3339 format (default: now)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Incident window length in minutes when start and end are not both given (default: 60, minimum: 1)"`
+}
+
+const (
+	// rcaChangeLeadTime is how long before the incident change events are
+	// still considered candidate causes.
+	rcaChangeLeadTime = 30 * time.Minute
+	// rcaErrorDeltaPercent is the error percentage increase over baseline
+	// that counts as a regression, for the service and its dependencies.
+	rcaErrorDeltaPercent = 2.0
+	// rcaLatencyRatio is the p95 to baseline ratio that counts as a
+	// latency regression.
+	rcaLatencyRatio = 1.5
+	// rcaMaxDependencies caps the dependency anomalies reported.
+	rcaMaxDependencies = 5
+)
+
+// Confidence levels for suspected causes.
+const (
+	rcaConfidenceHigh   = "high"
+	rcaConfidenceMedium = "medium"
+	rcaConfidenceLow    = "low"
+)
+
+// RCAWindow is the incident window and the equally long baseline window
+// immediately before it that signals are compared against.
+type RCAWindow struct {
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	BaselineStart string `json:"baseline_start"`
+	BaselineEnd   string `json:"baseline_end"`
+}
+
+// RCATimelineEntry is one event on the incident timeline.
+type RCATimelineEntry struct {
+	Time   string `json:"time"`
+	Source string `json:"source"`
+	Event  string `json:"event"`
+}
+
+// RCASignal compares one service signal between the incident and baseline
+// windows. Nil values mean no data.
+type RCASignal struct {
+	Current   *float64 `json:"current,omitempty"`
+	Baseline  *float64 `json:"baseline,omitempty"`
+	Regressed bool     `json:"regressed"`
+}
+
+// RCAImpact summarizes how the service was affected.
+type RCAImpact struct {
+	ErrorPercent     RCASignal                     `json:"error_percent"`
+	LatencyP95       RCASignal                     `json:"latency_p95"`
+	ThroughputRPM    RCASignal                     `json:"throughput_rpm"`
+	Alerts           *alerting.ServiceAlertSummary `json:"alerts,omitempty"`
+	AffectedRequests *float64                      `json:"affected_requests,omitempty"`
+}
+
+// RCADependency is a downstream whose error rate rose during the incident.
+type RCADependency struct {
+	Name                 string   `json:"name"`
+	ErrorPercent         float64  `json:"error_percent"`
+	BaselineErrorPercent *float64 `json:"baseline_error_percent,omitempty"`
+}
+
+// RCACause is a suspected cause with the evidence behind it.
+type RCACause struct {
+	Kind       string   `json:"kind"`
+	Summary    string   `json:"summary"`
+	Confidence string   `json:"confidence"`
+	Evidence   []string `json:"evidence"`
+}
+
+// RCADraft is the response of draft_rca.
+type RCADraft struct {
+	ServiceName     string             `json:"service_name"`
+	Env             string             `json:"env"`
+	IncidentWindow  RCAWindow          `json:"incident_window"`
+	Summary         string             `json:"summary"`
+	Timeline        []RCATimelineEntry `json:"timeline"`
+	Impact          RCAImpact          `json:"impact"`
+	Dependencies    []RCADependency    `json:"dependency_anomalies"`
+	SuspectedCauses []RCACause         `json:"suspected_causes"`
+	NextSteps       []string           `json:"next_steps"`
+	Meta            *ResponseMeta      `json:"_meta,omitempty"`
+}
+
+// rcaChange is a change event seen around the incident.
+type rcaChange struct {
+	at    int64
+	name  string
+	state string
+	attrs string
+}
+
+// rcaInputs is everything a draft is assembled from.
+type rcaInputs struct {
+	serviceName  string
+	env          string
+	start, end   int64
+	values       map[string]*float64
+	dependencies []RCADependency
+	alerts       *alerting.ServiceAlertSummary
+	changes      []rcaChange
+}
+
+func NewDraftRCAHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, DraftRCAArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DraftRCAArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		durationMin := (endTime - startTime) / 60
+		if durationMin <= 0 {
+			durationMin = 1
+		}
+
+		env := resolveEnv(cfg, args.Env)
+		svc := fmt.Sprintf(`service_name="%s", env=~"%s"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+		serverSel := svc + `, span_kind="SPAN_KIND_SERVER"`
+
+		queries := map[string]string{}
+		for _, window := range []struct{ suffix, offset string }{
+			{"", ""},
+			{"_baseline", fmt.Sprintf(" offset %dm", durationMin)},
+		} {
+			queries["error_percent"+window.suffix] = fmt.Sprintf(
+				`100 * (sum(sum_over_time(trace_endpoint_count{%[1]s, status_code="STATUS_CODE_ERROR"}[%[2]dm]%[3]s)) or vector(0)) / sum(sum_over_time(trace_endpoint_count{%[1]s}[%[2]dm]%[3]s))`,
+				serverSel, durationMin, window.offset,
+			)
+			queries["throughput"+window.suffix] = fmt.Sprintf(
+				`sum(sum_over_time(trace_endpoint_count{%s}[%dm]%s)) / %d`,
+				serverSel, durationMin, window.offset, durationMin,
+			)
+			queries["latency"+window.suffix] = fmt.Sprintf(
+				`max(avg_over_time(trace_service_response_time{%s, quantile="p95"}[%dm]%s))`,
+				svc, durationMin, window.offset,
+			)
+		}
+		queries["errors"] = fmt.Sprintf(
+			`sum(sum_over_time(trace_endpoint_count{%s, status_code="STATUS_CODE_ERROR"}[%dm]))`,
+			serverSel, durationMin,
+		)
+		dependencyQuery := func(offset string) string {
+			by := "net_peer_name, db_system, messaging_system, rpc_system"
+			return fmt.Sprintf(
+				`100 * (sum by (%[1]s)(sum_over_time(trace_client_count{%[2]s, status_code="STATUS_CODE_ERROR"}[%[3]dm]%[4]s)) or (0 * sum by (%[1]s)(sum_over_time(trace_client_count{%[2]s}[%[3]dm]%[4]s)))) / sum by (%[1]s)(sum_over_time(trace_client_count{%[2]s}[%[3]dm]%[4]s))`,
+				by, svc, durationMin, offset,
+			)
+		}
+
+		var (
+			mu         sync.Mutex
+			values     = make(map[string]*float64, len(queries))
+			depSeries  = map[string]apiPromInstantResp{}
+			alerts     *alerting.ServiceAlertSummary
+			changes    []rcaChange
+			failures   []string
+			wg         sync.WaitGroup
+			changeFrom = startTime - int64(rcaChangeLeadTime/time.Second)
+		)
+		fail := func(format string, a ...any) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, fmt.Sprintf(format, a...))
+		}
+		for name, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+				if err != nil {
+					fail("%s query failed: %v", name, err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				values[name] = promScalar(series)
+			}()
+		}
+		for name, offset := range map[string]string{"current": "", "baseline": fmt.Sprintf(" offset %dm", durationMin)} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := fetchPromInstant(ctx, client, cfg, dependencyQuery(offset), endTime)
+				if err != nil {
+					fail("dependency %s query failed: %v", name, err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				depSeries[name] = series
+			}()
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			window := min(endTime-startTime, healthAlertsMaxWindow)
+			summary, err := alerting.SummarizeServiceAlerts(ctx, client, cfg, args.ServiceName, env, endTime, max(window, 1))
+			if err != nil {
+				fail("alerts lookup failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			alerts = &summary
+		}()
+		go func() {
+			defer wg.Done()
+			series, err := change_events.QueryChangeEvents(ctx, client, cfg, args.ServiceName, args.Env, "", changeFrom, endTime)
+			if err != nil {
+				fail("change events lookup failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			changes = rcaChangesFromSeries(series)
+		}()
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		draft := buildRCADraft(rcaInputs{
+			serviceName:  args.ServiceName,
+			env:          env,
+			start:        startTime,
+			end:          endTime,
+			values:       values,
+			dependencies: rcaDependencyAnomalies(depSeries["current"], depSeries["baseline"]),
+			alerts:       alerts,
+			changes:      changes,
+		})
+		sort.Strings(failures)
+		draft.Meta = buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+			fmt.Sprintf("trace_endpoint_count{%s}", serverSel),
+		), failures...)
+
+		jsonBytes, err := json.Marshal(draft)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
+
+// rcaChangesFromSeries turns change event series into one change per series,
+// dated by its first sample.
+func rcaChangesFromSeries(series []change_events.TimeSeries) []rcaChange {
+	var changes []rcaChange
+	for _, s := range series {
+		if len(s.Values) == 0 {
+			continue
+		}
+		change := rcaChange{
+			at:    int64(s.Values[0].Timestamp),
+			name:  firstNonEmpty(s.Metric["event_name"], s.Metric["event_type"], "change"),
+			state: s.Metric["event_state"],
+		}
+		var attrs []string
+		for _, key := range []string{"version", "author", "commit", "pr"} {
+			if v := s.Metric[key]; v != "" {
+				attrs = append(attrs, key+"="+v)
+			}
+		}
+		change.attrs = strings.Join(attrs, ", ")
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].at < changes[j].at })
+	return changes
+}
+
+// rcaDependencyAnomalies returns downstreams whose error percentage rose by
+// at least rcaErrorDeltaPercent over baseline, worst first.
+func rcaDependencyAnomalies(current, baseline apiPromInstantResp) []RCADependency {
+	baselineByName := map[string]float64{}
+	for _, s := range baseline {
+		v := parsePromValue(s.Value)
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			baselineByName[rcaDependencyName(s.Metric)] = v
+		}
+	}
+	var out []RCADependency
+	for _, s := range current {
+		v := parsePromValue(s.Value)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		name := rcaDependencyName(s.Metric)
+		dep := RCADependency{Name: name, ErrorPercent: round1(v)}
+		base, ok := baselineByName[name]
+		if ok {
+			b := round1(base)
+			dep.BaselineErrorPercent = &b
+		}
+		if v-base < rcaErrorDeltaPercent {
+			continue
+		}
+		out = append(out, dep)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ErrorPercent != out[j].ErrorPercent {
+			return out[i].ErrorPercent > out[j].ErrorPercent
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > rcaMaxDependencies {
+		out = out[:rcaMaxDependencies]
+	}
+	return out
+}
+
+func rcaDependencyName(labels map[string]string) string {
+	return firstNonEmpty(labels["net_peer_name"], labels["db_system"], labels["messaging_system"], labels["rpc_system"], "unknown")
+}
+
+// buildRCADraft assembles the draft from gathered inputs. It does no I/O.
+func buildRCADraft(in rcaInputs) RCADraft {
+	durationMin := max((in.end-in.start)/60, 1)
+	baselineStart := in.start - durationMin*60
+
+	draft := RCADraft{
+		ServiceName: in.serviceName,
+		Env:         in.env,
+		IncidentWindow: RCAWindow{
+			Start:         rcaTime(in.start),
+			End:           rcaTime(in.end),
+			BaselineStart: rcaTime(baselineStart),
+			BaselineEnd:   rcaTime(in.start),
+		},
+		Dependencies: in.dependencies,
+		Timeline:     []RCATimelineEntry{},
+		NextSteps:    []string{},
+	}
+
+	errPct := rcaSignal(in.values["error_percent"], in.values["error_percent_baseline"], func(cur, base float64) bool {
+		return cur-base >= rcaErrorDeltaPercent
+	})
+	latency := rcaSignal(in.values["latency"], in.values["latency_baseline"], func(cur, base float64) bool {
+		return base > 0 && cur/base >= rcaLatencyRatio
+	})
+	throughput := rcaSignal(in.values["throughput"], in.values["throughput_baseline"], func(cur, base float64) bool {
+		// A drop of half or more suggests requests are failing before they
+		// reach the service or clients are backing off.
+		return base > 0 && cur/base <= 0.5
+	})
+	draft.Impact = RCAImpact{
+		ErrorPercent:     errPct,
+		LatencyP95:       latency,
+		ThroughputRPM:    throughput,
+		Alerts:           in.alerts,
+		AffectedRequests: in.values["errors"],
+	}
+
+	// Timeline.
+	for _, c := range in.changes {
+		event := c.name
+		if c.state != "" {
+			event += " (" + c.state + ")"
+		}
+		if c.attrs != "" {
+			event += ": " + c.attrs
+		}
+		draft.Timeline = append(draft.Timeline, RCATimelineEntry{Time: rcaTime(c.at), Source: "change_event", Event: event})
+	}
+	draft.Timeline = append(draft.Timeline, RCATimelineEntry{Time: rcaTime(in.start), Source: "incident", Event: "incident window starts"})
+	if in.alerts != nil && in.alerts.Firing > 0 {
+		at := in.end
+		if in.alerts.FiringSince > 0 {
+			at = in.alerts.FiringSince
+		}
+		draft.Timeline = append(draft.Timeline, RCATimelineEntry{
+			Time:   rcaTime(at),
+			Source: "alert",
+			Event:  fmt.Sprintf("%d alert instance(s) firing: %s", in.alerts.Firing, strings.Join(in.alerts.Rules, ", ")),
+		})
+	}
+	draft.Timeline = append(draft.Timeline, RCATimelineEntry{Time: rcaTime(in.end), Source: "incident", Event: "incident window ends"})
+	sort.SliceStable(draft.Timeline, func(i, j int) bool { return draft.Timeline[i].Time < draft.Timeline[j].Time })
+
+	// Suspected causes, most likely first.
+	regressed := errPct.Regressed || latency.Regressed || throughput.Regressed
+	for _, c := range in.changes {
+		if c.at > in.end {
+			continue
+		}
+		confidence := rcaConfidenceMedium
+		if regressed && c.at >= in.start-int64(rcaChangeLeadTime/time.Second) && c.at <= in.start+durationMin*30 {
+			confidence = rcaConfidenceHigh
+		}
+		evidence := []string{fmt.Sprintf("%s at %s", c.name, rcaTime(c.at))}
+		if c.attrs != "" {
+			evidence = append(evidence, c.attrs)
+		}
+		draft.SuspectedCauses = append(draft.SuspectedCauses, RCACause{
+			Kind:       "change",
+			Summary:    fmt.Sprintf("%s shortly before or during the incident", c.name),
+			Confidence: confidence,
+			Evidence:   evidence,
+		})
+	}
+	for _, d := range in.dependencies {
+		confidence := rcaConfidenceMedium
+		if d.ErrorPercent >= 20 {
+			confidence = rcaConfidenceHigh
+		}
+		evidence := fmt.Sprintf("error rate %.1f%%", d.ErrorPercent)
+		if d.BaselineErrorPercent != nil {
+			evidence += fmt.Sprintf(" (baseline %.1f%%)", *d.BaselineErrorPercent)
+		}
+		draft.SuspectedCauses = append(draft.SuspectedCauses, RCACause{
+			Kind:       "dependency",
+			Summary:    fmt.Sprintf("calls to %s started failing", d.Name),
+			Confidence: confidence,
+			Evidence:   []string{evidence},
+		})
+	}
+	if len(draft.SuspectedCauses) == 0 {
+		switch {
+		case errPct.Regressed:
+			draft.SuspectedCauses = append(draft.SuspectedCauses, RCACause{
+				Kind:       "service",
+				Summary:    "errors originate in the service itself; no change event or failing dependency lines up",
+				Confidence: rcaConfidenceLow,
+				Evidence:   []string{rcaSignalEvidence("error rate", errPct, "%")},
+			})
+		case latency.Regressed:
+			draft.SuspectedCauses = append(draft.SuspectedCauses, RCACause{
+				Kind:       "service",
+				Summary:    "latency regression in the service itself; check saturation and slow database queries",
+				Confidence: rcaConfidenceLow,
+				Evidence:   []string{rcaSignalEvidence("p95 latency", latency, "")},
+			})
+		}
+	}
+	sort.SliceStable(draft.SuspectedCauses, func(i, j int) bool {
+		return rcaConfidenceRank(draft.SuspectedCauses[i].Confidence) > rcaConfidenceRank(draft.SuspectedCauses[j].Confidence)
+	})
+	if draft.SuspectedCauses == nil {
+		draft.SuspectedCauses = []RCACause{}
+	}
+
+	// Next steps point at the tools that confirm or rule out each lead.
+	if errPct.Regressed || len(in.dependencies) > 0 {
+		draft.NextSteps = append(draft.NextSteps, "get_exceptions and get_service_traces for the incident window to confirm the failing operations")
+	}
+	if latency.Regressed {
+		draft.NextSteps = append(draft.NextSteps, "get_service_performance_details and get_database_slow_queries to locate the slow path")
+	}
+	if len(in.changes) > 0 {
+		draft.NextSteps = append(draft.NextSteps, "get_change_events to review the change details, and compare before/after with get_apm_service_deviations")
+	}
+	draft.NextSteps = append(draft.NextSteps, "get_service_logs for the incident window to collect error messages for the write-up")
+
+	draft.Summary = rcaSummary(in, draft)
+	return draft
+}
+
+func rcaSignal(current, baseline *float64, regressed func(cur, base float64) bool) RCASignal {
+	s := RCASignal{Current: roundPtr(current), Baseline: roundPtr(baseline)}
+	if current != nil {
+		base := 0.0
+		if baseline != nil {
+			base = *baseline
+		}
+		s.Regressed = regressed(*current, base)
+	}
+	return s
+}
+
+func roundPtr(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	r := math.Round(*v*1000) / 1000
+	return &r
+}
+
+func rcaSignalEvidence(name string, s RCASignal, unit string) string {
+	if s.Current == nil {
+		return name + ": no data"
+	}
+	if s.Baseline == nil {
+		return fmt.Sprintf("%s %g%s (no baseline)", name, *s.Current, unit)
+	}
+	return fmt.Sprintf("%s %g%s (baseline %g%s)", name, *s.Current, unit, *s.Baseline, unit)
+}
+
+func rcaConfidenceRank(confidence string) int {
+	switch confidence {
+	case rcaConfidenceHigh:
+		return 2
+	case rcaConfidenceMedium:
+		return 1
+	}
+	return 0
+}
+
+func rcaSummary(in rcaInputs, draft RCADraft) string {
+	var parts []string
+	impact := draft.Impact
+	if impact.ErrorPercent.Regressed {
+		parts = append(parts, rcaSignalEvidence("error rate", impact.ErrorPercent, "%"))
+	}
+	if impact.LatencyP95.Regressed {
+		parts = append(parts, rcaSignalEvidence("p95 latency", impact.LatencyP95, ""))
+	}
+	if impact.ThroughputRPM.Regressed {
+		parts = append(parts, rcaSignalEvidence("throughput", impact.ThroughputRPM, " rpm"))
+	}
+	if in.alerts != nil && in.alerts.Firing > 0 {
+		parts = append(parts, fmt.Sprintf("%d alert instance(s) firing", in.alerts.Firing))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "no regression against the preceding window")
+	}
+	summary := fmt.Sprintf("%s: %s.", in.serviceName, strings.Join(parts, "; "))
+	if len(draft.SuspectedCauses) > 0 {
+		top := draft.SuspectedCauses[0]
+		summary += fmt.Sprintf(" Most likely cause (%s confidence): %s.", top.Confidence, top.Summary)
+	} else {
+		summary += " No suspected cause found."
+	}
+	return summary
+}
+
+func rcaTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,193 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/change_events"
+	"github.com/last9/last9-mcp-server/internal/constants"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestBuildRCADraft_DeploymentBeforeRegression(t *testing.T) {
+	start := int64(1700000000)
+	end := start + 3600
+	draft := buildRCADraft(rcaInputs{
+		serviceName: "checkout",
+		env:         "prod",
+		start:       start,
+		end:         end,
+		values: map[string]*float64{
+			"error_percent":          ptr(12),
+			"error_percent_baseline": ptr(0.5),
+			"latency":                ptr(0.2),
+			"latency_baseline":       ptr(0.2),
+		},
+		dependencies: []RCADependency{{Name: "payments", ErrorPercent: 8, BaselineErrorPercent: ptr(0)}},
+		alerts:       &alerting.ServiceAlertSummary{Firing: 1, Breach: 1, Rules: []string{"error rate"}, FiringSince: start + 300},
+		changes:      []rcaChange{{at: start - 600, name: "deployment", state: "start", attrs: "version=v42"}},
+	})
+
+	if !draft.Impact.ErrorPercent.Regressed || draft.Impact.LatencyP95.Regressed {
+		t.Errorf("impact = %+v, want error regression only", draft.Impact)
+	}
+	if len(draft.SuspectedCauses) != 2 {
+		t.Fatalf("suspected causes = %+v, want change and dependency", draft.SuspectedCauses)
+	}
+	if top := draft.SuspectedCauses[0]; top.Kind != "change" || top.Confidence != rcaConfidenceHigh {
+		t.Errorf("top cause = %+v, want high-confidence change", top)
+	}
+	if got := draft.SuspectedCauses[1]; got.Kind != "dependency" || got.Confidence != rcaConfidenceMedium {
+		t.Errorf("second cause = %+v, want medium-confidence dependency", got)
+	}
+
+	var sources []string
+	for _, e := range draft.Timeline {
+		sources = append(sources, e.Source)
+	}
+	if got, want := strings.Join(sources, ","), "change_event,incident,alert,incident"; got != want {
+		t.Errorf("timeline sources = %s, want %s", got, want)
+	}
+	if !strings.Contains(draft.Timeline[0].Event, "version=v42") {
+		t.Errorf("change timeline entry = %q, want version", draft.Timeline[0].Event)
+	}
+	if !strings.Contains(draft.Summary, "Most likely cause (high confidence)") {
+		t.Errorf("summary = %q", draft.Summary)
+	}
+	if draft.IncidentWindow.BaselineEnd != draft.IncidentWindow.Start {
+		t.Errorf("baseline should end where the incident starts: %+v", draft.IncidentWindow)
+	}
+}
+
+func TestBuildRCADraft_NoRegression(t *testing.T) {
+	draft := buildRCADraft(rcaInputs{
+		serviceName: "checkout",
+		start:       1700000000,
+		end:         1700003600,
+		values: map[string]*float64{
+			"error_percent":          ptr(0.5),
+			"error_percent_baseline": ptr(0.4),
+		},
+	})
+	if len(draft.SuspectedCauses) != 0 {
+		t.Errorf("suspected causes = %+v, want none", draft.SuspectedCauses)
+	}
+	if !strings.Contains(draft.Summary, "no regression") {
+		t.Errorf("summary = %q", draft.Summary)
+	}
+}
+
+func TestRCADependencyAnomalies(t *testing.T) {
+	series := func(values map[string]string) apiPromInstantResp {
+		var out apiPromInstantResp
+		for name, v := range values {
+			out = append(out, struct {
+				Metric map[string]string `json:"metric"`
+				Value  []any             `json:"value"`
+			}{Metric: map[string]string{"net_peer_name": name}, Value: []any{1700000000.0, v}})
+		}
+		return out
+	}
+	got := rcaDependencyAnomalies(
+		series(map[string]string{"payments": "30", "inventory": "1.5", "search": "5"}),
+		series(map[string]string{"payments": "0", "inventory": "1", "search": "4"}),
+	)
+	if len(got) != 1 || got[0].Name != "payments" || got[0].ErrorPercent != 30 {
+		t.Errorf("anomalies = %+v, want payments only", got)
+	}
+}
+
+func TestRCAChangesFromSeries(t *testing.T) {
+	got := rcaChangesFromSeries([]change_events.TimeSeries{
+		{Metric: map[string]string{"event_name": "config_change"}, Values: []change_events.TimeSeriesPoint{{Timestamp: 200, Value: 1}}},
+		{Metric: map[string]string{"event_name": "deployment", "event_state": "start", "version": "v1"}, Values: []change_events.TimeSeriesPoint{{Timestamp: 100, Value: 1}}},
+		{Metric: map[string]string{"event_name": "empty"}},
+	})
+	if len(got) != 2 || got[0].name != "deployment" || got[0].attrs != "version=v1" || got[1].at != 200 {
+		t.Errorf("changes = %+v", got)
+	}
+}
+
+func TestDraftRCAHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constants.EndpointAlertsMonitor:
+			json.NewEncoder(w).Encode(alerting.AlertsResponse{})
+			return
+		case constants.EndpointPromQuery:
+			json.NewEncoder(w).Encode([]map[string]any{{
+				"metric": map[string]string{"event_name": "deployment", "service_name": "api"},
+				"values": [][]any{{float64(time.Now().Add(-70 * time.Minute).Unix()), "1"}},
+			}})
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		value := func(v string) []map[string]any {
+			return []map[string]any{{"metric": map[string]string{}, "value": []any{1700000000, v}}}
+		}
+		var response []map[string]any
+		switch {
+		case strings.Contains(body.Query, "timestamp("):
+			response = value("1700000000")
+		case strings.Contains(body.Query, "trace_client_count"):
+			response = nil
+		case strings.Contains(body.Query, "100 *") && strings.Contains(body.Query, "offset"):
+			response = value("0.5")
+		case strings.Contains(body.Query, "100 *"):
+			response = value("15")
+		default:
+			response = value("10")
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	handler := NewDraftRCAHandler(server.Client(), testDBConfig(server.URL))
+	now := time.Now().UTC()
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, DraftRCAArgs{
+		ServiceName:  "api",
+		Env:          "prod",
+		StartTimeISO: now.Add(-60 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var draft RCADraft
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &draft); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !draft.Impact.ErrorPercent.Regressed {
+		t.Errorf("expected error regression, impact = %+v", draft.Impact)
+	}
+	if len(draft.SuspectedCauses) == 0 || draft.SuspectedCauses[0].Kind != "change" {
+		t.Errorf("suspected causes = %+v, want deployment first", draft.SuspectedCauses)
+	}
+	if draft.Meta == nil {
+		t.Fatal("expected _meta")
+	}
+	for _, caveat := range draft.Meta.Caveats {
+		if strings.Contains(caveat, "failed") {
+			t.Errorf("unexpected lookup failure: %s", caveat)
+		}
+	}
+}
+
+func TestDraftRCAHandler_RequiresServiceName(t *testing.T) {
+	handler := NewDraftRCAHandler(http.DefaultClient, testDBConfig("http://unused"))
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, DraftRCAArgs{}); err == nil {
+		t.Fatal("expected error when service_name is missing")
+	}
+}
@@ -0,0 +1,308 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- check_release_health tool ---
+
+type CheckReleaseHealthArgs struct {
+	ServiceName               string  `json:"service_name" jsonschema:"Name of the released service (required)"`
+	Env                       string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	DeployTimeISO             string  `json:"deploy_time_iso,omitempty" jsonschema:"When the release went out, in RFC3339/ISO8601 format (default: canary_minutes ago)"`
+	CanaryMinutes             float64 `json:"canary_minutes,omitempty" jsonschema:"Minutes after the deploy to judge the release on (default: 30, max: 360); cut short at now"`
+	BaselineMinutes           float64 `json:"baseline_minutes,omitempty" jsonschema:"Minutes before the deploy used as the baseline (default: 60, max: 1440)"`
+	MaxErrorRateIncrease      float64 `json:"max_error_rate_increase,omitempty" jsonschema:"Largest allowed increase of the error rate, in percentage points (default: 1)"`
+	MaxLatencyIncreasePercent float64 `json:"max_latency_increase_percent,omitempty" jsonschema:"Largest allowed p95 latency increase over the baseline, in percent (default: 20)"`
+	MinRequests               float64 `json:"min_requests,omitempty" jsonschema:"Fewest requests the canary window needs for a verdict (default: 100)"`
+}
+
+const (
+	defaultReleaseCanaryMinutes      = 30
+	maxReleaseCanaryMinutes          = 360
+	defaultReleaseBaselineMinutes    = 60
+	maxReleaseBaselineMinutes        = 1440
+	defaultReleaseMaxErrorIncrease   = 1.0
+	defaultReleaseMaxLatencyIncrease = 20.0
+	defaultReleaseMinRequests        = 100
+)
+
+// Release verdicts.
+const (
+	releaseVerdictPass         = "pass"
+	releaseVerdictFail         = "fail"
+	releaseVerdictInconclusive = "inconclusive"
+)
+
+// Release checks.
+const (
+	releaseCheckTraffic = "traffic"
+	releaseCheckErrors  = "error_rate"
+	releaseCheckLatency = "p95_latency"
+)
+
+// ReleaseCheck compares one signal between the baseline and canary windows.
+// Status is pass, fail or inconclusive (no data on either side).
+type ReleaseCheck struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Baseline *float64 `json:"baseline,omitempty"`
+	Canary   *float64 `json:"canary,omitempty"`
+	Change   *float64 `json:"change,omitempty"`
+	Limit    float64  `json:"limit"`
+	Reason   string   `json:"reason"`
+}
+
+// ReleaseWindow is a time window in RFC3339.
+type ReleaseWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ReleaseHealth is the response of check_release_health.
+type ReleaseHealth struct {
+	ServiceName    string         `json:"service_name"`
+	Env            string         `json:"env"`
+	Verdict        string         `json:"verdict"`
+	ShouldRollBack bool           `json:"should_roll_back"`
+	DeployTime     string         `json:"deploy_time"`
+	Baseline       ReleaseWindow  `json:"baseline_window"`
+	Canary         ReleaseWindow  `json:"canary_window"`
+	Checks         []ReleaseCheck `json:"checks"`
+	Meta           *ResponseMeta  `json:"_meta,omitempty"`
+}
+
+// releaseThresholds are the limits a canary is judged against.
+type releaseThresholds struct {
+	maxErrorIncrease   float64 // percentage points
+	maxLatencyIncrease float64 // percent over baseline
+	minRequests        float64
+}
+
+// releaseSignals are the measurements of one window; nil means no data.
+type releaseSignals struct {
+	requests     *float64
+	errorPercent *float64
+	latencyP95   *float64
+}
+
+// judgeRelease compares canary against baseline. A failed check fails the
+// release; otherwise missing data or too little canary traffic makes it
+// inconclusive. It is pure so the rules can be tested without a backend.
+func judgeRelease(baseline, canary releaseSignals, limits releaseThresholds) (string, []ReleaseCheck) {
+	traffic := ReleaseCheck{Name: releaseCheckTraffic, Baseline: baseline.requests, Canary: canary.requests, Limit: limits.minRequests}
+	switch {
+	case canary.requests == nil || *canary.requests < limits.minRequests:
+		traffic.Status = releaseVerdictInconclusive
+		traffic.Reason = fmt.Sprintf("fewer than %.0f requests in the canary window", limits.minRequests)
+	default:
+		traffic.Status = releaseVerdictPass
+		traffic.Reason = fmt.Sprintf("%.0f requests in the canary window", *canary.requests)
+	}
+
+	errorsCheck := ReleaseCheck{Name: releaseCheckErrors, Baseline: baseline.errorPercent, Canary: canary.errorPercent, Limit: limits.maxErrorIncrease}
+	if baseline.errorPercent == nil || canary.errorPercent == nil {
+		errorsCheck.Status = releaseVerdictInconclusive
+		errorsCheck.Reason = "no error rate in the baseline or canary window"
+	} else {
+		change := round1(*canary.errorPercent - *baseline.errorPercent)
+		errorsCheck.Change = &change
+		errorsCheck.Status = releaseVerdictPass
+		if change > limits.maxErrorIncrease {
+			errorsCheck.Status = releaseVerdictFail
+		}
+		errorsCheck.Reason = fmt.Sprintf("error rate %.2f%% vs %.2f%% before the deploy (%+.1f points, limit +%.1f)", *canary.errorPercent, *baseline.errorPercent, change, limits.maxErrorIncrease)
+	}
+
+	latency := ReleaseCheck{Name: releaseCheckLatency, Baseline: baseline.latencyP95, Canary: canary.latencyP95, Limit: limits.maxLatencyIncrease}
+	if baseline.latencyP95 == nil || canary.latencyP95 == nil || *baseline.latencyP95 <= 0 {
+		latency.Status = releaseVerdictInconclusive
+		latency.Reason = "no p95 latency in the baseline or canary window"
+	} else {
+		change := round1(100 * (*canary.latencyP95 - *baseline.latencyP95) / *baseline.latencyP95)
+		latency.Change = &change
+		latency.Status = releaseVerdictPass
+		if change > limits.maxLatencyIncrease {
+			latency.Status = releaseVerdictFail
+		}
+		latency.Reason = fmt.Sprintf("p95 latency %.4g vs %.4g before the deploy (%+.1f%%, limit +%.0f%%)", *canary.latencyP95, *baseline.latencyP95, change, limits.maxLatencyIncrease)
+	}
+
+	checks := []ReleaseCheck{traffic, errorsCheck, latency}
+	verdict := releaseVerdictPass
+	for _, c := range checks {
+		if c.Status == releaseVerdictFail {
+			return releaseVerdictFail, checks
+		}
+		if c.Status == releaseVerdictInconclusive {
+			verdict = releaseVerdictInconclusive
+		}
+	}
+	return verdict, checks
+}
+
+// releaseWindows resolves the deploy time and the baseline and canary
+// windows, in Unix seconds, from args with defaults applied.
+func releaseWindows(args CheckReleaseHealthArgs, now time.Time) (deploy, baselineStart, canaryEnd int64, err error) {
+	canary := time.Duration(args.CanaryMinutes * float64(time.Minute))
+	baseline := time.Duration(args.BaselineMinutes * float64(time.Minute))
+	deployTime := now.Add(-canary)
+	if args.DeployTimeISO != "" {
+		if deployTime, err = utils.ParseToolTimestamp(args.DeployTimeISO); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid deploy_time_iso: %w", err)
+		}
+	}
+	if !deployTime.Before(now) {
+		return 0, 0, 0, fmt.Errorf("deploy_time_iso must be in the past")
+	}
+	end := deployTime.Add(canary)
+	if end.After(now) {
+		end = now
+	}
+	return deployTime.Unix(), deployTime.Add(-baseline).Unix(), end.Unix(), nil
+}
+
+func NewCheckReleaseHealthHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, CheckReleaseHealthArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args CheckReleaseHealthArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		if args.CanaryMinutes == 0 {
+			args.CanaryMinutes = defaultReleaseCanaryMinutes
+		}
+		if args.BaselineMinutes == 0 {
+			args.BaselineMinutes = defaultReleaseBaselineMinutes
+		}
+		if args.CanaryMinutes < 1 || args.CanaryMinutes > maxReleaseCanaryMinutes {
+			return nil, nil, fmt.Errorf("canary_minutes must be between 1 and %d", maxReleaseCanaryMinutes)
+		}
+		if args.BaselineMinutes < 1 || args.BaselineMinutes > maxReleaseBaselineMinutes {
+			return nil, nil, fmt.Errorf("baseline_minutes must be between 1 and %d", maxReleaseBaselineMinutes)
+		}
+		limits := releaseThresholds{
+			maxErrorIncrease:   args.MaxErrorRateIncrease,
+			maxLatencyIncrease: args.MaxLatencyIncreasePercent,
+			minRequests:        args.MinRequests,
+		}
+		if limits.maxErrorIncrease == 0 {
+			limits.maxErrorIncrease = defaultReleaseMaxErrorIncrease
+		}
+		if limits.maxLatencyIncrease == 0 {
+			limits.maxLatencyIncrease = defaultReleaseMaxLatencyIncrease
+		}
+		if limits.minRequests == 0 {
+			limits.minRequests = defaultReleaseMinRequests
+		}
+
+		deployTime, baselineStart, canaryEnd, err := releaseWindows(args, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		env := resolveEnv(cfg, args.Env)
+		svc := fmt.Sprintf(`service_name="%s", env=~"%s"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+		serverSel := svc + `, span_kind="SPAN_KIND_SERVER"`
+
+		// Each window is one instant query at its end over its length.
+		windowQueries := func(minutes int64) map[string]string {
+			minutes = max(minutes, 1)
+			return map[string]string{
+				releaseCheckTraffic: fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%s}[%dm]))`, serverSel, minutes),
+				releaseCheckErrors: fmt.Sprintf(
+					`100 * (sum(sum_over_time(trace_endpoint_count{%[1]s, status_code="STATUS_CODE_ERROR"}[%[2]dm])) or vector(0)) / sum(sum_over_time(trace_endpoint_count{%[1]s}[%[2]dm]))`,
+					serverSel, minutes,
+				),
+				releaseCheckLatency: fmt.Sprintf(`max(avg_over_time(trace_service_response_time{%s, quantile="p95"}[%dm]))`, svc, minutes),
+			}
+		}
+		windows := []struct {
+			at      int64
+			queries map[string]string
+			values  map[string]*float64
+		}{
+			{at: deployTime, queries: windowQueries((deployTime - baselineStart) / 60)},
+			{at: canaryEnd, queries: windowQueries((canaryEnd - deployTime) / 60)},
+		}
+
+		var (
+			mu       sync.Mutex
+			failures []string
+			wg       sync.WaitGroup
+		)
+		for i := range windows {
+			w := &windows[i]
+			w.values = make(map[string]*float64, len(w.queries))
+			for name, query := range w.queries {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					series, err := fetchPromInstant(ctx, client, cfg, query, w.at)
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						failures = append(failures, fmt.Sprintf("%s query failed: %v", name, err))
+						return
+					}
+					w.values[name] = promScalar(series)
+				}()
+			}
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		signals := func(values map[string]*float64) releaseSignals {
+			return releaseSignals{requests: values[releaseCheckTraffic], errorPercent: values[releaseCheckErrors], latencyP95: values[releaseCheckLatency]}
+		}
+		verdict, checks := judgeRelease(signals(windows[0].values), signals(windows[1].values), limits)
+
+		sort.Strings(failures)
+		caveats := failures
+		if canaryEnd-deployTime < int64(args.CanaryMinutes*60) {
+			caveats = append(caveats, fmt.Sprintf("canary window cut short at now: %d of %.0f minutes since the deploy", (canaryEnd-deployTime)/60, args.CanaryMinutes))
+		}
+
+		window := func(start, end int64) ReleaseWindow {
+			return ReleaseWindow{Start: time.Unix(start, 0).UTC().Format(time.RFC3339), End: time.Unix(end, 0).UTC().Format(time.RFC3339)}
+		}
+		result := ReleaseHealth{
+			ServiceName:    args.ServiceName,
+			Env:            env,
+			Verdict:        verdict,
+			ShouldRollBack: verdict == releaseVerdictFail,
+			DeployTime:     time.Unix(deployTime, 0).UTC().Format(time.RFC3339),
+			Baseline:       window(baselineStart, deployTime),
+			Canary:         window(deployTime, canaryEnd),
+			Checks:         checks,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, deployTime, canaryEnd,
+				fmt.Sprintf("trace_endpoint_count{%s}", serverSel),
+			), caveats...),
+		}
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(baselineStart*1000, canaryEnd*1000, args.ServiceName, env, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,59 @@
+package apm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJudgeRelease(t *testing.T) {
+	limits := releaseThresholds{maxErrorIncrease: 1, maxLatencyIncrease: 20, minRequests: 100}
+	baseline := releaseSignals{requests: ptr(5000), errorPercent: ptr(0.5), latencyP95: ptr(0.2)}
+
+	verdict, checks := judgeRelease(baseline, releaseSignals{requests: ptr(2000), errorPercent: ptr(1.2), latencyP95: ptr(0.22)}, limits)
+	if verdict != releaseVerdictPass {
+		t.Errorf("verdict = %s, want pass: %+v", verdict, checks)
+	}
+	if c := checks[2]; c.Change == nil || *c.Change != 10 {
+		t.Errorf("latency check = %+v, want a 10%% change", c)
+	}
+
+	verdict, checks = judgeRelease(baseline, releaseSignals{requests: ptr(2000), errorPercent: ptr(3), latencyP95: ptr(0.2)}, limits)
+	if verdict != releaseVerdictFail || checks[1].Status != releaseVerdictFail {
+		t.Errorf("error spike: verdict = %s, checks = %+v", verdict, checks)
+	}
+
+	// A failing check wins over too little traffic.
+	verdict, _ = judgeRelease(baseline, releaseSignals{requests: ptr(10), errorPercent: ptr(0.5), latencyP95: ptr(0.5)}, limits)
+	if verdict != releaseVerdictFail {
+		t.Errorf("latency regression with low traffic: verdict = %s, want fail", verdict)
+	}
+
+	verdict, checks = judgeRelease(baseline, releaseSignals{requests: ptr(10), errorPercent: ptr(0.5), latencyP95: ptr(0.2)}, limits)
+	if verdict != releaseVerdictInconclusive || checks[0].Status != releaseVerdictInconclusive {
+		t.Errorf("low traffic: verdict = %s, checks = %+v", verdict, checks)
+	}
+
+	verdict, _ = judgeRelease(releaseSignals{}, releaseSignals{requests: ptr(2000)}, limits)
+	if verdict != releaseVerdictInconclusive {
+		t.Errorf("no data: verdict = %s, want inconclusive", verdict)
+	}
+}
+
+func TestReleaseWindows(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	deploy, baselineStart, canaryEnd, err := releaseWindows(CheckReleaseHealthArgs{
+		DeployTimeISO:   "2026-05-01T11:50:00Z",
+		CanaryMinutes:   30,
+		BaselineMinutes: 60,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deploy != now.Add(-10*time.Minute).Unix() || baselineStart != now.Add(-70*time.Minute).Unix() || canaryEnd != now.Unix() {
+		t.Errorf("windows = %d %d %d, want the canary cut short at now", deploy, baselineStart, canaryEnd)
+	}
+
+	if _, _, _, err := releaseWindows(CheckReleaseHealthArgs{DeployTimeISO: "2026-05-01T12:30:00Z", CanaryMinutes: 30, BaselineMinutes: 60}, now); err == nil {
+		t.Error("expected an error for a deploy time in the future")
+	}
+}
@@ -0,0 +1,214 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/chart"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Chart image formats accepted by render_chart.
+const (
+	chartFormatPNG = "png"
+	chartFormatSVG = "svg"
+)
+
+// RenderChartArgs represents the input arguments for the render_chart tool
+type RenderChartArgs struct {
+	Query           string  `json:"query" jsonschema:"PromQL query to chart (required)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2024-06-01T12:00:00Z). Optional when lookback_minutes is provided."`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2024-06-01T13:00:00Z). Defaults to now when omitted."`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1). Use for relative windows like last 30 minutes."`
+	Datasource      string  `json:"datasource,omitempty" jsonschema:"Name of the datasource to query. If omitted, uses the default configured datasource."`
+	Title           string  `json:"title,omitempty" jsonschema:"Chart title (default: the query)"`
+	Format          string  `json:"format,omitempty" jsonschema:"Image format: png has the widest client support but no text on the image (default), svg adds a title, axis labels and a legend (one of: png, svg)"`
+}
+
+// ChartSeriesSummary describes one drawn series so the image can be read
+// without text rendered into it.
+type ChartSeriesSummary struct {
+	Label string   `json:"label"`
+	Color string   `json:"color"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Last  *float64 `json:"last,omitempty"`
+}
+
+// ChartSummary is the text content returned next to the chart image.
+type ChartSummary struct {
+	Query         string               `json:"query"`
+	Format        string               `json:"format"`
+	StartTime     string               `json:"start_time"`
+	EndTime       string               `json:"end_time"`
+	Series        []ChartSeriesSummary `json:"series"`
+	OmittedSeries int                  `json:"omitted_series,omitempty"`
+	Note          string               `json:"note,omitempty"`
+}
+
+// NewRenderChartHandler runs a PromQL range query and returns the series as
+// a line chart image. The same window and series guardrails as
+// prometheus_range_query apply.
+func NewRenderChartHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, RenderChartArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args RenderChartArgs) (*mcp.CallToolResult, any, error) {
+		if args.Query == "" {
+			return nil, nil, fmt.Errorf("query is required")
+		}
+		format := strings.ToLower(strings.TrimSpace(args.Format))
+		if format != chartFormatSVG {
+			format = chartFormatPNG
+		}
+
+		startTimeParam, endTimeParam, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		queryCfg, err := resolveDatasourceCfg(cfg, args.Datasource)
+		if err != nil {
+			return nil, nil, err
+		}
+		if limitErr := checkRangeQueryWindow(cfg, startTimeParam, endTimeParam); limitErr != nil {
+			return limitErr.result(), nil, nil
+		}
+		limitErr, err := checkRangeQuerySeries(ctx, client, queryCfg, args.Query, endTimeParam)
+		if err != nil {
+			return nil, nil, err
+		}
+		if limitErr != nil {
+			return limitErr.result(), nil, nil
+		}
+
+		httpResp, err := utils.MakePromRangeAPIQuery(ctx, client, args.Query, startTimeParam, endTimeParam, queryCfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer httpResp.Body.Close()
+		if httpResp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("failed to execute Prometheus range query: %s", httpResp.Status)
+		}
+		body, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		data, err := decodeCompactRange(body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		title := args.Title
+		if title == "" {
+			title = args.Query
+		}
+		c, summary := buildChart(title, data)
+		summary.Query = args.Query
+		summary.Format = format
+		summary.StartTime = time.Unix(startTimeParam, 0).UTC().Format(time.RFC3339)
+		summary.EndTime = time.Unix(endTimeParam, 0).UTC().Format(time.RFC3339)
+
+		var (
+			image    []byte
+			mimeType string
+		)
+		if format == chartFormatSVG {
+			image, err = chart.SVG(c)
+			mimeType = "image/svg+xml"
+		} else {
+			image, err = chart.PNG(c)
+			mimeType = "image/png"
+			summary.Note = "The PNG has no text: the x axis spans start_time to end_time (UTC) and series colors are listed above."
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		text, err := json.Marshal(summary)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.ImageContent{Data: image, MIMEType: mimeType},
+				&mcp.TextContent{Text: string(text)},
+			},
+		}, nil, nil
+	}
+}
+
+// buildChart keeps the series with the highest peaks, up to one per palette
+// color, and summarizes each drawn series.
+func buildChart(title string, data CompactRangeResult) (chart.Chart, ChartSummary) {
+	type ranked struct {
+		label          string
+		values         []*float64
+		min, max, last *float64
+	}
+	all := make([]ranked, 0, len(data.Series))
+	for _, s := range data.Series {
+		r := ranked{label: seriesLabel(s.Metric), values: s.Values}
+		for _, v := range s.Values {
+			if v == nil {
+				continue
+			}
+			if r.min == nil || *v < *r.min {
+				r.min = v
+			}
+			if r.max == nil || *v > *r.max {
+				r.max = v
+			}
+			r.last = v
+		}
+		all = append(all, r)
+	}
+	peak := func(r ranked) float64 {
+		if r.max == nil {
+			return math.Inf(-1)
+		}
+		return *r.max
+	}
+	sort.SliceStable(all, func(i, j int) bool { return peak(all[i]) > peak(all[j]) })
+
+	c := chart.Chart{Title: title, Timestamps: data.Timestamps}
+	summary := ChartSummary{Series: []ChartSeriesSummary{}}
+	for i, r := range all {
+		if i == len(chart.Palette) {
+			summary.OmittedSeries = len(all) - i
+			break
+		}
+		c.Series = append(c.Series, chart.Series{Label: r.label, Values: r.values})
+		summary.Series = append(summary.Series, ChartSeriesSummary{
+			Label: r.label,
+			Color: chart.Hex(chart.Palette[i]),
+			Min:   r.min,
+			Max:   r.max,
+			Last:  r.last,
+		})
+	}
+	return c, summary
+}
+
+// seriesLabel formats a metric in PromQL selector notation, e.g.
+// http_requests_total{code="500",service="api"}.
+func seriesLabel(metric map[string]string) string {
+	keys := make([]string, 0, len(metric))
+	for k := range metric {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, metric[k]))
+	}
+	return metric["__name__"] + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,83 @@
+package apm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/chart"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestBuildChart_KeepsHighestPeaks(t *testing.T) {
+	data := CompactRangeResult{Timestamps: []int64{1, 2}}
+	for i := 0; i < len(chart.Palette)+2; i++ {
+		data.Series = append(data.Series, CompactSeries{
+			Metric: map[string]string{"pod": fmt.Sprintf("p%02d", i)},
+			Values: []*float64{ptr(float64(i)), nil},
+		})
+	}
+	c, summary := buildChart("t", data)
+	if len(c.Series) != len(chart.Palette) || summary.OmittedSeries != 2 {
+		t.Fatalf("drawn %d series, omitted %d", len(c.Series), summary.OmittedSeries)
+	}
+	if top := summary.Series[0]; top.Label != `{pod="p11"}` || *top.Max != 11 || *top.Last != 11 {
+		t.Errorf("top series = %+v", top)
+	}
+}
+
+func TestSeriesLabel(t *testing.T) {
+	got := seriesLabel(map[string]string{"__name__": "up", "job": "api", "env": "prod"})
+	if got != `up{env="prod",job="api"}` {
+		t.Errorf("seriesLabel = %s", got)
+	}
+}
+
+func TestRenderChartHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/prom_query_instant") {
+			_, _ = w.Write([]byte(`[{"metric":{},"value":[1700000000,"2"]}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"metric":{"service":"a"},"values":[[1700000000,"1"],[1700000060,"2"]]},
+			{"metric":{"service":"b"},"values":[[1700000000,"5"],[1700000060,"NaN"]]}
+		]`))
+	}))
+	defer server.Close()
+	handler := NewRenderChartHandler(server.Client(), testDBConfig(server.URL))
+
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, RenderChartArgs{Query: "rate(x[5m])"})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	image, ok := result.Content[0].(*mcp.ImageContent)
+	if !ok || image.MIMEType != "image/png" {
+		t.Fatalf("first content = %#v, want png image", result.Content[0])
+	}
+	if _, err := png.Decode(bytes.NewReader(image.Data)); err != nil {
+		t.Errorf("invalid png: %v", err)
+	}
+	var summary ChartSummary
+	if err := json.Unmarshal([]byte(result.Content[1].(*mcp.TextContent).Text), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Series) != 2 || summary.Series[0].Label != `{service="b"}` || summary.Note == "" {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, RenderChartArgs{Query: "rate(x[5m])", Format: "svg", Title: "Rate"})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if image := result.Content[0].(*mcp.ImageContent); image.MIMEType != "image/svg+xml" || !bytes.Contains(image.Data, []byte(">Rate<")) {
+		t.Errorf("svg content = %s", image.Data)
+	}
+}
@@ -0,0 +1,417 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_runtime_metrics tool ---
+
+type GetRuntimeMetricsArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"(Required) Name of the service to report runtime metrics for (e.g. checkout)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	View            string  `json:"view,omitempty" jsonschema:"Name of a saved view (see save_view) that supplies arguments such as service_name, env and the time range. Arguments given in the call override it (optional)"`
+	Runtime         string  `json:"runtime,omitempty" jsonschema:"Language runtime; detected from the process_runtime_* metrics the service emits when omitted (one of: jvm, go, python)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+// RuntimeSignal summarises one runtime metric over the window.
+type RuntimeSignal struct {
+	Name      string     `json:"name"`
+	Unit      string     `json:"unit"`
+	Current   float64    `json:"current"`
+	Avg       float64    `json:"avg"`
+	Max       float64    `json:"max"`
+	Sparkline []*float64 `json:"sparkline"`
+	// LatencyCorrelation is the Pearson correlation with the service's p95
+	// latency, nil when there were too few overlapping samples.
+	LatencyCorrelation *float64 `json:"latency_correlation,omitempty"`
+}
+
+// runtimeSignalQuery is the PromQL for one runtime signal. Query takes the
+// label filter and the rate window in minutes as %[1]s and %[2]d. Metric names
+// use __name__ regexes because OTel-to-Prometheus exporters differ on whether
+// they append unit suffixes such as _bytes.
+type runtimeSignalQuery struct {
+	Name  string
+	Unit  string
+	Query string
+}
+
+// Runtime signal names shared by the hints.
+const (
+	runtimeHeapUsed    = "heap_used_bytes"
+	runtimeHeapLimit   = "heap_limit_bytes"
+	runtimeGCPause     = "gc_pause_avg_ms"
+	runtimeGCRate      = "gc_per_min"
+	runtimeThreads     = "threads"
+	runtimeGoroutines  = "goroutines"
+	runtimeMemoryRSS   = "memory_rss_bytes"
+	runtimeCorrelation = 0.7 // |r| at or above this is reported as a strong correlation
+	runtimeMinOverlap  = 5   // overlapping samples needed before a correlation is computed
+)
+
+// runtimeSignals lists the signals reported for each supported runtime,
+// following the OpenTelemetry process.runtime.* conventions.
+var runtimeSignals = map[string][]runtimeSignalQuery{
+	"jvm": {
+		{runtimeHeapUsed, "bytes", `sum({__name__=~"process_runtime_jvm_memory_usage(_bytes)?", %[1]s, type="heap"})`},
+		{runtimeHeapLimit, "bytes", `sum({__name__=~"process_runtime_jvm_memory_limit(_bytes)?", %[1]s, type="heap"})`},
+		{runtimeGCPause, "ms", `sum(rate({__name__=~"process_runtime_jvm_gc_duration(_milliseconds)?_sum", %[1]s}[%[2]dm])) / sum(rate({__name__=~"process_runtime_jvm_gc_duration(_milliseconds)?_count", %[1]s}[%[2]dm]))`},
+		{runtimeGCRate, "per_min", `sum(rate({__name__=~"process_runtime_jvm_gc_duration(_milliseconds)?_count", %[1]s}[%[2]dm])) * 60`},
+		{runtimeThreads, "count", `sum(process_runtime_jvm_threads_count{%[1]s})`},
+	},
+	"go": {
+		{runtimeHeapUsed, "bytes", `sum({__name__=~"process_runtime_go_mem_heap_alloc(_bytes)?", %[1]s})`},
+		{runtimeGCPause, "ms", `sum(rate(process_runtime_go_gc_pause_ns_sum{%[1]s}[%[2]dm])) / sum(rate(process_runtime_go_gc_pause_ns_count{%[1]s}[%[2]dm])) / 1e6`},
+		{runtimeGCRate, "per_min", `sum(rate({__name__=~"process_runtime_go_gc_count(_total)?", %[1]s}[%[2]dm])) * 60`},
+		{runtimeGoroutines, "count", `sum(process_runtime_go_goroutines{%[1]s})`},
+	},
+	"python": {
+		{runtimeMemoryRSS, "bytes", `sum({__name__=~"process_runtime_cpython_memory(_bytes)?", %[1]s, type="rss"})`},
+		{runtimeGCRate, "per_min", `sum(rate({__name__=~"process_runtime_cpython_gc_count(_total)?", %[1]s}[%[2]dm])) * 60`},
+		{runtimeThreads, "count", `sum(process_runtime_cpython_thread_count{%[1]s})`},
+	},
+}
+
+// runtimeMetricPrefixes maps the process_runtime_<name>_ metric prefix to the
+// runtime argument value, in detection order.
+var runtimeMetricPrefixes = []struct{ prefix, runtime string }{
+	{"process_runtime_jvm_", "jvm"},
+	{"process_runtime_go_", "go"},
+	{"process_runtime_cpython_", "python"},
+}
+
+// runtimeMetricPrefix returns the metric name prefix of a runtime.
+func runtimeMetricPrefix(runtime string) string {
+	for _, p := range runtimeMetricPrefixes {
+		if p.runtime == runtime {
+			return p.prefix
+		}
+	}
+	return ""
+}
+
+// detectRuntime returns the runtime whose process_runtime_* metrics the
+// service emitted in the window, or "" when it emitted none.
+func detectRuntime(ctx context.Context, client utils.HTTPClient, cfg models.Config, filter string, durationMin, endTime int64) (string, error) {
+	query := fmt.Sprintf(
+		`count by (__name__)(last_over_time({__name__=~"process_runtime_(jvm|go|cpython)_.+", %s}[%dm]))`,
+		filter, durationMin,
+	)
+	series, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range runtimeMetricPrefixes {
+		for _, point := range series {
+			if strings.HasPrefix(point.Metric["__name__"], p.prefix) {
+				return p.runtime, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// fetchPromRangeSeries runs a range query expected to return a single series
+// and returns its points; no series yields nil.
+func fetchPromRangeSeries(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, start, end int64) ([]TimeSeriesPoint, error) {
+	resp, err := utils.MakePromRangeAPIQuery(ctx, client, query, start, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	series, err := parsePromTimeSeries(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, nil
+	}
+	return series[0].Values, nil
+}
+
+// summariseRuntimeSignal reduces points to a RuntimeSignal, skipping NaN
+// samples such as a GC pause average over a window without collections.
+func summariseRuntimeSignal(q runtimeSignalQuery, points []TimeSeriesPoint, start, end int64) (RuntimeSignal, bool) {
+	s := RuntimeSignal{Name: q.Name, Unit: q.Unit, Sparkline: sparkline(points, start, end, sparklineBuckets)}
+	var sum float64
+	n := 0
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		if n == 0 || p.Value > s.Max {
+			s.Max = p.Value
+		}
+		sum += p.Value
+		s.Current = p.Value
+		n++
+	}
+	if n == 0 {
+		return s, false
+	}
+	s.Avg = round3(sum / float64(n))
+	s.Max = round3(s.Max)
+	s.Current = round3(s.Current)
+	return s, true
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// pearson returns the correlation of the samples of a and b that share a
+// timestamp, or nil when fewer than runtimeMinOverlap do or either side is
+// constant.
+func pearson(a, b []TimeSeriesPoint) *float64 {
+	byTime := make(map[uint64]float64, len(b))
+	for _, p := range b {
+		if !math.IsNaN(p.Value) && !math.IsInf(p.Value, 0) {
+			byTime[p.Timestamp] = p.Value
+		}
+	}
+	var xs, ys []float64
+	for _, p := range a {
+		y, ok := byTime[p.Timestamp]
+		if !ok || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		xs = append(xs, p.Value)
+		ys = append(ys, y)
+	}
+	if len(xs) < runtimeMinOverlap {
+		return nil
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(ys))
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return nil
+	}
+	r := math.Round(cov/math.Sqrt(vx*vy)*100) / 100
+	return &r
+}
+
+// runtimeHints turns the signals into short, actionable observations about
+// memory pressure and its relation to latency.
+func runtimeHints(signals []RuntimeSignal) []string {
+	byName := make(map[string]RuntimeSignal, len(signals))
+	for _, s := range signals {
+		byName[s.Name] = s
+	}
+
+	var hints []string
+	if used, ok := byName[runtimeHeapUsed]; ok {
+		if limit, ok := byName[runtimeHeapLimit]; ok && limit.Max > 0 {
+			if pct := used.Max / limit.Max * 100; pct >= 85 {
+				hints = append(hints, fmt.Sprintf("Heap peaked at %.0f%% of its limit; memory pressure this high usually shows up as longer and more frequent GC pauses.", pct))
+			}
+		}
+	}
+	if gc, ok := byName[runtimeGCPause]; ok && gc.Max >= 100 {
+		hints = append(hints, fmt.Sprintf("Average GC pause reached %.0fms in part of the window; requests in flight during a pause stall for that long.", gc.Max))
+	}
+	for _, name := range []string{runtimeGoroutines, runtimeThreads} {
+		s, ok := byName[name]
+		if !ok {
+			continue
+		}
+		if first := firstSparklineValue(s.Sparkline); first > 0 && s.Current >= 2*first {
+			hints = append(hints, fmt.Sprintf("%s grew %.1fx over the window (%.0f to %.0f); a steady climb suggests a leak or blocked workers.", name, s.Current/first, first, s.Current))
+		}
+	}
+
+	strong := false
+	for _, s := range signals {
+		if s.LatencyCorrelation == nil || math.Abs(*s.LatencyCorrelation) < runtimeCorrelation {
+			continue
+		}
+		strong = true
+		direction := "rises"
+		if *s.LatencyCorrelation < 0 {
+			direction = "falls"
+		}
+		hints = append(hints, fmt.Sprintf("p95 latency %s with %s (r=%.2f); check whether the runtime is the bottleneck before looking at dependencies.", direction, s.Name, *s.LatencyCorrelation))
+	}
+	if !strong {
+		hints = append(hints, fmt.Sprintf("No runtime signal correlates strongly with p95 latency (|r| < %.1f); latency changes are more likely from dependencies or load.", runtimeCorrelation))
+	}
+	return hints
+}
+
+func firstSparklineValue(line []*float64) float64 {
+	for _, v := range line {
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
+
+func NewGetRuntimeMetricsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetRuntimeMetricsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetRuntimeMetricsArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		runtime := strings.ToLower(strings.TrimSpace(args.Runtime))
+
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		durationMin := (endTime - startTime) / 60
+		if durationMin <= 0 {
+			durationMin = 1
+		}
+		rateMin := max(durationMin/sparklineBuckets, 2)
+
+		env := resolveEnv(cfg, args.Env)
+		filter := fmt.Sprintf(`service_name="%s", env=~"%s"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+
+		if runtime == "" {
+			runtime, err = detectRuntime(ctx, client, cfg, filter, durationMin, endTime)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to detect runtime: %w", err)
+			}
+			if runtime == "" {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("No process_runtime_* metrics found for service %q (env=~%q) in the given time range. Enable OpenTelemetry runtime instrumentation, or pass runtime if the service reports under a different name.", args.ServiceName, env)},
+					},
+				}, nil, nil
+			}
+		}
+
+		queries := runtimeSignals[runtime]
+		points := make([][]TimeSeriesPoint, len(queries))
+		errs := make([]error, len(queries))
+		var (
+			latency    []TimeSeriesPoint
+			latencyErr error
+			wg         sync.WaitGroup
+		)
+		for i, q := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				points[i], errs[i] = fetchPromRangeSeries(ctx, client, cfg, fmt.Sprintf(q.Query, filter, rateMin), startTime, endTime)
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, latencyErr = fetchPromRangeSeries(ctx, client, cfg,
+				fmt.Sprintf(`max(trace_service_response_time{%s, quantile="p95"})`, filter), startTime, endTime)
+		}()
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		var (
+			signals  []RuntimeSignal
+			warnings []string
+		)
+		for i, q := range queries {
+			if errs[i] != nil {
+				warnings = append(warnings, fmt.Sprintf("%s unavailable: %v", q.Name, errs[i]))
+				continue
+			}
+			s, ok := summariseRuntimeSignal(q, points[i], startTime, endTime)
+			if !ok {
+				continue
+			}
+			s.LatencyCorrelation = pearson(points[i], latency)
+			signals = append(signals, s)
+		}
+		if latencyErr != nil {
+			warnings = append(warnings, "p95 latency unavailable; correlations omitted")
+		}
+
+		if len(signals) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("No %s runtime metrics found for service %q (env=~%q) in the given time range.", runtime, args.ServiceName, env)},
+				},
+			}, nil, nil
+		}
+		sort.SliceStable(signals, func(i, j int) bool {
+			return math.Abs(derefOr(signals[i].LatencyCorrelation)) > math.Abs(derefOr(signals[j].LatencyCorrelation))
+		})
+
+		response := map[string]any{
+			"service_name":   args.ServiceName,
+			"env":            env,
+			"runtime":        runtime,
+			"window_minutes": durationMin,
+			"signals":        signals,
+			"hints":          runtimeHints(signals),
+			"_meta": buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf(`{__name__=~"%s.+", %s}`, runtimeMetricPrefix(runtime), filter),
+			), "Runtime metrics are summed across all instances of the service; one unhealthy instance can be diluted by healthy ones."),
+		}
+		if len(latency) > 0 {
+			p95, _ := summariseRuntimeSignal(runtimeSignalQuery{Name: "p95_latency", Unit: "ms"}, latency, startTime, endTime)
+			response["p95_latency_ms"] = p95
+		}
+		if len(warnings) > 0 {
+			response["_warnings"] = warnings
+		}
+
+		jsonBytes, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "overview")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
+
+func derefOr(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,129 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestPearson(t *testing.T) {
+	a := []TimeSeriesPoint{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}, {6, 6}}
+	b := []TimeSeriesPoint{{1, 10}, {2, 20}, {3, 30}, {4, 40}, {5, 50}, {7, 70}}
+	if r := pearson(a, b); r == nil || *r != 1 {
+		t.Errorf("pearson() = %v, want 1", r)
+	}
+	if r := pearson(a[:4], b[:4]); r != nil {
+		t.Errorf("pearson() with 4 samples = %v, want nil", *r)
+	}
+	flat := []TimeSeriesPoint{{1, 3}, {2, 3}, {3, 3}, {4, 3}, {5, 3}}
+	if r := pearson(a, flat); r != nil {
+		t.Errorf("pearson() against a constant = %v, want nil", *r)
+	}
+}
+
+func TestRuntimeHints(t *testing.T) {
+	r := 0.9
+	hints := runtimeHints([]RuntimeSignal{
+		{Name: runtimeHeapUsed, Max: 900},
+		{Name: runtimeHeapLimit, Max: 1000},
+		{Name: runtimeGCPause, Max: 250, LatencyCorrelation: &r},
+		{Name: runtimeGoroutines, Current: 300, Sparkline: []*float64{nil, ptr(100)}},
+	})
+	joined := strings.Join(hints, "\n")
+	for _, want := range []string{"90% of its limit", "250ms", "goroutines grew 3.0x", "gc_pause_avg_ms (r=0.90)"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("hints missing %q:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "No runtime signal") {
+		t.Errorf("hints should not report a lack of correlation:\n%s", joined)
+	}
+}
+
+func TestGetRuntimeMetricsHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		// series returns one range series over five minutes whose value at
+		// minute i is f(i).
+		series := func(f func(i int) float64) []map[string]any {
+			values := make([][]any, 0, 6)
+			for i := range 6 {
+				values = append(values, []any{float64(1700000000 + 60*i), fmt.Sprint(f(i))})
+			}
+			return []map[string]any{{"metric": map[string]string{}, "values": values}}
+		}
+
+		var response any = []map[string]any{}
+		switch {
+		case strings.Contains(body.Query, "count by (__name__)"):
+			response = []map[string]any{
+				{"metric": map[string]string{"__name__": "process_runtime_go_goroutines"}, "value": []any{1700000000, "1"}},
+			}
+		case strings.Contains(body.Query, "trace_service_response_time"):
+			response = series(func(i int) float64 { return 100 + 20*float64(i) })
+		case strings.Contains(body.Query, "process_runtime_go_mem_heap_alloc"):
+			response = series(func(i int) float64 { return 1e8 * float64(i+1) })
+		case strings.Contains(body.Query, "process_runtime_go_goroutines"):
+			response = series(func(i int) float64 { return 50 })
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	handler := NewGetRuntimeMetricsHandler(server.Client(), testDBConfig(server.URL))
+	now := time.Now().UTC()
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetRuntimeMetricsArgs{
+		ServiceName:  "cart",
+		StartTimeISO: now.Add(-60 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var response struct {
+		Runtime string          `json:"runtime"`
+		Signals []RuntimeSignal `json:"signals"`
+		Hints   []string        `json:"hints"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Runtime != "go" {
+		t.Errorf("runtime = %q, want go", response.Runtime)
+	}
+	if len(response.Signals) != 2 {
+		t.Fatalf("signals = %+v, want heap and goroutines", response.Signals)
+	}
+	heap := response.Signals[0]
+	if heap.Name != runtimeHeapUsed || heap.Current != 6e8 || heap.Max != 6e8 {
+		t.Errorf("heap signal = %+v", heap)
+	}
+	if heap.LatencyCorrelation == nil || *heap.LatencyCorrelation != 1 {
+		t.Errorf("heap latency_correlation = %v, want 1", heap.LatencyCorrelation)
+	}
+	if goroutines := response.Signals[1]; goroutines.LatencyCorrelation != nil {
+		t.Errorf("constant goroutines should have no correlation, got %v", *goroutines.LatencyCorrelation)
+	}
+	if !strings.Contains(strings.Join(response.Hints, "\n"), "heap_used_bytes (r=1.00)") {
+		t.Errorf("hints = %v, want a heap/latency correlation hint", response.Hints)
+	}
+}
+
+func TestGetRuntimeMetricsHandler_Validation(t *testing.T) {
+	handler := NewGetRuntimeMetricsHandler(http.DefaultClient, testDBConfig("http://unused"))
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetRuntimeMetricsArgs{}); err == nil {
+		t.Fatal("expected error when service_name is missing")
+	}
+}
@@ -0,0 +1,162 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// defaultSamplingKey is the --sampling_rates entry that applies to services
+// without their own rate.
+const defaultSamplingKey = "*"
+
+// normalizeSamplingRate turns a configured or reported sampling rate into the
+// fraction of traces kept. Values in (0, 1] are already fractions; values
+// above 1 are read as "1 in N", the convention of several samplers.
+func normalizeSamplingRate(v float64) (float64, bool) {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0) || v <= 0:
+		return 0, false
+	case v > 1:
+		return 1 / v, true
+	default:
+		return v, true
+	}
+}
+
+// ParseSamplingRates parses the --sampling_rates flag: comma-separated
+// service=rate pairs, where "*" sets the rate of every other service. Rates
+// are fractions such as 0.1 or 1-in-N values such as 10.
+func ParseSamplingRates(spec string) (map[string]float64, error) {
+	rates := map[string]float64{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		service, value, ok := strings.Cut(pair, "=")
+		service = strings.TrimSpace(service)
+		if !ok || service == "" {
+			return nil, fmt.Errorf("expected service=rate, got %q", pair)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling rate for %s: %w", service, err)
+		}
+		rate, ok := normalizeSamplingRate(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid sampling rate for %s: %v must be positive", service, v)
+		}
+		rates[service] = rate
+	}
+	if len(rates) == 0 {
+		return nil, nil
+	}
+	return rates, nil
+}
+
+// fetchSamplingRates reads each service's sampling rate from the configured
+// sampling rate metric, averaged over the window. Series with a rate that
+// can't be normalized are skipped.
+func fetchSamplingRates(ctx context.Context, client utils.HTTPClient, cfg models.Config, env string, windowMinutes, end int64) (map[string]float64, error) {
+	query := fmt.Sprintf(
+		"avg by (service_name)(avg_over_time(%s{env=~'%s'}[%dm]))",
+		cfg.SamplingRateMetric, env, windowMinutes,
+	)
+	resp, err := utils.MakePromInstantAPIQuery(ctx, client, query, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get sampling rates: %s", resp.Status)
+	}
+	var raw apiPromInstantResp
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode Prometheus response: %w", err)
+	}
+	rates := map[string]float64{}
+	for _, r := range raw {
+		if len(r.Value) < 2 {
+			continue
+		}
+		valStr, _ := r.Value[1].(string)
+		v, err := strconv.ParseFloat(valStr, 64)
+		if err != nil {
+			continue
+		}
+		if rate, ok := normalizeSamplingRate(v); ok {
+			rates[r.Metric["service_name"]] = rate
+		}
+	}
+	return rates, nil
+}
+
+// extrapolateSampling divides the throughput and error rate of each summary,
+// sparklines included, by its service's sampling rate so they estimate the
+// traffic before sampling. A service's rate is its --sampling_rates entry,
+// else the sampling rate metric, else the "*" entry; services without one
+// are left as measured. The returned caveats describe what was done.
+func extrapolateSampling(ctx context.Context, client utils.HTTPClient, cfg models.Config, summaries map[string]ServiceSummary, env string, start, end int64) []string {
+	var caveats []string
+	var reported map[string]float64
+	if cfg.SamplingRateMetric != "" {
+		var err error
+		if reported, err = fetchSamplingRates(ctx, client, cfg, env, (end-start)/60, end); err != nil {
+			caveats = append(caveats, fmt.Sprintf("sampling rate metric %s unavailable: %v", cfg.SamplingRateMetric, err))
+		}
+	}
+	var extrapolated, unknown int
+	for name, s := range summaries {
+		rate, ok := cfg.SamplingRates[name]
+		if !ok {
+			rate, ok = reported[name]
+		}
+		if !ok {
+			rate, ok = cfg.SamplingRates[defaultSamplingKey]
+		}
+		if !ok {
+			unknown++
+			continue
+		}
+		s.SamplingRate = rate
+		s.Extrapolated = rate < 1
+		if s.Extrapolated {
+			s.Throughput /= rate
+			s.ErrorRate /= rate
+			s.ThroughputSparkline = scaleSparkline(s.ThroughputSparkline, 1/rate)
+			s.ErrorRateSparkline = scaleSparkline(s.ErrorRateSparkline, 1/rate)
+			extrapolated++
+		}
+		summaries[name] = s
+	}
+	if extrapolated > 0 {
+		caveats = append(caveats, fmt.Sprintf("Throughput and ErrorRate of %d service(s) marked Extrapolated are estimates: measured values divided by the service's SamplingRate.", extrapolated))
+	}
+	if unknown > 0 {
+		caveats = append(caveats, fmt.Sprintf("%d service(s) have no known sampling rate and are reported as measured; configure --sampling_rates or --sampling_rate_metric.", unknown))
+	}
+	return caveats
+}
+
+// scaleSparkline returns a copy of line with every value multiplied by factor.
+func scaleSparkline(line []*float64, factor float64) []*float64 {
+	if line == nil {
+		return nil
+	}
+	out := make([]*float64, len(line))
+	for i, v := range line {
+		if v != nil {
+			scaled := *v * factor
+			out[i] = &scaled
+		}
+	}
+	return out
+}
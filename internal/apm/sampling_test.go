@@ -0,0 +1,109 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParseSamplingRates(t *testing.T) {
+	got, err := ParseSamplingRates(" checkout=0.1, *=4 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]float64{"checkout": 0.1, "*": 0.25}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSamplingRates() = %v, want %v", got, want)
+	}
+	if got, err := ParseSamplingRates(""); err != nil || got != nil {
+		t.Errorf("ParseSamplingRates(empty) = %v, %v", got, err)
+	}
+	for _, spec := range []string{"checkout", "=0.5", "checkout=0", "checkout=-1", "checkout=half"} {
+		if _, err := ParseSamplingRates(spec); err == nil {
+			t.Errorf("ParseSamplingRates(%q): want error", spec)
+		}
+	}
+}
+
+func TestNewServiceSummaryHandler_ExtrapolateSampling(t *testing.T) {
+	var rateQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch q := body.Query; {
+		case strings.Contains(q, "trace_sampling_ratio"):
+			rateQuery = q
+			io.WriteString(w, `[{"metric":{"service_name":"web"},"value":[0,"0.5"]},{"metric":{"service_name":"api"},"value":[0,"0.2"]}]`)
+		case strings.Contains(q, "timestamp("):
+			io.WriteString(w, `[]`)
+		case strings.Contains(q, "http_status_code"):
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"value":[0,"2"]}]`)
+		default:
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"value":[0,"20"]},{"metric":{"service_name":"web"},"value":[0,"5"]},{"metric":{"service_name":"cron"},"value":[0,"1"]}]`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := testDBConfig(server.URL)
+	cfg.SamplingRates = map[string]float64{"api": 0.1}
+	cfg.SamplingRateMetric = "trace_sampling_ratio"
+	handler := NewServiceSummaryHandler(server.Client(), cfg)
+	args := ServiceSummaryArgs{StartTimeISO: "1970-01-01T00:00:00Z", EndTimeISO: "1970-01-01T01:00:00Z", Env: "prod", ExtrapolateSampling: true}
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	var summaries map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &summaries); err != nil {
+		t.Fatal(err)
+	}
+	var api, web, cron ServiceSummary
+	json.Unmarshal(summaries["api"], &api)
+	json.Unmarshal(summaries["web"], &web)
+	json.Unmarshal(summaries["cron"], &cron)
+
+	// The configured rate for api wins over the metric's 0.2.
+	if api.Throughput != 200 || api.ErrorRate != 20 || api.SamplingRate != 0.1 || !api.Extrapolated {
+		t.Errorf("api = %+v", api)
+	}
+	if web.Throughput != 10 || web.SamplingRate != 0.5 || !web.Extrapolated {
+		t.Errorf("web = %+v", web)
+	}
+	if cron.Throughput != 1 || cron.Extrapolated {
+		t.Errorf("cron = %+v", cron)
+	}
+	if !strings.Contains(rateQuery, "trace_sampling_ratio{env=~'prod'}[60m]") {
+		t.Errorf("sampling rate query = %s", rateQuery)
+	}
+	for _, want := range []string{"2 service(s) marked Extrapolated", "1 service(s) have no known sampling rate"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("response missing caveat %q: %s", want, text)
+		}
+	}
+
+	args.ExtrapolateSampling = false
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; strings.Contains(text, "Extrapolated") {
+		t.Errorf("extrapolated without extrapolate_sampling: %s", text)
+	}
+}
+
+func TestScaleSparkline(t *testing.T) {
+	v := 3.0
+	got := scaleSparkline([]*float64{&v, nil}, 2)
+	if *got[0] != 6 || got[1] != nil || v != 3 {
+		t.Errorf("scaleSparkline = %v, input now %v", sparklineValues(got), v)
+	}
+}
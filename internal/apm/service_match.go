@@ -0,0 +1,119 @@
+package apm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+const (
+	// maxServiceSuggestions bounds the did_you_mean list of a no-data response.
+	maxServiceSuggestions = 3
+	// minServiceMatchScore is the similarity below which a name is not
+	// suggested.
+	minServiceMatchScore = 0.5
+)
+
+// suggestServices returns the known services closest to serviceName, taken
+// from the service_name values reporting spans in env during the window. It
+// also reports whether serviceName itself is known, so a wrong env can be told
+// apart from a wrong name. Lookup failures yield no suggestions: they are a
+// best-effort addition to a response that is already empty.
+func suggestServices(ctx context.Context, client utils.HTTPClient, cfg models.Config, serviceName, env string, start, end int64) (suggestions []string, known bool) {
+	selector := fmt.Sprintf("trace_endpoint_count{env=~'%s'}", env)
+	names, err := fetchPromLabelValues(ctx, client, cfg, "service_name", selector, start, end)
+	if err != nil {
+		return nil, false
+	}
+	if slices.Contains(names, serviceName) {
+		return nil, true
+	}
+	return closestNames(serviceName, names, maxServiceSuggestions), false
+}
+
+// closestNames ranks candidates by similarity to name, case-insensitively,
+// and returns up to n scoring at least minServiceMatchScore. Similarity is
+// one minus the edit distance over the longer length; a candidate containing
+// the name (payment in payments-svc) or contained in it scores at least 0.75.
+func closestNames(name string, candidates []string, n int) []string {
+	type scored struct {
+		name  string
+		score float64
+	}
+	query := strings.ToLower(name)
+	var matches []scored
+	for _, c := range candidates {
+		score := nameSimilarity(query, strings.ToLower(c))
+		if score >= minServiceMatchScore {
+			matches = append(matches, scored{c, score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+	var out []string
+	for _, m := range matches[:min(n, len(matches))] {
+		out = append(out, m.name)
+	}
+	return out
+}
+
+func nameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	longer, shorter := max(len(ra), len(rb)), min(len(ra), len(rb))
+	score := 1 - float64(editDistance(ra, rb))/float64(longer)
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		score = max(score, 0.75+0.25*float64(shorter)/float64(longer))
+	}
+	return score
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// withServiceSuggestions adds did_you_mean suggestions to a response that
+// withDataAvailability found empty. Responses with data are returned as is,
+// without a lookup.
+func (meta *ResponseMeta) withServiceSuggestions(ctx context.Context, client utils.HTTPClient, cfg models.Config, serviceName, env string, start, end int64) *ResponseMeta {
+	if meta.DataAvailable == nil || *meta.DataAvailable {
+		return meta
+	}
+	suggestions, known := suggestServices(ctx, client, cfg, serviceName, env, start, end)
+	switch {
+	case known:
+		meta.Hints = append(meta.Hints, fmt.Sprintf("Service %q reports spans in env %q, but not to the metrics these sub-queries read.", serviceName, env))
+	case len(suggestions) > 0:
+		meta.DidYouMean = suggestions
+		meta.Hints = append(meta.Hints, fmt.Sprintf("Service %q has no spans in env %q; did you mean %s?", serviceName, env, strings.Join(suggestions, ", ")))
+	}
+	return meta
+}
@@ -0,0 +1,71 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestClosestNames(t *testing.T) {
+	services := []string{"payments-svc", "cart", "checkout", "checkout-worker", "notifications"}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"payment", []string{"payments-svc"}},
+		{"chekout", []string{"checkout"}},
+		{"Checkout", []string{"checkout", "checkout-worker"}},
+		{"inventory", nil},
+	}
+	for _, tt := range tests {
+		if got := closestNames(tt.name, services, 3); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("closestNames(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := editDistance([]rune("kitten"), []rune("sitting")); got != 3 {
+		t.Errorf("editDistance = %d, want 3", got)
+	}
+}
+
+func TestServiceOperationsSummaryHandler_DidYouMean(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == constants.EndpointPromLabelValues {
+			w.Write([]byte(`["payments-svc","cart"]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	handler := NewServiceOperationsSummaryHandler(server.Client(), testDBConfig(server.URL))
+	for _, tt := range []struct {
+		service    string
+		want       []string
+		hintSubstr string
+	}{
+		{"payment", []string{"payments-svc"}, "did you mean payments-svc"},
+		{"cart", nil, "reports spans in env"},
+	} {
+		result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ServiceOperationsSummaryArgs{ServiceName: tt.service})
+		if err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		var resp ServiceOperationsSummaryResponse
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resp.Meta.DidYouMean, tt.want) {
+			t.Errorf("%s: did_you_mean = %v, want %v", tt.service, resp.Meta.DidYouMean, tt.want)
+		}
+		if hints := strings.Join(resp.Meta.Hints, "\n"); !strings.Contains(hints, tt.hintSubstr) {
+			t.Errorf("%s: hints lack %q: %s", tt.service, tt.hintSubstr, hints)
+		}
+	}
+}
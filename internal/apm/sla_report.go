@@ -0,0 +1,361 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/criticality"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_endpoint_sla_report tool ---
+
+type GetEndpointSLAReportArgs struct {
+	Env         string `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	Tier        string `json:"tier,omitempty" jsonschema:"Only report this tier (optional, e.g. critical)"`
+	ServiceName string `json:"service_name,omitempty" jsonschema:"Only report endpoints of this service (optional)"`
+	Days        int    `json:"days,omitempty" jsonschema:"Days to report on (default: 7, min: 1, max: 31)"`
+	EndTimeISO  string `json:"end_time_iso,omitempty" jsonschema:"End of the window in RFC3339/ISO8601 format (default: now)"`
+}
+
+const (
+	defaultSLAReportDays = 7
+	maxSLAReportDays     = 31
+)
+
+// Endpoint SLA statuses.
+const (
+	slaStatusMet      = "met"
+	slaStatusBreached = "breached"
+	slaStatusNoData   = "no_data"
+)
+
+// EndpointSLA is one tagged endpoint judged against its tier's targets.
+type EndpointSLA struct {
+	ServiceName  string              `json:"service_name"`
+	Endpoint     string              `json:"endpoint"`
+	Tier         string              `json:"tier"`
+	Targets      criticality.Targets `json:"targets"`
+	Requests     float64             `json:"requests"`
+	ErrorPercent *float64            `json:"error_percent,omitempty"`
+	P95LatencyMs *float64            `json:"p95_latency_ms,omitempty"`
+	Status       string              `json:"status"`
+	Breaches     []string            `json:"breaches,omitempty"`
+}
+
+// TierSLA is the compliance of one tier's endpoints. CompliancePercent is
+// the share of endpoints with data that met their targets and
+// RequestsInSLAPercent the share of requests those endpoints served.
+type TierSLA struct {
+	Tier                 string   `json:"tier"`
+	Endpoints            int      `json:"endpoints"`
+	Met                  int      `json:"met"`
+	Breached             int      `json:"breached"`
+	NoData               int      `json:"no_data"`
+	CompliancePercent    *float64 `json:"compliance_percent,omitempty"`
+	RequestsInSLAPercent *float64 `json:"requests_in_sla_percent,omitempty"`
+	ErrorPercent         *float64 `json:"error_percent,omitempty"`
+	WithinSLA            bool     `json:"within_sla"`
+}
+
+// EndpointSLAReport is the response of get_endpoint_sla_report.
+type EndpointSLAReport struct {
+	Env       string        `json:"env"`
+	Window    ReleaseWindow `json:"window"`
+	WithinSLA bool          `json:"within_sla"`
+	Summary   string        `json:"summary"`
+	Tiers     []TierSLA     `json:"tiers"`
+	Endpoints []EndpointSLA `json:"endpoints"`
+	Meta      *ResponseMeta `json:"_meta,omitempty"`
+}
+
+// endpointKey identifies an endpoint of a service.
+type endpointKey struct{ service, endpoint string }
+
+// endpointStats are an endpoint's totals over the report window.
+type endpointStats struct {
+	requests float64
+	errors   *float64
+	p95      *float64
+}
+
+// judgeEndpoint compares an endpoint's totals with its tag's targets.
+func judgeEndpoint(tag criticality.Tag, service, endpoint string, stats endpointStats, ok bool) EndpointSLA {
+	row := EndpointSLA{ServiceName: service, Endpoint: endpoint, Tier: tag.Tier, Targets: tag.Targets(), Status: slaStatusNoData}
+	if !ok || stats.requests <= 0 {
+		return row
+	}
+	row.Requests = round1(stats.requests)
+	judged := false
+	if stats.errors != nil {
+		errPct := round3(100 * *stats.errors / stats.requests)
+		row.ErrorPercent = &errPct
+		judged = true
+		if errPct > row.Targets.ErrorPercent {
+			row.Breaches = append(row.Breaches, fmt.Sprintf("error rate %.3g%% above the %.3g%% target", errPct, row.Targets.ErrorPercent))
+		}
+	}
+	if stats.p95 != nil {
+		p95 := round1(*stats.p95)
+		row.P95LatencyMs = &p95
+		judged = true
+		if p95 > row.Targets.P95LatencyMs {
+			row.Breaches = append(row.Breaches, fmt.Sprintf("p95 latency %.1fms above the %.0fms target", p95, row.Targets.P95LatencyMs))
+		}
+	}
+	switch {
+	case len(row.Breaches) > 0:
+		row.Status = slaStatusBreached
+	case judged:
+		row.Status = slaStatusMet
+	}
+	return row
+}
+
+// buildSLAReport judges every tagged endpoint in stats, plus tagged
+// endpoints without data, and aggregates them per tier. Endpoints are
+// sorted by tier, breached first. It is pure so it can be tested without a
+// backend.
+func buildSLAReport(tags []criticality.Tag, lookup func(service, endpoint string) (criticality.Tag, bool), stats map[endpointKey]endpointStats, keep func(criticality.Tag) bool) ([]TierSLA, []EndpointSLA) {
+	var rows []EndpointSLA
+	seen := map[endpointKey]bool{}
+	withData := map[string]bool{}
+	for key, s := range stats {
+		tag, ok := lookup(key.service, key.endpoint)
+		if !ok || !keep(tag) {
+			continue
+		}
+		rows = append(rows, judgeEndpoint(tag, key.service, key.endpoint, s, true))
+		seen[endpointKey{strings.ToLower(tag.ServiceName), tag.Endpoint}] = true
+		withData[strings.ToLower(key.service)] = true
+	}
+	for _, tag := range tags {
+		if !keep(tag) {
+			continue
+		}
+		service := strings.ToLower(tag.ServiceName)
+		if tag.Endpoint == criticality.AllEndpoints && withData[service] {
+			continue
+		}
+		if tag.Endpoint != criticality.AllEndpoints && seen[endpointKey{service, tag.Endpoint}] {
+			continue
+		}
+		rows = append(rows, judgeEndpoint(tag, tag.ServiceName, tag.Endpoint, endpointStats{}, false))
+	}
+
+	statusOrder := map[string]int{slaStatusBreached: 0, slaStatusMet: 1, slaStatusNoData: 2}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if ra, rb := criticality.Rank(a.Tier), criticality.Rank(b.Tier); ra != rb {
+			return ra < rb
+		}
+		if a.Status != b.Status {
+			return statusOrder[a.Status] < statusOrder[b.Status]
+		}
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		return a.Endpoint < b.Endpoint
+	})
+
+	var tiers []TierSLA
+	for _, name := range criticality.Tiers {
+		tier := TierSLA{Tier: name}
+		var requests, requestsMet, errors float64
+		for _, r := range rows {
+			if r.Tier != name {
+				continue
+			}
+			tier.Endpoints++
+			switch r.Status {
+			case slaStatusMet:
+				tier.Met++
+				requestsMet += r.Requests
+			case slaStatusBreached:
+				tier.Breached++
+			default:
+				tier.NoData++
+			}
+			requests += r.Requests
+			errors += r.Requests * valueOr(r.ErrorPercent, 0) / 100
+		}
+		if tier.Endpoints == 0 {
+			continue
+		}
+		if judged := tier.Met + tier.Breached; judged > 0 {
+			pct := round1(100 * float64(tier.Met) / float64(judged))
+			tier.CompliancePercent = &pct
+		}
+		if requests > 0 {
+			inSLA, errPct := round1(100*requestsMet/requests), round3(100*errors/requests)
+			tier.RequestsInSLAPercent, tier.ErrorPercent = &inSLA, &errPct
+		}
+		tier.WithinSLA = tier.Breached == 0 && tier.Met > 0
+		tiers = append(tiers, tier)
+	}
+	return tiers, rows
+}
+
+// summarizeSLA describes each tier's compliance in one clause.
+func summarizeSLA(tiers []TierSLA) string {
+	if len(tiers) == 0 {
+		return "No endpoints are tagged; tag them with tag_endpoint_criticality."
+	}
+	var parts []string
+	for _, t := range tiers {
+		part := fmt.Sprintf("%s: %d of %d endpoints within SLA", t.Tier, t.Met, t.Endpoints)
+		if t.Breached > 0 {
+			part += fmt.Sprintf(", %d breached", t.Breached)
+		}
+		if t.NoData > 0 {
+			part += fmt.Sprintf(", %d without data", t.NoData)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ") + "."
+}
+
+func NewGetEndpointSLAReportHandler(client utils.HTTPClient, cfg models.Config, tags *criticality.Store) func(context.Context, *mcp.CallToolRequest, GetEndpointSLAReportArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetEndpointSLAReportArgs) (*mcp.CallToolResult, any, error) {
+		tier := strings.ToLower(strings.TrimSpace(args.Tier))
+		if tier != "" && criticality.Rank(tier) == len(criticality.Tiers) {
+			return nil, nil, fmt.Errorf("tier must be one of %s, got %q", strings.Join(criticality.Tiers, ", "), args.Tier)
+		}
+		days := args.Days
+		if days == 0 {
+			days = defaultSLAReportDays
+		}
+		if days < 1 || days > maxSLAReportDays {
+			return nil, nil, fmt.Errorf("days must be between 1 and %d, got %d", maxSLAReportDays, days)
+		}
+		end := time.Now()
+		if args.EndTimeISO != "" {
+			var err error
+			if end, err = utils.ParseToolTimestamp(args.EndTimeISO); err != nil {
+				return nil, nil, fmt.Errorf("invalid end_time_iso: %w", err)
+			}
+		}
+		endTime := end.Unix()
+		startTime := end.AddDate(0, 0, -days).Unix()
+		env := resolveEnv(cfg, args.Env)
+		keep := func(t criticality.Tag) bool {
+			return (tier == "" || t.Tier == tier) && (args.ServiceName == "" || strings.EqualFold(t.ServiceName, args.ServiceName))
+		}
+
+		var (
+			tagged   []criticality.Tag
+			services []string
+			known    = map[string]bool{}
+		)
+		for _, t := range tags.All() {
+			if !keep(t) {
+				continue
+			}
+			tagged = append(tagged, t)
+			if !known[t.ServiceName] {
+				known[t.ServiceName] = true
+				services = append(services, regexp.QuoteMeta(t.ServiceName))
+			}
+		}
+		result := EndpointSLAReport{
+			Env:       env,
+			Window:    ReleaseWindow{Start: time.Unix(startTime, 0).UTC().Format(time.RFC3339), End: time.Unix(endTime, 0).UTC().Format(time.RFC3339)},
+			Tiers:     []TierSLA{},
+			Endpoints: []EndpointSLA{},
+		}
+		if len(tagged) == 0 {
+			result.Summary = summarizeSLA(nil)
+			return slaReportResult(result)
+		}
+
+		durationMin := max((endTime-startTime)/60, 1)
+		sel := fmt.Sprintf(`service_name=~"%s", env=~"%s", span_kind="SPAN_KIND_SERVER"`, escapePromQLLabel(strings.Join(services, "|")), escapePromQLLabel(env))
+		queries := map[string]string{
+			compareRequests:   fmt.Sprintf(`sum by (service_name, span_name) (sum_over_time(trace_endpoint_count{%s}[%dm]))`, sel, durationMin),
+			compareErrors:     fmt.Sprintf(`sum by (service_name, span_name) (sum_over_time(trace_endpoint_count{%s, status_code="STATUS_CODE_ERROR"}[%dm]))`, sel, durationMin),
+			compareLatencyP95: fmt.Sprintf(`max by (service_name, span_name) (avg_over_time(trace_endpoint_duration{%s, quantile="p95"}[%dm]))`, sel, durationMin),
+		}
+
+		var (
+			mu       sync.Mutex
+			values   = make(map[string]map[endpointKey]float64, len(queries))
+			failures []string
+			wg       sync.WaitGroup
+		)
+		for name, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s query failed: %v", name, err))
+					return
+				}
+				byEndpoint := map[endpointKey]float64{}
+				for _, s := range series {
+					if v := promScalar(apiPromInstantResp{s}); v != nil && s.Metric["span_name"] != "" {
+						byEndpoint[endpointKey{s.Metric["service_name"], s.Metric["span_name"]}] = *v
+					}
+				}
+				values[name] = byEndpoint
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := values[compareRequests]; !ok {
+			return nil, nil, fmt.Errorf("failed to fetch endpoint requests: %s", strings.Join(failures, "; "))
+		}
+
+		stats := map[endpointKey]endpointStats{}
+		for key, requests := range values[compareRequests] {
+			s := endpointStats{requests: requests}
+			if errs, ok := values[compareErrors]; ok {
+				e := errs[key]
+				s.errors = &e
+			}
+			if p95, ok := values[compareLatencyP95][key]; ok {
+				s.p95 = &p95
+			}
+			stats[key] = s
+		}
+		result.Tiers, result.Endpoints = buildSLAReport(tagged, tags.Lookup, stats, keep)
+		result.WithinSLA = len(result.Tiers) > 0
+		for _, t := range result.Tiers {
+			if !t.WithinSLA {
+				result.WithinSLA = false
+			}
+		}
+		result.Summary = summarizeSLA(result.Tiers)
+
+		sort.Strings(failures)
+		result.Meta = buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+			fmt.Sprintf("trace_endpoint_count{%s}", sel),
+		), failures...)
+		return slaReportResult(result)
+	}
+}
+
+func slaReportResult(result EndpointSLAReport) (*mcp.CallToolResult, any, error) {
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, nil, nil
+}
@@ -0,0 +1,61 @@
+package apm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/criticality"
+)
+
+func TestBuildSLAReport(t *testing.T) {
+	store := criticality.New("")
+	for _, tag := range []criticality.Tag{
+		{ServiceName: "payments", Endpoint: criticality.AllEndpoints, Tier: criticality.TierCritical},
+		{ServiceName: "checkout", Endpoint: "POST /api/checkout", Tier: criticality.TierCritical},
+		{ServiceName: "checkout", Endpoint: "GET /api/orders/{id}", Tier: criticality.TierStandard},
+		{ServiceName: "ghost", Endpoint: "GET /", Tier: criticality.TierHigh},
+	} {
+		if _, err := store.Set(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := map[endpointKey]endpointStats{
+		{"payments", "POST /api/charge"}:     {requests: 10000, errors: ptr(420), p95: ptr(260)},
+		{"checkout", "POST /api/checkout"}:   {requests: 30000, errors: ptr(3), p95: ptr(280)},
+		{"checkout", "GET /api/orders/{id}"}: {requests: 5000, errors: ptr(10), p95: ptr(450)},
+		{"cart", "GET /api/cart"}:            {requests: 80000, errors: ptr(0), p95: ptr(40)},
+	}
+	all := func(criticality.Tag) bool { return true }
+	tiers, rows := buildSLAReport(store.All(), store.Lookup, stats, all)
+
+	var order []string
+	for _, r := range rows {
+		order = append(order, r.ServiceName+" "+r.Endpoint+" "+r.Status)
+	}
+	want := "payments POST /api/charge breached,checkout POST /api/checkout met,ghost GET / no_data,checkout GET /api/orders/{id} met"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("rows = %s, want %s", got, want)
+	}
+	if !strings.Contains(strings.Join(rows[0].Breaches, ";"), "error rate 4.2%") {
+		t.Errorf("payments breaches = %v", rows[0].Breaches)
+	}
+
+	if len(tiers) != 3 {
+		t.Fatalf("got %d tiers, want critical, high and standard", len(tiers))
+	}
+	critical := tiers[0]
+	if critical.Met != 1 || critical.Breached != 1 || critical.WithinSLA || valueOr(critical.CompliancePercent, -1) != 50 || valueOr(critical.RequestsInSLAPercent, -1) != 75 {
+		t.Errorf("critical = %+v", critical)
+	}
+	if high := tiers[1]; high.NoData != 1 || high.WithinSLA || high.CompliancePercent != nil {
+		t.Errorf("high = %+v, want one endpoint without data", high)
+	}
+	if standard := tiers[2]; !standard.WithinSLA {
+		t.Errorf("standard = %+v, want within SLA", standard)
+	}
+
+	onlyCritical := func(tag criticality.Tag) bool { return tag.Tier == criticality.TierCritical }
+	if tiers, _ := buildSLAReport(store.All(), store.Lookup, stats, onlyCritical); len(tiers) != 1 {
+		t.Errorf("tier filter: got %d tiers, want 1", len(tiers))
+	}
+}
@@ -0,0 +1,138 @@
+package apm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// sparklineBuckets is how many points a summary sparkline has: enough to tell
+// a spike from a steady rate while staying a few bytes per service.
+const sparklineBuckets = 12
+
+// serviceSparklineQueries returns the per-service throughput and 5xx error
+// rate queries, in requests per minute, with a rate window of one bucket.
+func serviceSparklineQueries(env string, start, end int64) (throughput, errors string) {
+	bucketMinutes := max((end-start)/60/sparklineBuckets, 1)
+	throughput = fmt.Sprintf(
+		"sum by (service_name)(rate(trace_endpoint_count{env=~'%s', span_kind='SPAN_KIND_SERVER'}[%dm])) * 60",
+		env, bucketMinutes,
+	)
+	errors = fmt.Sprintf(
+		"sum by (service_name)(rate(trace_endpoint_count{env=~'%s', span_kind='SPAN_KIND_SERVER', http_status_code=~'5.*'}[%dm])) * 60",
+		env, bucketMinutes,
+	)
+	return throughput, errors
+}
+
+// fetchSparklines runs a range query grouped by service_name and returns a
+// sparkline per service.
+func fetchSparklines(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, start, end int64) (map[string][]*float64, error) {
+	return fetchSparklinesBy(ctx, client, cfg, query, start, end, func(metric map[string]string) string {
+		return metric["service_name"]
+	})
+}
+
+// fetchSparklinesBy runs a range query and returns a sparkline per series,
+// keyed by key(series labels).
+func fetchSparklinesBy(ctx context.Context, client utils.HTTPClient, cfg models.Config, query string, start, end int64, key func(map[string]string) string) (map[string][]*float64, error) {
+	resp, err := utils.MakePromRangeAPIQuery(ctx, client, query, start, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	series, err := parsePromTimeSeries(data)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]*float64, len(series))
+	for _, s := range series {
+		out[key(s.Metric)] = sparkline(s.Values, start, end, sparklineBuckets)
+	}
+	return out, nil
+}
+
+// sparkline averages points into n equal buckets over [start, end]. Buckets
+// without samples are nil, so a gap in the data is not shown as zero traffic.
+// Values are rounded to three decimals to keep responses compact.
+func sparkline(points []TimeSeriesPoint, start, end int64, n int) []*float64 {
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	width := float64(end-start) / float64(n)
+	for _, p := range points {
+		if width <= 0 || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		i := int(float64(int64(p.Timestamp)-start) / width)
+		if i < 0 || i > n {
+			continue
+		}
+		i = min(i, n-1) // a sample at end belongs to the last bucket
+		sums[i] += p.Value
+		counts[i]++
+	}
+	out := make([]*float64, n)
+	for i := range out {
+		if counts[i] > 0 {
+			v := math.Round(sums[i]/float64(counts[i])*1000) / 1000
+			out[i] = &v
+		}
+	}
+	return out
+}
+
+// addServiceSparklines sets the throughput and error rate sparklines of every
+// summary. A failed query leaves its sparklines unset and is returned as a
+// caveat rather than failing the summary.
+func addServiceSparklines(ctx context.Context, client utils.HTTPClient, cfg models.Config, summaries map[string]ServiceSummary, env string, start, end int64) []string {
+	throughputQuery, errorsQuery := serviceSparklineQueries(env, start, end)
+	var caveats []string
+	throughput, err := fetchSparklines(ctx, client, cfg, throughputQuery, start, end)
+	if err != nil {
+		caveats = append(caveats, fmt.Sprintf("throughput sparklines unavailable: %v", err))
+	}
+	errorRates, err := fetchSparklines(ctx, client, cfg, errorsQuery, start, end)
+	if err != nil {
+		caveats = append(caveats, fmt.Sprintf("error rate sparklines unavailable: %v", err))
+	}
+	for name, s := range summaries {
+		if throughput != nil {
+			s.ThroughputSparkline = throughput[name]
+			if s.ThroughputSparkline == nil {
+				s.ThroughputSparkline = make([]*float64, sparklineBuckets)
+			}
+		}
+		if errorRates != nil {
+			// No 5xx series means no errors in any bucket that had traffic.
+			s.ErrorRateSparkline = errorRates[name]
+			if s.ErrorRateSparkline == nil {
+				s.ErrorRateSparkline = zeroWhereSet(s.ThroughputSparkline)
+			}
+		}
+		summaries[name] = s
+	}
+	return caveats
+}
+
+// zeroWhereSet returns a sparkline that is zero where line has a value.
+func zeroWhereSet(line []*float64) []*float64 {
+	out := make([]*float64, sparklineBuckets)
+	for i, v := range line {
+		if v != nil && i < len(out) {
+			out[i] = new(float64)
+		}
+	}
+	return out
+}
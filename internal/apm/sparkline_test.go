@@ -0,0 +1,98 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func sparklineValues(line []*float64) []any {
+	out := make([]any, len(line))
+	for i, v := range line {
+		if v != nil {
+			out[i] = *v
+		}
+	}
+	return out
+}
+
+func TestSparkline(t *testing.T) {
+	const start, end = 1000, 1400 // 4 buckets of 100s
+	points := []TimeSeriesPoint{
+		{Timestamp: 1000, Value: 1}, {Timestamp: 1050, Value: 2}, // bucket 0
+		{Timestamp: 1250, Value: 10},  // bucket 2
+		{Timestamp: 1400, Value: 4.5}, // end of window: last bucket
+		{Timestamp: 2000, Value: 99},  // outside the window
+	}
+	got := sparklineValues(sparkline(points, start, end, 4))
+	want := []any{1.5, nil, 10.0, 4.5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sparkline = %v, want %v", got, want)
+		}
+	}
+	if q, e := serviceSparklineQueries("prod", 0, 3600); !strings.Contains(q, "[5m]") || !strings.Contains(e, "http_status_code=~'5.*'") {
+		t.Errorf("queries = %s / %s", q, e)
+	}
+}
+
+func TestNewServiceSummaryHandler_Sparklines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query  string `json:"query"`
+			Window int64  `json:"window"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case body.Window > 0 && strings.Contains(body.Query, "http_status_code"):
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"values":[[3600,"2"]]}]`)
+		case body.Window > 0:
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"values":[[0,"10"],[3600,"30"]]},{"metric":{"service_name":"web"},"values":[[1800,"5"]]}]`)
+		case strings.Contains(body.Query, "timestamp("):
+			io.WriteString(w, `[]`)
+		default:
+			io.WriteString(w, `[{"metric":{"service_name":"api"},"value":[0,"20"]},{"metric":{"service_name":"web"},"value":[0,"5"]}]`)
+		}
+	}))
+	defer server.Close()
+
+	handler := NewServiceSummaryHandler(server.Client(), testDBConfig(server.URL))
+	args := ServiceSummaryArgs{StartTimeISO: "1970-01-01T00:00:00Z", EndTimeISO: "1970-01-01T01:00:00Z", IncludeSparklines: true}
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	var summaries map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &summaries); err != nil {
+		t.Fatal(err)
+	}
+	var api, web ServiceSummary
+	json.Unmarshal(summaries["api"], &api)
+	json.Unmarshal(summaries["web"], &web)
+
+	if len(api.ThroughputSparkline) != sparklineBuckets || *api.ThroughputSparkline[0] != 10 || *api.ThroughputSparkline[11] != 30 || api.ThroughputSparkline[5] != nil {
+		t.Errorf("api throughput sparkline = %v", sparklineValues(api.ThroughputSparkline))
+	}
+	if *api.ErrorRateSparkline[11] != 2 {
+		t.Errorf("api error sparkline = %v", sparklineValues(api.ErrorRateSparkline))
+	}
+	// web had traffic in bucket 6 and no 5xx series: zero errors there only.
+	if web.ErrorRateSparkline[6] == nil || *web.ErrorRateSparkline[6] != 0 || web.ErrorRateSparkline[0] != nil {
+		t.Errorf("web error sparkline = %v", sparklineValues(web.ErrorRateSparkline))
+	}
+
+	args.IncludeSparklines = false
+	result, _, err = handler(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; strings.Contains(text, "Sparkline") {
+		t.Errorf("sparklines returned without include_sparklines: %s", text)
+	}
+}
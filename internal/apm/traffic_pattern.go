@@ -0,0 +1,404 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- classify_traffic_pattern tool ---
+
+type ClassifyTrafficPatternArgs struct {
+	ServiceName string `json:"service_name" jsonschema:"Name of the service to classify (required)"`
+	Env         string `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	Days        int    `json:"days,omitempty" jsonschema:"Days of throughput to classify (default: 7, min: 2, max: 14). Weekly seasonality needs at least 7."`
+	EndTimeISO  string `json:"end_time_iso,omitempty" jsonschema:"End of the window in RFC3339/ISO8601 format (default: now)"`
+}
+
+const (
+	defaultTrafficPatternDays = 7
+	minTrafficPatternDays     = 2
+	maxTrafficPatternDays     = 14
+	// trafficPeakHours is how many peak and quiet hours are reported.
+	trafficPeakHours = 3
+)
+
+// Traffic patterns.
+const (
+	trafficPatternDiurnal      = "diurnal"
+	trafficPatternBursty       = "bursty"
+	trafficPatternFlat         = "flat"
+	trafficPatternIrregular    = "irregular"
+	trafficPatternInsufficient = "insufficient_data"
+)
+
+// Classification cut-offs. Diurnal strength is the share of hourly variance
+// explained by the hour of day; a weekend ratio this far from 1 is weekly
+// seasonality; a burst hour is 3 standard deviations above its hour-of-day
+// mean once the daily cycle is removed.
+const (
+	trafficDiurnalStrength   = 0.5
+	trafficWeeklyDeviation   = 0.25
+	trafficBurstZ            = 3.0
+	trafficBurstShare        = 0.02
+	trafficBurstPeakToMedian = 3.0
+	trafficFlatCV            = 0.2
+	// trafficMinHours is the fewest hourly points a classification needs.
+	trafficMinHours = 48
+)
+
+// TrafficStats summarizes hourly request counts.
+type TrafficStats struct {
+	HourlyPoints    int     `json:"hourly_points"`
+	MeanPerHour     float64 `json:"mean_requests_per_hour"`
+	MedianPerHour   float64 `json:"median_requests_per_hour"`
+	MaxPerHour      float64 `json:"max_requests_per_hour"`
+	CV              float64 `json:"coefficient_of_variation"`
+	DiurnalStrength float64 `json:"diurnal_strength"`
+	// WeekendRatio is mean weekend over mean weekday traffic; nil with less
+	// than a week of data.
+	WeekendRatio *float64 `json:"weekend_ratio,omitempty"`
+	BurstHours   int      `json:"burst_hours"`
+}
+
+// TrafficThreshold is a suggested throughput alert, in requests per hour.
+type TrafficThreshold struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Value       *float64 `json:"value,omitempty"`
+	PromQL      string   `json:"promql,omitempty"`
+}
+
+// TrafficPattern is the response of classify_traffic_pattern.
+type TrafficPattern struct {
+	ServiceName         string             `json:"service_name"`
+	Env                 string             `json:"env"`
+	Window              ReleaseWindow      `json:"window"`
+	Pattern             string             `json:"pattern"`
+	WeeklySeasonality   bool               `json:"weekly_seasonality"`
+	Summary             string             `json:"summary"`
+	PeakHoursUTC        []int              `json:"peak_hours_utc,omitempty"`
+	QuietHoursUTC       []int              `json:"quiet_hours_utc,omitempty"`
+	Stats               TrafficStats       `json:"stats"`
+	SuggestedThresholds []TrafficThreshold `json:"suggested_thresholds,omitempty"`
+	Meta                *ResponseMeta      `json:"_meta,omitempty"`
+}
+
+// trafficClassification is the outcome of classifyTraffic.
+type trafficClassification struct {
+	pattern    string
+	weekly     bool
+	stats      TrafficStats
+	hourMeans  [24]float64
+	peakHours  []int
+	quietHours []int
+	p99        float64
+}
+
+// hourlyTraffic buckets points into UTC hours, averaging points that fall
+// in the same hour, and drops NaN samples. The result is in time order.
+func hourlyTraffic(points []TimeSeriesPoint) []TimeSeriesPoint {
+	sums := map[int64]float64{}
+	counts := map[int64]int{}
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		hour := int64(p.Timestamp) / 3600 * 3600
+		sums[hour] += p.Value
+		counts[hour]++
+	}
+	out := make([]TimeSeriesPoint, 0, len(sums))
+	for hour, sum := range sums {
+		out = append(out, TimeSeriesPoint{Timestamp: uint64(hour), Value: sum / float64(counts[hour])})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+	return out
+}
+
+// classifyTraffic classifies hourly request counts. Bursts win over the
+// daily cycle because they break static and relative thresholds alike. It
+// is pure so the rules can be tested without a backend.
+func classifyTraffic(hourly []TimeSeriesPoint) trafficClassification {
+	c := trafficClassification{pattern: trafficPatternInsufficient}
+	c.stats.HourlyPoints = len(hourly)
+	if len(hourly) == 0 {
+		return c
+	}
+
+	values := make([]float64, len(hourly))
+	var sum float64
+	for i, p := range hourly {
+		values[i] = p.Value
+		sum += p.Value
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := percentile(sorted, 50)
+	c.p99 = percentile(sorted, 99)
+	c.stats.MeanPerHour = round1(mean)
+	c.stats.MedianPerHour = round1(median)
+	c.stats.MaxPerHour = round1(sorted[len(sorted)-1])
+	if mean > 0 {
+		c.stats.CV = round3(math.Sqrt(variance) / mean)
+	}
+	if len(hourly) < trafficMinHours {
+		return c
+	}
+
+	// Hour-of-day profile and how much of the variance it explains.
+	var hourSums [24]float64
+	var hourCounts [24]int
+	var weekday, weekend struct {
+		sum float64
+		n   int
+	}
+	for _, p := range hourly {
+		t := time.Unix(int64(p.Timestamp), 0).UTC()
+		hourSums[t.Hour()] += p.Value
+		hourCounts[t.Hour()]++
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			weekend.sum += p.Value
+			weekend.n++
+		} else {
+			weekday.sum += p.Value
+			weekday.n++
+		}
+	}
+	var explained float64
+	for h := range hourSums {
+		if hourCounts[h] > 0 {
+			c.hourMeans[h] = hourSums[h] / float64(hourCounts[h])
+			explained += float64(hourCounts[h]) * (c.hourMeans[h] - mean) * (c.hourMeans[h] - mean)
+		}
+	}
+	if variance > 0 {
+		c.stats.DiurnalStrength = round3(explained / (variance * float64(len(values))))
+	}
+
+	span := time.Duration(hourly[len(hourly)-1].Timestamp-hourly[0].Timestamp) * time.Second
+	if span >= 7*24*time.Hour-time.Hour && weekday.n > 0 && weekend.n > 0 && weekday.sum > 0 {
+		ratio := round3((weekend.sum / float64(weekend.n)) / (weekday.sum / float64(weekday.n)))
+		c.stats.WeekendRatio = &ratio
+		c.weekly = math.Abs(1-ratio) >= trafficWeeklyDeviation
+	}
+
+	// Bursts are hours far above what their hour of day predicts.
+	var residual float64
+	for _, p := range hourly {
+		d := p.Value - c.hourMeans[time.Unix(int64(p.Timestamp), 0).UTC().Hour()]
+		residual += d * d
+	}
+	residualStd := math.Sqrt(residual / float64(len(hourly)))
+	if residualStd > 0 {
+		for _, p := range hourly {
+			if (p.Value-c.hourMeans[time.Unix(int64(p.Timestamp), 0).UTC().Hour()])/residualStd > trafficBurstZ {
+				c.stats.BurstHours++
+			}
+		}
+	}
+	peakToMedian := math.Inf(1)
+	if median > 0 {
+		peakToMedian = sorted[len(sorted)-1] / median
+	}
+
+	hours := make([]int, 0, 24)
+	for h := range hourCounts {
+		if hourCounts[h] > 0 {
+			hours = append(hours, h)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool { return c.hourMeans[hours[i]] > c.hourMeans[hours[j]] })
+	n := min(trafficPeakHours, len(hours))
+	c.peakHours = append([]int(nil), hours[:n]...)
+	c.quietHours = append([]int(nil), hours[len(hours)-n:]...)
+	sort.Ints(c.peakHours)
+	sort.Ints(c.quietHours)
+
+	switch {
+	case mean == 0:
+		c.pattern = trafficPatternFlat
+	case float64(c.stats.BurstHours)/float64(len(hourly)) >= trafficBurstShare && peakToMedian >= trafficBurstPeakToMedian:
+		c.pattern = trafficPatternBursty
+	case c.stats.DiurnalStrength >= trafficDiurnalStrength:
+		c.pattern = trafficPatternDiurnal
+	case c.stats.CV < trafficFlatCV:
+		c.pattern = trafficPatternFlat
+	default:
+		c.pattern = trafficPatternIrregular
+	}
+	return c
+}
+
+// percentile returns the p-th percentile of sorted values by nearest rank.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// suggestTrafficThresholds proposes throughput alerts that suit c's pattern:
+// static bands for flat traffic, same-hour comparisons for cyclic traffic
+// and spike-only alerts for bursty traffic. sel selects the service's
+// server spans.
+func suggestTrafficThresholds(c trafficClassification, sel string) []TrafficThreshold {
+	hourly := fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%s}[1h]))`, sel)
+	value := func(v float64) *float64 {
+		v = round1(math.Max(v, 0))
+		return &v
+	}
+	// Weekly traffic compares with the same hour last week, so weekends are
+	// not judged against weekdays.
+	offset := "1d"
+	if c.weekly {
+		offset = "7d"
+	}
+	relative := fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%[1]s}[1h] offset %[2]s))`, sel, offset)
+
+	mean := c.stats.MeanPerHour
+	std := c.stats.CV * mean
+	switch c.pattern {
+	case trafficPatternFlat:
+		low, high := mean-3*std, mean+3*std
+		return []TrafficThreshold{
+			{Name: "traffic_drop", Description: "Static floor 3 standard deviations below the mean hourly traffic", Value: value(low), PromQL: fmt.Sprintf(`%s < %g`, hourly, *value(low))},
+			{Name: "traffic_spike", Description: "Static ceiling 3 standard deviations above the mean hourly traffic", Value: value(high), PromQL: fmt.Sprintf(`%s > %g`, hourly, *value(high))},
+		}
+	case trafficPatternDiurnal, trafficPatternIrregular:
+		quiet := math.Inf(1)
+		for _, h := range c.quietHours {
+			quiet = math.Min(quiet, c.hourMeans[h])
+		}
+		out := []TrafficThreshold{
+			{Name: "traffic_drop", Description: fmt.Sprintf("Traffic below half of the same hour %s ago; a static floor would fire every night or miss daytime drops", offset), PromQL: fmt.Sprintf(`%s < 0.5 * %s`, hourly, relative)},
+			{Name: "traffic_spike", Description: fmt.Sprintf("Traffic above three times the same hour %s ago", offset), PromQL: fmt.Sprintf(`%s > 3 * %s`, hourly, relative)},
+		}
+		if !math.IsInf(quiet, 1) && quiet > 0 {
+			out = append(out, TrafficThreshold{Name: "traffic_floor", Description: "Static floor at half the quietest hour's mean traffic, as a backstop when the comparison has no data", Value: value(quiet / 2), PromQL: fmt.Sprintf(`%s < %g`, hourly, *value(quiet / 2))})
+		}
+		return out
+	case trafficPatternBursty:
+		return []TrafficThreshold{
+			{Name: "traffic_spike", Description: "Ceiling at twice the 99th percentile hour; bursts below it are normal for this service", Value: value(2 * c.p99), PromQL: fmt.Sprintf(`%s > %g`, hourly, *value(2 * c.p99))},
+			{Name: "traffic_absent", Description: "Alert on no traffic at all for 6 hours rather than on drops, which bursty traffic makes noisy", PromQL: fmt.Sprintf(`absent_over_time(trace_endpoint_count{%s}[6h])`, sel)},
+		}
+	}
+	return nil
+}
+
+// trafficSummary describes c in one sentence.
+func trafficSummary(c trafficClassification) string {
+	weekly := ""
+	if c.weekly && c.stats.WeekendRatio != nil {
+		weekly = fmt.Sprintf(", with weekend traffic at %.0f%% of weekdays", 100**c.stats.WeekendRatio)
+	}
+	switch c.pattern {
+	case trafficPatternDiurnal:
+		return fmt.Sprintf("Daily cycle: the hour of day explains %.0f%% of the variation, peaking at %s UTC%s.", 100*c.stats.DiurnalStrength, formatHours(c.peakHours), weekly)
+	case trafficPatternBursty:
+		return fmt.Sprintf("Bursty: %d of %d hours spike well above their usual level%s.", c.stats.BurstHours, c.stats.HourlyPoints, weekly)
+	case trafficPatternFlat:
+		return fmt.Sprintf("Flat: hourly traffic varies by %.0f%% around %.0f requests%s.", 100*c.stats.CV, c.stats.MeanPerHour, weekly)
+	case trafficPatternIrregular:
+		return fmt.Sprintf("Irregular: traffic varies by %.0f%% without a clear daily cycle%s.", 100*c.stats.CV, weekly)
+	}
+	return fmt.Sprintf("Only %d hours of traffic; at least %d are needed to classify it.", c.stats.HourlyPoints, trafficMinHours)
+}
+
+func formatHours(hours []int) string {
+	s := ""
+	for i, h := range hours {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%02d:00", h)
+	}
+	return s
+}
+
+func NewClassifyTrafficPatternHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, ClassifyTrafficPatternArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ClassifyTrafficPatternArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		days := args.Days
+		if days == 0 {
+			days = defaultTrafficPatternDays
+		}
+		if days < minTrafficPatternDays || days > maxTrafficPatternDays {
+			return nil, nil, fmt.Errorf("days must be between %d and %d, got %d", minTrafficPatternDays, maxTrafficPatternDays, days)
+		}
+		end := time.Now()
+		if args.EndTimeISO != "" {
+			var err error
+			if end, err = utils.ParseToolTimestamp(args.EndTimeISO); err != nil {
+				return nil, nil, fmt.Errorf("invalid end_time_iso: %w", err)
+			}
+		}
+		endTime := end.Unix()
+		startTime := end.AddDate(0, 0, -days).Unix()
+
+		env := resolveEnv(cfg, args.Env)
+		serverSel := fmt.Sprintf(`service_name="%s", env=~"%s", span_kind="SPAN_KIND_SERVER"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+
+		// Each point counts the requests of the hour before it.
+		points, err := fetchPromRangeSeries(ctx, client, cfg,
+			fmt.Sprintf(`sum(sum_over_time(trace_endpoint_count{%s}[1h]))`, serverSel), startTime, endTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch throughput: %w", err)
+		}
+
+		c := classifyTraffic(hourlyTraffic(points))
+		result := TrafficPattern{
+			ServiceName:         args.ServiceName,
+			Env:                 env,
+			Window:              ReleaseWindow{Start: time.Unix(startTime, 0).UTC().Format(time.RFC3339), End: time.Unix(endTime, 0).UTC().Format(time.RFC3339)},
+			Pattern:             c.pattern,
+			WeeklySeasonality:   c.weekly,
+			Summary:             trafficSummary(c),
+			PeakHoursUTC:        c.peakHours,
+			QuietHoursUTC:       c.quietHours,
+			Stats:               c.stats,
+			SuggestedThresholds: suggestTrafficThresholds(c, serverSel),
+		}
+		var caveats []string
+		if days < 7 {
+			caveats = append(caveats, fmt.Sprintf("%d days cannot show weekly seasonality; use days=7 or more", days))
+		}
+		result.Meta = buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+			fmt.Sprintf("trace_endpoint_count{%s}", serverSel),
+		), caveats...)
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildAPMServiceLink(startTime*1000, endTime*1000, args.ServiceName, env, "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,104 @@
+package apm
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// weekOfHours builds a week of hourly points starting on a Monday from f,
+// which gets the hour index.
+func weekOfHours(f func(i int) float64) []TimeSeriesPoint {
+	start := time.Date(2026, 4, 27, 0, 0, 0, 0, time.UTC)
+	points := make([]TimeSeriesPoint, 7*24)
+	for i := range points {
+		points[i] = TimeSeriesPoint{Timestamp: uint64(start.Add(time.Duration(i) * time.Hour).Unix()), Value: f(i)}
+	}
+	return points
+}
+
+func daily(i int) float64 {
+	return 1000 + 800*math.Sin(2*math.Pi*float64(i%24-6)/24)
+}
+
+func TestClassifyTraffic(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []TimeSeriesPoint
+		want   string
+		weekly bool
+	}{
+		{name: "diurnal", points: weekOfHours(daily), want: trafficPatternDiurnal},
+		{name: "weekly", points: weekOfHours(func(i int) float64 {
+			if i/24 >= 5 {
+				return 0.4 * daily(i)
+			}
+			return daily(i)
+		}), want: trafficPatternDiurnal, weekly: true},
+		{name: "flat", points: weekOfHours(func(i int) float64 { return float64(100 + i%5) }), want: trafficPatternFlat},
+		{name: "bursty", points: weekOfHours(func(i int) float64 {
+			if i%37 == 5 {
+				return 2100
+			}
+			return float64(100 + i%5)
+		}), want: trafficPatternBursty},
+		{name: "too short", points: weekOfHours(daily)[:24], want: trafficPatternInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := classifyTraffic(tt.points)
+			if c.pattern != tt.want || c.weekly != tt.weekly {
+				t.Errorf("pattern = %s, weekly = %v, want %s, %v (stats %+v)", c.pattern, c.weekly, tt.want, tt.weekly, c.stats)
+			}
+		})
+	}
+}
+
+func TestClassifyTrafficPeakHours(t *testing.T) {
+	// The daily sine peaks at 12:00 and bottoms out at 00:00.
+	c := classifyTraffic(weekOfHours(daily))
+	if got := formatHours(c.peakHours); got != "11:00, 12:00, 13:00" {
+		t.Errorf("peak hours = %s", got)
+	}
+	if got := formatHours(c.quietHours); got != "00:00, 01:00, 23:00" {
+		t.Errorf("quiet hours = %s", got)
+	}
+}
+
+func TestHourlyTraffic(t *testing.T) {
+	got := hourlyTraffic([]TimeSeriesPoint{
+		{Timestamp: 7200 + 60, Value: 4},
+		{Timestamp: 3600, Value: 1},
+		{Timestamp: 3600 + 1800, Value: 3},
+		{Timestamp: 7200, Value: math.NaN()},
+	})
+	if len(got) != 2 || got[0] != (TimeSeriesPoint{Timestamp: 3600, Value: 2}) || got[1] != (TimeSeriesPoint{Timestamp: 7200, Value: 4}) {
+		t.Errorf("hourlyTraffic = %+v", got)
+	}
+}
+
+func TestSuggestTrafficThresholds(t *testing.T) {
+	sel := `service_name="api"`
+	weekly := classifyTraffic(weekOfHours(func(i int) float64 {
+		if i/24 >= 5 {
+			return 0.4 * daily(i)
+		}
+		return daily(i)
+	}))
+	thresholds := suggestTrafficThresholds(weekly, sel)
+	if len(thresholds) == 0 || !strings.Contains(thresholds[0].PromQL, "offset 7d") {
+		t.Errorf("weekly traffic should compare with last week: %+v", thresholds)
+	}
+
+	flat := classifyTraffic(weekOfHours(func(i int) float64 { return 100 }))
+	for _, th := range suggestTrafficThresholds(flat, sel) {
+		if th.Value == nil || *th.Value != 100 {
+			t.Errorf("constant traffic: %s = %v, want 100", th.Name, th.Value)
+		}
+	}
+
+	if got := suggestTrafficThresholds(trafficClassification{pattern: trafficPatternInsufficient}, sel); got != nil {
+		t.Errorf("insufficient data should suggest nothing, got %+v", got)
+	}
+}
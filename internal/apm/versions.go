@@ -0,0 +1,273 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// --- get_service_versions tool ---
+
+type GetServiceVersionsArgs struct {
+	ServiceName     string  `json:"service_name" jsonschema:"Name of the service (required)"`
+	Env             string  `json:"env,omitempty" jsonschema:"Deployment environment to filter by (e.g. production). Default: the server default env if configured, else all environments."`
+	VersionLabel    string  `json:"version_label,omitempty" jsonschema:"Metric label holding the version (default: service_version, e.g. deployment_version)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"Minutes to look back (default: 60, minimum: 1)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339 format"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339 format"`
+}
+
+const defaultVersionLabel = "service_version"
+
+// unsetVersion names the series that do not carry the version label.
+const unsetVersion = "(unset)"
+
+// Version verdicts, relative to the baseline version.
+const (
+	versionVerdictBaseline = "baseline"
+	versionVerdictWorse    = "worse"
+	versionVerdictSimilar  = "similar"
+	versionVerdictNoData   = "no_data"
+)
+
+// ServiceVersion is one version's share of a service's traffic and its RED
+// metrics. Changes are against the baseline version, the one with the most
+// traffic; sparklines hold requests and errors per minute.
+type ServiceVersion struct {
+	Version             string     `json:"version"`
+	TrafficSharePercent *float64   `json:"traffic_share_percent,omitempty"`
+	Throughput          *float64   `json:"throughput_rpm,omitempty"`
+	ErrorPercent        *float64   `json:"error_percent,omitempty"`
+	P95Latency          *float64   `json:"p95_latency,omitempty"`
+	ErrorPercentChange  *float64   `json:"error_percent_change,omitempty"`
+	P95ChangePercent    *float64   `json:"p95_latency_change_percent,omitempty"`
+	Verdict             string     `json:"verdict"`
+	Reason              string     `json:"reason,omitempty"`
+	ThroughputSparkline []*float64 `json:"throughput_sparkline,omitempty"`
+	ErrorSparkline      []*float64 `json:"error_sparkline,omitempty"`
+}
+
+// ServiceVersionsResult is the response of get_service_versions.
+type ServiceVersionsResult struct {
+	ServiceName  string           `json:"service_name"`
+	Env          string           `json:"env"`
+	VersionLabel string           `json:"version_label"`
+	Window       ReleaseWindow    `json:"window"`
+	Baseline     string           `json:"baseline,omitempty"`
+	Versions     []ServiceVersion `json:"versions"`
+	Meta         *ResponseMeta    `json:"_meta,omitempty"`
+}
+
+// compareVersions sorts versions by throughput, busiest first, sets their
+// traffic shares and judges each against the busiest, the baseline, with
+// the check_release_health default limits. It returns the baseline's name.
+// It is pure so the comparison can be tested without a backend.
+func compareVersions(versions []ServiceVersion) string {
+	sort.SliceStable(versions, func(a, b int) bool {
+		ta, tb := valueOr(versions[a].Throughput, -1), valueOr(versions[b].Throughput, -1)
+		if ta != tb {
+			return ta > tb
+		}
+		return versions[a].Version < versions[b].Version
+	})
+	var total float64
+	for _, v := range versions {
+		total += valueOr(v.Throughput, 0)
+	}
+	if total > 0 {
+		for i := range versions {
+			if versions[i].Throughput != nil {
+				share := round1(100 * *versions[i].Throughput / total)
+				versions[i].TrafficSharePercent = &share
+			}
+		}
+	}
+	if len(versions) == 0 || versions[0].Throughput == nil {
+		for i := range versions {
+			versions[i].Verdict = versionVerdictNoData
+		}
+		return ""
+	}
+
+	base := versions[0]
+	versions[0].Verdict = versionVerdictBaseline
+	for i := 1; i < len(versions); i++ {
+		v := &versions[i]
+		if v.Throughput == nil {
+			v.Verdict = versionVerdictNoData
+			continue
+		}
+		var worse []string
+		if v.ErrorPercent != nil && base.ErrorPercent != nil {
+			change := round1(*v.ErrorPercent - *base.ErrorPercent)
+			v.ErrorPercentChange = &change
+			if change > defaultReleaseMaxErrorIncrease {
+				worse = append(worse, fmt.Sprintf("error rate %.1f points above %s", change, base.Version))
+			}
+		}
+		if v.P95Latency != nil && base.P95Latency != nil && *base.P95Latency > 0 {
+			change := round1(100 * (*v.P95Latency - *base.P95Latency) / *base.P95Latency)
+			v.P95ChangePercent = &change
+			if change > defaultReleaseMaxLatencyIncrease {
+				worse = append(worse, fmt.Sprintf("p95 latency %.1f%% above %s", change, base.Version))
+			}
+		}
+		v.Verdict = versionVerdictSimilar
+		if len(worse) > 0 {
+			v.Verdict, v.Reason = versionVerdictWorse, strings.Join(worse, ", ")
+		}
+	}
+	return base.Version
+}
+
+func NewGetServiceVersionsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetServiceVersionsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetServiceVersionsArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" {
+			return nil, nil, fmt.Errorf("service_name is required")
+		}
+		label := args.VersionLabel
+		if label == "" {
+			label = defaultVersionLabel
+		}
+		if !promLabelNamePattern.MatchString(label) {
+			return nil, nil, fmt.Errorf("invalid version_label %q", label)
+		}
+		startTime, endTime, err := resolveTimeRange(args.StartTimeISO, args.EndTimeISO, args.LookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		durationMin := max((endTime-startTime)/60, 1)
+		bucketMin := max(durationMin/sparklineBuckets, 1)
+		env := resolveEnv(cfg, args.Env)
+
+		sel := fmt.Sprintf(`service_name="%s", env=~"%s", span_kind="SPAN_KIND_SERVER"`, escapePromQLLabel(args.ServiceName), escapePromQLLabel(env))
+		errSel := sel + `, status_code="STATUS_CODE_ERROR"`
+		instant := map[string]string{
+			compareRequests:   fmt.Sprintf(`sum by (%s) (sum_over_time(trace_endpoint_count{%s}[%dm]))`, label, sel, durationMin),
+			compareErrors:     fmt.Sprintf(`sum by (%s) (sum_over_time(trace_endpoint_count{%s}[%dm]))`, label, errSel, durationMin),
+			compareLatencyP95: fmt.Sprintf(`max by (%s) (avg_over_time(trace_endpoint_duration{%s, quantile="p95"}[%dm]))`, label, sel, durationMin),
+		}
+		ranges := map[string]string{
+			compareRequests: fmt.Sprintf(`sum by (%s) (rate(trace_endpoint_count{%s}[%dm])) * 60`, label, sel, bucketMin),
+			compareErrors:   fmt.Sprintf(`sum by (%s) (rate(trace_endpoint_count{%s}[%dm])) * 60`, label, errSel, bucketMin),
+		}
+
+		var (
+			mu         sync.Mutex
+			values     = make(map[string]map[string]float64, len(instant))
+			sparklines = make(map[string]map[string][]*float64, len(ranges))
+			failures   []string
+			wg         sync.WaitGroup
+		)
+		versionOf := func(metric map[string]string) string {
+			if v := metric[label]; v != "" {
+				return v
+			}
+			return unsetVersion
+		}
+		for name, query := range instant {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				series, err := fetchPromInstant(ctx, client, cfg, query, endTime)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s query failed: %v", name, err))
+					return
+				}
+				byVersion := map[string]float64{}
+				for _, s := range series {
+					if v := promScalar(apiPromInstantResp{s}); v != nil {
+						byVersion[versionOf(s.Metric)] = *v
+					}
+				}
+				values[name] = byVersion
+			}()
+		}
+		for name, query := range ranges {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lines, err := fetchSparklinesBy(ctx, client, cfg, query, startTime, endTime, versionOf)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s sparkline query failed: %v", name, err))
+					return
+				}
+				sparklines[name] = lines
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		names := map[string]bool{}
+		for _, byVersion := range values {
+			for v := range byVersion {
+				names[v] = true
+			}
+		}
+		versions := make([]ServiceVersion, 0, len(names))
+		for name := range names {
+			v := ServiceVersion{
+				Version:             name,
+				ThroughputSparkline: sparklines[compareRequests][name],
+				ErrorSparkline:      sparklines[compareErrors][name],
+			}
+			if p95, ok := values[compareLatencyP95][name]; ok {
+				v.P95Latency = &p95
+			}
+			if requests, ok := values[compareRequests][name]; ok {
+				rpm := round1(requests / float64(durationMin))
+				v.Throughput = &rpm
+				if _, ok := values[compareErrors]; ok && requests > 0 {
+					errPct := round1(100 * values[compareErrors][name] / requests)
+					v.ErrorPercent = &errPct
+				}
+			}
+			versions = append(versions, v)
+		}
+		baseline := compareVersions(versions)
+
+		sort.Strings(failures)
+		caveats := failures
+		if len(versions) == 0 {
+			caveats = append(caveats, fmt.Sprintf("no data for %s; check the name with did_you_mean", args.ServiceName))
+		} else if len(versions) == 1 && versions[0].Version == unsetVersion {
+			caveats = append(caveats, fmt.Sprintf("no series carry the %s label; set version_label to the label your instrumentation uses (e.g. deployment_version)", label))
+		}
+		result := ServiceVersionsResult{
+			ServiceName:  args.ServiceName,
+			Env:          env,
+			VersionLabel: label,
+			Window:       ReleaseWindow{Start: time.Unix(startTime, 0).UTC().Format(time.RFC3339), End: time.Unix(endTime, 0).UTC().Format(time.RFC3339)},
+			Baseline:     baseline,
+			Versions:     versions,
+			Meta: buildResponseMeta(checkFreshness(ctx, client, cfg, startTime, endTime,
+				fmt.Sprintf("trace_endpoint_count{%s}", sel),
+			), caveats...),
+		}
+
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonBytes)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,41 @@
+package apm
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	versions := []ServiceVersion{
+		{Version: "1.5.0", Throughput: ptr(29), ErrorPercent: ptr(10.5), P95Latency: ptr(0.338)},
+		{Version: "1.4.3", Throughput: ptr(10), ErrorPercent: ptr(3.8), P95Latency: ptr(0.27)},
+		{Version: "1.4.2", Throughput: ptr(261), ErrorPercent: ptr(3.5), P95Latency: ptr(0.26)},
+		{Version: "0.9.0", P95Latency: ptr(0.2)},
+	}
+	if baseline := compareVersions(versions); baseline != "1.4.2" {
+		t.Fatalf("baseline = %q, want 1.4.2", baseline)
+	}
+
+	want := []struct {
+		version, verdict string
+		share            float64
+	}{
+		{"1.4.2", versionVerdictBaseline, 87.0},
+		{"1.5.0", versionVerdictWorse, 9.7},
+		{"1.4.3", versionVerdictSimilar, 3.3},
+	}
+	for i, w := range want {
+		v := versions[i]
+		if v.Version != w.version || v.Verdict != w.verdict || valueOr(v.TrafficSharePercent, -1) != w.share {
+			t.Errorf("versions[%d] = %s %s %v, want %s %s %v", i, v.Version, v.Verdict, valueOr(v.TrafficSharePercent, -1), w.version, w.verdict, w.share)
+		}
+	}
+	if canary := versions[1]; valueOr(canary.ErrorPercentChange, 0) != 7 || valueOr(canary.P95ChangePercent, 0) != 30 || canary.Reason == "" {
+		t.Errorf("canary = %+v", canary)
+	}
+	if ghost := versions[3]; ghost.Verdict != versionVerdictNoData || ghost.TrafficSharePercent != nil {
+		t.Errorf("version without throughput = %+v", ghost)
+	}
+
+	none := []ServiceVersion{{Version: unsetVersion, P95Latency: ptr(0.2)}}
+	if baseline := compareVersions(none); baseline != "" || none[0].Verdict != versionVerdictNoData {
+		t.Errorf("no throughput: baseline %q, verdict %q", baseline, none[0].Verdict)
+	}
+}
@@ -0,0 +1,116 @@
+package apm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// widerProbeWindows are the windows, ending at the end of the requested
+// window, probed for samples when a response has no data. Windows no wider
+// than the requested one are skipped.
+var widerProbeWindows = []time.Duration{6 * time.Hour, 24 * time.Hour}
+
+// widerProbeStep is the resolution of the probe subquery.
+const widerProbeStep = 5 * time.Minute
+
+// TimeRangeSuggestion points at data before an empty requested window.
+type TimeRangeSuggestion struct {
+	Metric                   string `json:"metric"`
+	LatestSample             string `json:"latest_sample"`
+	AgeSeconds               int64  `json:"age_seconds"` // before the end of the window
+	ProbedWindow             string `json:"probed_window"`
+	SuggestedLookbackMinutes int64  `json:"suggested_lookback_minutes"`
+}
+
+// latestSampleWithin returns the timestamp of the newest sample of selector
+// in the window before end, or 0 when there is none.
+func latestSampleWithin(ctx context.Context, client utils.HTTPClient, cfg models.Config, selector string, end int64, window time.Duration) (int64, error) {
+	query := fmt.Sprintf("max(max_over_time(timestamp(%s)[%s:%s]))", selector, promDuration(window), promDuration(widerProbeStep))
+	series, err := fetchPromInstant(ctx, client, cfg, query, end)
+	if err != nil {
+		return 0, err
+	}
+	if len(series) == 0 {
+		return 0, nil
+	}
+	if v := promScalar(series); v != nil {
+		return int64(*v), nil
+	}
+	return 0, nil
+}
+
+// suggestLookback is the lookback, in whole hours, that reaches back from
+// end past latest by the length of the requested window.
+func suggestLookback(start, end, latest int64) int64 {
+	minutes := (end - latest + end - start + 59) / 60
+	return (minutes + 59) / 60 * 60
+}
+
+// withWiderWindowProbe adds a time range suggestion to a response that
+// withDataAvailability found empty. It probes the freshness selectors over
+// progressively wider windows ending at end and, at the first window with
+// samples older than start, says how long ago the data was last seen and
+// which lookback covers it. Probe failures are ignored: the suggestion is a
+// best-effort addition to a response that is already empty.
+func (meta *ResponseMeta) withWiderWindowProbe(ctx context.Context, client utils.HTTPClient, cfg models.Config, start, end int64) *ResponseMeta {
+	if meta.DataAvailable == nil || *meta.DataAvailable || len(meta.Freshness) == 0 {
+		return meta
+	}
+	requested := time.Duration(end-start) * time.Second
+	probed, failed := time.Duration(0), false
+	for _, window := range widerProbeWindows {
+		if window <= requested {
+			continue
+		}
+		latest := make([]int64, len(meta.Freshness))
+		errs := make([]error, len(meta.Freshness))
+		var wg sync.WaitGroup
+		for i, f := range meta.Freshness {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				latest[i], errs[i] = latestSampleWithin(ctx, client, cfg, f.Metric, end, window)
+			}()
+		}
+		wg.Wait()
+
+		best := -1
+		for i := range latest {
+			if errs[i] != nil {
+				failed = true
+			}
+			if latest[i] > 0 && (best < 0 || latest[i] > latest[best]) {
+				best = i
+			}
+		}
+		probed = window
+		if best < 0 {
+			continue
+		}
+		if latest[best] >= start {
+			// The metric has data in the window; the sub-queries did not.
+			return meta
+		}
+		age := end - latest[best]
+		meta.TimeRangeSuggestion = &TimeRangeSuggestion{
+			Metric:                   meta.Freshness[best].Metric,
+			LatestSample:             time.Unix(latest[best], 0).UTC().Format(time.RFC3339),
+			AgeSeconds:               age,
+			ProbedWindow:             promDuration(window),
+			SuggestedLookbackMinutes: suggestLookback(start, end, latest[best]),
+		}
+		meta.Hints = append(meta.Hints, fmt.Sprintf("Data exists %s before the end of the window (last sample at %s); widen your range, e.g. lookback_minutes=%d.",
+			strings.TrimSuffix((time.Duration(age)*time.Second).Round(time.Minute).String(), "0s"), meta.TimeRangeSuggestion.LatestSample, meta.TimeRangeSuggestion.SuggestedLookbackMinutes))
+		return meta
+	}
+	if probed > 0 && !failed {
+		meta.Hints = append(meta.Hints, fmt.Sprintf("No samples in the %s before the end of the window either; widening the range will not help.", promDuration(probed)))
+	}
+	return meta
+}
@@ -0,0 +1,61 @@
+package apm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithWiderWindowProbe(t *testing.T) {
+	const end = int64(1700000000)
+	latest := end - 3*3600 - 600
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Query)
+		if strings.Contains(body.Query, "[24h:5m]") && strings.Contains(body.Query, "checkout") {
+			fmt.Fprintf(w, `[{"metric":{},"value":[%d,"%d"]}]`, end, latest)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	cfg := testDBConfig(server.URL)
+
+	empty := func(service string) *ResponseMeta {
+		var checks dataChecks
+		checks.record("operations.throughput", subQueryTraffic, 0)
+		freshness := []MetricFreshness{{Metric: fmt.Sprintf("trace_endpoint_count{service_name='%s'}", service), Status: freshnessNoData}}
+		return buildResponseMeta(freshness).withDataAvailability(service, ".*", checks)
+	}
+
+	meta := empty("checkout").withWiderWindowProbe(context.Background(), server.Client(), cfg, end-3600, end)
+	s := meta.TimeRangeSuggestion
+	if s == nil || s.ProbedWindow != "24h" || s.AgeSeconds != 11400 || s.SuggestedLookbackMinutes != 300 {
+		t.Fatalf("time_range_suggestion = %+v", s)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], "max(max_over_time(timestamp(trace_endpoint_count{service_name='checkout'})[6h:5m]))") {
+		t.Errorf("queries = %q, want the 6h then the 24h probe", queries)
+	}
+	if hints := strings.Join(meta.Hints, "\n"); !strings.Contains(hints, "Data exists 3h10m before the end of the window") {
+		t.Errorf("hints = %s", hints)
+	}
+
+	meta = empty("ghost").withWiderWindowProbe(context.Background(), server.Client(), cfg, end-3600, end)
+	if meta.TimeRangeSuggestion != nil || !strings.Contains(strings.Join(meta.Hints, "\n"), "No samples in the 24h") {
+		t.Errorf("no data anywhere: suggestion %+v, hints %q", meta.TimeRangeSuggestion, meta.Hints)
+	}
+
+	queries = nil
+	empty("checkout").withWiderWindowProbe(context.Background(), server.Client(), cfg, end-2*86400, end)
+	if len(queries) != 0 {
+		t.Errorf("a 2 day window was probed with %q", queries)
+	}
+}
@@ -2,63 +2,160 @@ package attributes
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/telemetry/logs"
-	"last9-mcp/internal/telemetry/traces"
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/telemetry/logs"
+	"github.com/last9/last9-mcp-server/internal/telemetry/traces"
+	"github.com/last9/last9-mcp-server/internal/utils"
 )
 
 const defaultTTL = 2 * time.Hour
 
-// AttributeCache caches log and trace attribute names fetched from the API.
+// catalogWindow is how far back environments and services are looked up.
+const catalogWindow = time.Hour
+
+// catalogSelector matches the APM series environments and services are read
+// from, as get_service_environments does.
+const catalogSelector = "domain_attributes_count{span_kind='SPAN_KIND_SERVER'}"
+
+// AttributeCache caches log and trace attribute names fetched from the API,
+// and the environments and services that recently served traffic.
 type AttributeCache struct {
 	client      *http.Client
 	cfg         models.Config
 	logAttrs    []string
 	traceAttrs  []string
+	envs        []string
+	services    []string
 	lastFetched time.Time
 	ttl         time.Duration
+	disk        *diskcache.Store
+	diskKey     string
 	mu          sync.RWMutex
 }
 
-// NewAttributeCache creates a new AttributeCache.
+// snapshot is the persisted form of the cache.
+type snapshot struct {
+	LogAttrs     []string `json:"log_attributes"`
+	TraceAttrs   []string `json:"trace_attributes"`
+	Environments []string `json:"environments,omitempty"`
+	Services     []string `json:"services,omitempty"`
+}
+
+// NewAttributeCache creates a new AttributeCache. When cfg.CacheDir is set the
+// attributes are also persisted there, keyed by org and cluster.
 func NewAttributeCache(client *http.Client, cfg models.Config) *AttributeCache {
 	return &AttributeCache{
-		client: client,
-		cfg:    cfg,
-		ttl:    defaultTTL,
+		client:  client,
+		cfg:     cfg,
+		ttl:     defaultTTL,
+		disk:    diskcache.New(cfg.CacheDir),
+		diskKey: "attributes-" + cfg.OrgSlug + "-" + cfg.ClusterID,
 	}
 }
 
-// Warm performs an initial best-effort fetch of both log and trace attributes.
-func (c *AttributeCache) Warm(ctx context.Context) {
+// Warm performs an initial best-effort fetch of log and trace attributes,
+// environments and services, in parallel. A fresh on-disk snapshot, if
+// present, is used instead of hitting the API. Lists that fail to load are
+// left empty and their errors returned joined.
+func (c *AttributeCache) Warm(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	updated := false
-	logAttrs, err := logs.FetchLogAttributeNames(ctx, c.client, c.cfg)
-	if err != nil {
-		log.Printf("Warning: failed to warm log attributes cache: %v", err)
-	} else {
-		c.logAttrs = logAttrs
-		updated = true
+	var snap snapshot
+	if fetchedAt, ok := c.disk.Get(c.diskKey, c.ttl, &snap); ok {
+		c.logAttrs = snap.LogAttrs
+		c.traceAttrs = snap.TraceAttrs
+		c.envs = snap.Environments
+		c.services = snap.Services
+		c.lastFetched = fetchedAt
+		return nil
 	}
 
-	traceAttrs, err := traces.FetchTraceAttributeNames(ctx, c.client, c.cfg)
+	fetched, err := c.fetchAll(ctx)
+	updated := false
+	for i, dst := range []*[]string{&c.logAttrs, &c.traceAttrs, &c.envs, &c.services} {
+		if fetched[i] != nil {
+			*dst = fetched[i]
+			updated = true
+		}
+	}
 	if err != nil {
-		log.Printf("Warning: failed to warm trace attributes cache: %v", err)
-	} else {
-		c.traceAttrs = traceAttrs
-		updated = true
+		logging.Logger("attributes").Warn("failed to warm attributes cache", "error", err)
 	}
-
 	if updated {
 		c.lastFetched = time.Now()
+		c.persist()
 	}
+	return err
+}
+
+// fetchAll fetches log attributes, trace attributes, environments and
+// services concurrently, in that order. A list that failed is nil.
+func (c *AttributeCache) fetchAll(ctx context.Context) ([4][]string, error) {
+	end := time.Now()
+	start := end.Add(-catalogWindow)
+	fetchers := [4]struct {
+		name  string
+		fetch func() ([]string, error)
+	}{
+		{"log attributes", func() ([]string, error) { return logs.FetchLogAttributeNames(ctx, c.client, c.cfg) }},
+		{"trace attributes", func() ([]string, error) { return traces.FetchTraceAttributeNames(ctx, c.client, c.cfg) }},
+		{"environments", func() ([]string, error) {
+			return fetchLabelValues(ctx, c.client, c.cfg, "env", start.Unix(), end.Unix())
+		}},
+		{"services", func() ([]string, error) {
+			return fetchLabelValues(ctx, c.client, c.cfg, "service_name", start.Unix(), end.Unix())
+		}},
+	}
+	var out [4][]string
+	errs := make([]error, len(fetchers))
+	var wg sync.WaitGroup
+	for i, f := range fetchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values, err := f.fetch()
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", f.name, err)
+				return
+			}
+			if values == nil {
+				values = []string{}
+			}
+			out[i] = values
+		}()
+	}
+	wg.Wait()
+	return out, errors.Join(errs...)
+}
+
+// fetchLabelValues returns the sorted values of label on the APM server
+// spans series between start and end.
+func fetchLabelValues(ctx context.Context, client *http.Client, cfg models.Config, label string, start, end int64) ([]string, error) {
+	resp, err := utils.MakePromLabelValuesAPIQuery(ctx, client, label, catalogSelector, start, end, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to execute Prometheus label values query: %s", resp.Status)
+	}
+	var values []string
+	if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode label values response: %w", err)
+	}
+	sort.Strings(values)
+	return values, nil
 }
 
 // GetLogAttributes returns cached log attribute names.
@@ -70,6 +167,29 @@ func (c *AttributeCache) GetLogAttributes() []string {
 	return attrs
 }
 
+// GetTraceAttributes returns cached trace attribute names.
+func (c *AttributeCache) GetTraceAttributes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.traceAttrs...)
+}
+
+// GetEnvironments returns the cached environments that served traffic in
+// the hour before the last fetch.
+func (c *AttributeCache) GetEnvironments() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.envs...)
+}
+
+// GetServices returns the cached services that served traffic in the hour
+// before the last fetch.
+func (c *AttributeCache) GetServices() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.services...)
+}
+
 // IsStale returns true if the cache is older than the TTL.
 func (c *AttributeCache) IsStale() bool {
 	c.mu.RLock()
@@ -92,18 +212,28 @@ func (c *AttributeCache) RefreshIfStale(ctx context.Context) error {
 		return nil
 	}
 
-	logAttrs, err := logs.FetchLogAttributeNames(ctx, c.client, c.cfg)
-	if err != nil {
+	fetched, err := c.fetchAll(ctx)
+	// Attribute names are required; environments and services are kept
+	// from the previous fetch when their lookup fails.
+	if fetched[0] == nil || fetched[1] == nil {
 		return err
 	}
-
-	traceAttrs, err := traces.FetchTraceAttributeNames(ctx, c.client, c.cfg)
-	if err != nil {
-		return err
+	c.logAttrs, c.traceAttrs = fetched[0], fetched[1]
+	if fetched[2] != nil {
+		c.envs = fetched[2]
+	}
+	if fetched[3] != nil {
+		c.services = fetched[3]
 	}
-
-	c.logAttrs = logAttrs
-	c.traceAttrs = traceAttrs
 	c.lastFetched = time.Now()
+	c.persist()
 	return nil
 }
+
+// persist writes the current attributes to disk. Callers must hold c.mu.
+func (c *AttributeCache) persist() {
+	snap := snapshot{LogAttrs: c.logAttrs, TraceAttrs: c.traceAttrs, Environments: c.envs, Services: c.services}
+	if err := c.disk.Put(c.diskKey, snap, c.lastFetched); err != nil {
+		logging.Logger("attributes").Warn("failed to persist attributes cache", "error", err)
+	}
+}
@@ -0,0 +1,87 @@
+package attributes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+func TestWarm_UsesFreshDiskSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	cfg := models.Config{CacheDir: dir, OrgSlug: "acme", ClusterID: "c1"}
+
+	store := diskcache.New(dir)
+	snap := snapshot{LogAttrs: []string{"service.name"}, TraceAttrs: []string{"http.route"}, Environments: []string{"prod"}}
+	if err := store.Put("attributes-acme-c1", snap, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("seed snapshot: %v", err)
+	}
+
+	// A nil client would panic if Warm fell through to the API.
+	c := NewAttributeCache(nil, cfg)
+	c.Warm(context.Background())
+
+	if got := c.GetLogAttributes(); len(got) != 1 || got[0] != "service.name" {
+		t.Errorf("log attributes = %v, want [service.name]", got)
+	}
+	if got := c.GetEnvironments(); len(got) != 1 || got[0] != "prod" {
+		t.Errorf("environments = %v, want [prod]", got)
+	}
+	if c.IsStale() {
+		t.Error("cache warmed from a fresh snapshot should not be stale")
+	}
+}
+
+func TestPersist_WritesSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	c := NewAttributeCache(nil, models.Config{CacheDir: dir, OrgSlug: "acme"})
+	c.logAttrs = []string{"level"}
+	c.lastFetched = time.Now()
+	c.persist()
+
+	var snap snapshot
+	if _, ok := diskcache.New(dir).Get(c.diskKey, time.Hour, &snap); !ok {
+		t.Fatal("expected persisted snapshot")
+	}
+	if len(snap.LogAttrs) != 1 || snap.LogAttrs[0] != "level" {
+		t.Errorf("persisted log attributes = %v", snap.LogAttrs)
+	}
+}
+
+func TestFetchLabelValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.EndpointPromLabelValues {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Label   string   `json:"label"`
+			Matches []string `json:"matches"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Label != "env" || len(body.Matches) != 1 || body.Matches[0] != catalogSelector {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]string{"staging", "prod"})
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "mock-token", ExpiresAt: time.Now().Add(time.Hour)}
+	end := time.Now().Unix()
+	got, err := fetchLabelValues(context.Background(), server.Client(), cfg, "env", end-3600, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "prod" || got[1] != "staging" {
+		t.Errorf("values = %v, want [prod staging]", got)
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+// Keychain item identifiers for the stored refresh token.
+const (
+	KeychainService = "last9-mcp"
+	KeychainAccount = "refresh_token"
+)
+
+// ErrKeychainUnsupported is returned on platforms without a supported keychain.
+var ErrKeychainUnsupported = errors.New("OS keychain is not supported on this platform (supported: macOS Keychain, Secret Service via secret-tool)")
+
+// runCommand executes name with args, feeding stdin and returning stdout.
+// Replaced in tests.
+var runCommand = func(ctx context.Context, stdin string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// ReadRefreshTokenFile reads a refresh token from path, trimming surrounding
+// whitespace. It warns when the file is readable by group or others.
+func ReadRefreshTokenFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read refresh token file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		logging.Logger("auth").Warn("refresh token file is accessible by other users; run chmod 600 on it", "path", path, "mode", fmt.Sprintf("%#o", info.Mode().Perm()))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read refresh token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("refresh token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// LoadRefreshTokenFromKeychain reads the refresh token stored by
+// StoreRefreshTokenInKeychain from the macOS Keychain or, on Linux, the
+// Secret Service (via secret-tool).
+func LoadRefreshTokenFromKeychain(ctx context.Context) (string, error) {
+	var (
+		out string
+		err error
+	)
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = runCommand(ctx, "", "security", "find-generic-password", "-s", KeychainService, "-a", KeychainAccount, "-w")
+	case "linux":
+		out, err = runCommand(ctx, "", "secret-tool", "lookup", "service", KeychainService, "account", KeychainAccount)
+	default:
+		return "", ErrKeychainUnsupported
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read refresh token from keychain: %w", err)
+	}
+
+	token := strings.TrimSpace(out)
+	if token == "" {
+		return "", errors.New("no refresh token found in keychain; store one with `last9-mcp store-token`")
+	}
+	return token, nil
+}
+
+// StoreRefreshTokenInKeychain saves token in the OS keychain, replacing any
+// existing entry. The token is passed on stdin so it never shows up in the
+// process list.
+func StoreRefreshTokenInKeychain(ctx context.Context, token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("refresh token is empty")
+	}
+
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		// security -i reads commands from stdin.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", KeychainService, KeychainAccount, token)
+		_, err = runCommand(ctx, command, "security", "-i")
+	case "linux":
+		_, err = runCommand(ctx, token, "secret-tool", "store", "--label=Last9 MCP refresh token", "service", KeychainService, "account", KeychainAccount)
+	default:
+		return ErrKeychainUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token in keychain: %w", err)
+	}
+	return nil
+}
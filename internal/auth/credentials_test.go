@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReadRefreshTokenFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("  my-refresh-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadRefreshTokenFile(path)
+	if err != nil || got != "my-refresh-token" {
+		t.Errorf("ReadRefreshTokenFile() = %q, %v; want my-refresh-token", got, err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRefreshTokenFile(empty); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("expected empty-file error, got %v", err)
+	}
+
+	if _, err := ReadRefreshTokenFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// stubRunCommand replaces runCommand for the duration of a test and records
+// the invocation.
+func stubRunCommand(t *testing.T, out string, err error) (name *string, args *[]string, stdin *string) {
+	t.Helper()
+	orig := runCommand
+	t.Cleanup(func() { runCommand = orig })
+
+	var gotName, gotStdin string
+	var gotArgs []string
+	runCommand = func(_ context.Context, in string, n string, a ...string) (string, error) {
+		gotName, gotArgs, gotStdin = n, a, in
+		return out, err
+	}
+	return &gotName, &gotArgs, &gotStdin
+}
+
+func TestKeychainRoundTrip(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("keychain not supported on " + runtime.GOOS)
+	}
+
+	name, args, stdin := stubRunCommand(t, "stored-token\n", nil)
+	got, err := LoadRefreshTokenFromKeychain(context.Background())
+	if err != nil || got != "stored-token" {
+		t.Fatalf("LoadRefreshTokenFromKeychain() = %q, %v", got, err)
+	}
+	if !strings.Contains(strings.Join(*args, " "), KeychainService) {
+		t.Errorf("%s %v should reference the %s service", *name, *args, KeychainService)
+	}
+
+	if err := StoreRefreshTokenInKeychain(context.Background(), " new-token \n"); err != nil {
+		t.Fatalf("StoreRefreshTokenInKeychain() error: %v", err)
+	}
+	if strings.Contains(strings.Join(*args, " "), "new-token") {
+		t.Errorf("token must not be passed as an argument: %s %v", *name, *args)
+	}
+	if !strings.Contains(*stdin, "new-token") {
+		t.Errorf("token should be passed on stdin, got %q", *stdin)
+	}
+}
+
+func TestKeychainErrors(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("keychain not supported on " + runtime.GOOS)
+	}
+
+	stubRunCommand(t, "", errors.New("item not found"))
+	if _, err := LoadRefreshTokenFromKeychain(context.Background()); err == nil || !strings.Contains(err.Error(), "item not found") {
+		t.Errorf("expected lookup error, got %v", err)
+	}
+
+	stubRunCommand(t, "\n", nil)
+	if _, err := LoadRefreshTokenFromKeychain(context.Background()); err == nil || !strings.Contains(err.Error(), "store-token") {
+		t.Errorf("expected hint to store a token, got %v", err)
+	}
+
+	if err := StoreRefreshTokenInKeychain(context.Background(), "  "); err == nil {
+		t.Error("expected error for empty token")
+	}
+}
@@ -13,11 +13,17 @@ import (
 	"sync"
 	"time"
 
-	"last9-mcp/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/constants"
 
 	last9mcp "github.com/last9/mcp-go-sdk/mcp"
 )
 
+// Background refresh retry bounds after a failed refresh.
+const (
+	minRefreshRetry = 10 * time.Second
+	maxRefreshRetry = 5 * time.Minute
+)
+
 var (
 	httpClient     *http.Client
 	httpClientOnce sync.Once
@@ -38,13 +44,39 @@ type TokenManager struct {
 	RefreshToken string
 	ExpiresAt    time.Time
 
-	// Synchronization
-	mu          sync.RWMutex
-	refreshing  bool
-	refreshCond *sync.Cond
+	// Synchronization. inflight is non-nil while a refresh is running so
+	// concurrent callers wait on the same exchange instead of starting their own.
+	mu       sync.RWMutex
+	inflight *refreshCall
 
 	// Configuration
 	refreshBuffer time.Duration
+	// refreshFunc exchanges the refresh token for a new access token. It
+	// defaults to RefreshAccessToken and is replaced in tests.
+	refreshFunc func(ctx context.Context, refreshToken string) (string, error)
+
+	// Observability
+	lastRefreshAt  time.Time
+	lastRefreshErr error
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// refreshCall tracks a single in-flight token refresh.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// TokenStatus is a point-in-time view of the access token lifecycle, exposed
+// on the health endpoint.
+type TokenStatus struct {
+	ExpiresAt        time.Time `json:"expires_at"`
+	ExpiresInSeconds int64     `json:"expires_in_seconds"`
+	RefreshAt        time.Time `json:"refresh_at"`
+	LastRefreshAt    time.Time `json:"last_refresh_at,omitempty"`
+	LastRefreshError string    `json:"last_refresh_error,omitempty"`
 }
 
 // ExtractOrgSlugFromToken extracts organization slug from JWT token
@@ -171,13 +203,15 @@ func NewTokenManager(refreshToken string) (*TokenManager, error) {
 		return nil, fmt.Errorf("failed to parse access token expiry: %w", err)
 	}
 
+	now := time.Now()
 	tm := &TokenManager{
 		AccessToken:   accessToken,
 		RefreshToken:  refreshToken,
 		ExpiresAt:     expiry,
-		refreshBuffer: expiry.Sub(time.Now()) / 2, // 50% of token lifespan
+		refreshBuffer: refreshBufferFor(expiry.Sub(now)),
+		lastRefreshAt: now,
+		stop:          make(chan struct{}),
 	}
-	tm.refreshCond = sync.NewCond(&tm.mu)
 
 	// background refresh goroutine
 	go tm.backgroundRefresh()
@@ -185,6 +219,15 @@ func NewTokenManager(refreshToken string) (*TokenManager, error) {
 	return tm, nil
 }
 
+// refreshBufferFor returns how long before expiry a token with the given
+// lifetime should be refreshed.
+func refreshBufferFor(lifetime time.Duration) time.Duration {
+	if lifetime <= 0 {
+		return 0
+	}
+	return lifetime * constants.TokenRefreshBufferPercent / 100
+}
+
 // GetTokenExpiry extracts the expiration time from a JWT access token
 func GetTokenExpiry(accessToken string) (time.Time, error) {
 	claims, err := ExtractClaimsFromToken(accessToken)
@@ -201,80 +244,177 @@ func GetTokenExpiry(accessToken string) (time.Time, error) {
 	return time.Unix(int64(exp), 0), nil
 }
 
+// GetAccessToken returns a valid access token, refreshing it first if it is
+// inside the refresh buffer. The background loop normally refreshes ahead of
+// time, so this path only blocks after a failed or missed refresh. If the
+// refresh fails but the current token has not expired yet, it is still returned.
 func (tm *TokenManager) GetAccessToken(ctx context.Context) string {
 	tm.mu.RLock()
-	defer tm.mu.RUnlock()
+	token, expiresAt, refreshAt := tm.AccessToken, tm.ExpiresAt, tm.ExpiresAt.Add(-tm.refreshBuffer)
+	tm.mu.RUnlock()
 
-	// Check if token is valid
-	if time.Now().Before(tm.ExpiresAt.Add(-tm.refreshBuffer)) {
-		return tm.AccessToken
+	if time.Now().Before(refreshAt) || tm.RefreshToken == "" {
+		return token
 	}
 
-	if !tm.refreshing {
-		go tm.refreshToken(ctx)
+	if err := tm.refresh(ctx); err != nil && time.Now().Before(expiresAt) {
+		return token
 	}
 
-	for tm.refreshing {
-		tm.refreshCond.Wait()
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.AccessToken
+}
+
+// Status reports the current token expiry and the outcome of the last refresh.
+func (tm *TokenManager) Status() TokenStatus {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	status := TokenStatus{
+		ExpiresAt:        tm.ExpiresAt,
+		ExpiresInSeconds: int64(time.Until(tm.ExpiresAt).Seconds()),
+		RefreshAt:        tm.ExpiresAt.Add(-tm.refreshBuffer),
+		LastRefreshAt:    tm.lastRefreshAt,
+	}
+	if tm.lastRefreshErr != nil {
+		status.LastRefreshError = tm.lastRefreshErr.Error()
 	}
+	return status
+}
 
-	return tm.AccessToken
+// String implements fmt.Stringer without exposing the tokens.
+func (tm *TokenManager) String() string {
+	status := tm.Status()
+	return fmt.Sprintf("TokenManager{ExpiresAt: %s}", status.ExpiresAt.Format(time.RFC3339))
+}
+
+// GoString hides the tokens from %#v as well.
+func (tm *TokenManager) GoString() string { return tm.String() }
+
+// Close stops the background refresh loop.
+func (tm *TokenManager) Close() {
+	tm.stopOnce.Do(func() {
+		if tm.stop != nil {
+			close(tm.stop)
+		}
+	})
 }
 
-func (tm *TokenManager) refreshToken(ctx context.Context) {
+// refresh exchanges the refresh token for a new access token. Concurrent
+// callers share a single exchange; each waits until it finishes or its own
+// context is done.
+func (tm *TokenManager) refresh(ctx context.Context) error {
 	tm.mu.Lock()
-	if tm.refreshing {
+	if call := tm.inflight; call != nil {
 		tm.mu.Unlock()
-		return
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	tm.refreshing = true
+	call := &refreshCall{done: make(chan struct{})}
+	tm.inflight = call
 	tm.mu.Unlock()
 
-	defer func() {
-		tm.mu.Lock()
-		tm.refreshing = false
-		tm.refreshCond.Broadcast()
-		tm.mu.Unlock()
-	}()
+	// The exchange is shared, so it must not be cancelled by whichever
+	// caller happened to start it.
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), constants.DefaultHTTPTimeout)
+	defer cancel()
+	call.err = tm.doRefresh(refreshCtx)
 
-	client := GetHTTPClient()
-	newAccessToken, err := RefreshAccessToken(ctx, client, tm.RefreshToken)
-	if err != nil {
-		return
+	tm.mu.Lock()
+	tm.inflight = nil
+	tm.mu.Unlock()
+	close(call.done)
+
+	return call.err
+}
+
+func (tm *TokenManager) doRefresh(ctx context.Context) error {
+	refreshFunc := tm.refreshFunc
+	if refreshFunc == nil {
+		refreshFunc = func(ctx context.Context, refreshToken string) (string, error) {
+			return RefreshAccessToken(ctx, GetHTTPClient(), refreshToken)
+		}
 	}
 
-	expiry, err := GetTokenExpiry(newAccessToken)
-	if err != nil {
-		return
+	newAccessToken, err := refreshFunc(ctx, tm.RefreshToken)
+	if err == nil {
+		var expiry time.Time
+		if expiry, err = GetTokenExpiry(newAccessToken); err == nil {
+			now := time.Now()
+			tm.mu.Lock()
+			tm.AccessToken = newAccessToken
+			tm.ExpiresAt = expiry
+			tm.refreshBuffer = refreshBufferFor(expiry.Sub(now))
+			tm.lastRefreshAt = now
+			tm.lastRefreshErr = nil
+			tm.mu.Unlock()
+			return nil
+		}
 	}
 
+	err = fmt.Errorf("failed to refresh access token: %w", err)
 	tm.mu.Lock()
-	tm.AccessToken = newAccessToken
-	tm.ExpiresAt = expiry
+	tm.lastRefreshErr = err
 	tm.mu.Unlock()
+	return err
+}
+
+// nextRefreshDelay returns how long the background loop should sleep before
+// the next refresh attempt. After a failure it retries sooner, but never
+// faster than minRefreshRetry.
+func (tm *TokenManager) nextRefreshDelay(now time.Time) time.Duration {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	delay := tm.ExpiresAt.Add(-tm.refreshBuffer).Sub(now)
+	if tm.lastRefreshErr != nil && delay > maxRefreshRetry {
+		delay = maxRefreshRetry
+	}
+	if delay < minRefreshRetry {
+		delay = minRefreshRetry
+	}
+	return delay
 }
 
+// backgroundRefresh refreshes the token when it enters the refresh buffer, so
+// tool calls after a long idle period do not pay for the exchange.
 func (tm *TokenManager) backgroundRefresh() {
-	ticker := time.NewTicker(45 * time.Minute)
-	defer ticker.Stop()
+	for {
+		timer := time.NewTimer(tm.nextRefreshDelay(time.Now()))
+		select {
+		case <-tm.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
 
-	for range ticker.C {
 		tm.mu.RLock()
-		needsRefresh := time.Now().After(tm.ExpiresAt.Add(-tm.refreshBuffer))
+		needsRefresh := !time.Now().Before(tm.ExpiresAt.Add(-tm.refreshBuffer))
 		tm.mu.RUnlock()
 
 		if needsRefresh {
-			tm.refreshToken(context.Background())
+			_ = tm.refresh(context.Background())
 		}
 	}
 }
 
+// GetHTTPClient returns the process-wide HTTP client used for all Last9 API
+// calls. It is built once on top of NewUpstreamTransport so every handler
+// shares a single connection pool.
 func GetHTTPClient() *http.Client {
-	// implement sync.Once if needed in future
 	httpClientOnce.Do(func() {
-		httpClient = last9mcp.WithHTTPTracing(&http.Client{
-			Timeout: constants.DefaultHTTPTimeout,
+		client := last9mcp.WithHTTPTracing(&http.Client{
+			Timeout:   constants.DefaultHTTPTimeout,
+			Transport: newUpstreamRoundTripper(),
 		})
+		upstreamMu.Lock()
+		httpClient = client
+		upstreamMu.Unlock()
 	})
 
 	return httpClient
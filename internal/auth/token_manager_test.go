@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testJWT builds an unsigned JWT whose exp claim is set to expiresAt.
+func testJWT(t *testing.T, expiresAt time.Time) string {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{"exp": expiresAt.Unix()})
+	if err != nil {
+		t.Fatalf("marshal claims: %v", err)
+	}
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestRefreshBufferFor(t *testing.T) {
+	if got := refreshBufferFor(time.Hour); got != 30*time.Minute {
+		t.Errorf("refreshBufferFor(1h) = %v, want 30m", got)
+	}
+	if got := refreshBufferFor(-time.Minute); got != 0 {
+		t.Errorf("refreshBufferFor(-1m) = %v, want 0", got)
+	}
+}
+
+func TestGetAccessToken_FreshTokenSkipsRefresh(t *testing.T) {
+	tm := &TokenManager{
+		AccessToken:   "current",
+		RefreshToken:  "refresh",
+		ExpiresAt:     time.Now().Add(time.Hour),
+		refreshBuffer: 30 * time.Minute,
+		refreshFunc: func(context.Context, string) (string, error) {
+			t.Fatal("refresh should not be called for a fresh token")
+			return "", nil
+		},
+	}
+	if got := tm.GetAccessToken(context.Background()); got != "current" {
+		t.Errorf("GetAccessToken() = %q, want current", got)
+	}
+}
+
+func TestGetAccessToken_ConcurrentCallersShareOneRefresh(t *testing.T) {
+	newToken := testJWT(t, time.Now().Add(time.Hour))
+	var calls atomic.Int32
+	release := make(chan struct{})
+	tm := &TokenManager{
+		AccessToken:   "stale",
+		RefreshToken:  "refresh",
+		ExpiresAt:     time.Now().Add(time.Minute),
+		refreshBuffer: 30 * time.Minute,
+		refreshFunc: func(context.Context, string) (string, error) {
+			calls.Add(1)
+			<-release
+			return newToken, nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = tm.GetAccessToken(context.Background())
+		}(i)
+	}
+	// Give every caller time to join the in-flight refresh.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("refresh called %d times, want 1", n)
+	}
+	for i, got := range results {
+		if got != newToken {
+			t.Errorf("caller %d got %q, want refreshed token", i, got)
+		}
+	}
+	if status := tm.Status(); status.LastRefreshError != "" || status.ExpiresInSeconds <= 0 {
+		t.Errorf("unexpected status after refresh: %+v", status)
+	}
+}
+
+func TestGetAccessToken_RefreshFailureKeepsUnexpiredToken(t *testing.T) {
+	tm := &TokenManager{
+		AccessToken:   "still-valid",
+		RefreshToken:  "refresh",
+		ExpiresAt:     time.Now().Add(time.Minute),
+		refreshBuffer: 30 * time.Minute,
+		refreshFunc: func(context.Context, string) (string, error) {
+			return "", errors.New("upstream unavailable")
+		},
+	}
+	if got := tm.GetAccessToken(context.Background()); got != "still-valid" {
+		t.Errorf("GetAccessToken() = %q, want still-valid", got)
+	}
+	status := tm.Status()
+	if status.LastRefreshError == "" {
+		t.Error("Status().LastRefreshError should record the failed refresh")
+	}
+	if got := tm.nextRefreshDelay(time.Now()); got != minRefreshRetry {
+		t.Errorf("nextRefreshDelay() after failure = %v, want %v", got, minRefreshRetry)
+	}
+}
+
+func TestNextRefreshDelay(t *testing.T) {
+	now := time.Now()
+	tm := &TokenManager{ExpiresAt: now.Add(time.Hour), refreshBuffer: 30 * time.Minute}
+	if got := tm.nextRefreshDelay(now); got != 30*time.Minute {
+		t.Errorf("nextRefreshDelay() = %v, want 30m", got)
+	}
+
+	tm.lastRefreshErr = errors.New("boom")
+	if got := tm.nextRefreshDelay(now); got != maxRefreshRetry {
+		t.Errorf("nextRefreshDelay() after failure = %v, want %v", got, maxRefreshRetry)
+	}
+}
+
+func TestBackgroundRefreshStopsOnClose(t *testing.T) {
+	tm := &TokenManager{ExpiresAt: time.Now().Add(time.Hour), refreshBuffer: time.Minute, stop: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		tm.backgroundRefresh()
+		close(done)
+	}()
+	tm.Close()
+	tm.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("backgroundRefresh did not stop after Close")
+	}
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/constants"
+)
+
+// UpstreamOptions adjusts how the shared client reaches the Last9 API from
+// networks that need it: an explicit proxy, a private CA and headers an
+// egress gateway expects on every request.
+type UpstreamOptions struct {
+	// ProxyURL routes every request through this proxy. Empty honours
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment.
+	ProxyURL string
+	// CABundleFile is a PEM file of CA certificates trusted in addition to
+	// the system roots.
+	CABundleFile string
+	// Headers are set on every request that does not already carry them.
+	Headers map[string]string
+}
+
+// upstreamConfig is the validated form of UpstreamOptions.
+type upstreamConfig struct {
+	proxy   *url.URL
+	roots   *x509.CertPool
+	headers http.Header
+}
+
+func (c upstreamConfig) isZero() bool {
+	return c.proxy == nil && c.roots == nil && len(c.headers) == 0
+}
+
+var (
+	upstreamMu sync.Mutex
+	upstream   upstreamConfig
+)
+
+// ConfigureUpstream validates opts and applies them to transports built by
+// NewUpstreamTransport and to the shared client. Options must be set before
+// the first GetHTTPClient call; afterwards the client is fixed and setting
+// any returns an error.
+func ConfigureUpstream(opts UpstreamOptions) error {
+	c, err := parseUpstreamOptions(opts)
+	if err != nil {
+		return err
+	}
+	upstreamMu.Lock()
+	defer upstreamMu.Unlock()
+	if httpClient != nil {
+		if c.isZero() {
+			return nil
+		}
+		return errors.New("upstream options must be configured before the shared HTTP client is first used")
+	}
+	upstream = c
+	return nil
+}
+
+func parseUpstreamOptions(opts UpstreamOptions) (upstreamConfig, error) {
+	var c upstreamConfig
+	if opts.ProxyURL != "" {
+		u, err := url.Parse(opts.ProxyURL)
+		if err != nil || u.Host == "" {
+			return c, fmt.Errorf("invalid proxy URL %q: want scheme://host[:port]", opts.ProxyURL)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return c, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", opts.ProxyURL)
+		}
+		c.proxy = u
+	}
+	if opts.CABundleFile != "" {
+		pem, err := os.ReadFile(opts.CABundleFile)
+		if err != nil {
+			return c, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if c.roots, err = x509.SystemCertPool(); err != nil || c.roots == nil {
+			c.roots = x509.NewCertPool()
+		}
+		if !c.roots.AppendCertsFromPEM(pem) {
+			return c, fmt.Errorf("CA bundle %s contains no PEM certificates", opts.CABundleFile)
+		}
+	}
+	for name, value := range opts.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+			return c, fmt.Errorf("invalid extra header %q", name)
+		}
+		if textproto.CanonicalMIMEHeaderKey(name) == "Authorization" {
+			return c, errors.New("extra headers cannot set Authorization; it carries the Last9 access token")
+		}
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+		c.headers.Set(name, value)
+	}
+	return c, nil
+}
+
+// ParseHeaders parses a comma-separated list of Name=Value pairs, as given
+// to --extra_headers.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("header %q: want Name=Value", pair)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// NewUpstreamTransport returns the tuned transport shared by every outbound
+// Last9 API call. It keeps a large per-host idle pool so concurrent chunked
+// queries reuse keep-alive connections, enables HTTP/2 negotiation, and
+// caches TLS sessions so re-dialled connections skip the full handshake.
+//
+// Compression stays enabled: the transport advertises Accept-Encoding: gzip
+// and transparently decompresses responses, which shrinks large PromQL range
+// and log payloads several-fold. Callers must not set Accept-Encoding
+// themselves, or net/http hands back the raw compressed body.
+//
+// The proxy and trusted roots come from ConfigureUpstream when set.
+func NewUpstreamTransport() *http.Transport {
+	upstreamMu.Lock()
+	c := upstream
+	upstreamMu.Unlock()
+	proxy := http.ProxyFromEnvironment
+	if c.proxy != nil {
+		proxy = http.ProxyURL(c.proxy)
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          constants.UpstreamMaxIdleConns,
+		MaxIdleConnsPerHost:   constants.UpstreamMaxIdleConnsPerHost,
+		IdleConnTimeout:       constants.UpstreamIdleConnTimeout,
+		TLSHandshakeTimeout:   constants.UpstreamTLSHandshakeTimeout,
+		ExpectContinueTimeout: constants.UpstreamExpectContinueTimeout,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ClientSessionCache: tls.NewLRUClientSessionCache(constants.UpstreamTLSSessionCacheSize),
+			RootCAs:            c.roots,
+		},
+	}
+}
+
+// headerTransport sets extra headers on requests that do not already carry
+// them.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var clone *http.Request
+	for name, values := range t.headers {
+		if req.Header.Get(name) != "" {
+			continue
+		}
+		if clone == nil {
+			// RoundTrippers must not modify the caller's request.
+			clone = req.Clone(req.Context())
+		}
+		clone.Header[name] = values
+	}
+	if clone == nil {
+		return t.base.RoundTrip(req)
+	}
+	return t.base.RoundTrip(clone)
+}
+
+// newUpstreamRoundTripper wraps NewUpstreamTransport with the configured
+// extra headers.
+func newUpstreamRoundTripper() http.RoundTripper {
+	upstreamMu.Lock()
+	headers := upstream.headers
+	upstreamMu.Unlock()
+	if len(headers) == 0 {
+		return NewUpstreamTransport()
+	}
+	return &headerTransport{base: NewUpstreamTransport(), headers: headers}
+}
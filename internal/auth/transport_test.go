@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"compress/gzip"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/constants"
+)
+
+func TestNewUpstreamTransport_PoolTuning(t *testing.T) {
+	tr := NewUpstreamTransport()
+
+	if tr.MaxIdleConnsPerHost != constants.UpstreamMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, want %d", tr.MaxIdleConnsPerHost, constants.UpstreamMaxIdleConnsPerHost)
+	}
+	if tr.MaxIdleConnsPerHost <= http.DefaultMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, should exceed net/http default %d", tr.MaxIdleConnsPerHost, http.DefaultMaxIdleConnsPerHost)
+	}
+	if !tr.ForceAttemptHTTP2 {
+		t.Error("expected ForceAttemptHTTP2 to be enabled")
+	}
+	if tr.TLSClientConfig == nil || tr.TLSClientConfig.ClientSessionCache == nil {
+		t.Error("expected a TLS client session cache for session resumption")
+	}
+	if tr.DisableKeepAlives {
+		t.Error("keep-alives must stay enabled")
+	}
+}
+
+func TestGetHTTPClient_IsShared(t *testing.T) {
+	if GetHTTPClient() != GetHTTPClient() {
+		t.Fatal("GetHTTPClient should return the same client on every call")
+	}
+}
+
+func TestNewUpstreamTransport_TransparentGzip(t *testing.T) {
+	const payload = `[{"metric":{"service_name":"svc"},"values":[[1700000000,"1"]]}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(payload))
+		_ = gz.Close()
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewUpstreamTransport()}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if string(body) != payload {
+		t.Fatalf("body = %q, want decompressed %q", body, payload)
+	}
+	if !resp.Uncompressed {
+		t.Error("expected the transport to report the body as transparently decompressed")
+	}
+}
+
+// setUpstream applies opts for one test without the ConfigureUpstream guard
+// against an already-built shared client.
+func setUpstream(t *testing.T, opts UpstreamOptions) {
+	t.Helper()
+	c, err := parseUpstreamOptions(opts)
+	if err != nil {
+		t.Fatalf("parseUpstreamOptions: %v", err)
+	}
+	upstreamMu.Lock()
+	prev := upstream
+	upstream = c
+	upstreamMu.Unlock()
+	t.Cleanup(func() {
+		upstreamMu.Lock()
+		upstream = prev
+		upstreamMu.Unlock()
+	})
+}
+
+func TestUpstreamOptions_CABundleAndHeaders(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Gateway-Key"); got != "gw-secret" {
+			t.Errorf("X-Gateway-Key = %q, want gw-secret", got)
+		}
+		if got := r.Header.Get("X-Team"); got != "payments" {
+			t.Errorf("X-Team = %q, want the caller's value to win", got)
+		}
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&http.Client{Transport: NewUpstreamTransport()}).Get(server.URL); err == nil {
+		t.Fatal("expected the test server's certificate to be untrusted without the CA bundle")
+	}
+
+	setUpstream(t, UpstreamOptions{
+		CABundleFile: bundle,
+		Headers:      map[string]string{"X-Gateway-Key": "gw-secret", "X-Team": "platform"},
+	})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("X-Team", "payments")
+	resp, err := (&http.Client{Transport: newUpstreamRoundTripper()}).Do(req)
+	if err != nil {
+		t.Fatalf("request with the CA bundle failed: %v", err)
+	}
+	resp.Body.Close()
+	if req.Header.Get("X-Gateway-Key") != "" {
+		t.Error("the caller's request must not be modified")
+	}
+}
+
+func TestUpstreamOptions_Proxy(t *testing.T) {
+	setUpstream(t, UpstreamOptions{ProxyURL: "http://proxy.internal:3128"})
+	req, _ := http.NewRequest(http.MethodGet, "https://app.last9.io/api/v4", nil)
+	proxy, err := NewUpstreamTransport().Proxy(req)
+	if err != nil || proxy == nil || proxy.Host != "proxy.internal:3128" {
+		t.Errorf("proxy = %v, %v; want proxy.internal:3128", proxy, err)
+	}
+}
+
+func TestParseUpstreamOptions_Invalid(t *testing.T) {
+	for _, opts := range []UpstreamOptions{
+		{ProxyURL: "proxy.internal:3128"},
+		{ProxyURL: "ftp://proxy.internal"},
+		{CABundleFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{Headers: map[string]string{"Bad Header": "x"}},
+		{Headers: map[string]string{"X-Key": "a\r\nInjected: b"}},
+		{Headers: map[string]string{"authorization": "Bearer other"}},
+	} {
+		if _, err := parseUpstreamOptions(opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got, err := ParseHeaders(" X-Gateway-Key=abc=def , X-Team=payments,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["X-Gateway-Key"] != "abc=def" || got["X-Team"] != "payments" {
+		t.Errorf("headers = %v", got)
+	}
+	if _, err := ParseHeaders("X-Gateway-Key"); err == nil {
+		t.Error("expected an error for a header without a value")
+	}
+}
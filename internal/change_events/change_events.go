@@ -10,8 +10,8 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -43,20 +43,13 @@ type GetChangeEventsArgs struct {
 	EventName       string `json:"event_name,omitempty" jsonschema:"Exact event type filter (optional). Use available_event_names from a previous call."`
 }
 
-func NewGetChangeEventsHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetChangeEventsArgs) (*mcp.CallToolResult, any, error) {
+func NewGetChangeEventsHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetChangeEventsArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetChangeEventsArgs) (*mcp.CallToolResult, any, error) {
-		timeParams := map[string]interface{}{}
-		if args.LookbackMinutes > 0 {
-			timeParams["lookback_minutes"] = args.LookbackMinutes
-		}
-		if args.StartTimeISO != "" {
-			timeParams["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			timeParams["end_time_iso"] = args.EndTimeISO
-		}
-
-		startTime, endTime, err := utils.GetTimeRange(timeParams, utils.DefaultLookbackMinutes)
+		startTime, endTime, err := utils.TimeRange{
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: float64(args.LookbackMinutes),
+		}.Resolve(utils.DefaultLookbackMinutes)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -69,56 +62,9 @@ func NewGetChangeEventsHandler(client *http.Client, cfg models.Config) func(cont
 			return nil, nil, fmt.Errorf("failed to fetch available event names: %w", err)
 		}
 
-		// Build label filters for the Prometheus query
-		var labelFilters []string
-
-		if args.ServiceName != "" {
-			labelFilters = append(labelFilters, fmt.Sprintf(`service_name="%s"`, args.ServiceName))
-		}
-
-		if args.Env != "" {
-			labelFilters = append(labelFilters, fmt.Sprintf(`env="%s"`, args.Env))
-		}
-
-		// Use event_name parameter directly - the AI should provide the exact event type
-		if args.EventName != "" {
-			labelFilters = append(labelFilters, fmt.Sprintf(`event_type="%s"`, args.EventName))
-		}
-
-		// Add default filters to exclude backup and rehydration events
-		labelFilters = append(labelFilters, `event_name!~"cold_storage_logs_backup|cold_storage_logs_backup_endtime|cold_storage_logs_backup_time_taken_in_sec|manual_rehydration_event"`)
-		labelFilters = append(labelFilters, `l9_event_name!~"last9_scheduled_search"`)
-
-		// Build the filter string
-		var filterStr string
-		if len(labelFilters) > 0 {
-			filterStr = "{" + strings.Join(labelFilters, ",") + "}"
-		}
-
-		// Build PromQL query for change events
-		promql := fmt.Sprintf("last9_change_events%s", filterStr)
-
-		// Make range query to get change events over time
-		resp, err := utils.MakePromRangeAPIQuery(ctx, client, promql, startTimeParam, endTimeParam, cfg)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to query change events: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return nil, nil, fmt.Errorf("change events API request failed with status %d: %s", resp.StatusCode, string(body))
-		}
-
-		body, err := io.ReadAll(resp.Body)
+		changeEvents, err := QueryChangeEvents(ctx, client, cfg, args.ServiceName, args.Env, args.EventName, startTimeParam, endTimeParam)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		// Parse Prometheus response into timeseries format
-		changeEvents, err := parseChangeEventsTimeSeries(body)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse change events: %w", err)
+			return nil, nil, err
 		}
 
 		result := map[string]any{
@@ -147,8 +93,66 @@ func NewGetChangeEventsHandler(client *http.Client, cfg models.Config) func(cont
 	}
 }
 
+// QueryChangeEvents returns the change events recorded between startTime and
+// endTime (unix seconds), optionally filtered by service, environment and
+// event type. Empty filters match everything.
+func QueryChangeEvents(ctx context.Context, client utils.HTTPClient, cfg models.Config, serviceName, env, eventName string, startTime, endTime int64) ([]TimeSeries, error) {
+	// Build label filters for the Prometheus query
+	var labelFilters []string
+
+	if serviceName != "" {
+		labelFilters = append(labelFilters, fmt.Sprintf(`service_name="%s"`, serviceName))
+	}
+
+	if env != "" {
+		labelFilters = append(labelFilters, fmt.Sprintf(`env="%s"`, env))
+	}
+
+	// Use event_name parameter directly - the caller should provide the exact event type
+	if eventName != "" {
+		labelFilters = append(labelFilters, fmt.Sprintf(`event_type="%s"`, eventName))
+	}
+
+	// Add default filters to exclude backup and rehydration events
+	labelFilters = append(labelFilters, `event_name!~"cold_storage_logs_backup|cold_storage_logs_backup_endtime|cold_storage_logs_backup_time_taken_in_sec|manual_rehydration_event"`)
+	labelFilters = append(labelFilters, `l9_event_name!~"last9_scheduled_search"`)
+
+	// Build the filter string
+	var filterStr string
+	if len(labelFilters) > 0 {
+		filterStr = "{" + strings.Join(labelFilters, ",") + "}"
+	}
+
+	// Build PromQL query for change events
+	promql := fmt.Sprintf("last9_change_events%s", filterStr)
+
+	// Make range query to get change events over time
+	resp, err := utils.MakePromRangeAPIQuery(ctx, client, promql, startTime, endTime, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("change events API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Parse Prometheus response into timeseries format
+	changeEvents, err := parseChangeEventsTimeSeries(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse change events: %w", err)
+	}
+	return changeEvents, nil
+}
+
 // fetchAvailableEventNames fetches all available event_name values from the last9_change_events metric
-func fetchAvailableEventNames(ctx context.Context, client *http.Client, startTime, endTime int64, cfg models.Config) ([]string, error) {
+func fetchAvailableEventNames(ctx context.Context, client utils.HTTPClient, startTime, endTime int64, cfg models.Config) ([]string, error) {
 	// Use the label values API to get all event_name values
 	resp, err := utils.MakePromLabelValuesAPIQuery(ctx, client, "event_type", "last9_change_events", startTime, endTime, cfg)
 	if err != nil {
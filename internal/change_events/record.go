@@ -0,0 +1,141 @@
+package change_events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultDeploymentEventName = "deployment"
+	maxRecordErrorBodyBytes    = 4096
+)
+
+// RecordDeploymentArgs represents the input arguments for the record_deployment tool
+type RecordDeploymentArgs struct {
+	ServiceName string            `json:"service_name" jsonschema:"Service that was deployed or changed (required)"`
+	Env         string            `json:"env,omitempty" jsonschema:"Deployment environment (e.g. production)"`
+	Version     string            `json:"version,omitempty" jsonschema:"Version, tag or commit SHA that was deployed"`
+	Author      string            `json:"author,omitempty" jsonschema:"Person or pipeline that made the change"`
+	TimeISO     string            `json:"time_iso,omitempty" jsonschema:"When the change happened, in RFC3339 format (default: now)"`
+	EventName   string            `json:"event_name,omitempty" jsonschema:"Change event name (default: deployment)"`
+	EventState  string            `json:"event_state,omitempty" jsonschema:"Marks the beginning (start, the default) or end (stop) of a rollout (one of: start, stop)"`
+	Attributes  map[string]string `json:"attributes,omitempty" jsonschema:"Additional attributes to attach (e.g. pr, ticket, region)"`
+}
+
+// changeEventRequest is the body of the Last9 change events API.
+type changeEventRequest struct {
+	Timestamp      string            `json:"timestamp"`
+	EventName      string            `json:"event_name"`
+	EventState     string            `json:"event_state"`
+	DataSourceName string            `json:"data_source_name,omitempty"`
+	Attributes     map[string]string `json:"attributes"`
+}
+
+func buildChangeEventRequest(args RecordDeploymentArgs, cfg models.Config, now time.Time) (changeEventRequest, error) {
+	serviceName := strings.TrimSpace(args.ServiceName)
+	if serviceName == "" {
+		return changeEventRequest{}, fmt.Errorf("service_name is required")
+	}
+
+	timestamp := now.UTC()
+	if args.TimeISO != "" {
+		t, err := time.Parse(time.RFC3339, args.TimeISO)
+		if err != nil {
+			return changeEventRequest{}, fmt.Errorf("invalid time_iso format: %w", err)
+		}
+		timestamp = t
+	}
+
+	state := strings.ToLower(strings.TrimSpace(args.EventState))
+	if state == "" {
+		state = "start"
+	}
+
+	eventName := strings.TrimSpace(args.EventName)
+	if eventName == "" {
+		eventName = defaultDeploymentEventName
+	}
+
+	attributes := make(map[string]string, len(args.Attributes)+4)
+	for k, v := range args.Attributes {
+		attributes[k] = v
+	}
+	// Well-known fields win over free-form attributes of the same name.
+	attributes["service_name"] = serviceName
+	for key, value := range map[string]string{"env": args.Env, "version": args.Version, "author": args.Author} {
+		if value = strings.TrimSpace(value); value != "" {
+			attributes[key] = value
+		}
+	}
+
+	return changeEventRequest{
+		Timestamp:      timestamp.Format(time.RFC3339),
+		EventName:      eventName,
+		EventState:     state,
+		DataSourceName: cfg.DatasourceName,
+		Attributes:     attributes,
+	}, nil
+}
+
+func NewRecordDeploymentHandler(client utils.HTTPClient, cfg models.Config) func(context.Context, *mcp.CallToolRequest, RecordDeploymentArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args RecordDeploymentArgs) (*mcp.CallToolResult, any, error) {
+		event, err := buildChangeEventRequest(args, cfg, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal change event: %w", err)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, cfg.APIBaseURL+constants.EndpointChangeEvents, bytes.NewReader(payload))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		httpReq.Header.Set(constants.HeaderContentType, constants.HeaderContentTypeJSON)
+		httpReq.Header.Set(constants.HeaderAccept, constants.HeaderAcceptJSON)
+		httpReq.Header.Set(constants.HeaderXLast9APIToken, constants.BearerPrefix+cfg.TokenManager.GetAccessToken(ctx))
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to make request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxRecordErrorBodyBytes))
+			return nil, nil, fmt.Errorf("change events API request failed with status %d: %s", resp.StatusCode, string(body))
+		}
+
+		result := map[string]any{
+			"recorded": true,
+			"event":    event,
+			"note":     fmt.Sprintf("Use get_change_events with service_name=%q to see this event alongside other changes.", event.Attributes["service_name"]),
+		}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: string(resultJSON),
+				},
+			},
+		}, nil, nil
+	}
+}
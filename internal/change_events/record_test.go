@@ -0,0 +1,130 @@
+package change_events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestBuildChangeEventRequest(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	cfg := models.Config{DatasourceName: "prod-metrics"}
+
+	event, err := buildChangeEventRequest(RecordDeploymentArgs{
+		ServiceName: " checkout ",
+		Env:         "prod",
+		Version:     "v1.4.2",
+		Author:      "ci",
+		Attributes:  map[string]string{"pr": "1234", "service_name": "ignored"},
+	}, cfg, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Timestamp != "2026-03-01T10:00:00Z" || event.EventName != "deployment" || event.EventState != "start" {
+		t.Errorf("unexpected defaults: %+v", event)
+	}
+	if event.DataSourceName != "prod-metrics" {
+		t.Errorf("data_source_name = %q, want prod-metrics", event.DataSourceName)
+	}
+	want := map[string]string{"service_name": "checkout", "env": "prod", "version": "v1.4.2", "author": "ci", "pr": "1234"}
+	for k, v := range want {
+		if event.Attributes[k] != v {
+			t.Errorf("attributes[%s] = %q, want %q", k, event.Attributes[k], v)
+		}
+	}
+
+	event, err = buildChangeEventRequest(RecordDeploymentArgs{ServiceName: "checkout", TimeISO: "2026-02-09T15:04:05+05:30", EventState: "STOP"}, cfg, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Timestamp != "2026-02-09T15:04:05+05:30" || event.EventState != "stop" {
+		t.Errorf("explicit time/state not honoured: %+v", event)
+	}
+	if _, ok := event.Attributes["env"]; ok {
+		t.Errorf("empty env should be omitted: %v", event.Attributes)
+	}
+}
+
+func TestBuildChangeEventRequest_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		args RecordDeploymentArgs
+		want string
+	}{
+		{"missing service", RecordDeploymentArgs{}, "service_name is required"},
+		{"bad time", RecordDeploymentArgs{ServiceName: "a", TimeISO: "yesterday"}, "invalid time_iso"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildChangeEventRequest(tt.args, models.Config{}, time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordDeploymentHandler(t *testing.T) {
+	var (
+		gotMethod string
+		gotToken  string
+		gotBody   changeEventRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.EndpointChangeEvents {
+			http.NotFound(w, r)
+			return
+		}
+		gotMethod = r.Method
+		gotToken = r.Header.Get(constants.HeaderXLast9APIToken)
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "test-token", ExpiresAt: time.Now().Add(time.Hour)}
+
+	handler := NewRecordDeploymentHandler(server.Client(), cfg)
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, RecordDeploymentArgs{ServiceName: "checkout", Version: "v2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodPut || gotToken != "Bearer test-token" {
+		t.Errorf("request = %s with token %q, want PUT with bearer token", gotMethod, gotToken)
+	}
+	if gotBody.Attributes["version"] != "v2" || gotBody.EventName != "deployment" {
+		t.Errorf("unexpected body: %+v", gotBody)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, `"recorded":true`) {
+		t.Errorf("unexpected response: %s", text)
+	}
+}
+
+func TestRecordDeploymentHandler_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"write scope required"}`))
+	}))
+	defer server.Close()
+
+	cfg := models.Config{APIBaseURL: server.URL}
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "test-token", ExpiresAt: time.Now().Add(time.Hour)}
+
+	handler := NewRecordDeploymentHandler(server.Client(), cfg)
+	_, _, err := handler(context.Background(), &mcp.CallToolRequest{}, RecordDeploymentArgs{ServiceName: "checkout"})
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "write scope required") {
+		t.Fatalf("error = %v, want 403 with body", err)
+	}
+}
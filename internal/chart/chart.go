@@ -0,0 +1,354 @@
+// Package chart renders time series as line charts. It depends only on the
+// standard library: SVG output carries a title, axis labels and a legend,
+// while PNG output (for clients that only display raster images) draws the
+// grid and lines without text, so callers should describe the axes and
+// series alongside it.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+	"time"
+)
+
+// Default canvas size in pixels.
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 400
+)
+
+// Layout margins in pixels.
+const (
+	marginLeft   = 64
+	marginRight  = 20
+	marginTop    = 32
+	marginBottom = 36
+	legendRow    = 16
+	maxLabelLen  = 60
+)
+
+// Palette holds the series colors, in order. Charts draw at most
+// len(Palette) series.
+var Palette = []color.RGBA{
+	{31, 119, 180, 255},
+	{255, 127, 14, 255},
+	{44, 160, 44, 255},
+	{214, 39, 40, 255},
+	{148, 103, 189, 255},
+	{140, 86, 75, 255},
+	{227, 119, 194, 255},
+	{127, 127, 127, 255},
+	{188, 189, 34, 255},
+	{23, 190, 207, 255},
+}
+
+var (
+	background = color.RGBA{255, 255, 255, 255}
+	gridColor  = color.RGBA{225, 225, 225, 255}
+	axisColor  = color.RGBA{90, 90, 90, 255}
+)
+
+// Series is one line. Values are aligned to Chart.Timestamps; nil marks a
+// missing sample and breaks the line.
+type Series struct {
+	Label  string
+	Values []*float64
+}
+
+// Chart describes what to draw.
+type Chart struct {
+	Title      string
+	Timestamps []int64 // unix seconds, ascending
+	Series     []Series
+	Width      int
+	Height     int
+}
+
+// Hex returns the color as #rrggbb.
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// layout maps data coordinates onto the canvas.
+type layout struct {
+	width, height         int
+	left, right, top, bot float64
+	t0, t1                int64
+	yMin, yMax            float64
+	yTicks                []float64
+	series                []Series
+}
+
+func newLayout(c Chart, withLegend bool) (layout, error) {
+	if len(c.Timestamps) == 0 {
+		return layout{}, fmt.Errorf("nothing to chart: the query returned no samples")
+	}
+	series := c.Series
+	if len(series) > len(Palette) {
+		series = series[:len(Palette)]
+	}
+	l := layout{
+		width:  c.Width,
+		height: c.Height,
+		t0:     c.Timestamps[0],
+		t1:     c.Timestamps[len(c.Timestamps)-1],
+		series: series,
+	}
+	if l.width <= 0 {
+		l.width = DefaultWidth
+	}
+	if l.height <= 0 {
+		l.height = DefaultHeight
+	}
+	if withLegend {
+		l.height += legendRow * len(series)
+	}
+	l.left = marginLeft
+	l.right = float64(l.width - marginRight)
+	l.top = marginTop
+	l.bot = float64(l.height - marginBottom)
+	if withLegend {
+		l.bot -= float64(legendRow * len(series))
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, v := range s.Values {
+			if v != nil {
+				lo, hi = math.Min(lo, *v), math.Max(hi, *v)
+			}
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return layout{}, fmt.Errorf("nothing to chart: every sample is missing")
+	}
+	l.yMin, l.yMax, l.yTicks = niceScale(lo, hi, 5)
+	return l, nil
+}
+
+func (l layout) x(i int, timestamps []int64) float64 {
+	if l.t1 == l.t0 {
+		return (l.left + l.right) / 2
+	}
+	return l.left + float64(timestamps[i]-l.t0)/float64(l.t1-l.t0)*(l.right-l.left)
+}
+
+func (l layout) y(v float64) float64 {
+	return l.bot - (v-l.yMin)/(l.yMax-l.yMin)*(l.bot-l.top)
+}
+
+// segments splits a series into runs of consecutive present samples.
+func segments(values []*float64) [][2]int {
+	var out [][2]int
+	start := -1
+	for i, v := range values {
+		switch {
+		case v != nil && start < 0:
+			start = i
+		case v == nil && start >= 0:
+			out = append(out, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, [2]int{start, len(values)})
+	}
+	return out
+}
+
+// SVG renders the chart as an SVG document.
+func SVG(c Chart) ([]byte, error) {
+	l, err := newLayout(c, true)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n", l.width, l.height, l.width, l.height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", Hex(background))
+	if c.Title != "" {
+		fmt.Fprintf(&b, `<text x="%.1f" y="20" font-size="14" text-anchor="middle">%s</text>`+"\n", float64(l.width)/2, html.EscapeString(truncate(c.Title, 100)))
+	}
+
+	for _, tick := range l.yTicks {
+		y := l.y(tick)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", l.left, y, l.right, y, Hex(gridColor))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", l.left-6, y, formatValue(tick))
+	}
+	for _, ts := range timeTicks(l.t0, l.t1, 5) {
+		x := l.left
+		if l.t1 > l.t0 {
+			x += float64(ts-l.t0) / float64(l.t1-l.t0) * (l.right - l.left)
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", x, l.bot+16, formatTime(ts, l.t1-l.t0))
+	}
+	fmt.Fprintf(&b, `<polyline points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="none" stroke="%s"/>`+"\n", l.left, l.top, l.left, l.bot, l.right, l.bot, Hex(axisColor))
+
+	for i, s := range l.series {
+		stroke := Hex(Palette[i])
+		for _, seg := range segments(s.Values) {
+			if seg[1]-seg[0] == 1 {
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="2" fill="%s"/>`+"\n", l.x(seg[0], c.Timestamps), l.y(*s.Values[seg[0]]), stroke)
+				continue
+			}
+			var points []string
+			for j := seg[0]; j < seg[1]; j++ {
+				points = append(points, fmt.Sprintf("%.1f,%.1f", l.x(j, c.Timestamps), l.y(*s.Values[j])))
+			}
+			fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5"/>`+"\n", strings.Join(points, " "), stroke)
+		}
+		y := float64(l.height-marginBottom) - float64(legendRow*(len(l.series)-1-i)) + 8
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="10" height="10" fill="%s"/>`+"\n", l.left, y-9, stroke)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f">%s</text>`+"\n", l.left+16, y, html.EscapeString(truncate(s.Label, maxLabelLen)))
+	}
+	b.WriteString("</svg>\n")
+	return []byte(b.String()), nil
+}
+
+// PNG renders the chart as a PNG image: grid, axes and lines, no text.
+func PNG(c Chart) ([]byte, error) {
+	l, err := newLayout(c, false)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	for _, tick := range l.yTicks {
+		y := l.y(tick)
+		line(img, l.left, y, l.right, y, gridColor, 1)
+	}
+	line(img, l.left, l.top, l.left, l.bot, axisColor, 1)
+	line(img, l.left, l.bot, l.right, l.bot, axisColor, 1)
+
+	for i, s := range l.series {
+		for _, seg := range segments(s.Values) {
+			if seg[1]-seg[0] == 1 {
+				x, y := l.x(seg[0], c.Timestamps), l.y(*s.Values[seg[0]])
+				line(img, x, y, x, y, Palette[i], 3)
+				continue
+			}
+			for j := seg[0] + 1; j < seg[1]; j++ {
+				line(img, l.x(j-1, c.Timestamps), l.y(*s.Values[j-1]), l.x(j, c.Timestamps), l.y(*s.Values[j]), Palette[i], 2)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// line draws a straight line of the given pixel thickness.
+func line(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA, thickness int) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0)))
+	if steps == 0 {
+		steps = 1
+	}
+	offset := thickness / 2
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x0 + (x1-x0)*t))
+		y := int(math.Round(y0 + (y1-y0)*t))
+		for dx := -offset; dx < thickness-offset; dx++ {
+			for dy := -offset; dy < thickness-offset; dy++ {
+				img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+}
+
+// niceScale widens [lo, hi] to round tick boundaries and returns the ticks.
+func niceScale(lo, hi float64, maxTicks int) (float64, float64, []float64) {
+	if lo == hi {
+		pad := math.Abs(lo) * 0.1
+		if pad == 0 {
+			pad = 1
+		}
+		lo, hi = lo-pad, hi+pad
+	}
+	step := niceNum((hi-lo)/float64(maxTicks-1), true)
+	lo = math.Floor(lo/step) * step
+	hi = math.Ceil(hi/step) * step
+	var ticks []float64
+	for v := lo; v <= hi+step/2; v += step {
+		ticks = append(ticks, math.Round(v/step)*step)
+	}
+	return lo, hi, ticks
+}
+
+// niceNum returns a 1, 2 or 5 multiple of a power of ten close to x.
+func niceNum(x float64, round bool) float64 {
+	exp := math.Floor(math.Log10(x))
+	f := x / math.Pow(10, exp)
+	var nf float64
+	switch {
+	case round && f < 1.5, !round && f <= 1:
+		nf = 1
+	case round && f < 3, !round && f <= 2:
+		nf = 2
+	case round && f < 7, !round && f <= 5:
+		nf = 5
+	default:
+		nf = 10
+	}
+	return nf * math.Pow(10, exp)
+}
+
+// timeTicks returns up to n evenly spaced timestamps covering [t0, t1].
+func timeTicks(t0, t1 int64, n int) []int64 {
+	if t1 <= t0 {
+		return []int64{t0}
+	}
+	ticks := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		ticks = append(ticks, t0+(t1-t0)*int64(i)/int64(n-1))
+	}
+	return ticks
+}
+
+func formatTime(ts, span int64) string {
+	t := time.Unix(ts, 0).UTC()
+	if span > 24*60*60 {
+		return t.Format("01-02 15:04")
+	}
+	return t.Format("15:04")
+}
+
+func formatValue(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e9:
+		return trimZeros(v/1e9) + "G"
+	case abs >= 1e6:
+		return trimZeros(v/1e6) + "M"
+	case abs >= 1e3:
+		return trimZeros(v/1e3) + "k"
+	default:
+		return trimZeros(v)
+	}
+}
+
+func trimZeros(v float64) string {
+	s := fmt.Sprintf("%.3f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func truncate(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -0,0 +1,81 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func sample() Chart {
+	return Chart{
+		Title:      "errors <5xx>",
+		Timestamps: []int64{1700000000, 1700000060, 1700000120, 1700000180},
+		Series: []Series{
+			{Label: `{service="a"}`, Values: []*float64{ptr(1), ptr(3), nil, ptr(2)}},
+			{Label: `{service="b"}`, Values: []*float64{ptr(10), ptr(12), ptr(11), ptr(9)}},
+		},
+	}
+}
+
+func TestSVG(t *testing.T) {
+	out, err := SVG(sample())
+	if err != nil {
+		t.Fatalf("SVG() error: %v", err)
+	}
+	svg := string(out)
+	for _, want := range []string{"<svg", "errors &lt;5xx&gt;", `{service=&#34;a&#34;}`, Hex(Palette[1]), "<circle"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg missing %q", want)
+		}
+	}
+	// The gap in series a splits it into a polyline and a single point.
+	if got := strings.Count(svg, `stroke-width="1.5"`); got != 2 {
+		t.Errorf("series polylines = %d, want 2", got)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	out, err := PNG(sample())
+	if err != nil {
+		t.Fatalf("PNG() error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("invalid png: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != DefaultWidth || b.Dy() != DefaultHeight {
+		t.Errorf("size = %v", b)
+	}
+}
+
+func TestNoData(t *testing.T) {
+	if _, err := PNG(Chart{}); err == nil {
+		t.Error("expected error for empty chart")
+	}
+	c := Chart{Timestamps: []int64{1}, Series: []Series{{Values: []*float64{nil}}}}
+	if _, err := SVG(c); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("err = %v, want all-missing error", err)
+	}
+}
+
+func TestNiceScale(t *testing.T) {
+	lo, hi, ticks := niceScale(0.3, 9.2, 5)
+	if lo != 0 || hi != 10 || !reflect.DeepEqual(ticks, []float64{0, 2, 4, 6, 8, 10}) {
+		t.Errorf("niceScale = %v, %v, %v", lo, hi, ticks)
+	}
+	if lo, hi, _ := niceScale(5, 5, 5); lo >= 5 || hi <= 5 {
+		t.Errorf("flat series should be padded, got [%v, %v]", lo, hi)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for in, want := range map[float64]string{0: "0", 0.25: "0.25", 1500: "1.5k", 2e6: "2M", -3e9: "-3G"} {
+		if got := formatValue(in); got != want {
+			t.Errorf("formatValue(%v) = %s, want %s", in, got, want)
+		}
+	}
+}
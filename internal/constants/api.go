@@ -33,8 +33,12 @@ const (
 	EndpointAlertsMonitor        = "/alerts/monitor"
 	EndpointEntitiesList         = "/entities/list"
 	EndpointEntityKPI            = "/entities/%s/kpis/%s"
+	EndpointEntityKPIs           = "/entities/%s/kpis"
 	EndpointEntityAlertRules     = "/entities/%s/alert-rules"
+	EndpointEntityAlertRule      = "/entities/%s/alert-rules/%s"
 	EndpointNotificationSettings = "/notification_settings"
+	// EndpointChangeEvents records change events (deployments, config changes) via PUT.
+	EndpointChangeEvents = "/change_events"
 	// EndpointSuggest returns fuzzy entity-name suggestions for the did_you_mean tool.
 	EndpointSuggest = "/suggest"
 
@@ -50,6 +54,10 @@ const (
 	// PerChunkHTTPTimeout bounds a single chunked upstream call so one slow
 	// chunk can't stall the whole tool invocation. ENG-914.
 	PerChunkHTTPTimeout = 30 * time.Second
+
+	// TokenRefreshBufferPercent is the percentage of access token lifetime
+	// left at which the token manager refreshes it (50%).
+	TokenRefreshBufferPercent = 50
 )
 
 // HTTP Headers
@@ -67,3 +75,29 @@ const BearerPrefix = "Bearer "
 
 // User Agent
 const UserAgentLast9MCP = "Last9-MCP-Server/1.0"
+
+// HTTP transport hardening against slow or oversized requests. Reading a
+// request, headers and body, must finish within HTTPServerReadTimeout; the
+// write timeout stays at DefaultHTTPTimeout because tool calls can run that
+// long.
+const (
+	HTTPServerReadHeaderTimeout = 10 * time.Second
+	HTTPServerReadTimeout       = 30 * time.Second
+	HTTPServerIdleTimeout       = 60 * time.Second
+	HTTPServerMaxHeaderBytes    = 64 << 10
+)
+
+// Upstream connection pool tuning for the shared HTTP client. Tool handlers
+// fan out many concurrent PromQL/log/trace queries to the same Last9 API
+// host, so the per-host idle pool must be much larger than net/http's
+// default of 2 or every burst reopens TCP+TLS connections.
+const (
+	UpstreamMaxIdleConns          = 100
+	UpstreamMaxIdleConnsPerHost   = 32
+	UpstreamIdleConnTimeout       = 90 * time.Second
+	UpstreamTLSHandshakeTimeout   = 10 * time.Second
+	UpstreamExpectContinueTimeout = 1 * time.Second
+	// UpstreamTLSSessionCacheSize bounds the client-side TLS session cache used
+	// for session resumption when a pooled connection has to be re-dialled.
+	UpstreamTLSSessionCacheSize = 64
+)
@@ -0,0 +1,266 @@
+// Package criticality stores the criticality tier of endpoints (critical,
+// high, standard or low) with optional SLA targets, so reports can judge
+// latency and error compliance per tier. Endpoints are tagged with
+// tag_endpoint_criticality and kept in a JSON file so tags survive restarts.
+package criticality
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+const (
+	// maxTags bounds how many endpoints can be tagged.
+	maxTags = 2000
+	// AllEndpoints as the endpoint tags every endpoint of a service. A tag
+	// for a specific endpoint takes precedence.
+	AllEndpoints = "*"
+)
+
+// Tiers, from most to least critical.
+const (
+	TierCritical = "critical"
+	TierHigh     = "high"
+	TierStandard = "standard"
+	TierLow      = "low"
+)
+
+// Tiers lists the tiers from most to least critical.
+var Tiers = []string{TierCritical, TierHigh, TierStandard, TierLow}
+
+// Targets are the SLA targets of an endpoint: its p95 latency and error
+// percentage must stay at or below them.
+type Targets struct {
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	ErrorPercent float64 `json:"error_percent"`
+}
+
+// DefaultTargets are the targets of each tier when a tag sets none.
+var DefaultTargets = map[string]Targets{
+	TierCritical: {P95LatencyMs: 300, ErrorPercent: 0.1},
+	TierHigh:     {P95LatencyMs: 500, ErrorPercent: 0.5},
+	TierStandard: {P95LatencyMs: 1000, ErrorPercent: 1},
+	TierLow:      {P95LatencyMs: 2000, ErrorPercent: 5},
+}
+
+// Tag assigns an endpoint (a server span name such as "POST /api/checkout")
+// of a service to a tier. Zero targets fall back to the tier's defaults.
+type Tag struct {
+	ServiceName        string    `json:"service_name"`
+	Endpoint           string    `json:"endpoint"`
+	Tier               string    `json:"tier"`
+	P95TargetMs        float64   `json:"p95_target_ms,omitempty"`
+	ErrorPercentTarget float64   `json:"error_percent_target,omitempty"`
+	Note               string    `json:"note,omitempty"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Targets returns the tag's targets, filling unset ones from the tier.
+func (t Tag) Targets() Targets {
+	targets := DefaultTargets[t.Tier]
+	if t.P95TargetMs > 0 {
+		targets.P95LatencyMs = t.P95TargetMs
+	}
+	if t.ErrorPercentTarget > 0 {
+		targets.ErrorPercent = t.ErrorPercentTarget
+	}
+	return targets
+}
+
+// Validate checks a tag's service, endpoint, tier and targets.
+func (t Tag) Validate() error {
+	if strings.TrimSpace(t.ServiceName) == "" {
+		return fmt.Errorf("service_name is required")
+	}
+	if strings.TrimSpace(t.Endpoint) == "" {
+		return fmt.Errorf("endpoint is required (use %q for every endpoint of the service)", AllEndpoints)
+	}
+	if _, ok := DefaultTargets[t.Tier]; !ok {
+		return fmt.Errorf("tier must be one of %s, got %q", strings.Join(Tiers, ", "), t.Tier)
+	}
+	if t.P95TargetMs < 0 || t.ErrorPercentTarget < 0 || t.ErrorPercentTarget > 100 {
+		return fmt.Errorf("targets must be positive and error_percent_target at most 100")
+	}
+	return nil
+}
+
+// Rank orders tiers from most critical (0) to least; unknown tiers last.
+func Rank(tier string) int {
+	for i, t := range Tiers {
+		if t == tier {
+			return i
+		}
+	}
+	return len(Tiers)
+}
+
+// Store holds endpoint tags. A nil *Store is valid and has no tags.
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	tags []Tag
+	// loadErr is why the file could not be loaded. While it is set the
+	// store refuses to write, so a file it failed to parse is not replaced.
+	loadErr error
+}
+
+// DefaultPath returns the criticality file in the per-user cache directory,
+// or "" when the platform has none.
+func DefaultPath() string {
+	dir := diskcache.DefaultDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "criticality.json")
+}
+
+// New returns a store persisted to path, loading the tags already in it.
+// An empty path keeps tags in memory for the life of the process. A file
+// that cannot be read or parsed is logged and does not block startup, but
+// the store then has no tags and refuses to save over it.
+func New(path string) *Store {
+	s := &Store{path: path}
+	if path == "" {
+		return s
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s
+	}
+	if err == nil {
+		err = json.Unmarshal(raw, &s.tags)
+	}
+	if err != nil {
+		s.tags = nil
+		s.loadErr = fmt.Errorf("failed to load criticality file %s: %w", path, err)
+		logging.Logger("criticality").Warn("ignoring endpoint tags", "path", path, "error", err)
+	}
+	return s
+}
+
+// Set validates t and stores it, replacing the tag of the same service and
+// endpoint if there is one.
+func (s *Store) Set(t Tag) (Tag, error) {
+	t.ServiceName, t.Endpoint = strings.TrimSpace(t.ServiceName), strings.TrimSpace(t.Endpoint)
+	t.Tier = strings.ToLower(strings.TrimSpace(t.Tier))
+	if err := t.Validate(); err != nil {
+		return t, err
+	}
+	t.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.tags
+	s.tags = append([]Tag{}, s.tags...)
+	if i := s.index(t.ServiceName, t.Endpoint); i >= 0 {
+		s.tags[i] = t
+	} else {
+		if len(s.tags) >= maxTags {
+			s.tags = previous
+			return t, fmt.Errorf("cannot tag more than %d endpoints; delete one with delete_endpoint_criticality first", maxTags)
+		}
+		s.tags = append(s.tags, t)
+	}
+	if err := s.write(); err != nil {
+		s.tags = previous
+		return t, err
+	}
+	return t, nil
+}
+
+// Delete removes the tag of the service and endpoint and reports whether
+// it existed.
+func (s *Store) Delete(service, endpoint string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(service, endpoint)
+	if i < 0 {
+		return false, nil
+	}
+	previous := s.tags
+	s.tags = append(append([]Tag{}, s.tags[:i]...), s.tags[i+1:]...)
+	if err := s.write(); err != nil {
+		s.tags = previous
+		return false, err
+	}
+	return true, nil
+}
+
+// All returns the stored tags ordered by tier, service and endpoint.
+func (s *Store) All() []Tag {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := append([]Tag{}, s.tags...)
+	sort.Slice(out, func(i, j int) bool {
+		if ri, rj := Rank(out[i].Tier), Rank(out[j].Tier); ri != rj {
+			return ri < rj
+		}
+		if out[i].ServiceName != out[j].ServiceName {
+			return out[i].ServiceName < out[j].ServiceName
+		}
+		return out[i].Endpoint < out[j].Endpoint
+	})
+	return out
+}
+
+// Lookup returns the tag that applies to an endpoint of a service: its own
+// tag, else the service's AllEndpoints tag.
+func (s *Store) Lookup(service, endpoint string) (Tag, bool) {
+	var fallback Tag
+	found := false
+	for _, t := range s.All() {
+		if !strings.EqualFold(t.ServiceName, service) {
+			continue
+		}
+		if t.Endpoint == endpoint {
+			return t, true
+		}
+		if t.Endpoint == AllEndpoints {
+			fallback, found = t, true
+		}
+	}
+	return fallback, found
+}
+
+// index returns the position of the tag of the service and endpoint, or -1.
+// Callers hold s.mu.
+func (s *Store) index(service, endpoint string) int {
+	for i, t := range s.tags {
+		if strings.EqualFold(t.ServiceName, service) && t.Endpoint == endpoint {
+			return i
+		}
+	}
+	return -1
+}
+
+// write saves all tags. Callers hold s.mu.
+func (s *Store) write() error {
+	if s.path == "" {
+		return nil
+	}
+	if s.loadErr != nil {
+		return fmt.Errorf("%w; fix or remove it to tag or delete endpoints", s.loadErr)
+	}
+	data, err := json.MarshalIndent(s.tags, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode endpoint tags: %w", err)
+	}
+	if err := diskcache.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write criticality file: %w", err)
+	}
+	return nil
+}
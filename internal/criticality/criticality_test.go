@@ -0,0 +1,96 @@
+package criticality
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "criticality.json")
+	s := New(path)
+	if _, err := s.Set(Tag{ServiceName: "checkout", Endpoint: "POST /api/checkout", Tier: "Critical"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Set(Tag{ServiceName: "checkout", Endpoint: "POST /api/checkout", Tier: TierHigh, P95TargetMs: 250}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := New(path)
+	all := reloaded.All()
+	if len(all) != 1 || all[0].Tier != TierHigh {
+		t.Fatalf("All() after reload = %+v, want the replaced tag only", all)
+	}
+	if got := all[0].Targets(); got.P95LatencyMs != 250 || got.ErrorPercent != DefaultTargets[TierHigh].ErrorPercent {
+		t.Errorf("Targets() = %+v, want the p95 override and the tier's error target", got)
+	}
+	if deleted, err := reloaded.Delete("checkout", "POST /api/checkout"); err != nil || !deleted {
+		t.Fatalf("Delete() = %v, %v", deleted, err)
+	}
+	if deleted, _ := reloaded.Delete("checkout", "POST /api/checkout"); deleted {
+		t.Error("Delete() of a missing tag reported true")
+	}
+	if got := New(path).All(); len(got) != 0 {
+		t.Errorf("All() after delete and reload = %+v", got)
+	}
+}
+
+func TestStoreKeepsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "criticality.json")
+	corrupt := []byte(`[{"service_name":"checkout","endpoint":"GET /"`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := New(path)
+	if all := s.All(); len(all) != 0 {
+		t.Errorf("All() = %+v, want no tags from a corrupt file", all)
+	}
+	if _, err := s.Set(Tag{ServiceName: "checkout", Endpoint: "GET /", Tier: TierLow}); err == nil || !strings.Contains(err.Error(), "fix or remove it") {
+		t.Errorf("Set() error = %v, want a refusal to overwrite", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(corrupt) {
+		t.Errorf("file = %s, want it left as it was", got)
+	}
+	if len(s.All()) != 0 {
+		t.Error("a tag that was not saved should not be kept")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := map[string]Tag{
+		"no service":  {Endpoint: "GET /", Tier: TierLow},
+		"no endpoint": {ServiceName: "cart", Tier: TierLow},
+		"bad tier":    {ServiceName: "cart", Endpoint: "GET /", Tier: "gold"},
+		"bad target":  {ServiceName: "cart", Endpoint: "GET /", Tier: TierLow, ErrorPercentTarget: 120},
+	}
+	for name, tag := range invalid {
+		if err := tag.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", name)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	s := New("")
+	for _, tag := range []Tag{
+		{ServiceName: "payments", Endpoint: AllEndpoints, Tier: TierCritical},
+		{ServiceName: "payments", Endpoint: "GET /health", Tier: TierLow},
+	} {
+		if _, err := s.Set(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tag, ok := s.Lookup("Payments", "POST /api/charge"); !ok || tag.Tier != TierCritical {
+		t.Errorf("Lookup(charge) = %+v, %v, want the service-wide critical tag", tag, ok)
+	}
+	if tag, ok := s.Lookup("payments", "GET /health"); !ok || tag.Tier != TierLow {
+		t.Errorf("Lookup(health) = %+v, %v, want the endpoint's own tag", tag, ok)
+	}
+	if _, ok := s.Lookup("cart", "GET /api/cart"); ok {
+		t.Error("Lookup() of an untagged service found a tag")
+	}
+	if all := s.All(); all[0].Tier != TierCritical {
+		t.Errorf("All() = %+v, want the critical tag first", all)
+	}
+}
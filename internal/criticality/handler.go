@@ -0,0 +1,102 @@
+package criticality
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TagEndpointCriticalityArgs represents the input arguments for the tag_endpoint_criticality tool
+type TagEndpointCriticalityArgs struct {
+	ServiceName        string  `json:"service_name" jsonschema:"Service serving the endpoint (required, e.g. checkout)"`
+	Endpoint           string  `json:"endpoint" jsonschema:"Server span name of the endpoint, or * for every endpoint of the service (required, e.g. POST /api/checkout)"`
+	Tier               string  `json:"tier" jsonschema:"Criticality tier: critical, high, standard or low (required)"`
+	P95TargetMs        float64 `json:"p95_target_ms,omitempty" jsonschema:"p95 latency target in milliseconds (optional, e.g. 250). Default: the tier's target."`
+	ErrorPercentTarget float64 `json:"error_percent_target,omitempty" jsonschema:"Error percentage target (optional, e.g. 0.5). Default: the tier's target."`
+	Note               string  `json:"note,omitempty" jsonschema:"Why the endpoint has this tier (optional, e.g. revenue path)"`
+}
+
+// ListEndpointCriticalityArgs represents the input arguments for the list_endpoint_criticality tool
+type ListEndpointCriticalityArgs struct {
+	ServiceName string `json:"service_name,omitempty" jsonschema:"Only list tags of this service (optional)"`
+	Tier        string `json:"tier,omitempty" jsonschema:"Only list tags of this tier (optional, e.g. critical)"`
+}
+
+// DeleteEndpointCriticalityArgs represents the input arguments for the delete_endpoint_criticality tool
+type DeleteEndpointCriticalityArgs struct {
+	ServiceName string `json:"service_name" jsonschema:"Service of the tagged endpoint (required, e.g. checkout)"`
+	Endpoint    string `json:"endpoint" jsonschema:"Tagged endpoint, or * for the service-wide tag (required, e.g. POST /api/checkout)"`
+}
+
+func jsonResult(v any) (*mcp.CallToolResult, any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+	}, nil, nil
+}
+
+// NewTagEndpointCriticalityHandler returns a handler that tags an endpoint.
+func NewTagEndpointCriticalityHandler(store *Store) func(context.Context, *mcp.CallToolRequest, TagEndpointCriticalityArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args TagEndpointCriticalityArgs) (*mcp.CallToolResult, any, error) {
+		t, err := store.Set(Tag{
+			ServiceName:        args.ServiceName,
+			Endpoint:           args.Endpoint,
+			Tier:               args.Tier,
+			P95TargetMs:        args.P95TargetMs,
+			ErrorPercentTarget: args.ErrorPercentTarget,
+			Note:               args.Note,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonResult(map[string]any{
+			"tagged":  t,
+			"targets": t.Targets(),
+			"usage":   "get_endpoint_sla_report judges this endpoint against the targets and aggregates compliance per tier.",
+		})
+	}
+}
+
+// NewListEndpointCriticalityHandler returns a handler that lists tags.
+func NewListEndpointCriticalityHandler(store *Store) func(context.Context, *mcp.CallToolRequest, ListEndpointCriticalityArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ListEndpointCriticalityArgs) (*mcp.CallToolResult, any, error) {
+		type listed struct {
+			Tag
+			Targets Targets `json:"targets"`
+		}
+		tags := []listed{}
+		for _, t := range store.All() {
+			if args.ServiceName != "" && !strings.EqualFold(t.ServiceName, args.ServiceName) {
+				continue
+			}
+			if args.Tier != "" && !strings.EqualFold(t.Tier, args.Tier) {
+				continue
+			}
+			tags = append(tags, listed{t, t.Targets()})
+		}
+		return jsonResult(map[string]any{"tags": tags, "count": len(tags), "default_targets": DefaultTargets})
+	}
+}
+
+// NewDeleteEndpointCriticalityHandler returns a handler that deletes a tag.
+func NewDeleteEndpointCriticalityHandler(store *Store) func(context.Context, *mcp.CallToolRequest, DeleteEndpointCriticalityArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DeleteEndpointCriticalityArgs) (*mcp.CallToolResult, any, error) {
+		if args.ServiceName == "" || args.Endpoint == "" {
+			return nil, nil, fmt.Errorf("service_name and endpoint are required")
+		}
+		deleted, err := store.Delete(args.ServiceName, args.Endpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !deleted {
+			return nil, nil, fmt.Errorf("no tag for %s %q; list_endpoint_criticality shows the tagged endpoints", args.ServiceName, args.Endpoint)
+		}
+		return jsonResult(map[string]any{"deleted": map[string]string{"service_name": args.ServiceName, "endpoint": args.Endpoint}})
+	}
+}
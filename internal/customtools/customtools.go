@@ -0,0 +1,298 @@
+// Package customtools loads organization-specific tools from a declarative
+// JSON spec. Each tool is a templated HTTP request, so teams can expose
+// internal services (runbook search, ownership lookups) without forking.
+package customtools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/redact"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	maxTimeout     = 5 * time.Minute
+	// maxResponseBytes caps what is read from a custom endpoint.
+	maxResponseBytes = 4 << 20
+	// errorBodyBytes is how much of a failed response is quoted in the error.
+	errorBodyBytes = 512
+)
+
+var (
+	toolNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+	paramNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	placeholder      = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\}\}`)
+	envReference     = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	allowedMethods   = map[string]bool{http.MethodGet: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true}
+	paramTypes       = map[string]bool{"string": true, "integer": true, "number": true, "boolean": true}
+)
+
+// File is the custom tools spec file.
+type File struct {
+	Tools []Spec `json:"tools"`
+}
+
+// Spec declares one HTTP-backed tool. {{param}} placeholders in URL, Headers
+// and Body are filled from the tool arguments: path-escaped in the URL path,
+// query-escaped in the URL query and JSON-string-escaped in the body. ${ENV} references in URL and Headers are
+// expanded once at load time, so secrets stay out of the spec file.
+type Spec struct {
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Method         string            `json:"method,omitempty"`
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	Parameters     []Param           `json:"parameters,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+// Param is one tool argument.
+type Param struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Load reads and validates the spec file at path. An empty path means no
+// custom tools.
+func Load(path string) ([]Spec, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom tools file: %w", err)
+	}
+	var file File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse custom tools file %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for i := range file.Tools {
+		spec := &file.Tools[i]
+		if err := spec.normalize(); err != nil {
+			return nil, fmt.Errorf("custom tool %d (%q) in %s: %w", i, spec.Name, path, err)
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("custom tool %q is defined more than once in %s", spec.Name, path)
+		}
+		seen[spec.Name] = true
+	}
+	return file.Tools, nil
+}
+
+// normalize fills defaults, expands environment references and rejects specs
+// that could not be called.
+func (s *Spec) normalize() error {
+	if !toolNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("name must be lowercase snake_case, up to 64 characters")
+	}
+	if strings.TrimSpace(s.Description) == "" {
+		return fmt.Errorf("description is required")
+	}
+	s.Method = strings.ToUpper(s.Method)
+	if s.Method == "" {
+		s.Method = http.MethodGet
+	}
+	if !allowedMethods[s.Method] {
+		return fmt.Errorf("unsupported method %q", s.Method)
+	}
+	if s.TimeoutSeconds < 0 || time.Duration(s.TimeoutSeconds)*time.Second > maxTimeout {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", int(maxTimeout.Seconds()))
+	}
+
+	params := map[string]bool{}
+	for i := range s.Parameters {
+		p := &s.Parameters[i]
+		if !paramNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("parameter name %q must be lowercase snake_case", p.Name)
+		}
+		if params[p.Name] {
+			return fmt.Errorf("parameter %q is declared more than once", p.Name)
+		}
+		params[p.Name] = true
+		if p.Type == "" {
+			p.Type = "string"
+		}
+		if !paramTypes[p.Type] {
+			return fmt.Errorf("parameter %q has unsupported type %q", p.Name, p.Type)
+		}
+	}
+
+	var missingEnv []string
+	expand := func(v string) string {
+		return envReference.ReplaceAllStringFunc(v, func(ref string) string {
+			name := envReference.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				missingEnv = append(missingEnv, name)
+			}
+			redact.Register(value)
+			return value
+		})
+	}
+	s.URL = expand(s.URL)
+	for k, v := range s.Headers {
+		s.Headers[k] = expand(v)
+	}
+	if len(missingEnv) > 0 {
+		return fmt.Errorf("environment variable(s) not set: %s", strings.Join(missingEnv, ", "))
+	}
+
+	u, err := url.Parse(placeholder.ReplaceAllString(s.URL, "x"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	templates := []string{s.URL, s.Body}
+	for _, v := range s.Headers {
+		templates = append(templates, v)
+	}
+	for _, t := range templates {
+		for _, m := range placeholder.FindAllStringSubmatch(t, -1) {
+			if !params[m[1]] {
+				return fmt.Errorf("placeholder {{%s}} does not match a parameter", m[1])
+			}
+		}
+	}
+	return nil
+}
+
+// InputSchema returns the MCP input schema for the tool's parameters.
+func (s Spec) InputSchema() map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, p := range s.Parameters {
+		prop := map[string]interface{}{"type": p.Type}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		properties[p.Name] = prop
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// NewHandler returns a handler that fills the spec's templates from the
+// arguments, performs the request and returns the response body as text.
+func NewHandler(client *http.Client, spec Spec) func(context.Context, *mcp.CallToolRequest, map[string]any) (*mcp.CallToolResult, any, error) {
+	timeout := defaultTimeout
+	if spec.TimeoutSeconds > 0 {
+		timeout = time.Duration(spec.TimeoutSeconds) * time.Second
+	}
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		values := map[string]string{}
+		for _, p := range spec.Parameters {
+			v, ok := args[p.Name]
+			if !ok || v == nil {
+				if p.Required {
+					return nil, nil, fmt.Errorf("%s is required", p.Name)
+				}
+				continue
+			}
+			value, err := formatArg(p, v)
+			if err != nil {
+				return nil, nil, err
+			}
+			values[p.Name] = value
+		}
+
+		fill := func(t string, escape func(string) string) string {
+			return placeholder.ReplaceAllStringFunc(t, func(m string) string {
+				return escape(values[placeholder.FindStringSubmatch(m)[1]])
+			})
+		}
+		// Placeholders in the path fill one segment each; those in the query
+		// string fill a parameter value.
+		path, query, hasQuery := strings.Cut(spec.URL, "?")
+		target := fill(path, url.PathEscape)
+		if hasQuery {
+			target += "?" + fill(query, url.QueryEscape)
+		}
+		var body io.Reader
+		if spec.Body != "" {
+			body = strings.NewReader(fill(spec.Body, jsonEscape))
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(ctx, spec.Method, target, body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		for k, v := range spec.Headers {
+			httpReq.Header.Set(k, fill(v, func(s string) string { return s }))
+		}
+		if body != nil && httpReq.Header.Get("Content-Type") == "" {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s request failed: %w", spec.Name, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s response: %w", spec.Name, err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			snippet := data
+			if len(snippet) > errorBodyBytes {
+				snippet = snippet[:errorBodyBytes]
+			}
+			return nil, nil, fmt.Errorf("%s returned status %d: %s", spec.Name, resp.StatusCode, strings.TrimSpace(string(snippet)))
+		}
+		if len(data) > maxResponseBytes {
+			return nil, nil, fmt.Errorf("%s response exceeds %d bytes", spec.Name, maxResponseBytes)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// formatArg renders an argument for a template. Numbers arrive as float64
+// and are written out in full, not in exponent form (1e+06), which
+// endpoints taking ticket ids or epoch timestamps would not parse.
+func formatArg(p Param, v any) (string, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return fmt.Sprint(v), nil
+	}
+	if p.Type == "integer" && f != math.Trunc(f) {
+		return "", fmt.Errorf("%s must be an integer, got %v", p.Name, f)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// jsonEscape escapes s for use inside a JSON string literal in a body
+// template.
+func jsonEscape(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
@@ -0,0 +1,206 @@
+package customtools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func writeSpec(t *testing.T, tools ...Spec) string {
+	t.Helper()
+	data, err := json.Marshal(File{Tools: tools})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tools.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	valid := Spec{
+		Name:        "lookup_runbook",
+		Description: "Find the runbook for an alert",
+		URL:         "https://runbooks.example/search?q={{query}}",
+		Parameters:  []Param{{Name: "query", Required: true}},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		specs, err := Load(writeSpec(t, valid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(specs) != 1 || specs[0].Method != http.MethodGet || specs[0].Parameters[0].Type != "string" {
+			t.Fatalf("specs = %+v, want GET with string parameter", specs)
+		}
+	})
+
+	t.Run("empty path", func(t *testing.T) {
+		specs, err := Load("")
+		if err != nil || specs != nil {
+			t.Fatalf("Load(\"\") = %v, %v", specs, err)
+		}
+	})
+
+	t.Run("expands environment", func(t *testing.T) {
+		t.Setenv("RUNBOOK_TOKEN", "s3cret")
+		spec := valid
+		spec.Headers = map[string]string{"Authorization": "Bearer ${RUNBOOK_TOKEN}"}
+		specs, err := Load(writeSpec(t, spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := specs[0].Headers["Authorization"]; got != "Bearer s3cret" {
+			t.Fatalf("Authorization = %q", got)
+		}
+	})
+
+	invalid := map[string]func(*Spec){
+		"bad name":              func(s *Spec) { s.Name = "Lookup-Runbook" },
+		"missing description":   func(s *Spec) { s.Description = " " },
+		"bad method":            func(s *Spec) { s.Method = "TRACE" },
+		"relative url":          func(s *Spec) { s.URL = "/search?q={{query}}" },
+		"unknown placeholder":   func(s *Spec) { s.Body = `{"q":"{{missing}}"}` },
+		"bad parameter type":    func(s *Spec) { s.Parameters[0].Type = "object" },
+		"unset environment":     func(s *Spec) { s.Headers = map[string]string{"X-Token": "${CUSTOMTOOLS_UNSET_VAR}"} },
+		"timeout out of range":  func(s *Spec) { s.TimeoutSeconds = 3600 },
+		"duplicate parameter":   func(s *Spec) { s.Parameters = append(s.Parameters, Param{Name: "query"}) },
+		"uppercase placeholder": func(s *Spec) { s.Parameters[0].Name = "Query" },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			spec := valid
+			spec.Parameters = append([]Param(nil), valid.Parameters...)
+			mutate(&spec)
+			if _, err := Load(writeSpec(t, spec)); err == nil {
+				t.Fatal("Load succeeded, want error")
+			}
+		})
+	}
+
+	t.Run("duplicate tool", func(t *testing.T) {
+		if _, err := Load(writeSpec(t, valid, valid)); err == nil {
+			t.Fatal("Load succeeded, want duplicate error")
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tools.json")
+		if err := os.WriteFile(path, []byte(`{"tools":[{"name":"x","descripton":"typo"}]}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Fatal("Load succeeded, want unknown field error")
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	var gotQuery, gotBody, gotAuth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "upstream down", http.StatusBadGateway)
+			return
+		}
+		gotQuery = r.URL.Query().Get("q")
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Write([]byte(`{"runbook":"https://wiki/rb/1"}`))
+	}))
+	defer ts.Close()
+
+	spec := Spec{
+		Name:        "lookup_runbook",
+		Description: "Find the runbook",
+		Method:      http.MethodPost,
+		URL:         ts.URL + "/search?q={{query}}",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Body:        `{"query":"{{query}}","limit":{{limit}}}`,
+		Parameters:  []Param{{Name: "query", Required: true}, {Name: "limit", Type: "integer"}},
+	}
+	if err := spec.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHandler(ts.Client(), spec)
+
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, map[string]any{"query": `high "5xx" & errors`, "limit": float64(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; text != `{"runbook":"https://wiki/rb/1"}` {
+		t.Fatalf("result = %s", text)
+	}
+	if gotQuery != `high "5xx" & errors` {
+		t.Errorf("query param = %q, want the raw value after URL decoding", gotQuery)
+	}
+	var body map[string]any
+	if err := json.Unmarshal([]byte(gotBody), &body); err != nil || body["query"] != `high "5xx" & errors` || body["limit"] != float64(3) {
+		t.Errorf("body = %s (%v), want valid JSON carrying the arguments", gotBody, err)
+	}
+	if gotAuth != "Bearer token" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, map[string]any{}); err == nil || !strings.Contains(err.Error(), "query is required") {
+		t.Errorf("missing required argument error = %v", err)
+	}
+
+	spec.URL = ts.URL + "/fail"
+	spec.Body = ""
+	_, _, err = NewHandler(ts.Client(), spec)(context.Background(), &mcp.CallToolRequest{}, map[string]any{"query": "x"})
+	if err == nil || !strings.Contains(err.Error(), "status 502") || !strings.Contains(err.Error(), "upstream down") {
+		t.Errorf("upstream error = %v, want status and body", err)
+	}
+}
+
+func TestHandlerFormatsArguments(t *testing.T) {
+	var gotPath, gotQuery, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.EscapedPath(), r.URL.RawQuery
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	spec := Spec{
+		Name:        "get_runbook",
+		Description: "Fetch a runbook",
+		Method:      http.MethodPost,
+		URL:         ts.URL + "/runbooks/{{name}}?ticket={{ticket}}&q={{name}}",
+		Body:        `{"ticket":{{ticket}},"since":{{since}}}`,
+		Parameters:  []Param{{Name: "name", Required: true}, {Name: "ticket", Type: "integer"}, {Name: "since", Type: "number"}},
+	}
+	if err := spec.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHandler(ts.Client(), spec)
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, map[string]any{"name": "disk full/eu", "ticket": float64(12345678), "since": 1777593600.5}); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/runbooks/disk%20full%2Feu" {
+		t.Errorf("path = %s, want the name as one path-escaped segment", gotPath)
+	}
+	if gotQuery != "ticket=12345678&q=disk+full%2Feu" {
+		t.Errorf("query = %s, want the ticket in full and the name query-escaped", gotQuery)
+	}
+	if gotBody != `{"ticket":12345678,"since":1777593600.5}` {
+		t.Errorf("body = %s, want numbers without exponents", gotBody)
+	}
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, map[string]any{"name": "x", "ticket": 1.5}); err == nil || !strings.Contains(err.Error(), "ticket must be an integer") {
+		t.Errorf("fractional integer argument error = %v", err)
+	}
+}
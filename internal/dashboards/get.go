@@ -7,9 +7,9 @@ import (
 	"net/http"
 	"net/url"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -8,8 +8,8 @@ import (
 	"net/http"
 	"strings"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 const maxAPIErrorBodyBytes = 4096
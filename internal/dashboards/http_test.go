@@ -8,7 +8,7 @@ import (
 	"strings"
 	"testing"
 
-	"last9-mcp/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/constants"
 )
 
 func TestDoJSONRequest_4xxWithBody(t *testing.T) {
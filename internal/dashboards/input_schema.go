@@ -7,6 +7,13 @@ func dashboardObjectSchema(description string) map[string]interface{} {
 	}
 }
 
+func displayTimezoneSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "IANA timezone (e.g. Asia/Kolkata) for human-readable *_local timestamps added next to epoch values. Overrides the server default display timezone.",
+	}
+}
+
 func metadataObjectSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type":        "object",
@@ -21,8 +28,9 @@ func GetCreateDashboardInputSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
-			"dashboard": dashboardObjectSchema("Dashboard definition with name and panels."),
-			"metadata":  metadataObjectSchema(),
+			"dashboard":        dashboardObjectSchema("Dashboard definition with name and panels."),
+			"metadata":         metadataObjectSchema(),
+			"display_timezone": displayTimezoneSchema(),
 		},
 		"required": []string{"dashboard"},
 	}
@@ -37,8 +45,9 @@ func GetUpdateDashboardInputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Dashboard UUID to update.",
 			},
-			"dashboard": dashboardObjectSchema("Full replacement dashboard definition with name and panels."),
-			"metadata":  metadataObjectSchema(),
+			"dashboard":        dashboardObjectSchema("Full replacement dashboard definition with name and panels."),
+			"metadata":         metadataObjectSchema(),
+			"display_timezone": displayTimezoneSchema(),
 		},
 		"required": []string{"id", "dashboard"},
 	}
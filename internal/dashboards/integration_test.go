@@ -9,8 +9,8 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -1,7 +1,7 @@
 package dashboards
 
 import (
-	"last9-mcp/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -3,8 +3,8 @@ package dashboards
 import (
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 func testDashboardConfig(apiBase string) models.Config {
@@ -0,0 +1,124 @@
+// Package diskcache persists slow-changing discovery data (attribute names,
+// label catalogs) as small JSON files so that STDIO sessions, which start a
+// fresh process per client, can warm up without re-fetching it.
+package diskcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// envelope is the on-disk format of a cache entry.
+type envelope struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Store reads and writes cache entries under a single directory.
+// A nil *Store is valid and behaves as an always-empty, write-discarding cache.
+type Store struct {
+	dir string
+}
+
+// New returns a Store rooted at dir, or nil when dir is empty (cache disabled).
+func New(dir string) *Store {
+	if dir == "" {
+		return nil
+	}
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns the per-user cache directory for the server, or "" when
+// the platform has no user cache directory.
+func DefaultDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "last9-mcp")
+}
+
+// Get decodes the entry stored under key into v. It reports the time the entry
+// was written and whether a usable entry was found. Entries older than ttl are
+// treated as missing; a zero ttl disables the age check.
+func (s *Store) Get(key string, ttl time.Duration, v any) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	raw, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return time.Time{}, false
+	}
+	if ttl > 0 && time.Since(env.StoredAt) > ttl {
+		return time.Time{}, false
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return time.Time{}, false
+	}
+	return env.StoredAt, true
+}
+
+// Put stores v under key, stamped with storedAt.
+func (s *Store) Put(key string, v any, storedAt time.Time) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %q: %w", key, err)
+	}
+	raw, err := json.Marshal(envelope{StoredAt: storedAt.UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %q: %w", key, err)
+	}
+	if err := WriteFile(s.path(key), raw); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes data to path, creating its directory. The data goes to a
+// temporary file in the same directory that is then renamed over path, so
+// concurrent readers see either the old or the new file, never a partial
+// one. The stores that persist state as files write through it.
+func WriteFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path maps a cache key to a file name, replacing characters that are not
+// safe in file names on every platform.
+func (s *Store) path(key string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	return filepath.Join(s.dir, safe+".json")
+}
@@ -0,0 +1,101 @@
+package diskcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	s := New(t.TempDir())
+	want := []string{"service.name", "http.route"}
+	stored := time.Now().Add(-time.Minute)
+
+	if err := s.Put("attributes-acme", want, stored); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	var got []string
+	at, ok := s.Get("attributes-acme", time.Hour, &got)
+	if !ok {
+		t.Fatal("expected cache hit")
+	}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !at.Equal(stored) {
+		t.Errorf("stored_at = %v, want %v", at, stored)
+	}
+}
+
+func TestStore_ExpiredEntryIsMiss(t *testing.T) {
+	s := New(t.TempDir())
+	if err := s.Put("k", []string{"a"}, time.Now().Add(-3*time.Hour)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	var got []string
+	if _, ok := s.Get("k", 2*time.Hour, &got); ok {
+		t.Error("expected expired entry to be a miss")
+	}
+	if _, ok := s.Get("k", 0, &got); !ok {
+		t.Error("expected zero ttl to skip the age check")
+	}
+}
+
+func TestStore_CorruptFileIsMiss(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	if err := os.WriteFile(filepath.Join(dir, "k.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if _, ok := s.Get("k", time.Hour, &got); ok {
+		t.Error("expected corrupt entry to be a miss")
+	}
+}
+
+func TestStore_KeySanitized(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	if err := s.Put("../org/slug:1", 1, time.Now()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".._org_slug_1.json")); err != nil {
+		t.Errorf("expected sanitized file inside cache dir: %v", err)
+	}
+}
+
+func TestStore_NilIsDisabled(t *testing.T) {
+	s := New("")
+	if s != nil {
+		t.Fatal("expected nil store for empty dir")
+	}
+	if err := s.Put("k", 1, time.Now()); err != nil {
+		t.Errorf("Put on nil store: %v", err)
+	}
+	var v int
+	if _, ok := s.Get("k", 0, &v); ok {
+		t.Error("expected miss on nil store")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	path := filepath.Join(dir, "views.json")
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(data)); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != data {
+			t.Errorf("file = %q, want %q", got, data)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the file and no temporary ones", len(entries))
+	}
+}
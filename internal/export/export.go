@@ -0,0 +1,245 @@
+// Package export writes tool results to files under a configured output
+// directory, so an agent can hand a user a CSV of top operations or a JSON
+// dump of a dependency graph without pasting it into the conversation.
+// Paths are resolved through os.Root: neither ".." nor symlinks can reach
+// outside the directory.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported formats.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// ErrDisabled is returned when no export directory is configured.
+var ErrDisabled = errors.New("export is disabled: set LAST9_EXPORT_DIR to allow writing results to files")
+
+// Options is the optional export argument accepted by heavy read tools.
+type Options struct {
+	Format string `json:"format,omitempty" jsonschema:"File format: json (default, the full result) or csv (one row per record; see field)"`
+	Path   string `json:"path" jsonschema:"File path relative to the server's export directory (e.g. checkout/operations.csv). Missing directories are created; an existing file is overwritten."`
+	Field  string `json:"field,omitempty" jsonschema:"For csv: top-level result field holding the rows, when the result has more than one list (e.g. edges)"`
+}
+
+// Result describes a written file.
+type Result struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	Bytes  int    `json:"bytes"`
+	Rows   *int   `json:"rows,omitempty"`
+}
+
+// Write encodes the JSON tool result text in the requested format and writes
+// it to opts.Path under dir.
+func Write(dir string, opts Options, text string) (Result, error) {
+	if dir == "" {
+		return Result{}, ErrDisabled
+	}
+	format, err := resolveFormat(opts)
+	if err != nil {
+		return Result{}, err
+	}
+	name := filepath.ToSlash(filepath.Clean(opts.Path))
+	if opts.Path == "" || name == "." || !filepath.IsLocal(opts.Path) {
+		return Result{}, fmt.Errorf("export path must be a relative file path inside the export directory, got %q", opts.Path)
+	}
+
+	var (
+		data []byte
+		rows *int
+	)
+	switch format {
+	case FormatCSV:
+		var n int
+		data, n, err = toCSV(text, opts.Field)
+		rows = &n
+	default:
+		data, err = toJSON(text)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Result{}, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to open export directory: %w", err)
+	}
+	defer root.Close()
+	if parent := path.Dir(name); parent != "." {
+		if err := root.MkdirAll(parent, 0o755); err != nil {
+			return Result{}, fmt.Errorf("failed to create %s: %w", parent, err)
+		}
+	}
+	if err := root.WriteFile(name, data, 0o644); err != nil {
+		return Result{}, fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	abs, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Path: abs, Format: format, Bytes: len(data), Rows: rows}, nil
+}
+
+// resolveFormat validates opts.Format, inferring it from the file extension
+// when omitted.
+func resolveFormat(opts Options) (string, error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = FormatJSON
+		if strings.EqualFold(filepath.Ext(opts.Path), ".csv") {
+			format = FormatCSV
+		}
+	}
+	if format != FormatJSON && format != FormatCSV {
+		return "", fmt.Errorf("export format must be json or csv, got %q", opts.Format)
+	}
+	return format, nil
+}
+
+func toJSON(text string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(text), "", "  "); err != nil {
+		// Not every tool returns JSON; keep the text as-is.
+		return []byte(text), nil
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// toCSV writes one row per record. Nested objects are flattened into dotted
+// column names and arrays are kept as JSON.
+func toCSV(text, field string) ([]byte, int, error) {
+	var result any
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("csv export needs a JSON result: %w", err)
+	}
+	records, err := findRecords(result, field)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows := make([]map[string]string, 0, len(records))
+	columnSet := map[string]bool{}
+	for _, record := range records {
+		row := map[string]string{}
+		if obj, ok := record.(map[string]any); ok {
+			flatten("", obj, row)
+		} else {
+			row["value"] = cell(record)
+		}
+		for column := range row {
+			columnSet[column] = true
+		}
+		rows = append(rows, row)
+	}
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(columns)
+	for _, row := range rows {
+		line := make([]string, len(columns))
+		for i, column := range columns {
+			line[i] = row[column]
+		}
+		_ = w.Write(line)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, 0, fmt.Errorf("failed to encode csv: %w", err)
+	}
+	return buf.Bytes(), len(rows), nil
+}
+
+// findRecords locates the list to export: the result itself when it is an
+// array, the named field, or the only top-level field holding a list. The
+// _meta block added by APM tools is never a candidate.
+func findRecords(result any, field string) ([]any, error) {
+	if list, ok := result.([]any); ok {
+		return list, nil
+	}
+	obj, ok := result.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("csv export needs a list of records")
+	}
+	if field != "" {
+		list, ok := obj[field].([]any)
+		if !ok {
+			return nil, fmt.Errorf("result field %q is not a list", field)
+		}
+		return list, nil
+	}
+
+	var candidates []string
+	for key, value := range obj {
+		if key == "_meta" {
+			continue
+		}
+		if _, ok := value.([]any); ok {
+			candidates = append(candidates, key)
+		}
+	}
+	sort.Strings(candidates)
+	switch len(candidates) {
+	case 0:
+		// A single record, e.g. a summary object.
+		return []any{obj}, nil
+	case 1:
+		return obj[candidates[0]].([]any), nil
+	default:
+		return nil, fmt.Errorf("result has several lists (%s); set export.field to pick one", strings.Join(candidates, ", "))
+	}
+}
+
+func flatten(prefix string, obj map[string]any, row map[string]string) {
+	for key, value := range obj {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			flatten(key, nested, row)
+			continue
+		}
+		row[key] = cell(value)
+	}
+}
+
+func cell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	dir := t.TempDir()
+	text := `{"service":"checkout","operations":[{"name":"GET /cart","p95_ms":12.5,"tags":{"kind":"server"}},{"name":"POST /pay","error_pct":1,"ids":[1,2]}]}`
+
+	got, err := Write(dir, Options{Path: "checkout/ops.csv"}, text)
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if got.Format != FormatCSV || got.Rows == nil || *got.Rows != 2 {
+		t.Errorf("result = %+v, want csv with 2 rows", got)
+	}
+	if got.Path != filepath.Join(dir, "checkout", "ops.csv") {
+		t.Errorf("path = %s", got.Path)
+	}
+	data, err := os.ReadFile(got.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "error_pct,ids,name,p95_ms,tags.kind\n,,GET /cart,12.5,server\n1,\"[1,2]\",POST /pay,,\n"
+	if string(data) != want {
+		t.Errorf("csv =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestWriteCSVField(t *testing.T) {
+	dir := t.TempDir()
+	text := `{"nodes":[{"id":"a"}],"edges":[{"from":"a","to":"b"},{"from":"b","to":"c"}]}`
+
+	if _, err := Write(dir, Options{Path: "graph.csv"}, text); err == nil || !strings.Contains(err.Error(), "edges, nodes") {
+		t.Errorf("expected ambiguous list error, got %v", err)
+	}
+	got, err := Write(dir, Options{Path: "graph.csv", Field: "edges"}, text)
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if *got.Rows != 2 {
+		t.Errorf("rows = %d, want 2", *got.Rows)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	dir := t.TempDir()
+	got, err := Write(dir, Options{Format: "json", Path: "graph"}, `{"a":[1,2]}`)
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if got.Rows != nil {
+		t.Errorf("rows should be omitted for json: %+v", got)
+	}
+	data, _ := os.ReadFile(got.Path)
+	if string(data) != "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n" {
+		t.Errorf("json = %q", data)
+	}
+}
+
+func TestWriteRejectsEscapes(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"parent", Options{Path: "../x.json"}, "relative file path"},
+		{"absolute", Options{Path: filepath.Join(outside, "x.json")}, "relative file path"},
+		{"empty", Options{}, "relative file path"},
+		{"symlink", Options{Path: "link/x.json"}, "escapes"},
+		{"format", Options{Path: "x.txt", Format: "xml"}, "json or csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Write(dir, tt.opts, `{}`); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Write() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("files written outside the export directory: %v", entries)
+	}
+}
+
+func TestWriteDisabled(t *testing.T) {
+	if _, err := Write("", Options{Path: "x.json"}, `{}`); err == nil || !strings.Contains(err.Error(), "LAST9_EXPORT_DIR") {
+		t.Errorf("expected disabled error, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+// Package jsonrpcerr builds the JSON-RPC error responses the STDIO and
+// WebSocket transports send for frames they drop instead of closing the
+// session.
+package jsonrpcerr
+
+import "encoding/json"
+
+// JSON-RPC error codes for frames that are dropped.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+)
+
+// Marshal returns a JSON-RPC error response for the request id, or with a
+// null id when id is nil.
+func Marshal(id json.RawMessage, code int, message string) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	resp := struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}{JSONRPC: "2.0", ID: id}
+	resp.Error.Code = code
+	resp.Error.Message = message
+	data, err := json.Marshal(resp)
+	if err != nil {
+		// Only an id that is not valid JSON fails to marshal.
+		return Marshal(nil, code, message)
+	}
+	return data
+}
+
+// RequestID returns the id of a message that failed to decode, if it has a
+// usable one, so the client can match the error to its request.
+func RequestID(msg []byte) json.RawMessage {
+	var m struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(msg, &m) != nil || len(m.ID) == 0 {
+		return nil
+	}
+	switch m.ID[0] {
+	case '"', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return m.ID
+	}
+	return nil
+}
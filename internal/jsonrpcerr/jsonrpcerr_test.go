@@ -0,0 +1,29 @@
+package jsonrpcerr
+
+import "testing"
+
+func TestMarshal(t *testing.T) {
+	if got := string(Marshal(nil, CodeParseError, "Parse error")); got != `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}` {
+		t.Errorf("Marshal(nil) = %s", got)
+	}
+	if got := string(Marshal([]byte(`"a"`), CodeInvalidRequest, "Invalid Request")); got != `{"jsonrpc":"2.0","id":"a","error":{"code":-32600,"message":"Invalid Request"}}` {
+		t.Errorf("Marshal(\"a\") = %s", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	cases := map[string]string{
+		`{"id":7,"method":1}`:    `7`,
+		`{"id":"abc"}`:           `"abc"`,
+		`{"id":-1}`:              `-1`,
+		`{"id":{"nested":true}}`: ``,
+		`{"id":null}`:            ``,
+		`{"method":"ping"}`:      ``,
+		`not json`:               ``,
+	}
+	for msg, want := range cases {
+		if got := string(RequestID([]byte(msg))); got != want {
+			t.Errorf("RequestID(%s) = %q, want %q", msg, got, want)
+		}
+	}
+}
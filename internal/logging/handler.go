@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Bounds for how long a set_log_level change lasts before it reverts.
+const (
+	defaultLevelChangeMinutes = 30
+	maxLevelChangeMinutes     = 24 * 60
+)
+
+// SetLogLevelArgs represents the input arguments for the set_log_level tool
+type SetLogLevelArgs struct {
+	Level           string `json:"level" jsonschema:"New minimum log level (required, one of: debug, info, warn, error)"`
+	Module          string `json:"module,omitempty" jsonschema:"Only change this module's level (e.g. auth, http, tools). Default: the level of every module without its own level."`
+	DurationMinutes int    `json:"duration_minutes,omitempty" jsonschema:"Minutes until the previous level is restored (default: 30, max: 1440)"`
+	Reason          string `json:"reason,omitempty" jsonschema:"Why the level is changed, recorded in the audit log (e.g. debugging token refresh failures)"`
+}
+
+// NewSetLogLevelHandler returns a handler that changes the server's log level
+// until it reverts.
+func NewSetLogLevelHandler() func(context.Context, *mcp.CallToolRequest, SetLogLevelArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args SetLogLevelArgs) (*mcp.CallToolResult, any, error) {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(args.Level)); err != nil {
+			return nil, nil, fmt.Errorf("invalid level %q: use debug, info, warn or error", args.Level)
+		}
+		minutes := args.DurationMinutes
+		if minutes == 0 {
+			minutes = defaultLevelChangeMinutes
+		}
+		if minutes < 0 || minutes > maxLevelChangeMinutes {
+			return nil, nil, fmt.Errorf("duration_minutes must be between 1 and %d", maxLevelChangeMinutes)
+		}
+
+		reason := strings.TrimSpace(args.Reason)
+		if client := clientName(req); client != "" {
+			reason = strings.TrimSpace(reason + " (client: " + client + ")")
+		}
+		change := SetLevel(strings.TrimSpace(args.Module), level, time.Duration(minutes)*time.Minute, reason)
+		data, err := json.Marshal(map[string]any{
+			"changed": change,
+			"pending": PendingChanges(),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// clientName identifies the calling client for the audit record, when it
+// introduced itself in initialize.
+func clientName(req *mcp.CallToolRequest) string {
+	if req == nil || req.Session == nil {
+		return ""
+	}
+	params := req.Session.InitializeParams()
+	if params == nil || params.ClientInfo == nil {
+		return ""
+	}
+	return strings.TrimSpace(params.ClientInfo.Name + " " + params.ClientInfo.Version)
+}
@@ -0,0 +1,318 @@
+// Package logging configures the server's own logs: a process-wide slog
+// handler writing text or JSON, with a default level and per-module levels.
+// Records carry the module they come from in a "module" attribute; loggers
+// for a module come from Logger. The standard log package is routed through
+// the same handler, so log.Printf output is formatted and filtered alike.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Output formats accepted by Options.Format.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ModuleKey is the attribute naming the module a record comes from.
+const ModuleKey = "module"
+
+// Options configures the process logger.
+type Options struct {
+	// Level is the minimum level for records of modules without their own
+	// entry in Modules, and for records without a module.
+	Level slog.Level
+	// Format is FormatText or FormatJSON; empty means text.
+	Format string
+	// Modules overrides Level per module (e.g. auth=debug).
+	Modules map[string]slog.Level
+}
+
+// ParseOptions validates the --log_level, --log_format and --log_modules
+// flag values.
+func ParseOptions(level, format, modules string) (Options, error) {
+	var opts Options
+	if level != "" {
+		if err := opts.Level.UnmarshalText([]byte(level)); err != nil {
+			return opts, fmt.Errorf("invalid log level %q: use debug, info, warn or error", level)
+		}
+	}
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		opts.Format = FormatText
+	case FormatJSON:
+		opts.Format = FormatJSON
+	default:
+		return opts, fmt.Errorf("invalid log format %q: use %s or %s", format, FormatText, FormatJSON)
+	}
+	for _, pair := range strings.Split(modules, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		module, lvl, ok := strings.Cut(pair, "=")
+		module = strings.TrimSpace(module)
+		if !ok || module == "" {
+			return opts, fmt.Errorf("invalid log module level %q: want module=level", pair)
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(strings.TrimSpace(lvl))); err != nil {
+			return opts, fmt.Errorf("invalid log level %q for module %s: use debug, info, warn or error", lvl, module)
+		}
+		if opts.Modules == nil {
+			opts.Modules = map[string]slog.Level{}
+		}
+		opts.Modules[module] = l
+	}
+	return opts, nil
+}
+
+// levelFor returns the minimum level for module.
+func (o Options) levelFor(module string) slog.Level {
+	if l, ok := o.Modules[module]; ok {
+		return l
+	}
+	return o.Level
+}
+
+// minLevel is the lowest level any module logs at.
+func (o Options) minLevel() slog.Level {
+	minimum := o.Level
+	for _, l := range o.Modules {
+		minimum = min(minimum, l)
+	}
+	return minimum
+}
+
+var (
+	current atomic.Pointer[Options]
+
+	mu    sync.Mutex // guards sinks and overrides
+	sinks []slog.Handler
+)
+
+// Setup makes the process logger write to w in opts.Format, filtered by
+// opts' levels, and installs it as the slog and log default.
+func Setup(w io.Writer, opts Options) {
+	// Sinks see every record; levels are applied by handler.
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug - 4}
+	var sink slog.Handler = slog.NewTextHandler(w, handlerOpts)
+	if opts.Format == FormatJSON {
+		sink = slog.NewJSONHandler(w, handlerOpts)
+	}
+	current.Store(&opts)
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = []slog.Handler{sink}
+	slog.SetDefault(slog.New(&handler{sinks: sinks}))
+}
+
+// AddHandler tees records that pass the configured levels to h as well, for
+// exporters such as the OpenTelemetry log bridge.
+func AddHandler(h slog.Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = append(sinks[:len(sinks):len(sinks)], h)
+	slog.SetDefault(slog.New(&handler{sinks: sinks}))
+}
+
+// override is a runtime level change pending its revert.
+type override struct {
+	original  slog.Level
+	hadModule bool // whether Modules had an entry before the change
+	revertsAt time.Time
+	timer     *time.Timer
+}
+
+// overrides holds pending runtime changes by module; "" is the default
+// level. Guarded by mu.
+var overrides = map[string]*override{}
+
+// LevelChange describes a runtime level change made with SetLevel.
+type LevelChange struct {
+	Module        string    `json:"module,omitempty"`
+	Level         string    `json:"level"`
+	PreviousLevel string    `json:"previous_level"`
+	RevertsAt     time.Time `json:"reverts_at"`
+}
+
+// SetLevel changes the level of module, or the default level when module is
+// empty, until revertAfter elapses. The level in effect before the first
+// pending change is then restored, so repeated changes extend the same
+// override. Changes and reverts are audited whatever the levels.
+func SetLevel(module string, level slog.Level, revertAfter time.Duration, reason string) LevelChange {
+	mu.Lock()
+	defer mu.Unlock()
+	opts := options()
+	previous := opts.levelFor(module)
+	if module == "" {
+		previous = opts.Level
+	}
+	o, pending := overrides[module]
+	if !pending {
+		_, hadModule := opts.Modules[module]
+		o = &override{original: previous, hadModule: hadModule}
+		overrides[module] = o
+	} else {
+		o.timer.Stop()
+	}
+	o.revertsAt = time.Now().Add(revertAfter).UTC().Truncate(time.Second)
+	o.timer = time.AfterFunc(revertAfter, func() { revertLevel(module, o) })
+	storeLevel(module, level, true)
+
+	change := LevelChange{Module: module, Level: level.String(), PreviousLevel: previous.String(), RevertsAt: o.revertsAt}
+	audit("log level changed", "target_module", moduleName(module), "log_level", change.Level,
+		"previous_log_level", change.PreviousLevel, "reverts_at", change.RevertsAt, "reason", reason)
+	return change
+}
+
+// revertLevel restores the level module had before o, unless a later change
+// replaced o.
+func revertLevel(module string, o *override) {
+	mu.Lock()
+	defer mu.Unlock()
+	if overrides[module] != o {
+		return
+	}
+	delete(overrides, module)
+	storeLevel(module, o.original, o.hadModule || module == "")
+	audit("log level reverted", "target_module", moduleName(module), "log_level", o.original.String())
+}
+
+// storeLevel publishes a copy of the current options with module's level
+// set, or its entry removed when keep is false. Callers must hold mu.
+func storeLevel(module string, level slog.Level, keep bool) {
+	opts := options()
+	modules := make(map[string]slog.Level, len(opts.Modules)+1)
+	for k, v := range opts.Modules {
+		modules[k] = v
+	}
+	switch {
+	case module == "":
+		opts.Level = level
+	case keep:
+		modules[module] = level
+	default:
+		delete(modules, module)
+	}
+	opts.Modules = modules
+	current.Store(&opts)
+}
+
+// PendingChanges lists the runtime level changes not yet reverted.
+func PendingChanges() []LevelChange {
+	mu.Lock()
+	defer mu.Unlock()
+	opts := options()
+	out := make([]LevelChange, 0, len(overrides))
+	for module, o := range overrides {
+		level := opts.Level
+		if module != "" {
+			level = opts.levelFor(module)
+		}
+		out = append(out, LevelChange{Module: module, Level: level.String(), PreviousLevel: o.original.String(), RevertsAt: o.revertsAt})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Module < out[j].Module })
+	return out
+}
+
+func moduleName(module string) string {
+	if module == "" {
+		return "(default)"
+	}
+	return module
+}
+
+// audit writes an info record tagged module=audit straight to the sinks, so
+// level changes are recorded even when info records are filtered out.
+// Callers must hold mu.
+func audit(msg string, args ...any) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+	r.AddAttrs(slog.String(ModuleKey, "audit"))
+	r.Add(args...)
+	for _, s := range sinks {
+		_ = s.Handle(context.Background(), r.Clone())
+	}
+}
+
+// Logger returns the default logger tagged with module.
+func Logger(module string) *slog.Logger {
+	return slog.Default().With(ModuleKey, module)
+}
+
+func options() Options {
+	if opts := current.Load(); opts != nil {
+		return *opts
+	}
+	return Options{}
+}
+
+// handler filters records by their module's level and fans them out to the
+// sinks.
+type handler struct {
+	sinks  []slog.Handler
+	module string // set once a logger is tagged with a module
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	opts := options()
+	if h.module != "" {
+		return level >= opts.levelFor(h.module)
+	}
+	// The module may still be attached to the record; Handle decides.
+	return level >= opts.minLevel()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	module := h.module
+	if module == "" {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == ModuleKey {
+				module = a.Value.String()
+				return false
+			}
+			return true
+		})
+	}
+	if r.Level < options().levelFor(module) {
+		return nil
+	}
+	var errs []error
+	for _, s := range h.sinks {
+		if s.Enabled(ctx, r.Level) {
+			errs = append(errs, s.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := &handler{sinks: make([]slog.Handler, len(h.sinks)), module: h.module}
+	for _, a := range attrs {
+		if a.Key == ModuleKey {
+			out.module = a.Value.String()
+		}
+	}
+	for i, s := range h.sinks {
+		out.sinks[i] = s.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	out := &handler{sinks: make([]slog.Handler, len(h.sinks)), module: h.module}
+	for i, s := range h.sinks {
+		out.sinks[i] = s.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestParseOptions(t *testing.T) {
+	opts, err := ParseOptions("WARN", "json", " auth=debug , http=error,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Level != slog.LevelWarn || opts.Format != FormatJSON {
+		t.Errorf("opts = %+v, want warn and json", opts)
+	}
+	if opts.Modules["auth"] != slog.LevelDebug || opts.Modules["http"] != slog.LevelError || len(opts.Modules) != 2 {
+		t.Errorf("modules = %v", opts.Modules)
+	}
+
+	for _, bad := range [][3]string{{"verbose", "", ""}, {"", "xml", ""}, {"", "", "auth"}, {"", "", "auth=loud"}} {
+		if _, err := ParseOptions(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("ParseOptions%q: expected an error", bad)
+		}
+	}
+}
+
+func TestSetup_ModuleLevels(t *testing.T) {
+	prev, prevOutput, prevFlags := slog.Default(), log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+		log.SetOutput(prevOutput)
+		log.SetFlags(prevFlags)
+		current.Store(nil)
+	})
+
+	var buf bytes.Buffer
+	Setup(&buf, Options{
+		Level:   slog.LevelInfo,
+		Format:  FormatJSON,
+		Modules: map[string]slog.Level{"auth": slog.LevelDebug, "http": slog.LevelError},
+	})
+	Logger("auth").Debug("token refreshed")
+	Logger("http").Warn("slow client")
+	Logger("watch").Debug("tick")
+	Logger("watch").Info("watch state changed", "watch_id", "w1")
+	slog.Debug("untagged debug")
+	slog.Info("untagged info", ModuleKey, "http")
+	log.Printf("from the log package")
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		got = append(got, rec["msg"].(string)+"@"+stringField(rec, ModuleKey))
+	}
+	want := "token refreshed@auth|watch state changed@watch|from the log package@"
+	if strings.Join(got, "|") != want {
+		t.Errorf("records = %s, want %s", strings.Join(got, "|"), want)
+	}
+}
+
+func stringField(rec map[string]any, key string) string {
+	s, _ := rec[key].(string)
+	return s
+}
+
+func TestSetLevel_Reverts(t *testing.T) {
+	prev, prevOutput, prevFlags := slog.Default(), log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+		log.SetOutput(prevOutput)
+		log.SetFlags(prevFlags)
+		current.Store(nil)
+	})
+
+	var buf syncBuffer
+	Setup(&buf, Options{Level: slog.LevelWarn, Format: FormatJSON})
+	change := SetLevel("auth", slog.LevelDebug, time.Hour, "first")
+	if change.PreviousLevel != "WARN" || change.Level != "DEBUG" {
+		t.Errorf("change = %+v", change)
+	}
+	// A second change before the revert keeps the original level to restore.
+	SetLevel("auth", slog.LevelInfo, 50*time.Millisecond, "second")
+	if pending := PendingChanges(); len(pending) != 1 || pending[0].PreviousLevel != "WARN" || pending[0].Level != "INFO" {
+		t.Errorf("pending = %+v, want auth at INFO reverting to WARN", pending)
+	}
+	Logger("auth").Info("while raised")
+	Logger("http").Info("other module")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(PendingChanges()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	Logger("auth").Info("after revert")
+
+	out := buf.String()
+	for _, want := range []string{`"msg":"while raised"`, `"msg":"log level changed","module":"audit","target_module":"auth","log_level":"INFO","previous_log_level":"DEBUG"`, `"msg":"log level reverted","module":"audit","target_module":"auth","log_level":"WARN"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %s:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"other module", "after revert"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("log should not contain %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestSetLogLevelHandler_Validation(t *testing.T) {
+	handler := NewSetLogLevelHandler()
+	for _, args := range []SetLogLevelArgs{{Level: "loud"}, {Level: "debug", DurationMinutes: -1}, {Level: "debug", DurationMinutes: 2000}} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("%+v: expected an error", args)
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the revert timer's writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
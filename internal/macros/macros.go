@@ -0,0 +1,419 @@
+// Package macros composes existing tools into named, parameterized sequences
+// that run as a single tool call, so a common investigation is one step for
+// the model. Macros come from a JSON file or are defined at runtime with
+// define_macro.
+package macros
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxSteps bounds how many tool calls one macro makes.
+const maxSteps = 20
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+	// reference matches {{param}} and {{steps.<id>.<path>}}.
+	reference      = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*(?:\.[A-Za-z0-9_\-]+)*)\s*\}\}`)
+	wholeReference = regexp.MustCompile(`^\{\{\s*([a-z][a-z0-9_]*(?:\.[A-Za-z0-9_\-]+)*)\s*\}\}$`)
+	paramTypes     = map[string]bool{"string": true, "integer": true, "number": true, "boolean": true}
+)
+
+// File is the macros file.
+type File struct {
+	Macros []Spec `json:"macros"`
+}
+
+// Spec declares a macro: parameters and the tool calls it runs in order.
+type Spec struct {
+	Name        string  `json:"name" jsonschema:"Tool name for the macro, lowercase snake_case (required)"`
+	Description string  `json:"description" jsonschema:"What the macro investigates; shown to the model as the tool description (required)"`
+	Parameters  []Param `json:"parameters,omitempty" jsonschema:"Arguments the macro accepts"`
+	Steps       []Step  `json:"steps" jsonschema:"Tool calls run in order (required)"`
+}
+
+// Param is one macro argument.
+type Param struct {
+	Name        string `json:"name" jsonschema:"Parameter name, lowercase snake_case (required)"`
+	Type        string `json:"type,omitempty" jsonschema:"string (default), integer, number or boolean"`
+	Description string `json:"description,omitempty" jsonschema:"Parameter description"`
+	Required    bool   `json:"required,omitempty" jsonschema:"Whether the parameter must be provided"`
+}
+
+// Step is one tool call. String values in Arguments may use {{param}} for a
+// macro argument or {{steps.<id>.<field>...}} for a field of an earlier
+// step's JSON result; a string that is only a reference keeps the value's
+// JSON type.
+type Step struct {
+	ID        string         `json:"id" jsonschema:"Step id, referenced by later steps as {{steps.<id>...}} (required)"`
+	Tool      string         `json:"tool" jsonschema:"Name of the tool to call (required)"`
+	Arguments map[string]any `json:"arguments,omitempty" jsonschema:"Tool arguments; strings may contain {{param}} or {{steps.<id>.<field>}} references"`
+}
+
+// Load reads the macros file at path. An empty path means no macros.
+func Load(path string) ([]Spec, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macros file: %w", err)
+	}
+	var file File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse macros file %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, spec := range file.Macros {
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("macro %q is defined more than once in %s", spec.Name, path)
+		}
+		seen[spec.Name] = true
+	}
+	return file.Macros, nil
+}
+
+// Validate checks the spec against the tools a macro may call and fills
+// parameter defaults.
+func (s *Spec) Validate(isTool func(string) bool) error {
+	if !namePattern.MatchString(s.Name) {
+		return fmt.Errorf("macro name %q must be lowercase snake_case, up to 64 characters", s.Name)
+	}
+	if strings.TrimSpace(s.Description) == "" {
+		return fmt.Errorf("macro %s: description is required", s.Name)
+	}
+	if len(s.Steps) == 0 || len(s.Steps) > maxSteps {
+		return fmt.Errorf("macro %s: needs between 1 and %d steps", s.Name, maxSteps)
+	}
+
+	params := map[string]bool{}
+	for i := range s.Parameters {
+		p := &s.Parameters[i]
+		if !namePattern.MatchString(p.Name) || p.Name == "steps" {
+			return fmt.Errorf("macro %s: invalid parameter name %q", s.Name, p.Name)
+		}
+		if params[p.Name] {
+			return fmt.Errorf("macro %s: parameter %q is declared more than once", s.Name, p.Name)
+		}
+		params[p.Name] = true
+		if p.Type == "" {
+			p.Type = "string"
+		}
+		if !paramTypes[p.Type] {
+			return fmt.Errorf("macro %s: parameter %q has unsupported type %q", s.Name, p.Name, p.Type)
+		}
+	}
+
+	steps := map[string]bool{}
+	for _, step := range s.Steps {
+		if !namePattern.MatchString(step.ID) {
+			return fmt.Errorf("macro %s: invalid step id %q", s.Name, step.ID)
+		}
+		if steps[step.ID] {
+			return fmt.Errorf("macro %s: step id %q is used more than once", s.Name, step.ID)
+		}
+		if !isTool(step.Tool) {
+			return fmt.Errorf("macro %s: step %s calls %q, which is not an enabled tool (macros cannot call other macros)", s.Name, step.ID, step.Tool)
+		}
+		for _, ref := range references(step.Arguments) {
+			if rest, ok := strings.CutPrefix(ref, "steps."); ok {
+				if id, _, _ := strings.Cut(rest, "."); !steps[id] {
+					return fmt.Errorf("macro %s: step %s references {{%s}}, which is not an earlier step", s.Name, step.ID, ref)
+				}
+			} else if !params[ref] {
+				return fmt.Errorf("macro %s: step %s references undeclared parameter {{%s}}", s.Name, step.ID, ref)
+			}
+		}
+		steps[step.ID] = true
+	}
+	return nil
+}
+
+// InputSchema returns the MCP input schema for the macro's parameters.
+func (s Spec) InputSchema() map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, p := range s.Parameters {
+		prop := map[string]interface{}{"type": p.Type}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		properties[p.Name] = prop
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// StepResult is the outcome of one step in a macro response.
+type StepResult struct {
+	ID     string `json:"id"`
+	Tool   string `json:"tool"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CallFunc runs a tool by name in-process.
+type CallFunc func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error)
+
+// NewHandler returns a handler that runs the macro's steps in order. A failed
+// step is reported and later steps that reference it are skipped; the others
+// still run, so one broken signal does not hide the rest of the
+// investigation.
+func NewHandler(spec Spec, call CallFunc) func(context.Context, *mcp.CallToolRequest, map[string]any) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		for _, p := range spec.Parameters {
+			if v, ok := args[p.Name]; p.Required && (!ok || v == nil) {
+				return nil, nil, fmt.Errorf("%s is required", p.Name)
+			}
+		}
+
+		scope := map[string]any{}
+		for k, v := range args {
+			scope[k] = v
+		}
+		outputs := map[string]any{}
+		scope["steps"] = outputs
+
+		results := make([]StepResult, 0, len(spec.Steps))
+		for _, step := range spec.Steps {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			res := StepResult{ID: step.ID, Tool: step.Tool}
+			stepArgs, err := render(step.Arguments, scope)
+			if err != nil {
+				res.Error = err.Error()
+				results = append(results, res)
+				continue
+			}
+			out, err := call(ctx, step.Tool, stepArgs.(map[string]any))
+			switch {
+			case err != nil:
+				res.Error = err.Error()
+			case out == nil:
+				res.Error = "tool returned no result"
+			case out.IsError:
+				res.Error = resultText(out)
+			default:
+				res.Result = decodeResult(out)
+				outputs[step.ID] = res.Result
+			}
+			results = append(results, res)
+		}
+
+		data, err := json.Marshal(map[string]any{"macro": spec.Name, "steps": results})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// render copies v, replacing references in strings with values from scope.
+// Map entries whose value is a reference to an unset optional parameter are
+// dropped so the tool's own default applies.
+func render(v any, scope map[string]any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			if s, ok := item.(string); ok {
+				if m := wholeReference.FindStringSubmatch(s); m != nil && !strings.HasPrefix(m[1], "steps.") {
+					if value, ok := scope[m[1]]; !ok || value == nil {
+						continue
+					}
+				}
+			}
+			rendered, err := render(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := render(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case string:
+		if m := wholeReference.FindStringSubmatch(v); m != nil {
+			return lookup(scope, m[1])
+		}
+		var lookupErr error
+		s := reference.ReplaceAllStringFunc(v, func(ref string) string {
+			value, err := lookup(scope, reference.FindStringSubmatch(ref)[1])
+			if err != nil {
+				lookupErr = err
+				return ""
+			}
+			switch value := value.(type) {
+			case nil:
+				return ""
+			case string:
+				return value
+			default:
+				data, _ := json.Marshal(value)
+				return string(data)
+			}
+		})
+		return s, lookupErr
+	}
+	return v, nil
+}
+
+// lookup resolves a dotted reference; array elements are addressed by index.
+func lookup(scope map[string]any, ref string) (any, error) {
+	parts := strings.Split(ref, ".")
+	if parts[0] == "steps" && len(parts) > 1 {
+		if _, ok := scope["steps"].(map[string]any)[parts[1]]; !ok {
+			return nil, fmt.Errorf("depends on step %s, which failed", parts[1])
+		}
+	}
+	var cur any = scope
+	for i, part := range parts {
+		switch node := cur.(type) {
+		case map[string]any:
+			cur = node[part]
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("{{%s}}: no element %q", ref, part)
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("{{%s}}: %s is not an object or array", ref, strings.Join(parts[:i], "."))
+		}
+	}
+	return cur, nil
+}
+
+// references lists the references used anywhere in v.
+func references(v any) []string {
+	var refs []string
+	switch v := v.(type) {
+	case map[string]any:
+		for _, item := range v {
+			refs = append(refs, references(item)...)
+		}
+	case []any:
+		for _, item := range v {
+			refs = append(refs, references(item)...)
+		}
+	case string:
+		for _, m := range reference.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, m[1])
+		}
+	}
+	return refs
+}
+
+// decodeResult returns a step's text result as JSON when it parses, and as
+// a string otherwise.
+func decodeResult(result *mcp.CallToolResult) any {
+	text := resultText(result)
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err == nil {
+		return v
+	}
+	return text
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Set holds macros defined at runtime with define_macro, so they survive
+// tool re-registration for the life of the process.
+type Set struct {
+	mu     sync.Mutex
+	macros map[string]Spec
+	order  []string
+}
+
+// NewSet returns an empty set.
+func NewSet() *Set {
+	return &Set{macros: map[string]Spec{}}
+}
+
+// Put adds or replaces a macro.
+func (s *Set) Put(spec Spec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.macros[spec.Name]; !ok {
+		s.order = append(s.order, spec.Name)
+	}
+	s.macros[spec.Name] = spec
+}
+
+// All returns the macros in definition order.
+func (s *Set) All() []Spec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	specs := make([]Spec, 0, len(s.order))
+	for _, name := range s.order {
+		specs = append(specs, s.macros[name])
+	}
+	return specs
+}
+
+// Has reports whether name is a runtime-defined macro.
+func (s *Set) Has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.macros[name]
+	return ok
+}
+
+// NewDefineMacroHandler returns a handler that validates a macro and
+// registers it as a tool through register.
+func NewDefineMacroHandler(register func(Spec) error) func(context.Context, *mcp.CallToolRequest, Spec) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, spec Spec) (*mcp.CallToolResult, any, error) {
+		if err := register(spec); err != nil {
+			return nil, nil, err
+		}
+		data, err := json.Marshal(map[string]any{
+			"defined": spec.Name,
+			"steps":   len(spec.Steps),
+			"note":    "The macro is now available as a tool for the rest of this server's lifetime. Clients that cache the tool list may need to refresh it.",
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,180 @@
+package macros
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func testSpec() Spec {
+	return Spec{
+		Name:        "check_service",
+		Description: "Summary and errors for one service",
+		Parameters:  []Param{{Name: "service", Required: true}, {Name: "env"}, {Name: "limit", Type: "integer"}},
+		Steps: []Step{
+			{ID: "summary", Tool: "get_service_summary", Arguments: map[string]any{"env": "{{env}}"}},
+			{ID: "logs", Tool: "get_service_logs", Arguments: map[string]any{
+				"service_name": "{{service}}",
+				"limit":        "{{limit}}",
+				"body_filters": []any{"{{steps.summary.top.0.name}} failed"},
+			}},
+			{ID: "first", Tool: "echo", Arguments: map[string]any{"value": "{{steps.summary.top.0}}"}},
+		},
+	}
+}
+
+func isKnownTool(name string) bool {
+	return name == "get_service_summary" || name == "get_service_logs" || name == "echo"
+}
+
+func TestValidate(t *testing.T) {
+	spec := testSpec()
+	if err := spec.Validate(isKnownTool); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if spec.Parameters[0].Type != "string" {
+		t.Errorf("default parameter type = %q, want string", spec.Parameters[0].Type)
+	}
+
+	invalid := map[string]func(*Spec){
+		"bad name":             func(s *Spec) { s.Name = "Check-Service" },
+		"no description":       func(s *Spec) { s.Description = "" },
+		"no steps":             func(s *Spec) { s.Steps = nil },
+		"unknown tool":         func(s *Spec) { s.Steps[0].Tool = "check_service" },
+		"undeclared parameter": func(s *Spec) { s.Steps[0].Arguments = map[string]any{"env": "{{environment}}"} },
+		"forward reference":    func(s *Spec) { s.Steps[0].Arguments = map[string]any{"env": "{{steps.logs.env}}"} },
+		"duplicate step id":    func(s *Spec) { s.Steps[1].ID = "summary" },
+		"reserved parameter":   func(s *Spec) { s.Parameters = append(s.Parameters, Param{Name: "steps"}) },
+		"bad parameter type":   func(s *Spec) { s.Parameters[0].Type = "object" },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			spec := testSpec()
+			mutate(&spec)
+			if err := spec.Validate(isKnownTool); err == nil {
+				t.Fatal("Validate() succeeded, want error")
+			}
+		})
+	}
+}
+
+func textResult(t *testing.T, v any) *mcp.CallToolResult {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(data)}}}
+}
+
+func TestHandler(t *testing.T) {
+	spec := testSpec()
+	if err := spec.Validate(isKnownTool); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []map[string]any
+	call := func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+		calls = append(calls, args)
+		switch tool {
+		case "get_service_summary":
+			return textResult(t, map[string]any{"top": []any{map[string]any{"name": "GET /cart", "p95": 1.5}}}), nil
+		case "get_service_logs":
+			return textResult(t, map[string]any{"entries": 2}), nil
+		}
+		return textResult(t, args), nil
+	}
+
+	result, _, err := NewHandler(spec, call)(context.Background(), &mcp.CallToolRequest{}, map[string]any{"service": "checkout", "limit": float64(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// env was not provided, so it is dropped rather than sent empty.
+	if want := map[string]any{}; !reflect.DeepEqual(calls[0], want) {
+		t.Errorf("summary args = %v, want %v", calls[0], want)
+	}
+	wantLogs := map[string]any{"service_name": "checkout", "limit": float64(5), "body_filters": []any{"GET /cart failed"}}
+	if !reflect.DeepEqual(calls[1], wantLogs) {
+		t.Errorf("logs args = %v, want %v", calls[1], wantLogs)
+	}
+	// A whole-string reference keeps the JSON type of the value.
+	if want := map[string]any{"value": map[string]any{"name": "GET /cart", "p95": 1.5}}; !reflect.DeepEqual(calls[2], want) {
+		t.Errorf("echo args = %v, want %v", calls[2], want)
+	}
+
+	var out struct {
+		Macro string       `json:"macro"`
+		Steps []StepResult `json:"steps"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Macro != "check_service" || len(out.Steps) != 3 || out.Steps[1].Result == nil {
+		t.Fatalf("response = %+v", out)
+	}
+
+	if _, _, err := NewHandler(spec, call)(context.Background(), &mcp.CallToolRequest{}, map[string]any{}); err == nil || !strings.Contains(err.Error(), "service is required") {
+		t.Errorf("missing required parameter error = %v", err)
+	}
+}
+
+func TestHandlerStepFailure(t *testing.T) {
+	spec := testSpec()
+	if err := spec.Validate(isKnownTool); err != nil {
+		t.Fatal(err)
+	}
+	var called []string
+	call := func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+		called = append(called, tool)
+		if tool == "get_service_summary" {
+			return nil, errors.New("upstream timeout")
+		}
+		return textResult(t, args), nil
+	}
+	spec.Steps = append(spec.Steps, Step{ID: "independent", Tool: "echo", Arguments: map[string]any{"service": "{{service}}"}})
+
+	result, _, err := NewHandler(spec, call)(context.Background(), &mcp.CallToolRequest{}, map[string]any{"service": "checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Steps []StepResult `json:"steps"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Steps[0].Error != "upstream timeout" {
+		t.Errorf("failed step error = %q", out.Steps[0].Error)
+	}
+	for _, i := range []int{1, 2} {
+		if !strings.Contains(out.Steps[i].Error, "depends on step summary") {
+			t.Errorf("step %s error = %q, want dependency failure", out.Steps[i].ID, out.Steps[i].Error)
+		}
+	}
+	if out.Steps[3].Error != "" || out.Steps[3].Result == nil {
+		t.Errorf("independent step = %+v, want a result", out.Steps[3])
+	}
+	if want := []string{"get_service_summary", "echo"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("called = %v, want %v", called, want)
+	}
+}
+
+func TestSet(t *testing.T) {
+	s := NewSet()
+	s.Put(Spec{Name: "b"})
+	s.Put(Spec{Name: "a"})
+	s.Put(Spec{Name: "b", Description: "replaced"})
+	all := s.All()
+	if len(all) != 2 || all[0].Name != "b" || all[0].Description != "replaced" || all[1].Name != "a" {
+		t.Fatalf("All() = %+v", all)
+	}
+	if !s.Has("a") || s.Has("c") {
+		t.Error("Has() mismatch")
+	}
+}
@@ -0,0 +1,112 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DeclareMaintenanceWindowArgs represents the input arguments for the declare_maintenance_window tool
+type DeclareMaintenanceWindowArgs struct {
+	ServiceName     string `json:"service_name" jsonschema:"Service under maintenance, or * for all services (required, e.g. checkout)"`
+	Env             string `json:"env,omitempty" jsonschema:"Environment under maintenance (e.g. production). Default: all environments."`
+	Reason          string `json:"reason" jsonschema:"Why the service is under maintenance (required, e.g. Postgres 16 upgrade)"`
+	StartTimeISO    string `json:"start_time_iso,omitempty" jsonschema:"Window start in RFC3339 format (e.g. 2026-02-09T22:00:00Z). Default: now."`
+	EndTimeISO      string `json:"end_time_iso,omitempty" jsonschema:"Window end in RFC3339 format (e.g. 2026-02-09T23:30:00Z). Either this or duration_minutes is required."`
+	DurationMinutes int    `json:"duration_minutes,omitempty" jsonschema:"Window length in minutes from the start, used when end_time_iso is omitted (e.g. 90)"`
+}
+
+// ListMaintenanceWindowsArgs represents the input arguments for the list_maintenance_windows tool
+type ListMaintenanceWindowsArgs struct {
+	ServiceName  string `json:"service_name,omitempty" jsonschema:"Only list windows covering this service (optional)"`
+	IncludeEnded bool   `json:"include_ended,omitempty" jsonschema:"Also list windows that have already ended (default: false)"`
+}
+
+// DeleteMaintenanceWindowArgs represents the input arguments for the delete_maintenance_window tool
+type DeleteMaintenanceWindowArgs struct {
+	ID string `json:"id" jsonschema:"ID of the window to delete, as returned by declare_maintenance_window (required, e.g. mw-1a2b3c4d)"`
+}
+
+func jsonResult(v any) (*mcp.CallToolResult, any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+	}, nil, nil
+}
+
+// NewDeclareMaintenanceWindowHandler returns a handler that declares a window.
+func NewDeclareMaintenanceWindowHandler(store *Store) func(context.Context, *mcp.CallToolRequest, DeclareMaintenanceWindowArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DeclareMaintenanceWindowArgs) (*mcp.CallToolResult, any, error) {
+		start := time.Now().UTC()
+		if args.StartTimeISO != "" {
+			t, err := time.Parse(time.RFC3339, args.StartTimeISO)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid start_time_iso: %w", err)
+			}
+			start = t
+		}
+		var end time.Time
+		switch {
+		case args.EndTimeISO != "":
+			t, err := time.Parse(time.RFC3339, args.EndTimeISO)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid end_time_iso: %w", err)
+			}
+			end = t
+		case args.DurationMinutes > 0:
+			end = start.Add(time.Duration(args.DurationMinutes) * time.Minute)
+		default:
+			return nil, nil, fmt.Errorf("end_time_iso or duration_minutes is required")
+		}
+
+		w, err := store.Add(Window{ServiceName: args.ServiceName, Env: args.Env, Start: start, End: end, Reason: args.Reason})
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonResult(map[string]any{
+			"declared": w,
+			"usage":    "get_alerts and get_apm_service_deviations annotate findings for this service inside the window; get_alerts drops them with suppress_maintenance=true.",
+		})
+	}
+}
+
+// NewListMaintenanceWindowsHandler returns a handler that lists windows.
+func NewListMaintenanceWindowsHandler(store *Store) func(context.Context, *mcp.CallToolRequest, ListMaintenanceWindowsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ListMaintenanceWindowsArgs) (*mcp.CallToolResult, any, error) {
+		now := time.Now()
+		windows := []Window{}
+		for _, w := range store.All() {
+			if !args.IncludeEnded && w.End.Before(now) {
+				continue
+			}
+			if args.ServiceName != "" && !w.Covers(args.ServiceName, "") {
+				continue
+			}
+			windows = append(windows, w)
+		}
+		return jsonResult(map[string]any{"windows": windows, "count": len(windows)})
+	}
+}
+
+// NewDeleteMaintenanceWindowHandler returns a handler that deletes a window.
+func NewDeleteMaintenanceWindowHandler(store *Store) func(context.Context, *mcp.CallToolRequest, DeleteMaintenanceWindowArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DeleteMaintenanceWindowArgs) (*mcp.CallToolResult, any, error) {
+		if args.ID == "" {
+			return nil, nil, fmt.Errorf("id is required")
+		}
+		deleted, err := store.Delete(args.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !deleted {
+			return nil, nil, fmt.Errorf("unknown maintenance window %q; list_maintenance_windows shows the declared windows", args.ID)
+		}
+		return jsonResult(map[string]any{"deleted": args.ID})
+	}
+}
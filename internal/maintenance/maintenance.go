@@ -0,0 +1,272 @@
+// Package maintenance stores declared maintenance windows (a service, a time
+// range and a reason) so that alert and anomaly tools can tell expected
+// disruption from incidents. Windows are declared with
+// declare_maintenance_window and kept in a JSON file so they survive restarts.
+package maintenance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+const (
+	// maxWindows bounds how many windows can be declared.
+	maxWindows = 500
+	// maxDuration bounds a single window, so a typo in the end time does not
+	// silence a service indefinitely.
+	maxDuration = 7 * 24 * time.Hour
+	// retention is how long ended windows are kept for looking back at past
+	// alerts before they are pruned.
+	retention = 30 * 24 * time.Hour
+	// AllServices as the service name declares a window for every service.
+	AllServices = "*"
+)
+
+// Window is a declared maintenance window. An empty Env covers every
+// environment of the service.
+type Window struct {
+	ID          string    `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Env         string    `json:"env,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Covers reports whether the window applies to the service and env. An
+// empty env (unknown) matches windows for any environment.
+func (w Window) Covers(service, env string) bool {
+	if w.ServiceName != AllServices && !strings.EqualFold(w.ServiceName, service) {
+		return false
+	}
+	return w.Env == "" || env == "" || strings.EqualFold(w.Env, env)
+}
+
+// Overlaps reports whether the window intersects [start, end].
+func (w Window) Overlaps(start, end time.Time) bool {
+	return !w.Start.After(end) && !w.End.Before(start)
+}
+
+// String describes the window for annotations in tool output.
+func (w Window) String() string {
+	return fmt.Sprintf("%s (%s, %s to %s)", w.Reason, w.ID,
+		w.Start.UTC().Format(time.RFC3339), w.End.UTC().Format(time.RFC3339))
+}
+
+// Store holds maintenance windows. A nil *Store is valid and has no windows.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	windows []Window
+	// loadErr is why the file could not be loaded. While it is set the
+	// store refuses to write, so a file it failed to parse is not replaced.
+	loadErr error
+}
+
+// DefaultPath returns the maintenance file in the per-user cache directory,
+// or "" when the platform has none.
+func DefaultPath() string {
+	dir := diskcache.DefaultDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "maintenance.json")
+}
+
+// New returns a store persisted to path, loading the windows already in it.
+// An empty path keeps windows in memory for the life of the process. A file
+// that cannot be read or parsed is logged and does not block startup, but
+// the store then has no windows and refuses to save over it.
+func New(path string) *Store {
+	s := &Store{path: path}
+	if path == "" {
+		return s
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s
+	}
+	if err == nil {
+		err = json.Unmarshal(raw, &s.windows)
+	}
+	if err != nil {
+		s.windows = nil
+		s.loadErr = fmt.Errorf("failed to load maintenance file %s: %w", path, err)
+		logging.Logger("maintenance").Warn("ignoring maintenance windows", "path", path, "error", err)
+	}
+	return s
+}
+
+// Validate checks a window's service, range and reason.
+func (w Window) Validate() error {
+	if strings.TrimSpace(w.ServiceName) == "" {
+		return fmt.Errorf("service_name is required (use %q for all services)", AllServices)
+	}
+	if strings.TrimSpace(w.Reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	if w.End.Sub(w.Start) > maxDuration {
+		return fmt.Errorf("maintenance windows can last at most %s, got %s", maxDuration, w.End.Sub(w.Start))
+	}
+	return nil
+}
+
+// Add validates w, assigns it an ID and stores it.
+func (s *Store) Add(w Window) (Window, error) {
+	if err := w.Validate(); err != nil {
+		return w, err
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return w, fmt.Errorf("failed to generate window ID: %w", err)
+	}
+	w.ID = "mw-" + hex.EncodeToString(id)
+	w.Start, w.End = w.Start.UTC(), w.End.UTC()
+	w.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.windows
+	s.windows = pruned(s.windows, w.CreatedAt)
+	if len(s.windows) >= maxWindows {
+		s.windows = previous
+		return w, fmt.Errorf("cannot declare more than %d maintenance windows; delete one with delete_maintenance_window first", maxWindows)
+	}
+	s.windows = append(s.windows, w)
+	if err := s.write(); err != nil {
+		s.windows = previous
+		return w, err
+	}
+	return w, nil
+}
+
+// Delete removes the window with the given ID and reports whether it existed.
+func (s *Store) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, w := range s.windows {
+		if w.ID != id {
+			continue
+		}
+		previous := s.windows
+		s.windows = append(append([]Window{}, s.windows[:i]...), s.windows[i+1:]...)
+		if err := s.write(); err != nil {
+			s.windows = previous
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// All returns the stored windows ordered by start time.
+func (s *Store) All() []Window {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := append([]Window{}, s.windows...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// Find returns the windows for the service and env that overlap
+// [start, end], ordered by start time.
+func (s *Store) Find(service, env string, start, end time.Time) []Window {
+	var out []Window
+	for _, w := range s.All() {
+		if w.Covers(service, env) && w.Overlaps(start, end) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// Match is a window found for a service. Dependency names the service the
+// window covers when it applies only through a dependency the service
+// calls, and is empty for the service's own windows.
+type Match struct {
+	Window
+	Dependency string `json:"dependency,omitempty"`
+}
+
+// String describes the match for annotations in tool output.
+func (m Match) String() string {
+	if m.Dependency == "" {
+		return m.Window.String()
+	}
+	return fmt.Sprintf("%s on dependency %s", m.Window, m.Dependency)
+}
+
+// FindWithDependencies returns the windows for the service and env that
+// overlap [start, end], followed by those of the dependencies it calls in
+// that env: maintenance of a downstream service shows in the caller's
+// latency and errors too. A window is returned once, preferring the
+// service's own match.
+func (s *Store) FindWithDependencies(service, env string, dependencies []string, start, end time.Time) []Match {
+	seen := map[string]bool{}
+	var out []Match
+	for _, w := range s.Find(service, env, start, end) {
+		seen[w.ID] = true
+		out = append(out, Match{Window: w})
+	}
+	for _, dep := range dependencies {
+		if strings.EqualFold(dep, service) {
+			continue
+		}
+		for _, w := range s.Find(dep, env, start, end) {
+			if !seen[w.ID] {
+				seen[w.ID] = true
+				out = append(out, Match{Window: w, Dependency: dep})
+			}
+		}
+	}
+	return out
+}
+
+// pruned drops windows that ended more than the retention period before now.
+func pruned(windows []Window, now time.Time) []Window {
+	out := make([]Window, 0, len(windows))
+	for _, w := range windows {
+		if now.Sub(w.End) <= retention {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// write saves all windows. Callers hold s.mu.
+func (s *Store) write() error {
+	if s.path == "" {
+		return nil
+	}
+	if s.loadErr != nil {
+		return fmt.Errorf("%w; fix or remove it to declare or delete windows", s.loadErr)
+	}
+	data, err := json.MarshalIndent(s.windows, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode maintenance windows: %w", err)
+	}
+	if err := diskcache.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write maintenance file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package maintenance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// start is in the future: Add prunes windows that ended long ago.
+var start = time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "maintenance.json")
+	s := New(path)
+	w, err := s.Add(Window{ServiceName: "checkout", Env: "prod", Start: start, End: start.Add(time.Hour), Reason: "db upgrade"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(w.ID, "mw-") {
+		t.Errorf("ID = %q, want an mw- prefix", w.ID)
+	}
+
+	reloaded := New(path)
+	if all := reloaded.All(); len(all) != 1 || all[0].Reason != "db upgrade" {
+		t.Fatalf("All() after reload = %+v", all)
+	}
+	if deleted, err := reloaded.Delete(w.ID); err != nil || !deleted {
+		t.Fatalf("Delete() = %v, %v", deleted, err)
+	}
+	if deleted, _ := reloaded.Delete(w.ID); deleted {
+		t.Error("Delete() of a missing window reported true")
+	}
+	if got := New(path).All(); len(got) != 0 {
+		t.Errorf("All() after delete and reload = %+v", got)
+	}
+}
+
+func TestStoreKeepsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance.json")
+	corrupt := []byte(`[{"id":"mw-1","service_name":"checkout"`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := New(path)
+	if all := s.All(); len(all) != 0 {
+		t.Errorf("All() = %+v, want no windows from a corrupt file", all)
+	}
+	if _, err := s.Add(Window{ServiceName: "checkout", Start: start, End: start.Add(time.Hour), Reason: "db upgrade"}); err == nil || !strings.Contains(err.Error(), "fix or remove it") {
+		t.Errorf("Add() error = %v, want a refusal to overwrite", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(corrupt) {
+		t.Errorf("file = %s, want it left as it was", got)
+	}
+	if len(s.All()) != 0 {
+		t.Error("a window that was not saved should not be kept")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := map[string]Window{
+		"no service": {Start: start, End: start.Add(time.Hour), Reason: "x"},
+		"no reason":  {ServiceName: "checkout", Start: start, End: start.Add(time.Hour)},
+		"end first":  {ServiceName: "checkout", Start: start, End: start, Reason: "x"},
+		"too long":   {ServiceName: "checkout", Start: start, End: start.Add(maxDuration + time.Minute), Reason: "x"},
+	}
+	for name, w := range invalid {
+		if err := w.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want an error", name)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	s := New("")
+	for _, w := range []Window{
+		{ServiceName: "checkout", Env: "prod", Start: start, End: start.Add(time.Hour), Reason: "prod upgrade"},
+		{ServiceName: "checkout", Env: "staging", Start: start, End: start.Add(time.Hour), Reason: "staging upgrade"},
+		{ServiceName: AllServices, Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour), Reason: "network maintenance"},
+	} {
+		if _, err := s.Add(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := s.Find("Checkout", "prod", start.Add(30*time.Minute), start.Add(30*time.Minute))
+	if len(got) != 1 || got[0].Reason != "prod upgrade" {
+		t.Errorf("Find(checkout, prod) = %+v, want the prod window", got)
+	}
+	if got := s.Find("checkout", "", start, start.Add(time.Minute)); len(got) != 2 {
+		t.Errorf("Find with unknown env = %+v, want both checkout windows", got)
+	}
+	if got := s.Find("cart", "prod", start.Add(2*time.Hour), start.Add(4*time.Hour)); len(got) != 1 || got[0].ServiceName != AllServices {
+		t.Errorf("Find(cart) = %+v, want the all-services window", got)
+	}
+	if got := s.Find("checkout", "prod", start.Add(-2*time.Hour), start.Add(-time.Hour)); len(got) != 0 {
+		t.Errorf("Find before the window = %+v, want none", got)
+	}
+	var nilStore *Store
+	if got := nilStore.Find("checkout", "", start, start); got != nil {
+		t.Errorf("nil store Find() = %+v", got)
+	}
+}
+
+func TestFindWithDependencies(t *testing.T) {
+	s := New("")
+	for _, w := range []Window{
+		{ServiceName: "checkout", Start: start, End: start.Add(time.Hour), Reason: "checkout deploy"},
+		{ServiceName: "payments-db", Env: "prod", Start: start, End: start.Add(time.Hour), Reason: "db failover"},
+		{ServiceName: "payments-db", Env: "staging", Start: start, End: start.Add(time.Hour), Reason: "staging failover"},
+		{ServiceName: AllServices, Start: start, End: start.Add(time.Hour), Reason: "network maintenance"},
+		{ServiceName: "inventory", Start: start, End: start.Add(time.Hour), Reason: "inventory deploy"},
+	} {
+		if _, err := s.Add(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := s.FindWithDependencies("checkout", "prod", []string{"payments-db", "checkout"}, start, start.Add(time.Minute))
+	var own, viaDep []string
+	for _, m := range got {
+		if m.Dependency == "" {
+			own = append(own, m.Reason)
+		} else {
+			viaDep = append(viaDep, m.Dependency+":"+m.Reason)
+		}
+	}
+	if len(own) != 2 {
+		t.Errorf("own windows = %v, want checkout deploy and the all-services window once", own)
+	}
+	if len(viaDep) != 1 || viaDep[0] != "payments-db:db failover" {
+		t.Errorf("dependency windows = %v, want only the prod payments-db window", viaDep)
+	}
+	if want := "on dependency payments-db"; !strings.HasSuffix(got[len(got)-1].String(), want) {
+		t.Errorf("String() = %q, want it to end with %q", got[len(got)-1], want)
+	}
+	if got := s.FindWithDependencies("checkout", "prod", nil, start.Add(2*time.Hour), start.Add(3*time.Hour)); len(got) != 0 {
+		t.Errorf("after the windows = %+v, want none", got)
+	}
+}
+
+func TestDeclareHandler_Duration(t *testing.T) {
+	s := New("")
+	handler := NewDeclareMaintenanceWindowHandler(s)
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, DeclareMaintenanceWindowArgs{
+		ServiceName: "checkout", Reason: "deploy", StartTimeISO: start.Format(time.RFC3339), DurationMinutes: 90,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if all := s.All(); len(all) != 1 || !all[0].End.Equal(start.Add(90*time.Minute)) {
+		t.Errorf("declared windows = %+v", all)
+	}
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, DeclareMaintenanceWindowArgs{ServiceName: "checkout", Reason: "deploy"}); err == nil {
+		t.Error("expected an error without end_time_iso or duration_minutes")
+	}
+}
@@ -0,0 +1,181 @@
+package mockbackend
+
+import (
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// service is one service of the synthetic shop the mock backend reports on.
+type service struct {
+	name      string
+	rpm       float64 // requests per minute in production
+	latencyMs float64 // p95 latency
+	errorRate float64 // fraction of requests that fail
+	endpoints []string
+	calls     []string // services called
+	db        string   // db_system used, if any
+	external  string   // third-party host called, if any
+	exception string   // exception_type of failed requests
+	topic     string   // Kafka topic produced to, if any
+	version   string   // service_version label
+	canary    string   // version in a canary rollout, if any
+}
+
+var services = []service{
+	{name: "frontend", rpm: 1200, latencyMs: 180, errorRate: 0.008, endpoints: []string{"GET /", "GET /product/{id}", "POST /cart"}, calls: []string{"checkout", "cart"}, exception: "UpstreamTimeoutError", version: "2.14.0"},
+	{name: "checkout", rpm: 300, latencyMs: 420, errorRate: 0.015, endpoints: []string{"POST /api/checkout", "GET /api/orders/{id}"}, calls: []string{"payments", "cart", "inventory"}, db: "postgresql", exception: "OrderValidationError", topic: "orders", version: "3.2.1"},
+	{name: "cart", rpm: 800, latencyMs: 45, errorRate: 0.004, endpoints: []string{"GET /api/cart", "POST /api/cart/items"}, db: "redis", exception: "RedisConnectionError", version: "1.8.0"},
+	{name: "payments", rpm: 290, latencyMs: 260, errorRate: 0.035, endpoints: []string{"POST /api/charge"}, external: "api.stripe.com", exception: "CardDeclinedError", topic: "payment-events", version: "1.4.2", canary: "1.5.0"},
+	{name: "inventory", rpm: 500, latencyMs: 60, errorRate: 0.003, endpoints: []string{"GET /api/stock/{sku}"}, db: "postgresql", exception: "StockLookupError", version: "0.9.7"},
+}
+
+// dbHosts are the hosts of the shared databases, by db_system.
+var dbHosts = map[string]string{
+	"postgresql": "pg-main.shop.internal",
+	"redis":      "redis-cart.shop.internal",
+}
+
+// kafkaHost is the broker the services produce to.
+const kafkaHost = "kafka.shop.internal"
+
+// serviceVersion is a deployed version of a service, with its share of the
+// traffic and how it fares against the stable version.
+type serviceVersion struct {
+	name         string
+	share        float64
+	errorScale   float64
+	latencyScale float64
+}
+
+// versions returns the versions of s. A canary takes a tenth of the
+// traffic and fails three times as often.
+func (s service) versions() []serviceVersion {
+	if s.canary == "" {
+		return []serviceVersion{{s.version, 1, 1, 1}}
+	}
+	return []serviceVersion{{s.version, 0.9, 1, 1}, {s.canary, 0.1, 3, 1.3}}
+}
+
+// envs are the environments, with their traffic relative to production.
+var envs = []struct {
+	name  string
+	scale float64
+}{
+	{"production", 1},
+	{"staging", 0.08},
+}
+
+// quantileFactors scale a p95 latency to other quantiles.
+var quantileFactors = map[string]float64{
+	"p50": 0.55, "p75": 0.75, "p90": 0.9, "p95": 1, "p99": 1.6, "p999": 2.4, "avg": 0.6, "max": 3,
+}
+
+// row is one fine-grained series of the dataset. Queries filter rows by
+// their matchers and sum or average them per group.
+type row struct {
+	labels    map[string]string
+	rpm       float64
+	latencyMs float64
+	failed    bool
+}
+
+// Row families: the series behind different metrics.
+const (
+	familyEndpoint          = "endpoint"
+	familyClient            = "client"
+	familyCallGraph         = "call_graph"
+	familyInternalCallGraph = "internal_call_graph" // calls to databases and brokers
+	familyPod               = "pod"
+)
+
+func findService(name string) (service, bool) {
+	for _, s := range services {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return service{}, false
+}
+
+// rows returns the series of family. Endpoint rows come in pairs, one for
+// successful and one for failed requests, so status matchers pick errors.
+func rows(family string) []row {
+	var out []row
+	for _, e := range envs {
+		for _, s := range services {
+			switch family {
+			case familyEndpoint:
+				share := 1 / float64(len(s.endpoints))
+				for i, ep := range s.endpoints {
+					for _, v := range s.versions() {
+						rpm := s.rpm * e.scale * share * v.share
+						errorRate := s.errorRate * v.errorScale
+						latency := s.latencyMs * v.latencyScale * (0.7 + 0.6*float64(i)/float64(len(s.endpoints)))
+						base := map[string]string{"service_name": s.name, "env": e.name, "span_name": ep, "span_kind": "SPAN_KIND_SERVER", "service_version": v.name}
+						out = append(out,
+							row{labels: with(base, "http_status_code", "200", "status_code", "STATUS_CODE_UNSET"), rpm: rpm * (1 - errorRate), latencyMs: latency},
+							row{labels: with(base, "http_status_code", "500", "status_code", "STATUS_CODE_ERROR", "exception_type", s.exception), rpm: rpm * errorRate, latencyMs: latency * 1.8, failed: true},
+						)
+					}
+				}
+			case familyClient:
+				base := map[string]string{"service_name": s.name, "env": e.name, "span_kind": "SPAN_KIND_CLIENT"}
+				for _, callee := range s.calls {
+					c, _ := findService(callee)
+					out = append(out, row{labels: with(base, "span_name", "POST "+callee, "net_peer_name", callee, "rpc_system", "http"), rpm: s.rpm * e.scale * 0.6, latencyMs: c.latencyMs * 1.05})
+				}
+				if s.db != "" {
+					out = append(out, row{labels: with(base, "span_name", "SELECT "+s.name, "net_peer_name", dbHosts[s.db], "db_system", s.db), rpm: s.rpm * e.scale * 2, latencyMs: 8})
+				}
+				if s.external != "" {
+					out = append(out, row{labels: with(base, "span_name", "POST /v1/charges", "net_peer_name", s.external), rpm: s.rpm * e.scale, latencyMs: s.latencyMs * 0.7})
+				}
+			case familyCallGraph:
+				for _, callee := range s.calls {
+					c, _ := findService(callee)
+					out = append(out, row{labels: map[string]string{"client": s.name, "server": callee, "env": e.name, "client_status": "ok"}, rpm: s.rpm * e.scale * 0.6, latencyMs: c.latencyMs * 1.05})
+				}
+			case familyInternalCallGraph:
+				base := map[string]string{"client": s.name, "env": e.name, "client_status": "ok"}
+				if s.db != "" {
+					out = append(out, row{labels: with(base, "server_host", dbHosts[s.db], "server_db_system", s.db), rpm: s.rpm * e.scale * 2, latencyMs: 8})
+				}
+				if s.topic != "" {
+					out = append(out, row{labels: with(base, "server_host", kafkaHost, "server_messaging_system", "kafka", "server_rpc_system", "kafka", "server_rpc_service", s.topic), rpm: s.rpm * e.scale, latencyMs: 3})
+				}
+			case familyPod:
+				for _, suffix := range []string{"7d9f8c-x2k9p", "7d9f8c-q7m4z"} {
+					out = append(out, row{labels: map[string]string{"namespace": "shop-" + e.name, "pod": s.name + "-" + suffix, "service_name": s.name, "env": e.name}, rpm: s.rpm * e.scale / 2, latencyMs: s.latencyMs})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// with returns a copy of labels with the name, value pairs in kv added.
+func with(labels map[string]string, kv ...string) map[string]string {
+	out := make(map[string]string, len(labels)+len(kv)/2)
+	for k, v := range labels {
+		out[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[kv[i]] = kv[i+1]
+	}
+	return out
+}
+
+// noise returns a stable pseudo-random number in [0, 1) for key.
+func noise(key string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return float64(h.Sum64()%10000) / 10000
+}
+
+// diurnal is the daily traffic shape: busiest in the afternoon UTC and
+// quietest at night.
+func diurnal(t time.Time) float64 {
+	hour := float64(t.UTC().Hour()) + float64(t.UTC().Minute())/60
+	return 1 + 0.35*math.Sin(2*math.Pi*(hour-9)/24)
+}
@@ -0,0 +1,255 @@
+// Package mockbackend is an in-process stand-in for the Last9 API that
+// serves synthetic metrics, traces, logs and alerts for a small shop of
+// services, so the tools can be run without a Last9 account.
+package mockbackend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+// OrgSlug is the organization the mock backend serves.
+const OrgSlug = "mock"
+
+// maxRangePoints is the number of points a range query returns per series.
+const maxRangePoints = 120
+
+// Server is a running mock backend.
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+}
+
+// Start serves the mock backend on a free loopback port.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	s := &Server{
+		listener: listener,
+		server: &http.Server{
+			Handler:           Handler(),
+			ReadHeaderTimeout: constants.HTTPServerReadHeaderTimeout,
+		},
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Logger("server").Error("mock backend stopped", "error", err)
+		}
+	}()
+	return s, nil
+}
+
+// URL is the API base URL of the mock backend.
+func (s *Server) URL() string {
+	return "http://" + s.listener.Addr().String() + "/api/v4/organizations/" + OrgSlug
+}
+
+// Close stops the mock backend.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Configure points cfg at the mock backend in place of Authenticate: the
+// API base URL, a datasource and an access token that never needs a
+// refresh.
+func (s *Server) Configure(cfg *models.Config) {
+	Configure(cfg, s.URL())
+}
+
+// Configure points cfg at a mock backend serving the API at baseURL.
+func Configure(cfg *models.Config, baseURL string) {
+	cfg.OrgSlug = OrgSlug
+	cfg.APIBaseURL = baseURL
+	cfg.ActionURL = baseURL
+	cfg.Region = "mock-region"
+	cfg.ClusterID = "mock-cluster"
+	cfg.PrometheusReadURL = baseURL + "/prometheus"
+	cfg.PrometheusUsername = "mock"
+	cfg.PrometheusPassword = "mock"
+	cfg.Datasources = []models.DatasourceInfo{{
+		Name:      "mock",
+		ReadURL:   cfg.PrometheusReadURL,
+		Username:  cfg.PrometheusUsername,
+		Password:  cfg.PrometheusPassword,
+		Region:    cfg.Region,
+		ClusterID: cfg.ClusterID,
+		IsDefault: true,
+	}}
+	// Without a refresh token the manager hands out the access token as is.
+	cfg.TokenManager = &auth.TokenManager{AccessToken: "mock-access-token", ExpiresAt: time.Now().AddDate(10, 0, 0)}
+}
+
+// Handler serves the mock API under /api/v4/organizations/mock. Endpoints
+// it does not fake answer 501 with a JSON error naming the endpoint.
+func Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("POST "+constants.EndpointPromQueryInstant, handlePromInstant)
+	api.HandleFunc("POST "+constants.EndpointPromQuery, handlePromRange)
+	api.HandleFunc("POST "+constants.EndpointPromLabelValues, handleLabelValues)
+	api.HandleFunc("POST "+constants.EndpointPromLabels, handleLabels)
+	api.HandleFunc("POST "+constants.EndpointAPMLabels, handleLabels)
+	api.HandleFunc("GET "+constants.EndpointDatasources, handleDatasources)
+	api.HandleFunc("POST "+constants.EndpointTracesQueryRange, handleTraces)
+	api.HandleFunc("GET /cat/api/traces/{id}", handleTraceDetails)
+	api.HandleFunc("GET "+constants.EndpointTraceTags, handleTraceTags)
+	api.HandleFunc("POST "+constants.EndpointLogsQueryRange, handleLogs)
+	api.HandleFunc("GET /logs/api/v1/labels", handleLogLabels)
+	api.HandleFunc("GET "+constants.EndpointAlertRules, handleAlertRules)
+	api.HandleFunc("GET "+constants.EndpointAlertsMonitor, handleAlerts)
+	api.HandleFunc("POST "+constants.EndpointEntitiesList, func(w http.ResponseWriter, r *http.Request) { writeJSON(w, []any{}) })
+	api.HandleFunc("PUT "+constants.EndpointChangeEvents, func(w http.ResponseWriter, r *http.Request) { writeJSON(w, map[string]any{}) })
+	api.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(constants.HeaderContentType, constants.HeaderContentTypeJSON)
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("the mock backend does not implement %s %s", r.Method, r.URL.Path)})
+	})
+
+	mux := http.NewServeMux()
+	prefix := "/api/v4/organizations/" + OrgSlug
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, api))
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set(constants.HeaderContentType, constants.HeaderContentTypeJSON)
+	json.NewEncoder(w).Encode(v)
+}
+
+// promRequest is the body of the Prometheus proxy endpoints.
+type promRequest struct {
+	Query     string   `json:"query"`
+	Timestamp int64    `json:"timestamp"`
+	Window    int64    `json:"window"`
+	Label     string   `json:"label"`
+	Matches   []string `json:"matches"`
+	Metric    string   `json:"metric"`
+}
+
+func decodePromRequest(w http.ResponseWriter, r *http.Request) (promRequest, bool) {
+	var req promRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return req, false
+	}
+	if req.Timestamp == 0 {
+		req.Timestamp = time.Now().Unix()
+	}
+	return req, true
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func handlePromInstant(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePromRequest(w, r)
+	if !ok {
+		return
+	}
+	at := time.Unix(req.Timestamp, 0)
+	out := []map[string]any{}
+	for _, s := range evaluate(req.Query) {
+		out = append(out, map[string]any{
+			"metric": s.g.labels,
+			"value":  []any{req.Timestamp, formatValue(s.q.value(s.g, at))},
+		})
+	}
+	writeJSON(w, out)
+}
+
+func handlePromRange(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePromRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Window <= 0 {
+		req.Window = 3600
+	}
+	// Points fall on multiples of a whole-minute step.
+	step := max(int64(60), (req.Window/maxRangePoints+59)/60*60)
+	first := (req.Timestamp - req.Window + step - 1) / step * step
+
+	out := []map[string]any{}
+	for _, s := range evaluate(req.Query) {
+		values := [][]any{}
+		for ts := first; ts <= req.Timestamp; ts += step {
+			values = append(values, []any{ts, formatValue(s.q.value(s.g, time.Unix(ts, 0)))})
+		}
+		out = append(out, map[string]any{"metric": s.g.labels, "values": values})
+	}
+	writeJSON(w, out)
+}
+
+// handleLabelValues returns the values of a label on the series the first
+// match selects, or the metric names for __name__.
+func handleLabelValues(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePromRequest(w, r)
+	if !ok {
+		return
+	}
+	selector := ""
+	if len(req.Matches) > 0 {
+		selector = req.Matches[0]
+	}
+	if req.Label == "__name__" {
+		writeJSON(w, metricNames)
+		return
+	}
+	if req.Label == "event_type" {
+		writeJSON(w, []string{"deployment"})
+		return
+	}
+	seen := map[string]bool{}
+	values := []string{}
+	for _, row := range parseQuery(selector).selectRows() {
+		if v := row.labels[req.Label]; v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	writeJSON(w, values)
+}
+
+// handleLabels returns the label names of the series a selector selects.
+func handleLabels(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePromRequest(w, r)
+	if !ok {
+		return
+	}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, row := range parseQuery(req.Metric).selectRows() {
+		for k := range row.labels {
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+	}
+	sort.Strings(names)
+	writeJSON(w, names)
+}
+
+func handleDatasources(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []map[string]any{{
+		"name":       "mock",
+		"is_default": true,
+		"url":        "http://" + r.Host + "/prometheus",
+		"region":     "mock-region",
+		"properties": map[string]string{"username": "mock", "password": "mock", "levitate_cluster_id": "mock-cluster"},
+	}})
+}
@@ -0,0 +1,109 @@
+package mockbackend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/last9/last9-mcp-server/internal/apm"
+	"github.com/last9/last9-mcp-server/internal/models"
+)
+
+func TestParseQuery(t *testing.T) {
+	q := parseQuery(`sum by (service_name) (rate(trace_endpoint_count{env=~'production', span_kind="SPAN_KIND_SERVER", http_status_code=~"5.*"}[5m]))`)
+	if q.kind != kindCount || !q.perSec || q.family != familyEndpoint || len(q.by) != 1 {
+		t.Fatalf("parseQuery = %+v", q)
+	}
+	groups := q.groups()
+	if len(groups) != len(services) {
+		t.Fatalf("got %d groups, want one per service", len(groups))
+	}
+	for _, g := range groups {
+		for _, r := range g.rows {
+			if !r.failed || r.labels["env"] != "production" {
+				t.Errorf("%s: row %v should be a failed production row", g.labels["service_name"], r.labels)
+			}
+		}
+	}
+
+	ratio := parseQuery(`sum(trace_endpoint_count{service_name="payments", http_status_code=~"5.."}) / sum(trace_endpoint_count{service_name="payments"}) * 100`)
+	g := ratio.groups()
+	if ratio.kind != kindErrorRatio || len(g) != 1 {
+		t.Fatalf("ratio = %+v, %d groups", ratio, len(g))
+	}
+	// 3.5% on the stable version and three times that on the 10% canary.
+	if v := ratio.value(g[0], time.Unix(1700000000, 0)); v < 3.9 || v > 4.5 {
+		t.Errorf("payments error percent = %v, want about 4.2", v)
+	}
+
+	latency := parseQuery(`trace_service_response_time{service_name="cart", quantile="p99"}`)
+	if latency.kind != kindLatency || latency.quantile != quantileFactors["p99"] {
+		t.Errorf("latency = %+v", latency)
+	}
+	edges := parseQuery(`sum by (server) (trace_call_graph_count{client="checkout"})`).groups()
+	if len(edges) != 3 {
+		t.Errorf("checkout calls %d services, want 3", len(edges))
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+	var cfg models.Config
+	Configure(&cfg, server.URL+"/api/v4/organizations/"+OrgSlug)
+
+	result, _, err := apm.NewServiceSummaryHandler(server.Client(), cfg)(context.Background(), &mcp.CallToolRequest{}, apm.ServiceSummaryArgs{Env: "production"})
+	if err != nil {
+		t.Fatalf("service summary: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	for _, s := range services {
+		if !strings.Contains(text, s.name) {
+			t.Errorf("service summary is missing %s: %s", s.name, text)
+		}
+	}
+
+	// The component lookup ors one selector per label and escapes the host.
+	result, _, err = apm.NewGetComponentConsumersHandler(server.Client(), cfg)(context.Background(), &mcp.CallToolRequest{}, apm.GetComponentConsumersArgs{HostOrSystem: "pg-main.shop.internal", Env: "production"})
+	if err != nil {
+		t.Fatalf("component consumers: %v", err)
+	}
+	var consumers apm.ComponentConsumersResult
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &consumers); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(consumers.Services, ","); got != "inventory,checkout" {
+		t.Errorf("pg-main.shop.internal consumers = %s, want inventory,checkout", got)
+	}
+
+	resp, err := server.Client().Post(cfg.APIBaseURL+"/prom_query", "application/json", strings.NewReader(`{"query":"sum by (env) (trace_endpoint_count)","timestamp":1700002800,"window":3600}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var series []struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]any          `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != len(envs) || len(series[0].Values) != 61 {
+		t.Errorf("range query: %d series, %d points", len(series), len(series[0].Values))
+	}
+
+	resp, err = server.Client().Get(cfg.APIBaseURL + "/dashboards")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Errorf("unfaked endpoint: status %d, want 501", resp.StatusCode)
+	}
+}
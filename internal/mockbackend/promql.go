@@ -0,0 +1,416 @@
+package mockbackend
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The mock backend does not evaluate PromQL. It reads the matchers, the
+// outer "by" clause and the metric names of a query, picks the dataset rows
+// the matchers select, groups them and derives a plausible value for the
+// kind of metric the query reads: a rate, a latency, an error ratio, an
+// apdex score or a plain gauge.
+
+var (
+	matcherRE   = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(?:"([^"]*)"|'([^']*)')`)
+	byRE        = regexp.MustCompile(`\bby\s*\(([^)]*)\)`)
+	upRE        = regexp.MustCompile(`(^|[^a-z_])up\s*(\{|$|\[)`)
+	histogramRE = regexp.MustCompile(`histogram_quantile\s*\(\s*([0-9.]+)`)
+	errorRE     = regexp.MustCompile(`error|5\.\.|5\.\*|5xx|"5|'5`)
+)
+
+// metricNames are the metric names label value lookups return.
+var metricNames = []string{
+	"trace_call_graph_count",
+	"trace_call_graph_duration",
+	"trace_client_count",
+	"trace_client_duration",
+	"trace_endpoint_apdex_score",
+	"trace_endpoint_count",
+	"trace_endpoint_duration",
+	"trace_service_apdex_score",
+	"trace_service_response_time",
+	"up",
+}
+
+// Value kinds of a query.
+const (
+	kindGauge = iota
+	kindConstant
+	kindCount
+	kindLatency
+	kindErrorRatio
+	kindApdex
+	kindTimestamp
+)
+
+// scrapeLag is how far the newest sample trails the evaluation time.
+const scrapeLag = 15 * time.Second
+
+type matcher struct {
+	name, op, value string
+}
+
+func (m matcher) matches(v string) bool {
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	}
+	re, err := regexp.Compile("^(?:" + m.value + ")$")
+	if err != nil {
+		return true
+	}
+	return re.MatchString(v) == (m.op == "=~")
+}
+
+// query is what the mock backend reads from a PromQL expression.
+type query struct {
+	family   string
+	matchers []matcher
+	by       []string
+	grouped  bool // the query has a by clause
+	kind     int
+	percent  bool    // error ratios are scaled to percent
+	perSec   bool    // counts are per second rather than per minute
+	quantile float64 // latency factor
+}
+
+// parseQuery reads q. Matchers on status labels are dropped for error
+// ratios, whose numerator and denominator select different statuses.
+func parseQuery(q string) query {
+	lower := strings.ToLower(q)
+	p := query{family: familyOf(lower, ""), quantile: 1}
+
+	if m := byRE.FindStringSubmatch(q); m != nil {
+		p.grouped = true
+		for _, name := range strings.Split(m[1], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				p.by = append(p.by, name)
+			}
+		}
+		p.family = familyOf(lower, m[1])
+	}
+
+	switch {
+	case strings.Contains(lower, "timestamp("):
+		p.kind = kindTimestamp
+	case strings.Contains(lower, "apdex"):
+		p.kind = kindApdex
+	case strings.Contains(lower, "sampling_ratio") || upRE.MatchString(lower):
+		p.kind = kindConstant
+	case strings.Contains(lower, "/") && errorRE.MatchString(lower):
+		p.kind = kindErrorRatio
+		p.percent = strings.Contains(lower, "100")
+	case strings.Contains(lower, "duration") || strings.Contains(lower, "response_time") || strings.Contains(lower, "latency") || strings.Contains(lower, "histogram_quantile"):
+		p.kind = kindLatency
+	case strings.Contains(lower, "count") || strings.Contains(lower, "rate(") || strings.Contains(lower, "increase(") || strings.Contains(lower, "_total"):
+		p.kind = kindCount
+		p.perSec = strings.Contains(lower, "rate(") && !strings.Contains(lower, "increase(")
+	}
+
+	if m := histogramRE.FindStringSubmatch(lower); m != nil {
+		// The by (le) inside histogram_quantile does not group the result.
+		p.by = without(p.by, "le")
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			p.quantile = quantileFactor(v)
+		}
+	}
+
+	for _, m := range matcherRE.FindAllStringSubmatch(q, -1) {
+		value := m[3]
+		if value == "" {
+			value = m[4]
+		} else if unquoted, err := strconv.Unquote(`"` + value + `"`); err == nil {
+			// Double-quoted PromQL strings use Go escapes, e.g. a \\. in a regex.
+			value = unquoted
+		}
+		mt := matcher{name: m[1], op: m[2], value: value}
+		if mt.name == "quantile" && mt.op == "=" {
+			if f, ok := quantileFactors[mt.value]; ok {
+				p.quantile = f
+			}
+		}
+		if p.kind == kindErrorRatio && (mt.name == "http_status_code" || mt.name == "status_code") {
+			continue
+		}
+		p.matchers = append(p.matchers, mt)
+	}
+	return p
+}
+
+// series is one output series of an expression and the query it came from.
+type series struct {
+	q query
+	g group
+}
+
+// evaluate returns the series of expr. The branches of a top-level or are
+// evaluated separately, keeping the first series of each label set as
+// PromQL does.
+func evaluate(expr string) []series {
+	var out []series
+	seen := map[string]bool{}
+	for _, part := range splitOr(expr) {
+		q := parseQuery(part)
+		for _, g := range q.groups() {
+			if key := labelKey(g.labels); !seen[key] {
+				seen[key] = true
+				out = append(out, series{q, g})
+			}
+		}
+	}
+	return out
+}
+
+// splitOr splits expr at the or operators outside parentheses, braces and
+// brackets.
+func splitOr(expr string) []string {
+	var parts []string
+	depth, last := 0, 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(', '{', '[':
+			depth++
+		case ')', '}', ']':
+			depth--
+		case ' ':
+			if depth == 0 && strings.HasPrefix(expr[i:], " or ") {
+				parts = append(parts, expr[last:i])
+				last = i + len(" or ")
+				i = last - 1
+			}
+		}
+	}
+	return append(parts, expr[last:])
+}
+
+// familyOf returns the row family a query or selector reads.
+func familyOf(lower, by string) string {
+	switch {
+	case strings.Contains(lower, "internal_call_graph"):
+		return familyInternalCallGraph
+	case strings.Contains(lower, "call_graph"):
+		return familyCallGraph
+	case strings.Contains(lower, "trace_client"):
+		return familyClient
+	case strings.Contains(by, "namespace") || strings.Contains(by, "pod") || strings.Contains(lower, "kube_") || strings.Contains(lower, "container_"):
+		return familyPod
+	}
+	return familyEndpoint
+}
+
+// quantileFactor scales a p95 latency to quantile q.
+func quantileFactor(q float64) float64 {
+	switch {
+	case q >= 0.999:
+		return quantileFactors["p999"]
+	case q >= 0.99:
+		return quantileFactors["p99"]
+	case q >= 0.95:
+		return 1
+	case q >= 0.9:
+		return quantileFactors["p90"]
+	case q >= 0.75:
+		return quantileFactors["p75"]
+	}
+	return quantileFactors["p50"]
+}
+
+func without(names []string, drop string) []string {
+	var out []string
+	for _, n := range names {
+		if n != drop {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// selectRows returns the rows of the query's family its matchers select.
+// Matchers on labels the family does not have are ignored, so selectors
+// for other metrics still return data.
+func (p query) selectRows() []row {
+	all := rows(p.family)
+	known := map[string]bool{}
+	for _, r := range all {
+		for k := range r.labels {
+			known[k] = true
+		}
+	}
+	var out []row
+rowLoop:
+	for _, r := range all {
+		for _, m := range p.matchers {
+			if known[m.name] && !m.matches(r.labels[m.name]) {
+				continue rowLoop
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// group is one output series: its labels and the rows summed into it.
+type group struct {
+	labels   map[string]string
+	rows     []row
+	quantile float64
+}
+
+// groups returns the output series of the query, sorted by labels.
+func (p query) groups() []group {
+	selected := p.selectRows()
+	if len(selected) == 0 {
+		return nil
+	}
+	if !p.grouped {
+		labels := map[string]string{}
+		for _, m := range p.matchers {
+			if m.op == "=" {
+				labels[m.name] = m.value
+			}
+		}
+		return []group{{labels: labels, rows: selected, quantile: p.quantile}}
+	}
+
+	byKey := map[string]*group{}
+	var keys []string
+	for _, r := range selected {
+		for _, labels := range p.expand(r) {
+			key := labelKey(labels)
+			g, ok := byKey[key]
+			if !ok {
+				g = &group{labels: labels, quantile: p.quantile}
+				if q, ok := labels["quantile"]; ok {
+					g.quantile = quantileFactors[q]
+				}
+				byKey[key] = g
+				keys = append(keys, key)
+			}
+			g.rows = append(g.rows, r)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]group, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, *byKey[k])
+	}
+	return out
+}
+
+// expand projects r onto the by labels. Grouping by quantile, which rows
+// do not carry, gives one series per quantile the matchers allow; grouping
+// by __name__ gives one per matching metric name.
+func (p query) expand(r row) []map[string]string {
+	out := []map[string]string{{}}
+	for _, name := range p.by {
+		var values []string
+		switch name {
+		case "quantile":
+			values = p.allowed(name, []string{"p50", "p90", "p95", "p99"})
+		case "__name__":
+			values = p.allowed(name, metricNames)
+		default:
+			if v := r.labels[name]; v != "" {
+				values = []string{v}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		var next []map[string]string
+		for _, labels := range out {
+			for _, v := range values {
+				next = append(next, with(labels, name, v))
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// allowed returns the values the matchers on name accept.
+func (p query) allowed(name string, values []string) []string {
+	var out []string
+valueLoop:
+	for _, v := range values {
+		for _, m := range p.matchers {
+			if m.name == name && !m.matches(v) {
+				continue valueLoop
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func labelKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, k := range names {
+		b.WriteString(k + "=" + labels[k] + ",")
+	}
+	return b.String()
+}
+
+// value returns the value of g at t.
+func (p query) value(g group, t time.Time) float64 {
+	var rpm, failed, weighted float64
+	for _, r := range g.rows {
+		rpm += r.rpm
+		weighted += r.rpm * r.latencyMs
+		if r.failed {
+			failed += r.rpm
+		}
+	}
+	if _, ok := g.labels["__name__"]; ok {
+		return float64(len(g.rows))
+	}
+	key := labelKey(g.labels)
+	jitter := 0.95 + 0.1*noise(key+strconv.FormatInt(t.Unix(), 10))
+
+	var v float64
+	switch p.kind {
+	case kindConstant:
+		return 1
+	case kindTimestamp:
+		return float64(t.Add(-scrapeLag).Unix())
+	case kindCount:
+		v = rpm * diurnal(t) * jitter
+		if p.perSec {
+			v /= 60
+		}
+	case kindLatency:
+		latency := 0.0
+		if rpm > 0 {
+			latency = weighted / rpm
+		}
+		v = latency * g.quantile * (1 + 0.3*(diurnal(t)-1)) * jitter
+	case kindErrorRatio:
+		if rpm > 0 {
+			v = failed / rpm * jitter
+		}
+		if p.percent {
+			v *= 100
+		}
+	case kindApdex:
+		latency, errRatio := 0.0, 0.0
+		if rpm > 0 {
+			latency, errRatio = weighted/rpm, failed/rpm
+		}
+		v = math.Max(0.5, 1-errRatio-latency/5000*jitter)
+	default:
+		v = (20 + 60*noise(key)) * jitter
+	}
+	return math.Round(v*1e4) / 1e4
+}
@@ -0,0 +1,273 @@
+package mockbackend
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTraces and maxLogLines cap what the trace and log endpoints return.
+const (
+	maxTraces   = 50
+	maxLogLines = 200
+)
+
+// queryWindow reads the start and end query parameters, in seconds, with
+// the last hour as the default, and the limit, capped at maxLimit.
+func queryWindow(r *http.Request, maxLimit int) (start, end time.Time, limit int) {
+	end = time.Now()
+	if v, err := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64); err == nil {
+		end = time.Unix(v, 0)
+	}
+	start = end.Add(-time.Hour)
+	if v, err := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64); err == nil && v < end.Unix() {
+		start = time.Unix(v, 0)
+	}
+	limit = maxLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v < maxLimit {
+		limit = v
+	}
+	return start, end, limit
+}
+
+// bodyServices returns the services named in the request body, or all of
+// them when the body names none.
+func bodyServices(r *http.Request) ([]service, string) {
+	data, _ := io.ReadAll(r.Body)
+	body := string(data)
+	var out []service
+	for _, s := range services {
+		if strings.Contains(body, `"`+s.name+`"`) {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		out = services
+	}
+	return out, body
+}
+
+// hexID returns a stable hex ID of n bytes (8 or 16) for seed.
+func hexID(seed string, n int) string {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	id := fmt.Sprintf("%016x", h.Sum64())
+	if n == 16 {
+		h.Write([]byte("/"))
+		id += fmt.Sprintf("%016x", h.Sum64())
+	}
+	return id
+}
+
+// span is a synthetic span in the shape of the traces API.
+func span(s service, endpoint, kind, traceID, spanID string, at time.Time, durationMs float64, failed bool) map[string]any {
+	status := "STATUS_CODE_UNSET"
+	httpStatus := "200"
+	if failed {
+		status, httpStatus = "STATUS_CODE_ERROR", "500"
+	}
+	return map[string]any{
+		"Timestamp":   at.UTC().Format(time.RFC3339Nano),
+		"TraceId":     traceID,
+		"SpanId":      spanID,
+		"SpanName":    endpoint,
+		"SpanKind":    kind,
+		"ServiceName": s.name,
+		"Duration":    int64(durationMs * 1e6),
+		"StatusCode":  status,
+		"ResourceAttributes": map[string]string{
+			"service.name":           s.name,
+			"deployment.environment": "production",
+		},
+		"SpanAttributes": map[string]string{
+			"http.route":       endpoint,
+			"http.status_code": httpStatus,
+		},
+	}
+}
+
+// handleTraces returns root spans of the services the pipeline names,
+// newest first. Pipelines that mention STATUS_CODE_ERROR get failed spans.
+func handleTraces(w http.ResponseWriter, r *http.Request) {
+	start, end, limit := queryWindow(r, maxTraces)
+	svcs, body := bodyServices(r)
+	onlyErrors := strings.Contains(body, "STATUS_CODE_ERROR")
+
+	spacing := end.Sub(start) / time.Duration(limit)
+	items := []map[string]any{}
+	for i := 0; i < limit; i++ {
+		s := svcs[i%len(svcs)]
+		at := end.Add(-time.Duration(i) * spacing)
+		seed := s.name + strconv.FormatInt(at.Unix(), 10)
+		failed := onlyErrors || noise(seed) < s.errorRate*5
+		endpoint := s.endpoints[i%len(s.endpoints)]
+		duration := s.latencyMs * (0.4 + noise(seed+"d"))
+		items = append(items, span(s, endpoint, "SPAN_KIND_SERVER", hexID(seed, 16), hexID(seed, 8), at, duration, failed))
+	}
+	writeJSON(w, map[string]any{"data": map[string]any{"result": items}})
+}
+
+// handleTraceDetails returns the spans of a trace: a root span of a service
+// picked from the trace ID and one child span per service it calls.
+func handleTraceDetails(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("id")
+	root := services[int(noise(traceID)*float64(len(services)))]
+	at := time.Now().Add(-time.Duration(noise(traceID+"t")*3600) * time.Second)
+	rootID := hexID(traceID+root.name, 8)
+
+	spans := []map[string]any{span(root, root.endpoints[0], "SPAN_KIND_SERVER", traceID, rootID, at, root.latencyMs, false)}
+	offset := time.Duration(root.latencyMs*0.1) * time.Millisecond
+	for _, callee := range root.calls {
+		c, _ := findService(callee)
+		child := span(c, c.endpoints[0], "SPAN_KIND_SERVER", traceID, hexID(traceID+callee, 8), at.Add(offset), c.latencyMs*0.8, false)
+		child["ParentSpanId"] = rootID
+		spans = append(spans, child)
+		offset += time.Duration(c.latencyMs*0.8) * time.Millisecond
+	}
+	writeJSON(w, map[string]any{"traces": spans})
+}
+
+func handleTraceTags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"scopes": []map[string]any{
+		{"name": "resource", "tags": []string{"deployment.environment", "k8s.namespace.name", "k8s.pod.name", "service.name"}},
+		{"name": "span", "tags": []string{"db.system", "http.method", "http.route", "http.status_code"}},
+	}})
+}
+
+// logTemplates are the info and error log lines of each service.
+var logTemplates = map[string][2]string{
+	"frontend":  {"rendered product page in %dms", "upstream checkout timed out after %dms"},
+	"checkout":  {"order placed, items=%d", "order validation failed: %d items out of stock"},
+	"cart":      {"cart updated, items=%d", "redis connection reset after %dms"},
+	"payments":  {"charge succeeded in %dms", "card declined by issuer after %dms"},
+	"inventory": {"stock lookup in %dms", "stock lookup failed after %dms"},
+}
+
+// handleLogs returns log streams of the services the query names, one per
+// service and severity, newest first.
+func handleLogs(w http.ResponseWriter, r *http.Request) {
+	start, end, limit := queryWindow(r, maxLogLines)
+	svcs, _ := bodyServices(r)
+
+	type stream struct {
+		labels map[string]string
+		values [][2]string
+	}
+	streams := map[string]*stream{}
+	spacing := end.Sub(start) / time.Duration(limit)
+	for i := 0; i < limit; i++ {
+		s := svcs[i%len(svcs)]
+		at := end.Add(-time.Duration(i) * spacing)
+		seed := s.name + strconv.FormatInt(at.UnixNano(), 10)
+		severity, template := "info", logTemplates[s.name][0]
+		if noise(seed) < s.errorRate*10 {
+			severity, template = "error", logTemplates[s.name][1]
+		}
+		key := s.name + "/" + severity
+		st, ok := streams[key]
+		if !ok {
+			st = &stream{labels: map[string]string{"service": s.name, "severity": severity, "env": "production"}}
+			streams[key] = st
+		}
+		msg := fmt.Sprintf(template, 1+int(noise(seed+"m")*s.latencyMs))
+		st.values = append(st.values, [2]string{strconv.FormatInt(at.UnixNano(), 10), msg})
+	}
+
+	keys := make([]string, 0, len(streams))
+	for k := range streams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := []map[string]any{}
+	for _, k := range keys {
+		result = append(result, map[string]any{"stream": streams[k].labels, "values": streams[k].values})
+	}
+	writeJSON(w, map[string]any{"data": map[string]any{"resultType": "streams", "result": result}})
+}
+
+func handleLogLabels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"status": "success", "data": []string{"env", "message", "service", "severity"}})
+}
+
+// alertRules are the alert rules of the mock organization. The payments
+// error rate rule is firing.
+var alertRules = []struct {
+	id, name, service, indicator, severity string
+	threshold                              float64
+	firing                                 bool
+}{
+	{"rule-payments-errors", "Payments error rate above 2%", "payments", "error_rate", "breach", 2, true},
+	{"rule-checkout-latency", "Checkout p95 latency above 800ms", "checkout", "p95_latency", "threat", 800, false},
+	{"rule-frontend-throughput", "Frontend throughput drop", "frontend", "throughput", "threat", 600, false},
+}
+
+func handleAlertRules(w http.ResponseWriter, r *http.Request) {
+	created := time.Now().AddDate(0, -2, 0).Unix()
+	out := []map[string]any{}
+	for _, rule := range alertRules {
+		out = append(out, map[string]any{
+			"id":                rule.id,
+			"organization_id":   OrgSlug,
+			"entity_id":         "entity-" + rule.service,
+			"primary_indicator": rule.indicator,
+			"condition":         fmt.Sprintf("%s > %g", rule.indicator, rule.threshold),
+			"eval_window":       300,
+			"created_at":        created,
+			"updated_at":        created,
+			"state":             "active",
+			"severity":          rule.severity,
+			"algorithm":         "static_threshold",
+			"rule_name":         rule.name,
+			"properties":        map[string]any{"service_name": rule.service},
+		})
+	}
+	writeJSON(w, out)
+}
+
+// handleAlerts returns the alert rules with their state at the requested
+// timestamp; the firing rule started firing 40 minutes before it.
+func handleAlerts(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if v, err := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64); err == nil {
+		at = time.Unix(v, 0)
+	}
+	window, _ := strconv.ParseInt(r.URL.Query().Get("window"), 10, 64)
+	since := at.Add(-40 * time.Minute).Unix()
+
+	out := []map[string]any{}
+	for _, rule := range alertRules {
+		state, firedAt, alerts := "normal", int64(0), []map[string]any{}
+		if rule.firing {
+			state, firedAt = "firing", since
+			alerts = append(alerts, map[string]any{
+				"state":              "firing",
+				"label_hash":         hexID(rule.id, 8),
+				"annotations":        map[string]any{"summary": rule.name},
+				"group_labels":       map[string]any{"service_name": rule.service, "env": "production"},
+				"metric_degradation": 1.7,
+				"current_value":      rule.threshold * 1.7,
+				"last_fired_at":      at.Unix(),
+				"since":              since,
+			})
+		}
+		out = append(out, map[string]any{
+			"alert_group_id":   "group-" + rule.service,
+			"alert_group_name": rule.service,
+			"rule_id":          rule.id,
+			"rule_name":        rule.name,
+			"state":            state,
+			"severity":         rule.severity,
+			"rule_type":        "static",
+			"last_fired_at":    firedAt,
+			"since":            firedAt,
+			"alerts":           alerts,
+			"rule_properties":  map[string]any{},
+		})
+	}
+	writeJSON(w, map[string]any{"timestamp": at.Unix(), "window": window, "alert_rules": out})
+}
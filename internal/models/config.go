@@ -1,10 +1,42 @@
 package models
 
-import "last9-mcp/internal/auth"
+import (
+	"fmt"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/redact"
+)
 
 const DefaultMaxGetLogsEntries = 5000
 const DefaultMaxGetTracesEntries = 5000
 
+// Guardrails for prometheus_range_query.
+const DefaultMaxQuerySeries = 5000
+const DefaultMaxQueryWindowHours = 168
+const DefaultMaxQueryPoints = 500000
+
+// DefaultMaxMessageBytes is the largest tool result sent in one message;
+// bigger results are split into chunks.
+const DefaultMaxMessageBytes = 1 << 20
+
+// DefaultResultTTLHours is how long tool results are kept for fetch_result.
+const DefaultResultTTLHours = 24
+
+// Defaults for the request limits of the http and websocket transports.
+const (
+	DefaultMaxRequestBytes     = 4 << 20
+	DefaultMaxRequestJSONDepth = 64
+)
+
+// Transports selectable with --transport.
+const (
+	TransportStdio     = "stdio"
+	TransportHTTP      = "http"
+	TransportWebSocket = "websocket"
+	TransportUnix      = "unix"
+)
+
 // DatasourceInfo holds resolved credentials for a named datasource.
 // Populated at startup from the /datasources API response and cached in Config.Datasources.
 type DatasourceInfo struct {
@@ -21,6 +53,7 @@ type DatasourceInfo struct {
 type Config struct {
 	// Last9 connection settings
 	RefreshToken string // Refresh token for authentication
+	MockBackend  bool   // Serve synthetic data from an in-process mock backend instead of Last9
 	Region       string // AWS region (e.g., us-east-1, ap-south-1)
 
 	// Rate limiting configuration
@@ -28,11 +61,26 @@ type Config struct {
 	RequestRateBurst    int     // Maximum burst capacity for requests
 	MaxGetLogsEntries   int     // Maximum number of entries returned by chunked raw get_logs requests
 	MaxGetTracesEntries int     // Maximum number of traces returned by chunked get_traces requests
+	MaxQuerySeries      int     // Maximum series a prometheus_range_query may return
+	MaxQueryWindowHours int     // Maximum prometheus_range_query window in hours
+	MaxQueryPoints      int     // Maximum points a prometheus_range_query reads before truncating
 
 	// HTTP server configuration
-	HTTPMode bool   // Enable HTTP server mode instead of STDIO
-	Port     string // HTTP server port
-	Host     string // HTTP server host
+	HTTPMode   bool   // Serve over HTTP (http or websocket transport); --http is the same as Transport "http"
+	Transport  string // stdio, http, websocket or unix; resolved from HTTPMode when empty
+	Port       string // HTTP server port
+	Host       string // HTTP server host
+	SocketPath string // Unix socket path for the unix transport
+	// TLS for the http and websocket transports. Serving TLS needs both
+	// TLSCertFile and TLSKeyFile; TLSClientCAFile additionally requires and
+	// verifies client certificates (mTLS).
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	// Request limits for the http and websocket transports; 0 disables each.
+	// MaxRequestBytes also caps incoming STDIO and unix socket frames.
+	MaxRequestBytes     int // Largest request body accepted
+	MaxRequestJSONDepth int // Deepest nesting of arrays and objects accepted in a JSON body
 
 	OrgSlug    string // Organization slug for multi-tenant support
 	ActionURL  string
@@ -41,6 +89,10 @@ type Config struct {
 	DatasourceName   string // Datasource name to use (overrides default datasource)
 	APIHost          string // API host (defaults to app.last9.io)
 	DisableTelemetry bool   // Disable OpenTelemetry tracing/metrics
+	// Upstream network settings for Last9 API calls
+	ProxyURL     string            // Proxy for Last9 API calls; empty honours HTTPS_PROXY/HTTP_PROXY/NO_PROXY
+	CABundleFile string            // PEM CA certificates trusted in addition to the system roots
+	ExtraHeaders map[string]string // Headers set on every Last9 API request
 	// Prometheus configuration
 	PrometheusReadURL  string // URL for Prometheus read API
 	PrometheusUsername string // Username for Prometheus authentication
@@ -48,6 +100,45 @@ type Config struct {
 
 	ClusterID string // Cluster ID from datasource (for dashboard deep links)
 
+	CacheDir string // Directory for the on-disk discovery cache; empty disables it
+
+	Logging logging.Options // Level, format and per-module levels of the server's own logs
+
+	DefaultEnv string // env APM tools filter by when a call sets none; empty means every environment
+
+	Quantiles []string // response-time quantiles APM tools report when a call selects none; empty means the default set
+
+	SamplingRates      map[string]float64 // trace sampling rate per service, "*" for the rest, used by extrapolate_sampling
+	SamplingRateMetric string             // gauge with a service_name label reporting each service's trace sampling rate
+
+	DisplayTimezone string // IANA timezone for *_local timestamps in tool output; empty disables them
+
+	ExportDir string // Directory the export argument writes files to; empty disables exports
+
+	MaxMessageBytes int // Largest tool result or outgoing STDIO frame in bytes; 0 disables the limit
+
+	ResultArchiveDir string // Directory tool results are stored in for fetch_result; empty disables storing
+	ResultTTLHours   int    // Hours a stored tool result is kept; 0 disables storing
+
+	CustomToolsFile string // JSON file declaring extra HTTP-backed tools; empty disables them
+	MacrosFile      string // JSON file declaring macros of tool calls; empty means none
+
+	QueryHistoryFile string // JSON Lines file PromQL queries are recorded to; empty keeps history in memory
+	ViewsFile        string // JSON file saved views are kept in; empty keeps them in memory
+	MaintenanceFile  string // JSON file declared maintenance windows are kept in; empty keeps them in memory
+	CriticalityFile  string // JSON file endpoint criticality tags are kept in; empty keeps them in memory
+
+	// Tool surface. When EnabledTools is set only those tools are registered;
+	// DisabledTools are then removed. Unknown names are rejected at startup.
+	EnabledTools  []string
+	DisabledTools []string
+	// EnableWriteTools registers tools that change Last9 configuration,
+	// such as create_alert_rule.
+	EnableWriteTools bool
+	// EnableAdminTools registers tools that change how the server itself
+	// runs, such as set_log_level.
+	EnableAdminTools bool
+
 	// Datasources holds all available datasources fetched at startup.
 	// Used to resolve per-query datasource credentials without an extra API call.
 	Datasources []DatasourceInfo
@@ -68,3 +159,41 @@ func (cfg Config) ResolveDatasource(name string) (DatasourceInfo, bool) {
 	}
 	return DatasourceInfo{}, false
 }
+
+// String implements fmt.Stringer with credentials masked, so configs can be
+// logged or wrapped into errors with %v / %+v without leaking them.
+func (cfg Config) String() string {
+	masked := cfg
+	masked.RefreshToken = maskSecret(cfg.RefreshToken)
+	masked.PrometheusPassword = maskSecret(cfg.PrometheusPassword)
+	masked.Datasources = make([]DatasourceInfo, len(cfg.Datasources))
+	for i, ds := range cfg.Datasources {
+		masked.Datasources[i] = ds.masked()
+	}
+	type plain Config // drops the String method to avoid recursion
+	return fmt.Sprintf("%+v", plain(masked))
+}
+
+// GoString masks credentials for %#v as well.
+func (cfg Config) GoString() string { return cfg.String() }
+
+// String implements fmt.Stringer with the password masked.
+func (ds DatasourceInfo) String() string {
+	type plain DatasourceInfo
+	return fmt.Sprintf("%+v", plain(ds.masked()))
+}
+
+// GoString masks the password for %#v as well.
+func (ds DatasourceInfo) GoString() string { return ds.String() }
+
+func (ds DatasourceInfo) masked() DatasourceInfo {
+	ds.Password = maskSecret(ds.Password)
+	return ds
+}
+
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redact.Placeholder
+}
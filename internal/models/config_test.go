@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConfigStringMasksCredentials(t *testing.T) {
+	cfg := Config{
+		RefreshToken:       "refresh-token-value",
+		PrometheusUsername: "reader",
+		PrometheusPassword: "prom-password-value",
+		Datasources:        []DatasourceInfo{{Name: "prod", Password: "ds-password-value"}},
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		got := fmt.Sprintf(verb, cfg)
+		for _, secret := range []string{"refresh-token-value", "prom-password-value", "ds-password-value"} {
+			if strings.Contains(got, secret) {
+				t.Errorf("%s leaked %q: %s", verb, secret, got)
+			}
+		}
+		if !strings.Contains(got, "reader") || !strings.Contains(got, "prod") {
+			t.Errorf("%s should keep non-secret fields: %s", verb, got)
+		}
+	}
+
+	if cfg.PrometheusPassword != "prom-password-value" || cfg.Datasources[0].Password != "ds-password-value" {
+		t.Error("String must not modify the original config")
+	}
+}
+
+func TestDatasourceInfoStringMasksPassword(t *testing.T) {
+	got := fmt.Sprint(DatasourceInfo{Name: "prod", Password: "ds-password-value"})
+	if strings.Contains(got, "ds-password-value") || !strings.Contains(got, "prod") {
+		t.Errorf("unexpected DatasourceInfo string: %s", got)
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+// Error kinds distinguish the signal an ErrorEntry was derived from.
+const (
+	// ErrorKindHTTP is an HTTP response status (4xx/5xx); Name is the code.
+	ErrorKindHTTP = "http"
+	// ErrorKindException is a recorded exception; Name is the exception type.
+	ErrorKindException = "exception"
+	// ErrorKindOTelStatus is a span with status ERROR; Name is the status code.
+	ErrorKindOTelStatus = "otel_status"
+)
+
+// ErrorEntry is one class of error with its occurrence count, in a shape
+// shared by every tool that reports errors. SampleSpan names the operation
+// contributing the most occurrences.
+type ErrorEntry struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+	SampleSpan string `json:"sample_span,omitempty"`
+}
@@ -17,12 +17,48 @@ var GetMetricsInstructions string
 //go:embed descriptions/get_exceptions.md
 var GetExceptionsInstructions string
 
+//go:embed descriptions/get_exception_samples.md
+var GetExceptionSamplesDescription string
+
 //go:embed descriptions/get_service_logs.md
 var GetServiceLogsInstructions string
 
 //go:embed descriptions/get_service_summary.md
 var GetServiceSummaryDescription string
 
+//go:embed descriptions/get_service_health_score.md
+var GetServiceHealthScoreDescription string
+
+//go:embed descriptions/check_release_health.md
+var CheckReleaseHealthDescription string
+
+//go:embed descriptions/classify_traffic_pattern.md
+var ClassifyTrafficPatternDescription string
+
+//go:embed descriptions/compare_services.md
+var CompareServicesDescription string
+
+//go:embed descriptions/get_service_versions.md
+var GetServiceVersionsDescription string
+
+//go:embed descriptions/get_endpoint_sla_report.md
+var GetEndpointSLAReportDescription string
+
+//go:embed descriptions/draft_rca.md
+var DraftRCADescription string
+
+//go:embed descriptions/generate_handoff_summary.md
+var GenerateHandoffSummaryDescription string
+
+//go:embed descriptions/create_watch.md
+var CreateWatchDescription string
+
+//go:embed descriptions/list_watches.md
+var ListWatchesDescription string
+
+//go:embed descriptions/delete_watch.md
+var DeleteWatchDescription string
+
 //go:embed descriptions/get_apm_service_deviations.md
 var GetAPMServiceDeviationsDescription string
 
@@ -35,15 +71,51 @@ var GetServicePerformanceDetails string
 //go:embed descriptions/get_service_operations_summary.md
 var GetServiceOperationsSummaryDescription string
 
+//go:embed descriptions/get_service_endpoints.md
+var GetServiceEndpointsDescription string
+
+//go:embed descriptions/get_consumer_operations.md
+var GetConsumerOperationsDescription string
+
+//go:embed descriptions/get_grpc_operations.md
+var GetGRPCOperationsDescription string
+
+//go:embed descriptions/get_runtime_metrics.md
+var GetRuntimeMetricsDescription string
+
+//go:embed descriptions/get_service_history.md
+var GetServiceHistoryDescription string
+
 //go:embed descriptions/get_service_dependency_graph.md
 var GetServiceDependencyGraphDetails string
 
+//go:embed descriptions/attribute_dependency_latency.md
+var AttributeDependencyLatencyDescription string
+
+//go:embed descriptions/get_component_consumers.md
+var GetComponentConsumersDescription string
+
 //go:embed descriptions/list_datasources.md
 var ListDatasourcesDescription string
 
 //go:embed descriptions/prometheus_instant_query.md
 var PromqlInstantQueryDetails string
 
+//go:embed descriptions/render_chart.md
+var RenderChartDescription string
+
+//go:embed descriptions/get_latency_distribution.md
+var GetLatencyDistributionDescription string
+
+//go:embed descriptions/evaluate_burn_rate.md
+var EvaluateBurnRateDescription string
+
+//go:embed descriptions/analyze_cardinality.md
+var AnalyzeCardinalityDescription string
+
+//go:embed descriptions/get_ingestion_volume.md
+var GetIngestionVolumeDescription string
+
 //go:embed descriptions/prometheus_label_values.md
 var PromqlLabelValuesQueryDetails string
 
@@ -71,6 +143,9 @@ var GetAlertsDescription string
 //go:embed descriptions/get_alert_rule_state.md
 var GetAlertRuleStateDescription string
 
+//go:embed descriptions/analyze_alert_flapping.md
+var AnalyzeAlertFlappingDescription string
+
 //go:embed descriptions/get_log_attributes.md
 var GetLogAttributesDescription string
 
@@ -89,6 +164,9 @@ var GetTraceAttributesForPipelineDescription string
 //go:embed descriptions/get_change_events.md
 var GetChangeEventsDescription string
 
+//go:embed descriptions/record_deployment.md
+var RecordDeploymentDescription string
+
 //go:embed descriptions/get_databases.md
 var GetDatabasesDescription string
 
@@ -140,5 +218,59 @@ var GetTracesDescription string
 //go:embed descriptions/get_service_traces_base.md
 var GetServiceTracesDescription string
 
+//go:embed descriptions/search_traces.md
+var SearchTracesDescription string
+
 //go:embed descriptions/prometheus_range_query_base.md
 var PromqlRangeQueryDetails string
+
+//go:embed descriptions/get_result_chunk.md
+var GetResultChunkDescription string
+
+//go:embed descriptions/diff_results.md
+var DiffResultsDescription string
+
+//go:embed descriptions/fetch_result.md
+var FetchResultDescription string
+
+//go:embed descriptions/list_query_history.md
+var ListQueryHistoryDescription string
+
+//go:embed descriptions/replay_query.md
+var ReplayQueryDescription string
+
+//go:embed descriptions/save_view.md
+var SaveViewDescription string
+
+//go:embed descriptions/list_views.md
+var ListViewsDescription string
+
+//go:embed descriptions/delete_view.md
+var DeleteViewDescription string
+
+//go:embed descriptions/declare_maintenance_window.md
+var DeclareMaintenanceWindowDescription string
+
+//go:embed descriptions/list_maintenance_windows.md
+var ListMaintenanceWindowsDescription string
+
+//go:embed descriptions/delete_maintenance_window.md
+var DeleteMaintenanceWindowDescription string
+
+//go:embed descriptions/tag_endpoint_criticality.md
+var TagEndpointCriticalityDescription string
+
+//go:embed descriptions/list_endpoint_criticality.md
+var ListEndpointCriticalityDescription string
+
+//go:embed descriptions/delete_endpoint_criticality.md
+var DeleteEndpointCriticalityDescription string
+
+//go:embed descriptions/set_log_level.md
+var SetLogLevelDescription string
+
+//go:embed descriptions/create_alert_rule.md
+var CreateAlertRuleDescription string
+
+//go:embed descriptions/define_macro.md
+var DefineMacroDescription string
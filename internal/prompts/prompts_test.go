@@ -4,7 +4,7 @@ import (
 	"strings"
 	"testing"
 
-	"last9-mcp/internal/prompts"
+	"github.com/last9/last9-mcp-server/internal/prompts"
 )
 
 func TestGetServiceLogsInstructionsEmbedded(t *testing.T) {
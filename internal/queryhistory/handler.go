@@ -0,0 +1,145 @@
+package queryhistory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 200
+)
+
+// timeArguments are the query tool arguments replay_query replaces when a
+// new time range is given.
+var timeArguments = []string{"start_time_iso", "end_time_iso", "lookback_minutes", "time_iso"}
+
+// ListQueryHistoryArgs represents the input arguments for the list_query_history tool
+type ListQueryHistoryArgs struct {
+	Tool          string `json:"tool,omitempty" jsonschema:"Only list queries run by this tool: prometheus_range_query or prometheus_instant_query (optional)"`
+	QueryContains string `json:"query_contains,omitempty" jsonschema:"Only list queries containing this text, case-insensitive (optional, e.g. http_requests)"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"Maximum number of queries to return, newest first (default: 20, max: 200)"`
+}
+
+// ReplayQueryArgs represents the input arguments for the replay_query tool
+type ReplayQueryArgs struct {
+	ID              string  `json:"id" jsonschema:"Query id from list_query_history (required)"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty" jsonschema:"New start time in RFC3339/ISO8601 format, for range queries (optional)"`
+	EndTimeISO      string  `json:"end_time_iso,omitempty" jsonschema:"New end time in RFC3339/ISO8601 format, for range queries (optional)"`
+	TimeISO         string  `json:"time_iso,omitempty" jsonschema:"New evaluation time in RFC3339/ISO8601 format, for instant queries (optional)"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty" jsonschema:"New window of this many minutes ending now; for instant queries, evaluate this many minutes ago (optional)"`
+	ShiftMinutes    float64 `json:"shift_minutes,omitempty" jsonschema:"Move the original resolved time range by this many minutes; negative values move it earlier (optional, e.g. -1440 for the same window yesterday)"`
+}
+
+// CallFunc runs the named tool with the given arguments.
+type CallFunc func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error)
+
+// NewListQueryHistoryHandler returns a handler that lists recorded queries.
+func NewListQueryHistoryHandler(store *Store) func(context.Context, *mcp.CallToolRequest, ListQueryHistoryArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ListQueryHistoryArgs) (*mcp.CallToolResult, any, error) {
+		limit := args.Limit
+		if limit == 0 {
+			limit = defaultListLimit
+		}
+		if limit < 0 || limit > maxListLimit {
+			return nil, nil, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		entries := store.List(Filter{Tool: args.Tool, Contains: args.QueryContains, Limit: limit})
+		if entries == nil {
+			entries = []Entry{}
+		}
+		data, err := json.Marshal(map[string]any{
+			"queries": entries,
+			"count":   len(entries),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// NewReplayQueryHandler returns a handler that re-runs a recorded query
+// through call, optionally over a different time range. The replayed call is
+// recorded like any other.
+func NewReplayQueryHandler(store *Store, call CallFunc) func(context.Context, *mcp.CallToolRequest, ReplayQueryArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ReplayQueryArgs) (*mcp.CallToolResult, any, error) {
+		if args.ID == "" {
+			return nil, nil, fmt.Errorf("id is required")
+		}
+		entry, err := store.Get(args.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		callArgs, err := replayArguments(entry, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		result, err := call(ctx, entry.Tool, callArgs)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, nil, nil
+	}
+}
+
+// replayArguments returns the original arguments with the time range
+// replaced as requested. Without a new range the original arguments are sent
+// unchanged, so a relative window such as lookback_minutes ends now.
+func replayArguments(entry Entry, args ReplayQueryArgs) (map[string]any, error) {
+	out := maps.Clone(entry.Arguments)
+	if out == nil {
+		out = map[string]any{}
+	}
+	isRange := entry.StartTime != 0
+	explicit := args.StartTimeISO != "" || args.EndTimeISO != "" || args.TimeISO != "" || args.LookbackMinutes != 0
+
+	switch {
+	case explicit && args.ShiftMinutes != 0:
+		return nil, fmt.Errorf("shift_minutes cannot be combined with an explicit time range")
+	case isRange && args.TimeISO != "":
+		return nil, fmt.Errorf("time_iso applies to instant queries; use start_time_iso and end_time_iso for %s", entry.Tool)
+	case !isRange && (args.StartTimeISO != "" || args.EndTimeISO != ""):
+		return nil, fmt.Errorf("start_time_iso and end_time_iso apply to range queries; use time_iso for %s", entry.Tool)
+	case !explicit && args.ShiftMinutes == 0:
+		return out, nil
+	}
+
+	for _, key := range timeArguments {
+		delete(out, key)
+	}
+	if args.ShiftMinutes != 0 {
+		if entry.EndTime == 0 {
+			return nil, fmt.Errorf("query %s has no recorded time range to shift", entry.ID)
+		}
+		shift := time.Duration(args.ShiftMinutes * float64(time.Minute))
+		end := time.Unix(entry.EndTime, 0).UTC().Add(shift)
+		if !isRange {
+			out["time_iso"] = end.Format(time.RFC3339)
+			return out, nil
+		}
+		out["start_time_iso"] = time.Unix(entry.StartTime, 0).UTC().Add(shift).Format(time.RFC3339)
+		out["end_time_iso"] = end.Format(time.RFC3339)
+		return out, nil
+	}
+
+	setIf := func(key, value string) {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	setIf("start_time_iso", args.StartTimeISO)
+	setIf("end_time_iso", args.EndTimeISO)
+	setIf("time_iso", args.TimeISO)
+	if args.LookbackMinutes != 0 {
+		out["lookback_minutes"] = args.LookbackMinutes
+	}
+	return out, nil
+}
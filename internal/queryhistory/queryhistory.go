@@ -0,0 +1,287 @@
+// Package queryhistory records the PromQL queries the server runs so they
+// can be listed and re-run later. Entries are appended to a JSON Lines file,
+// which keeps the history across STDIO sessions; when the file holds more
+// than the configured number of entries it is rewritten with the newest.
+package queryhistory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/redact"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultMaxEntries is how many queries are kept when New is given no limit.
+const DefaultMaxEntries = 500
+
+// maxErrorBytes bounds the error text stored for a failed query.
+const maxErrorBytes = 300
+
+// ErrNotFound is returned for unknown or trimmed query ids.
+var ErrNotFound = errors.New("query not found in history; list_query_history shows the queries that are kept")
+
+// Entry is one executed query.
+type Entry struct {
+	ID         string `json:"id"`
+	Tool       string `json:"tool"`
+	Query      string `json:"query"`
+	Datasource string `json:"datasource,omitempty"`
+	// StartTime and EndTime are the resolved window in Unix seconds. Instant
+	// queries have only EndTime, the evaluation time.
+	StartTime   int64          `json:"start_time,omitempty"`
+	EndTime     int64          `json:"end_time,omitempty"`
+	Arguments   map[string]any `json:"arguments"`
+	ExecutedAt  time.Time      `json:"executed_at"`
+	LatencyMs   int64          `json:"latency_ms"`
+	ResultBytes int            `json:"result_bytes"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// Store holds the query history. A nil *Store is valid and records nothing.
+type Store struct {
+	path       string
+	maxEntries int
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// DefaultPath returns the history file in the per-user cache directory, or
+// "" when the platform has none.
+func DefaultPath() string {
+	dir := diskcache.DefaultDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "query_history.jsonl")
+}
+
+// New returns a store persisted to path, loading the entries already in it.
+// An empty path keeps the history in memory for the life of the process.
+// Unreadable lines are skipped so a damaged file does not disable history.
+func New(path string, maxEntries int) *Store {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	s := &Store{path: path, maxEntries: maxEntries}
+	if path == "" {
+		return s
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.ID == "" {
+			continue
+		}
+		s.entries = append(s.entries, e)
+	}
+	if len(s.entries) > maxEntries {
+		s.entries = s.entries[len(s.entries)-maxEntries:]
+	}
+	return s
+}
+
+// Record assigns e an id, stores it and returns it.
+func (s *Store) Record(e Entry) (Entry, error) {
+	if s == nil {
+		return e, nil
+	}
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return e, fmt.Errorf("failed to generate query id: %w", err)
+	}
+	e.ID = hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	if len(s.entries) > s.maxEntries {
+		s.entries = s.entries[len(s.entries)-s.maxEntries:]
+		return e, s.rewrite()
+	}
+	return e, s.appendLine(e)
+}
+
+// Get returns the entry with the given id.
+func (s *Store) Get(id string) (Entry, error) {
+	if s == nil {
+		return Entry{}, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Filter selects entries for List.
+type Filter struct {
+	Tool     string
+	Contains string // case-insensitive substring of the query
+	Limit    int
+}
+
+// List returns matching entries, newest first.
+func (s *Store) List(f Filter) []Entry {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contains := strings.ToLower(f.Contains)
+	var out []Entry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		if f.Tool != "" && e.Tool != f.Tool {
+			continue
+		}
+		if contains != "" && !strings.Contains(strings.ToLower(e.Query), contains) {
+			continue
+		}
+		out = append(out, e)
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+func (s *Store) appendLine(e Entry) error {
+	if s.path == "" {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode query history entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create query history dir: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open query history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write query history: %w", err)
+	}
+	return f.Close()
+}
+
+// rewrite replaces the file with the kept entries.
+func (s *Store) rewrite() error {
+	if s.path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, e := range s.entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode query history entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := diskcache.WriteFile(s.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write query history: %w", err)
+	}
+	return nil
+}
+
+// Recorded wraps a query tool handler so every call is recorded. describe
+// fills in the query, datasource and resolved time range from the arguments;
+// it runs before the handler so relative windows resolve to the same "now".
+// Failing to record is logged and never fails the call.
+func Recorded[In any](s *Store, tool string, describe func(In) Entry, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	if s == nil {
+		return handler
+	}
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		entry := describe(args)
+		entry.Tool = tool
+		entry.Arguments = argumentMap(args)
+		entry.ExecutedAt = time.Now().UTC()
+
+		result, out, err := handler(ctx, req, args)
+
+		entry.LatencyMs = time.Since(entry.ExecutedAt).Milliseconds()
+		switch {
+		case err != nil:
+			entry.Error = truncate(err.Error())
+		case result != nil:
+			text := resultText(result)
+			entry.ResultBytes = len(text)
+			if result.IsError {
+				entry.Error = truncate(text)
+			}
+		}
+		if _, recErr := s.Record(entry); recErr != nil {
+			logging.Logger("queryhistory").Warn("failed to record query history", "tool", tool, "error", recErr)
+		}
+		return result, out, err
+	}
+}
+
+// argumentMap converts tool arguments to the map replay_query sends back.
+// export is dropped: replaying a query should not overwrite an export.
+func argumentMap(args any) map[string]any {
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	delete(m, "export")
+	return m
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var sb strings.Builder
+	for _, c := range result.Content {
+		if tc, ok := c.(*mcp.TextContent); ok {
+			sb.WriteString(tc.Text)
+		}
+	}
+	return sb.String()
+}
+
+// truncate shortens error text for the history file, redacting credentials
+// first since the file outlives the process.
+func truncate(s string) string {
+	s = redact.String(s)
+	if len(s) <= maxErrorBytes {
+		return s
+	}
+	cut := maxErrorBytes
+	for cut > 0 && !isRuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
@@ -0,0 +1,184 @@
+package queryhistory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type queryArgs struct {
+	Query           string  `json:"query"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty"`
+	Export          any     `json:"export,omitempty"`
+}
+
+func TestStorePersistsAndTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	s := New(path, 3)
+	var ids []string
+	for _, q := range []string{"up", "rate(a[5m])", "rate(b[5m])", "sum(c)"} {
+		e, err := s.Record(Entry{Tool: "prometheus_instant_query", Query: q})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, e.ID)
+	}
+
+	reloaded := New(path, 3)
+	got := reloaded.List(Filter{})
+	if len(got) != 3 || got[0].Query != "sum(c)" || got[2].Query != "rate(a[5m])" {
+		t.Fatalf("List() after reload = %+v, want the newest 3, newest first", got)
+	}
+	if _, err := reloaded.Get(ids[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(trimmed) error = %v, want ErrNotFound", err)
+	}
+	if e, err := reloaded.Get(ids[3]); err != nil || e.Query != "sum(c)" {
+		t.Errorf("Get() = %+v, %v", e, err)
+	}
+
+	if got := reloaded.List(Filter{Contains: "RATE", Limit: 1}); len(got) != 1 || got[0].Query != "rate(b[5m])" {
+		t.Errorf("List(contains, limit) = %+v", got)
+	}
+	if got := reloaded.List(Filter{Tool: "prometheus_range_query"}); len(got) != 0 {
+		t.Errorf("List(tool) = %+v, want none", got)
+	}
+}
+
+func TestRecorded(t *testing.T) {
+	s := New("", 0)
+	handler := Recorded(s, "prometheus_range_query", func(a queryArgs) Entry {
+		return Entry{Query: a.Query, StartTime: 100, EndTime: 200}
+	}, func(ctx context.Context, req *mcp.CallToolRequest, a queryArgs) (*mcp.CallToolResult, any, error) {
+		if a.Query == "bad(" {
+			return nil, nil, errors.New("parse error")
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: `{"result":[]}`}}}, nil, nil
+	})
+
+	if _, _, err := handler(context.Background(), nil, queryArgs{Query: "up", LookbackMinutes: 30, Export: map[string]any{"path": "x.json"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := handler(context.Background(), nil, queryArgs{Query: "bad("}); err == nil {
+		t.Fatal("want handler error to pass through")
+	}
+
+	got := s.List(Filter{})
+	if len(got) != 2 || got[0].Error != "parse error" {
+		t.Fatalf("entries = %+v", got)
+	}
+	ok := got[1]
+	if ok.Tool != "prometheus_range_query" || ok.ResultBytes != len(`{"result":[]}`) || ok.StartTime != 100 || ok.EndTime != 200 {
+		t.Errorf("entry = %+v", ok)
+	}
+	if want := map[string]any{"query": "up", "lookback_minutes": 30.0}; !reflect.DeepEqual(ok.Arguments, want) {
+		t.Errorf("arguments = %v, want %v (export dropped)", ok.Arguments, want)
+	}
+}
+
+func TestReplayArguments(t *testing.T) {
+	rangeEntry := Entry{
+		ID: "r", Tool: "prometheus_range_query", StartTime: 1717236000, EndTime: 1717239600,
+		Arguments: map[string]any{"query": "up", "lookback_minutes": 60.0, "datasource": "prod"},
+	}
+	instantEntry := Entry{
+		ID: "i", Tool: "prometheus_instant_query", EndTime: 1717239600,
+		Arguments: map[string]any{"query": "up", "time_iso": "2024-06-01T11:00:00Z"},
+	}
+
+	tests := []struct {
+		name  string
+		entry Entry
+		args  ReplayQueryArgs
+		want  map[string]any
+	}{
+		{"unchanged", rangeEntry, ReplayQueryArgs{}, rangeEntry.Arguments},
+		{"shift range", rangeEntry, ReplayQueryArgs{ShiftMinutes: -1440}, map[string]any{
+			"query": "up", "datasource": "prod", "start_time_iso": "2024-05-31T10:00:00Z", "end_time_iso": "2024-05-31T11:00:00Z",
+		}},
+		{"explicit range", rangeEntry, ReplayQueryArgs{StartTimeISO: "2024-06-02T00:00:00Z"}, map[string]any{
+			"query": "up", "datasource": "prod", "start_time_iso": "2024-06-02T00:00:00Z",
+		}},
+		{"shift instant", instantEntry, ReplayQueryArgs{ShiftMinutes: 30}, map[string]any{"query": "up", "time_iso": "2024-06-01T11:30:00Z"}},
+		{"instant lookback", instantEntry, ReplayQueryArgs{LookbackMinutes: 5}, map[string]any{"query": "up", "lookback_minutes": 5.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replayArguments(tt.entry, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if rangeEntry.Arguments["lookback_minutes"] != 60.0 {
+		t.Error("replayArguments modified the recorded arguments")
+	}
+
+	for name, tc := range map[string]struct {
+		entry Entry
+		args  ReplayQueryArgs
+	}{
+		"shift with explicit":   {rangeEntry, ReplayQueryArgs{ShiftMinutes: 5, LookbackMinutes: 5}},
+		"time_iso on range":     {rangeEntry, ReplayQueryArgs{TimeISO: "2024-06-01T00:00:00Z"}},
+		"start_time on instant": {instantEntry, ReplayQueryArgs{StartTimeISO: "2024-06-01T00:00:00Z"}},
+	} {
+		if _, err := replayArguments(tc.entry, tc.args); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	s := New("", 0)
+	e, err := s.Record(Entry{Tool: "prometheus_instant_query", Query: "up", EndTime: 1717239600, Arguments: map[string]any{"query": "up"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _, err := NewListQueryHistoryHandler(s)(context.Background(), nil, ListQueryHistoryArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed struct {
+		Queries []Entry `json:"queries"`
+		Count   int     `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if listed.Count != 1 || listed.Queries[0].ID != e.ID {
+		t.Fatalf("listed = %+v", listed)
+	}
+
+	var calledTool string
+	var calledArgs map[string]any
+	replay := NewReplayQueryHandler(s, func(ctx context.Context, tool string, args map[string]any) (*mcp.CallToolResult, error) {
+		calledTool, calledArgs = tool, args
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+	})
+	result, _, err = replay(context.Background(), nil, ReplayQueryArgs{ID: e.ID, ShiftMinutes: -60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calledTool != "prometheus_instant_query" || calledArgs["time_iso"] != "2024-06-01T10:00:00Z" {
+		t.Errorf("replayed %s with %v", calledTool, calledArgs)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; text != "ok" {
+		t.Errorf("replay result = %q, want the tool's result", text)
+	}
+
+	if _, _, err := replay(context.Background(), nil, ReplayQueryArgs{ID: "missing"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unknown id error = %v", err)
+	}
+	if _, _, err := NewListQueryHistoryHandler(s)(context.Background(), nil, ListQueryHistoryArgs{Limit: 1000}); err == nil {
+		t.Error("limit above max: want error")
+	}
+}
@@ -0,0 +1,119 @@
+package resultarchive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/redact"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Keys of the result _meta that name the stored copy of a tool result.
+const (
+	MetaStoredResultID = "stored_result_id"
+	MetaExpiresAt      = "stored_result_expires_at"
+)
+
+// FetchResultArgs represents the input arguments for the fetch_result tool
+type FetchResultArgs struct {
+	ID        string  `json:"id" jsonschema:"stored_result_id from the _meta of an earlier tool result (required)"`
+	KeepHours float64 `json:"keep_hours,omitempty" jsonschema:"Keep the result this many hours from now instead of its current expiry (optional, max 720, e.g. 168 to hand it off for a week)"`
+}
+
+// Attach stores the text of a tool result and returns a copy with the stored
+// id and expiry in its _meta, so the content clients read is unchanged.
+// Error results, and results without exactly one text content, are returned
+// unchanged. A failure to store is logged rather than failing the call.
+func (s *Store) Attach(tool string, req *mcp.CallToolRequest, result *mcp.CallToolResult) *mcp.CallToolResult {
+	if !s.Enabled() || result == nil || result.IsError {
+		return result
+	}
+	textIdx := -1
+	for i, content := range result.Content {
+		if _, ok := content.(*mcp.TextContent); ok {
+			if textIdx >= 0 {
+				return result
+			}
+			textIdx = i
+		}
+	}
+	if textIdx < 0 {
+		return result
+	}
+	var arguments json.RawMessage
+	if req != nil && req.Params != nil {
+		arguments = redactArguments(req.Params.Arguments)
+	}
+	e, err := s.Put(tool, arguments, result.Content[textIdx].(*mcp.TextContent).Text)
+	if err != nil {
+		logging.Logger("resultarchive").Warn("failed to store tool result", "tool", tool, "error", err)
+		return result
+	}
+	stored := *result
+	stored.Meta = mcp.Meta{}
+	maps.Copy(stored.Meta, result.Meta)
+	stored.Meta[MetaStoredResultID] = e.ID
+	stored.Meta[MetaExpiresAt] = e.ExpiresAt.Format(time.RFC3339)
+	return &stored
+}
+
+// redactArguments scrubs credentials from the arguments of a call before
+// they are stored. Arguments left invalid by the redaction are dropped.
+func redactArguments(arguments json.RawMessage) json.RawMessage {
+	if len(arguments) == 0 {
+		return nil
+	}
+	redacted := json.RawMessage(redact.String(string(arguments)))
+	if !json.Valid(redacted) {
+		return nil
+	}
+	return redacted
+}
+
+// NewFetchResultHandler returns a handler that returns a stored result,
+// optionally keeping it longer.
+func NewFetchResultHandler(store *Store) func(context.Context, *mcp.CallToolRequest, FetchResultArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args FetchResultArgs) (*mcp.CallToolResult, any, error) {
+		if !store.Enabled() {
+			return nil, nil, errDisabled
+		}
+		if args.ID == "" {
+			return nil, nil, fmt.Errorf("id is required")
+		}
+		if args.KeepHours < 0 || args.KeepHours > MaxTTL.Hours() {
+			return nil, nil, fmt.Errorf("keep_hours must be between 0 and %d", int(MaxTTL.Hours()))
+		}
+		e, err := store.Get(args.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if args.KeepHours > 0 {
+			kept, err := store.Keep(args.ID, time.Duration(args.KeepHours*float64(time.Hour)))
+			if err != nil {
+				return nil, nil, err
+			}
+			e.ExpiresAt = kept.ExpiresAt
+		}
+		about, err := json.Marshal(map[string]any{
+			"stored_result_id": e.ID,
+			"tool":             e.Tool,
+			"arguments":        e.Arguments,
+			"stored_at":        e.StoredAt,
+			"expires_at":       e.ExpiresAt,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: e.Text},
+				&mcp.TextContent{Text: string(about)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,248 @@
+// Package resultarchive keeps tool results on disk, gzip-compressed, under
+// a generated id, so later calls and other sessions can fetch a result again
+// or diff it without re-querying the backend. Storing is opt-in: it needs a
+// configured directory, and the arguments of each call are redacted. Each
+// result is one file whose modification time is set to its expiry, so
+// expired and surplus results are pruned from a directory listing without
+// reading them.
+package resultarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+)
+
+const (
+	// DefaultMaxEntries is how many results are kept when New is given no
+	// limit; the ones closest to expiry are pruned first.
+	DefaultMaxEntries = 500
+	// MaxTTL bounds how long a result can be kept.
+	MaxTTL = 30 * 24 * time.Hour
+
+	fileSuffix = ".json.gz"
+)
+
+// ErrNotFound is returned for unknown, expired or pruned result ids.
+var ErrNotFound = errors.New("stored result not found; it may have expired or been pruned, re-run the original tool call")
+
+// errDisabled is returned when the server stores no results.
+var errDisabled = errors.New("result storage is disabled; set LAST9_RESULT_ARCHIVE_DIR and a positive LAST9_RESULT_TTL_HOURS")
+
+// Entry is a stored tool result.
+type Entry struct {
+	ID        string          `json:"id"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	StoredAt  time.Time       `json:"stored_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Text      string          `json:"text"`
+}
+
+// Store keeps results in a directory. A nil *Store is valid and stores
+// nothing.
+type Store struct {
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+// New returns a store keeping results in dir for ttl, at most maxEntries
+// of them (DefaultMaxEntries when <= 0). An empty dir or a ttl <= 0
+// disables storing and New returns nil.
+func New(dir string, ttl time.Duration, maxEntries int) *Store {
+	if dir == "" || ttl <= 0 {
+		return nil
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Store{dir: dir, ttl: min(ttl, MaxTTL), maxEntries: maxEntries}
+}
+
+// Enabled reports whether results are stored.
+func (s *Store) Enabled() bool { return s != nil }
+
+// Put stores the result text of a tool call and returns the entry without
+// its text.
+func (s *Store) Put(tool string, arguments json.RawMessage, text string) (Entry, error) {
+	if s == nil {
+		return Entry{}, errDisabled
+	}
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return Entry{}, fmt.Errorf("failed to generate result id: %w", err)
+	}
+	now := time.Now().UTC()
+	e := Entry{
+		ID:        hex.EncodeToString(b[:]),
+		Tool:      tool,
+		Arguments: arguments,
+		StoredAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+		Text:      text,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(e); err != nil {
+		return Entry{}, err
+	}
+	s.prune(now)
+	e.Text = ""
+	return e, nil
+}
+
+// Get returns a stored result.
+func (s *Store) Get(id string) (Entry, error) {
+	if s == nil {
+		return Entry{}, ErrNotFound
+	}
+	path, ok := s.path(id)
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := read(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	if !time.Now().Before(e.ExpiresAt) {
+		os.Remove(path)
+		return Entry{}, ErrNotFound
+	}
+	return e, nil
+}
+
+// Keep moves the expiry of a stored result to ttl from now, up to MaxTTL,
+// and returns the updated entry.
+func (s *Store) Keep(id string, ttl time.Duration) (Entry, error) {
+	if ttl <= 0 || ttl > MaxTTL {
+		return Entry{}, fmt.Errorf("expiry must be between 0 and %d hours", int(MaxTTL.Hours()))
+	}
+	e, err := s.Get(id)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.ExpiresAt = time.Now().UTC().Add(ttl)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// Text returns the text of a stored result.
+func (s *Store) Text(id string) (string, error) {
+	e, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+	return e.Text, nil
+}
+
+// path maps an id to its file. Ids are hex, so anything else is unknown
+// rather than a path to escape the directory with.
+func (s *Store) path(id string) (string, bool) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return "", false
+	}
+	return filepath.Join(s.dir, id+fileSuffix), true
+}
+
+func read(path string) (Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Entry{}, ErrNotFound
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read stored result: %w", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read stored result: %w", err)
+	}
+	var e Entry
+	if err := json.NewDecoder(zr).Decode(&e); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode stored result: %w", err)
+	}
+	return e, nil
+}
+
+// write saves e compressed and sets the file's modification time to its
+// expiry. Callers hold s.mu.
+func (s *Store) write(e Entry) error {
+	path, ok := s.path(e.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(e); err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	if err := diskcache.WriteFile(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if err := os.Chtimes(path, e.ExpiresAt, e.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	return nil
+}
+
+// prune removes expired results, then the ones closest to expiry while
+// more than maxEntries remain. It is best effort. Callers hold s.mu.
+func (s *Store) prune(now time.Time) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	type file struct {
+		path      string
+		expiresAt time.Time
+	}
+	var live []file
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), fileSuffix) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(s.dir, de.Name())
+		if !now.Before(info.ModTime()) {
+			os.Remove(path)
+			continue
+		}
+		live = append(live, file{path, info.ModTime()})
+	}
+	if len(live) <= s.maxEntries {
+		return
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].expiresAt.Before(live[j].expiresAt) })
+	for _, f := range live[:len(live)-s.maxEntries] {
+		os.Remove(f.path)
+	}
+}
@@ -0,0 +1,493 @@
+// Package resultdiff compares two JSON tool results structurally, for
+// before/after comparisons around a mitigation or deploy.
+package resultdiff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/resultarchive"
+	"github.com/last9/last9-mcp-server/internal/resultstore"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultMinChangePercent = 5
+	defaultLimit            = 100
+	maxLimit                = 1000
+)
+
+// Change kinds.
+const (
+	KindAdded       = "added"
+	KindRemoved     = "removed"
+	KindChanged     = "changed"
+	KindTypeChanged = "type_changed"
+)
+
+// identityKeys are fields that identify an element of an array of objects,
+// in order of preference: Prometheus series labels first, then common ids.
+var identityKeys = []string{"metric", "labels", "id", "name", "service_name", "service", "group", "rule_name", "key"}
+
+// defaultIgnoredKeys change on every call and would drown real differences.
+var defaultIgnoredKeys = []string{"start_time", "end_time", "timestamp", "time", "generated_at"}
+
+// DiffResultsArgs represents the input arguments for the diff_results tool
+type DiffResultsArgs struct {
+	Before           string   `json:"before,omitempty" jsonschema:"Earlier tool result as JSON text. Use this or before_result_id."`
+	After            string   `json:"after,omitempty" jsonschema:"Later tool result as JSON text. Use this or after_result_id."`
+	BeforeResultID   string   `json:"before_result_id,omitempty" jsonschema:"result_id of a split earlier result (see get_result_chunk) or its stored_result_id (see fetch_result), instead of before"`
+	AfterResultID    string   `json:"after_result_id,omitempty" jsonschema:"result_id or stored_result_id of the later result, instead of after"`
+	MinChangePercent *float64 `json:"min_change_percent,omitempty" jsonschema:"Smallest relative numeric change to report, in percent (default: 5). 0 reports every change."`
+	IgnoreKeys       []string `json:"ignore_keys,omitempty" jsonschema:"Object keys to skip anywhere in the results (default: start_time, end_time, timestamp, time, generated_at, plus *_local display fields)"`
+	Limit            int      `json:"limit,omitempty" jsonschema:"Maximum number of changes listed, most significant first (default: 100, max: 1000)"`
+}
+
+// Change is one difference between the results.
+type Change struct {
+	Path         string   `json:"path"`
+	Kind         string   `json:"kind"`
+	Before       any      `json:"before,omitempty"`
+	After        any      `json:"after,omitempty"`
+	Delta        *float64 `json:"delta,omitempty"`
+	DeltaPercent *float64 `json:"delta_percent,omitempty"`
+}
+
+// Summary counts the differences found.
+type Summary struct {
+	Added          int `json:"added"`
+	Removed        int `json:"removed"`
+	Changed        int `json:"changed"`
+	BelowThreshold int `json:"numeric_changes_below_threshold"`
+}
+
+// Report is the diff_results response.
+type Report struct {
+	Summary Summary  `json:"summary"`
+	Changes []Change `json:"changes"`
+	Omitted int      `json:"omitted_changes,omitempty"`
+}
+
+// NewDiffResultsHandler compares two results given inline, by the result_id
+// of a split result or by the id of a stored result.
+func NewDiffResultsHandler(store *resultstore.Store, archive *resultarchive.Store) func(context.Context, *mcp.CallToolRequest, DiffResultsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DiffResultsArgs) (*mcp.CallToolResult, any, error) {
+		before, err := loadSide(store, archive, "before", args.Before, args.BeforeResultID)
+		if err != nil {
+			return nil, nil, err
+		}
+		after, err := loadSide(store, archive, "after", args.After, args.AfterResultID)
+		if err != nil {
+			return nil, nil, err
+		}
+		minChange := float64(defaultMinChangePercent)
+		if args.MinChangePercent != nil {
+			minChange = *args.MinChangePercent
+		}
+		if minChange < 0 {
+			return nil, nil, fmt.Errorf("min_change_percent must not be negative")
+		}
+		limit := args.Limit
+		if limit == 0 {
+			limit = defaultLimit
+		}
+		if limit < 0 || limit > maxLimit {
+			return nil, nil, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		ignore := args.IgnoreKeys
+		if ignore == nil {
+			ignore = defaultIgnoredKeys
+		}
+
+		report := Diff(before, after, Options{MinChangePercent: minChange, IgnoreKeys: ignore, IgnoreLocalKeys: args.IgnoreKeys == nil}, limit)
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// loadSide parses one side of the comparison from inline JSON, the split
+// results or the stored results.
+func loadSide(store *resultstore.Store, archive *resultarchive.Store, name, inline, resultID string) (any, error) {
+	if (inline == "") == (resultID == "") {
+		return nil, fmt.Errorf("provide exactly one of %s and %s_result_id", name, name)
+	}
+	text := inline
+	if resultID != "" {
+		stored, err := store.Text(resultID)
+		if errors.Is(err, resultstore.ErrNotFound) && archive.Enabled() {
+			stored, err = archive.Text(resultID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s_result_id: %w", name, err)
+		}
+		text = stored
+	}
+	var v any
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%s is not valid JSON: %w", name, err)
+	}
+	return v, nil
+}
+
+// Options tune Diff.
+type Options struct {
+	MinChangePercent float64
+	IgnoreKeys       []string
+	// IgnoreLocalKeys skips *_local fields added by display_timezone.
+	IgnoreLocalKeys bool
+}
+
+// Diff compares before and after and returns at most limit changes.
+func Diff(before, after any, opts Options, limit int) Report {
+	d := &differ{opts: opts, ignore: map[string]bool{}}
+	for _, k := range opts.IgnoreKeys {
+		d.ignore[k] = true
+	}
+	d.walk("$", normalize(before), normalize(after))
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return significance(d.changes[i]) > significance(d.changes[j])
+	})
+	report := Report{Summary: d.summary, Changes: d.changes}
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+	if len(report.Changes) > limit {
+		report.Omitted = len(report.Changes) - limit
+		report.Changes = report.Changes[:limit]
+	}
+	return report
+}
+
+type differ struct {
+	opts    Options
+	ignore  map[string]bool
+	changes []Change
+	summary Summary
+}
+
+func (d *differ) add(c Change) {
+	switch c.Kind {
+	case KindAdded:
+		d.summary.Added++
+	case KindRemoved:
+		d.summary.Removed++
+	default:
+		d.summary.Changed++
+	}
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) skipKey(k string) bool {
+	return d.ignore[k] || (d.opts.IgnoreLocalKeys && strings.HasSuffix(k, "_local"))
+}
+
+func (d *differ) walk(path string, before, after any) {
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			d.add(Change{Path: path, Kind: KindTypeChanged, Before: before, After: after})
+			return
+		}
+		for _, k := range unionKeys(b, a) {
+			if d.skipKey(k) {
+				continue
+			}
+			bv, inBefore := b[k]
+			av, inAfter := a[k]
+			child := path + "." + k
+			switch {
+			case !inAfter:
+				d.add(Change{Path: child, Kind: KindRemoved, Before: bv})
+			case !inBefore:
+				d.add(Change{Path: child, Kind: KindAdded, After: av})
+			default:
+				d.walk(child, bv, av)
+			}
+		}
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			d.add(Change{Path: path, Kind: KindTypeChanged, Before: before, After: after})
+			return
+		}
+		d.walkArray(path, b, a)
+	case float64:
+		a, ok := after.(float64)
+		if !ok {
+			d.add(Change{Path: path, Kind: KindTypeChanged, Before: before, After: after})
+			return
+		}
+		d.compareNumbers(path, b, a)
+	default:
+		if fmt.Sprint(before) != fmt.Sprint(after) || typeName(before) != typeName(after) {
+			kind := KindChanged
+			if typeName(before) != typeName(after) {
+				kind = KindTypeChanged
+			}
+			d.add(Change{Path: path, Kind: kind, Before: before, After: after})
+		}
+	}
+}
+
+func (d *differ) compareNumbers(path string, before, after float64) {
+	if before == after {
+		return
+	}
+	delta := round(after - before)
+	c := Change{Path: path, Kind: KindChanged, Before: before, After: after, Delta: &delta}
+	if before != 0 {
+		pct := round((after - before) / math.Abs(before) * 100)
+		if math.Abs(pct) < d.opts.MinChangePercent {
+			d.summary.BelowThreshold++
+			return
+		}
+		c.DeltaPercent = &pct
+	}
+	d.add(c)
+}
+
+// walkArray matches elements by identity when every element on both sides
+// is an object sharing a unique identity field, so reordered or new series
+// are reported as appeared/disappeared rather than as shifted values.
+// Otherwise elements are compared by position.
+func (d *differ) walkArray(path string, before, after []any) {
+	if key, ok := identityKey(before, after); ok {
+		bByID, order := indexBy(before, key)
+		aByID, afterOrder := indexBy(after, key)
+		for _, id := range afterOrder {
+			if _, ok := bByID[id]; !ok {
+				order = append(order, id)
+			}
+		}
+		for _, id := range order {
+			child := fmt.Sprintf("%s[%s=%s]", path, key, id)
+			bv, inBefore := bByID[id]
+			av, inAfter := aByID[id]
+			switch {
+			case !inAfter:
+				d.add(Change{Path: child, Kind: KindRemoved, Before: bv})
+			case !inBefore:
+				d.add(Change{Path: child, Kind: KindAdded, After: av})
+			default:
+				d.walk(child, bv, av)
+			}
+		}
+		return
+	}
+	for i := 0; i < max(len(before), len(after)); i++ {
+		child := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(after):
+			d.add(Change{Path: child, Kind: KindRemoved, Before: before[i]})
+		case i >= len(before):
+			d.add(Change{Path: child, Kind: KindAdded, After: after[i]})
+		default:
+			d.walk(child, before[i], after[i])
+		}
+	}
+}
+
+// identityKey returns the first identity field present, with unique values,
+// in every element of both arrays.
+func identityKey(before, after []any) (string, bool) {
+	if len(before) == 0 && len(after) == 0 {
+		return "", false
+	}
+	for _, key := range identityKeys {
+		if uniqueField(before, key) && uniqueField(after, key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func uniqueField(items []any, key string) bool {
+	seen := map[string]bool{}
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+		v, ok := obj[key]
+		if !ok {
+			return false
+		}
+		id := identity(v)
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+func indexBy(items []any, key string) (map[string]any, []string) {
+	byID := make(map[string]any, len(items))
+	order := make([]string, 0, len(items))
+	for _, item := range items {
+		obj := item.(map[string]any)
+		id := identity(obj[key])
+		byID[id] = withoutKey(obj, key)
+		order = append(order, id)
+	}
+	return byID, order
+}
+
+// identity renders an identity value; label sets become {a="x",b="y"}.
+func identity(v any) string {
+	if labels, ok := v.(map[string]any); ok {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s=%q", k, fmt.Sprint(labels[k]))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	}
+	return fmt.Sprint(v)
+}
+
+func withoutKey(obj map[string]any, key string) map[string]any {
+	out := make(map[string]any, len(obj))
+	for k, v := range obj {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// normalize converts json.Number to float64 and collapses Prometheus
+// samples: an instant [ts, "v"] becomes v, and a range of samples becomes
+// {avg, max, min, last}, since timestamps differ between any two queries.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = normalize(item)
+		}
+		return out
+	case []any:
+		if value, ok := sampleValue(v); ok {
+			return value
+		}
+		if stats, ok := sampleStats(v); ok {
+			return stats
+		}
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	}
+	return v
+}
+
+// sampleValue recognizes a Prometheus sample [timestamp, "value"].
+func sampleValue(v []any) (float64, bool) {
+	if len(v) != 2 {
+		return 0, false
+	}
+	if _, ok := v[0].(json.Number); !ok {
+		return 0, false
+	}
+	s, ok := v[1].(string)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+func sampleStats(v []any) (map[string]any, bool) {
+	if len(v) == 0 {
+		return nil, false
+	}
+	values := make([]float64, 0, len(v))
+	for _, item := range v {
+		sample, ok := item.([]any)
+		if !ok {
+			return nil, false
+		}
+		f, ok := sampleValue(sample)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, f)
+	}
+	sum, lo, hi := 0.0, math.Inf(1), math.Inf(-1)
+	for _, f := range values {
+		sum += f
+		lo = math.Min(lo, f)
+		hi = math.Max(hi, f)
+	}
+	return map[string]any{
+		"avg":  round(sum / float64(len(values))),
+		"max":  hi,
+		"min":  lo,
+		"last": values[len(values)-1],
+	}, true
+}
+
+// significance orders changes: removed and added elements first, then
+// numeric changes by relative size, then everything else.
+func significance(c Change) float64 {
+	switch {
+	case c.Kind == KindRemoved || c.Kind == KindAdded || c.Kind == KindTypeChanged:
+		return math.Inf(1)
+	case c.DeltaPercent != nil:
+		return math.Abs(*c.DeltaPercent)
+	case c.Delta != nil:
+		// From zero: no percentage, but always worth showing.
+		return math.MaxFloat64
+	}
+	return 0
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeName(v any) string {
+	return fmt.Sprintf("%T", v)
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,135 @@
+package resultdiff
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/resultarchive"
+	"github.com/last9/last9-mcp-server/internal/resultstore"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func parse(t *testing.T, s string) any {
+	t.Helper()
+	v, err := loadSide(nil, nil, "test", s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func changeAt(report Report, path string) *Change {
+	for i := range report.Changes {
+		if report.Changes[i].Path == path {
+			return &report.Changes[i]
+		}
+	}
+	return nil
+}
+
+func TestDiffPrometheusVector(t *testing.T) {
+	before := parse(t, `[
+		{"metric":{"service":"cart","code":"500"},"value":[1700000000,"10"]},
+		{"metric":{"service":"cart","code":"200"},"value":[1700000000,"1000"]},
+		{"metric":{"service":"cart","code":"503"},"value":[1700000000,"4"]}
+	]`)
+	after := parse(t, `[
+		{"metric":{"service":"cart","code":"200"},"value":[1700000600,"1020"]},
+		{"metric":{"service":"cart","code":"500"},"value":[1700000600,"2"]},
+		{"metric":{"service":"cart","code":"404"},"value":[1700000600,"7"]}
+	]`)
+	report := Diff(before, after, Options{MinChangePercent: 5}, 100)
+
+	if got := report.Summary; got.Added != 1 || got.Removed != 1 || got.Changed != 1 || got.BelowThreshold != 1 {
+		t.Fatalf("summary = %+v, want 1 added, 1 removed, 1 changed, 1 below threshold", got)
+	}
+	c := changeAt(report, `$[metric={code="500",service="cart"}].value`)
+	if c == nil || *c.Delta != -8 || *c.DeltaPercent != -80 {
+		t.Fatalf("500 series change = %+v, want -8 (-80%%); changes: %+v", c, report.Changes)
+	}
+	if c := changeAt(report, `$[metric={code="503",service="cart"}]`); c == nil || c.Kind != KindRemoved {
+		t.Errorf("503 series = %+v, want removed", c)
+	}
+	if c := changeAt(report, `$[metric={code="404",service="cart"}]`); c == nil || c.Kind != KindAdded {
+		t.Errorf("404 series = %+v, want added", c)
+	}
+	// Added and removed series sort ahead of value changes.
+	if report.Changes[0].Kind == KindChanged {
+		t.Errorf("first change = %+v, want an added or removed series", report.Changes[0])
+	}
+}
+
+func TestDiffRangeSeries(t *testing.T) {
+	before := parse(t, `{"result":[{"metric":{"pod":"a"},"values":[[1,"1"],[2,"3"]]}]}`)
+	after := parse(t, `{"result":[{"metric":{"pod":"a"},"values":[[9,"10"],[10,"30"]]}]}`)
+	report := Diff(before, after, Options{}, 100)
+	c := changeAt(report, `$.result[metric={pod="a"}].values.avg`)
+	if c == nil || c.Before != 2.0 || c.After != 20.0 {
+		t.Fatalf("avg change = %+v; changes: %+v", c, report.Changes)
+	}
+}
+
+func TestDiffIgnoresAndPositional(t *testing.T) {
+	before := parse(t, `{"start_time":"a","start_time_local":"x","items":[1,2],"status":"ok","count":0}`)
+	after := parse(t, `{"start_time":"b","start_time_local":"y","items":[1,2,3],"status":"degraded","count":3}`)
+	report := Diff(before, after, Options{IgnoreKeys: defaultIgnoredKeys, IgnoreLocalKeys: true}, 100)
+	if len(report.Changes) != 3 {
+		t.Fatalf("changes = %+v, want items[2] added, status and count changed", report.Changes)
+	}
+	if c := changeAt(report, "$.items[2]"); c == nil || c.Kind != KindAdded {
+		t.Errorf("items[2] = %+v", c)
+	}
+	if c := changeAt(report, "$.count"); c == nil || c.DeltaPercent != nil || *c.Delta != 3 {
+		t.Errorf("count change from zero = %+v, want delta without percent", c)
+	}
+	if c := changeAt(report, "$.status"); c == nil || c.Before != "ok" || c.After != "degraded" {
+		t.Errorf("status = %+v", c)
+	}
+
+	limited := Diff(before, after, Options{IgnoreKeys: defaultIgnoredKeys, IgnoreLocalKeys: true}, 1)
+	if len(limited.Changes) != 1 || limited.Omitted != 2 {
+		t.Errorf("limited = %+v, want 1 change and 2 omitted", limited)
+	}
+}
+
+func TestDiffResultsHandler(t *testing.T) {
+	store := resultstore.New(3000)
+	id, _, err := store.Put(`{"errors":` + strings.Repeat(" ", 5000) + `100}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := resultarchive.New(t.TempDir(), time.Hour, 0)
+	after, err := archive.Put("get_service_summary", nil, `{"errors":40}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewDiffResultsHandler(store, archive)
+
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, DiffResultsArgs{BeforeResultID: id, AfterResultID: after.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 1 || *report.Changes[0].DeltaPercent != -60 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	for name, args := range map[string]DiffResultsArgs{
+		"both sides given": {Before: "{}", BeforeResultID: id, After: "{}"},
+		"side missing":     {Before: "{}"},
+		"invalid json":     {Before: "{", After: "{}"},
+		"unknown id":       {BeforeResultID: "nope", After: "{}"},
+		"bad limit":        {Before: "{}", After: "{}", Limit: 5000},
+	} {
+		if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, args); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package resultstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Notice accompanies the first chunk of a split result.
+type Notice struct {
+	ResultID    string `json:"result_id"`
+	Chunk       int    `json:"chunk"`
+	TotalChunks int    `json:"total_chunks"`
+	TotalBytes  int    `json:"total_bytes"`
+	Hint        string `json:"hint"`
+}
+
+// GetResultChunkArgs represents the input arguments for the get_result_chunk tool
+type GetResultChunkArgs struct {
+	ResultID string `json:"result_id" jsonschema:"result_id from the chunk notice of a split tool result (required)"`
+	Chunk    int    `json:"chunk" jsonschema:"Zero-based chunk index; chunk 0 was returned with the original result (required)"`
+}
+
+// Limit replaces the largest text content of an oversized result with its
+// first chunk and appends a Notice. Results that fit, and results without
+// text content, are returned unchanged.
+func (s *Store) Limit(result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+	if !s.Enabled() || result == nil {
+		return result, nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil || len(data) <= s.maxBytes {
+		return result, nil
+	}
+	textIdx := -1
+	for i, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			if textIdx < 0 || len(text.Text) > len(result.Content[textIdx].(*mcp.TextContent).Text) {
+				textIdx = i
+			}
+		}
+	}
+	if textIdx < 0 {
+		return result, nil
+	}
+
+	text := result.Content[textIdx].(*mcp.TextContent).Text
+	id, chunks, err := s.Put(text)
+	if err != nil {
+		return nil, err
+	}
+	notice, err := json.Marshal(Notice{
+		ResultID:    id,
+		Chunk:       0,
+		TotalChunks: len(chunks),
+		TotalBytes:  len(text),
+		Hint:        fmt.Sprintf("The result exceeded %d bytes and was split. Call get_result_chunk with this result_id for chunks 1-%d and concatenate the text in order; narrow the query or use export to avoid splitting.", s.maxBytes, len(chunks)-1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	limited := *result
+	limited.Content = append([]mcp.Content(nil), result.Content...)
+	limited.Content[textIdx] = &mcp.TextContent{Text: chunks[0]}
+	limited.Content = append(limited.Content, &mcp.TextContent{Text: string(notice)})
+	return &limited, nil
+}
+
+// NewGetResultChunkHandler returns a handler that reads back chunks of a
+// split result.
+func NewGetResultChunkHandler(store *Store) func(context.Context, *mcp.CallToolRequest, GetResultChunkArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetResultChunkArgs) (*mcp.CallToolResult, any, error) {
+		if args.ResultID == "" {
+			return nil, nil, fmt.Errorf("result_id is required")
+		}
+		chunk, total, err := store.Chunk(args.ResultID, args.Chunk)
+		if err != nil {
+			return nil, nil, err
+		}
+		notice, err := json.Marshal(map[string]any{
+			"result_id":    args.ResultID,
+			"chunk":        args.Chunk,
+			"total_chunks": total,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: chunk},
+				&mcp.TextContent{Text: string(notice)},
+			},
+		}, nil, nil
+	}
+}
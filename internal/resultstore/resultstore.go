@@ -0,0 +1,126 @@
+// Package resultstore splits tool results too large for one MCP message into
+// chunks and keeps them in memory so clients can read the rest back.
+package resultstore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// capacity is how many chunked results are kept; the oldest is evicted.
+	capacity = 32
+	// envelopeBytes is reserved for the JSON-RPC envelope and the chunk notice.
+	envelopeBytes = 2048
+	minChunkBytes = 1024
+)
+
+// ErrNotFound is returned for unknown or evicted result ids.
+var ErrNotFound = errors.New("result not found; it may have been evicted, re-run the original tool call")
+
+// Store holds chunked results. The zero value is unusable; use New.
+type Store struct {
+	maxBytes int
+
+	mu      sync.Mutex
+	results map[string][]string
+	order   []string
+}
+
+// New returns a store for results that must fit in maxBytes per message.
+// maxBytes <= 0 disables chunking.
+func New(maxBytes int) *Store {
+	return &Store{maxBytes: maxBytes, results: map[string][]string{}}
+}
+
+// Enabled reports whether results are size-limited.
+func (s *Store) Enabled() bool { return s != nil && s.maxBytes > 0 }
+
+// MaxBytes returns the per-message limit.
+func (s *Store) MaxBytes() int { return s.maxBytes }
+
+// ChunkBytes is the JSON-encoded size budget of one chunk.
+func (s *Store) ChunkBytes() int {
+	return max(s.maxBytes-envelopeBytes, minChunkBytes)
+}
+
+// Put splits text into chunks that fit in a message once JSON-encoded,
+// stores them and returns the result id and the chunks.
+func (s *Store) Put(text string) (string, []string, error) {
+	chunks := Split(text, s.ChunkBytes())
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", nil, fmt.Errorf("failed to generate result id: %w", err)
+	}
+	id := hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[id] = chunks
+	s.order = append(s.order, id)
+	if len(s.order) > capacity {
+		delete(s.results, s.order[0])
+		s.order = s.order[1:]
+	}
+	return id, chunks, nil
+}
+
+// Chunk returns chunk index of a stored result and the chunk count.
+func (s *Store) Chunk(id string, index int) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chunks, ok := s.results[id]
+	if !ok {
+		return "", 0, ErrNotFound
+	}
+	if index < 0 || index >= len(chunks) {
+		return "", len(chunks), fmt.Errorf("chunk %d out of range: result has chunks 0-%d", index, len(chunks)-1)
+	}
+	return chunks[index], len(chunks), nil
+}
+
+// Split cuts text on rune boundaries into pieces whose JSON string encoding
+// is at most limit bytes.
+func Split(text string, limit int) []string {
+	var chunks []string
+	start, size := 0, 2 // the surrounding quotes
+	for i, r := range text {
+		n := encodedLen(r)
+		if size+n > limit && i > start {
+			chunks = append(chunks, text[start:i])
+			start, size = i, 2
+		}
+		size += n
+	}
+	return append(chunks, text[start:])
+}
+
+// encodedLen is the size of r inside a JSON string as written by
+// encoding/json, which also escapes HTML characters.
+func encodedLen(r rune) int {
+	switch {
+	case r == '"' || r == '\\' || r == '\n' || r == '\r' || r == '\t':
+		return 2
+	case r < 0x20 || r == '<' || r == '>' || r == '&' || r == '\u2028' || r == '\u2029':
+		return 6
+	case r == utf8.RuneError:
+		return 6
+	}
+	return utf8.RuneLen(r)
+}
+
+// Text returns the full text of a stored result.
+func (s *Store) Text(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chunks, ok := s.results[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return strings.Join(chunks, ""), nil
+}
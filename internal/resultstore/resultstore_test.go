@@ -0,0 +1,87 @@
+package resultstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSplit(t *testing.T) {
+	text := strings.Repeat(`{"msg":"héllo <b>"}`+"\n", 200)
+	chunks := Split(text, 100)
+	if strings.Join(chunks, "") != text {
+		t.Fatal("chunks do not reassemble the text")
+	}
+	for i, c := range chunks {
+		encoded, _ := json.Marshal(c)
+		if len(encoded) > 100 {
+			t.Errorf("chunk %d encodes to %d bytes", i, len(encoded))
+		}
+	}
+	if got := Split("", 100); len(got) != 1 || got[0] != "" {
+		t.Errorf("Split(\"\") = %q", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	store := New(4096)
+	small := &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}
+	if got, _ := store.Limit(small); got != small {
+		t.Error("small result should pass through")
+	}
+
+	text := strings.Repeat("0123456789", 1000)
+	limited, err := store.Limit(&mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited.Content) != 2 {
+		t.Fatalf("content = %d items, want chunk and notice", len(limited.Content))
+	}
+	if data, _ := json.Marshal(limited); len(data) > store.MaxBytes() {
+		t.Errorf("limited result is %d bytes, max %d", len(data), store.MaxBytes())
+	}
+	var notice Notice
+	if err := json.Unmarshal([]byte(limited.Content[1].(*mcp.TextContent).Text), &notice); err != nil {
+		t.Fatal(err)
+	}
+	if notice.TotalChunks != 5 || notice.TotalBytes != len(text) {
+		t.Fatalf("notice = %+v", notice)
+	}
+
+	handler := NewGetResultChunkHandler(store)
+	rebuilt := limited.Content[0].(*mcp.TextContent).Text
+	for i := 1; i < notice.TotalChunks; i++ {
+		result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetResultChunkArgs{ResultID: notice.ResultID, Chunk: i})
+		if err != nil {
+			t.Fatalf("chunk %d: %v", i, err)
+		}
+		rebuilt += result.Content[0].(*mcp.TextContent).Text
+	}
+	if rebuilt != text {
+		t.Error("chunks do not reassemble the result")
+	}
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetResultChunkArgs{ResultID: notice.ResultID, Chunk: 5}); err == nil {
+		t.Error("expected out of range error")
+	}
+
+	if got, _ := New(0).Limit(&mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}); len(got.Content) != 1 {
+		t.Error("disabled store should not split")
+	}
+}
+
+func TestStoreEviction(t *testing.T) {
+	store := New(4096)
+	first, _, _ := store.Put("a")
+	for i := 0; i < capacity; i++ {
+		store.Put(fmt.Sprint(i))
+	}
+	if _, _, err := store.Chunk(first, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("oldest result should be evicted, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package stdio is the MCP STDIO transport with defensive framing. The SDK's
+// transport closes the session on the first frame it cannot decode; this one
+// logs the frame, answers with a JSON-RPC error and keeps reading.
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/last9/last9-mcp-server/internal/jsonrpcerr"
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+// Transport is an mcp.Transport over newline-delimited JSON-RPC on in and out.
+type Transport struct {
+	in          io.Reader
+	out         io.Writer
+	maxInBytes  int
+	maxOutBytes int
+}
+
+// NewTransport returns a transport reading frames from in and writing them to
+// out. Incoming frames larger than maxInBytes are discarded and outgoing
+// frames larger than maxOutBytes are logged; zero disables either limit.
+func NewTransport(in io.Reader, out io.Writer, maxInBytes, maxOutBytes int) *Transport {
+	return &Transport{in: in, out: out, maxInBytes: maxInBytes, maxOutBytes: maxOutBytes}
+}
+
+// Connect implements mcp.Transport.
+func (t *Transport) Connect(ctx context.Context) (mcp.Connection, error) {
+	w := &frameWriter{w: t.out, maxFrameBytes: t.maxOutBytes}
+	r := &frameReader{src: t.in, r: bufio.NewReader(t.in), reply: w, maxFrameBytes: t.maxInBytes}
+	return (&mcp.IOTransport{Reader: r, Writer: w}).Connect(ctx)
+}
+
+// frameReader passes through only frames the SDK can decode, one per line.
+type frameReader struct {
+	src           io.Reader
+	r             *bufio.Reader
+	reply         *frameWriter
+	maxFrameBytes int
+
+	pending []byte
+	err     error
+}
+
+func (r *frameReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, oversized, err := r.readLine()
+		switch {
+		case oversized:
+			logging.Logger("stdio").Warn("dropping oversized stdio frame", "max_bytes", r.maxFrameBytes)
+			r.reply.writeError(nil, jsonrpcerr.CodeInvalidRequest, fmt.Sprintf("message exceeds %d bytes", r.maxFrameBytes))
+		case len(line) > 0:
+			if frame, ok := r.check(line); ok {
+				r.pending = append(frame, '\n')
+			}
+		}
+		r.err = err
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *frameReader) Close() error {
+	if c, ok := r.src.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// readLine reads up to the next newline. Lines over the frame limit are
+// consumed without being buffered and reported as oversized.
+func (r *frameReader) readLine() (line []byte, oversized bool, err error) {
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if !oversized {
+			line = append(line, chunk...)
+			if r.maxFrameBytes > 0 && len(bytes.TrimSpace(line)) > r.maxFrameBytes {
+				oversized, line = true, nil
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		return line, oversized, err
+	}
+}
+
+// check validates one frame the way the SDK will decode it: a JSON-RPC
+// message or a non-empty batch of them. Invalid frames are answered with an
+// error response and dropped.
+func (r *frameReader) check(line []byte) ([]byte, bool) {
+	frame := bytes.TrimSpace(line)
+	if len(frame) == 0 {
+		return nil, false
+	}
+	if !json.Valid(frame) {
+		logging.Logger("stdio").Warn("dropping malformed stdio frame", "bytes", len(frame), "prefix", prefix(frame))
+		r.reply.writeError(nil, jsonrpcerr.CodeParseError, "Parse error")
+		return nil, false
+	}
+	var msgs []json.RawMessage
+	if frame[0] == '[' {
+		if err := json.Unmarshal(frame, &msgs); err != nil || len(msgs) == 0 {
+			logging.Logger("stdio").Warn("dropping invalid stdio batch", "prefix", prefix(frame))
+			r.reply.writeError(nil, jsonrpcerr.CodeInvalidRequest, "Invalid Request: empty or malformed batch")
+			return nil, false
+		}
+	} else {
+		msgs = []json.RawMessage{frame}
+	}
+	for _, msg := range msgs {
+		if _, err := jsonrpc.DecodeMessage(msg); err != nil {
+			logging.Logger("stdio").Warn("dropping invalid JSON-RPC message", "error", err, "prefix", prefix(msg))
+			r.reply.writeError(jsonrpcerr.RequestID(msg), jsonrpcerr.CodeInvalidRequest, "Invalid Request: "+err.Error())
+			return nil, false
+		}
+	}
+	return frame, true
+}
+
+// frameWriter serializes writes so error replies from the reader never
+// interleave with SDK frames.
+type frameWriter struct {
+	mu            sync.Mutex
+	w             io.Writer
+	maxFrameBytes int
+}
+
+func (w *frameWriter) Write(p []byte) (int, error) {
+	if w.maxFrameBytes > 0 && len(p) > w.maxFrameBytes {
+		logging.Logger("stdio").Warn("outgoing stdio frame exceeds max message size", "bytes", len(p), "max_bytes", w.maxFrameBytes)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// Close leaves the underlying writer open, like the SDK's stdio transport
+// does for stdout.
+func (w *frameWriter) Close() error { return nil }
+
+func (w *frameWriter) writeError(id json.RawMessage, code int, message string) {
+	data := jsonrpcerr.Marshal(id, code, message)
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		logging.Logger("stdio").Warn("failed to write stdio error response", "error", err)
+	}
+}
+
+// prefix returns the start of a frame for logs.
+func prefix(frame []byte) string {
+	const n = 80
+	if len(frame) > n {
+		return string(frame[:n]) + "..."
+	}
+	return string(frame)
+}
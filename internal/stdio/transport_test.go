@@ -0,0 +1,103 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/jsonrpcerr"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestFrameReader(t *testing.T) {
+	valid := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	input := strings.Join([]string{
+		"not json",
+		valid,
+		"",
+		`42`,
+		`{"jsonrpc":"2.0","id":"a","method":"tools/call","params":` + strings.Repeat(" ", 200) + `{}}`,
+		`[]`,
+		`{"jsonrpc":"1.0","id":9,"method":"ping"}`,
+		`[` + valid + `]`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	r := &frameReader{src: strings.NewReader(input), r: bufio.NewReaderSize(strings.NewReader(input), 16), reply: &frameWriter{w: &out}, maxFrameBytes: 150}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := valid + "\n[" + valid + "]\n"; string(got) != want {
+		t.Errorf("passed frames = %q, want %q", got, want)
+	}
+
+	var replies []struct {
+		ID    json.RawMessage `json:"id"`
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var reply struct {
+			ID    json.RawMessage `json:"id"`
+			Error struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &reply); err != nil {
+			t.Fatalf("invalid reply %q: %v", line, err)
+		}
+		replies = append(replies, reply)
+	}
+	wantCodes := []int{jsonrpcerr.CodeParseError, jsonrpcerr.CodeInvalidRequest, jsonrpcerr.CodeInvalidRequest, jsonrpcerr.CodeInvalidRequest, jsonrpcerr.CodeInvalidRequest}
+	if len(replies) != len(wantCodes) {
+		t.Fatalf("got %d error replies, want %d: %s", len(replies), len(wantCodes), out.String())
+	}
+	for i, reply := range replies {
+		if reply.Error.Code != wantCodes[i] {
+			t.Errorf("reply %d code = %d, want %d", i, reply.Error.Code, wantCodes[i])
+		}
+	}
+	if string(replies[4].ID) != "9" {
+		t.Errorf("invalid message reply id = %s, want 9", replies[4].ID)
+	}
+}
+
+func TestTransportSurvivesMalformedFrames(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v0"}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() { _ = server.Run(ctx, NewTransport(inR, outW, 0, 0)) }()
+
+	lines := bufio.NewScanner(outR)
+	send := func(s string) {
+		if _, err := io.WriteString(inW, s+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := func() string {
+		if !lines.Scan() {
+			t.Fatalf("no response: %v", lines.Err())
+		}
+		return lines.Text()
+	}
+
+	send("{garbage")
+	if got := next(); !strings.Contains(got, `"code":-32700`) {
+		t.Fatalf("expected parse error, got %s", got)
+	}
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"c","version":"1"}}}`)
+	if got := next(); !strings.Contains(got, `"id":1`) || !strings.Contains(got, `"serverInfo"`) {
+		t.Fatalf("expected initialize result after malformed frame, got %s", got)
+	}
+	inW.Close()
+}
@@ -9,8 +9,8 @@ import (
 	"sort"
 	"time"
 
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -94,19 +94,12 @@ func fetchLogLabels(ctx context.Context, client *http.Client, cfg models.Config,
 // NewGetLogAttributesHandler creates a handler for fetching log attributes
 func NewGetLogAttributesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetLogAttributesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetLogAttributesArgs) (*mcp.CallToolResult, any, error) {
-		params := make(map[string]interface{})
-		if args.LookbackMinutes > 0 {
-			params["lookback_minutes"] = args.LookbackMinutes
-		}
-		if args.StartTimeISO != "" {
-			params["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			params["end_time_iso"] = args.EndTimeISO
-		}
-
 		const defaultLogAttributesLookback = 15
-		startTimeParsed, endTimeParsed, err := utils.GetTimeRange(params, defaultLogAttributesLookback)
+		startTimeParsed, endTimeParsed, err := utils.TimeRange{
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: float64(args.LookbackMinutes),
+		}.Resolve(defaultLogAttributesLookback)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to parse time range: %w", err)
 		}
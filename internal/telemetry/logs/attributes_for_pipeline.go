@@ -11,9 +11,9 @@ import (
 	"sort"
 	"strings"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -477,19 +477,12 @@ func NewGetLogAttributesForPipelineHandler(client *http.Client, cfg models.Confi
 			return nil, nil, fmt.Errorf("pipeline parameter is required. Provide at least one filter stage to scope discovery, e.g. [{\"type\":\"filter\",\"query\":{\"$eq\":[\"ServiceName\",\"<service>\"]}}]")
 		}
 
-		params := make(map[string]interface{})
-		if args.LookbackMinutes > 0 {
-			params["lookback_minutes"] = args.LookbackMinutes
-		}
-		if args.StartTimeISO != "" {
-			params["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			params["end_time_iso"] = args.EndTimeISO
-		}
-
 		const defaultLogAttributesLookback = 15
-		startTimeParsed, endTimeParsed, err := utils.GetTimeRange(params, defaultLogAttributesLookback)
+		startTimeParsed, endTimeParsed, err := utils.TimeRange{
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: float64(args.LookbackMinutes),
+		}.Resolve(defaultLogAttributesLookback)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to parse time range: %w", err)
 		}
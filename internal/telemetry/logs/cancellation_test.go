@@ -0,0 +1,36 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestGetLogsHonoursCancellation(t *testing.T) {
+	utils.AssertCancelsPromptly(t, func(ctx context.Context, baseURL string) error {
+		handler := NewGetLogsHandler(http.DefaultClient, testLogsConfig(baseURL))
+		_, _, err := handler(ctx, &mcp.CallToolRequest{}, GetLogsArgs{
+			LogjsonQuery: []map[string]interface{}{
+				{
+					"type":  "filter",
+					"query": map[string]interface{}{"$contains": []interface{}{"Body", "error"}},
+				},
+			},
+			StartTimeISO: "1970-01-01T00:00:00Z",
+			EndTimeISO:   "1970-01-01T01:30:00Z",
+		})
+		return err
+	})
+}
+
+func TestGetServiceLogsHonoursCancellation(t *testing.T) {
+	utils.AssertCancelsPromptly(t, func(ctx context.Context, baseURL string) error {
+		handler := NewGetServiceLogsHandler(http.DefaultClient, testLogsConfig(baseURL))
+		_, _, err := handler(ctx, &mcp.CallToolRequest{}, GetServiceLogsArgs{ServiceName: "checkout"})
+		return err
+	})
+}
@@ -8,7 +8,7 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
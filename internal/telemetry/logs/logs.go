@@ -5,17 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
-	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/export"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -31,6 +31,7 @@ type GetLogsArgs struct {
 	LookbackMinutes int                      `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 5, minimum: 1)"`
 	Limit           int                      `json:"limit,omitempty" jsonschema:"Maximum number of rows to return (optional, default: 5000 for chunked raw queries)"`
 	Index           string                   `json:"index,omitempty" jsonschema:"Optional log index in the form physical_index:<name> or rehydration_index:<block_name>. Omit this when the user did not specify an index."`
+	Export          *export.Options          `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
 }
 
 // NewGetLogsHandler creates a handler for getting logs using logjson_query parameter
@@ -96,18 +97,10 @@ func handleLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Con
 }
 
 func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Config, logjsonQuery interface{}, startTime, endTime int64, args GetLogsArgs) (map[string]interface{}, error) {
-	chunkingDebug := chunkingDebugEnabled()
+	logger := logging.Logger("logs")
 
 	if !shouldChunkGetLogsQuery(logjsonQuery) {
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_logs chunking disabled start_ms=%d end_ms=%d limit=%d index=%q",
-				startTime,
-				endTime,
-				args.Limit,
-				args.Index,
-			)
-		}
+		logger.Debug("get_logs chunking disabled", "start_ms", startTime, "end_ms", endTime, "limit", args.Limit, "index", args.Index)
 		result, err := executeLogJSONQuery(ctx, client, cfg, logjsonQuery, startTime, endTime, args.Limit, args.Index)
 		if err != nil {
 			return nil, err
@@ -133,42 +126,17 @@ func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Conf
 	})
 	chunks := utils.GetAdaptiveChunks(startTime, endTime, adaptiveCfg)
 	if len(chunks) == 0 {
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_logs produced no chunks start_ms=%d end_ms=%d limit=%d index=%q",
-				startTime,
-				endTime,
-				args.Limit,
-				args.Index,
-			)
-		}
+		logger.Debug("get_logs produced no chunks", "start_ms", startTime, "end_ms", endTime, "limit", args.Limit, "index", args.Index)
 		return emptyStreamsResponse(), nil
 	}
 
 	effectiveLimit := effectiveGetLogsChunkLimit(cfg, args.Limit)
 
-	if chunkingDebug {
-		log.Printf(
-			"[chunking] get_logs chunking enabled chunks=%d max_parallel=%d chunk_size_ms=%d start_ms=%d end_ms=%d requested_limit=%d effective_limit=%d index=%q reason=%q",
-			len(chunks),
-			adaptiveCfg.MaxParallelChunks,
-			adaptiveCfg.ChunkSizeMs,
-			startTime,
-			endTime,
-			args.Limit,
-			effectiveLimit,
-			args.Index,
-			adaptiveCfg.Reason,
-		)
-		// Preserved from the pre-refactor format so existing log greps
-		// matching `requested limit capped` keep working.
-		if args.Limit > 0 && args.Limit > effectiveLimit {
-			log.Printf(
-				"[chunking] get_logs requested limit capped requested_limit=%d configured_max=%d",
-				args.Limit,
-				effectiveLimit,
-			)
-		}
+	logger.Debug("get_logs chunking enabled", "chunks", len(chunks), "max_parallel", adaptiveCfg.MaxParallelChunks, "chunk_size_ms", adaptiveCfg.ChunkSizeMs, "start_ms", startTime, "end_ms", endTime, "requested_limit", args.Limit, "effective_limit", effectiveLimit, "index", args.Index, "reason", adaptiveCfg.Reason)
+	// Preserved from the pre-refactor format so existing log greps
+	// matching `requested limit capped` keep working.
+	if args.Limit > 0 && args.Limit > effectiveLimit {
+		logger.Debug("get_logs requested limit capped", "requested_limit", args.Limit, "configured_max", effectiveLimit)
 	}
 
 	// Known over-fetch: each chunk asks the upstream for effectiveLimit rows,
@@ -202,7 +170,7 @@ func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Conf
 		chunkNum := r.Index + 1
 
 		if r.Err != nil {
-			slog.Error("chunked log query failed",
+			logging.Logger("logs").Error("chunked log query failed",
 				"tool", "get_logs",
 				"chunk_index", chunkNum,
 				"total_chunks", len(chunks),
@@ -218,7 +186,7 @@ func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Conf
 
 		resultType, items, err := extractResultItems(r.Value)
 		if err != nil {
-			slog.Error("chunked log query parse failed",
+			logging.Logger("logs").Error("chunked log query parse failed",
 				"tool", "get_logs",
 				"chunk_index", chunkNum,
 				"total_chunks", len(chunks),
@@ -231,7 +199,7 @@ func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Conf
 		}
 
 		if resultType != "streams" {
-			slog.Error("chunked log query unexpected result_type",
+			logging.Logger("logs").Error("chunked log query unexpected result_type",
 				"tool", "get_logs",
 				"chunk_index", chunkNum,
 				"total_chunks", len(chunks),
@@ -259,16 +227,7 @@ func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Conf
 			mergedItems = append(mergedItems, items...)
 		}
 
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_logs chunk result chunk=%d/%d kept_entries=%d remaining_limit=%d truncated_at_limit=%t",
-				chunkNum,
-				len(chunks),
-				kept,
-				remaining,
-				truncatedAtLimit,
-			)
-		}
+		logger.Debug("get_logs chunk result", "chunk", chunkNum, "chunks", len(chunks), "kept_entries", kept, "remaining_limit", remaining, "truncated_at_limit", truncatedAtLimit)
 	}
 
 	if baseResponse == nil {
@@ -287,28 +246,11 @@ func fetchLogJSONQuery(ctx context.Context, client *http.Client, cfg models.Conf
 	data["resultType"] = "streams"
 	if partialErr != nil {
 		annotatePartialGetLogsResponse(baseResponse, partialErr, len(chunks), countLogEntriesInResultItems(mergedItems))
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_logs chunking partial chunks=%d returned_entries=%d start_ms=%d end_ms=%d err=%v",
-				len(chunks),
-				countLogEntriesInResultItems(mergedItems),
-				startTime,
-				endTime,
-				partialErr,
-			)
-		}
+		logger.Debug("get_logs chunking partial", "chunks", len(chunks), "returned_entries", countLogEntriesInResultItems(mergedItems), "start_ms", startTime, "end_ms", endTime, "error", partialErr)
 		return baseResponse, nil
 	}
 
-	if chunkingDebug {
-		log.Printf(
-			"[chunking] get_logs chunking complete chunks=%d returned_entries=%d start_ms=%d end_ms=%d",
-			len(chunks),
-			countLogEntriesInResultItems(mergedItems),
-			startTime,
-			endTime,
-		)
-	}
+	logger.Debug("get_logs chunking complete", "chunks", len(chunks), "returned_entries", countLogEntriesInResultItems(mergedItems), "start_ms", startTime, "end_ms", endTime)
 
 	return baseResponse, nil
 }
@@ -481,18 +423,11 @@ func parseTimeRangeFromArgs(args GetLogsArgs) (int64, int64, error) {
 }
 
 func parseTimeRangeFromArgsAt(args GetLogsArgs, now time.Time) (int64, int64, error) {
-	params := make(map[string]interface{})
-	if args.LookbackMinutes > 0 {
-		params["lookback_minutes"] = args.LookbackMinutes
-	}
-	if args.StartTimeISO != "" {
-		params["start_time_iso"] = args.StartTimeISO
-	}
-	if args.EndTimeISO != "" {
-		params["end_time_iso"] = args.EndTimeISO
-	}
-
-	startTime, endTime, err := utils.GetTimeRangeAt(params, defaultGetLogsLookbackMinutes, now)
+	startTime, endTime, err := utils.TimeRange{
+		StartTimeISO:    args.StartTimeISO,
+		EndTimeISO:      args.EndTimeISO,
+		LookbackMinutes: float64(args.LookbackMinutes),
+	}.ResolveAt(defaultGetLogsLookbackMinutes, now)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -9,7 +9,7 @@ import (
 	"strings"
 	"testing"
 
-	"last9-mcp/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/constants"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -4,16 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/export"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -40,15 +40,16 @@ type LogEntry struct {
 
 // GetServiceLogsArgs represents the input arguments for the get_service_logs tool
 type GetServiceLogsArgs struct {
-	ServiceName     string   `json:"service_name" jsonschema:"Name of the service to retrieve logs for (e.g. api) (required)"`
-	StartTimeISO    string   `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2023-10-01T10:00:00Z). If not provided lookback_minutes is used"`
-	EndTimeISO      string   `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2023-10-01T11:00:00Z). If not provided current time is used"`
-	LookbackMinutes int      `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from current time if start_time_iso not provided (default: 60, minimum: 1)"`
-	Limit           int      `json:"limit,omitempty" jsonschema:"Maximum number of log entries to return (optional, default: 20)"`
-	SeverityFilters []string `json:"severity_filters,omitempty" jsonschema:"Array of severity patterns to match (uses OR logic) (e.g. [error warn])"`
-	BodyFilters     []string `json:"body_filters,omitempty" jsonschema:"Array of message content patterns to match (uses OR logic) (e.g. [timeout failed])"`
-	Env             string   `json:"env,omitempty" jsonschema:"Environment to filter by. Empty string if environment is unknown (e.g. production)"`
-	Index           string   `json:"index,omitempty" jsonschema:"Optional log index in the form physical_index:<name> or rehydration_index:<block_name>. Omit this when the user did not specify an index."`
+	ServiceName     string          `json:"service_name" jsonschema:"Name of the service to retrieve logs for (e.g. api) (required)"`
+	StartTimeISO    string          `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2023-10-01T10:00:00Z). If not provided lookback_minutes is used"`
+	EndTimeISO      string          `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2023-10-01T11:00:00Z). If not provided current time is used"`
+	LookbackMinutes int             `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from current time if start_time_iso not provided (default: 60, minimum: 1)"`
+	Limit           int             `json:"limit,omitempty" jsonschema:"Maximum number of log entries to return (optional, default: 20)"`
+	SeverityFilters []string        `json:"severity_filters,omitempty" jsonschema:"Array of severity patterns to match (uses OR logic) (e.g. [error warn])"`
+	BodyFilters     []string        `json:"body_filters,omitempty" jsonschema:"Array of message content patterns to match (uses OR logic) (e.g. [timeout failed])"`
+	Env             string          `json:"env,omitempty" jsonschema:"Environment to filter by. Empty string if environment is unknown (e.g. production)"`
+	Index           string          `json:"index,omitempty" jsonschema:"Optional log index in the form physical_index:<name> or rehydration_index:<block_name>. Omit this when the user did not specify an index."`
+	Export          *export.Options `json:"export,omitempty" jsonschema:"Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)"`
 }
 
 // NewGetServiceLogsHandler creates a new handler for the get_service_logs tool
@@ -70,17 +71,10 @@ func NewGetServiceLogsHandler(client *http.Client, cfg models.Config) func(conte
 			lookbackMinutes = 60
 		}
 
-		// Convert args to map for GetTimeRange utility
-		params := make(map[string]interface{})
-		if args.StartTimeISO != "" {
-			params["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			params["end_time_iso"] = args.EndTimeISO
-		}
-
-		// Get time range using existing utility
-		startTime, endTime, err := utils.GetTimeRange(params, lookbackMinutes)
+		startTime, endTime, err := utils.TimeRange{
+			StartTimeISO: args.StartTimeISO,
+			EndTimeISO:   args.EndTimeISO,
+		}.Resolve(lookbackMinutes)
 		if err != nil {
 			return nil, nil, fmt.Errorf("invalid time range: %w", err)
 		}
@@ -239,22 +233,8 @@ func fetchServiceLogs(ctx context.Context, client *http.Client, cfg models.Confi
 		Pipeline: logjsonQuery,
 	})
 	chunks := utils.GetAdaptiveChunks(startMs, endMs, adaptiveCfg)
-	chunkingDebug := chunkingDebugEnabled()
-
-	if chunkingDebug {
-		log.Printf(
-			"[chunking] get_service_logs chunking enabled service=%q chunks=%d max_parallel=%d chunk_size_ms=%d start_ms=%d end_ms=%d limit=%d index=%q reason=%q",
-			service,
-			len(chunks),
-			adaptiveCfg.MaxParallelChunks,
-			adaptiveCfg.ChunkSizeMs,
-			startMs,
-			endMs,
-			limit,
-			index,
-			adaptiveCfg.Reason,
-		)
-	}
+	logger := logging.Logger("logs")
+	logger.Debug("get_service_logs chunking enabled", "service", service, "chunks", len(chunks), "max_parallel", adaptiveCfg.MaxParallelChunks, "chunk_size_ms", adaptiveCfg.ChunkSizeMs, "start_ms", startMs, "end_ms", endMs, "limit", limit, "index", index, "reason", adaptiveCfg.Reason)
 
 	// Known over-fetch (regression from the pre-PR serial loop): each chunk
 	// asks the upstream for the full limit, not "remaining = limit - len(logs)".
@@ -285,7 +265,7 @@ func fetchServiceLogs(ctx context.Context, client *http.Client, cfg models.Confi
 		chunkNum := r.Index + 1
 
 		if r.Err != nil {
-			slog.Error("chunked service_logs query failed",
+			logging.Logger("logs").Error("chunked service_logs query failed",
 				"tool", "get_service_logs",
 				"service", service,
 				"chunk_index", chunkNum,
@@ -318,17 +298,7 @@ func fetchServiceLogs(ctx context.Context, client *http.Client, cfg models.Confi
 		}
 		logs = append(logs, chunkLogs...)
 
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_service_logs chunk result service=%q chunk=%d/%d kept_entries=%d remaining_limit=%d truncated_at_limit=%t",
-				service,
-				chunkNum,
-				len(chunks),
-				len(chunkLogs),
-				limit-len(logs),
-				truncatedAtLimit,
-			)
-		}
+		logger.Debug("get_service_logs chunk result", "service", service, "chunk", chunkNum, "chunks", len(chunks), "kept_entries", len(chunkLogs), "remaining_limit", limit-len(logs), "truncated_at_limit", truncatedAtLimit)
 	}
 
 	// Hard-error only when NO chunk succeeded. If even one chunk returned a
@@ -338,16 +308,7 @@ func fetchServiceLogs(ctx context.Context, client *http.Client, cfg models.Confi
 		return nil, partialErr
 	}
 
-	if chunkingDebug {
-		log.Printf(
-			"[chunking] get_service_logs chunking complete service=%q returned_entries=%d start_ms=%d end_ms=%d partial=%t",
-			service,
-			len(logs),
-			startMs,
-			endMs,
-			partialErr != nil,
-		)
-	}
+	logger.Debug("get_service_logs chunking complete", "service", service, "returned_entries", len(logs), "start_ms", startMs, "end_ms", endMs, "partial", partialErr != nil)
 
 	response := &ServiceLogsResponse{
 		Service:   service,
@@ -375,17 +336,11 @@ func fetchServiceLogsChunk(ctx context.Context, client *http.Client, cfg models.
 
 func parseServiceLogEntries(apiResponse map[string]any, service string) []LogEntry {
 	logs := make([]LogEntry, 0)
-	chunkingDebug := chunkingDebugEnabled()
+	logger := logging.Logger("logs")
 
 	data, ok := apiResponse["data"].(map[string]any)
 	if !ok {
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_service_logs parse missing data object service=%q response=%#v",
-				service,
-				apiResponse,
-			)
-		}
+		logger.Debug("get_service_logs parse missing data object", "service", service, "response", apiResponse)
 		return logs
 	}
 
@@ -395,38 +350,20 @@ func parseServiceLogEntries(apiResponse map[string]any, service string) []LogEnt
 		if resultType == "streams" {
 			return logs
 		}
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_service_logs parse missing result array service=%q data=%#v",
-				service,
-				data,
-			)
-		}
+		logger.Debug("get_service_logs parse missing result array", "service", service, "data", data)
 		return logs
 	}
 
 	result, ok := rawResult.([]any)
 	if !ok {
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_service_logs parse missing result array service=%q data=%#v",
-				service,
-				data,
-			)
-		}
+		logger.Debug("get_service_logs parse missing result array", "service", service, "data", data)
 		return logs
 	}
 
 	for _, item := range result {
 		streamData, ok := item.(map[string]any)
 		if !ok {
-			if chunkingDebug {
-				log.Printf(
-					"[chunking] get_service_logs parse skipped non-stream item service=%q item=%#v",
-					service,
-					item,
-				)
-			}
+			logger.Debug("get_service_logs parse skipped non-stream item", "service", service, "item", item)
 			continue
 		}
 
@@ -434,50 +371,28 @@ func parseServiceLogEntries(apiResponse map[string]any, service string) []LogEnt
 		if stream, exists := streamData["stream"].(map[string]any); exists {
 			streamMetadata = stream
 		} else {
-			if chunkingDebug {
-				log.Printf(
-					"[chunking] get_service_logs parse missing stream metadata service=%q item=%#v",
-					service,
-					item,
-				)
-			}
+			logger.Debug("get_service_logs parse missing stream metadata", "service", service, "item", item)
 		}
 
 		var severity any
 		hasSeverity := false
 		if streamMetadata != nil {
 			severity, hasSeverity = streamMetadata["severity"]
-			if !hasSeverity && chunkingDebug {
-				log.Printf(
-					"[chunking] get_service_logs parse missing severity service=%q stream=%#v",
-					service,
-					streamMetadata,
-				)
+			if !hasSeverity {
+				logger.Debug("get_service_logs parse missing severity", "service", service, "stream", streamMetadata)
 			}
 		}
 
 		vals, ok := streamData["values"].([]any)
 		if !ok {
-			if chunkingDebug {
-				log.Printf(
-					"[chunking] get_service_logs parse missing values array service=%q item=%#v",
-					service,
-					item,
-				)
-			}
+			logger.Debug("get_service_logs parse missing values array", "service", service, "item", item)
 			continue
 		}
 
 		for _, val := range vals {
 			valArray, ok := val.([]any)
 			if !ok || len(valArray) < 2 {
-				if chunkingDebug {
-					log.Printf(
-						"[chunking] get_service_logs parse skipped malformed value service=%q value=%#v",
-						service,
-						val,
-					)
-				}
+				logger.Debug("get_service_logs parse skipped malformed value", "service", service, "value", val)
 				continue
 			}
 
@@ -3,8 +3,8 @@ package telemetry
 import (
 	"context"
 	"errors"
-	"log/slog"
-	"os"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
 
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
@@ -23,7 +23,8 @@ import (
 const mcpServiceName = "last9-mcp"
 
 // InitProviders initialises OTLP trace, metric, and log providers, registers
-// them globally, and wires slog.SetDefault so structured logs reach Last9.
+// them globally, and tees the process logger to OTLP so structured logs reach
+// Last9.
 // Exporters pick up OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_HEADERS
 // from the environment automatically.
 // Returns a shutdown function that must be called on process exit to flush buffers.
@@ -75,51 +76,11 @@ func InitProviders(ctx context.Context, version, tenant, clusterID string) (func
 	)
 	logGlobal.SetLoggerProvider(lp)
 
-	// Tee to both OTLP and stderr: slog.SetDefault in Go 1.21+ also redirects
-	// the standard log package, so stderr output must be preserved explicitly.
-	otelHandler := otelslog.NewHandler(mcpServiceName, otelslog.WithLoggerProvider(lp))
-	stderrHandler := slog.NewJSONHandler(os.Stderr, nil)
-	slog.SetDefault(slog.New(multiHandler{otelHandler, stderrHandler}))
+	// Tee to OTLP next to the stderr output set up by logging.Setup, under
+	// the same level filtering.
+	logging.AddHandler(otelslog.NewHandler(mcpServiceName, otelslog.WithLoggerProvider(lp)))
 
 	return func(ctx context.Context) error {
 		return errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx), lp.Shutdown(ctx))
 	}, nil
 }
-
-// multiHandler fans out slog records to multiple handlers.
-type multiHandler []slog.Handler
-
-func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	for _, h := range m {
-		if h.Enabled(ctx, level) {
-			return true
-		}
-	}
-	return false
-}
-
-func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
-	var errs []error
-	for _, h := range m {
-		if h.Enabled(ctx, r.Level) {
-			errs = append(errs, h.Handle(ctx, r.Clone()))
-		}
-	}
-	return errors.Join(errs...)
-}
-
-func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	out := make(multiHandler, len(m))
-	for i, h := range m {
-		out[i] = h.WithAttrs(attrs)
-	}
-	return out
-}
-
-func (m multiHandler) WithGroup(name string) slog.Handler {
-	out := make(multiHandler, len(m))
-	for i, h := range m {
-		out[i] = h.WithGroup(name)
-	}
-	return out
-}
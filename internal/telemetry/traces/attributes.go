@@ -9,9 +9,9 @@ import (
 	"sort"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -122,17 +122,11 @@ func FetchTraceAttributeNames(ctx context.Context, client *http.Client, cfg mode
 // NewGetTraceAttributesHandler creates a handler for fetching the global trace attributes.
 func NewGetTraceAttributesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetTraceAttributesArgs) (*mcp.CallToolResult, any, error) {
 	return func(ctx context.Context, req *mcp.CallToolRequest, args GetTraceAttributesArgs) (*mcp.CallToolResult, any, error) {
-		timeParams := map[string]interface{}{}
-		if args.LookbackMinutes > 0 {
-			timeParams["lookback_minutes"] = args.LookbackMinutes
-		}
-		if args.StartTimeISO != "" {
-			timeParams["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			timeParams["end_time_iso"] = args.EndTimeISO
-		}
-		startTimeValue, endTimeValue, err := utils.GetTimeRange(timeParams, 15)
+		startTimeValue, endTimeValue, err := utils.TimeRange{
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: float64(args.LookbackMinutes),
+		}.Resolve(15)
 		if err != nil {
 			return nil, nil, err
 		}
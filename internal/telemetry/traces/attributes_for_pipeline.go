@@ -9,9 +9,9 @@ import (
 	"net/url"
 	"sort"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -102,17 +102,11 @@ func NewGetTraceAttributesForPipelineHandler(client *http.Client, cfg models.Con
 			return nil, nil, fmt.Errorf("pipeline parameter is required. Provide at least one filter stage to scope discovery, e.g. [{\"type\":\"filter\",\"query\":{\"$eq\":[\"ServiceName\",\"<service>\"]}}]")
 		}
 
-		timeParams := map[string]interface{}{}
-		if args.LookbackMinutes > 0 {
-			timeParams["lookback_minutes"] = args.LookbackMinutes
-		}
-		if args.StartTimeISO != "" {
-			timeParams["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			timeParams["end_time_iso"] = args.EndTimeISO
-		}
-		startTimeValue, endTimeValue, err := utils.GetTimeRange(timeParams, 15)
+		startTimeValue, endTimeValue, err := utils.TimeRange{
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: float64(args.LookbackMinutes),
+		}.Resolve(15)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -12,9 +12,9 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -64,17 +64,10 @@ func NewGetExceptionsHandler(client *http.Client, cfg models.Config) func(contex
 			lookbackMinutes = int(args.LookbackMinutes)
 		}
 
-		// Prepare arguments map for GetTimeRange function
-		arguments := make(map[string]interface{})
-		if args.StartTimeISO != "" {
-			arguments["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			arguments["end_time_iso"] = args.EndTimeISO
-		}
-
-		// Get time range using the common utility
-		startTime, endTime, err := utils.GetTimeRange(arguments, lookbackMinutes)
+		startTime, endTime, err := utils.TimeRange{
+			StartTimeISO: args.StartTimeISO,
+			EndTimeISO:   args.EndTimeISO,
+		}.Resolve(lookbackMinutes)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -170,6 +163,7 @@ func NewGetExceptionsHandler(client *http.Client, cfg models.Config) func(contex
 				"span_name":              exceptionData.SpanName,
 				"timestamp":              lastSeen,
 				"exception_type":         exceptionData.ExceptionType,
+				"kind":                   models.ErrorKindException,
 				"exception_message":      "",
 				"exception_stacktrace":   "",
 				"exception_escaped":      nil,
@@ -0,0 +1,352 @@
+package traces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// exceptionSamplesDefault and exceptionSamplesMax bound the samples
+	// returned per source (spans and logs).
+	exceptionSamplesDefault = 5
+	exceptionSamplesMax     = 50
+	// exceptionStackLines is how many stack trace lines a sample keeps; the
+	// top frames are the ones that locate the failure.
+	exceptionStackLines = 15
+	// exceptionContextValueMax truncates long context attribute values.
+	exceptionContextValueMax = 200
+)
+
+// GetExceptionSamplesArgs defines the input for the get_exception_samples tool.
+type GetExceptionSamplesArgs struct {
+	ExceptionType   string `json:"exception_type" jsonschema:"(Required) Exception type as reported by get_exceptions or get_service_performance_details top_errors (e.g. java.net.SocketTimeoutException)"`
+	ServiceName     string `json:"service_name,omitempty" jsonschema:"Restrict samples to this service (e.g. checkout)"`
+	Env             string `json:"env,omitempty" jsonschema:"Environment (deployment.environment) to filter by (e.g. production)"`
+	Limit           int    `json:"limit,omitempty" jsonschema:"Samples to return from each of spans and logs (default: 5, maximum: 50)"`
+	StartTimeISO    string `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2026-02-09T15:04:05Z)"`
+	EndTimeISO      string `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2026-02-09T16:04:05Z)"`
+	LookbackMinutes int    `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1)"`
+}
+
+// ExceptionSpanSample is one span that recorded the exception.
+type ExceptionSpanSample struct {
+	Timestamp   string            `json:"timestamp"`
+	TraceID     string            `json:"trace_id"`
+	SpanID      string            `json:"span_id"`
+	ServiceName string            `json:"service_name"`
+	SpanName    string            `json:"span_name"`
+	Message     string            `json:"exception_message,omitempty"`
+	Stacktrace  string            `json:"exception_stacktrace,omitempty"`
+	Context     map[string]string `json:"context,omitempty"`
+}
+
+// ExceptionLogSample is one log line mentioning the exception.
+type ExceptionLogSample struct {
+	Timestamp   string            `json:"timestamp"`
+	ServiceName string            `json:"service_name,omitempty"`
+	Severity    string            `json:"severity,omitempty"`
+	Message     string            `json:"message"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	Context     map[string]string `json:"context,omitempty"`
+}
+
+// buildExceptionSpansPipeline selects spans with an exception event of the
+// given type.
+func buildExceptionSpansPipeline(args GetExceptionSamplesArgs) []map[string]interface{} {
+	conditions := []interface{}{
+		map[string]interface{}{"$eq": []interface{}{"events['exception.type']", args.ExceptionType}},
+	}
+	if args.ServiceName != "" {
+		conditions = append(conditions, map[string]interface{}{"$eq": []interface{}{"ServiceName", args.ServiceName}})
+	}
+	if args.Env != "" {
+		conditions = append(conditions, map[string]interface{}{"$eq": []interface{}{"resources['deployment.environment']", args.Env}})
+	}
+	return []map[string]interface{}{{"type": "filter", "query": map[string]interface{}{"$and": conditions}}}
+}
+
+// buildExceptionLogsPipeline selects log lines whose body mentions the
+// exception type, the way stack traces and error logs name it.
+func buildExceptionLogsPipeline(args GetExceptionSamplesArgs) []map[string]interface{} {
+	conditions := []interface{}{
+		map[string]interface{}{"$icontains": []interface{}{"Body", args.ExceptionType}},
+	}
+	if args.ServiceName != "" {
+		conditions = append(conditions, map[string]interface{}{"$eq": []interface{}{"ServiceName", args.ServiceName}})
+	}
+	if args.Env != "" {
+		conditions = append(conditions, map[string]interface{}{"$ieq": []interface{}{"resources['deployment.environment']", args.Env}})
+	}
+	return []map[string]interface{}{{"type": "filter", "query": map[string]interface{}{"$and": conditions}}}
+}
+
+// queryJSON executes a request built by do and decodes its JSON body.
+func queryJSON(do func() (*http.Response, error)) (map[string]interface{}, error) {
+	resp, err := do()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result, nil
+}
+
+// resultItems returns data.result of a query_range response.
+func resultItems(raw map[string]interface{}) []interface{} {
+	data, _ := raw["data"].(map[string]interface{})
+	items, _ := data["result"].([]interface{})
+	return items
+}
+
+// contextFields flattens attribute maps into string context, prefixing
+// resource attributes with resource. and truncating long values.
+func contextFields(into map[string]string, prefix string, attrs interface{}) {
+	m, ok := attrs.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k, v := range m {
+		s := fmt.Sprint(v)
+		if len(s) > exceptionContextValueMax {
+			s = s[:exceptionContextValueMax] + "..."
+		}
+		into[prefix+k] = s
+	}
+}
+
+// truncateStack keeps the first exceptionStackLines lines of a stack trace.
+func truncateStack(stack string) string {
+	lines := strings.Split(stack, "\n")
+	if len(lines) <= exceptionStackLines {
+		return stack
+	}
+	return strings.Join(lines[:exceptionStackLines], "\n") + fmt.Sprintf("\n... %d more lines", len(lines)-exceptionStackLines)
+}
+
+// spanExceptionEvent returns the message and stack trace of the span's
+// exception event matching exceptionType. Events arrive either as a list of
+// {Name, Attributes} objects or as the flattened Events.Attributes column.
+func spanExceptionEvent(span map[string]interface{}, exceptionType string) (message, stack string) {
+	var attrsList []interface{}
+	if events, ok := span["Events"].([]interface{}); ok {
+		for _, e := range events {
+			if ev, ok := e.(map[string]interface{}); ok {
+				attrsList = append(attrsList, ev["Attributes"])
+			}
+		}
+	} else if flat, ok := span["Events.Attributes"].([]interface{}); ok {
+		attrsList = flat
+	}
+	for _, a := range attrsList {
+		attrs, ok := a.(map[string]interface{})
+		if !ok || fmt.Sprint(attrs["exception.type"]) != exceptionType {
+			continue
+		}
+		message, _ = attrs["exception.message"].(string)
+		stack, _ = attrs["exception.stacktrace"].(string)
+		return message, truncateStack(stack)
+	}
+	return "", ""
+}
+
+func parseExceptionSpanSamples(raw map[string]interface{}, exceptionType string) []ExceptionSpanSample {
+	samples := []ExceptionSpanSample{}
+	for _, item := range resultItems(raw) {
+		span, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sample := ExceptionSpanSample{
+			Timestamp:   utils.ConvertTimestamp(span["Timestamp"]),
+			TraceID:     extractString(span, "TraceId"),
+			SpanID:      extractString(span, "SpanId"),
+			ServiceName: extractString(span, "ServiceName"),
+			SpanName:    extractString(span, "SpanName"),
+			Context:     map[string]string{},
+		}
+		sample.Message, sample.Stacktrace = spanExceptionEvent(span, exceptionType)
+		if sample.Message == "" {
+			sample.Message = extractString(span, "StatusMessage")
+		}
+		contextFields(sample.Context, "", span["SpanAttributes"])
+		contextFields(sample.Context, "resource.", span["ResourceAttributes"])
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func parseExceptionLogSamples(raw map[string]interface{}) []ExceptionLogSample {
+	samples := []ExceptionLogSample{}
+	for _, item := range resultItems(raw) {
+		streamData, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stream, _ := streamData["stream"].(map[string]interface{})
+		labels := map[string]string{}
+		contextFields(labels, "", stream)
+
+		values, _ := streamData["values"].([]interface{})
+		for _, v := range values {
+			pair, ok := v.([]interface{})
+			if !ok || len(pair) < 2 {
+				continue
+			}
+			sample := ExceptionLogSample{
+				Timestamp:   utils.ConvertTimestamp(pair[0]),
+				Message:     fmt.Sprint(pair[1]),
+				ServiceName: labels["service_name"],
+				Severity:    labels["severity"],
+				TraceID:     firstNonEmptyLabel(labels, "trace_id", "TraceId"),
+				Context:     map[string]string{},
+			}
+			for k, v := range labels {
+				switch k {
+				case "service_name", "severity", "trace_id", "TraceId":
+				default:
+					sample.Context[k] = v
+				}
+			}
+			samples = append(samples, sample)
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Timestamp > samples[j].Timestamp })
+	return samples
+}
+
+func firstNonEmptyLabel(labels map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := labels[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// NewGetExceptionSamplesHandler creates a handler that fetches sample spans
+// and log lines for one exception type, bridging exception counts from the
+// metrics-based tools to the concrete failures behind them.
+func NewGetExceptionSamplesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, GetExceptionSamplesArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args GetExceptionSamplesArgs) (*mcp.CallToolResult, any, error) {
+		args.ExceptionType = strings.TrimSpace(args.ExceptionType)
+		if args.ExceptionType == "" {
+			return nil, nil, fmt.Errorf("exception_type is required")
+		}
+
+		limit := args.Limit
+		if limit <= 0 {
+			limit = exceptionSamplesDefault
+		}
+		limit = min(limit, exceptionSamplesMax)
+
+		lookbackMinutes := args.LookbackMinutes
+		if lookbackMinutes == 0 {
+			lookbackMinutes = 60
+		}
+		startTime, endTime, err := utils.TimeRange{
+			StartTimeISO: args.StartTimeISO,
+			EndTimeISO:   args.EndTimeISO,
+		}.Resolve(lookbackMinutes)
+		if err != nil {
+			return nil, nil, err
+		}
+		startMs, endMs := startTime.UnixMilli(), endTime.UnixMilli()
+
+		spansPipeline := buildExceptionSpansPipeline(args)
+		logsPipeline := buildExceptionLogsPipeline(args)
+
+		var (
+			spansRaw, logsRaw map[string]interface{}
+			spansErr, logsErr error
+			wg                sync.WaitGroup
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			spansRaw, spansErr = queryJSON(func() (*http.Response, error) {
+				return utils.MakeTracesJSONQueryAPI(ctx, client, cfg, spansPipeline, startMs, endMs, limit)
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			logsRaw, logsErr = queryJSON(func() (*http.Response, error) {
+				return utils.MakeLogsJSONQueryAPI(ctx, client, cfg, logsPipeline, startMs, endMs, limit, "")
+			})
+		}()
+		wg.Wait()
+
+		if spansErr != nil && logsErr != nil {
+			return nil, nil, fmt.Errorf("failed to fetch exception samples: spans: %v; logs: %v", spansErr, logsErr)
+		}
+
+		spanSamples := []ExceptionSpanSample{}
+		logSamples := []ExceptionLogSample{}
+		var warnings []string
+		if spansErr != nil {
+			warnings = append(warnings, fmt.Sprintf("span samples unavailable: %v", spansErr))
+		} else {
+			spanSamples = parseExceptionSpanSamples(spansRaw, args.ExceptionType)
+		}
+		if logsErr != nil {
+			warnings = append(warnings, fmt.Sprintf("log samples unavailable: %v", logsErr))
+		} else {
+			logSamples = parseExceptionLogSamples(logsRaw)
+		}
+		if len(spanSamples) > limit {
+			spanSamples = spanSamples[:limit]
+		}
+		if len(logSamples) > limit {
+			logSamples = logSamples[:limit]
+		}
+
+		response := map[string]interface{}{
+			"exception_type": args.ExceptionType,
+			"service_name":   args.ServiceName,
+			"env":            args.Env,
+			"start_time":     startTime.Format("2006-01-02T15:04:05Z"),
+			"end_time":       endTime.Format("2006-01-02T15:04:05Z"),
+			"span_samples":   spanSamples,
+			"log_samples":    logSamples,
+		}
+		if len(spanSamples) == 0 && len(logSamples) == 0 {
+			warnings = append(warnings, "no spans or log lines matched; check the exact exception_type spelling with get_exceptions, or widen the time range")
+		}
+		if len(warnings) > 0 {
+			response["_warnings"] = warnings
+		}
+
+		jsonData, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+
+		dlBuilder := deeplink.NewBuilder(cfg.OrgSlug, cfg.ClusterID)
+		dashboardURL := dlBuilder.BuildTracesLink(startMs, endMs, spansPipeline, "", "")
+
+		return &mcp.CallToolResult{
+			Meta: deeplink.ToMeta(dashboardURL),
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(jsonData)},
+			},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,118 @@
+package traces
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestTruncateStack(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = "at frame"
+	}
+	got := truncateStack(strings.Join(lines, "\n"))
+	if n := strings.Count(got, "at frame"); n != exceptionStackLines {
+		t.Errorf("kept %d frames, want %d", n, exceptionStackLines)
+	}
+	if !strings.HasSuffix(got, "... 5 more lines") {
+		t.Errorf("truncated stack should say how many lines were dropped: %q", got)
+	}
+	if got := truncateStack("one\ntwo"); got != "one\ntwo" {
+		t.Errorf("short stack changed: %q", got)
+	}
+}
+
+func TestGetExceptionSamplesHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Pipeline []map[string]any `json:"pipeline"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		pipeline, _ := json.Marshal(body.Pipeline)
+
+		switch r.URL.Path {
+		case constants.EndpointTracesQueryRange:
+			if !strings.Contains(string(pipeline), `events['exception.type']`) || !strings.Contains(string(pipeline), `"checkout"`) {
+				t.Errorf("unexpected span pipeline: %s", pipeline)
+			}
+			io.WriteString(w, `{"data":{"result":[{
+				"Timestamp":"2026-01-20T10:05:00Z","TraceId":"t1","SpanId":"s1","ServiceName":"checkout","SpanName":"POST /orders",
+				"SpanAttributes":{"http.route":"/orders"},"ResourceAttributes":{"host.name":"node-1"},
+				"Events":[
+					{"Name":"log","Attributes":{"event":"retry"}},
+					{"Name":"exception","Attributes":{"exception.type":"TimeoutException","exception.message":"read timed out","exception.stacktrace":"at a\nat b"}}
+				]}]}}`)
+		case constants.EndpointLogsQueryRange:
+			if !strings.Contains(string(pipeline), `"$icontains":["Body","TimeoutException"]`) {
+				t.Errorf("unexpected log pipeline: %s", pipeline)
+			}
+			io.WriteString(w, `{"data":{"resultType":"streams","result":[{
+				"stream":{"service_name":"checkout","severity":"ERROR","trace_id":"t1","pod":"checkout-1"},
+				"values":[["1768903500000000000","TimeoutException: read timed out"]]}]}}`)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg := models.Config{
+		APIBaseURL: server.URL,
+		TokenManager: &auth.TokenManager{
+			AccessToken: "test-access-token",
+			ExpiresAt:   time.Now().Add(24 * time.Hour),
+		},
+	}
+	handler := NewGetExceptionSamplesHandler(server.Client(), cfg)
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetExceptionSamplesArgs{
+		ExceptionType: "TimeoutException",
+		ServiceName:   "checkout",
+	})
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	var payload struct {
+		Spans []ExceptionSpanSample `json:"span_samples"`
+		Logs  []ExceptionLogSample  `json:"log_samples"`
+	}
+	if err := json.Unmarshal([]byte(utils.GetTextContent(t, result)), &payload); err != nil {
+		t.Fatalf("failed to decode tool response: %v", err)
+	}
+	if len(payload.Spans) != 1 || len(payload.Logs) != 1 {
+		t.Fatalf("got %d span and %d log samples, want 1 each", len(payload.Spans), len(payload.Logs))
+	}
+	span := payload.Spans[0]
+	if span.TraceID != "t1" || span.Message != "read timed out" || span.Stacktrace != "at a\nat b" {
+		t.Errorf("span sample = %+v", span)
+	}
+	if span.Context["http.route"] != "/orders" || span.Context["resource.host.name"] != "node-1" {
+		t.Errorf("span context = %v", span.Context)
+	}
+	logSample := payload.Logs[0]
+	if logSample.TraceID != "t1" || logSample.Severity != "ERROR" || logSample.Context["pod"] != "checkout-1" {
+		t.Errorf("log sample = %+v", logSample)
+	}
+	if _, ok := logSample.Context["trace_id"]; ok {
+		t.Errorf("trace_id should not be repeated in context: %v", logSample.Context)
+	}
+}
+
+func TestGetExceptionSamplesHandler_RequiresExceptionType(t *testing.T) {
+	handler := NewGetExceptionSamplesHandler(http.DefaultClient, models.Config{})
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, GetExceptionSamplesArgs{ExceptionType: " "}); err == nil {
+		t.Fatal("expected error when exception_type is missing")
+	}
+}
@@ -12,10 +12,10 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -140,6 +140,9 @@ func TestGetExceptionsHandler_UsesFrontendPromQueries(t *testing.T) {
 	if first["exception_type"] != "NullPointerException" {
 		t.Fatalf("exceptions are not sorted by count descending: first=%v", first["exception_type"])
 	}
+	if first["kind"] != "exception" {
+		t.Fatalf("unexpected kind: got %v, want exception", first["kind"])
+	}
 	if got := first["count"].(float64); got != 12 {
 		t.Fatalf("unexpected first exception count: got %v, want 12", got)
 	}
@@ -29,6 +29,11 @@ func GetTracesInputSchema() map[string]interface{} {
 				"type":        []string{"integer", "null"},
 				"description": "Maximum number of traces to return (optional, default: 5000).",
 			},
+			"display_timezone": map[string]interface{}{
+				"type":        []string{"string", "null"},
+				"description": "IANA timezone (e.g. Asia/Kolkata) for human-readable *_local timestamps added next to epoch values. Overrides the server default display timezone.",
+			},
+			"export": exportSchema(),
 		},
 		"required": []string{"tracejson_query"},
 	}
@@ -196,3 +201,26 @@ func selectStageSchema() map[string]interface{} {
 		},
 	}
 }
+
+func exportSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        []string{"object", "null"},
+		"description": "Write the result to a file under the server export directory (LAST9_EXPORT_DIR) and return the file path instead of the data (optional)",
+		"properties": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"json", "csv"},
+				"description": "File format: json (default, the full result) or csv (one row per record; see field)",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path relative to the server's export directory (e.g. checkout/traces.json). Missing directories are created; an existing file is overwritten.",
+			},
+			"field": map[string]interface{}{
+				"type":        "string",
+				"description": "For csv: top-level result field holding the rows, when the result has more than one list",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
@@ -0,0 +1,126 @@
+package traces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SearchLimitDefault is the number of spans search_traces returns when no limit is given.
+const SearchLimitDefault = 20
+
+// searchTraceOperators maps search_traces comparison names to tracejson operators.
+var searchTraceOperators = map[string]string{
+	"eq":       "$eq",
+	"neq":      "$neq",
+	"contains": "$contains",
+	"regex":    "$regex",
+}
+
+// TraceAttributeFilter is one attribute condition for search_traces.
+type TraceAttributeFilter struct {
+	Key   string `json:"key" jsonschema:"Attribute to match (required): a raw span attribute name (e.g. user_id, http.target), resource_<name> for a resource attribute, a top-level field (SpanName, StatusCode), or an explicit filter field such as attributes['order.id']"`
+	Value string `json:"value" jsonschema:"Value to compare against (required). For op=regex this is a regular expression."`
+	Op    string `json:"op,omitempty" jsonschema:"Comparison: eq (default), neq, contains or regex"`
+}
+
+// SearchTracesArgs defines the input for the search_traces tool.
+type SearchTracesArgs struct {
+	Attributes      []TraceAttributeFilter `json:"attributes" jsonschema:"Attribute conditions, all of which must match (required, at least one)"`
+	ServiceName     string                 `json:"service_name,omitempty" jsonschema:"Restrict to spans from this service"`
+	Env             string                 `json:"env,omitempty" jsonschema:"Environment (deployment.environment) to filter by"`
+	StartTimeISO    string                 `json:"start_time_iso,omitempty" jsonschema:"Start time in RFC3339/ISO8601 format (e.g. 2026-02-09T15:04:05Z)"`
+	EndTimeISO      string                 `json:"end_time_iso,omitempty" jsonschema:"End time in RFC3339/ISO8601 format (e.g. 2026-02-09T16:04:05Z)"`
+	LookbackMinutes int                    `json:"lookback_minutes,omitempty" jsonschema:"Number of minutes to look back from now (default: 60, minimum: 1)"`
+	Limit           int                    `json:"limit,omitempty" jsonschema:"Maximum number of spans to return (optional, default: 20)"`
+}
+
+// searchFilterField resolves a user-supplied attribute key to a tracejson
+// filter field. Keys already in bracket form are passed through; everything
+// else goes through the same mapping get_trace_attributes uses.
+func searchFilterField(key string) string {
+	if strings.Contains(key, "['") {
+		return key
+	}
+	return enrichAttribute(key).FilterField
+}
+
+// buildSearchTracesPipeline turns search_traces arguments into a single-stage
+// tracejson filter pipeline.
+func buildSearchTracesPipeline(args SearchTracesArgs) ([]map[string]interface{}, error) {
+	if len(args.Attributes) == 0 {
+		return nil, errors.New("at least one attribute filter is required; use get_service_traces to list traces for a service without attribute filters")
+	}
+
+	var conditions []interface{}
+	if args.ServiceName != "" {
+		conditions = append(conditions, map[string]interface{}{"$eq": []interface{}{"ServiceName", args.ServiceName}})
+	}
+	if args.Env != "" {
+		conditions = append(conditions, map[string]interface{}{"$eq": []interface{}{"resources['deployment.environment']", args.Env}})
+	}
+
+	for i, attr := range args.Attributes {
+		key := strings.TrimSpace(attr.Key)
+		if key == "" {
+			return nil, fmt.Errorf("attributes[%d].key is required", i)
+		}
+		opName := strings.ToLower(strings.TrimSpace(attr.Op))
+		if opName == "" {
+			opName = "eq"
+		}
+		op, ok := searchTraceOperators[opName]
+		if !ok {
+			return nil, fmt.Errorf("attributes[%d].op %q is not supported; use eq, neq, contains or regex", i, attr.Op)
+		}
+		if op == "$regex" {
+			if _, err := regexp.Compile(attr.Value); err != nil {
+				return nil, fmt.Errorf("attributes[%d].value is not a valid regular expression: %w", i, err)
+			}
+		}
+		conditions = append(conditions, map[string]interface{}{op: []interface{}{searchFilterField(key), attr.Value}})
+	}
+
+	return []map[string]interface{}{{
+		"type":  "filter",
+		"query": map[string]interface{}{"$and": conditions},
+	}}, nil
+}
+
+// NewSearchTracesHandler creates a handler that finds spans by attribute values
+// by building a tracejson pipeline and running it through the get_traces path.
+func NewSearchTracesHandler(client *http.Client, cfg models.Config) func(context.Context, *mcp.CallToolRequest, SearchTracesArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args SearchTracesArgs) (*mcp.CallToolResult, any, error) {
+		pipeline, err := buildSearchTracesPipeline(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := sanitizeTraceJSONQuery(pipeline); err != nil {
+			return nil, nil, err
+		}
+
+		limit := args.Limit
+		if limit <= 0 {
+			limit = SearchLimitDefault
+		}
+
+		result, err := handleTraceJSONQuery(ctx, client, cfg, pipeline, GetTracesArgs{
+			TracejsonQuery:  pipeline,
+			StartTimeISO:    args.StartTimeISO,
+			EndTimeISO:      args.EndTimeISO,
+			LookbackMinutes: args.LookbackMinutes,
+			Limit:           limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, nil, nil
+	}
+}
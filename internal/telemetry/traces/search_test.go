@@ -0,0 +1,123 @@
+package traces
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestBuildSearchTracesPipeline(t *testing.T) {
+	pipeline, err := buildSearchTracesPipeline(SearchTracesArgs{
+		ServiceName: "checkout",
+		Env:         "prod",
+		Attributes: []TraceAttributeFilter{
+			{Key: "user_id", Value: "u-42"},
+			{Key: "http.target", Value: "^/orders/[0-9]+$", Op: "regex"},
+			{Key: "resource_k8s.pod.name", Value: "checkout-7", Op: "contains"},
+			{Key: "attributes['order.id']", Value: "o-1", Op: "NEQ"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildSearchTracesPipeline error = %v", err)
+	}
+
+	want := []map[string]interface{}{{
+		"type": "filter",
+		"query": map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"$eq": []interface{}{"ServiceName", "checkout"}},
+			map[string]interface{}{"$eq": []interface{}{"resources['deployment.environment']", "prod"}},
+			map[string]interface{}{"$eq": []interface{}{"attributes['user_id']", "u-42"}},
+			map[string]interface{}{"$regex": []interface{}{"attributes['http.target']", "^/orders/[0-9]+$"}},
+			map[string]interface{}{"$contains": []interface{}{"resources['k8s.pod.name']", "checkout-7"}},
+			map[string]interface{}{"$neq": []interface{}{"attributes['order.id']", "o-1"}},
+		}},
+	}}
+	if !reflect.DeepEqual(pipeline, want) {
+		t.Errorf("pipeline mismatch:\ngot  %v\nwant %v", pipeline, want)
+	}
+	if err := sanitizeTraceJSONQuery(pipeline); err != nil {
+		t.Errorf("generated pipeline fails validation: %v", err)
+	}
+}
+
+func TestBuildSearchTracesPipeline_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args SearchTracesArgs
+		want string
+	}{
+		{"no attributes", SearchTracesArgs{ServiceName: "svc"}, "at least one attribute"},
+		{"empty key", SearchTracesArgs{Attributes: []TraceAttributeFilter{{Value: "x"}}}, "attributes[0].key"},
+		{"bad op", SearchTracesArgs{Attributes: []TraceAttributeFilter{{Key: "a", Value: "x", Op: "gt"}}}, "not supported"},
+		{"bad regex", SearchTracesArgs{Attributes: []TraceAttributeFilter{{Key: "a", Value: "(", Op: "regex"}}}, "regular expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildSearchTracesPipeline(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchTracesHandler(t *testing.T) {
+	var gotBody map[string]interface{}
+	var gotLimit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, createMockTraceResponse(2))
+	}))
+	defer server.Close()
+
+	cfg := models.Config{
+		APIBaseURL: server.URL,
+		Region:     "ap-south-1",
+		TokenManager: &auth.TokenManager{
+			AccessToken: "mock-access-token-for-testing",
+			ExpiresAt:   time.Now().Add(365 * 24 * time.Hour),
+		},
+	}
+
+	now := time.Now().UTC()
+	handler := NewSearchTracesHandler(server.Client(), cfg)
+	result, _, err := handler(context.Background(), &mcp.CallToolRequest{}, SearchTracesArgs{
+		Attributes:   []TraceAttributeFilter{{Key: "user_id", Value: "u-42"}},
+		StartTimeISO: now.Add(-5 * time.Minute).Format(time.RFC3339),
+		EndTimeISO:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if len(result.Content) == 0 {
+		t.Fatal("expected content in result")
+	}
+	if gotLimit != "20" {
+		t.Errorf("limit = %q, want default 20", gotLimit)
+	}
+	if !strings.Contains(mustJSON(t, gotBody["pipeline"]), `attributes['user_id']`) {
+		t.Errorf("request pipeline = %v, want user_id filter", gotBody["pipeline"])
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -13,10 +13,10 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -222,17 +222,10 @@ func GetServiceTracesHandler(client *http.Client, cfg models.Config) func(contex
 			return nil, nil, err
 		}
 
-		// Prepare arguments map for GetTimeRange function
-		arguments := make(map[string]interface{})
-		if args.StartTimeISO != "" {
-			arguments["start_time_iso"] = args.StartTimeISO
-		}
-		if args.EndTimeISO != "" {
-			arguments["end_time_iso"] = args.EndTimeISO
-		}
-
-		// Get time range
-		startTime, endTime, err := utils.GetTimeRange(arguments, queryParams.LookbackMinutes)
+		startTime, endTime, err := utils.TimeRange{
+			StartTimeISO: args.StartTimeISO,
+			EndTimeISO:   args.EndTimeISO,
+		}.Resolve(queryParams.LookbackMinutes)
 		if err != nil {
 			return nil, nil, err
 		}
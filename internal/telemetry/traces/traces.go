@@ -5,16 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
-	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/deeplink"
-	"last9-mcp/internal/models"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/deeplink"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -86,19 +85,11 @@ func handleTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.C
 // and truncated to the effective limit. An exact trace_id lookup short-
 // circuits chunking entirely (see extractExactTraceIDLookup).
 func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Config, tracejsonQuery interface{}, startMs, endMs int64, args GetTracesArgs) (map[string]interface{}, error) {
-	chunkingDebug := chunkingDebugEnabled()
+	logger := logging.Logger("traces")
 	effectiveLimit := effectiveGetTracesLimit(cfg, args.Limit)
 
 	if traceID, ok := extractExactTraceIDLookup(args.TracejsonQuery); ok {
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_traces exact trace_id lookup detected trace_id=%s using single request start_ms=%d end_ms=%d effective_limit=%d",
-				traceID,
-				startMs,
-				endMs,
-				effectiveLimit,
-			)
-		}
+		logger.Debug("get_traces exact trace_id lookup detected, using single request", "trace_id", traceID, "start_ms", startMs, "end_ms", endMs, "effective_limit", effectiveLimit)
 		return executeTraceJSONQuery(ctx, client, cfg, tracejsonQuery, startMs, endMs, effectiveLimit)
 	}
 
@@ -107,14 +98,7 @@ func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Co
 	// duplicate group-by keys and mathematically wrong aggregates. Run the
 	// full window as a single request instead.
 	if utils.PipelineHasAggregateStage(args.TracejsonQuery) {
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_traces aggregate stage detected, using single request start_ms=%d end_ms=%d effective_limit=%d",
-				startMs,
-				endMs,
-				effectiveLimit,
-			)
-		}
+		logger.Debug("get_traces aggregate stage detected, using single request", "start_ms", startMs, "end_ms", endMs, "effective_limit", effectiveLimit)
 		return executeTraceJSONQuery(ctx, client, cfg, tracejsonQuery, startMs, endMs, effectiveLimit)
 	}
 
@@ -135,26 +119,15 @@ func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Co
 	})
 	chunks := utils.GetAdaptiveChunks(startMs, endMs, adaptiveCfg)
 	if len(chunks) == 0 {
-		if chunkingDebug {
-			log.Printf("[chunking] get_traces produced no chunks start_ms=%d end_ms=%d limit=%d", startMs, endMs, args.Limit)
-		}
+		logger.Debug("get_traces produced no chunks", "start_ms", startMs, "end_ms", endMs, "limit", args.Limit)
 		return emptyTracesResponse(), nil
 	}
 
-	if chunkingDebug {
-		log.Printf(
-			"[chunking] get_traces chunking enabled chunks=%d max_parallel=%d chunk_size_ms=%d start_ms=%d end_ms=%d requested_limit=%d effective_limit=%d reason=%q",
-			len(chunks), adaptiveCfg.MaxParallelChunks, adaptiveCfg.ChunkSizeMs, startMs, endMs, args.Limit, effectiveLimit, adaptiveCfg.Reason,
-		)
-		// Preserved from the pre-refactor format so existing log greps
-		// matching `requested limit capped` keep working.
-		if args.Limit > 0 && args.Limit > effectiveLimit {
-			log.Printf(
-				"[chunking] get_traces requested limit capped requested_limit=%d configured_max=%d",
-				args.Limit,
-				effectiveLimit,
-			)
-		}
+	logger.Debug("get_traces chunking enabled", "chunks", len(chunks), "max_parallel", adaptiveCfg.MaxParallelChunks, "chunk_size_ms", adaptiveCfg.ChunkSizeMs, "start_ms", startMs, "end_ms", endMs, "requested_limit", args.Limit, "effective_limit", effectiveLimit, "reason", adaptiveCfg.Reason)
+	// Preserved from the pre-refactor format so existing log greps
+	// matching `requested limit capped` keep working.
+	if args.Limit > 0 && args.Limit > effectiveLimit {
+		logger.Debug("get_traces requested limit capped", "requested_limit", args.Limit, "configured_max", effectiveLimit)
 	}
 
 	// Known over-fetch: each chunk asks the upstream for effectiveLimit
@@ -187,7 +160,7 @@ func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Co
 		chunkNum := r.Index + 1
 
 		if r.Err != nil {
-			slog.Error("chunked trace query failed",
+			logging.Logger("traces").Error("chunked trace query failed",
 				"tool", "get_traces",
 				"chunk_index", chunkNum,
 				"total_chunks", len(chunks),
@@ -203,7 +176,7 @@ func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Co
 
 		items, err := extractTraceResultItems(r.Value)
 		if err != nil {
-			slog.Error("chunked trace query parse failed",
+			logging.Logger("traces").Error("chunked trace query parse failed",
 				"tool", "get_traces",
 				"chunk_index", chunkNum,
 				"total_chunks", len(chunks),
@@ -233,16 +206,7 @@ func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Co
 			mergedItems = append(mergedItems, items...)
 		}
 
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_traces chunk result chunk=%d/%d kept_traces=%d remaining_limit=%d truncated_at_limit=%t",
-				chunkNum,
-				len(chunks),
-				kept,
-				remaining,
-				truncatedAtLimit,
-			)
-		}
+		logger.Debug("get_traces chunk result", "chunk", chunkNum, "chunks", len(chunks), "kept_traces", kept, "remaining_limit", remaining, "truncated_at_limit", truncatedAtLimit)
 	}
 
 	if baseResponse == nil {
@@ -260,21 +224,9 @@ func fetchTraceJSONQuery(ctx context.Context, client *http.Client, cfg models.Co
 
 	if partialErr != nil {
 		annotatePartialGetTracesResponse(baseResponse, partialErr, len(chunks), len(mergedItems))
-		if chunkingDebug {
-			log.Printf(
-				"[chunking] get_traces chunking partial chunks=%d returned_traces=%d start_ms=%d end_ms=%d err=%v",
-				len(chunks),
-				len(mergedItems),
-				startMs,
-				endMs,
-				partialErr,
-			)
-		}
-	} else if chunkingDebug {
-		log.Printf(
-			"[chunking] get_traces chunking complete chunks=%d returned_traces=%d start_ms=%d end_ms=%d",
-			len(chunks), len(mergedItems), startMs, endMs,
-		)
+		logger.Debug("get_traces chunking partial", "chunks", len(chunks), "returned_traces", len(mergedItems), "start_ms", startMs, "end_ms", endMs, "error", partialErr)
+	} else {
+		logger.Debug("get_traces chunking complete", "chunks", len(chunks), "returned_traces", len(mergedItems), "start_ms", startMs, "end_ms", endMs)
 	}
 
 	return baseResponse, nil
@@ -363,22 +315,7 @@ func emptyTracesResponse() map[string]interface{} {
 
 // parseTimeRangeFromArgs extracts start and end times from GetTracesArgs
 func parseTimeRangeFromArgs(args GetTracesArgs) (int64, int64, error) {
-	params := make(map[string]interface{})
-	if args.LookbackMinutes > 0 {
-		params["lookback_minutes"] = args.LookbackMinutes
-	}
-	if args.StartTimeISO != "" {
-		params["start_time_iso"] = args.StartTimeISO
-	}
-	if args.EndTimeISO != "" {
-		params["end_time_iso"] = args.EndTimeISO
-	}
-
-	startTime, endTime, err := utils.GetTimeRange(params, utils.DefaultLookbackMinutes)
-	if err != nil {
-		return 0, 0, err
-	}
-	return startTime.UnixMilli(), endTime.UnixMilli(), nil
+	return parseTimeRangeFromArgsAt(args, time.Now().UTC())
 }
 
 // formatJSON formats JSON for display
@@ -392,18 +329,11 @@ func formatJSON(data interface{}) string {
 
 // parseTimeRangeFromArgsAt is the testable version of parseTimeRangeFromArgs
 func parseTimeRangeFromArgsAt(args GetTracesArgs, now time.Time) (int64, int64, error) {
-	params := make(map[string]interface{})
-	if args.LookbackMinutes > 0 {
-		params["lookback_minutes"] = args.LookbackMinutes
-	}
-	if args.StartTimeISO != "" {
-		params["start_time_iso"] = args.StartTimeISO
-	}
-	if args.EndTimeISO != "" {
-		params["end_time_iso"] = args.EndTimeISO
-	}
-
-	startTime, endTime, err := utils.GetTimeRangeAt(params, utils.DefaultLookbackMinutes, now)
+	startTime, endTime, err := utils.TimeRange{
+		StartTimeISO:    args.StartTimeISO,
+		EndTimeISO:      args.EndTimeISO,
+		LookbackMinutes: float64(args.LookbackMinutes),
+	}.ResolveAt(utils.DefaultLookbackMinutes, now)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -17,12 +17,12 @@ func TestParseTimeRangeFromArgs(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "valid legacy range compatibility",
+			name: "legacy space-separated range should fail",
 			args: GetTracesArgs{
 				StartTimeISO: "2026-02-09 15:04:05",
 				EndTimeISO:   "2026-02-09 15:34:05",
 			},
-			wantErr: false,
+			wantErr: true,
 		},
 		{
 			name: "invalid start_time_iso should fail",
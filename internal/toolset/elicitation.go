@@ -0,0 +1,166 @@
+package toolset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+// elicitableArgs are string arguments the server may ask the user for when a
+// tool requires them and the call leaves them out, with the prompt shown for
+// each.
+var elicitableArgs = map[string]string{
+	"service_name": "Service name (as shown in Last9 APM, e.g. checkout)",
+	"env":          "Deployment environment (e.g. production)",
+}
+
+// clientSupportsElicitation reports whether the calling client declared the
+// elicitation capability during initialize. Stateless HTTP sessions have no
+// initialize params and cannot receive server-to-client requests, so they
+// report false.
+func clientSupportsElicitation(req *mcp.CallToolRequest) bool {
+	if req == nil || req.Session == nil {
+		return false
+	}
+	params := req.Session.InitializeParams()
+	return params != nil && params.Capabilities != nil && params.Capabilities.Elicitation != nil
+}
+
+// elicitableRequired removes the elicitable arguments from the required
+// list of the tool's input schema and returns them. The SDK rejects a call
+// that omits a required argument before any middleware runs, so they would
+// never reach withElicitation; withArgValidation still reports them missing
+// when the client cannot be asked.
+func elicitableRequired(tool *mcp.Tool) []string {
+	schema, ok := tool.InputSchema.(*jsonschema.Schema)
+	if !ok {
+		return nil
+	}
+	var names []string
+	schema.Required = slices.DeleteFunc(slices.Clone(schema.Required), func(name string) bool {
+		if _, ok := elicitableArgs[name]; ok {
+			names = append(names, name)
+			return true
+		}
+		return false
+	})
+	sort.Strings(names)
+	return names
+}
+
+// missingElicitableArgs returns the names that are omitted, null or blank in
+// the call arguments.
+func missingElicitableArgs(names []string, fields map[string]any) []string {
+	var missing []string
+	for _, name := range names {
+		switch value := fields[name].(type) {
+		case nil:
+			missing = append(missing, name)
+		case string:
+			if strings.TrimSpace(value) == "" {
+				missing = append(missing, name)
+			}
+		}
+	}
+	return missing
+}
+
+// withElicitation asks the user for the required names (see
+// elicitableRequired) the call leaves out through MCP elicitation instead of
+// letting the tool fail, when the client supports it. Clients without
+// elicitation get the tool's normal validation error, and a call naming a
+// saved view is left to the view's parameters.
+func withElicitation[In any](names []string, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	if len(names) == 0 {
+		return handler
+	}
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		if !clientSupportsElicitation(req) || req.Params == nil {
+			return handler(ctx, req, args)
+		}
+
+		fields := map[string]any{}
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &fields); err != nil {
+				return handler(ctx, req, args)
+			}
+		}
+		if view, _ := fields["view"].(string); view != "" {
+			return handler(ctx, req, args)
+		}
+		missing := missingElicitableArgs(names, fields)
+		if len(missing) == 0 {
+			return handler(ctx, req, args)
+		}
+
+		values, err := elicitArgs(ctx, req, missing)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, value := range values {
+			fields[name] = value
+		}
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to apply elicited arguments: %w", err)
+		}
+		filled := args
+		if err := json.Unmarshal(raw, &filled); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply elicited arguments: %w", err)
+		}
+		// Later middleware reads the raw arguments, so they carry the
+		// elicited values too.
+		params := *req.Params
+		params.Arguments = raw
+		filledReq := *req
+		filledReq.Params = &params
+		return handler(ctx, &filledReq, filled)
+	}
+}
+
+// elicitArgs prompts the user for the named arguments. It returns an error
+// when the user declines or cancels, or leaves a value empty.
+func elicitArgs(ctx context.Context, req *mcp.CallToolRequest, names []string) (map[string]string, error) {
+	properties := make(map[string]any, len(names))
+	for _, name := range names {
+		properties[name] = map[string]any{"type": "string", "description": elicitableArgs[name]}
+	}
+	toolName := ""
+	if req.Params != nil {
+		toolName = req.Params.Name
+	}
+
+	result, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+		Message: fmt.Sprintf("%s needs %s to run.", toolName, strings.Join(names, " and ")),
+		RequestedSchema: map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   names,
+		},
+	})
+	if err != nil {
+		logging.Logger("tools").Warn("elicitation failed", "tool", toolName, "error", err)
+		return nil, fmt.Errorf("%s is required", strings.Join(names, " and "))
+	}
+	if result.Action != "accept" {
+		return nil, fmt.Errorf("%s is required (user chose to %s)", strings.Join(names, " and "), result.Action)
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, _ := result.Content[name].(string)
+		if value = strings.TrimSpace(value); value == "" {
+			return nil, fmt.Errorf("%s is required", name)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
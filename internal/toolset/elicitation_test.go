@@ -0,0 +1,95 @@
+package toolset
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type elicitTestArgs struct {
+	ServiceName string `json:"service_name"`
+	Env         string `json:"env,omitempty"`
+}
+
+// callWithElicitation registers a tool the way registerTool does, with
+// argument validation and elicitation, and calls it without service_name
+// from a client using elicitHandler (nil: no elicitation capability). It
+// returns the service name the tool saw.
+func callWithElicitation(t *testing.T, elicitHandler func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error)) (string, *mcp.CallToolResult) {
+	t.Helper()
+	ctx := context.Background()
+
+	var seen string
+	var handler mcp.ToolHandlerFor[elicitTestArgs, any] = func(ctx context.Context, req *mcp.CallToolRequest, args elicitTestArgs) (*mcp.CallToolResult, any, error) {
+		seen = args.ServiceName
+		if args.ServiceName == "" {
+			return nil, nil, errServiceNameRequired
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil, nil
+	}
+	tool := &mcp.Tool{Name: "get_service_summary"}
+	rules, err := newArgRules[elicitTestArgs](tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler = withArgValidation(tool.Name, rules, handler)
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	mcp.AddTool(server, tool, withElicitation(elicitableRequired(tool), handler))
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "0"}, &mcp.ClientOptions{ElicitationHandler: elicitHandler})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "get_service_summary", Arguments: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return seen, result
+}
+
+var errServiceNameRequired = errors.New("service_name is required")
+
+func TestWithElicitation(t *testing.T) {
+	t.Run("asks for missing required args", func(t *testing.T) {
+		var asked []any
+		seen, result := callWithElicitation(t, func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			asked = req.Params.RequestedSchema.(map[string]any)["required"].([]any)
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"service_name": "checkout"}}, nil
+		})
+		if result.IsError || seen != "checkout" {
+			t.Errorf("tool saw service_name %q (error result: %v), want checkout", seen, result.IsError)
+		}
+		// env is optional (omitempty), so it must not be requested.
+		if len(asked) != 1 || asked[0] != "service_name" {
+			t.Errorf("elicited %v, want only service_name", asked)
+		}
+	})
+
+	t.Run("declined elicitation returns an error", func(t *testing.T) {
+		_, result := callWithElicitation(t, func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			return &mcp.ElicitResult{Action: "decline"}, nil
+		})
+		if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "decline") {
+			t.Errorf("expected decline error, got %+v", result.Content)
+		}
+	})
+
+	t.Run("clients without elicitation get the tool's own error", func(t *testing.T) {
+		seen, result := callWithElicitation(t, nil)
+		if seen != "" || !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "service_name: required argument is missing") {
+			t.Errorf("expected passthrough validation error, got %+v", result.Content)
+		}
+	})
+}
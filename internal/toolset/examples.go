@@ -0,0 +1,281 @@
+package toolset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// curatedExamples are hand-written sample arguments for tools whose required
+// arguments can't be derived usefully from the schema (query languages, epoch
+// times, nested filters), or where an optional argument is the usual way to
+// call them. They are merged over the generated values.
+var curatedExamples = map[string]map[string]any{
+	"get_service_summary":      {"lookback_minutes": 30, "sort_by": "error_percent", "top_n": 10},
+	"get_exceptions":           {"service_name": "checkout", "lookback_minutes": 60},
+	"get_alerts":               {"lookback_minutes": 60},
+	"get_change_events":        {"service_name": "checkout", "lookback_minutes": 120},
+	"prometheus_range_query":   {"query": `sum by (service_name) (rate(http_requests_total[5m]))`, "lookback_minutes": 60},
+	"prometheus_instant_query": {"query": `sum by (service_name) (rate(http_requests_total[5m]))`},
+	"render_chart":             {"query": `sum(rate(http_requests_total[5m]))`, "lookback_minutes": 60},
+	"create_watch": {
+		"query":     `sum(rate(http_requests_total{code=~"5.."}[5m])) / sum(rate(http_requests_total[5m])) * 100`,
+		"operator":  ">",
+		"threshold": 5,
+		"name":      "checkout 5xx",
+	},
+	"get_logs": {
+		"logjson_query":    []any{map[string]any{"type": "filter", "query": map[string]any{"$containsWords": []any{"Body", "error"}}}},
+		"lookback_minutes": 15,
+	},
+	"get_service_logs": {"service_name": "checkout", "severity_filters": []any{"error"}, "lookback_minutes": 30},
+	"get_traces": {
+		"tracejson_query":  []any{map[string]any{"type": "filter", "query": map[string]any{"$and": []any{map[string]any{"$eq": []any{"ServiceName", "checkout"}}, map[string]any{"$eq": []any{"StatusCode", "STATUS_CODE_ERROR"}}}}}},
+		"lookback_minutes": 60,
+	},
+	"search_traces":        {"attributes": []any{map[string]any{"key": "user_id", "value": "42"}}, "lookback_minutes": 60},
+	"get_alert_rule_state": {"start_time": 1717243200, "end_time": 1717246800, "step": 60},
+	"add_drop_rule": {
+		"name":    "drop-debug-logs",
+		"filters": []any{map[string]any{"key": "severity", "value": "debug", "operator": "equals", "conjunction": "and"}},
+	},
+	"save_view": {"name": "checkout-prod-1h", "parameters": map[string]any{"service_name": "checkout", "env": "prod", "lookback_minutes": 60}},
+}
+
+// noExample lists tools whose required argument is a whole document, for
+// which a placeholder would mislead more than help. Their descriptions show
+// the format.
+var noExample = map[string]bool{
+	"create_dashboard": true,
+	"update_dashboard": true,
+}
+
+// withExample appends an "Example arguments" payload to the tool description,
+// to help models pass valid arguments on the first call. The payload is built
+// by exampleArgs and checked against the input schema; tools without
+// required arguments or a curated sample, and payloads that don't validate,
+// get no example.
+func withExample[In any](tool *mcp.Tool) {
+	if noExample[tool.Name] {
+		return
+	}
+	schema, err := inputSchema[In](tool)
+	if err != nil {
+		return
+	}
+	args := exampleArgs(tool.Name, schema)
+	if len(args) == 0 {
+		return
+	}
+	resolved, err := schema.Resolve(&jsonschema.ResolveOptions{ValidateDefaults: true})
+	if err != nil {
+		return
+	}
+	// Validate a JSON round-trip, as the SDK does for client arguments.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(args); err != nil {
+		return
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || resolved.Validate(decoded) != nil {
+		return
+	}
+	tool.Description = strings.TrimRight(tool.Description, "\n") + "\n\nExample arguments: " + strings.TrimSpace(buf.String())
+}
+
+// inputSchema returns the tool's declared input schema, or the one the SDK
+// infers from In when none is declared.
+func inputSchema[In any](tool *mcp.Tool) (*jsonschema.Schema, error) {
+	switch s := tool.InputSchema.(type) {
+	case nil:
+		return jsonschema.For[In](&jsonschema.ForOptions{})
+	case *jsonschema.Schema:
+		return s, nil
+	default:
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal input schema: %w", err)
+		}
+		var schema jsonschema.Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal input schema: %w", err)
+		}
+		return &schema, nil
+	}
+}
+
+// exampleArgs returns sample values for the required arguments of a tool,
+// with the tool's curated sample merged over them.
+func exampleArgs(name string, schema *jsonschema.Schema) map[string]any {
+	args := map[string]any{}
+	for _, prop := range schema.Required {
+		if v, ok := sampleValue(prop, schema.Properties[prop], 0); ok {
+			args[prop] = v
+		}
+	}
+	for k, v := range curatedExamples[name] {
+		args[k] = v
+	}
+	return args
+}
+
+// maxExampleDepth bounds recursion into nested object and array schemas.
+const maxExampleDepth = 4
+
+// sampleValue picks a value for one property: its const or first enum
+// value (declared or from enumHint), the "(e.g. ...)" hint in its description, its default, or a
+// placeholder for its type.
+func sampleValue(name string, s *jsonschema.Schema, depth int) (any, bool) {
+	if s == nil || depth > maxExampleDepth {
+		return nil, false
+	}
+	if s.Const != nil {
+		return *s.Const, true
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0], true
+	}
+	if values := enumHint(s.Description); len(values) > 0 && schemaType(s) == "string" {
+		return values[0], true
+	}
+	hint := descriptionHint(s.Description)
+	var def any
+	if len(s.Default) > 0 && json.Unmarshal(s.Default, &def) == nil && def != nil && hint == "" {
+		return def, true
+	}
+
+	switch schemaType(s) {
+	case "string":
+		if hint != "" {
+			return firstAlternative(hint), true
+		}
+		if strings.HasSuffix(name, "_iso") {
+			return "2024-06-01T12:00:00Z", true
+		}
+		if v, ok := placeholderStrings[name]; ok {
+			return v, true
+		}
+		return "<" + name + ">", true
+	case "integer":
+		if n, err := strconv.ParseInt(firstAlternative(hint), 10, 64); err == nil {
+			return n, true
+		}
+		if s.Minimum != nil {
+			return int64(math.Ceil(*s.Minimum)), true
+		}
+		return 1, true
+	case "number":
+		if n, err := strconv.ParseFloat(firstAlternative(hint), 64); err == nil {
+			return n, true
+		}
+		if s.Minimum != nil {
+			return *s.Minimum, true
+		}
+		return 1, true
+	case "boolean":
+		return true, true
+	case "array":
+		if items := hintList(hint); len(items) > 0 && s.Items != nil && schemaType(s.Items) == "string" {
+			return items, true
+		}
+		if v, ok := sampleValue(name, s.Items, depth+1); ok {
+			return []any{v}, true
+		}
+		return []any{}, true
+	case "object":
+		obj := map[string]any{}
+		for _, prop := range s.Required {
+			if v, ok := sampleValue(prop, s.Properties[prop], depth+1); ok {
+				obj[prop] = v
+			}
+		}
+		return obj, true
+	}
+	for _, alternatives := range [][]*jsonschema.Schema{s.OneOf, s.AnyOf} {
+		if len(alternatives) > 0 {
+			return sampleValue(name, alternatives[0], depth+1)
+		}
+	}
+	return nil, false
+}
+
+// placeholderStrings are sample values for common string arguments whose
+// descriptions carry no hint.
+var placeholderStrings = map[string]string{
+	"service_name": "checkout",
+	"service":      "checkout",
+	"env":          "prod",
+	"query":        "up",
+	"selector":     "http_requests_total",
+	"metric":       "http_requests_total",
+	"label":        "service_name",
+}
+
+// schemaType returns the schema's type, ignoring "null" in a type list.
+func schemaType(s *jsonschema.Schema) string {
+	if s.Type != "" {
+		return s.Type
+	}
+	for _, t := range s.Types {
+		if t != "null" {
+			return t
+		}
+	}
+	return ""
+}
+
+// descriptionHint extracts the example from a description such as
+// "Database system (required, e.g. postgresql, mysql)": the text after
+// "e.g. " up to the parenthesis that closes it. Hints that are not inside
+// parentheses are ignored.
+func descriptionHint(desc string) string {
+	i := strings.Index(desc, "e.g. ")
+	if i < 0 {
+		return ""
+	}
+	rest := desc[i+len("e.g. "):]
+	depth := 0
+	for j, r := range rest {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				if r != ')' {
+					return ""
+				}
+				return strings.TrimSpace(rest[:j])
+			}
+			depth--
+		}
+	}
+	return ""
+}
+
+// firstAlternative returns the first of a list of example values such as
+// "postgresql, mysql" or "checkout or cart".
+func firstAlternative(hint string) string {
+	hint, _, _ = strings.Cut(hint, ", ")
+	hint, _, _ = strings.Cut(hint, " or ")
+	return hint
+}
+
+// hintList parses a bracketed list hint such as "[timeout failed]".
+func hintList(hint string) []any {
+	if !strings.HasPrefix(hint, "[") || !strings.HasSuffix(hint, "]") {
+		return nil
+	}
+	var items []any
+	for _, item := range strings.FieldsFunc(hint[1:len(hint)-1], func(r rune) bool { return r == ' ' || r == ',' }) {
+		items = append(items, item)
+	}
+	return items
+}
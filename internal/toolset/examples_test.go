@@ -0,0 +1,96 @@
+package toolset
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+func TestDescriptionHint(t *testing.T) {
+	tests := map[string]string{
+		"Database system (required, e.g. postgresql, mysql, mongodb, redis)":                             "postgresql, mysql, mongodb, redis",
+		`PromQL query to match series (e.g. up{job="prometheus"})`:                                       `up{job="prometheus"}`,
+		"PromQL with $window (e.g. sum(rate(x[$window])) / sum(rate(y[$window]))). Required unless set.": "sum(rate(x[$window])) / sum(rate(y[$window]))",
+		"PromQL expression to evaluate, e.g. a service error percentage (required)":                      "",
+		"Label name to get values for (required)":                                                        "",
+	}
+	for desc, want := range tests {
+		if got := descriptionHint(desc); got != want {
+			t.Errorf("descriptionHint(%q) = %q, want %q", desc, got, want)
+		}
+	}
+}
+
+func TestExampleArgs(t *testing.T) {
+	type filter struct {
+		Key   string `json:"key" jsonschema:"Field to match (e.g. severity)"`
+		Value string `json:"value"`
+	}
+	type args struct {
+		DBSystem   string   `json:"db_system" jsonschema:"Database system (required, e.g. postgresql, mysql)"`
+		Service    string   `json:"service_name"`
+		Objective  float64  `json:"objective" jsonschema:"SLO target in percent (e.g. 99.9)"`
+		Severities []string `json:"severities" jsonschema:"Severities (e.g. [error warn])"`
+		Filters    []filter `json:"filters"`
+		Limit      int      `json:"limit,omitempty"`
+	}
+	schema, err := jsonschema.For[args](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := exampleArgs("test_tool", schema)
+	want := map[string]any{
+		"db_system":    "postgresql",
+		"service_name": "checkout",
+		"objective":    99.9,
+		"severities":   []any{"error", "warn"},
+		"filters":      []any{map[string]any{"key": "severity", "value": "<value>"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exampleArgs = %#v\nwant %#v", got, want)
+	}
+}
+
+// TestRegisteredToolExamples checks that every curated example survives
+// schema validation, and that tools with required arguments get an example.
+func TestRegisteredToolExamples(t *testing.T) {
+	session := newInMemoryClientSession(t)
+	for tool, err := range session.Tools(context.Background(), nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, example, found := strings.Cut(tool.Description, "\n\nExample arguments: ")
+		var schema struct {
+			Required []string `json:"required"`
+		}
+		raw, _ := json.Marshal(tool.InputSchema)
+		json.Unmarshal(raw, &schema)
+
+		_, curated := curatedExamples[tool.Name]
+		wantExample := (curated || len(schema.Required) > 0) && !noExample[tool.Name]
+		var args map[string]any
+		if found {
+			if err := json.Unmarshal([]byte(example), &args); err != nil {
+				t.Errorf("%s: example is not a JSON object: %q", tool.Name, example)
+				continue
+			}
+		}
+		// Required service_name and env are left out of the schema for
+		// elicitation, but the example still shows them.
+		if found && !wantExample && !noExample[tool.Name] {
+			wantExample = true
+			for name := range args {
+				if _, ok := elicitableArgs[name]; !ok {
+					wantExample = false
+				}
+			}
+		}
+		if found != wantExample {
+			t.Errorf("%s: example present = %v, want %v", tool.Name, found, wantExample)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package toolset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/macros"
+	"github.com/last9/last9-mcp-server/internal/resultarchive"
+	"github.com/last9/last9-mcp-server/internal/resultstore"
+	"github.com/last9/last9-mcp-server/internal/views"
+)
+
+// toolRegistry carries per-registration settings through registerTool and
+// records which tools were actually registered.
+type toolRegistry struct {
+	displayLoc *time.Location
+	exportDir  string
+	results    *resultstore.Store
+	archive    *resultarchive.Store
+	views      *views.Store
+	filter     *toolFilter
+	registered []string
+	// calls runs registered tools in-process, for macros.
+	calls map[string]macros.CallFunc
+}
+
+// toolFilter decides which tools are exposed. With an enabled list only
+// those tools are registered; the disabled list is then removed from what
+// remains. Names are matched exactly.
+type toolFilter struct {
+	enabled  map[string]bool
+	disabled map[string]bool
+	known    map[string]bool
+}
+
+func newToolFilter(enabled, disabled []string) *toolFilter {
+	return &toolFilter{
+		enabled:  toolNameSet(enabled),
+		disabled: toolNameSet(disabled),
+		known:    make(map[string]bool),
+	}
+}
+
+func toolNameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// allows reports whether the named tool should be registered.
+func (f *toolFilter) allows(name string) bool {
+	f.known[name] = true
+	if len(f.enabled) > 0 && !f.enabled[name] {
+		return false
+	}
+	return !f.disabled[name]
+}
+
+// validate returns an error naming configured tools that do not exist, so a
+// typo in the allowlist does not silently hide (or expose) a tool. Call it
+// after every tool has been offered to allows.
+func (f *toolFilter) validate() error {
+	var unknown []string
+	for _, set := range []map[string]bool{f.enabled, f.disabled} {
+		for name := range set {
+			if !f.known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown tool(s) in enabled_tools/disabled_tools: %s", strings.Join(unknown, ", "))
+}
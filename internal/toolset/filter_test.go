@@ -0,0 +1,134 @@
+package toolset
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestToolFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  []string
+		disabled []string
+		want     []string
+	}{
+		{"no configuration", nil, nil, []string{"get_alerts", "get_logs", "prometheus_range_query"}},
+		{"disabled list", nil, []string{"prometheus_range_query"}, []string{"get_alerts", "get_logs"}},
+		{"enabled list", []string{"get_logs", " get_alerts "}, nil, []string{"get_alerts", "get_logs"}},
+		{"disabled wins over enabled", []string{"get_logs", "get_alerts"}, []string{"get_alerts"}, []string{"get_logs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newToolFilter(tt.enabled, tt.disabled)
+			var got []string
+			for _, name := range []string{"get_alerts", "get_logs", "prometheus_range_query"} {
+				if f.allows(name) {
+					got = append(got, name)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("allowed = %v, want %v", got, tt.want)
+			}
+			if err := f.validate(); err != nil {
+				t.Errorf("validate() = %v", err)
+			}
+		})
+	}
+}
+
+func TestToolFilterRejectsUnknownNames(t *testing.T) {
+	f := newToolFilter([]string{"get_logs", "get_lgos"}, []string{"promql_query"})
+	f.allows("get_logs")
+	err := f.validate()
+	if err == nil || !strings.Contains(err.Error(), "get_lgos, promql_query") {
+		t.Fatalf("validate() = %v, want unknown tool names", err)
+	}
+}
+
+func TestRegisterAllTools_HonoursToolConfiguration(t *testing.T) {
+	listTools := func(t *testing.T, enabled, disabled []string) ([]string, error) {
+		t.Helper()
+		server, err := last9mcp.NewServerWithOptions("test-last9-mcp", "test", last9mcp.WithSkipProviderInit())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer server.Shutdown(context.Background())
+
+		cfg := testToolRegistrationConfig()
+		cfg.EnabledTools, cfg.DisabledTools = enabled, disabled
+		if err := registerAllTools(server, New(cfg)); err != nil {
+			return nil, err
+		}
+
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		serverSession, err := server.Server.Connect(context.Background(), serverTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer serverSession.Close()
+		clientSession, err := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil).Connect(context.Background(), clientTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer clientSession.Close()
+
+		list, err := clientSession.ListTools(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(list.Tools))
+		for _, tool := range list.Tools {
+			names = append(names, tool.Name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	got, err := listTools(t, []string{"get_logs", "get_alerts"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "get_alerts,get_logs" {
+		t.Errorf("enabled_tools: registered %v", got)
+	}
+
+	got, err = listTools(t, nil, []string{"prometheus_range_query", "prometheus_instant_query"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range got {
+		if name == "prometheus_range_query" || name == "prometheus_instant_query" {
+			t.Errorf("disabled tool %s was registered", name)
+		}
+	}
+	if len(got) == 0 {
+		t.Error("disabled_tools should leave the remaining tools registered")
+	}
+
+	if _, err := listTools(t, nil, []string{"no_such_tool"}); err == nil {
+		t.Error("expected error for unknown tool name")
+	}
+
+	// Write tools are known but not registered without --enable_write_tools.
+	got, err = listTools(t, []string{"get_alerts", "create_alert_rule"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "get_alerts" {
+		t.Errorf("create_alert_rule without write tools: registered %v", got)
+	}
+
+	// Admin tools likewise need --enable_admin_tools.
+	got, err = listTools(t, []string{"get_alerts", "set_log_level"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "get_alerts" {
+		t.Errorf("set_log_level without admin tools: registered %v", got)
+	}
+}
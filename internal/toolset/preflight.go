@@ -0,0 +1,100 @@
+package toolset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+)
+
+// PreflightCheck is the outcome of one startup check.
+type PreflightCheck struct {
+	Name       string `json:"name"`
+	OK         bool   `json:"ok"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Readiness summarizes the startup preflight. The server is ready when the
+// token, datasource and tools checks pass; a failed catalog check only means
+// tool descriptions lack the attribute and environment hints.
+type Readiness struct {
+	Ready      bool             `json:"ready"`
+	CheckedAt  time.Time        `json:"checked_at"`
+	DurationMs int64            `json:"duration_ms"`
+	Checks     []PreflightCheck `json:"checks"`
+}
+
+// Preflight does the work the first tool call would otherwise pay for, then
+// registers the tools on server: it makes sure the access token is valid,
+// checks the datasource resolved by Authenticate, and loads log and trace
+// attribute names, environments and services into the cache, which also
+// opens pooled connections to the API. It replaces Warm followed by
+// Register. Call it after Authenticate.
+func (t *Toolset) Preflight(ctx context.Context, server *last9mcp.Last9MCPServer) Readiness {
+	started := time.Now()
+	r := Readiness{CheckedAt: started.UTC()}
+	check := func(name string, run func() (string, error)) bool {
+		checkStart := time.Now()
+		detail, err := run()
+		c := PreflightCheck{Name: name, OK: err == nil, Detail: detail, DurationMs: time.Since(checkStart).Milliseconds()}
+		if err != nil {
+			c.Detail = err.Error()
+		}
+		r.Checks = append(r.Checks, c)
+		return c.OK
+	}
+
+	tokenOK := check("token", func() (string, error) {
+		if t.cfg.TokenManager == nil {
+			return "", fmt.Errorf("not authenticated")
+		}
+		t.cfg.TokenManager.GetAccessToken(ctx)
+		status := t.cfg.TokenManager.Status()
+		if status.ExpiresInSeconds <= 0 {
+			return "", fmt.Errorf("access token expired at %s: %s", status.ExpiresAt.Format(time.RFC3339), status.LastRefreshError)
+		}
+		return fmt.Sprintf("valid until %s", status.ExpiresAt.UTC().Format(time.RFC3339)), nil
+	})
+	datasourceOK := check("datasource", func() (string, error) {
+		if t.cfg.PrometheusReadURL == "" {
+			return "", fmt.Errorf("no datasource resolved")
+		}
+		name := t.cfg.DatasourceName
+		if name == "" {
+			name = "default"
+		}
+		return fmt.Sprintf("%s (region %s)", name, t.cfg.Region), nil
+	})
+	check("catalog", func() (string, error) {
+		if !tokenOK || !datasourceOK {
+			return "", fmt.Errorf("skipped: needs a valid token and datasource")
+		}
+		err := t.attrCache.Warm(ctx)
+		detail := fmt.Sprintf("%d log attributes, %d trace attributes, %d environments, %d services",
+			len(t.attrCache.GetLogAttributes()), len(t.attrCache.GetTraceAttributes()),
+			len(t.attrCache.GetEnvironments()), len(t.attrCache.GetServices()))
+		if err != nil {
+			return "", fmt.Errorf("%s; %w", detail, err)
+		}
+		return detail, nil
+	})
+	toolsOK := check("tools", func() (string, error) {
+		return "", t.Register(server)
+	})
+
+	r.Ready = tokenOK && datasourceOK && toolsOK
+	r.DurationMs = time.Since(started).Milliseconds()
+	return r
+}
+
+// Check returns the named check, or false when the preflight has none.
+func (r Readiness) Check(name string) (PreflightCheck, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return PreflightCheck{}, false
+}
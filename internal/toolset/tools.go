@@ -0,0 +1,924 @@
+package toolset
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/alerting"
+	"github.com/last9/last9-mcp-server/internal/apm"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/change_events"
+	"github.com/last9/last9-mcp-server/internal/criticality"
+	"github.com/last9/last9-mcp-server/internal/customtools"
+	"github.com/last9/last9-mcp-server/internal/dashboards"
+	"github.com/last9/last9-mcp-server/internal/export"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/macros"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/prompts"
+	"github.com/last9/last9-mcp-server/internal/queryhistory"
+	"github.com/last9/last9-mcp-server/internal/redact"
+	"github.com/last9/last9-mcp-server/internal/resultarchive"
+	"github.com/last9/last9-mcp-server/internal/resultdiff"
+	"github.com/last9/last9-mcp-server/internal/resultstore"
+	"github.com/last9/last9-mcp-server/internal/suggest"
+	"github.com/last9/last9-mcp-server/internal/telemetry/logs"
+	"github.com/last9/last9-mcp-server/internal/telemetry/traces"
+	"github.com/last9/last9-mcp-server/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/views"
+	"github.com/last9/last9-mcp-server/internal/watch"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+)
+
+// buildEnhancedDescription appends the embedded markdown instructions to the
+// base tool description. For get_logs, it also replaces the {{labels}} placeholder
+// with the actual attribute names from the cache.
+func buildEnhancedDescription(base, instructions string, labelValues []string) string {
+	desc := base + "\n\n" + instructions
+	if len(labelValues) > 0 {
+		desc = strings.ReplaceAll(desc, "{{labels}}", strings.Join(labelValues, ", "))
+	} else {
+		desc = strings.ReplaceAll(desc, "{{labels}}", "")
+	}
+	return desc
+}
+
+// registerTool registers an instrumented tool whose text results are
+// post-processed with localized timestamps (see withDisplayTimezone and
+// addDisplayTimezoneArg), can
+// be written to a file (see withExport) and are split when too large for one
+// message (see withResultLimit). Arguments are checked against the input
+// schema before the handler runs (see withArgValidation), except that a
+// missing service_name or env is first asked of the user when the client
+// supports it (see withElicitation). The description gets an example
+// argument payload (see withExample). A view argument is expanded into the saved
+// view's parameters (see views.Apply). Tools excluded by the enabled/disabled
+// tool configuration are skipped. Each registered tool is also recorded for
+// in-process calls from macros.
+func registerTool[In any](server *last9mcp.Last9MCPServer, reg *toolRegistry, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, any]) {
+	if !reg.filter.allows(tool.Name) {
+		return
+	}
+	handler = views.Apply(reg.views, handler)
+	if rules, err := newArgRules[In](tool); err == nil {
+		handler = withArgValidation(tool.Name, rules, handler)
+	}
+	withExample[In](tool)
+	addDisplayTimezoneArg(tool)
+	elicit := elicitableRequired(tool)
+	last9mcp.RegisterInstrumentedTool(server, tool, withResultLimit(reg.results, withResultArchive(reg.archive, tool.Name, withExport(reg.exportDir, withRedaction(withDisplayTimezone(reg.displayLoc, withElicitation(elicit, handler)))))))
+	reg.registered = append(reg.registered, tool.Name)
+	reg.calls[tool.Name] = inProcessCall(tool.Name, withRedaction(withDisplayTimezone(reg.displayLoc, handler)))
+}
+
+// inProcessCall adapts a typed handler to a call with loosely typed
+// arguments. Arguments are decoded strictly, like the SDK does for calls
+// from clients, except for display_timezone, which every tool accepts but
+// only withDisplayTimezone reads.
+func inProcessCall[In any](name string, handler mcp.ToolHandlerFor[In, any]) macros.CallFunc {
+	return func(ctx context.Context, _ string, args map[string]any) (*mcp.CallToolResult, error) {
+		raw, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal arguments for %s: %w", name, err)
+		}
+		fields := maps.Clone(args)
+		delete(fields, "display_timezone")
+		decoded, err := json.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal arguments for %s: %w", name, err)
+		}
+		var in In
+		dec := json.NewDecoder(bytes.NewReader(decoded))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&in); err != nil {
+			return nil, fmt.Errorf("invalid arguments for %s: %w", name, err)
+		}
+		req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: name, Arguments: raw}}
+		result, _, err := handler(ctx, req, in)
+		return result, err
+	}
+}
+
+// withRedaction scrubs credentials from tool errors and result text. Upstream
+// error bodies sometimes echo the request, which can carry datasource
+// passwords or tokens.
+func withRedaction[In any](handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		result, out, err := handler(ctx, req, args)
+		if result != nil {
+			for _, content := range result.Content {
+				if text, ok := content.(*mcp.TextContent); ok {
+					text.Text = redact.String(text.Text)
+				}
+			}
+		}
+		return result, out, redact.Error(err)
+	}
+}
+
+// withDisplayTimezone adds "<key>_local" human-readable timestamps to JSON text
+// results. The timezone comes from the call's display_timezone argument when
+// present, falling back to the server-wide default; with neither set the
+// result is passed through untouched.
+func withDisplayTimezone[In any](defaultLoc *time.Location, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		loc := defaultLoc
+		if name := displayTimezoneArg(req); name != "" {
+			override, err := utils.LoadDisplayLocation(name)
+			if err != nil {
+				return nil, nil, err
+			}
+			loc = override
+		}
+
+		result, out, err := handler(ctx, req, args)
+		if err != nil || result == nil || result.IsError || loc == nil {
+			return result, out, err
+		}
+		for _, content := range result.Content {
+			if text, ok := content.(*mcp.TextContent); ok {
+				text.Text = utils.LocalizeTimestamps(text.Text, loc)
+			}
+		}
+		return result, out, err
+	}
+}
+
+// addDisplayTimezoneArg adds the optional display_timezone argument that
+// withDisplayTimezone reads to the tool's input schema, so every tool
+// accepts it.
+func addDisplayTimezoneArg(tool *mcp.Tool) {
+	schema, ok := tool.InputSchema.(*jsonschema.Schema)
+	if !ok {
+		return
+	}
+	if schema.Properties == nil {
+		schema.Properties = map[string]*jsonschema.Schema{}
+	}
+	if _, ok := schema.Properties["display_timezone"]; ok {
+		return
+	}
+	schema.Properties["display_timezone"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "IANA timezone (e.g. Asia/Kolkata) for human-readable *_local timestamps added next to epoch values. Overrides the server default display timezone.",
+	}
+}
+
+// displayTimezoneArg reads the optional display_timezone argument from the raw
+// call arguments, so tools need not thread it through their handlers.
+func displayTimezoneArg(req *mcp.CallToolRequest) string {
+	if req == nil || req.Params == nil || len(req.Params.Arguments) == 0 {
+		return ""
+	}
+	var args struct {
+		DisplayTimezone string `json:"display_timezone"`
+	}
+	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+		return ""
+	}
+	return args.DisplayTimezone
+}
+
+// withExport writes the result to a file under exportDir when the call has an
+// export argument, returning the file's path and size in place of the result.
+// The export runs after redaction so files never hold more than the caller
+// would have seen.
+func withExport[In any](exportDir string, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		opts := exportArg(req)
+		if opts == nil {
+			return handler(ctx, req, args)
+		}
+		if exportDir == "" {
+			return nil, nil, export.ErrDisabled
+		}
+
+		result, out, err := handler(ctx, req, args)
+		if err != nil || result == nil || result.IsError {
+			return result, out, err
+		}
+		var texts []string
+		for _, content := range result.Content {
+			if text, ok := content.(*mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		if len(texts) != 1 {
+			return nil, nil, fmt.Errorf("export needs a single text result, got %d", len(texts))
+		}
+		written, err := export.Write(exportDir, *opts, texts[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := json.Marshal(map[string]any{"exported": written})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// withResultLimit splits text results larger than the configured message size
+// so the rest can be read with get_result_chunk. Some STDIO clients stall on
+// very large messages.
+func withResultLimit[In any](results *resultstore.Store, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		result, out, err := handler(ctx, req, args)
+		if err != nil {
+			return result, out, err
+		}
+		limited, err := results.Limit(result)
+		if err != nil {
+			return nil, nil, err
+		}
+		return limited, out, nil
+	}
+}
+
+// withResultArchive stores each text result, after redaction and before it
+// is split for size, and sets stored_result_id and stored_result_expires_at
+// in the result's _meta, the id fetch_result and diff_results read it back by.
+func withResultArchive[In any](archive *resultarchive.Store, name string, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		result, out, err := handler(ctx, req, args)
+		if err != nil {
+			return result, out, err
+		}
+		return archive.Attach(name, req, result), out, nil
+	}
+}
+
+// exportArg reads the optional export argument from the raw call arguments.
+func exportArg(req *mcp.CallToolRequest) *export.Options {
+	if req == nil || req.Params == nil || len(req.Params.Arguments) == 0 {
+		return nil
+	}
+	var args struct {
+		Export *export.Options `json:"export"`
+	}
+	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+		return nil
+	}
+	return args.Export
+}
+
+// registerAllTools registers the tools of t with the MCP server using the new SDK pattern
+func registerAllTools(server *last9mcp.Last9MCPServer, t *Toolset) error {
+	cfg := t.cfg
+	client := auth.GetHTTPClient()
+
+	displayLoc, err := utils.LoadDisplayLocation(cfg.DisplayTimezone)
+	if err != nil {
+		return err
+	}
+	reg := &toolRegistry{displayLoc: displayLoc, exportDir: cfg.ExportDir, results: t.results, archive: t.archive, views: t.views, filter: newToolFilter(cfg.EnabledTools, cfg.DisabledTools), calls: map[string]macros.CallFunc{}}
+
+	// Build enhanced descriptions for tools that have embedded instructions
+	getLogsDesc := buildEnhancedDescription(prompts.GetLogsDescription, prompts.GetLogsInstructions, t.attrCache.GetLogAttributes())
+	getServiceLogsDesc := buildEnhancedDescription(prompts.GetServiceLogsDescription, prompts.GetServiceLogsInstructions, t.attrCache.GetLogAttributes())
+	getTracesDesc := buildEnhancedDescription(prompts.GetTracesDescription, prompts.GetTracesInstructions, nil)
+	getServiceTracesDesc := buildEnhancedDescription(prompts.GetServiceTracesDescription, prompts.GetServiceTracesInstructions, nil)
+	getMetricsDesc := buildEnhancedDescription(prompts.PromqlRangeQueryDetails, prompts.GetMetricsInstructions, nil)
+	serviceEnvironmentsDesc := prompts.GetServiceEnvironmentsDescription
+	if envs := t.attrCache.GetEnvironments(); len(envs) > 0 {
+		serviceEnvironmentsDesc += "\n\nEnvironments with traffic when the server last refreshed its cache: " + strings.Join(envs, ", ") + "."
+	}
+
+	// Register exceptions tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_exceptions",
+		Description: prompts.GetExceptionsInstructions,
+	}, traces.NewGetExceptionsHandler(client, cfg))
+
+	// Register exception samples tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_exception_samples",
+		Description: prompts.GetExceptionSamplesDescription,
+	}, traces.NewGetExceptionSamplesHandler(client, cfg))
+
+	// Register service summary tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_summary",
+		Description: prompts.GetServiceSummaryDescription,
+	}, apm.NewServiceSummaryHandler(client, cfg))
+
+	// Register service health score tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_health_score",
+		Description: prompts.GetServiceHealthScoreDescription,
+	}, apm.NewGetServiceHealthScoreHandler(client, cfg))
+
+	// Register multi-service comparison tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "compare_services",
+		Description: prompts.CompareServicesDescription,
+	}, apm.NewCompareServicesHandler(client, cfg))
+
+	// Register service version breakdown tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_versions",
+		Description: prompts.GetServiceVersionsDescription,
+	}, apm.NewGetServiceVersionsHandler(client, cfg))
+
+	// Register endpoint SLA report tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_endpoint_sla_report",
+		Description: prompts.GetEndpointSLAReportDescription,
+	}, apm.NewGetEndpointSLAReportHandler(client, cfg, t.criticality))
+
+	// Register release health gate tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "check_release_health",
+		Description: prompts.CheckReleaseHealthDescription,
+	}, apm.NewCheckReleaseHealthHandler(client, cfg))
+
+	// Register traffic pattern classification tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "classify_traffic_pattern",
+		Description: prompts.ClassifyTrafficPatternDescription,
+	}, apm.NewClassifyTrafficPatternHandler(client, cfg))
+
+	// Register RCA draft tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "draft_rca",
+		Description: prompts.DraftRCADescription,
+	}, apm.NewDraftRCAHandler(client, cfg))
+
+	// Register on-call handoff summary tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "generate_handoff_summary",
+		Description: prompts.GenerateHandoffSummaryDescription,
+	}, apm.NewGenerateHandoffSummaryHandler(client, cfg, t.maintenance))
+
+	// Register APM service deviations tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_apm_service_deviations",
+		Description: prompts.GetAPMServiceDeviationsDescription,
+		InputSchema: apm.GetAPMServiceDeviationsInputSchema(),
+	}, apm.NewAPMServiceDeviationsHandler(client, cfg, t.maintenance))
+
+	// Register service environments tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_environments",
+		Description: serviceEnvironmentsDesc,
+	}, apm.NewServiceEnvironmentsHandler(client, cfg))
+
+	// Register service performance details tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_performance_details",
+		Description: prompts.GetServicePerformanceDetails,
+	}, apm.NewServicePerformanceDetailsHandler(client, cfg))
+
+	// Register service operations summary tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_operations_summary",
+		Description: prompts.GetServiceOperationsSummaryDescription,
+	}, apm.NewServiceOperationsSummaryHandler(client, cfg))
+
+	// Register service endpoints tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_endpoints",
+		Description: prompts.GetServiceEndpointsDescription,
+	}, apm.NewGetServiceEndpointsHandler(client, cfg))
+
+	// Register consumer operations tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_consumer_operations",
+		Description: prompts.GetConsumerOperationsDescription,
+	}, apm.NewGetConsumerOperationsHandler(client, cfg))
+
+	// Register gRPC operations tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_grpc_operations",
+		Description: prompts.GetGRPCOperationsDescription,
+	}, apm.NewGetGRPCOperationsHandler(client, cfg))
+
+	// Register runtime metrics tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_runtime_metrics",
+		Description: prompts.GetRuntimeMetricsDescription,
+	}, apm.NewGetRuntimeMetricsHandler(client, cfg))
+
+	// Register service history tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_history",
+		Description: prompts.GetServiceHistoryDescription,
+	}, apm.NewGetServiceHistoryHandler(client, cfg))
+
+	// Register service dependency graph tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_dependency_graph",
+		Description: prompts.GetServiceDependencyGraphDetails,
+	}, apm.NewServiceDependencyGraphHandler(client, cfg))
+
+	// Register dependency latency attribution tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "attribute_dependency_latency",
+		Description: prompts.AttributeDependencyLatencyDescription,
+	}, apm.NewAttributeDependencyLatencyHandler(client, cfg))
+
+	// Register inverted dependency lookup tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_component_consumers",
+		Description: prompts.GetComponentConsumersDescription,
+	}, apm.NewGetComponentConsumersHandler(client, cfg))
+
+	// Register list datasources tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_datasources",
+		Description: prompts.ListDatasourcesDescription,
+	}, apm.NewListDatasourcesHandler(cfg))
+
+	// Register PromQL range query tool (enhanced with metrics instructions)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "prometheus_range_query",
+		Description: getMetricsDesc,
+	}, queryhistory.Recorded(t.history, "prometheus_range_query", describeRangeQuery, apm.NewPromqlRangeQueryHandler(client, cfg)))
+
+	// Register PromQL instant query tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "prometheus_instant_query",
+		Description: prompts.PromqlInstantQueryDetails,
+	}, queryhistory.Recorded(t.history, "prometheus_instant_query", describeInstantQuery, apm.NewPromqlInstantQueryHandler(client, cfg)))
+
+	// Register chart rendering tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "render_chart",
+		Description: prompts.RenderChartDescription,
+	}, apm.NewRenderChartHandler(client, cfg))
+
+	// Register latency distribution tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_latency_distribution",
+		Description: prompts.GetLatencyDistributionDescription,
+	}, apm.NewGetLatencyDistributionHandler(client, cfg))
+
+	// Register burn rate evaluation tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "evaluate_burn_rate",
+		Description: prompts.EvaluateBurnRateDescription,
+	}, apm.NewEvaluateBurnRateHandler(client, cfg))
+
+	// Register label cardinality tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "analyze_cardinality",
+		Description: prompts.AnalyzeCardinalityDescription,
+	}, apm.NewAnalyzeCardinalityHandler(client, cfg))
+
+	// Register ingestion volume tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_ingestion_volume",
+		Description: prompts.GetIngestionVolumeDescription,
+	}, apm.NewGetIngestionVolumeHandler(client, cfg))
+
+	// Register PromQL label values tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "prometheus_label_values",
+		Description: prompts.PromqlLabelValuesQueryDetails,
+	}, apm.NewPromqlLabelValuesHandler(client, cfg))
+
+	// Register PromQL labels tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "prometheus_labels",
+		Description: prompts.PromqlLabelsQueryDetails,
+	}, apm.NewPromqlLabelsHandler(client, cfg))
+
+	// Register watch tools
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "create_watch",
+		Description: prompts.CreateWatchDescription,
+	}, watch.NewCreateWatchHandler(t.watches))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_watches",
+		Description: prompts.ListWatchesDescription,
+	}, watch.NewListWatchesHandler(t.watches))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "delete_watch",
+		Description: prompts.DeleteWatchDescription,
+	}, watch.NewDeleteWatchHandler(t.watches))
+
+	// Register logs tool (enhanced with log query instructions + labels)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_logs",
+		Description: getLogsDesc,
+	}, logs.NewGetLogsHandler(client, cfg))
+
+	// Register service logs tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_logs",
+		Description: getServiceLogsDesc,
+	}, logs.NewGetServiceLogsHandler(client, cfg))
+
+	// Register drop rules tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_drop_rules",
+		Description: prompts.GetDropRulesDescription,
+	}, logs.NewGetDropRulesHandler(client, cfg))
+
+	// Register add drop rule tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "add_drop_rule",
+		Description: prompts.AddDropRuleDescription,
+	}, logs.NewAddDropRuleHandler(client, cfg))
+
+	// Register notification channels tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_notification_channels",
+		Description: prompts.GetNotificationChannelsDescription,
+	}, alerting.NewGetNotificationChannelsHandler(client, cfg))
+
+	// Register alert config tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_alert_config",
+		Description: prompts.GetAlertConfigDescription,
+	}, alerting.NewGetAlertConfigHandler(client, cfg))
+
+	// Register entity alert rules tool (entity-scoped, includes expression_args and resolved PromQL)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_entity_alert_rules",
+		Description: prompts.GetEntityAlertRulesDescription,
+	}, alerting.NewGetEntityAlertRulesHandler(client, cfg))
+
+	// Register alerts tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_alerts",
+		Description: prompts.GetAlertsDescription,
+	}, alerting.NewGetAlertsHandler(client, cfg, t.maintenance))
+
+	// Register get alert rule state tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_alert_rule_state",
+		Description: prompts.GetAlertRuleStateDescription,
+	}, alerting.NewAlertRuleStateHandler(client, cfg))
+
+	// Register alert flapping analysis tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "analyze_alert_flapping",
+		Description: prompts.AnalyzeAlertFlappingDescription,
+	}, alerting.NewAnalyzeAlertFlappingHandler(client, cfg))
+
+	// Register the alert rule write tool only when write tools are enabled.
+	// Its name stays known, so the allowlist accepts it either way.
+	if cfg.EnableWriteTools {
+		registerTool(server, reg, &mcp.Tool{
+			Name:        "create_alert_rule",
+			Description: prompts.CreateAlertRuleDescription,
+		}, alerting.NewCreateAlertRuleHandler(client, cfg))
+	} else {
+		reg.filter.known["create_alert_rule"] = true
+	}
+
+	// Register get traces tool (enhanced with trace query instructions)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_traces",
+		Description: getTracesDesc,
+		InputSchema: traces.GetTracesInputSchema(),
+	}, traces.NewGetTracesHandler(client, cfg))
+
+	// Register service traces tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_service_traces",
+		Description: getServiceTracesDesc,
+	}, traces.GetServiceTracesHandler(client, cfg))
+
+	// Register span attribute search tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "search_traces",
+		Description: prompts.SearchTracesDescription,
+	}, traces.NewSearchTracesHandler(client, cfg))
+
+	// Register log attributes tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_log_attributes",
+		Description: prompts.GetLogAttributesDescription,
+	}, logs.NewGetLogAttributesHandler(client, cfg))
+
+	// Register pipeline-scoped log attributes tool (discovers fields actually
+	// present for a given pipeline via the series endpoint)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_log_attributes_for_pipeline",
+		Description: prompts.GetLogAttributesForPipelineDescription,
+	}, logs.NewGetLogAttributesForPipelineHandler(client, cfg))
+
+	// Register trace attributes tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_trace_attributes",
+		Description: prompts.GetTraceAttributesDescription,
+	}, traces.NewGetTraceAttributesHandler(client, cfg))
+
+	// Register pipeline-scoped trace attributes tool (discovers attributes actually
+	// present for a given pipeline via the series endpoint)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_trace_attributes_for_pipeline",
+		Description: prompts.GetTraceAttributesForPipelineDescription,
+	}, traces.NewGetTraceAttributesForPipelineHandler(client, cfg))
+
+	// Register trace attribute values tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_trace_attribute_values",
+		Description: prompts.GetTraceAttributeValuesDescription,
+	}, traces.NewGetTraceAttributeValuesHandler(client, cfg))
+
+	// Register change events tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_change_events",
+		Description: prompts.GetChangeEventsDescription,
+	}, change_events.NewGetChangeEventsHandler(client, cfg))
+
+	// Register record deployment tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "record_deployment",
+		Description: prompts.RecordDeploymentDescription,
+	}, change_events.NewRecordDeploymentHandler(client, cfg))
+
+	// Register database discovery tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_databases",
+		Description: prompts.GetDatabasesDescription,
+	}, apm.NewGetDatabasesHandler(client, cfg))
+
+	// Register database slow queries tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_database_slow_queries",
+		Description: prompts.GetDatabaseSlowQueriesDescription,
+	}, apm.NewGetDatabaseSlowQueriesHandler(client, cfg))
+
+	// Register database query patterns tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_database_queries",
+		Description: prompts.GetDatabaseQueriesDescription,
+	}, apm.NewGetDatabaseQueriesHandler(client, cfg))
+
+	// Register database server-side metrics tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_database_server_metrics",
+		Description: prompts.GetDatabaseServerMetricsDescription,
+	}, apm.NewGetDatabaseServerMetricsHandler(client, cfg))
+
+	// Register did_you_mean tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "did_you_mean",
+		Description: prompts.DidYouMeanDescription,
+	}, suggest.NewDidYouMeanHandler(client, cfg))
+
+	// Register dashboard tools
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_dashboards",
+		Description: prompts.ListDashboardsDescription,
+	}, dashboards.NewListDashboardsHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_dashboard",
+		Description: prompts.GetDashboardDescription,
+	}, dashboards.NewGetDashboardHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "create_dashboard",
+		Description: prompts.CreateDashboardDescription,
+		InputSchema: dashboards.GetCreateDashboardInputSchema(),
+	}, dashboards.NewCreateDashboardHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "update_dashboard",
+		Description: prompts.UpdateDashboardDescription,
+		InputSchema: dashboards.GetUpdateDashboardInputSchema(),
+	}, dashboards.NewUpdateDashboardHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "delete_dashboard",
+		Description: prompts.DeleteDashboardDescription,
+	}, dashboards.NewDeleteDashboardHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_dashboard_snapshots",
+		Description: prompts.ListDashboardSnapshotsDescription,
+	}, dashboards.NewListDashboardSnapshotsHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_dashboard_snapshot",
+		Description: prompts.GetDashboardSnapshotDescription,
+	}, dashboards.NewGetDashboardSnapshotHandler(client, cfg))
+
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "delete_dashboard_snapshot",
+		Description: prompts.DeleteDashboardSnapshotDescription,
+	}, dashboards.NewDeleteDashboardSnapshotHandler(client, cfg))
+
+	// Register result chunk tool for results split by withResultLimit
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "get_result_chunk",
+		Description: prompts.GetResultChunkDescription,
+	}, resultstore.NewGetResultChunkHandler(t.results))
+
+	// Register result diff tool
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "diff_results",
+		Description: prompts.DiffResultsDescription,
+	}, resultdiff.NewDiffResultsHandler(t.results, t.archive))
+
+	// Register stored result tool for results kept by withResultArchive
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "fetch_result",
+		Description: prompts.FetchResultDescription,
+	}, resultarchive.NewFetchResultHandler(t.archive))
+
+	// Register query history tools. replay_query runs the recorded tool
+	// in-process, so a replay is recorded like the original query. It looks
+	// tools up in a copy of the in-process calls: the PromQL tools the
+	// history records are registered by now, and define_macro adds to
+	// reg.calls while replays run.
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_query_history",
+		Description: prompts.ListQueryHistoryDescription,
+	}, queryhistory.NewListQueryHistoryHandler(t.history))
+	replayable := maps.Clone(reg.calls)
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "replay_query",
+		Description: prompts.ReplayQueryDescription,
+	}, queryhistory.NewReplayQueryHandler(t.history, func(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+		call := replayable[name]
+		if call == nil {
+			return nil, fmt.Errorf("tool %s is not enabled on this server", name)
+		}
+		return call(ctx, name, args)
+	}))
+
+	// Register saved view tools. APM tools accept a view argument that
+	// registerTool expands into the view's parameters.
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "save_view",
+		Description: prompts.SaveViewDescription,
+	}, views.NewSaveViewHandler(t.views))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_views",
+		Description: prompts.ListViewsDescription,
+	}, views.NewListViewsHandler(t.views))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "delete_view",
+		Description: prompts.DeleteViewDescription,
+	}, views.NewDeleteViewHandler(t.views))
+
+	// Register maintenance window tools. get_alerts and
+	// get_apm_service_deviations annotate findings inside declared windows.
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "declare_maintenance_window",
+		Description: prompts.DeclareMaintenanceWindowDescription,
+	}, maintenance.NewDeclareMaintenanceWindowHandler(t.maintenance))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_maintenance_windows",
+		Description: prompts.ListMaintenanceWindowsDescription,
+	}, maintenance.NewListMaintenanceWindowsHandler(t.maintenance))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "delete_maintenance_window",
+		Description: prompts.DeleteMaintenanceWindowDescription,
+	}, maintenance.NewDeleteMaintenanceWindowHandler(t.maintenance))
+
+	// Register endpoint criticality tools. get_endpoint_sla_report judges
+	// tagged endpoints against their tier's targets.
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "tag_endpoint_criticality",
+		Description: prompts.TagEndpointCriticalityDescription,
+	}, criticality.NewTagEndpointCriticalityHandler(t.criticality))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "list_endpoint_criticality",
+		Description: prompts.ListEndpointCriticalityDescription,
+	}, criticality.NewListEndpointCriticalityHandler(t.criticality))
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "delete_endpoint_criticality",
+		Description: prompts.DeleteEndpointCriticalityDescription,
+	}, criticality.NewDeleteEndpointCriticalityHandler(t.criticality))
+
+	// Register the log level tool only when admin tools are enabled: any
+	// client could otherwise turn on debug logs for everyone.
+	if cfg.EnableAdminTools {
+		registerTool(server, reg, &mcp.Tool{
+			Name:        "set_log_level",
+			Description: prompts.SetLogLevelDescription,
+		}, logging.NewSetLogLevelHandler())
+	} else {
+		reg.filter.known["set_log_level"] = true
+	}
+
+	// Register organization-specific tools declared in the custom tools file.
+	// They call their own endpoints, so they get a client without Last9 auth.
+	customTools, err := customtools.Load(cfg.CustomToolsFile)
+	if err != nil {
+		return err
+	}
+	customClient := &http.Client{}
+	for _, spec := range customTools {
+		if reg.filter.known[spec.Name] {
+			return fmt.Errorf("custom tool %q conflicts with a built-in tool", spec.Name)
+		}
+		registerTool(server, reg, &mcp.Tool{
+			Name:        spec.Name,
+			Description: spec.Description,
+			InputSchema: spec.InputSchema(),
+		}, customtools.NewHandler(customClient, spec))
+	}
+
+	// Register macros: the macros file, then those defined at runtime. Macros
+	// call the tools registered above in-process and cannot call each other.
+	if err := registerMacros(server, reg, cfg.MacrosFile, t.macros); err != nil {
+		return err
+	}
+
+	if err := reg.filter.validate(); err != nil {
+		return err
+	}
+	if len(cfg.EnabledTools) > 0 || len(cfg.DisabledTools) > 0 {
+		logging.Logger("tools").Info("tool configuration applied", "enabled_count", len(reg.registered), "enabled_tools", strings.Join(reg.registered, ","))
+	}
+	return nil
+}
+
+// describeRangeQuery and describeInstantQuery record a PromQL call in the
+// query history. A time range that does not resolve is left out; the call
+// itself reports the error.
+func describeRangeQuery(args apm.PromqlRangeQueryArgs) queryhistory.Entry {
+	e := queryhistory.Entry{Query: args.Query, Datasource: args.Datasource}
+	e.StartTime, e.EndTime, _ = args.TimeRange()
+	return e
+}
+
+func describeInstantQuery(args apm.PromqlInstantQueryArgs) queryhistory.Entry {
+	e := queryhistory.Entry{Query: args.Query, Datasource: args.Datasource}
+	e.EndTime, _ = args.EvalTime()
+	return e
+}
+
+// registerMacros registers file-defined and runtime-defined macros, and the
+// define_macro tool that adds more while the server runs.
+func registerMacros(server *last9mcp.Last9MCPServer, reg *toolRegistry, path string, defined *macros.Set) error {
+	fileMacros, err := macros.Load(path)
+	if err != nil {
+		return err
+	}
+	tools := maps.Clone(reg.calls)
+	isTool := func(name string) bool { return tools[name] != nil }
+	call := func(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+		return tools[name](ctx, name, args)
+	}
+	register := func(spec macros.Spec) {
+		registerTool(server, reg, &mcp.Tool{
+			Name:        spec.Name,
+			Description: spec.Description,
+			InputSchema: spec.InputSchema(),
+		}, macros.NewHandler(spec, call))
+	}
+
+	fromFile := map[string]bool{}
+	for _, spec := range fileMacros {
+		if err := spec.Validate(isTool); err != nil {
+			return err
+		}
+		if reg.filter.known[spec.Name] {
+			return fmt.Errorf("macro %q conflicts with an existing tool", spec.Name)
+		}
+		fromFile[spec.Name] = true
+		register(spec)
+	}
+	for _, spec := range defined.All() {
+		if err := spec.Validate(isTool); err != nil {
+			// A tool the macro used may have been disabled since it was defined.
+			logging.Logger("tools").Warn("skipping runtime macro", "macro", spec.Name, "error", err)
+			continue
+		}
+		register(spec)
+	}
+
+	var mu sync.Mutex
+	registerTool(server, reg, &mcp.Tool{
+		Name:        "define_macro",
+		Description: prompts.DefineMacroDescription,
+	}, macros.NewDefineMacroHandler(func(spec macros.Spec) error {
+		if err := spec.Validate(isTool); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if fromFile[spec.Name] || (reg.filter.known[spec.Name] && !defined.Has(spec.Name)) {
+			return fmt.Errorf("macro name %q is already used by another tool", spec.Name)
+		}
+		if !reg.filter.allows(spec.Name) {
+			return fmt.Errorf("macro name %q is excluded by enabled_tools/disabled_tools", spec.Name)
+		}
+		defined.Put(spec)
+		register(spec)
+		return nil
+	}))
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package toolset implements the Last9 tool surface: registration, the
+// middleware every tool call passes through, and the state tools share. The
+// server binary uses it directly; other programs embed the tools through
+// pkg/tools, which wraps it.
+package toolset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/attributes"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/criticality"
+	"github.com/last9/last9-mcp-server/internal/macros"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/queryhistory"
+	"github.com/last9/last9-mcp-server/internal/redact"
+	"github.com/last9/last9-mcp-server/internal/resultarchive"
+	"github.com/last9/last9-mcp-server/internal/resultstore"
+	"github.com/last9/last9-mcp-server/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/views"
+	"github.com/last9/last9-mcp-server/internal/watch"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+)
+
+// Authenticate exchanges cfg.RefreshToken for an access token and resolves
+// the organization, region and datasource settings the tools query. The
+// proxy, CA bundle and extra headers in cfg are applied to the shared HTTP
+// client first. The credentials and extra header values are registered for
+// redaction from logs and tool results.
+func Authenticate(cfg *models.Config) error {
+	err := auth.ConfigureUpstream(auth.UpstreamOptions{
+		ProxyURL:     cfg.ProxyURL,
+		CABundleFile: cfg.CABundleFile,
+		Headers:      cfg.ExtraHeaders,
+	})
+	if err != nil {
+		return err
+	}
+	for _, value := range cfg.ExtraHeaders {
+		redact.Register(value)
+	}
+	tokenManager, err := auth.NewTokenManager(cfg.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to create token manager: %w", err)
+	}
+	cfg.TokenManager = tokenManager
+	if err := utils.PopulateAPICfg(cfg); err != nil {
+		return fmt.Errorf("failed to populate API config: %w", err)
+	}
+	redact.Register(cfg.RefreshToken, cfg.PrometheusPassword)
+	for _, ds := range cfg.Datasources {
+		redact.Register(ds.Password)
+	}
+	return nil
+}
+
+// Toolset is the Last9 tool surface for one configuration, together with
+// the state its tools share: the attribute cache behind tool descriptions,
+// background watches, the store for results split across messages, the
+// stored results fetch_result reads, macros defined at runtime, the PromQL
+// query history, saved views, declared maintenance windows and endpoint
+// criticality tags.
+type Toolset struct {
+	cfg         models.Config
+	attrCache   *attributes.AttributeCache
+	watches     *watch.Manager
+	results     *resultstore.Store
+	archive     *resultarchive.Store
+	macros      *macros.Set
+	history     *queryhistory.Store
+	views       *views.Store
+	maintenance *maintenance.Store
+	criticality *criticality.Store
+}
+
+// New returns a Toolset for cfg. Call Close when done to stop watches.
+func New(cfg models.Config) *Toolset {
+	return &Toolset{
+		cfg:         cfg,
+		attrCache:   attributes.NewAttributeCache(auth.GetHTTPClient(), cfg),
+		watches:     watch.NewManager(auth.GetHTTPClient(), cfg),
+		results:     resultstore.New(cfg.MaxMessageBytes),
+		archive:     resultarchive.New(cfg.ResultArchiveDir, time.Duration(cfg.ResultTTLHours)*time.Hour, resultarchive.DefaultMaxEntries),
+		macros:      macros.NewSet(),
+		history:     queryhistory.New(cfg.QueryHistoryFile, queryhistory.DefaultMaxEntries),
+		views:       views.New(cfg.ViewsFile),
+		maintenance: maintenance.New(cfg.MaintenanceFile),
+		criticality: criticality.New(cfg.CriticalityFile),
+	}
+}
+
+// Warm fetches the attribute names and environments listed in tool
+// descriptions. It is best effort: on failure the descriptions omit them.
+// Call it before Register, or use Preflight, which does both and reports
+// what it found.
+func (t *Toolset) Warm(ctx context.Context) {
+	t.attrCache.Warm(ctx)
+}
+
+// Register adds the configured tools to server. Registering again replaces
+// the tools with fresh descriptions.
+func (t *Toolset) Register(server *last9mcp.Last9MCPServer) error {
+	return registerAllTools(server, t)
+}
+
+// Refresh reloads the attribute names if they are stale and re-registers
+// the tools on server so their descriptions reflect them.
+func (t *Toolset) Refresh(ctx context.Context, server *last9mcp.Last9MCPServer) error {
+	if err := t.attrCache.RefreshIfStale(ctx); err != nil {
+		return fmt.Errorf("failed to refresh attribute cache: %w", err)
+	}
+	return t.Register(server)
+}
+
+// Close stops background watches.
+func (t *Toolset) Close() {
+	t.watches.Close()
+}
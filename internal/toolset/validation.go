@@ -0,0 +1,137 @@
+package toolset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/utils"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// enumHint returns the values listed by a "one of: a, b, c" hint in an
+// argument description, which is how tools declare enumerated string
+// arguments. Handlers match the values case-insensitively, so they are
+// checked by argRules rather than declared as schema enums, which are
+// case-sensitive.
+func enumHint(desc string) []string {
+	_, rest, ok := strings.Cut(desc, "one of: ")
+	if !ok {
+		return nil
+	}
+	if i := strings.IndexAny(rest, ");"); i >= 0 {
+		rest = rest[:i]
+	}
+	var values []string
+	for _, v := range strings.Split(rest, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// argRules are the checks run on a tool's arguments before its handler:
+// required arguments, RFC3339 timestamps and enumerated values.
+type argRules struct {
+	required   []string
+	timestamps []string // string arguments named *_iso
+	enums      map[string][]string
+}
+
+// newArgRules derives the argument checks for a tool from its input schema
+// (see inputSchema): enumerated values come from enumHint, and string enums
+// declared in the schema, as custom tools can, are checked too. Timestamp arguments are marked with the
+// date-time format in the schema, which the SDK passes on to clients.
+func newArgRules[In any](tool *mcp.Tool) (argRules, error) {
+	schema, err := inputSchema[In](tool)
+	if err != nil {
+		return argRules{}, err
+	}
+	rules := argRules{required: schema.Required, enums: map[string][]string{}}
+	for name, prop := range schema.Properties {
+		if prop == nil {
+			continue
+		}
+		if strings.HasSuffix(name, "_iso") && schemaType(prop) == "string" {
+			rules.timestamps = append(rules.timestamps, name)
+			prop.Format = "date-time"
+		}
+		var values []string
+		for _, v := range prop.Enum {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		if hinted := enumHint(prop.Description); len(hinted) > 0 {
+			values = hinted
+		}
+		if len(values) > 0 {
+			rules.enums[name] = values
+		}
+	}
+	slices.Sort(rules.timestamps)
+	tool.InputSchema = schema
+	return rules, nil
+}
+
+// check validates args and returns one error per offending argument.
+// Omitted, null and empty optional arguments are left to the handler
+// defaults. Required arguments may come from a saved view, so they are not
+// checked when the call names one.
+func (r argRules) check(args map[string]any) error {
+	var errs []error
+	view, _ := args["view"].(string)
+	for _, name := range r.required {
+		if v, ok := args[name]; view == "" && (!ok || v == nil) {
+			errs = append(errs, fmt.Errorf("%s: required argument is missing", name))
+		}
+	}
+	for _, name := range r.timestamps {
+		s, _ := args[name].(string)
+		if s == "" {
+			continue
+		}
+		if _, err := utils.ParseToolTimestamp(s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	names := make([]string, 0, len(r.enums))
+	for name := range r.enums {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		s, _ := args[name].(string)
+		if s == "" {
+			continue
+		}
+		values := r.enums[name]
+		if !slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, strings.TrimSpace(s)) }) {
+			errs = append(errs, fmt.Errorf("%s: %q is not one of %s", name, s, strings.Join(values, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withArgValidation runs rules on the raw call arguments before the handler,
+// so malformed arguments fail with field-level errors before any upstream
+// request. Calls from macros, which skip the SDK's schema validation, are
+// checked the same way.
+func withArgValidation[In any](name string, rules argRules, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		raw := map[string]any{}
+		if req != nil && req.Params != nil && len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &raw); err != nil {
+				return nil, nil, fmt.Errorf("invalid arguments for %s: %w", name, err)
+			}
+		}
+		if err := rules.check(raw); err != nil {
+			return nil, nil, fmt.Errorf("invalid arguments for %s:\n%w", name, err)
+		}
+		return handler(ctx, req, args)
+	}
+}
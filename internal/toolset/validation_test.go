@@ -0,0 +1,101 @@
+package toolset
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type validationTestArgs struct {
+	ServiceName  string `json:"service_name"`
+	StartTimeISO string `json:"start_time_iso,omitempty"`
+	SortBy       string `json:"sort_by,omitempty" jsonschema:"Rank order (optional, one of: error_percent, error_rate, latency, throughput)"`
+	View         string `json:"view,omitempty"`
+}
+
+func TestArgRulesCheck(t *testing.T) {
+	tool := &mcp.Tool{Name: "get_service_summary"}
+	rules, err := newArgRules[validationTestArgs](tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr []string
+	}{
+		{"valid", map[string]any{"service_name": "api", "start_time_iso": "2024-06-01T12:00:00.5+05:30", "sort_by": "Latency"}, nil},
+		{"empty optional", map[string]any{"service_name": "api", "start_time_iso": "", "sort_by": ""}, nil},
+		{"view supplies required", map[string]any{"view": "checkout-prod"}, nil},
+		{"missing required", map[string]any{}, []string{"service_name: required argument is missing"}},
+		{"bad timestamp", map[string]any{"service_name": "api", "start_time_iso": "2024-06-01 12:00"}, []string{"start_time_iso: unsupported time format"}},
+		{"bad enum", map[string]any{"service_name": "api", "sort_by": "p99"}, []string{`sort_by: "p99" is not one of error_percent, error_rate, latency, throughput`}},
+		{"all errors", map[string]any{"start_time_iso": "yesterday", "sort_by": "p99"}, []string{"service_name:", "start_time_iso:", "sort_by:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.check(tt.args)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("check() = nil, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("check() = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+
+	if rules.timestamps[0] != "start_time_iso" {
+		t.Errorf("timestamps = %v", rules.timestamps)
+	}
+	if rules.enums["sort_by"] == nil {
+		t.Errorf("sort_by enum not picked up from its description: %v", rules.enums)
+	}
+}
+
+func TestEnumHint(t *testing.T) {
+	tests := map[string][]string{
+		"Rank order (optional, one of: error_percent, latency)": {"error_percent", "latency"},
+		"Comparison (required, one of: >, >=, <, <=)":           {">", ">=", "<", "<="},
+		"Level (one of: debug, info; e.g. debug)":               {"debug", "info"},
+		"Service name (required)":                               nil,
+	}
+	for desc, want := range tests {
+		if got := enumHint(desc); !slices.Equal(got, want) {
+			t.Errorf("enumHint(%q) = %v, want %v", desc, got, want)
+		}
+	}
+}
+
+// TestArgValidationBeforeHandler calls a registered tool with bad arguments
+// through the SDK and checks the field-level errors come back as a tool error.
+func TestArgValidationBeforeHandler(t *testing.T) {
+	session := newInMemoryClientSession(t)
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "get_service_summary",
+		Arguments: map[string]any{"start_time_iso": "last tuesday", "sort_by": "p99"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool error = %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected a tool error")
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	for _, want := range []string{"invalid arguments for get_service_summary", "start_time_iso: unsupported time format", `sort_by: "p99"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("error %q does not contain %q", text, want)
+		}
+	}
+}
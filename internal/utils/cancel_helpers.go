@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cancellationGrace is how long after cancellation an upstream request may
+// still arrive: one that was already being dialled when the context was
+// cancelled.
+const cancellationGrace = 100 * time.Millisecond
+
+// AssertCancelsPromptly runs call against an upstream whose every request
+// blocks until the request context is done. Once the first request arrives
+// the call's context is cancelled; call must then return an error within a
+// second and must not start further upstream requests.
+//
+// call receives the context to pass to the handler and the upstream base URL
+// (use it as cfg.APIBaseURL).
+func AssertCancelsPromptly(t *testing.T, call func(ctx context.Context, baseURL string) error) {
+	t.Helper()
+
+	var (
+		started     = make(chan struct{})
+		startedOnce sync.Once
+		cancelledAt atomic.Int64
+		late        atomic.Int32
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if at := cancelledAt.Load(); at != 0 && time.Since(time.Unix(0, at)) > cancellationGrace {
+			late.Add(1)
+		}
+		// Drain the body: net/http only notices a client disconnect (and
+		// cancels r.Context) once the request body has been consumed.
+		_, _ = io.Copy(io.Discard, r.Body)
+		startedOnce.Do(func() { close(started) })
+		<-r.Context().Done()
+	}))
+	defer func() {
+		upstream.CloseClientConnections()
+		upstream.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- call(ctx, upstream.URL) }()
+
+	select {
+	case <-started:
+	case err := <-done:
+		t.Fatalf("call returned before reaching the upstream: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("call never reached the upstream")
+	}
+
+	cancelledAt.Store(time.Now().UnixNano())
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("call returned nil error after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call did not return within 1s of cancellation")
+	}
+	if n := late.Load(); n > 0 {
+		t.Errorf("%d upstream request(s) started after cancellation", n)
+	}
+}
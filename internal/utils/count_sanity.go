@@ -8,8 +8,8 @@ import (
 	"net/http"
 	"strconv"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 // HasCountAggregateStage reports whether pipeline contains an aggregate or
@@ -219,7 +219,7 @@ type promInstantVectorPoint struct {
 // pure guardrail add-on: on any failure to determine a single service, parse
 // the matched count, or fetch/parse the baseline, it returns response
 // unchanged. Never blocks or alters the underlying result.
-func AppendCountSanity(ctx context.Context, client *http.Client, cfg models.Config, pipeline []map[string]interface{}, startMs, endMs int64, response map[string]interface{}) map[string]interface{} {
+func AppendCountSanity(ctx context.Context, client HTTPClient, cfg models.Config, pipeline []map[string]interface{}, startMs, endMs int64, response map[string]interface{}) map[string]interface{} {
 	if !HasCountAggregateStage(pipeline) {
 		return response
 	}
@@ -9,8 +9,8 @@ import (
 	"testing"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 func countAggregatePipeline(serviceEqValues ...string) []map[string]interface{} {
@@ -9,8 +9,8 @@ import (
 	"net/url"
 	"strings"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 const (
@@ -44,7 +44,7 @@ func NormalizeLogIndex(index string) (string, error) {
 	return parsed.value, nil
 }
 
-func ResolveLogIndexDashboardParam(ctx context.Context, client *http.Client, cfg models.Config, index string) (string, error) {
+func ResolveLogIndexDashboardParam(ctx context.Context, client HTTPClient, cfg models.Config, index string) (string, error) {
 	if strings.TrimSpace(index) == "" {
 		return "", nil
 	}
@@ -100,7 +100,7 @@ func parseLogIndex(index string) (parsedLogIndex, error) {
 	}
 }
 
-func fetchPhysicalIndexID(ctx context.Context, client *http.Client, cfg models.Config, name string) (string, error) {
+func fetchPhysicalIndexID(ctx context.Context, client HTTPClient, cfg models.Config, name string) (string, error) {
 	var response struct {
 		Properties []struct {
 			ID   string `json:"id"`
@@ -124,7 +124,7 @@ func fetchPhysicalIndexID(ctx context.Context, client *http.Client, cfg models.C
 	return "", fmt.Errorf("physical index %q not found", name)
 }
 
-func fetchRehydrationIndexID(ctx context.Context, client *http.Client, cfg models.Config, blockName string) (string, error) {
+func fetchRehydrationIndexID(ctx context.Context, client HTTPClient, cfg models.Config, blockName string) (string, error) {
 	var response struct {
 		Properties []struct {
 			ID        string `json:"id"`
@@ -148,7 +148,7 @@ func fetchRehydrationIndexID(ctx context.Context, client *http.Client, cfg model
 	return "", fmt.Errorf("rehydration index %q not found", blockName)
 }
 
-func fetchLogsSettings(ctx context.Context, client *http.Client, cfg models.Config, endpoint string, target any) error {
+func fetchLogsSettings(ctx context.Context, client HTTPClient, cfg models.Config, endpoint string, target any) error {
 	queryParams := url.Values{}
 	queryParams.Set("region", cfg.Region)
 
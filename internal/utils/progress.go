@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+// ProgressReporter emits MCP progress notifications for tools that run a
+// fixed sequence of upstream queries. It is a no-op when the client did not
+// send a progress token, so handlers can call it unconditionally.
+type ProgressReporter struct {
+	req   *mcp.CallToolRequest
+	token any
+	total int
+	done  int
+}
+
+// NewProgressReporter returns a reporter for a call made of total steps.
+func NewProgressReporter(req *mcp.CallToolRequest, total int) *ProgressReporter {
+	p := &ProgressReporter{req: req, total: total}
+	if req != nil && req.Session != nil && req.Params != nil {
+		p.token = req.Params.GetProgressToken()
+	}
+	return p
+}
+
+// Step reports that the next step, described by message, is starting. It
+// returns the context error when the call has been cancelled, so handlers
+// stop issuing further sub-queries mid-flight.
+func (p *ProgressReporter) Step(ctx context.Context, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.token != nil {
+		err := p.req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+			ProgressToken: p.token,
+			Progress:      float64(p.done),
+			Total:         float64(p.total),
+			Message:       message,
+		})
+		if err != nil {
+			// Progress is best effort; never fail the tool call over it.
+			logging.Logger("mcp").Debug("failed to send progress notification", "error", err)
+		}
+	}
+	p.done++
+	return nil
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestProgressReporter_SendsNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "slow"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		progress := NewProgressReporter(req, 2)
+		for _, step := range []string{"throughput", "latency"} {
+			if err := progress.Step(ctx, step); err != nil {
+				return nil, nil, err
+			}
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil, nil
+	})
+
+	var (
+		mu       sync.Mutex
+		received []*mcp.ProgressNotificationParams
+		got      = make(chan struct{}, 2)
+	)
+	client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "0"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			received = append(received, req.Params)
+			mu.Unlock()
+			got <- struct{}{}
+		},
+	})
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	params := &mcp.CallToolParams{Name: "slow", Arguments: map[string]any{}}
+	params.SetProgressToken("tok-1")
+	if _, err := session.CallTool(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+	<-got
+	<-got
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("received %d notifications, want 2", len(received))
+	}
+	for i, want := range []string{"throughput", "latency"} {
+		p := received[i]
+		if p.ProgressToken != "tok-1" || p.Message != want || p.Progress != float64(i) || p.Total != 2 {
+			t.Errorf("notification %d = %+v, want step %q %d/2", i, p, want, i)
+		}
+	}
+}
+
+func TestProgressReporter_NoTokenAndCancellation(t *testing.T) {
+	// Without a session or progress token Step is a no-op.
+	progress := NewProgressReporter(&mcp.CallToolRequest{}, 3)
+	if err := progress.Step(context.Background(), "first"); err != nil {
+		t.Fatalf("Step() = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := progress.Step(ctx, "second"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Step() after cancel = %v, want context.Canceled", err)
+	}
+}
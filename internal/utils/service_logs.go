@@ -11,8 +11,8 @@ import (
 	"net/url"
 	"strings"
 
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 // pipelineSchemaHint teaches the model how to fix a rejected pipeline instead
@@ -20,7 +20,7 @@ import (
 const pipelineSchemaHint = `Pipeline stage schema: every stage needs "type" (filter | parse | aggregate | window_aggregate). filter: {"type":"filter","query":{"$and":[{"$eq":["ServiceName","svc"]},{"$containsWords":["Body","text"]}]}}. Field names are exact: ServiceName (not service_name), Body, SeverityText; attributes['name'] / resources['name'] for others. Body-derived JSON fields need a parse stage first. To get exact field names + required parse stages for your scope, call get_log_attributes_for_pipeline with your filter stages.`
 
 // MakeLogsJSONQueryAPI posts a raw log JSON pipeline to the query_range API with the given time range.
-func MakeLogsJSONQueryAPI(ctx context.Context, client *http.Client, cfg models.Config, pipeline any, startMs, endMs int64, limit int, index string) (*http.Response, error) {
+func MakeLogsJSONQueryAPI(ctx context.Context, client HTTPClient, cfg models.Config, pipeline any, startMs, endMs int64, limit int, index string) (*http.Response, error) {
 	// Basic validation
 	if client == nil {
 		return nil, errors.New("http client cannot be nil")
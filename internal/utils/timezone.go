@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DisplayTimeLayout is the human-readable layout used for localized timestamps.
+const DisplayTimeLayout = "2006-01-02 15:04:05 MST"
+
+// localSuffix is appended to a timestamp field's key to name its localized sibling.
+const localSuffix = "_local"
+
+// timestampKeys are field names treated as timestamps regardless of suffix.
+var timestampKeys = map[string]bool{
+	"timestamp": true,
+	"time":      true,
+	"ts":        true,
+	"start":     true,
+	"end":       true,
+}
+
+// timestampKeySuffixes mark a field as a timestamp by naming convention.
+var timestampKeySuffixes = []string{"_time", "_at", "_ts", "_timestamp", "_start", "_end", "_iso"}
+
+// LoadDisplayLocation resolves an IANA timezone name (e.g. "Asia/Kolkata").
+// An empty name returns nil, meaning localization is disabled.
+func LoadDisplayLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid display_timezone %q: use an IANA name such as \"Asia/Kolkata\" or \"America/Los_Angeles\"", name)
+	}
+	return loc, nil
+}
+
+// LocalizeTimestamps adds a "<key>_local" sibling next to every timestamp field
+// in a JSON document, formatted in loc. Epoch values in seconds, milliseconds,
+// microseconds or nanoseconds (numbers or numeric strings) and RFC3339 strings
+// are recognised; a "timestamps" array gains a parallel "timestamps_local"
+// array, and Prometheus [timestamp, value] samples gain their local time: a
+// "value" sample a "value_local" string and a "values" array of samples a
+// parallel "values_local" array. Non-JSON input, or a nil loc, is returned unchanged. Object keys are
+// re-emitted in sorted order.
+func LocalizeTimestamps(text string, loc *time.Location) string {
+	if loc == nil {
+		return text
+	}
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return text
+	}
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return text
+	}
+	if !localizeValue(doc, loc) {
+		return text
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if strings.Contains(trimmed, "\n") {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(doc); err != nil {
+		return text
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// localizeValue walks v in place and reports whether anything was added.
+func localizeValue(v any, loc *time.Location) bool {
+	changed := false
+	switch node := v.(type) {
+	case map[string]any:
+		additions := map[string]any{}
+		for key, child := range node {
+			if localizeValue(child, loc) {
+				changed = true
+			}
+			if strings.HasSuffix(key, localSuffix) {
+				continue
+			}
+			if _, exists := node[key+localSuffix]; exists {
+				continue
+			}
+			if key == "timestamps" {
+				if arr, ok := child.([]any); ok {
+					if local, ok := localizeArray(arr, loc); ok {
+						additions[key+localSuffix] = local
+					}
+				}
+				continue
+			}
+			if key == "value" {
+				if t, ok := sampleTime(child); ok {
+					additions[key+localSuffix] = t.In(loc).Format(DisplayTimeLayout)
+				}
+				continue
+			}
+			if key == "values" {
+				if local, ok := localizeSamples(child, loc); ok {
+					additions[key+localSuffix] = local
+				}
+				continue
+			}
+			if !isTimestampKey(key) {
+				continue
+			}
+			if t, ok := parseTimestamp(child); ok {
+				additions[key+localSuffix] = t.In(loc).Format(DisplayTimeLayout)
+			}
+		}
+		for key, val := range additions {
+			node[key] = val
+			changed = true
+		}
+	case []any:
+		for _, child := range node {
+			if localizeValue(child, loc) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func localizeArray(arr []any, loc *time.Location) ([]string, bool) {
+	if len(arr) == 0 {
+		return nil, false
+	}
+	out := make([]string, len(arr))
+	for i, el := range arr {
+		t, ok := parseTimestamp(el)
+		if !ok {
+			return nil, false
+		}
+		out[i] = t.In(loc).Format(DisplayTimeLayout)
+	}
+	return out, true
+}
+
+// localizeSamples formats the timestamps of an array of Prometheus
+// [timestamp, value] samples in loc.
+func localizeSamples(v any, loc *time.Location) ([]string, bool) {
+	arr, ok := v.([]any)
+	if !ok || len(arr) == 0 {
+		return nil, false
+	}
+	out := make([]string, len(arr))
+	for i, el := range arr {
+		t, ok := sampleTime(el)
+		if !ok {
+			return nil, false
+		}
+		out[i] = t.In(loc).Format(DisplayTimeLayout)
+	}
+	return out, true
+}
+
+// sampleTime returns the timestamp of a Prometheus [timestamp, value] sample.
+func sampleTime(v any) (time.Time, bool) {
+	pair, ok := v.([]any)
+	if !ok || len(pair) != 2 {
+		return time.Time{}, false
+	}
+	return parseTimestamp(pair[0])
+}
+
+func isTimestampKey(key string) bool {
+	if timestampKeys[key] {
+		return true
+	}
+	for _, suffix := range timestampKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimestamp interprets v as an epoch (unit inferred from magnitude) or an
+// RFC3339 string. Values outside a plausible range (2001 onwards) are rejected
+// so durations and counts that happen to share a timestamp-like key are skipped.
+func parseTimestamp(v any) (time.Time, bool) {
+	var raw string
+	switch val := v.(type) {
+	case json.Number:
+		raw = val.String()
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			return t, true
+		}
+		raw = val
+	default:
+		return time.Time{}, false
+	}
+
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return time.Time{}, false
+	}
+	switch {
+	case f >= 1e9 && f < 1e11:
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	case f >= 1e12 && f < 1e14:
+		return time.UnixMilli(int64(f)), true
+	case f >= 1e15 && f < 1e17:
+		return time.UnixMicro(int64(f)), true
+	case f >= 1e18 && f < 1e20:
+		// Parse nanoseconds as an integer when possible to avoid float rounding.
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return time.Unix(0, n), true
+		}
+		return time.Unix(0, int64(f)), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func mustLoc(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := LoadDisplayLocation(name)
+	if err != nil {
+		t.Fatalf("LoadDisplayLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestLoadDisplayLocation(t *testing.T) {
+	if loc, err := LoadDisplayLocation(""); err != nil || loc != nil {
+		t.Errorf("empty name: got (%v, %v), want (nil, nil)", loc, err)
+	}
+	if _, err := LoadDisplayLocation("Mars/Olympus_Mons"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+	if loc := mustLoc(t, "Asia/Kolkata"); loc.String() != "Asia/Kolkata" {
+		t.Errorf("loc = %v", loc)
+	}
+}
+
+func TestLocalizeTimestamps(t *testing.T) {
+	loc := mustLoc(t, "Asia/Kolkata")
+	// 2024-06-01T12:00:00Z == 2024-06-01 17:30:00 IST
+	in := `{"timestamp":1717243200,"created_at":"2024-06-01T12:00:00Z","last_fired_at":1717243200000,` +
+		`"logs":[{"ts":"1717243200000000000","line":"x"}],"timestamps":[1717243200,1717243260],` +
+		`"duration_ms":1500,"start_time":42,"name":"a<b"}`
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(LocalizeTimestamps(in, loc)), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+
+	const want = "2024-06-01 17:30:00 IST"
+	for _, key := range []string{"timestamp_local", "created_at_local", "last_fired_at_local"} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %q", key, got[key], want)
+		}
+	}
+	if entry := got["logs"].([]any)[0].(map[string]any); entry["ts_local"] != want {
+		t.Errorf("nested ts_local = %v, want %q", entry["ts_local"], want)
+	}
+	if locals, ok := got["timestamps_local"].([]any); !ok || len(locals) != 2 || locals[1] != "2024-06-01 17:31:00 IST" {
+		t.Errorf("timestamps_local = %v", got["timestamps_local"])
+	}
+	if _, ok := got["duration_ms_local"]; ok {
+		t.Error("non-timestamp key should not be localized")
+	}
+	if _, ok := got["start_time_local"]; ok {
+		t.Error("implausible epoch should not be localized")
+	}
+	if got["name"] != "a<b" {
+		t.Errorf("name = %v, HTML must not be escaped", got["name"])
+	}
+}
+
+func TestLocalizeTimestamps_Passthrough(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	for _, in := range []string{
+		"plain text response",
+		`{"count": 3}`,
+		`{"broken":`,
+	} {
+		if out := LocalizeTimestamps(in, loc); out != in {
+			t.Errorf("LocalizeTimestamps(%q) = %q, want unchanged", in, out)
+		}
+	}
+	if out := LocalizeTimestamps(`{"timestamp":1717243200}`, nil); out != `{"timestamp":1717243200}` {
+		t.Errorf("nil location should be a no-op, got %q", out)
+	}
+}
+
+func TestLocalizeTimestamps_PreservesNumbers(t *testing.T) {
+	out := LocalizeTimestamps(`{"timestamp":1717243200.5,"value":12345678901234567890}`, time.UTC)
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got["value"]) != "12345678901234567890" {
+		t.Errorf("value = %s, large numbers must round-trip exactly", got["value"])
+	}
+	if string(got["timestamp_local"]) != `"2024-06-01 12:00:00 UTC"` {
+		t.Errorf("timestamp_local = %s", got["timestamp_local"])
+	}
+}
+
+func TestLocalizeTimestamps_PromSamples(t *testing.T) {
+	loc := mustLoc(t, "Asia/Kolkata")
+	in := `[{"metric":{"job":"api"},"values":[[1717243200,"1"],[1717243260,"2"]]},` +
+		`{"metric":{"job":"web"},"value":[1717243200,"3"]},{"values":[1,2,3]}]`
+
+	var got []map[string]any
+	if err := json.Unmarshal([]byte(LocalizeTimestamps(in, loc)), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if locals, ok := got[0]["values_local"].([]any); !ok || len(locals) != 2 || locals[1] != "2024-06-01 17:31:00 IST" {
+		t.Errorf("values_local = %v", got[0]["values_local"])
+	}
+	if got[1]["value_local"] != "2024-06-01 17:30:00 IST" {
+		t.Errorf("value_local = %v", got[1]["value_local"])
+	}
+	if _, ok := got[2]["values_local"]; ok {
+		t.Error("values that are not samples should not be localized")
+	}
+}
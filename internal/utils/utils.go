@@ -12,11 +12,9 @@ import (
 	"strings"
 	"time"
 
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/constants"
-	"last9-mcp/internal/models"
-
-	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
 )
 
 // Constants for time-related values
@@ -26,94 +24,79 @@ const (
 	// MaxLogAttributeLookbackMinutes caps the time window for attribute discovery
 	// queries. Longer windows return the same label set at higher cost.
 	MaxLogAttributeLookbackMinutes = 60
-	// TokenRefreshBuffer is the percentage of token lifetime to refresh before expiry (50%)
-	TokenRefreshBufferPercent = 50
+	// TokenRefreshBufferPercent is the percentage of token lifetime to refresh before expiry (50%)
+	TokenRefreshBufferPercent = constants.TokenRefreshBufferPercent
 )
 
-// ParseToolTimestamp parses tool timestamp input into UTC.
-//
-// Accepted formats:
-// - RFC3339Nano (canonical)
-// - RFC3339 (canonical)
-// - "2006-01-02 15:04:05" (legacy compatibility; interpreted as UTC)
+// ParseToolTimestamp parses an RFC3339/ISO8601 tool timestamp into UTC.
+// Fractional seconds and numeric offsets are accepted.
 func ParseToolTimestamp(value string) (time.Time, error) {
-	layouts := []string{
-		time.RFC3339Nano,
-		time.RFC3339,
-		"2006-01-02 15:04:05",
-	}
-
-	for _, layout := range layouts {
-		if parsed, err := time.Parse(layout, value); err == nil {
-			return parsed.UTC(), nil
-		}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"unsupported time format %q. Use RFC3339/ISO8601 like 2026-02-09T15:04:05Z",
+			value,
+		)
 	}
+	return parsed.UTC(), nil
+}
 
-	return time.Time{}, fmt.Errorf(
-		"unsupported time format %q. Use RFC3339/ISO8601 like 2026-02-09T15:04:05Z",
-		value,
-	)
+// TimeRange is the start_time_iso / end_time_iso / lookback_minutes window
+// accepted by tool arguments. Zero values mean "not provided".
+type TimeRange struct {
+	StartTimeISO    string
+	EndTimeISO      string
+	LookbackMinutes float64
 }
 
-// GetTimeRange returns start and end times based on lookback minutes.
-// If start_time_iso and end_time_iso are provided, they take precedence.
-// Otherwise, returns now and now - lookbackMinutes.
-func GetTimeRange(params map[string]interface{}, defaultLookbackMinutes int) (startTime, endTime time.Time, err error) {
-	return GetTimeRangeAt(params, defaultLookbackMinutes, time.Now().UTC())
+// Resolve returns start and end times for the range relative to now.
+// See ResolveAt.
+func (r TimeRange) Resolve(defaultLookbackMinutes int) (startTime, endTime time.Time, err error) {
+	return r.ResolveAt(defaultLookbackMinutes, time.Now().UTC())
 }
 
-// GetTimeRangeAt returns start and end times based on lookback minutes using the provided current time.
-func GetTimeRangeAt(params map[string]interface{}, defaultLookbackMinutes int, now time.Time) (startTime, endTime time.Time, err error) {
+// ResolveAt returns start and end times for the range using the provided
+// current time. Explicit start/end times take precedence; when only one
+// boundary is given the other is derived from the lookback, and when neither
+// is given the range is the lookback window ending at now. A zero
+// LookbackMinutes uses defaultLookbackMinutes.
+func (r TimeRange) ResolveAt(defaultLookbackMinutes int, now time.Time) (startTime, endTime time.Time, err error) {
 	// Always use UTC to ensure consistent behavior across timezones
 	endTime = now.UTC()
 
-	// First check if lookback_minutes is provided
 	lookbackMinutes := defaultLookbackMinutes
-	if rawLookback, ok := params["lookback_minutes"]; ok {
-		switch l := rawLookback.(type) {
-		case float64:
-			lookbackMinutes = int(l)
-		case int:
-			lookbackMinutes = l
-		case int64:
-			lookbackMinutes = int(l)
-		default:
-			return time.Time{}, time.Time{}, fmt.Errorf("lookback_minutes must be a number")
-		}
+	if r.LookbackMinutes != 0 {
+		lookbackMinutes = int(r.LookbackMinutes)
 	}
 	if lookbackMinutes < 1 {
 		return time.Time{}, time.Time{}, fmt.Errorf("lookback_minutes must be at least 1")
 	}
-	// Default start time based on lookback
-	startTime = endTime.Add(time.Duration(-lookbackMinutes) * time.Minute)
+	lookback := time.Duration(lookbackMinutes) * time.Minute
+	startTime = endTime.Add(-lookback)
 
-	startTimeStr, hasStart := params["start_time_iso"].(string)
-	endTimeStr, hasEnd := params["end_time_iso"].(string)
+	hasStart := r.StartTimeISO != ""
+	hasEnd := r.EndTimeISO != ""
 
-	if hasStart && startTimeStr != "" {
-		parsedStart, parseErr := ParseToolTimestamp(startTimeStr)
-		if parseErr != nil {
-			return time.Time{}, time.Time{}, fmt.Errorf("invalid start_time_iso format: %w", parseErr)
+	if hasStart {
+		startTime, err = ParseToolTimestamp(r.StartTimeISO)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start_time_iso format: %w", err)
 		}
-		startTime = parsedStart
 	}
-
-	if hasEnd && endTimeStr != "" {
-		parsedEnd, parseErr := ParseToolTimestamp(endTimeStr)
-		if parseErr != nil {
-			return time.Time{}, time.Time{}, fmt.Errorf("invalid end_time_iso format: %w", parseErr)
+	if hasEnd {
+		endTime, err = ParseToolTimestamp(r.EndTimeISO)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end_time_iso format: %w", err)
 		}
-		endTime = parsedEnd
 	}
 
 	// Apply lookback defaults only when one explicit boundary is provided.
-	if hasStart && startTimeStr != "" && (!hasEnd || endTimeStr == "") {
-		endTime = startTime.Add(time.Duration(lookbackMinutes) * time.Minute)
-	} else if hasEnd && endTimeStr != "" && (!hasStart || startTimeStr == "") {
-		startTime = endTime.Add(time.Duration(-lookbackMinutes) * time.Minute)
+	if hasStart && !hasEnd {
+		endTime = startTime.Add(lookback)
+	} else if hasEnd && !hasStart {
+		startTime = endTime.Add(-lookback)
 	}
 
-	// Validate time range
 	if startTime.After(endTime) {
 		return time.Time{}, time.Time{}, fmt.Errorf("start_time cannot be after end_time")
 	}
@@ -121,7 +104,13 @@ func GetTimeRangeAt(params map[string]interface{}, defaultLookbackMinutes int, n
 	return startTime, endTime, nil
 }
 
-func MakePromInstantAPIQuery(ctx context.Context, client *http.Client, promql string, endTimeParam int64, cfg models.Config) (*http.Response, error) {
+// HTTPClient sends Last9 API requests. *http.Client implements it; tests
+// substitute clients that serve recorded responses.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+func MakePromInstantAPIQuery(ctx context.Context, client HTTPClient, promql string, endTimeParam int64, cfg models.Config) (*http.Response, error) {
 	promInstantParam := struct {
 		Query     string `json:"query"`
 		Timestamp int64  `json:"timestamp"`
@@ -144,7 +133,7 @@ func MakePromInstantAPIQuery(ctx context.Context, client *http.Client, promql st
 	return client.Do(req)
 }
 
-func MakePromRangeAPIQuery(ctx context.Context, client *http.Client, promql string, startTimeParam, endTimeParam int64, cfg models.Config) (*http.Response, error) {
+func MakePromRangeAPIQuery(ctx context.Context, client HTTPClient, promql string, startTimeParam, endTimeParam int64, cfg models.Config) (*http.Response, error) {
 	// The Last9 PromQL HTTP endpoint treats `timestamp` as the END of the
 	// query window and runs Prometheus over [timestamp - window, timestamp]
 	// (this is also how MakePromInstantAPIQuery above uses endTimeParam as
@@ -185,7 +174,7 @@ func MakePromRangeAPIQuery(ctx context.Context, client *http.Client, promql stri
 // function to get the values of a particular label, for a given query filter
 // path: /prom_label_values
 
-func MakePromLabelValuesAPIQuery(ctx context.Context, client *http.Client, label string, matches string, startTimeParam, endTimeParam int64, cfg models.Config) (*http.Response, error) {
+func MakePromLabelValuesAPIQuery(ctx context.Context, client HTTPClient, label string, matches string, startTimeParam, endTimeParam int64, cfg models.Config) (*http.Response, error) {
 	promLabelValuesParam := struct {
 		Label     string   `json:"label"`
 		Timestamp int64    `json:"timestamp"`
@@ -220,7 +209,7 @@ func MakePromLabelValuesAPIQuery(ctx context.Context, client *http.Client, label
 	return client.Do(req)
 }
 
-func MakePromLabelsAPIQuery(ctx context.Context, client *http.Client, metric string, startTimeParam, endTimeParam int64, cfg models.Config) (*http.Response, error) {
+func MakePromLabelsAPIQuery(ctx context.Context, client HTTPClient, metric string, startTimeParam, endTimeParam int64, cfg models.Config) (*http.Response, error) {
 	promLabelsParam := struct {
 		Timestamp int64  `json:"timestamp"`
 		Window    int64  `json:"window"`
@@ -277,7 +266,7 @@ func ConvertTimestamp(timestamp any) string {
 }
 
 // MakeTracesJSONQueryAPI posts a raw trace JSON pipeline to the query_range API with the given time range
-func MakeTracesJSONQueryAPI(ctx context.Context, client *http.Client, cfg models.Config, pipeline any, startMs, endMs int64, limit int) (*http.Response, error) {
+func MakeTracesJSONQueryAPI(ctx context.Context, client HTTPClient, cfg models.Config, pipeline any, startMs, endMs int64, limit int) (*http.Response, error) {
 	// Basic validation
 	if client == nil {
 		return nil, errors.New("http client cannot be nil")
@@ -367,7 +356,7 @@ func PopulateAPICfg(cfg *models.Config) error {
 	}
 	cfg.ActionURL = actionURL
 
-	client := last9mcp.WithHTTPTracing(&http.Client{Timeout: constants.DefaultHTTPTimeout})
+	client := auth.GetHTTPClient()
 
 	apiHost := cfg.APIHost
 	if apiHost == "" {
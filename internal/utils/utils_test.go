@@ -6,29 +6,27 @@ import (
 	"time"
 )
 
-func TestGetTimeRange_TimezoneHandling(t *testing.T) {
+func TestTimeRangeResolve_TimezoneHandling(t *testing.T) {
 	tests := []struct {
 		name          string
-		params        map[string]interface{}
+		r             TimeRange
 		wantStartUnix int64
 		wantEndUnix   int64
 		wantErr       bool
 	}{
 		{
-			name: "legacy ISO timestamps parsed as UTC",
-			params: map[string]interface{}{
-				"start_time_iso": "2025-06-23 16:00:00",
-				"end_time_iso":   "2025-06-23 16:30:00",
+			name: "legacy space-separated timestamps are rejected",
+			r: TimeRange{
+				StartTimeISO: "2025-06-23 16:00:00",
+				EndTimeISO:   "2025-06-23 16:30:00",
 			},
-			wantStartUnix: 1750694400, // 2025-06-23 16:00:00 UTC
-			wantEndUnix:   1750696200, // 2025-06-23 16:30:00 UTC
-			wantErr:       false,
+			wantErr: true,
 		},
 		{
 			name: "RFC3339 timestamps parsed as UTC",
-			params: map[string]interface{}{
-				"start_time_iso": "2025-06-23T16:00:00Z",
-				"end_time_iso":   "2025-06-23T16:30:00Z",
+			r: TimeRange{
+				StartTimeISO: "2025-06-23T16:00:00Z",
+				EndTimeISO:   "2025-06-23T16:30:00Z",
 			},
 			wantStartUnix: 1750694400,
 			wantEndUnix:   1750696200,
@@ -36,8 +34,8 @@ func TestGetTimeRange_TimezoneHandling(t *testing.T) {
 		},
 		{
 			name: "only start_time provided - end time should be start + lookback",
-			params: map[string]interface{}{
-				"start_time_iso": "2025-06-27 16:00:00",
+			r: TimeRange{
+				StartTimeISO: "2025-06-27T16:00:00Z",
 			},
 			wantStartUnix: 1751040000, // 2025-06-27 16:00:00 UTC
 			// end time should be start + lookback and is checked separately
@@ -45,39 +43,39 @@ func TestGetTimeRange_TimezoneHandling(t *testing.T) {
 		},
 		{
 			name: "only end_time provided - start time should be end - lookback",
-			params: map[string]interface{}{
-				"end_time_iso": "2025-06-27 16:00:00",
+			r: TimeRange{
+				EndTimeISO: "2025-06-27T16:00:00Z",
 			},
 			wantEndUnix: 1751040000, // 2025-06-27 16:00:00 UTC
 			wantErr:     false,
 		},
 		{
 			name: "lookback minutes only - no explicit timestamps",
-			params: map[string]interface{}{
-				"lookback_minutes": float64(30),
+			r: TimeRange{
+				LookbackMinutes: 30,
 			},
 			// timestamps will be calculated from current time
 			wantErr: false,
 		},
 		{
 			name: "invalid start_time format",
-			params: map[string]interface{}{
-				"start_time_iso": "invalid-time",
+			r: TimeRange{
+				StartTimeISO: "invalid-time",
 			},
 			wantErr: true,
 		},
 		{
 			name: "invalid end_time format",
-			params: map[string]interface{}{
-				"end_time_iso": "invalid-time",
+			r: TimeRange{
+				EndTimeISO: "invalid-time",
 			},
 			wantErr: true,
 		},
 		{
 			name: "start_time after end_time",
-			params: map[string]interface{}{
-				"start_time_iso": "2025-06-23 17:00:00",
-				"end_time_iso":   "2025-06-23 16:00:00",
+			r: TimeRange{
+				StartTimeISO: "2025-06-23T17:00:00Z",
+				EndTimeISO:   "2025-06-23T16:00:00Z",
 			},
 			wantErr: true,
 		},
@@ -85,31 +83,31 @@ func TestGetTimeRange_TimezoneHandling(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			start, end, err := GetTimeRange(tt.params, 60)
+			start, end, err := tt.r.Resolve(60)
 
 			if tt.wantErr {
 				if err == nil {
-					t.Errorf("GetTimeRange() expected error but got none")
+					t.Errorf("Resolve() expected error but got none")
 				}
 				return
 			}
 
 			if err != nil {
-				t.Errorf("GetTimeRange() unexpected error: %v", err)
+				t.Errorf("Resolve() unexpected error: %v", err)
 				return
 			}
 
 			// Check start time if specified
 			if tt.wantStartUnix != 0 {
 				if start.Unix() != tt.wantStartUnix {
-					t.Errorf("GetTimeRange() start time = %d, want %d", start.Unix(), tt.wantStartUnix)
+					t.Errorf("Resolve() start time = %d, want %d", start.Unix(), tt.wantStartUnix)
 				}
 			}
 
 			// Check end time if specified
 			if tt.wantEndUnix != 0 {
 				if end.Unix() != tt.wantEndUnix {
-					t.Errorf("GetTimeRange() end time = %d, want %d", end.Unix(), tt.wantEndUnix)
+					t.Errorf("Resolve() end time = %d, want %d", end.Unix(), tt.wantEndUnix)
 				}
 			}
 
@@ -118,7 +116,7 @@ func TestGetTimeRange_TimezoneHandling(t *testing.T) {
 				// End time should be start time + 60 minutes (default lookback)
 				expectedEnd := start.Add(60 * time.Minute)
 				if end.Unix() != expectedEnd.Unix() {
-					t.Errorf("GetTimeRange() end time = %d, want %d (start + 60min)", end.Unix(), expectedEnd.Unix())
+					t.Errorf("Resolve() end time = %d, want %d (start + 60min)", end.Unix(), expectedEnd.Unix())
 				}
 			}
 
@@ -126,7 +124,7 @@ func TestGetTimeRange_TimezoneHandling(t *testing.T) {
 				// Start time should be end time - 60 minutes (default lookback)
 				expectedStart := end.Add(-60 * time.Minute)
 				if start.Unix() != expectedStart.Unix() {
-					t.Errorf("GetTimeRange() start time = %d, want %d (end - 60min)", start.Unix(), expectedStart.Unix())
+					t.Errorf("Resolve() start time = %d, want %d (end - 60min)", start.Unix(), expectedStart.Unix())
 				}
 			}
 
@@ -135,37 +133,37 @@ func TestGetTimeRange_TimezoneHandling(t *testing.T) {
 				now := time.Now().UTC()
 				timeDiff := end.Sub(now)
 				if timeDiff < -5*time.Second || timeDiff > 5*time.Second {
-					t.Errorf("GetTimeRange() end time should be close to now, got diff: %v", timeDiff)
+					t.Errorf("Resolve() end time should be close to now, got diff: %v", timeDiff)
 				}
 				expectedDiff := 30 * time.Minute
 				actualDiff := end.Sub(start)
 				if actualDiff != expectedDiff {
-					t.Errorf("GetTimeRange() time difference = %v, want %v", actualDiff, expectedDiff)
+					t.Errorf("Resolve() time difference = %v, want %v", actualDiff, expectedDiff)
 				}
 			}
 		})
 	}
 }
 
-func TestGetTimeRange_LookbackMinutes(t *testing.T) {
+func TestTimeRangeResolve_LookbackMinutes(t *testing.T) {
 	tests := []struct {
 		name                   string
-		params                 map[string]interface{}
+		r                      TimeRange
 		defaultLookbackMinutes int
 		wantLookbackUsed       int
 		wantErr                bool
 	}{
 		{
 			name:                   "default lookback minutes",
-			params:                 map[string]interface{}{},
+			r:                      TimeRange{},
 			defaultLookbackMinutes: 30,
 			wantLookbackUsed:       30,
 			wantErr:                false,
 		},
 		{
 			name: "custom lookback minutes",
-			params: map[string]interface{}{
-				"lookback_minutes": float64(45),
+			r: TimeRange{
+				LookbackMinutes: 45,
 			},
 			defaultLookbackMinutes: 30,
 			wantLookbackUsed:       45,
@@ -173,25 +171,25 @@ func TestGetTimeRange_LookbackMinutes(t *testing.T) {
 		},
 		{
 			name: "valid multi-day lookback minutes",
-			params: map[string]interface{}{
-				"lookback_minutes": float64(10080), // 7 days
+			r: TimeRange{
+				LookbackMinutes: 10080, // 7 days
 			},
 			defaultLookbackMinutes: 30,
 			wantLookbackUsed:       10080,
 			wantErr:                false,
 		},
 		{
-			name: "lookback too small",
-			params: map[string]interface{}{
-				"lookback_minutes": float64(0),
+			name: "negative lookback",
+			r: TimeRange{
+				LookbackMinutes: -5,
 			},
 			defaultLookbackMinutes: 30,
 			wantErr:                true,
 		},
 		{
 			name: "large lookback is valid",
-			params: map[string]interface{}{
-				"lookback_minutes": float64(25000),
+			r: TimeRange{
+				LookbackMinutes: 25000,
 			},
 			defaultLookbackMinutes: 30,
 			wantLookbackUsed:       25000,
@@ -201,17 +199,17 @@ func TestGetTimeRange_LookbackMinutes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			start, end, err := GetTimeRange(tt.params, tt.defaultLookbackMinutes)
+			start, end, err := tt.r.Resolve(tt.defaultLookbackMinutes)
 
 			if tt.wantErr {
 				if err == nil {
-					t.Errorf("GetTimeRange() expected error but got none")
+					t.Errorf("Resolve() expected error but got none")
 				}
 				return
 			}
 
 			if err != nil {
-				t.Errorf("GetTimeRange() unexpected error: %v", err)
+				t.Errorf("Resolve() unexpected error: %v", err)
 				return
 			}
 
@@ -220,30 +218,30 @@ func TestGetTimeRange_LookbackMinutes(t *testing.T) {
 			expectedDiff := time.Duration(tt.wantLookbackUsed) * time.Minute
 
 			if timeDiff != expectedDiff {
-				t.Errorf("GetTimeRange() time difference = %v, want %v", timeDiff, expectedDiff)
+				t.Errorf("Resolve() time difference = %v, want %v", timeDiff, expectedDiff)
 			}
 
 			// Verify times are in UTC
 			if start.Location() != time.UTC {
-				t.Errorf("GetTimeRange() start time not in UTC: %v", start.Location())
+				t.Errorf("Resolve() start time not in UTC: %v", start.Location())
 			}
 			if end.Location() != time.UTC {
-				t.Errorf("GetTimeRange() end time not in UTC: %v", end.Location())
+				t.Errorf("Resolve() end time not in UTC: %v", end.Location())
 			}
 		})
 	}
 }
 
-func TestGetTimeRange_UTCConsistency(t *testing.T) {
+func TestTimeRangeResolve_UTCConsistency(t *testing.T) {
 	// Test that all returned times are consistently in UTC
-	params := map[string]interface{}{
-		"start_time_iso": "2025-06-23T16:00:00Z",
-		"end_time_iso":   "2025-06-23T16:30:00Z",
+	r := TimeRange{
+		StartTimeISO: "2025-06-23T16:00:00Z",
+		EndTimeISO:   "2025-06-23T16:30:00Z",
 	}
 
-	start, end, err := GetTimeRange(params, 60)
+	start, end, err := r.Resolve(60)
 	if err != nil {
-		t.Fatalf("GetTimeRange() unexpected error: %v", err)
+		t.Fatalf("Resolve() unexpected error: %v", err)
 	}
 
 	if start.Location() != time.UTC {
@@ -262,25 +260,25 @@ func TestGetTimeRange_UTCConsistency(t *testing.T) {
 	}
 }
 
-func TestGetTimeRange_TimeRangeValidation(t *testing.T) {
+func TestTimeRangeResolve_TimeRangeValidation(t *testing.T) {
 	tests := []struct {
 		name    string
-		params  map[string]interface{}
+		r       TimeRange
 		wantErr bool
 	}{
 		{
 			name: "time range larger than 14 days is allowed",
-			params: map[string]interface{}{
-				"start_time_iso": "2025-06-01 00:00:00",
-				"end_time_iso":   "2025-06-16 00:00:00", // 15 days
+			r: TimeRange{
+				StartTimeISO: "2025-06-01T00:00:00Z",
+				EndTimeISO:   "2025-06-16T00:00:00Z", // 15 days
 			},
 			wantErr: false,
 		},
 		{
 			name: "valid 14 day range",
-			params: map[string]interface{}{
-				"start_time_iso": "2025-06-01 00:00:00",
-				"end_time_iso":   "2025-06-15 00:00:00", // exactly 14 days
+			r: TimeRange{
+				StartTimeISO: "2025-06-01T00:00:00Z",
+				EndTimeISO:   "2025-06-15T00:00:00Z", // exactly 14 days
 			},
 			wantErr: false,
 		},
@@ -288,32 +286,32 @@ func TestGetTimeRange_TimeRangeValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := GetTimeRange(tt.params, 60)
+			_, _, err := tt.r.Resolve(60)
 
 			if tt.wantErr {
 				if err == nil {
-					t.Errorf("GetTimeRange() expected error but got none")
+					t.Errorf("Resolve() expected error but got none")
 					return
 				}
 			} else {
 				if err != nil {
-					t.Errorf("GetTimeRange() unexpected error: %v", err)
+					t.Errorf("Resolve() unexpected error: %v", err)
 				}
 			}
 		})
 	}
 }
 
-func TestGetTimeRange_ExplicitRangePrecedenceOverLookback(t *testing.T) {
-	params := map[string]interface{}{
-		"start_time_iso":   "2026-02-09T15:04:05Z",
-		"end_time_iso":     "2026-02-09T16:04:05Z",
-		"lookback_minutes": float64(5),
+func TestTimeRangeResolve_ExplicitRangePrecedenceOverLookback(t *testing.T) {
+	r := TimeRange{
+		StartTimeISO:    "2026-02-09T15:04:05Z",
+		EndTimeISO:      "2026-02-09T16:04:05Z",
+		LookbackMinutes: 5,
 	}
 
-	start, end, err := GetTimeRange(params, 60)
+	start, end, err := r.Resolve(60)
 	if err != nil {
-		t.Fatalf("GetTimeRange() unexpected error: %v", err)
+		t.Fatalf("Resolve() unexpected error: %v", err)
 	}
 
 	if got, want := start.Unix(), int64(1770649445); got != want {
@@ -351,9 +349,10 @@ func TestParseToolTimestamp(t *testing.T) {
 			wantUnix: 1770649445,
 		},
 		{
-			name:     "Legacy format compatibility",
-			input:    "2026-02-09 15:04:05",
-			wantUnix: 1770649445,
+			name:       "Legacy space-separated format rejected",
+			input:      "2026-02-09 15:04:05",
+			wantErr:    true,
+			errSnippet: "Use RFC3339/ISO8601",
 		},
 		{
 			name:       "Invalid format",
@@ -388,3 +387,28 @@ func TestParseToolTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeRangeResolveAt(t *testing.T) {
+	now := time.Date(2026, 2, 9, 16, 0, 0, 0, time.UTC)
+
+	start, end, err := TimeRange{}.ResolveAt(15, now)
+	if err != nil {
+		t.Fatalf("ResolveAt() unexpected error: %v", err)
+	}
+	if !end.Equal(now) || !start.Equal(now.Add(-15*time.Minute)) {
+		t.Fatalf("ResolveAt() = %v..%v, want default 15m window ending at now", start, end)
+	}
+
+	// Fractional lookbacks are truncated to whole minutes.
+	start, _, err = TimeRange{LookbackMinutes: 30.9}.ResolveAt(15, now)
+	if err != nil {
+		t.Fatalf("ResolveAt() unexpected error: %v", err)
+	}
+	if !start.Equal(now.Add(-30 * time.Minute)) {
+		t.Fatalf("start = %v, want %v", start, now.Add(-30*time.Minute))
+	}
+
+	if _, _, err := (TimeRange{LookbackMinutes: 0.5}).ResolveAt(15, now); err == nil {
+		t.Fatal("ResolveAt() expected error for sub-minute lookback")
+	}
+}
@@ -0,0 +1,76 @@
+package views
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SaveViewArgs represents the input arguments for the save_view tool
+type SaveViewArgs struct {
+	Name        string         `json:"name" jsonschema:"View name: lowercase letters, digits, '-', '_' or '.' (required, e.g. checkout-prod-1h)"`
+	Description string         `json:"description,omitempty" jsonschema:"What the view is for (optional)"`
+	Parameters  map[string]any `json:"parameters" jsonschema:"Tool arguments the view supplies, e.g. {\"service_name\": \"checkout\", \"env\": \"prod\", \"lookback_minutes\": 60} (required)"`
+}
+
+// ListViewsArgs represents the input arguments for the list_views tool
+type ListViewsArgs struct{}
+
+// DeleteViewArgs represents the input arguments for the delete_view tool
+type DeleteViewArgs struct {
+	Name string `json:"name" jsonschema:"Name of the view to delete (required)"`
+}
+
+func jsonResult(v any) (*mcp.CallToolResult, any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+	}, nil, nil
+}
+
+// NewSaveViewHandler returns a handler that saves or replaces a view.
+func NewSaveViewHandler(store *Store) func(context.Context, *mcp.CallToolRequest, SaveViewArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args SaveViewArgs) (*mcp.CallToolResult, any, error) {
+		view, err := store.Save(View{Name: args.Name, Description: args.Description, Parameters: args.Parameters})
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonResult(map[string]any{
+			"saved": view,
+			"usage": fmt.Sprintf("Pass \"view\": %q to APM tools instead of these arguments. Arguments given in the call override the view.", view.Name),
+		})
+	}
+}
+
+// NewListViewsHandler returns a handler that lists saved views.
+func NewListViewsHandler(store *Store) func(context.Context, *mcp.CallToolRequest, ListViewsArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ListViewsArgs) (*mcp.CallToolResult, any, error) {
+		all := store.All()
+		if all == nil {
+			all = []View{}
+		}
+		return jsonResult(map[string]any{"views": all, "count": len(all)})
+	}
+}
+
+// NewDeleteViewHandler returns a handler that deletes a view.
+func NewDeleteViewHandler(store *Store) func(context.Context, *mcp.CallToolRequest, DeleteViewArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DeleteViewArgs) (*mcp.CallToolResult, any, error) {
+		if args.Name == "" {
+			return nil, nil, fmt.Errorf("name is required")
+		}
+		deleted, err := store.Delete(args.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !deleted {
+			return nil, nil, fmt.Errorf("unknown view %q; list_views shows the saved views", args.Name)
+		}
+		return jsonResult(map[string]any{"deleted": args.Name})
+	}
+}
@@ -0,0 +1,271 @@
+// Package views stores named parameter sets ("checkout-prod-1h" = service,
+// env and window) that APM tool calls reference with a view argument instead
+// of repeating the same arguments. Views are saved with save_view and kept in
+// a JSON file so they survive restarts.
+package views
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxViews bounds how many views can be saved.
+const maxViews = 200
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// timeArguments are the arguments that together select a time range. A call
+// that sets any of them ignores all of them from the view, so an explicit
+// range never mixes with the view's lookback.
+var timeArguments = []string{"start_time_iso", "end_time_iso", "lookback_minutes"}
+
+// View is a named set of tool arguments.
+type View struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Store holds saved views. A nil *Store is valid and has no views.
+type Store struct {
+	path string
+
+	mu    sync.Mutex
+	views map[string]View
+}
+
+// DefaultPath returns the views file in the per-user cache directory, or ""
+// when the platform has none.
+func DefaultPath() string {
+	dir := diskcache.DefaultDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "views.json")
+}
+
+// New returns a store persisted to path, loading the views already in it. An
+// empty path keeps views in memory for the life of the process. An
+// unreadable file is treated as empty so it does not block startup.
+func New(path string) *Store {
+	s := &Store{path: path, views: map[string]View{}}
+	if path == "" {
+		return s
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	var saved []View
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return s
+	}
+	for _, v := range saved {
+		s.views[v.Name] = v
+	}
+	return s
+}
+
+// Validate checks a view's name and parameters.
+func (v View) Validate() error {
+	if !namePattern.MatchString(v.Name) {
+		return fmt.Errorf("invalid view name %q: use lowercase letters, digits, '-', '_' or '.' (max 64)", v.Name)
+	}
+	if len(v.Parameters) == 0 {
+		return fmt.Errorf("view %q has no parameters", v.Name)
+	}
+	if _, ok := v.Parameters["view"]; ok {
+		return fmt.Errorf("view %q cannot set the view parameter", v.Name)
+	}
+	for key, value := range v.Parameters {
+		switch value.(type) {
+		case string, float64, bool, []any:
+		default:
+			return fmt.Errorf("view %q parameter %s must be a string, number, boolean or array", v.Name, key)
+		}
+	}
+	return nil
+}
+
+// Save validates v and stores it, replacing a view with the same name.
+func (s *Store) Save(v View) (View, error) {
+	if err := v.Validate(); err != nil {
+		return v, err
+	}
+	v.UpdatedAt = time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.views[v.Name]; !exists && len(s.views) >= maxViews {
+		return v, fmt.Errorf("cannot save more than %d views; delete one with delete_view first", maxViews)
+	}
+	previous, existed := s.views[v.Name]
+	s.views[v.Name] = v
+	if err := s.write(); err != nil {
+		if existed {
+			s.views[v.Name] = previous
+		} else {
+			delete(s.views, v.Name)
+		}
+		return v, err
+	}
+	return v, nil
+}
+
+// Delete removes the named view and reports whether it existed.
+func (s *Store) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.views[name]
+	if !ok {
+		return false, nil
+	}
+	delete(s.views, name)
+	if err := s.write(); err != nil {
+		s.views[name] = v
+		return false, err
+	}
+	return true, nil
+}
+
+// Get returns the named view.
+func (s *Store) Get(name string) (View, error) {
+	if s != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if v, ok := s.views[name]; ok {
+			return v, nil
+		}
+	}
+	return View{}, fmt.Errorf("unknown view %q; list_views shows the saved views", name)
+}
+
+// All returns the saved views sorted by name.
+func (s *Store) All() []View {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]View, 0, len(s.views))
+	for _, v := range s.views {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// write saves all views. Callers hold s.mu.
+func (s *Store) write() error {
+	if s.path == "" {
+		return nil
+	}
+	all := make([]View, 0, len(s.views))
+	for _, v := range s.views {
+		all = append(all, v)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode views: %w", err)
+	}
+	if err := diskcache.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write views file: %w", err)
+	}
+	return nil
+}
+
+// Merge returns args with the view's parameters filled in. Arguments set in
+// the call take precedence; zero values count as unset, since typed tool
+// arguments cannot tell them apart. The view parameter itself is removed.
+func (v View) Merge(args map[string]any) map[string]any {
+	out := make(map[string]any, len(args)+len(v.Parameters))
+	explicitTime := false
+	for key, value := range args {
+		if key == "view" || isZero(value) {
+			continue
+		}
+		out[key] = value
+		if slices.Contains(timeArguments, key) {
+			explicitTime = true
+		}
+	}
+	for key, value := range v.Parameters {
+		if _, set := out[key]; set {
+			continue
+		}
+		if explicitTime && slices.Contains(timeArguments, key) {
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func isZero(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// Apply wraps a tool handler so a view argument in the call is expanded into
+// the view's parameters. Parameters the tool does not accept are ignored, so
+// one view can serve several tools. Calls without a view are passed through,
+// as are tools with free-form arguments (custom tools and macros), where
+// "view" may be an ordinary parameter.
+func Apply[In any](s *Store, handler mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, any, error) {
+		if _, freeForm := any(args).(map[string]any); freeForm {
+			return handler(ctx, req, args)
+		}
+		raw, err := json.Marshal(args)
+		if err != nil {
+			return handler(ctx, req, args)
+		}
+		var m map[string]any
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return handler(ctx, req, args)
+		}
+		name, _ := m["view"].(string)
+		if name == "" {
+			return handler(ctx, req, args)
+		}
+		view, err := s.Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		merged, err := json.Marshal(view.Merge(m))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to apply view %q: %w", name, err)
+		}
+		var in In
+		if err := json.Unmarshal(merged, &in); err != nil {
+			return nil, nil, fmt.Errorf("view %q does not fit this tool: %w", name, err)
+		}
+		return handler(ctx, req, in)
+	}
+}
@@ -0,0 +1,157 @@
+package views
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type summaryArgs struct {
+	ServiceName     string  `json:"service_name"`
+	Env             string  `json:"env,omitempty"`
+	StartTimeISO    string  `json:"start_time_iso,omitempty"`
+	LookbackMinutes float64 `json:"lookback_minutes,omitempty"`
+	View            string  `json:"view,omitempty"`
+}
+
+var checkout = View{
+	Name:       "checkout-prod-1h",
+	Parameters: map[string]any{"service_name": "checkout", "env": "prod", "lookback_minutes": 60.0, "unused": "x"},
+}
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "views.json")
+	s := New(path)
+	if _, err := s.Save(checkout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Save(View{Name: "cart", Parameters: map[string]any{"service_name": "cart"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := New(path)
+	all := reloaded.All()
+	if len(all) != 2 || all[0].Name != "cart" || all[1].Parameters["env"] != "prod" {
+		t.Fatalf("All() after reload = %+v", all)
+	}
+	if deleted, err := reloaded.Delete("cart"); err != nil || !deleted {
+		t.Fatalf("Delete() = %v, %v", deleted, err)
+	}
+	if deleted, _ := reloaded.Delete("cart"); deleted {
+		t.Error("Delete() of a missing view reported true")
+	}
+	if got := New(path).All(); len(got) != 1 {
+		t.Errorf("All() after delete and reload = %+v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := map[string]View{
+		"bad name":      {Name: "Checkout Prod", Parameters: map[string]any{"env": "prod"}},
+		"no parameters": {Name: "empty"},
+		"nested view":   {Name: "loop", Parameters: map[string]any{"view": "other"}},
+		"object value":  {Name: "obj", Parameters: map[string]any{"env": map[string]any{"a": 1.0}}},
+	}
+	for name, v := range invalid {
+		if err := v.Validate(); err == nil {
+			t.Errorf("%s: Validate() succeeded, want error", name)
+		}
+	}
+	if err := checkout.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]any
+		want map[string]any
+	}{
+		{"fills unset", map[string]any{"view": "v", "service_name": ""}, map[string]any{"service_name": "checkout", "env": "prod", "lookback_minutes": 60.0, "unused": "x"}},
+		{"call overrides", map[string]any{"env": "staging"}, map[string]any{"service_name": "checkout", "env": "staging", "lookback_minutes": 60.0, "unused": "x"}},
+		{"explicit range replaces view range", map[string]any{"start_time_iso": "2024-06-01T00:00:00Z"}, map[string]any{"service_name": "checkout", "env": "prod", "start_time_iso": "2024-06-01T00:00:00Z", "unused": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkout.Merge(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	s := New("")
+	if _, err := s.Save(checkout); err != nil {
+		t.Fatal(err)
+	}
+	var got summaryArgs
+	handler := Apply(s, func(ctx context.Context, req *mcp.CallToolRequest, args summaryArgs) (*mcp.CallToolResult, any, error) {
+		got = args
+		return &mcp.CallToolResult{}, nil, nil
+	})
+
+	if _, _, err := handler(context.Background(), nil, summaryArgs{View: "checkout-prod-1h", Env: "staging"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (summaryArgs{ServiceName: "checkout", Env: "staging", LookbackMinutes: 60}); got != want {
+		t.Errorf("args = %+v, want %+v", got, want)
+	}
+
+	if _, _, err := handler(context.Background(), nil, summaryArgs{ServiceName: "cart"}); err != nil || got.ServiceName != "cart" || got.Env != "" {
+		t.Errorf("call without view: args = %+v, err = %v", got, err)
+	}
+
+	if _, _, err := handler(context.Background(), nil, summaryArgs{View: "missing"}); err == nil || !strings.Contains(err.Error(), "unknown view") {
+		t.Errorf("unknown view error = %v", err)
+	}
+
+	// Free-form tools may have their own view parameter.
+	var raw map[string]any
+	freeForm := Apply(s, func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		raw = args
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	if _, _, err := freeForm(context.Background(), nil, map[string]any{"view": "table"}); err != nil || raw["view"] != "table" {
+		t.Errorf("free-form args = %v, err = %v", raw, err)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	s := New("")
+	result, _, err := NewSaveViewHandler(s)(context.Background(), nil, SaveViewArgs{Name: checkout.Name, Parameters: checkout.Parameters})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, `\"view\": \"checkout-prod-1h\"`) {
+		t.Errorf("save response = %s", text)
+	}
+
+	result, _, err = NewListViewsHandler(s)(context.Background(), nil, ListViewsArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed struct {
+		Views []View `json:"views"`
+		Count int    `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if listed.Count != 1 || listed.Views[0].Name != checkout.Name {
+		t.Fatalf("listed = %+v", listed)
+	}
+
+	if _, _, err := NewDeleteViewHandler(s)(context.Background(), nil, DeleteViewArgs{Name: checkout.Name}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewDeleteViewHandler(s)(context.Background(), nil, DeleteViewArgs{Name: checkout.Name}); err == nil {
+		t.Error("deleting a missing view: want error")
+	}
+}
@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+// notificationLogger names the logger on watch log notifications.
+const notificationLogger = "last9-watch"
+
+// CreateWatchArgs represents the input arguments for the create_watch tool
+type CreateWatchArgs struct {
+	Query           string  `json:"query" jsonschema:"PromQL expression to evaluate, e.g. a service error percentage (required)"`
+	Operator        string  `json:"operator" jsonschema:"Comparison against threshold (required, one of: >, >=, <, <=)"`
+	Threshold       float64 `json:"threshold" jsonschema:"Value the query result is compared with (required)"`
+	Name            string  `json:"name,omitempty" jsonschema:"Short label used in notifications (optional)"`
+	IntervalSeconds int     `json:"interval_seconds,omitempty" jsonschema:"Evaluation interval in seconds (default: 60, minimum: 15)"`
+	DurationMinutes int     `json:"duration_minutes,omitempty" jsonschema:"How long to keep watching before the watch expires (default: 60, maximum: 1440)"`
+}
+
+// ListWatchesArgs represents the input arguments for the list_watches tool
+type ListWatchesArgs struct {
+	ID string `json:"id,omitempty" jsonschema:"Only return this watch (optional)"`
+}
+
+// DeleteWatchArgs represents the input arguments for the delete_watch tool
+type DeleteWatchArgs struct {
+	ID string `json:"id" jsonschema:"ID of the watch to stop and remove (required)"`
+}
+
+// NewCreateWatchHandler registers a watch. When the calling session stays
+// connected (stdio), breaches and recoveries are sent to it as log
+// notifications; in stateless HTTP mode they are only recorded as events.
+func NewCreateWatchHandler(m *Manager) func(context.Context, *mcp.CallToolRequest, CreateWatchArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args CreateWatchArgs) (*mcp.CallToolResult, any, error) {
+		if args.IntervalSeconds < 0 || args.DurationMinutes < 0 {
+			return nil, nil, fmt.Errorf("interval_seconds and duration_minutes must be positive")
+		}
+		w, err := m.Create(Spec{
+			Name:      args.Name,
+			Query:     args.Query,
+			Operator:  args.Operator,
+			Threshold: args.Threshold,
+			Interval:  time.Duration(args.IntervalSeconds) * time.Second,
+			Duration:  time.Duration(args.DurationMinutes) * time.Minute,
+		}, sessionNotifier(req))
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonResult(w)
+	}
+}
+
+// NewListWatchesHandler returns the watches with their current state and
+// recent events.
+func NewListWatchesHandler(m *Manager) func(context.Context, *mcp.CallToolRequest, ListWatchesArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args ListWatchesArgs) (*mcp.CallToolResult, any, error) {
+		watches := m.List()
+		if args.ID != "" {
+			var found []Watch
+			for _, w := range watches {
+				if w.ID == args.ID {
+					found = append(found, w)
+				}
+			}
+			if len(found) == 0 {
+				return nil, nil, fmt.Errorf("watch %q not found", args.ID)
+			}
+			watches = found
+		}
+		return jsonResult(map[string]any{
+			"watches": watches,
+			"count":   len(watches),
+		})
+	}
+}
+
+// NewDeleteWatchHandler stops and removes a watch.
+func NewDeleteWatchHandler(m *Manager) func(context.Context, *mcp.CallToolRequest, DeleteWatchArgs) (*mcp.CallToolResult, any, error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args DeleteWatchArgs) (*mcp.CallToolResult, any, error) {
+		if args.ID == "" {
+			return nil, nil, fmt.Errorf("id is required")
+		}
+		if !m.Delete(args.ID) {
+			return nil, nil, fmt.Errorf("watch %q not found", args.ID)
+		}
+		return jsonResult(map[string]any{"deleted": args.ID})
+	}
+}
+
+// sessionNotifier sends events to the calling session as warning-level log
+// messages. Clients only receive them after setting a log level, and a closed
+// session (e.g. a finished stateless HTTP request) drops them.
+func sessionNotifier(req *mcp.CallToolRequest) Notifier {
+	if req == nil || req.Session == nil {
+		return nil
+	}
+	session := req.Session
+	return func(ctx context.Context, event Event) {
+		level := mcp.LoggingLevel("warning")
+		if event.State != StateBreached {
+			level = "info"
+		}
+		if err := session.Log(ctx, &mcp.LoggingMessageParams{
+			Level:  level,
+			Logger: notificationLogger,
+			Data:   event,
+		}); err != nil {
+			logging.Logger("watch").Debug("watch notification not delivered", "watch_id", event.WatchID, "error", err)
+		}
+	}
+}
+
+func jsonResult(v any) (*mcp.CallToolResult, any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(data)},
+		},
+	}, nil, nil
+}
@@ -0,0 +1,395 @@
+// Package watch evaluates PromQL conditions in the background so an agent can
+// keep an eye on a signal (for example the error rate during a mitigation)
+// without polling. Each watch records state changes as events; when the
+// creating session is still connected, breaches and recoveries are also sent
+// to it as MCP log notifications.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+const (
+	// MaxWatches caps concurrently active watches per server.
+	MaxWatches = 10
+	// DefaultInterval and MinInterval bound how often a condition is evaluated.
+	DefaultInterval = time.Minute
+	MinInterval     = 15 * time.Second
+	// DefaultDuration and MaxDuration bound how long a watch runs before it
+	// expires on its own.
+	DefaultDuration = time.Hour
+	MaxDuration     = 24 * time.Hour
+	// maxEvents is the number of recent events kept per watch.
+	maxEvents = 20
+	// evaluateTimeout bounds a single evaluation.
+	evaluateTimeout = 30 * time.Second
+)
+
+// Watch states.
+const (
+	StatePending  = "pending"
+	StateOK       = "ok"
+	StateBreached = "breached"
+	StateNoData   = "no_data"
+	StateError    = "error"
+	StateExpired  = "expired"
+)
+
+// operators maps the supported comparison operators to their predicate.
+var operators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+}
+
+// Spec describes a watch to create.
+type Spec struct {
+	Name      string
+	Query     string
+	Operator  string
+	Threshold float64
+	Interval  time.Duration
+	Duration  time.Duration
+}
+
+// Event is a state change of a watch.
+type Event struct {
+	WatchID string    `json:"watch_id"`
+	Time    time.Time `json:"time"`
+	State   string    `json:"state"`
+	Value   *float64  `json:"value,omitempty"`
+	Message string    `json:"message"`
+}
+
+// Watch is the public view of a registered condition.
+type Watch struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name,omitempty"`
+	Query           string     `json:"query"`
+	Operator        string     `json:"operator"`
+	Threshold       float64    `json:"threshold"`
+	IntervalSeconds int        `json:"interval_seconds"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	State           string     `json:"state"`
+	LastValue       *float64   `json:"last_value,omitempty"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	Events          []Event    `json:"events"`
+}
+
+// Notifier delivers an event to whoever created the watch. It is best-effort.
+type Notifier func(ctx context.Context, event Event)
+
+// evaluator returns the sample values of an instant query.
+type evaluator func(ctx context.Context, query string, at time.Time) ([]float64, error)
+
+type entry struct {
+	seq    int
+	watch  Watch
+	notify Notifier
+	cancel context.CancelFunc
+}
+
+// Manager owns the active watches and their evaluation goroutines.
+type Manager struct {
+	client *http.Client
+	cfg    models.Config
+
+	mu      sync.Mutex
+	watches map[string]*entry
+	nextID  int
+	closed  bool
+
+	now      func() time.Time
+	evaluate evaluator
+}
+
+// NewManager creates a Manager that evaluates queries against the configured
+// Prometheus datasource.
+func NewManager(client *http.Client, cfg models.Config) *Manager {
+	m := &Manager{
+		client:  client,
+		cfg:     cfg,
+		watches: map[string]*entry{},
+		now:     time.Now,
+	}
+	m.evaluate = m.promInstant
+	return m
+}
+
+// Create validates spec, registers the watch and starts evaluating it.
+func (m *Manager) Create(spec Spec, notify Notifier) (Watch, error) {
+	if spec.Query == "" {
+		return Watch{}, fmt.Errorf("query is required")
+	}
+	if _, ok := operators[spec.Operator]; !ok {
+		return Watch{}, fmt.Errorf("operator must be one of >, >=, <, <=; got %q", spec.Operator)
+	}
+	if math.IsNaN(spec.Threshold) || math.IsInf(spec.Threshold, 0) {
+		return Watch{}, fmt.Errorf("threshold must be a finite number")
+	}
+	if spec.Interval == 0 {
+		spec.Interval = DefaultInterval
+	}
+	if spec.Interval < MinInterval {
+		return Watch{}, fmt.Errorf("interval must be at least %s", MinInterval)
+	}
+	if spec.Duration == 0 {
+		spec.Duration = DefaultDuration
+	}
+	if spec.Duration < time.Minute || spec.Duration > MaxDuration {
+		return Watch{}, fmt.Errorf("duration must be between 1 minute and %s", MaxDuration)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return Watch{}, fmt.Errorf("watch manager is shut down")
+	}
+	active := 0
+	for _, e := range m.watches {
+		if e.watch.State != StateExpired {
+			active++
+		}
+	}
+	if active >= MaxWatches {
+		return Watch{}, fmt.Errorf("at most %d watches can be active; delete one with delete_watch first", MaxWatches)
+	}
+
+	m.nextID++
+	now := m.now().UTC()
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		seq: m.nextID,
+		watch: Watch{
+			ID:              fmt.Sprintf("w%d", m.nextID),
+			Name:            spec.Name,
+			Query:           spec.Query,
+			Operator:        spec.Operator,
+			Threshold:       spec.Threshold,
+			IntervalSeconds: int(spec.Interval / time.Second),
+			CreatedAt:       now,
+			ExpiresAt:       now.Add(spec.Duration),
+			State:           StatePending,
+			Events:          []Event{},
+		},
+		notify: notify,
+		cancel: cancel,
+	}
+	m.watches[e.watch.ID] = e
+	go m.run(ctx, e.watch.ID, spec.Interval)
+	return e.watch, nil
+}
+
+// List returns all watches, including expired ones that have not been
+// deleted, ordered by creation.
+func (m *Manager) List() []Watch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]*entry, 0, len(m.watches))
+	for _, e := range m.watches {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+	out := make([]Watch, 0, len(entries))
+	for _, e := range entries {
+		w := e.watch
+		w.Events = append([]Event{}, e.watch.Events...)
+		out = append(out, w)
+	}
+	return out
+}
+
+// Delete stops and removes a watch. It reports whether the watch existed.
+func (m *Manager) Delete(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.watches[id]
+	if !ok {
+		return false
+	}
+	e.cancel()
+	delete(m.watches, id)
+	return true
+}
+
+// Close stops every watch. Create fails afterwards.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for _, e := range m.watches {
+		e.cancel()
+	}
+}
+
+func (m *Manager) run(ctx context.Context, id string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !m.tick(ctx, id) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick evaluates the watch once. It returns false once the watch has been
+// removed or has expired.
+func (m *Manager) tick(ctx context.Context, id string) bool {
+	m.mu.Lock()
+	e, ok := m.watches[id]
+	if !ok {
+		m.mu.Unlock()
+		return false
+	}
+	w := e.watch
+	m.mu.Unlock()
+
+	now := m.now().UTC()
+	if !now.Before(w.ExpiresAt) {
+		m.record(ctx, id, now, StateExpired, nil, "", "watch expired")
+		return false
+	}
+
+	evalCtx, cancel := context.WithTimeout(ctx, evaluateTimeout)
+	values, err := m.evaluate(evalCtx, w.Query, now)
+	cancel()
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch {
+	case err != nil:
+		m.record(ctx, id, now, StateError, nil, err.Error(), fmt.Sprintf("evaluation failed: %v", err))
+	case len(values) == 0:
+		m.record(ctx, id, now, StateNoData, nil, "", "query returned no data")
+	default:
+		value, breached := evaluateCondition(values, w.Operator, w.Threshold)
+		if breached {
+			m.record(ctx, id, now, StateBreached, &value, "", fmt.Sprintf("%s: value %g %s %g", watchLabel(w), value, w.Operator, w.Threshold))
+		} else {
+			m.record(ctx, id, now, StateOK, &value, "", fmt.Sprintf("%s: value %g is back within threshold (%s %g)", watchLabel(w), value, w.Operator, w.Threshold))
+		}
+	}
+	return true
+}
+
+// record updates the watch and, on a state change, appends an event and
+// notifies the creator about breaches and recoveries.
+func (m *Manager) record(ctx context.Context, id string, at time.Time, state string, value *float64, lastErr, message string) {
+	m.mu.Lock()
+	e, ok := m.watches[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	prev := e.watch.State
+	e.watch.State = state
+	e.watch.LastValue = value
+	e.watch.LastError = lastErr
+	if state != StateExpired {
+		e.watch.LastEvaluatedAt = &at
+	}
+	if prev == state {
+		m.mu.Unlock()
+		return
+	}
+	event := Event{WatchID: id, Time: at, State: state, Value: value, Message: message}
+	e.watch.Events = append(e.watch.Events, event)
+	if len(e.watch.Events) > maxEvents {
+		e.watch.Events = e.watch.Events[len(e.watch.Events)-maxEvents:]
+	}
+	notify := e.notify
+	m.mu.Unlock()
+
+	logging.Logger("watch").Info("watch state changed", "watch_id", id, "from", prev, "to", state)
+	// Only breaches and recoveries from a breach are worth interrupting for.
+	if notify != nil && (state == StateBreached || prev == StateBreached) {
+		notify(ctx, event)
+	}
+}
+
+// evaluateCondition applies the operator to every series and reports the
+// most extreme value in the direction of the condition, and whether any
+// series breaches.
+func evaluateCondition(values []float64, operator string, threshold float64) (float64, bool) {
+	test := operators[operator]
+	worst := values[0]
+	for _, v := range values[1:] {
+		if (operator == ">" || operator == ">=") && v > worst {
+			worst = v
+		}
+		if (operator == "<" || operator == "<=") && v < worst {
+			worst = v
+		}
+	}
+	return worst, test(worst, threshold)
+}
+
+func watchLabel(w Watch) string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return w.ID
+}
+
+// promInstant runs query as an instant query and returns the finite sample
+// values.
+func (m *Manager) promInstant(ctx context.Context, query string, at time.Time) ([]float64, error) {
+	resp, err := utils.MakePromInstantAPIQuery(ctx, m.client, query, at.Unix(), m.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instant query failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var series []struct {
+		Value []any `json:"value"`
+	}
+	if err := json.Unmarshal(body, &series); err != nil {
+		return nil, fmt.Errorf("failed to parse instant query response: %w", err)
+	}
+	values := make([]float64, 0, len(series))
+	for _, s := range series {
+		if len(s.Value) < 2 {
+			continue
+		}
+		raw, ok := s.Value[1].(string)
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,269 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/constants"
+	"github.com/last9/last9-mcp-server/internal/models"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fakeSource is a controllable evaluator.
+type fakeSource struct {
+	mu     sync.Mutex
+	values []float64
+	err    error
+}
+
+func (f *fakeSource) set(values []float64, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values, f.err = values, err
+}
+
+func (f *fakeSource) evaluate(context.Context, string, time.Time) ([]float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values, f.err
+}
+
+func newTestManager(t *testing.T, src *fakeSource) *Manager {
+	t.Helper()
+	m := NewManager(nil, models.Config{})
+	m.evaluate = src.evaluate
+	t.Cleanup(m.Close)
+	return m
+}
+
+// waitForState polls until the watch leaves the pending state.
+func waitForState(t *testing.T, m *Manager, id string) Watch {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, w := range m.List() {
+			if w.ID == id && w.State != StatePending {
+				return w
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("watch %s was never evaluated", id)
+	return Watch{}
+}
+
+func TestCreateValidation(t *testing.T) {
+	m := newTestManager(t, &fakeSource{})
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"missing query", Spec{Operator: ">"}, "query is required"},
+		{"bad operator", Spec{Query: "up", Operator: "=="}, "operator must be one of"},
+		{"interval too short", Spec{Query: "up", Operator: ">", Interval: time.Second}, "interval must be at least"},
+		{"duration too long", Spec{Query: "up", Operator: ">", Duration: 48 * time.Hour}, "duration must be between"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Create(tt.spec, nil); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Create() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreachAndRecoveryNotify(t *testing.T) {
+	src := &fakeSource{values: []float64{1, 7.5}}
+	m := newTestManager(t, src)
+
+	var (
+		mu     sync.Mutex
+		events []Event
+	)
+	notify := func(_ context.Context, e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	w, err := m.Create(Spec{Name: "checkout errors", Query: "error_pct", Operator: ">", Threshold: 5}, notify)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if got := waitForState(t, m, w.ID); got.State != StateBreached || *got.LastValue != 7.5 {
+		t.Fatalf("after first evaluation = %+v, want breached at 7.5", got)
+	}
+
+	// Same state again: no new event.
+	m.tick(context.Background(), w.ID)
+	src.set([]float64{2}, nil)
+	m.tick(context.Background(), w.ID)
+
+	got := m.List()[0]
+	if got.State != StateOK || len(got.Events) != 2 {
+		t.Fatalf("watch = %+v, want ok with 2 events", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0].State != StateBreached || events[1].State != StateOK {
+		t.Fatalf("notifications = %+v, want breach then recovery", events)
+	}
+	if !strings.Contains(events[0].Message, "checkout errors") {
+		t.Errorf("breach message = %q, want watch name", events[0].Message)
+	}
+}
+
+func TestErrorAndNoDataAreRecordedNotNotified(t *testing.T) {
+	src := &fakeSource{err: errors.New("upstream down")}
+	m := newTestManager(t, src)
+	notified := false
+	w, err := m.Create(Spec{Query: "up", Operator: "<", Threshold: 1}, func(context.Context, Event) { notified = true })
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if got := waitForState(t, m, w.ID); got.State != StateError || got.LastError != "upstream down" {
+		t.Fatalf("watch = %+v, want error state", got)
+	}
+	src.set(nil, nil)
+	m.tick(context.Background(), w.ID)
+	if got := m.List()[0]; got.State != StateNoData || len(got.Events) != 2 {
+		t.Fatalf("watch = %+v, want no_data with 2 events", got)
+	}
+	if notified {
+		t.Error("error and no_data states should not notify")
+	}
+}
+
+func TestExpiryAndLimit(t *testing.T) {
+	m := newTestManager(t, &fakeSource{values: []float64{0}})
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var (
+		mu  sync.Mutex
+		now = base
+	)
+	m.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	var ids []string
+	for i := 0; i < MaxWatches; i++ {
+		w, err := m.Create(Spec{Query: "up", Operator: "<", Threshold: 1, Duration: time.Minute}, nil)
+		if err != nil {
+			t.Fatalf("Create() #%d error: %v", i, err)
+		}
+		ids = append(ids, w.ID)
+	}
+	if _, err := m.Create(Spec{Query: "up", Operator: "<", Threshold: 1}, nil); err == nil {
+		t.Fatal("expected limit error")
+	}
+	for _, id := range ids {
+		waitForState(t, m, id)
+	}
+
+	mu.Lock()
+	now = base.Add(2 * time.Minute)
+	mu.Unlock()
+	if m.tick(context.Background(), ids[0]) {
+		t.Error("tick should stop an expired watch")
+	}
+	if got := m.List()[0]; got.State != StateExpired {
+		t.Errorf("state = %s, want expired", got.State)
+	}
+	if _, err := m.Create(Spec{Query: "up", Operator: "<", Threshold: 1}, nil); err != nil {
+		t.Errorf("expired watches should not count toward the limit: %v", err)
+	}
+
+	if !m.Delete(ids[0]) || m.Delete(ids[0]) {
+		t.Error("Delete should succeed once")
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	if v, ok := evaluateCondition([]float64{1, 9, 3}, ">", 5); v != 9 || !ok {
+		t.Errorf("> = %v, %v; want 9, true", v, ok)
+	}
+	if v, ok := evaluateCondition([]float64{4, 2, 3}, "<", 1); v != 2 || ok {
+		t.Errorf("< = %v, %v; want 2, false", v, ok)
+	}
+	if _, ok := evaluateCondition([]float64{5}, ">=", 5); !ok {
+		t.Error(">= should include the threshold")
+	}
+}
+
+func TestPromInstant(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.EndpointPromQueryInstant {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"metric": map[string]string{"a": "1"}, "value": []any{1700000000, "2.5"}},
+			{"metric": map[string]string{"a": "2"}, "value": []any{1700000000, "NaN"}},
+		})
+	}))
+	defer server.Close()
+
+	cfg := models.Config{
+		APIBaseURL: server.URL,
+		TokenManager: &auth.TokenManager{
+			AccessToken: "mock-token",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	}
+	m := NewManager(server.Client(), cfg)
+	values, err := m.promInstant(context.Background(), "up", time.Now())
+	if err != nil {
+		t.Fatalf("promInstant() error: %v", err)
+	}
+	if len(values) != 1 || values[0] != 2.5 {
+		t.Errorf("values = %v, want [2.5]", values)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	m := newTestManager(t, &fakeSource{values: []float64{1}})
+	create := NewCreateWatchHandler(m)
+	list := NewListWatchesHandler(m)
+	del := NewDeleteWatchHandler(m)
+	ctx := context.Background()
+
+	result, _, err := create(ctx, &mcp.CallToolRequest{}, CreateWatchArgs{Query: "up", Operator: "<", Threshold: 1, IntervalSeconds: 30, DurationMinutes: 10})
+	if err != nil {
+		t.Fatalf("create_watch error: %v", err)
+	}
+	var w Watch
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &w); err != nil {
+		t.Fatalf("failed to unmarshal watch: %v", err)
+	}
+	if w.ID == "" || w.IntervalSeconds != 30 || w.ExpiresAt.Sub(w.CreatedAt) != 10*time.Minute {
+		t.Errorf("watch = %+v", w)
+	}
+
+	if _, _, err := list(ctx, &mcp.CallToolRequest{}, ListWatchesArgs{ID: "missing"}); err == nil {
+		t.Error("expected error for unknown id")
+	}
+	result, _, err = list(ctx, &mcp.CallToolRequest{}, ListWatchesArgs{ID: w.ID})
+	if err != nil || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, `"count":1`) {
+		t.Errorf("list_watches = %v, %v", result, err)
+	}
+
+	if _, _, err := del(ctx, &mcp.CallToolRequest{}, DeleteWatchArgs{ID: w.ID}); err != nil {
+		t.Errorf("delete_watch error: %v", err)
+	}
+	if _, _, err := del(ctx, &mcp.CallToolRequest{}, DeleteWatchArgs{ID: w.ID}); err == nil {
+		t.Error("expected error deleting twice")
+	}
+	if _, _, err := create(ctx, &mcp.CallToolRequest{}, CreateWatchArgs{Query: "up", Operator: ">", IntervalSeconds: -1}); err == nil {
+		t.Error("expected error for negative interval")
+	}
+}
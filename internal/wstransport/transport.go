@@ -0,0 +1,135 @@
+// Package wstransport serves MCP over WebSocket: one JSON-RPC message per
+// text frame, one MCP session per connection. Like the STDIO transport,
+// frames that cannot be decoded are logged and answered with a JSON-RPC error
+// instead of closing the session.
+package wstransport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/net/websocket"
+
+	"github.com/last9/last9-mcp-server/internal/jsonrpcerr"
+	"github.com/last9/last9-mcp-server/internal/logging"
+)
+
+// NewHandler returns an http.Handler that upgrades requests to WebSocket and
+// runs an MCP session for server on each connection until the peer
+// disconnects. Incoming frames larger than maxFrameBytes are rejected; zero
+// keeps the library default of 32 MiB.
+func NewHandler(server *mcp.Server, maxFrameBytes int) http.Handler {
+	return websocket.Server{
+		Handshake: checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			// The HTTP server's read/write deadlines still apply to the
+			// hijacked connection; sessions are long-lived.
+			_ = ws.SetDeadline(time.Time{})
+			ws.MaxPayloadBytes = maxFrameBytes
+			serve(ws.Request().Context(), server, ws)
+		},
+	}
+}
+
+func serve(ctx context.Context, server *mcp.Server, ws *websocket.Conn) {
+	ss, err := server.Connect(ctx, &transport{ws: ws}, nil)
+	if err != nil {
+		logging.Logger("websocket").Warn("websocket MCP session failed to start", "remote", ws.Request().RemoteAddr, "error", err)
+		_ = ws.Close()
+		return
+	}
+	if err := ss.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		logging.Logger("websocket").Debug("websocket MCP session ended", "remote", ws.Request().RemoteAddr, "error", err)
+	}
+}
+
+// checkOrigin accepts clients that send no Origin (CLIs, gateways) and
+// browsers on the same host, so a web page on another site cannot drive the
+// server through a visitor's browser.
+func checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	if u.Host != req.Host {
+		return fmt.Errorf("cross-origin websocket from %q not allowed", origin)
+	}
+	config.Origin = u
+	return nil
+}
+
+// transport adapts an accepted connection to mcp.Transport.
+type transport struct {
+	ws *websocket.Conn
+}
+
+func (t *transport) Connect(context.Context) (mcp.Connection, error) {
+	return &conn{ws: t.ws}, nil
+}
+
+// conn implements mcp.Connection over a WebSocket. Writes are serialized by
+// websocket.Conn itself.
+type conn struct {
+	ws        *websocket.Conn
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (c *conn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(c.ws, &data); err != nil {
+			if errors.Is(err, websocket.ErrFrameTooLarge) {
+				logging.Logger("websocket").Warn("dropping oversized websocket frame", "max_bytes", c.ws.MaxPayloadBytes)
+				c.writeError(nil, jsonrpcerr.CodeInvalidRequest, fmt.Sprintf("message exceeds %d bytes", c.ws.MaxPayloadBytes))
+				continue
+			}
+			return nil, err
+		}
+		msg, err := jsonrpc.DecodeMessage(data)
+		if err == nil {
+			return msg, nil
+		}
+		if !json.Valid(data) {
+			logging.Logger("websocket").Warn("dropping malformed websocket frame", "bytes", len(data))
+			c.writeError(nil, jsonrpcerr.CodeParseError, "Parse error")
+			continue
+		}
+		logging.Logger("websocket").Warn("dropping invalid JSON-RPC message", "error", err)
+		c.writeError(jsonrpcerr.RequestID(data), jsonrpcerr.CodeInvalidRequest, "Invalid Request: "+err.Error())
+	}
+}
+
+func (c *conn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return websocket.Message.Send(c.ws, string(data))
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() { c.closeErr = c.ws.Close() })
+	return c.closeErr
+}
+
+func (c *conn) SessionID() string { return "" }
+
+func (c *conn) writeError(id json.RawMessage, code int, message string) {
+	data := jsonrpcerr.Marshal(id, code, message)
+	if err := websocket.Message.Send(c.ws, string(data)); err != nil {
+		logging.Logger("websocket").Warn("failed to write websocket error response", "error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package wstransport
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/internal/jsonrpcerr"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/net/websocket"
+)
+
+func dial(t *testing.T, url, origin string) *websocket.Conn {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(url, "http"), "", origin)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func receive(t *testing.T, ws *websocket.Conn) map[string]any {
+	t.Helper()
+	var data []byte
+	if err := websocket.Message.Receive(ws, &data); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	return msg
+}
+
+func errorCode(msg map[string]any) float64 {
+	e, _ := msg["error"].(map[string]any)
+	code, _ := e["code"].(float64)
+	return code
+}
+
+func TestWebSocketSession(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	ts := httptest.NewServer(NewHandler(server, 4096))
+	defer ts.Close()
+
+	ws := dial(t, ts.URL, ts.URL)
+
+	send := func(frame string) {
+		t.Helper()
+		if err := websocket.Message.Send(ws, frame); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	send("not json")
+	if got := errorCode(receive(t, ws)); got != jsonrpcerr.CodeParseError {
+		t.Fatalf("garbage frame: code = %v, want %d", got, jsonrpcerr.CodeParseError)
+	}
+
+	send(`{"jsonrpc":"1.0","id":9,"method":"ping"}`)
+	msg := receive(t, ws)
+	if got := errorCode(msg); got != jsonrpcerr.CodeInvalidRequest || msg["id"] != float64(9) {
+		t.Fatalf("invalid message: got %v, want code %d with id 9", msg, jsonrpcerr.CodeInvalidRequest)
+	}
+
+	send(`{"jsonrpc":"2.0","id":2,"method":"ping","params":{"pad":"` + strings.Repeat("x", 5000) + `"}}`)
+	if got := errorCode(receive(t, ws)); got != jsonrpcerr.CodeInvalidRequest {
+		t.Fatalf("oversized frame: code = %v, want %d", got, jsonrpcerr.CodeInvalidRequest)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"c","version":"0"}}}`)
+	msg = receive(t, ws)
+	if msg["id"] != float64(1) || msg["result"] == nil {
+		t.Fatalf("initialize: got %v, want a result for id 1", msg)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		wantErr bool
+	}{
+		{name: "no origin", origin: ""},
+		{name: "same host", origin: "http://example.com:8080"},
+		{name: "other host", origin: "https://evil.example", wantErr: true},
+		{name: "other port", origin: "http://example.com:9090", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com:8080/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			err := checkOrigin(&websocket.Config{}, req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkOrigin(%q) error = %v, wantErr %v", tt.origin, err, tt.wantErr)
+			}
+		})
+	}
+}
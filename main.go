@@ -11,11 +11,11 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	last9mcp "github.com/last9/mcp-go-sdk/mcp"
-	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/peterbourgon/ff/v3"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -23,11 +23,21 @@ import (
 	metricnoop "go.opentelemetry.io/otel/metric/noop"
 	tracenoop "go.opentelemetry.io/otel/trace/noop"
 
-	"last9-mcp/internal/attributes"
-	"last9-mcp/internal/auth"
-	"last9-mcp/internal/models"
-	l9telemetry "last9-mcp/internal/telemetry"
-	"last9-mcp/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/apm"
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/criticality"
+	"github.com/last9/last9-mcp-server/internal/diskcache"
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/maintenance"
+	"github.com/last9/last9-mcp-server/internal/mockbackend"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/queryhistory"
+	"github.com/last9/last9-mcp-server/internal/redact"
+	"github.com/last9/last9-mcp-server/internal/stdio"
+	l9telemetry "github.com/last9/last9-mcp-server/internal/telemetry"
+	"github.com/last9/last9-mcp-server/internal/toolset"
+	"github.com/last9/last9-mcp-server/internal/utils"
+	"github.com/last9/last9-mcp-server/internal/views"
 )
 
 // Version information
@@ -37,27 +47,69 @@ var (
 	BuildTime = "unknown" // Set by goreleaser
 )
 
-// setupConfig initializes and parses the configuration
-func SetupConfig(defaults models.Config) (models.Config, error) {
+// SetupConfig parses the configuration from args (flags), LAST9_* env vars
+// and an optional config file.
+func SetupConfig(defaults models.Config, args []string) (models.Config, error) {
 	fs := flag.NewFlagSet("last9-mcp", flag.ExitOnError)
 
 	var cfg models.Config
 	fs.StringVar(&cfg.RefreshToken, "refresh_token", os.Getenv("LAST9_REFRESH_TOKEN"), "Last9 refresh token for authentication")
+	fs.BoolVar(&cfg.MockBackend, "mock_backend", false, "Serve synthetic data from an in-process mock backend instead of Last9; no refresh token is needed")
 	fs.StringVar(&cfg.DatasourceName, "datasource", os.Getenv("LAST9_DATASOURCE"), "Datasource name to use (overrides default datasource)")
 	fs.StringVar(&cfg.APIHost, "api_host", os.Getenv("LAST9_API_HOST"), "API host (defaults to app.last9.io)")
+	fs.StringVar(&cfg.ProxyURL, "proxy_url", "", "Proxy for Last9 API calls (e.g. http://proxy.internal:3128); empty honours HTTPS_PROXY, HTTP_PROXY and NO_PROXY")
+	fs.StringVar(&cfg.CABundleFile, "ca_bundle_file", "", "PEM file of CA certificates to trust for Last9 API calls, in addition to the system roots")
+	extraHeaders := fs.String("extra_headers", "", "Comma-separated Name=Value headers added to every Last9 API request (e.g. X-Gateway-Key=abc)")
 	fs.BoolVar(&cfg.DisableTelemetry, "disable_telemetry", true, "Disable OpenTelemetry tracing/metrics")
 	fs.Float64Var(&cfg.RequestRateLimit, "rate", 1, "Requests per second limit")
 	fs.IntVar(&cfg.RequestRateBurst, "burst", 1, "Request burst capacity")
 	fs.IntVar(&cfg.MaxGetLogsEntries, "max_get_logs_entries", models.DefaultMaxGetLogsEntries, "Maximum number of entries returned by chunked raw get_logs requests")
-	fs.BoolVar(&cfg.HTTPMode, "http", false, "Run as HTTP server instead of STDIO")
+	fs.IntVar(&cfg.MaxQuerySeries, "max_query_series", models.DefaultMaxQuerySeries, "Maximum series a prometheus_range_query may return before it is refused")
+	fs.IntVar(&cfg.MaxQueryWindowHours, "max_query_window_hours", models.DefaultMaxQueryWindowHours, "Maximum prometheus_range_query window in hours")
+	fs.IntVar(&cfg.MaxQueryPoints, "max_query_points", models.DefaultMaxQueryPoints, "Maximum points a prometheus_range_query reads before the result is truncated")
+	fs.BoolVar(&cfg.HTTPMode, "http", false, "Run as HTTP server instead of STDIO (same as --transport http)")
+	fs.StringVar(&cfg.Transport, "transport", "", "Transport: stdio (default), http (streamable HTTP at /mcp), websocket (ws://host:port/ws) or unix (--socket_path)")
+	fs.StringVar(&cfg.SocketPath, "socket_path", "", "Unix socket path for --transport unix; created with mode 0600")
+	fs.StringVar(&cfg.TLSCertFile, "tls_cert_file", "", "PEM certificate (chain) to serve the http and websocket transports over TLS; requires --tls_key_file")
+	fs.StringVar(&cfg.TLSKeyFile, "tls_key_file", "", "PEM private key for --tls_cert_file")
+	fs.StringVar(&cfg.TLSClientCAFile, "tls_client_ca_file", "", "PEM CA certificates client certificates must chain to; enables mTLS and requires --tls_cert_file")
+	fs.IntVar(&cfg.MaxRequestBytes, "max_request_bytes", models.DefaultMaxRequestBytes, "Largest request body, or incoming STDIO or unix socket frame, the server accepts; 0 disables the limit")
+	fs.IntVar(&cfg.MaxRequestJSONDepth, "max_request_json_depth", models.DefaultMaxRequestJSONDepth, "Deepest nesting of JSON arrays and objects accepted in a request body; 0 disables the limit")
 	fs.StringVar(&cfg.Port, "port", "8080", "HTTP server port")
 	fs.StringVar(&cfg.Host, "host", "localhost", "HTTP server host")
+	logLevel := fs.String("log_level", "info", "Minimum level of the server's own logs: debug, info, warn or error")
+	logFormat := fs.String("log_format", logging.FormatText, "Format of the server's own logs on stderr: text or json")
+	logModules := fs.String("log_modules", "", "Comma-separated module=level overrides of --log_level (e.g. auth=debug,http=warn)")
+	fs.StringVar(&cfg.CacheDir, "cache_dir", diskcache.DefaultDir(), "Directory for the on-disk attribute cache")
+	fs.StringVar(&cfg.DefaultEnv, "default_env", "", "Environment APM tools filter by when a call does not set env (e.g. production); empty means all environments")
+	quantiles := fs.String("quantiles", "", "Comma-separated response-time quantiles APM tools report: p50, p75, p90, p95, p99, p999, avg, max (default p50,p90,p95,avg,max)")
+	samplingRates := fs.String("sampling_rates", "", "Comma-separated service=rate trace sampling rates for extrapolate_sampling, as fractions (0.1) or 1-in-N (10); * sets the rest (e.g. checkout=0.1,*=0.5)")
+	fs.StringVar(&cfg.SamplingRateMetric, "sampling_rate_metric", "", "Metric with a service_name label reporting each service's trace sampling rate, used by extrapolate_sampling for services without a --sampling_rates entry")
+	fs.StringVar(&cfg.DisplayTimezone, "display_timezone", "", "IANA timezone (e.g. Asia/Kolkata) for human-readable timestamps added to tool output")
+	fs.StringVar(&cfg.ExportDir, "export_dir", "", "Directory tool results may be exported to with the export argument; empty disables exports")
+	fs.IntVar(&cfg.MaxMessageBytes, "max_message_bytes", models.DefaultMaxMessageBytes, "Largest tool result sent in one message; bigger results are split into chunks read with get_result_chunk. 0 disables the limit")
+	fs.StringVar(&cfg.ResultArchiveDir, "result_archive_dir", "", "Directory tool results are stored in, compressed, for fetch_result and diff_results; empty disables storing")
+	fs.IntVar(&cfg.ResultTTLHours, "result_ttl_hours", models.DefaultResultTTLHours, "Hours a stored tool result is kept before it expires (max 720); 0 disables storing")
+	fs.StringVar(&cfg.CustomToolsFile, "custom_tools_file", "", "JSON file declaring extra organization-specific HTTP tools")
+	fs.StringVar(&cfg.QueryHistoryFile, "query_history_file", queryhistory.DefaultPath(), "JSON Lines file PromQL queries are recorded to for list_query_history and replay_query; empty keeps history in memory")
+	fs.StringVar(&cfg.ViewsFile, "views_file", views.DefaultPath(), "JSON file saved views (named APM tool arguments) are kept in; empty keeps them in memory")
+	fs.StringVar(&cfg.MaintenanceFile, "maintenance_file", maintenance.DefaultPath(), "JSON file declared maintenance windows are kept in; empty keeps them in memory")
+	fs.StringVar(&cfg.CriticalityFile, "criticality_file", criticality.DefaultPath(), "JSON file endpoint criticality tags are kept in; empty keeps them in memory")
+	fs.StringVar(&cfg.MacrosFile, "macros_file", "", "JSON file declaring macros: named sequences of tool calls exposed as single tools")
+	refreshTokenFile := fs.String("refresh_token_file", "", "Read the Last9 refresh token from this file instead of LAST9_REFRESH_TOKEN")
+	useKeychain := fs.Bool("use_keychain", false, "Read the Last9 refresh token from the OS keychain (store it with `last9-mcp store-token`)")
+	var enabledTools, disabledTools toolListFlag
+	fs.Var(&enabledTools, "enabled_tools", "Comma-separated tools to expose; all others are hidden")
+	fs.Var(&disabledTools, "disabled_tools", "Comma-separated tools to hide (e.g. prometheus_range_query,prometheus_instant_query)")
+	fs.BoolVar(&cfg.EnableWriteTools, "enable_write_tools", false, "Register tools that change Last9 configuration, such as create_alert_rule")
+	fs.BoolVar(&cfg.EnableAdminTools, "enable_admin_tools", false, "Register tools that change how the server runs, such as set_log_level")
+	disableDiskCache := fs.Bool("disable_disk_cache", false, "Disable the on-disk attribute cache")
 	versionFlag := fs.Bool("version", false, "Print version information")
 
 	var configFile string
 	fs.StringVar(&configFile, "config", "", "config file path")
 
-	err := ff.Parse(fs, os.Args[1:],
+	err := ff.Parse(fs, args,
 		ff.WithEnvVarPrefix("LAST9"),
 		ff.WithConfigFileFlag("config"),
 		ff.WithConfigFileParser(ff.JSONParser),
@@ -71,16 +123,75 @@ func SetupConfig(defaults models.Config) (models.Config, error) {
 		os.Exit(0)
 	}
 
-	if cfg.RefreshToken == "" {
+	if cfg.RefreshToken == "" && *refreshTokenFile != "" {
+		token, err := auth.ReadRefreshTokenFile(*refreshTokenFile)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.RefreshToken = token
+	}
+	if cfg.RefreshToken == "" && *useKeychain {
+		token, err := auth.LoadRefreshTokenFromKeychain(context.Background())
+		if err != nil {
+			return cfg, err
+		}
+		cfg.RefreshToken = token
+	}
+	if cfg.RefreshToken == "" && !cfg.MockBackend {
 		if defaults.RefreshToken != "" {
 			cfg.RefreshToken = defaults.RefreshToken
 		} else {
-			return cfg, errors.New("Last9 refresh token must be provided via LAST9_REFRESH_TOKEN env var")
+			return cfg, errors.New("Last9 refresh token must be provided via LAST9_REFRESH_TOKEN, LAST9_REFRESH_TOKEN_FILE or LAST9_USE_KEYCHAIN")
 		}
 	}
+	cfg.EnabledTools = enabledTools
+	cfg.DisabledTools = disabledTools
+	if cfg.Quantiles, err = apm.ParseQuantiles(strings.Split(*quantiles, ",")); err != nil {
+		return cfg, fmt.Errorf("invalid --quantiles: %w", err)
+	}
+	if cfg.SamplingRates, err = apm.ParseSamplingRates(*samplingRates); err != nil {
+		return cfg, fmt.Errorf("invalid --sampling_rates: %w", err)
+	}
+	if cfg.Logging, err = logging.ParseOptions(*logLevel, *logFormat, *logModules); err != nil {
+		return cfg, err
+	}
+	if cfg.ExtraHeaders, err = auth.ParseHeaders(*extraHeaders); err != nil {
+		return cfg, fmt.Errorf("invalid --extra_headers: %w", err)
+	}
+	if *disableDiskCache {
+		cfg.CacheDir = ""
+	}
 	if cfg.MaxGetLogsEntries <= 0 {
 		cfg.MaxGetLogsEntries = models.DefaultMaxGetLogsEntries
 	}
+	if cfg.Transport == "" {
+		cfg.Transport = models.TransportStdio
+		if cfg.HTTPMode {
+			cfg.Transport = models.TransportHTTP
+		}
+	}
+	switch cfg.Transport {
+	case models.TransportStdio, models.TransportHTTP, models.TransportWebSocket:
+	case models.TransportUnix:
+		if cfg.SocketPath == "" {
+			return cfg, errors.New("--transport unix requires --socket_path (LAST9_SOCKET_PATH)")
+		}
+	default:
+		return cfg, fmt.Errorf("invalid transport %q: use %s, %s, %s or %s", cfg.Transport, models.TransportStdio, models.TransportHTTP, models.TransportWebSocket, models.TransportUnix)
+	}
+	cfg.HTTPMode = cfg.Transport == models.TransportHTTP || cfg.Transport == models.TransportWebSocket
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return cfg, errors.New("--tls_cert_file and --tls_key_file must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return cfg, errors.New("--tls_client_ca_file requires --tls_cert_file and --tls_key_file")
+	}
+	if cfg.TLSCertFile != "" && !cfg.HTTPMode {
+		return cfg, fmt.Errorf("TLS options apply to the %s and %s transports only", models.TransportHTTP, models.TransportWebSocket)
+	}
+	if _, err := utils.LoadDisplayLocation(cfg.DisplayTimezone); err != nil {
+		return cfg, err
+	}
 
 	return cfg, nil
 }
@@ -94,18 +205,46 @@ func main() {
 		}
 		return
 	}
+	// store-token saves a refresh token read from stdin in the OS keychain,
+	// for use with --use_keychain.
+	if len(os.Args) > 1 && os.Args[1] == "store-token" {
+		if err := storeToken(os.Stdin, os.Stderr); err != nil {
+			log.Fatalf("store-token failed: %v", err)
+		}
+		return
+	}
 
-	log.Printf("Starting Last9 MCP Server v%s", Version)
+	// Scrub credentials from everything written through log and slog.
+	log.SetOutput(redact.Writer(os.Stderr))
 
-	// Load .env file if it exists (ignore errors if file doesn't exist)
-	if err := godotenv.Load(); err != nil {
-		log.Printf("No .env file found or error loading it (this is ok): %v", err)
+	// call runs one tool and prints its result, without an MCP client.
+	if len(os.Args) > 1 && os.Args[1] == "call" {
+		if err := runCall(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("call failed: %v", err)
+		}
+		return
+	}
+	// explore is an interactive terminal frontend over the same tools.
+	if len(os.Args) > 1 && os.Args[1] == "explore" {
+		if err := runExplore(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("explore failed: %v", err)
+		}
+		return
 	}
 
-	cfg, err := SetupConfig(models.Config{})
+	// Load .env file if it exists (ignore errors if file doesn't exist)
+	envErr := godotenv.Load()
+
+	cfg, err := SetupConfig(models.Config{}, os.Args[1:])
 	if err != nil {
 		log.Fatalf("config error: %v", err)
 	}
+	logging.Setup(redact.Writer(os.Stderr), cfg.Logging)
+	logger := logging.Logger("server")
+	logger.Info("starting Last9 MCP Server", "version", Version)
+	if envErr != nil {
+		logger.Debug("no .env file loaded", "error", envErr)
+	}
 	// OTEL_SDK_DISABLED is the standard OTel env var. Honour it explicitly so
 	// that users can override the default (disable_telemetry=true) without
 	// needing the LAST9_DISABLE_TELEMETRY env var.
@@ -117,15 +256,11 @@ func main() {
 
 	// Auth and API config must come before OTel init so tenant/cluster IDs
 	// are available as resource attributes on all spans and metrics.
-	tokenManager, err := auth.NewTokenManager(cfg.RefreshToken)
+	closeBackend, err := connect(&cfg)
 	if err != nil {
-		log.Fatalf("failed to create token manager: %v", err)
-	}
-
-	cfg.TokenManager = tokenManager
-	if err := utils.PopulateAPICfg(&cfg); err != nil {
-		log.Fatalf("failed to populate API config: %v", err)
+		fatal("authentication failed", err)
 	}
+	defer closeBackend()
 
 	if cfg.DisableTelemetry {
 		otel.SetMeterProvider(metricnoop.NewMeterProvider())
@@ -133,33 +268,52 @@ func main() {
 	} else {
 		shutdown, err := l9telemetry.InitProviders(context.Background(), Version, cfg.OrgSlug, cfg.ClusterID)
 		if err != nil {
-			log.Fatalf("failed to init telemetry: %v", err)
+			fatal("failed to init telemetry", err)
 		}
 		defer func() {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := shutdown(ctx); err != nil {
-				slog.Error("telemetry shutdown error", "error", err)
+				logger.Error("telemetry shutdown error", "error", err)
 			}
 		}()
 	}
 
-	slog.Info("config loaded",
-		"http_mode", cfg.HTTPMode,
+	logger.Info("config loaded",
+		"transport", cfg.Transport,
+		"tls", cfg.TLSCertFile != "",
+		"mtls", cfg.TLSClientCAFile != "",
+		"max_request_bytes", cfg.MaxRequestBytes,
+		"max_request_json_depth", cfg.MaxRequestJSONDepth,
 		"max_get_logs_entries", cfg.MaxGetLogsEntries,
+		"cache_dir", cfg.CacheDir,
+		"default_env", cfg.DefaultEnv,
+		"quantiles", cfg.Quantiles,
+		"sampling_rates", cfg.SamplingRates,
+		"sampling_rate_metric", cfg.SamplingRateMetric,
+		"display_timezone", cfg.DisplayTimezone,
+		"export_dir", cfg.ExportDir,
+		"max_message_bytes", cfg.MaxMessageBytes,
+		"result_archive_dir", cfg.ResultArchiveDir,
+		"result_ttl_hours", cfg.ResultTTLHours,
+		"custom_tools_file", cfg.CustomToolsFile,
+		"macros_file", cfg.MacrosFile,
+		"query_history_file", cfg.QueryHistoryFile,
+		"views_file", cfg.ViewsFile,
+		"maintenance_file", cfg.MaintenanceFile,
+		"criticality_file", cfg.CriticalityFile,
 		"telemetry_disabled", cfg.DisableTelemetry,
 		"version", Version,
 	)
 
-	// Create attribute cache and perform best-effort initial fetch
-	attrCache := attributes.NewAttributeCache(auth.GetHTTPClient(), cfg)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	attrCache.Warm(ctx)
-	cancel()
+	// The toolset owns the attribute cache, background watches and the
+	// store for results too large for one message.
+	ts := toolset.New(cfg)
+	defer ts.Close()
 
 	server, err := last9mcp.NewServerWithOptions("last9-mcp", Version, last9mcp.WithSkipProviderInit())
 	if err != nil {
-		log.Fatalf("failed to create MCP server: %v", err)
+		fatal("failed to create MCP server", err)
 	}
 
 	if !cfg.DisableTelemetry {
@@ -169,7 +323,7 @@ func main() {
 			metric.WithDescription("MCP server version info; value is always 1, use labels for version tracking"),
 		)
 		if err != nil {
-			slog.Warn("failed to create server info gauge", "error", err)
+			logger.Warn("failed to create server info gauge", "error", err)
 		} else if reg, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
 			o.ObserveInt64(serverInfo, 1,
 				metric.WithAttributes(
@@ -179,15 +333,28 @@ func main() {
 			)
 			return nil
 		}, serverInfo); err != nil {
-			slog.Warn("failed to register server info callback", "error", err)
+			logger.Warn("failed to register server info callback", "error", err)
 		} else {
 			defer reg.Unregister()
 		}
 	}
 
-	// Register all tools
-	if err := registerAllTools(server, cfg, attrCache); err != nil {
-		log.Fatalf("failed to register tools: %v", err)
+	// Preflight: validate the token and datasource, load attribute names,
+	// environments and services into the cache, and register the tools, so
+	// the first tool call does not pay for it.
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	readiness := ts.Preflight(ctx, server)
+	cancel()
+	for _, c := range readiness.Checks {
+		level := slog.LevelInfo
+		if !c.OK {
+			level = slog.LevelWarn
+		}
+		logger.Log(context.Background(), level, "preflight check", "check", c.Name, "ok", c.OK, "detail", c.Detail, "duration_ms", c.DurationMs)
+	}
+	logger.Info("preflight complete", "ready", readiness.Ready, "duration_ms", readiness.DurationMs)
+	if c, _ := readiness.Check("tools"); !c.OK {
+		fatal("failed to register tools", errors.New(c.Detail))
 	}
 
 	// Background goroutine to refresh attributes and re-register tools periodically
@@ -196,26 +363,50 @@ func main() {
 		defer ticker.Stop()
 		for range ticker.C {
 			refreshCtx, refreshCancel := context.WithTimeout(context.Background(), 30*time.Second)
-			if err := attrCache.RefreshIfStale(refreshCtx); err != nil {
-				slog.Warn("failed to refresh attribute cache", "error", err)
+			// Re-register tools with updated descriptions (AddTool is an upsert)
+			if err := ts.Refresh(refreshCtx, server); err != nil {
+				logger.Warn("failed to refresh tool descriptions", "error", err)
 			} else {
-				// Re-register tools with updated descriptions (AddTool is an upsert)
-				if err := registerAllTools(server, cfg, attrCache); err != nil {
-					slog.Warn("failed to re-register tools after cache refresh", "error", err)
-				} else {
-					slog.Info("attribute cache refreshed and tools re-registered")
-				}
+				logger.Info("attribute cache refreshed and tools re-registered")
 			}
 			refreshCancel()
 		}
 	}()
 
-	if cfg.HTTPMode {
+	switch {
+	case cfg.HTTPMode:
 		httpServer := NewHTTPServer(server, cfg)
+		httpServer.readiness = &readiness
 		if err := httpServer.Start(); err != nil {
-			log.Fatalf("HTTP server error: %v", err)
+			fatal("HTTP server error", err)
 		}
-	} else {
-		log.Fatal(server.Serve(context.Background(), &mcp.StdioTransport{}))
+	case cfg.Transport == models.TransportUnix:
+		if err := NewUnixServer(server, cfg).Start(); err != nil {
+			fatal("Unix socket server error", err)
+		}
+	default:
+		fatal("STDIO server stopped", server.Serve(context.Background(), stdio.NewTransport(os.Stdin, os.Stdout, cfg.MaxRequestBytes, cfg.MaxMessageBytes)))
 	}
 }
+
+// connect points cfg at the Last9 API with Authenticate or, with
+// --mock_backend, at an in-process mock backend serving synthetic data. The
+// returned func stops the mock backend.
+func connect(cfg *models.Config) (func(), error) {
+	if !cfg.MockBackend {
+		return func() {}, toolset.Authenticate(cfg)
+	}
+	mock, err := mockbackend.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start mock backend: %w", err)
+	}
+	mock.Configure(cfg)
+	logging.Logger("server").Warn("serving synthetic data from the mock backend", "url", mock.URL())
+	return mock.Close, nil
+}
+
+// fatal logs msg and err at error level and exits.
+func fatal(msg string, err error) {
+	logging.Logger("server").Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/toolset"
+	"github.com/last9/last9-mcp-server/internal/utils"
+)
+
+// Client is an authenticated connection to a Last9 organization. It is safe
+// for concurrent use; the access token is refreshed as it nears expiry.
+type Client struct {
+	cfg models.Config
+}
+
+// Connect exchanges cfg.RefreshToken for an access token and resolves the
+// organization, region and datasource the tools query. The proxy, CA bundle
+// and extra headers in cfg apply to every Last9 API call. The credentials
+// and extra header values are redacted from logs and tool results.
+func Connect(cfg Config) (*Client, error) {
+	if cfg.RefreshToken == "" {
+		return nil, errors.New("a refresh token is required")
+	}
+	c := cfg.models()
+	if err := toolset.Authenticate(&c); err != nil {
+		return nil, err
+	}
+	return &Client{cfg: c}, nil
+}
+
+// OrgSlug returns the organization the client is connected to.
+func (c *Client) OrgSlug() string { return c.cfg.OrgSlug }
+
+// Region returns the region of the resolved datasource.
+func (c *Client) Region() string { return c.cfg.Region }
+
+// ClusterID returns the cluster of the resolved datasource.
+func (c *Client) ClusterID() string { return c.cfg.ClusterID }
+
+// Query runs an instant PromQL query at t against the resolved datasource
+// and returns the JSON result as the API sent it.
+func (c *Client) Query(ctx context.Context, promql string, t time.Time) (json.RawMessage, error) {
+	resp, err := utils.MakePromInstantAPIQuery(ctx, auth.GetHTTPClient(), promql, t.Unix(), c.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %w", err)
+	}
+	return readQueryResponse(resp)
+}
+
+// QueryRange runs a PromQL range query over [start, end] against the
+// resolved datasource and returns the JSON result as the API sent it.
+func (c *Client) QueryRange(ctx context.Context, promql string, start, end time.Time) (json.RawMessage, error) {
+	if start.After(end) {
+		return nil, errors.New("start cannot be after end")
+	}
+	resp, err := utils.MakePromRangeAPIQuery(ctx, auth.GetHTTPClient(), promql, start.Unix(), end.Unix(), c.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run range query: %w", err)
+	}
+	return readQueryResponse(resp)
+}
+
+func readQueryResponse(resp *http.Response) (json.RawMessage, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query failed: %s: %s", resp.Status, body)
+	}
+	if !json.Valid(body) {
+		return nil, errors.New("query response is not valid JSON")
+	}
+	return body, nil
+}
+
+// resolve copies the settings Connect resolved into cfg.
+func (c *Client) resolve(cfg *models.Config) {
+	cfg.RefreshToken = c.cfg.RefreshToken
+	cfg.APIHost = c.cfg.APIHost
+	cfg.DatasourceName = c.cfg.DatasourceName
+	cfg.OrgSlug = c.cfg.OrgSlug
+	cfg.ActionURL = c.cfg.ActionURL
+	cfg.APIBaseURL = c.cfg.APIBaseURL
+	cfg.Region = c.cfg.Region
+	cfg.ClusterID = c.cfg.ClusterID
+	cfg.PrometheusReadURL = c.cfg.PrometheusReadURL
+	cfg.PrometheusUsername = c.cfg.PrometheusUsername
+	cfg.PrometheusPassword = c.cfg.PrometheusPassword
+	cfg.Datasources = c.cfg.Datasources
+	cfg.TokenManager = c.cfg.TokenManager
+}
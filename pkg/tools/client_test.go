@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/mockbackend"
+)
+
+func TestClientQuery(t *testing.T) {
+	backend, err := mockbackend.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	client := &Client{}
+	backend.Configure(&client.cfg)
+
+	end := time.Now()
+	raw, err := client.Query(context.Background(), `sum by (service_name)(trace_service_response_time{env="production"})`, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var instant []struct {
+		Metric map[string]string `json:"metric"`
+	}
+	if err := json.Unmarshal(raw, &instant); err != nil || len(instant) == 0 || instant[0].Metric["service_name"] == "" {
+		t.Fatalf("instant result = %s, %v", raw, err)
+	}
+
+	raw, err = client.QueryRange(context.Background(), "up", end.Add(-time.Hour), end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var series []struct {
+		Values [][]any `json:"values"`
+	}
+	if err := json.Unmarshal(raw, &series); err != nil || len(series) == 0 || len(series[0].Values) == 0 {
+		t.Fatalf("range result = %s, %v", raw, err)
+	}
+	if _, err := client.QueryRange(context.Background(), "up", end, end.Add(-time.Hour)); err == nil {
+		t.Error("a range ending before it starts should fail")
+	}
+
+	if client.OrgSlug() != mockbackend.OrgSlug {
+		t.Errorf("org = %q", client.OrgSlug())
+	}
+	if _, err := Connect(Config{}); err == nil {
+		t.Error("Connect without a refresh token should fail")
+	}
+}
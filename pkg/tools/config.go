@@ -0,0 +1,95 @@
+package tools
+
+import "github.com/last9/last9-mcp-server/internal/models"
+
+// Config configures the Last9 connection and the tool surface. Connect reads
+// the connection settings; New reads the rest. Only RefreshToken is
+// required; every other zero value means the server's default or disables
+// the feature, as noted per field.
+type Config struct {
+	// Connection settings.
+	RefreshToken   string            // Last9 refresh token; required by Connect
+	APIHost        string            // API host; empty derives it from the token
+	DatasourceName string            // Prometheus datasource; empty uses the organization's default
+	ProxyURL       string            // Proxy for Last9 API calls; empty honours HTTPS_PROXY/HTTP_PROXY/NO_PROXY
+	CABundleFile   string            // PEM CA certificates trusted in addition to the system roots
+	ExtraHeaders   map[string]string // Headers set on every Last9 API request
+
+	// Query limits; 0 uses the default of each.
+	MaxGetLogsEntries   int // Entries returned by chunked raw get_logs requests
+	MaxGetTracesEntries int // Traces returned by chunked get_traces requests
+	MaxQuerySeries      int // Series a prometheus_range_query may return
+	MaxQueryWindowHours int // prometheus_range_query window in hours
+	MaxQueryPoints      int // Points a prometheus_range_query reads before truncating
+
+	MaxMessageBytes int // Largest tool result in bytes; larger ones are split into chunks. 0 disables the limit
+
+	// Defaults applied when a call sets none.
+	DefaultEnv         string             // env APM tools filter by; empty means every environment
+	Quantiles          []string           // response-time quantiles APM tools report; empty means the default set
+	SamplingRates      map[string]float64 // trace sampling rate per service, "*" for the rest
+	SamplingRateMetric string             // gauge with a service_name label reporting each service's sampling rate
+	DisplayTimezone    string             // IANA timezone for *_local timestamps; empty disables them
+
+	// Files and directories the tools keep state in. An empty directory
+	// disables the feature; an empty file keeps the state in memory.
+	CacheDir         string // On-disk discovery cache
+	ExportDir        string // Files written by the export argument
+	ResultArchiveDir string // Tool results kept for fetch_result
+	ResultTTLHours   int    // Hours a stored tool result is kept; 0 disables storing
+	CustomToolsFile  string // Extra HTTP-backed tools
+	MacrosFile       string // Macros of tool calls
+	QueryHistoryFile string // PromQL query history
+	ViewsFile        string // Saved views
+	MaintenanceFile  string // Declared maintenance windows
+	CriticalityFile  string // Endpoint criticality tags
+
+	// Tool surface. When EnabledTools is set only those tools are registered;
+	// DisabledTools are then removed. Unknown names fail Register.
+	EnabledTools  []string
+	DisabledTools []string
+	// EnableWriteTools registers tools that change Last9 configuration,
+	// such as create_alert_rule.
+	EnableWriteTools bool
+	// EnableAdminTools registers tools that change how the server itself
+	// runs, such as set_log_level.
+	EnableAdminTools bool
+}
+
+// models returns the server configuration for cfg, without the settings
+// Connect resolves.
+func (cfg Config) models() models.Config {
+	return models.Config{
+		RefreshToken:        cfg.RefreshToken,
+		APIHost:             cfg.APIHost,
+		DatasourceName:      cfg.DatasourceName,
+		ProxyURL:            cfg.ProxyURL,
+		CABundleFile:        cfg.CABundleFile,
+		ExtraHeaders:        cfg.ExtraHeaders,
+		MaxGetLogsEntries:   cfg.MaxGetLogsEntries,
+		MaxGetTracesEntries: cfg.MaxGetTracesEntries,
+		MaxQuerySeries:      cfg.MaxQuerySeries,
+		MaxQueryWindowHours: cfg.MaxQueryWindowHours,
+		MaxQueryPoints:      cfg.MaxQueryPoints,
+		MaxMessageBytes:     cfg.MaxMessageBytes,
+		DefaultEnv:          cfg.DefaultEnv,
+		Quantiles:           cfg.Quantiles,
+		SamplingRates:       cfg.SamplingRates,
+		SamplingRateMetric:  cfg.SamplingRateMetric,
+		DisplayTimezone:     cfg.DisplayTimezone,
+		CacheDir:            cfg.CacheDir,
+		ExportDir:           cfg.ExportDir,
+		ResultArchiveDir:    cfg.ResultArchiveDir,
+		ResultTTLHours:      cfg.ResultTTLHours,
+		CustomToolsFile:     cfg.CustomToolsFile,
+		MacrosFile:          cfg.MacrosFile,
+		QueryHistoryFile:    cfg.QueryHistoryFile,
+		ViewsFile:           cfg.ViewsFile,
+		MaintenanceFile:     cfg.MaintenanceFile,
+		CriticalityFile:     cfg.CriticalityFile,
+		EnabledTools:        cfg.EnabledTools,
+		DisabledTools:       cfg.DisabledTools,
+		EnableWriteTools:    cfg.EnableWriteTools,
+		EnableAdminTools:    cfg.EnableAdminTools,
+	}
+}
@@ -0,0 +1,114 @@
+// Package tools registers the Last9 MCP tools on an MCP server. It is the
+// supported way to embed the tools in another Go program; the exported API
+// in this package follows semantic versioning, everything under internal/
+// does not.
+//
+//	cfg := tools.Config{RefreshToken: os.Getenv("LAST9_REFRESH_TOKEN")}
+//	client, err := tools.Connect(cfg)
+//	if err != nil {
+//		return err
+//	}
+//	ts := tools.New(client, cfg)
+//	defer ts.Close()
+//	ts.Warm(ctx)
+//	if err := ts.Register(server); err != nil {
+//		return err
+//	}
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/toolset"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+)
+
+// Toolset is the Last9 tool surface for one configuration, together with
+// the state its tools share: the attribute cache behind tool descriptions,
+// background watches, stored and split results, macros defined at runtime,
+// the PromQL query history, saved views, maintenance windows and endpoint
+// criticality tags.
+type Toolset struct {
+	ts *toolset.Toolset
+}
+
+// New returns a Toolset querying Last9 through client, with the tool
+// settings in cfg; its connection settings are ignored in favour of the
+// client's. A nil client registers the tools without a connection, which
+// is enough to list them; calls that query Last9 then fail. Call Close when
+// done to stop watches.
+func New(client *Client, cfg Config) *Toolset {
+	c := cfg.models()
+	if client != nil {
+		client.resolve(&c)
+	}
+	return &Toolset{ts: toolset.New(c)}
+}
+
+// Warm fetches the attribute names and environments listed in tool
+// descriptions. It is best effort: on failure the descriptions omit them.
+// Call it before Register, or use Preflight, which does both and reports
+// what it found.
+func (t *Toolset) Warm(ctx context.Context) {
+	t.ts.Warm(ctx)
+}
+
+// Register adds the configured tools to server. Registering again replaces
+// the tools with fresh descriptions.
+func (t *Toolset) Register(server *last9mcp.Last9MCPServer) error {
+	return t.ts.Register(server)
+}
+
+// Refresh reloads the attribute names if they are stale and re-registers
+// the tools on server so their descriptions reflect them.
+func (t *Toolset) Refresh(ctx context.Context, server *last9mcp.Last9MCPServer) error {
+	return t.ts.Refresh(ctx, server)
+}
+
+// Close stops background watches.
+func (t *Toolset) Close() {
+	t.ts.Close()
+}
+
+// PreflightCheck is the outcome of one startup check.
+type PreflightCheck struct {
+	Name       string `json:"name"`
+	OK         bool   `json:"ok"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Readiness summarizes the startup preflight. The toolset is ready when the
+// token, datasource and tools checks pass; a failed catalog check only means
+// tool descriptions lack the attribute and environment hints.
+type Readiness struct {
+	Ready      bool             `json:"ready"`
+	CheckedAt  time.Time        `json:"checked_at"`
+	DurationMs int64            `json:"duration_ms"`
+	Checks     []PreflightCheck `json:"checks"`
+}
+
+// Check returns the named check, or false when the preflight has none.
+func (r Readiness) Check(name string) (PreflightCheck, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return PreflightCheck{}, false
+}
+
+// Preflight makes sure the access token is valid, checks the datasource
+// resolved by Connect and loads the attribute names, environments and
+// services tool descriptions list, then registers the tools on server. It
+// replaces Warm followed by Register.
+func (t *Toolset) Preflight(ctx context.Context, server *last9mcp.Last9MCPServer) Readiness {
+	r := t.ts.Preflight(ctx, server)
+	readiness := Readiness{Ready: r.Ready, CheckedAt: r.CheckedAt, DurationMs: r.DurationMs}
+	for _, c := range r.Checks {
+		readiness.Checks = append(readiness.Checks, PreflightCheck(c))
+	}
+	return readiness
+}
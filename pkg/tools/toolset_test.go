@@ -0,0 +1,248 @@
+package tools_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/last9/last9-mcp-server/pkg/tools"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestToolsetEmbedding registers the tools the way an embedding program
+// would, using only the exported API.
+func TestToolsetEmbedding(t *testing.T) {
+	server, err := last9mcp.NewServerWithOptions("embedder", "test", last9mcp.WithSkipProviderInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	ts := tools.New(nil, tools.Config{
+		EnabledTools:  []string{"get_alerts", "get_logs"},
+		DisabledTools: []string{"get_logs"},
+	})
+	defer ts.Close()
+	if err := ts.Register(server); err != nil {
+		t.Fatal(err)
+	}
+	// Registering again replaces the tools rather than failing.
+	if err := ts.Register(server); err != nil {
+		t.Fatalf("second Register: %v", err)
+	}
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Server.Connect(context.Background(), serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	clientSession, err := client.Connect(context.Background(), clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientSession.Close()
+
+	list, err := clientSession.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Tools) != 1 || list.Tools[0].Name != "get_alerts" {
+		var names []string
+		for _, tool := range list.Tools {
+			names = append(names, tool.Name)
+		}
+		t.Fatalf("tools = %v, want [get_alerts]", names)
+	}
+}
+
+func TestRegisterRejectsUnknownToolNames(t *testing.T) {
+	server, err := last9mcp.NewServerWithOptions("embedder", "test", last9mcp.WithSkipProviderInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	ts := tools.New(nil, tools.Config{EnabledTools: []string{"get_alertz"}})
+	defer ts.Close()
+	if err := ts.Register(server); err == nil {
+		t.Fatal("Register with an unknown tool name succeeded")
+	}
+}
+
+func TestRegisterCustomTools(t *testing.T) {
+	write := func(t *testing.T, name string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "tools.json")
+		spec := `{"tools":[{"name":"` + name + `","description":"Find a runbook","url":"https://runbooks.example/search?q={{query}}","parameters":[{"name":"query","required":true}]}]}`
+		if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("registers", func(t *testing.T) {
+		server, err := last9mcp.NewServerWithOptions("embedder", "test", last9mcp.WithSkipProviderInit())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer server.Shutdown(context.Background())
+		ts := tools.New(nil, tools.Config{CustomToolsFile: write(t, "lookup_runbook"), EnabledTools: []string{"lookup_runbook"}})
+		defer ts.Close()
+		if err := ts.Register(server); err != nil {
+			t.Fatal(err)
+		}
+
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		serverSession, err := server.Server.Connect(context.Background(), serverTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer serverSession.Close()
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+		clientSession, err := client.Connect(context.Background(), clientTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer clientSession.Close()
+		list, err := clientSession.ListTools(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Tools) != 1 || list.Tools[0].Name != "lookup_runbook" {
+			t.Fatalf("tools = %v, want only lookup_runbook", list.Tools)
+		}
+	})
+
+	t.Run("rejects built-in names", func(t *testing.T) {
+		server, err := last9mcp.NewServerWithOptions("embedder", "test", last9mcp.WithSkipProviderInit())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer server.Shutdown(context.Background())
+		ts := tools.New(nil, tools.Config{CustomToolsFile: write(t, "get_alerts")})
+		defer ts.Close()
+		if err := ts.Register(server); err == nil || !strings.Contains(err.Error(), "conflicts with a built-in tool") {
+			t.Fatalf("Register error = %v, want conflict", err)
+		}
+	})
+}
+
+func TestDefineMacro(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"owner":"team-` + r.URL.Query().Get("service") + `"}`))
+	}))
+	defer upstream.Close()
+	toolsFile := filepath.Join(t.TempDir(), "tools.json")
+	spec := `{"tools":[{"name":"lookup_owner","description":"Service owner","url":"` + upstream.URL + `/owner?service={{service}}","parameters":[{"name":"service","required":true}]}]}`
+	if err := os.WriteFile(toolsFile, []byte(spec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := last9mcp.NewServerWithOptions("embedder", "test", last9mcp.WithSkipProviderInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+	ts := tools.New(nil, tools.Config{CustomToolsFile: toolsFile})
+	defer ts.Close()
+	if err := ts.Register(server); err != nil {
+		t.Fatal(err)
+	}
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Server.Connect(context.Background(), serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(*mcp.TextContent).Text
+	}
+
+	macro := map[string]any{
+		"name":        "owner_twice",
+		"description": "Look up an owner, then look up the owner's name as a service",
+		"parameters":  []any{map[string]any{"name": "service", "required": true}},
+		"steps": []any{
+			map[string]any{"id": "first", "tool": "lookup_owner", "arguments": map[string]any{"service": "{{service}}"}},
+			map[string]any{"id": "second", "tool": "lookup_owner", "arguments": map[string]any{"service": "{{steps.first.owner}}"}},
+		},
+	}
+	if result := call("define_macro", macro); result.IsError {
+		t.Fatalf("define_macro failed: %s", text(result))
+	}
+
+	// Runtime macros survive re-registration (e.g. the periodic refresh).
+	if err := ts.Register(server); err != nil {
+		t.Fatal(err)
+	}
+
+	result := call("owner_twice", map[string]any{"service": "cart"})
+	if result.IsError {
+		t.Fatalf("owner_twice failed: %s", text(result))
+	}
+	if got := text(result); !strings.Contains(got, `"owner":"team-team-cart"`) {
+		t.Fatalf("owner_twice = %s, want the second step to use the first step's output", got)
+	}
+
+	macro["name"] = "get_alerts"
+	if result := call("define_macro", macro); !result.IsError {
+		t.Fatal("define_macro over a built-in tool succeeded")
+	}
+	macro["name"] = "calls_macro"
+	macro["steps"] = []any{map[string]any{"id": "inner", "tool": "owner_twice"}}
+	if result := call("define_macro", macro); !result.IsError || !strings.Contains(text(result), "cannot call other macros") {
+		t.Fatalf("define_macro calling a macro: %v", text(result))
+	}
+}
+
+func TestPreflight_Unauthenticated(t *testing.T) {
+	server, err := last9mcp.NewServerWithOptions("embedder", "test", last9mcp.WithSkipProviderInit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	ts := tools.New(nil, tools.Config{EnabledTools: []string{"get_alerts"}})
+	defer ts.Close()
+	r := ts.Preflight(context.Background(), server)
+	if r.Ready {
+		t.Error("preflight without a token should not be ready")
+	}
+	var names []string
+	for _, c := range r.Checks {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "token,datasource,catalog,tools" {
+		t.Errorf("checks = %v", names)
+	}
+	if c, ok := r.Check("tools"); !ok || !c.OK {
+		t.Errorf("tools check = %+v, want registered without a token", c)
+	}
+	if c, _ := r.Check("catalog"); c.OK {
+		t.Errorf("catalog check = %+v, want skipped", c)
+	}
+}
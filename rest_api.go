@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxRESTBodyBytes bounds the JSON arguments of a REST tool call.
+const maxRESTBodyBytes = 1 << 20
+
+// newRESTHandler serves a REST facade over the MCP tools for scripts and
+// dashboards that cannot speak MCP:
+//
+//	GET  /api/tools         lists the tools (name, description, inputSchema)
+//	POST /api/tools/{name}  calls a tool; the JSON body is its arguments
+//
+// Calls go through the MCP server over in-memory transports, so they get the
+// same tool filtering, argument validation, views, exports and result
+// chunking as MCP clients, and the response body is the tools/call result
+// ({"content": [...], "isError": ...}). Like the streamable HTTP handler, a
+// fresh session is used per request.
+func newRESTHandler(server *mcp.Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tools", func(w http.ResponseWriter, r *http.Request) {
+		session, closeSession, err := connectInMemory(r.Context(), server)
+		if err != nil {
+			writeRESTError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer closeSession()
+		tools := []*mcp.Tool{}
+		for tool, err := range session.Tools(r.Context(), nil) {
+			if err != nil {
+				writeRESTError(w, http.StatusInternalServerError, fmt.Errorf("failed to list tools: %w", err))
+				return
+			}
+			tools = append(tools, tool)
+		}
+		writeRESTJSON(w, http.StatusOK, map[string]any{"tools": tools})
+	})
+	mux.HandleFunc("POST /api/tools/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		args, err := decodeRESTArguments(r)
+		if err != nil {
+			writeRESTError(w, http.StatusBadRequest, err)
+			return
+		}
+		session, closeSession, err := connectInMemory(r.Context(), server)
+		if err != nil {
+			writeRESTError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer closeSession()
+
+		known := false
+		for tool, err := range session.Tools(r.Context(), nil) {
+			if err != nil {
+				writeRESTError(w, http.StatusInternalServerError, fmt.Errorf("failed to list tools: %w", err))
+				return
+			}
+			if tool.Name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			writeRESTError(w, http.StatusNotFound, fmt.Errorf("unknown tool %q; GET /api/tools lists the available tools", name))
+			return
+		}
+
+		result, err := session.CallTool(r.Context(), &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			// JSON-RPC errors; tool failures, including arguments that
+			// fail the input schema, come back as results with isError.
+			writeRESTError(w, http.StatusBadRequest, err)
+			return
+		}
+		status := http.StatusOK
+		if result.IsError {
+			status = http.StatusUnprocessableEntity
+		}
+		writeRESTJSON(w, status, result)
+	})
+	return mux
+}
+
+// decodeRESTArguments reads the request body as a JSON object. An empty body
+// means no arguments.
+func decodeRESTArguments(r *http.Request) (map[string]any, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxRESTBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("request body exceeds %d bytes", maxRESTBodyBytes)
+		}
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	args := map[string]any{}
+	if len(body) == 0 {
+		return args, nil
+	}
+	if err := json.Unmarshal(body, &args); err != nil {
+		return nil, fmt.Errorf("request body must be a JSON object of tool arguments: %w", err)
+	}
+	return args, nil
+}
+
+// connectInMemory opens a client session to server over in-memory
+// transports. The returned func closes both ends.
+func connectInMemory(ctx context.Context, server *mcp.Server) (*mcp.ClientSession, func(), error) {
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect server: %w", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "last9-rest", Version: Version}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		serverSession.Close()
+		return nil, nil, fmt.Errorf("failed to connect client: %w", err)
+	}
+	return session, func() {
+		session.Close()
+		serverSession.Close()
+	}, nil
+}
+
+func writeRESTJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeRESTError(w http.ResponseWriter, status int, err error) {
+	writeRESTJSON(w, status, map[string]string{"error": err.Error()})
+}
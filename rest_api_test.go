@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type echoArgs struct {
+	Service string `json:"service" jsonschema:"Service name"`
+	Fail    bool   `json:"fail,omitempty"`
+}
+
+func newRESTTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	mcp.AddTool(srv, &mcp.Tool{Name: "echo", Description: "Echo the service"}, func(ctx context.Context, req *mcp.CallToolRequest, args echoArgs) (*mcp.CallToolResult, any, error) {
+		if args.Fail {
+			return nil, nil, errors.New("upstream unavailable")
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: `{"service":"` + args.Service + `"}`}}}, nil, nil
+	})
+	ts := httptest.NewServer(newRESTHandler(srv))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRESTHandler(t *testing.T) {
+	ts := newRESTTestServer(t)
+
+	post := func(path, body string) (int, map[string]any) {
+		t.Helper()
+		resp, err := http.Post(ts.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("%s: undecodable response: %v", path, err)
+		}
+		return resp.StatusCode, out
+	}
+
+	status, out := post("/api/tools/echo", `{"service":"checkout"}`)
+	if status != http.StatusOK {
+		t.Fatalf("call status = %d, body %v", status, out)
+	}
+	content := out["content"].([]any)[0].(map[string]any)
+	if content["text"] != `{"service":"checkout"}` {
+		t.Errorf("call content = %v", content)
+	}
+
+	if status, out := post("/api/tools/echo", `{"fail":true}`); status != http.StatusUnprocessableEntity || out["isError"] != true {
+		t.Errorf("tool error: status %d, body %v", status, out)
+	}
+	if status, out := post("/api/tools/missing", `{}`); status != http.StatusNotFound || !strings.Contains(out["error"].(string), "unknown tool") {
+		t.Errorf("unknown tool: status %d, body %v", status, out)
+	}
+	if status, _ := post("/api/tools/echo", `[1,2]`); status != http.StatusBadRequest {
+		t.Errorf("non-object body: status %d, want 400", status)
+	}
+	// The SDK reports arguments that fail the input schema as a tool error.
+	if status, out := post("/api/tools/echo", `{"service":42}`); status != http.StatusUnprocessableEntity || out["isError"] != true {
+		t.Errorf("schema mismatch: status %d, body %v", status, out)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/tools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var listed struct {
+		Tools []mcp.Tool `json:"tools"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Tools) != 1 || listed.Tools[0].Name != "echo" || listed.Tools[0].InputSchema == nil {
+		t.Errorf("listed tools = %+v", listed.Tools)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/tools/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET on a tool: status %d, want 405", resp.StatusCode)
+	}
+}
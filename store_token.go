@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/last9/last9-mcp-server/internal/auth"
+)
+
+// storeToken reads a refresh token from in (the first non-empty line) and
+// saves it in the OS keychain. Reading from stdin rather than an argument
+// keeps the token out of shell history and process listings:
+//
+//	pbpaste | last9-mcp store-token
+func storeToken(in io.Reader, out io.Writer) error {
+	fmt.Fprintln(out, "Paste your Last9 refresh token and press Enter:")
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 4096), 64*1024)
+	var token string
+	for scanner.Scan() {
+		if token = strings.TrimSpace(scanner.Text()); token != "" {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read refresh token: %w", err)
+	}
+	if token == "" {
+		return fmt.Errorf("no refresh token provided on stdin")
+	}
+
+	if err := auth.StoreRefreshTokenInKeychain(context.Background(), token); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Refresh token stored in the OS keychain (service %q). Start the server with LAST9_USE_KEYCHAIN=true.\n", auth.KeychainService)
+	return nil
+}
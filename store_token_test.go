@@ -0,0 +1,18 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStoreTokenRequiresInput(t *testing.T) {
+	var out bytes.Buffer
+	err := storeToken(strings.NewReader("\n  \n"), &out)
+	if err == nil || !strings.Contains(err.Error(), "no refresh token") {
+		t.Fatalf("storeToken() error = %v, want missing-token error", err)
+	}
+	if !strings.Contains(out.String(), "Paste your Last9 refresh token") {
+		t.Errorf("expected a prompt, got %q", out.String())
+	}
+}
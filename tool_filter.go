@@ -0,0 +1,20 @@
+package main
+
+import "strings"
+
+// toolListFlag is a flag.Value collecting tool names from comma-separated
+// values; repeating the flag appends.
+type toolListFlag []string
+
+func (l *toolListFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *toolListFlag) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
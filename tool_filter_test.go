@@ -0,0 +1,18 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestToolListFlag(t *testing.T) {
+	var tools toolListFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&tools, "disabled_tools", "")
+	if err := fs.Parse([]string{"--disabled_tools", "a, b,", "--disabled_tools", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tools.String(); got != "a,b,c" {
+		t.Errorf("toolListFlag = %q, want a,b,c", got)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/last9/last9-mcp-server/internal/logging"
+	"github.com/last9/last9-mcp-server/internal/models"
+	"github.com/last9/last9-mcp-server/internal/stdio"
+
+	last9mcp "github.com/last9/mcp-go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// socketFileMode restricts the socket to the user running the server; the
+// socket grants the same access as the refresh token it is configured with.
+const socketFileMode = 0o600
+
+// UnixServer serves MCP over a Unix domain socket. Each connection is its own
+// session speaking newline-delimited JSON-RPC, exactly as over STDIO.
+type UnixServer struct {
+	server *last9mcp.Last9MCPServer
+	config models.Config
+}
+
+// NewUnixServer creates a new Unix socket MCP server
+func NewUnixServer(server *last9mcp.Last9MCPServer, config models.Config) *UnixServer {
+	return &UnixServer{server: server, config: config}
+}
+
+// Start listens on the configured socket path until SIGINT or SIGTERM, then
+// closes open sessions and removes the socket file.
+func (u *UnixServer) Start() error {
+	path := u.config.SocketPath
+	l, err := listenUnix(path)
+	if err != nil {
+		return err
+	}
+	logger := logging.Logger("unix")
+	logger.Info("MCP server listening", "address", "unix://"+path)
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- serveUnix(ctx, l, u.server.Server, u.config.MaxRequestBytes, u.config.MaxMessageBytes)
+	}()
+
+	select {
+	case sig := <-signalChan:
+		logger.Info("received signal, initiating graceful shutdown", "signal", sig.String())
+	case err := <-serverErr:
+		if err != nil {
+			logger.Error("server error", "error", err)
+			return err
+		}
+	}
+
+	// Closing the listener makes serveUnix close every open session and
+	// return; the socket file is removed by the listener.
+	_ = l.Close()
+	<-serverErr
+	logger.Info("Unix socket server shutdown complete")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := u.server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("MCP server shutdown error", "error", err)
+		return err
+	}
+	logger.Info("MCP server shutdown complete")
+	return nil
+}
+
+// listenUnix binds path and restricts it to the current user. A stale socket
+// left by a crashed run is replaced; a live one, or any other file, is an
+// error rather than something to delete.
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("socket_path is required with --transport unix")
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("socket path %s exists and is not a socket", path)
+		}
+		if c, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("socket path %s is in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, socketFileMode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// serveUnix accepts connections until l is closed, running one MCP session
+// per connection. Sessions still open when l closes are closed before it
+// returns.
+func serveUnix(ctx context.Context, l net.Listener, server *mcp.Server, maxRequestBytes, maxMessageBytes int) error {
+	var (
+		mu    sync.Mutex
+		conns = map[net.Conn]struct{}{}
+		wg    sync.WaitGroup
+	)
+	defer func() {
+		mu.Lock()
+		for c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+			ss, err := server.Connect(ctx, stdio.NewTransport(conn, conn, maxRequestBytes, maxMessageBytes), nil)
+			if err != nil {
+				logging.Logger("unix").Warn("unix socket MCP session failed to start", "error", err)
+				return
+			}
+			_ = ss.Wait()
+		}()
+	}
+}
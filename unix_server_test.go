@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("restricts permissions", func(t *testing.T) {
+		path := filepath.Join(dir, "perm.sock")
+		l, err := listenUnix(path)
+		if err != nil {
+			t.Fatalf("listenUnix: %v", err)
+		}
+		defer l.Close()
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode().Perm(); got != socketFileMode {
+			t.Fatalf("mode = %o, want %o", got, socketFileMode)
+		}
+	})
+
+	t.Run("refuses a live socket", func(t *testing.T) {
+		path := filepath.Join(dir, "live.sock")
+		l, err := listenUnix(path)
+		if err != nil {
+			t.Fatalf("listenUnix: %v", err)
+		}
+		defer l.Close()
+		if _, err := listenUnix(path); err == nil || !strings.Contains(err.Error(), "in use") {
+			t.Fatalf("second listen error = %v, want in use", err)
+		}
+	})
+
+	t.Run("replaces a stale socket", func(t *testing.T) {
+		path := filepath.Join(dir, "stale.sock")
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+		l, err = listenUnix(path)
+		if err != nil {
+			t.Fatalf("listenUnix over stale socket: %v", err)
+		}
+		l.Close()
+	})
+
+	t.Run("refuses a regular file", func(t *testing.T) {
+		path := filepath.Join(dir, "file")
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := listenUnix(path); err == nil {
+			t.Fatal("listenUnix over a regular file succeeded")
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("regular file was removed: %v", err)
+		}
+	})
+
+	t.Run("requires a path", func(t *testing.T) {
+		if _, err := listenUnix(""); err == nil {
+			t.Fatal("listenUnix(\"\") succeeded")
+		}
+	})
+}
+
+func TestServeUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	l, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("listenUnix: %v", err)
+	}
+	srv := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	done := make(chan error, 1)
+	go func() { done <- serveUnix(context.Background(), l, srv, 0, 0) }()
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"c","version":"0"}}}` + "\n"
+	// Two clients get independent sessions.
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Write([]byte("garbage\n" + initialize)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		r := bufio.NewReader(conn)
+		var ids []any
+		for len(ids) < 2 {
+			line, err := r.ReadBytes('\n')
+			if err != nil {
+				t.Fatalf("client %d read: %v", i, err)
+			}
+			var msg map[string]any
+			if err := json.Unmarshal(line, &msg); err != nil {
+				t.Fatalf("client %d unmarshal %s: %v", i, line, err)
+			}
+			ids = append(ids, msg["id"])
+		}
+		if ids[0] != nil || ids[1] != float64(1) {
+			t.Fatalf("client %d ids = %v, want [<nil> 1] (parse error, then initialize)", i, ids)
+		}
+	}
+
+	l.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("serveUnix: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveUnix did not return after the listener closed")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file still exists after close: %v", err)
+	}
+}